@@ -0,0 +1,246 @@
+package main
+
+import (
+	"currency-exchange/pkg/client"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newAdminCommand(opts *options) *cobra.Command {
+	admin := &cobra.Command{
+		Use:   "admin",
+		Short: "Run admin operations, authenticated with --admin-key or an admin API key",
+	}
+	keys := &cobra.Command{Use: "keys", Short: "Manage API keys"}
+	keys.AddCommand(newKeysListCommand(opts), newKeysCreateCommand(opts), newKeysRotateCommand(opts), newKeysRevokeCommand(opts))
+	scheduler := &cobra.Command{Use: "scheduler", Short: "Inspect, pause and resume the background refreshes"}
+	scheduler.AddCommand(newSchedulerStatusCommand(opts), newSchedulerPauseCommand(opts), newSchedulerResumeCommand(opts))
+	admin.AddCommand(keys, scheduler, newProvidersCommand(opts), newImportCommand(opts), newRestoreCommand(opts))
+	return admin
+}
+
+func printKeys(w io.Writer, keys ...client.APIKey) {
+	fmt.Fprintln(w, "ID\tNAME\tROLE\tPREFIX\tCREATED\tEXPIRES\tREVOKED")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Role, key.Prefix, formatTime(&key.CreatedAt), formatTime(key.ExpiresAt), formatTime(key.RevokedAt))
+	}
+	for _, key := range keys {
+		if key.Secret != "" {
+			fmt.Fprintf(w, "\nSecret of %s, shown only once: %s\n", key.ID, key.Secret)
+		}
+		if key.SigningSecret != "" {
+			fmt.Fprintf(w, "Signing secret of %s, shown only once: %s\n", key.ID, key.SigningSecret)
+		}
+	}
+}
+
+func printKey(w io.Writer, key *client.APIKey) {
+	printKeys(w, *key)
+}
+
+func newKeysListCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List every API key, revoked and expired ones included",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			keys, err := c.Keys(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, keys, func(w io.Writer, keys []client.APIKey) {
+				printKeys(w, keys...)
+			})
+		},
+	}
+}
+
+func newKeysCreateCommand(opts *options) *cobra.Command {
+	var key client.NewKey
+	var expiresIn time.Duration
+	cmd := &cobra.Command{
+		Use:     "create NAME",
+		Short:   "Issue a new API key",
+		Example: "  currencyctl admin keys create partner-a --role reader --pair 'EUR/*' --expires-in 720h",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key.Name = args[0]
+			if expiresIn > 0 {
+				expiresAt := time.Now().Add(expiresIn).UTC()
+				key.ExpiresAt = &expiresAt
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			created, err := c.CreateKey(cmd.Context(), key)
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, created, printKey)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&key.ID, "id", "", "ID of the key; a random one by default")
+	flags.StringVar(&key.Role, "role", "", "role of the key: reader or admin")
+	flags.StringSliceVar(&key.Scopes, "scope", nil, "scopes granted besides the role's, repeatable")
+	flags.StringSliceVar(&key.Endpoints, "endpoint", nil, "restrict the key to these endpoints, repeatable")
+	flags.StringSliceVar(&key.Pairs, "pair", nil, "restrict the key to these pairs, e.g. USD/INR or EUR/*, repeatable")
+	flags.DurationVar(&expiresIn, "expires-in", 0, "expire the key after this long; never by default")
+	return cmd
+}
+
+func newKeysRotateCommand(opts *options) *cobra.Command {
+	var grace time.Duration
+	cmd := &cobra.Command{
+		Use:   "rotate ID",
+		Short: "Issue a new secret for an API key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			rotated, err := c.RotateKey(cmd.Context(), args[0], grace)
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, rotated, printKey)
+		},
+	}
+	cmd.Flags().DurationVar(&grace, "grace-period", 0, "keep the old secret working this long, at most 168h")
+	return cmd
+}
+
+func newKeysRevokeCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke ID",
+		Short: "Revoke an API key for good",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			revoked, err := c.RevokeKey(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, revoked, printKey)
+		},
+	}
+}
+
+func printPauseState(w io.Writer, state *client.PauseState) {
+	fmt.Fprintln(w, "PAUSED\tSINCE\tREASON")
+	fmt.Fprintf(w, "%t\t%s\t%s\n", state.Paused, formatTime(state.Since), state.Reason)
+}
+
+func newSchedulerStatusCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the refresh loops of the replica that answers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			status, err := c.SchedulerStatus(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, status, func(w io.Writer, status *client.SchedulerStatus) {
+				fmt.Fprintln(w, "LOOP\tINTERVAL\tLAST RUN\tNEXT RUN\tHOLDS LOCK\tBASES WITH FAILURES")
+				for _, loop := range status.Loops {
+					var failing []string // since the replica started
+					for base, bs := range loop.Bases {
+						if bs.Failures > 0 {
+							failing = append(failing, base)
+						}
+					}
+					sort.Strings(failing)
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", loop.Name, loop.Interval, formatTime(loop.LastRunAt), formatTime(loop.NextRunAt), loop.HoldsLock, strings.Join(failing, ","))
+				}
+				if status.Pause != nil {
+					fmt.Fprintln(w)
+					printPauseState(w, status.Pause)
+				}
+			})
+		},
+	}
+}
+
+func newSchedulerPauseCommand(opts *options) *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause background refreshes on every replica",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			state, err := c.PauseScheduler(cmd.Context(), reason)
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, state, printPauseState)
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "why refreshes are paused, recorded with the pause")
+	return cmd
+}
+
+func newSchedulerResumeCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume background refreshes on every replica",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			state, err := c.ResumeScheduler(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, state, printPauseState)
+		},
+	}
+}
+
+func newProvidersCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "providers",
+		Short: "Show the call statistics of the rate providers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			providers, err := c.Providers(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, providers, func(w io.Writer, providers []client.ProviderStatus) {
+				fmt.Fprintln(w, "PROVIDER\tCALLS\tERROR RATE\tP95 MS\tLAST SUCCESS\tDEPRIORITIZED\tLAST ERROR")
+				for _, p := range providers {
+					fmt.Fprintf(w, "%s\t%d\t%.2f\t%.0f\t%s\t%t\t%s\n", p.Name, p.Calls, p.ErrorRate, p.P95LatencyMs, formatTime(p.LastSuccess), p.Deprioritized, p.LastError)
+				}
+			})
+		},
+	}
+}
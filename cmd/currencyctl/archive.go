@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"currency-exchange/pkg/client"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// importBatchRows is how many CSV rows each import request carries, to stay well under the
+// server's default 4 MiB body limit.
+const importBatchRows = 20000
+
+func newImportCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import FILE...",
+		Short: "Load historical rates from CSV files of date,base,target,rate rows into the rate archive",
+		Long: "Load historical rates from CSV files of date,base,target,rate rows into the rate archive.\n\n" +
+			"Files are sent in batches of " + fmt.Sprint(importBatchRows) + " rows. A batch is imported only if every\n" +
+			"row in it is valid; the batches before a bad one stay imported, and importing them again is harmless.",
+		Example: "  currencyctl admin import rates-2001.csv rates-2002.csv",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			var results []client.ImportResult
+			for _, path := range args {
+				err := importFile(path, func(batch []byte) error {
+					result, err := c.ImportCSV(cmd.Context(), batch)
+					if err != nil {
+						return err
+					}
+					results = append(results, *result)
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			return render(cmd, opts, results, func(w io.Writer, results []client.ImportResult) {
+				fmt.Fprintln(w, "ROWS\tDAYS\tFROM\tTO")
+				for _, result := range results {
+					fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", result.Rows, result.Days, result.From, result.To)
+				}
+			})
+		},
+	}
+}
+
+// importFile passes the rows of the CSV file at path to send in batches of importBatchRows,
+// repeating its header row, if it has one, at the top of every batch.
+func importFile(path string, send func(batch []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	var header string
+	var batch bytes.Buffer
+	rows := 0
+	flush := func() error {
+		if rows == 0 {
+			return nil
+		}
+		err := send(batch.Bytes())
+		batch.Reset()
+		batch.WriteString(header)
+		rows = 0
+		return err
+	}
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if first && strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "date") {
+			header = line + "\n"
+			batch.WriteString(header)
+			continue
+		}
+		batch.WriteString(line + "\n")
+		if rows++; rows == importBatchRows {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// maxRestoreChunkDays is the longest span the service restores in one request.
+const maxRestoreChunkDays = 366
+
+func newRestoreCommand(opts *options) *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Load archived rates back from the service's off-site snapshots",
+		Long: "Load archived rates back from the service's off-site snapshots, e.g. into a new archive database.\n\n" +
+			"Days without a snapshot are skipped. Long ranges are restored a year at a time. Unless --timeout\n" +
+			"is given, restores are not timed out, as long ones take a while.",
+		Example: "  currencyctl admin restore --from 2020-01-01 --to 2024-12-31",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := parseDate("--from", from)
+			if err != nil {
+				return err
+			}
+			end, err := parseDate("--to", to)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("timeout") {
+				opts.timeout = 0
+			}
+			if start.After(end) {
+				return fmt.Errorf("--from %s is after --to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			result := &client.RestoreResult{From: start.Format("2006-01-02"), To: end.Format("2006-01-02")}
+			for next := start; !next.After(end); next = next.AddDate(0, 0, maxRestoreChunkDays) {
+				chunkEnd := next.AddDate(0, 0, maxRestoreChunkDays-1)
+				if chunkEnd.After(end) {
+					chunkEnd = end
+				}
+				chunk, err := c.Restore(cmd.Context(), next, chunkEnd)
+				if err != nil {
+					return fmt.Errorf("restore from %s to %s failed: %w", next.Format("2006-01-02"), chunkEnd.Format("2006-01-02"), err)
+				}
+				result.Days += chunk.Days
+				result.Rows += chunk.Rows
+			}
+			return render(cmd, opts, result, func(w io.Writer, result *client.RestoreResult) {
+				fmt.Fprintln(w, "DAYS\tROWS\tFROM\tTO")
+				fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", result.Days, result.Rows, result.From, result.To)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "first day to restore, as YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "last day to restore, as YYYY-MM-DD")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newExportCommand(opts *options) *cobra.Command {
+	var req client.ExportRequest
+	var pair, from, to, file string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Dump archived rates as CSV or Parquet, authenticated like admin commands",
+		Long: "Dump archived rates as CSV or Parquet, authenticated like admin commands.\n\n" +
+			"CSV exports can be loaded again with `currencyctl admin import`. Unless --timeout is given,\n" +
+			"exports are not timed out, as large ones take a while.",
+		Example: "  currencyctl export --pair USD-INR --from 2024-01-01 --to 2024-12-31 > usd-inr-2024.csv\n" +
+			"  currencyctl export --format parquet --file rates.parquet",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pair != "" {
+				base, target, ok := strings.Cut(strings.ToUpper(pair), "-")
+				if !ok {
+					return fmt.Errorf("--pair must be written as BASE-TARGET, e.g. USD-INR, got %q", pair)
+				}
+				req.Base, req.Target = base, target
+			}
+			for _, flag := range []struct {
+				name, value string
+				dest        **time.Time
+			}{{"--from", from, &req.From}, {"--to", to, &req.To}} {
+				if flag.value == "" {
+					continue
+				}
+				on, err := parseDate(flag.name, flag.value)
+				if err != nil {
+					return err
+				}
+				*flag.dest = &on
+			}
+			if !cmd.Flags().Changed("timeout") {
+				opts.timeout = 0
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := c.Export(cmd.Context(), req, out); err != nil {
+				if file != "" {
+					os.Remove(file)
+				}
+				return fmt.Errorf("export failed: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&req.Format, "format", "csv", "file format: csv or parquet")
+	cmd.Flags().StringVar(&pair, "pair", "", "only export this pair, as BASE-TARGET")
+	cmd.Flags().StringVar(&from, "from", "", "first day to export, as YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "last day to export, as YYYY-MM-DD")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "write to this file instead of standard output")
+	return cmd
+}
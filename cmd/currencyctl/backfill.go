@@ -0,0 +1,182 @@
+package main
+
+import (
+	"currency-exchange/pkg/client"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxBackfillChunkDays is the longest span the service backfills in one request.
+const maxBackfillChunkDays = 366
+
+// backfillCheckpoint records how far a backfill got, so an interrupted one picks up where it
+// stopped instead of fetching years of rates again.
+type backfillCheckpoint struct {
+	From string            `json:"from"` // YYYY-MM-DD the backfill started at
+	Done map[string]string `json:"done"` // last day backfilled, by base
+}
+
+// loadCheckpoint reads the checkpoint at path, or starts a new one when there is none.
+func loadCheckpoint(path string, from time.Time) (*backfillCheckpoint, error) {
+	checkpoint := &backfillCheckpoint{From: from.Format("2006-01-02"), Done: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved backfillCheckpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("checkpoint %s is not valid: %w", path, err)
+	}
+	if saved.From != checkpoint.From {
+		return nil, fmt.Errorf("checkpoint %s is of a backfill from %s; remove it or pass another --checkpoint to start one from %s", path, saved.From, checkpoint.From)
+	}
+	if saved.Done != nil {
+		checkpoint.Done = saved.Done
+	}
+	return checkpoint, nil
+}
+
+// save writes the checkpoint to path through a temporary file, so an interrupted write
+// leaves the previous checkpoint behind rather than a truncated one.
+func (c *backfillCheckpoint) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func newBackfillCommand(opts *options) *cobra.Command {
+	var from, to, checkpointPath string
+	var bases []string
+	var chunkDays int
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Fill the rate archive with years of history from the provider, authenticated like admin commands",
+		Long: "Fill the rate archive with years of history from the provider, authenticated like admin commands.\n\n" +
+			"The days from --from to --to are fetched for every supported base, or those given with --base,\n" +
+			"in chunks of --chunk-days, waiting --interval between requests to spare the provider's quota.\n" +
+			"Progress is recorded in the --checkpoint file after every chunk; running the same backfill again\n" +
+			"resumes after the last chunk done, and later on only fetches the days since.",
+		Example: "  currencyctl backfill --from 2015-01-01\n" +
+			"  currencyctl backfill --from 2015-01-01 --to 2019-12-31 --base USD --base EUR --interval 5s",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := parseDate("--from", from)
+			if err != nil {
+				return err
+			}
+			// The provider publishes a day's rates once it is over.
+			end := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+			if to != "" {
+				if end, err = parseDate("--to", to); err != nil {
+					return err
+				}
+			}
+			if start.After(end) {
+				return fmt.Errorf("--from %s is after --to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+			}
+			if chunkDays < 1 || chunkDays > maxBackfillChunkDays {
+				return fmt.Errorf("--chunk-days must be between 1 and %d, got %d", maxBackfillChunkDays, chunkDays)
+			}
+			if !cmd.Flags().Changed("timeout") {
+				opts.timeout = 2 * time.Minute
+			}
+			checkpoint, err := loadCheckpoint(checkpointPath, start)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			if len(bases) == 0 {
+				currencies, err := c.Currencies(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to list the supported currencies: %w", err)
+				}
+				for _, currency := range currencies {
+					bases = append(bases, currency.Code)
+				}
+				sort.Strings(bases)
+			}
+
+			var results []client.BackfillResult
+			var last time.Time
+			for _, base := range bases {
+				base = strings.ToUpper(base)
+				result := client.BackfillResult{Base: base}
+				next := start
+				if done, ok := checkpoint.Done[base]; ok {
+					if next, err = time.Parse("2006-01-02", done); err != nil {
+						return fmt.Errorf("checkpoint %s has an invalid day %q for %s", checkpointPath, done, base)
+					}
+					next = next.AddDate(0, 0, 1)
+				}
+				for ; !next.After(end); next = next.AddDate(0, 0, chunkDays) {
+					chunkEnd := next.AddDate(0, 0, chunkDays-1)
+					if chunkEnd.After(end) {
+						chunkEnd = end
+					}
+					if wait := interval - time.Since(last); !last.IsZero() && wait > 0 {
+						select {
+						case <-time.After(wait):
+						case <-cmd.Context().Done():
+							return cmd.Context().Err()
+						}
+					}
+					last = time.Now()
+					chunk, err := c.Backfill(cmd.Context(), base, next, chunkEnd)
+					if err != nil {
+						return fmt.Errorf("backfill of %s from %s to %s failed, run it again to resume: %w", base, next.Format("2006-01-02"), chunkEnd.Format("2006-01-02"), err)
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s %s..%s: %d days\n", base, chunk.From, chunk.To, chunk.Days)
+					if result.From == "" {
+						result.From = chunk.From
+					}
+					result.To = chunk.To
+					result.Days += chunk.Days
+					checkpoint.Done[base] = chunk.To
+					if err := checkpoint.save(checkpointPath); err != nil {
+						return fmt.Errorf("failed to save checkpoint: %w", err)
+					}
+				}
+				results = append(results, result)
+			}
+			return render(cmd, opts, results, func(w io.Writer, results []client.BackfillResult) {
+				fmt.Fprintln(w, "BASE\tFROM\tTO\tDAYS")
+				for _, result := range results {
+					if result.From == "" {
+						fmt.Fprintf(w, "%s\t-\t-\t0\n", result.Base)
+						continue
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", result.Base, result.From, result.To, result.Days)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "first day to backfill, as YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "last day to backfill, as YYYY-MM-DD (default yesterday)")
+	cmd.Flags().StringSliceVar(&bases, "base", nil, "only backfill these bases (default every supported currency)")
+	cmd.Flags().IntVar(&chunkDays, "chunk-days", 92, fmt.Sprintf("days fetched per request, at most %d", maxBackfillChunkDays))
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "time to leave between the start of two requests")
+	cmd.Flags().StringVar(&checkpointPath, "checkpoint", "currencyctl-backfill.json", "file recording the progress of the backfill")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
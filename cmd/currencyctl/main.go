@@ -0,0 +1,13 @@
+// Command currencyctl queries a running exchange rate service and runs its admin operations,
+// for scripts and smoke checks.
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	if err := newRootCommand(os.Stdout).Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runCommand(t *testing.T, server *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	root := newRootCommand(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(append([]string{"--server", server.URL}, args...))
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/latest", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		assert.Equal(t, "INR", r.URL.Query().Get("symbol"))
+		assert.Equal(t, "cx_test", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"base":"USD","rates":{"INR":85.2},"timestamp":1744624800}`))
+	}))
+	defer server.Close()
+
+	out, err := runCommand(t, server, "--api-key", "cx_test", "latest", "USD", "INR")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "BASE  TARGET  RATE")
+	assert.Contains(t, out, "USD   INR     85.2")
+
+	out, err = runCommand(t, server, "--api-key", "cx_test", "-o", "json", "latest", "USD", "INR")
+	assert.NoError(t, err)
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, "USD", decoded["base"])
+}
+
+func TestAdminCommandsSendAdminKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /admin/scheduler/pause", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Admin-Key"))
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "provider maintenance", body["reason"])
+		w.Write([]byte(`{"paused":true,"reason":"provider maintenance","since":"2025-04-14T10:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	out, err := runCommand(t, server, "--admin-key", "secret", "admin", "scheduler", "pause", "--reason", "provider maintenance")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "provider maintenance")
+}
+
+func TestErrorResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"UNSUPPORTED_CURRENCY","message":"currency XYZ is not supported"}}`))
+	}))
+	defer server.Close()
+
+	_, err := runCommand(t, server, "convert", "100", "USD", "XYZ")
+	assert.EqualError(t, err, "currency XYZ is not supported (400 UNSUPPORTED_CURRENCY)")
+
+	_, err = runCommand(t, server, "-o", "yaml", "latest", "USD", "INR")
+	assert.ErrorContains(t, err, "--output must be table or json")
+}
+
+func TestImport(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /admin/import", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"rows":2,"days":1,"from":"2001-01-02","to":"2001-01-02"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	csv := "date,base,target,rate\n2001-01-02,USD,INR,46.7\n\n2001-01-02,USD,EUR,1.06\n"
+	assert.NoError(t, os.WriteFile(path, []byte(csv), 0o644))
+
+	out, err := runCommand(t, server, "--admin-key", "secret", "admin", "import", path)
+	assert.NoError(t, err)
+	// Blank lines are dropped.
+	assert.Equal(t, []string{"date,base,target,rate\n2001-01-02,USD,INR,46.7\n2001-01-02,USD,EUR,1.06\n"}, bodies)
+	assert.Contains(t, out, "2001-01-02")
+
+	_, err = runCommand(t, server, "--admin-key", "secret", "admin", "import", filepath.Join(t.TempDir(), "missing.csv"))
+	assert.ErrorContains(t, err, "missing.csv")
+}
+
+func TestRestore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /admin/restore", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Admin-Key"))
+		w.Write([]byte(`{"days":63,"rows":1260,"from":"2025-01-01","to":"2025-03-31"}`))
+	}))
+	defer server.Close()
+
+	out, err := runCommand(t, server, "--admin-key", "secret", "admin", "restore", "--from", "2025-01-01", "--to", "2025-03-31")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "63    1260  2025-01-01  2025-03-31")
+
+	_, err = runCommand(t, server, "--admin-key", "secret", "admin", "restore", "--from", "2025-01-01")
+	assert.ErrorContains(t, err, `required flag(s) "to" not set`)
+}
+
+func TestRestore_Chunks(t *testing.T) {
+	var chunks []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		chunks = append(chunks, body["from"]+".."+body["to"])
+		json.NewEncoder(w).Encode(map[string]any{"days": 300, "rows": 6000, "from": body["from"], "to": body["to"]})
+	}))
+	defer server.Close()
+
+	out, err := runCommand(t, server, "--admin-key", "secret", "admin", "restore", "--from", "2023-01-01", "--to", "2024-12-31")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2023-01-01..2024-01-01", "2024-01-02..2024-12-31"}, chunks)
+	assert.Contains(t, out, "600   12000  2023-01-01  2024-12-31")
+
+	_, err = runCommand(t, server, "--admin-key", "secret", "admin", "restore", "--from", "2025-01-02", "--to", "2025-01-01")
+	assert.ErrorContains(t, err, "--from 2025-01-02 is after --to 2025-01-01")
+	assert.Len(t, chunks, 2)
+}
+
+func TestExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET /admin/export", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Admin-Key"))
+		assert.Equal(t, "format=csv&from=2001-01-01&pair=USD-INR", r.URL.RawQuery)
+		w.Write([]byte("date,base,target,rate\n2001-01-02,USD,INR,46.7\n"))
+	}))
+	defer server.Close()
+
+	out, err := runCommand(t, server, "--admin-key", "secret", "export", "--pair", "usd-inr", "--from", "2001-01-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "date,base,target,rate\n2001-01-02,USD,INR,46.7\n", out)
+
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	_, err = runCommand(t, server, "--admin-key", "secret", "export", "--pair", "usd-inr", "--from", "2001-01-01", "--file", path)
+	assert.NoError(t, err)
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, out, string(written))
+
+	_, err = runCommand(t, server, "export", "--pair", "USDINR")
+	assert.ErrorContains(t, err, "--pair must be written as BASE-TARGET")
+}
+
+func TestBackfill(t *testing.T) {
+	var chunks []string
+	failEUR := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/currencies" {
+			w.Write([]byte(`{"currencies":[{"code":"USD","kind":"FIAT"},{"code":"EUR","kind":"FIAT"}]}`))
+			return
+		}
+		assert.Equal(t, "POST /admin/backfill", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Admin-Key"))
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["base"] == "EUR" && body["from"] == "2015-01-11" && failEUR {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error":{"code":"Bad Gateway","message":"provider down"}}`))
+			return
+		}
+		chunks = append(chunks, body["base"]+" "+body["from"]+".."+body["to"])
+		json.NewEncoder(w).Encode(map[string]any{"base": body["base"], "from": body["from"], "to": body["to"], "days": 7})
+	}))
+	defer server.Close()
+
+	checkpoint := filepath.Join(t.TempDir(), "backfill.json")
+	args := []string{"--admin-key", "secret", "backfill", "--from", "2015-01-01", "--to", "2015-01-15",
+		"--chunk-days", "10", "--interval", "0", "--checkpoint", checkpoint}
+	_, err := runCommand(t, server, args...)
+	assert.ErrorContains(t, err, "run it again to resume")
+	assert.Equal(t, []string{"EUR 2015-01-01..2015-01-10"}, chunks)
+
+	failEUR = false
+	out, err := runCommand(t, server, args...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"EUR 2015-01-01..2015-01-10", "EUR 2015-01-11..2015-01-15", "USD 2015-01-01..2015-01-10", "USD 2015-01-11..2015-01-15"}, chunks)
+	assert.Contains(t, out, "EUR   2015-01-11  2015-01-15  7")
+	assert.Contains(t, out, "USD   2015-01-01  2015-01-15  14")
+
+	// Everything is done, so nothing is fetched again.
+	_, err = runCommand(t, server, args...)
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 4)
+
+	_, err = runCommand(t, server, "--admin-key", "secret", "backfill", "--from", "2016-01-01", "--checkpoint", checkpoint)
+	assert.ErrorContains(t, err, "is of a backfill from 2015-01-01")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// render writes a result as indented JSON with --output json, and otherwise has table lay it out
+// in aligned columns.
+func render[T any](cmd *cobra.Command, opts *options, result T, table func(w io.Writer, v T)) error {
+	out := cmd.OutOrStdout()
+	if opts.output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	table(w, result)
+	return w.Flush()
+}
+
+// formatTime formats optional times in tables, "-" standing in for missing ones.
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// formatString formats optional strings in tables, "-" standing in for empty ones.
+func formatString(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"currency-exchange/pkg/client"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newLatestCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "latest BASE TARGET",
+		Short:   "Show the latest rate of a pair",
+		Example: "  currencyctl latest USD INR",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			rates, err := c.Latest(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, rates, func(w io.Writer, rates *client.LatestRates) {
+				fmt.Fprintln(w, "BASE\tTARGET\tRATE\tAS OF\tSTALE")
+				asOf := rates.Time()
+				for _, target := range sortedTargets(rates.Rates) {
+					fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%t\n", rates.Base, target, rates.Rates[target], formatTime(&asOf), rates.Stale)
+				}
+			})
+		},
+	}
+}
+
+func newConvertCommand(opts *options) *cobra.Command {
+	var date string
+	cmd := &cobra.Command{
+		Use:     "convert AMOUNT FROM TO",
+		Short:   "Convert an amount at the latest rate, or at a past date's",
+		Example: "  currencyctl convert 100 USD INR\n  currencyctl convert 100 USD INR --date 2025-01-15",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return fmt.Errorf("invalid amount %q", args[0])
+			}
+			req := client.ConvertRequest{From: args[1], To: args[2], Amount: amount}
+			if date != "" {
+				on, err := parseDate("--date", date)
+				if err != nil {
+					return err
+				}
+				req.Date = &on
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			conversion, err := c.Convert(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, conversion, func(w io.Writer, conversion *client.Conversion) {
+				fmt.Fprintln(w, "FROM\tTO\tAMOUNT\tCONVERTED\tRATE\tDATE")
+				on := "latest"
+				if conversion.Date != nil {
+					on = conversion.Date.Format("2006-01-02")
+				}
+				fmt.Fprintf(w, "%s\t%s\t%v\t%v\t%v\t%s\n", conversion.From, conversion.To, conversion.Amount, conversion.ConvertedAmount, conversion.Rate, on)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&date, "date", "", "convert at this date's rate, as YYYY-MM-DD")
+	return cmd
+}
+
+func newHistoricalCommand(opts *options) *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:     "historical BASE TARGET",
+		Short:   "Show the daily rates of a pair over a date range",
+		Example: "  currencyctl historical USD INR --from 2025-01-01 --to 2025-01-31",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if from == "" && to == "" {
+				return fmt.Errorf("at least one of --from and --to is required")
+			}
+			if from == "" {
+				from = to
+			} else if to == "" {
+				to = from
+			}
+			start, err := parseDate("--from", from)
+			if err != nil {
+				return err
+			}
+			end, err := parseDate("--to", to)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			rates, err := c.Historical(cmd.Context(), args[0], args[1], start, end)
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, rates, func(w io.Writer, rates *client.HistoricalRates) {
+				fmt.Fprintln(w, "DATE\tBASE\tTARGET\tRATE")
+				for _, rate := range rates.Rates {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", rate.Date.Format("2006-01-02"), rates.Base, rates.Target, rate.Rate)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "first date, as YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "last date, as YYYY-MM-DD")
+	return cmd
+}
+
+func newCurrenciesCommand(opts *options) *cobra.Command {
+	var country string
+	cmd := &cobra.Command{
+		Use:     "currencies",
+		Short:   "List the supported currencies, or those used in a country",
+		Example: "  currencyctl currencies\n  currencyctl currencies --country IN",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			if country != "" {
+				currencies, err := c.CurrenciesByCountry(cmd.Context(), country)
+				if err != nil {
+					return err
+				}
+				return render(cmd, opts, currencies, func(w io.Writer, currencies *client.CountryCurrencies) {
+					fmt.Fprintln(w, "CODE\tNAME\tSYMBOL\tSUPPORTED\tDEFAULT")
+					for _, currency := range currencies.Currencies {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\n", currency.Code, currency.Name, formatString(currency.Symbol), currency.Supported, currency.Code == currencies.Default)
+					}
+				})
+			}
+			currencies, err := c.Currencies(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd, opts, currencies, func(w io.Writer, currencies []client.Currency) {
+				fmt.Fprintln(w, "CODE\tKIND\tNAME\tSYMBOL\tMINOR UNITS")
+				for _, currency := range currencies {
+					minorUnits := strconv.Itoa(currency.MinorUnits)
+					if currency.MinorUnits < 0 {
+						minorUnits = "-"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", currency.Code, strings.ToLower(currency.Kind), currency.Name, formatString(currency.Symbol), minorUnits)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&country, "country", "", "ISO 3166-1 alpha-2 code of a country, e.g. IN, to list the currencies used there")
+	return cmd
+}
+
+func parseDate(flag, value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date written as YYYY-MM-DD, got %q", flag, value)
+	}
+	return date, nil
+}
+
+func sortedTargets(rates map[string]float64) []string {
+	targets := make([]string, 0, len(rates))
+	for target := range rates {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"currency-exchange/pkg/client"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// options are the flags every command shares.
+type options struct {
+	server   string
+	apiKey   string
+	adminKey string
+	output   string
+	timeout  time.Duration
+}
+
+func newRootCommand(out io.Writer) *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:          "currencyctl",
+		Short:        "Query a running exchange rate service",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.output != "table" && opts.output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", opts.output)
+			}
+			return nil
+		},
+	}
+	root.SetOut(out)
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.server, "server", envOr("CURRENCYCTL_SERVER", "http://localhost:8080"), "URL of the service, or $CURRENCYCTL_SERVER")
+	flags.StringVar(&opts.apiKey, "api-key", os.Getenv("CURRENCYCTL_API_KEY"), "API key sent as X-API-Key, or $CURRENCYCTL_API_KEY")
+	flags.StringVar(&opts.adminKey, "admin-key", os.Getenv("CURRENCYCTL_ADMIN_KEY"), "admin key sent as X-Admin-Key by admin commands, or $CURRENCYCTL_ADMIN_KEY")
+	flags.StringVarP(&opts.output, "output", "o", "table", "output format: table or json")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of each request")
+
+	root.AddCommand(
+		newLatestCommand(opts),
+		newConvertCommand(opts),
+		newHistoricalCommand(opts),
+		newCurrenciesCommand(opts),
+		newAdminCommand(opts),
+		newExportCommand(opts),
+		newBackfillCommand(opts),
+	)
+	return root
+}
+
+func (o *options) client() (*client.Client, error) {
+	return client.New(o.server,
+		client.WithAPIKey(o.apiKey),
+		client.WithAdminKey(o.adminKey),
+		client.WithHTTPClient(&http.Client{Timeout: o.timeout}),
+		client.WithUserAgent("currencyctl"),
+	)
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
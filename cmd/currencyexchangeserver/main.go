@@ -2,24 +2,46 @@ package main
 
 import (
 	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/adapter/audit"
+	"currency-exchange/internals/adapter/broker"
 	"currency-exchange/internals/adapter/cache"
 	"currency-exchange/internals/adapter/cache/schedular"
+	"currency-exchange/internals/adapter/debuglog"
+	"currency-exchange/internals/adapter/errorreport"
 	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/adapter/jwtauth"
+	"currency-exchange/internals/adapter/lockout"
+	"currency-exchange/internals/adapter/nonce"
+	"currency-exchange/internals/adapter/notify"
+	"currency-exchange/internals/adapter/quota"
+	"currency-exchange/internals/adapter/ratealert"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/adapter/usage"
+	"currency-exchange/internals/adapter/webhook"
 	"currency-exchange/internals/api"
 	"currency-exchange/internals/config"
-	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/grpcapi"
+	"currency-exchange/internals/logging"
+	"currency-exchange/internals/metrics"
 	"currency-exchange/internals/repository"
 	"currency-exchange/internals/service"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/pflag"
 )
 
 func main() {
@@ -33,37 +55,403 @@ func main() {
 	fmt.Print(string(content) + "\n\n\n")
 	log.Println("Starting Exchange Rate Service...")
 
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	if err := config.BindFlags(flags); err != nil {
+		log.Fatalf("Failed to set up command-line flags: %v", err)
+	}
+	flags.Parse(os.Args[1:])
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	var logOutput io.Writer = os.Stderr
+	if cfg.LogFile != "" {
+		logFile := logging.NewFile(logging.FileOptions{
+			Path:       cfg.LogFile,
+			MaxSizeMB:  cfg.LogFileMaxSizeMB,
+			MaxBackups: cfg.LogFileMaxBackups,
+			MaxAgeDays: cfg.LogFileMaxAgeDays,
+			Compress:   cfg.LogFileCompress,
+		})
+		defer logFile.Close()
+		logOutput = logFile
+	}
+	if err := logging.Setup(logOutput, logging.Options{
+		Level:     cfg.LogLevel,
+		Format:    cfg.LogFormat,
+		Overrides: cfg.LogOverrides,
+		Sampling:  logging.Sampling{Initial: cfg.LogSampleInitial, Thereafter: cfg.LogSampleThereafter},
+	}); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
 
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisAddr,
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
 	})
-	redisCache := cache.NewRedisCache(redisClient, cfg.LatestRateCacheTTL, cfg.HistoricalCacheTTL)
-	frankFurterAPI := helpers.NewFrankFurterAPI(cfg.ExternalAPIURL, cfg.DateFmt)
-	apiClient := exchangerateapi.NewClient(frankFurterAPI)
-	rateRepo := repository.NewCachedRateRepository(apiClient, redisCache)
-	rateService := service.NewRateService(rateRepo, 90)
+	cacheLogger := logging.For("cache")
+	redisSupervisor := cache.NewRedisSupervisor(redisClient, cfg.RedisHealthCheckInterval, cfg.RedisBackoffBase, cfg.RedisBackoffMax, cacheLogger)
+
+	// Background workers run until shutdown cancels rootCtx.
+	rootCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(rootCtx)
+		}()
+	}
+
+	// With SERVER_PREFORK main runs again in every child process. Jobs that must run once per
+	// instance, like the schedulers and webhook deliveries, start only in the parent, which
+	// spawns the children and serves no requests itself.
+	startPrimaryWorker := func(run func(ctx context.Context)) {
+		if !fiber.IsChild() {
+			startWorker(run)
+		}
+	}
+
+	startWorker(redisSupervisor.Start)
+
+	var rateCache cache.Cache
+	if cfg.CacheBackend == "memory" {
+		cacheLogger.Info("Using in-process rate cache; cached rates are lost on restart and not shared between instances")
+		rateCache = cache.NewMemoryCache(cfg.LatestRateCacheTTL, cfg.HistoricalCacheTTL)
+	} else {
+		rateCache = cache.NewRedisCache(redisClient, cfg.LatestRateCacheTTL, cfg.HistoricalCacheTTL, redisSupervisor, cacheLogger)
+	}
+	if len(cfg.SupportedCurrencies) > 0 {
+		codes := make([]domain.Currency, 0, len(cfg.SupportedCurrencies))
+		for _, code := range cfg.SupportedCurrencies {
+			codes = append(codes, domain.Currency(code))
+		}
+		domain.SetSupportedCurrencies(codes...)
+	}
+	if cfg.CryptoEnabled {
+		for code := range domain.CryptoCurrencies {
+			domain.EnableCurrencies(code)
+		}
+	}
+	if cfg.MetalsEnabled {
+		for code := range domain.MetalCurrencies {
+			domain.EnableCurrencies(code)
+		}
+	}
+
+	refreshBases := make([]domain.Currency, 0, len(cfg.RefreshBases))
+	for _, code := range cfg.RefreshBases {
+		base := domain.Currency(code)
+		if !base.IsSupported() {
+			log.Printf("WARNING: REFRESH_BASES lists %s, which is not a supported currency", code)
+		}
+		refreshBases = append(refreshBases, base)
+	}
+
+	apiClient, err := exchangerateapi.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up rate provider: %v", err)
+	}
+	rateArchive := openArchive(cfg)
+	snapshots := openSnapshots(cfg)
+	rateRepo := repository.NewArchivedRateRepository(apiClient, rateCache, rateArchive, logging.For("repository"))
+	rateService := service.NewRateService(rateRepo, 90, logging.For("service"))
 	apiHandler := api.NewHandler(rateService)
+	healthHandler := api.NewHealthHandler(api.HealthSources{
+		Cache:     redisSupervisor,
+		Providers: exchangerateapi.DefaultMonitor,
+		Scheduler: schedular.DefaultTracker,
+	}, redisSupervisor)
+	apiLogger := logging.For("api")
+	errorReporter, err := errorreport.New(cfg.ErrorReportingDSN, cfg.AppEnv)
+	if err != nil {
+		log.Fatalf("Failed to set up error reporting: %v", err)
+	}
+	adminHandler := api.NewAdminHandler(exchangerateapi.DefaultMonitor, schedular.DefaultTracker, schedular.NewPauseSwitch(redisClient), apiLogger)
 
 	app := fiber.New(fiber.Config{
 		AppName:      "Exchange Rate Service",
-		ErrorHandler: api.ErrorHandler,
+		ErrorHandler: api.NewErrorHandler(apiLogger),
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+		IdleTimeout:  cfg.ServerIdleTimeout,
+		BodyLimit:    cfg.ServerBodyLimit,
+		Concurrency:  cfg.ServerConcurrency,
+		Prefork:      cfg.ServerPrefork,
+	})
+
+	auditLog := audit.NewRedisLog(redisClient, int64(cfg.AuditLogMaxEntries))
+	auditHandler := api.NewAuditHandler(auditLog, apiLogger)
+	debugLogHandler := api.NewDebugLogHandler(debuglog.NewRedisSwitch(redisClient), cfg.DebugLogMaxBodyBytes, apiLogger)
+	apiKeyStore := apikey.NewRedisStore(redisClient, []byte(cfg.RequestSigningPepper))
+	apiKeyHandler := api.NewAPIKeyHandler(apiKeyStore, apiLogger)
+	// Quotas, usage and rate limits are shared by the HTTP and gRPC listeners.
+	quotaCounter := quota.NewRedisCounter(redisClient)
+	quotaHandler := api.NewQuotaHandler(quotaCounter, cfg.RateLimits, apiLogger)
+	usageRetention := time.Duration(cfg.UsageRetentionDays) * 24 * time.Hour
+	usageRecorder := usage.NewRedisRecorder(redisClient, usageRetention)
+	usageHandler := api.NewUsageHandler(usageRecorder, cfg.UsageRetentionDays, apiLogger)
+	rateLimiter := api.NewRateLimiter(cfg.RateLimits)
+	graphQLHandler := api.NewGraphQLHandler(apiHandler, quotaHandler, usageHandler, apiLogger)
+	// rateUpdates carries each refresh of the latest rates to the clients streaming them.
+	// Refreshes reach it through Redis, so streams get them whichever replica ran them.
+	rateUpdates := updates.NewBus(cfg.StreamBuffer)
+	rateRelay := updates.NewRedisRelay(redisClient, rateUpdates, logging.For("updates"))
+	startWorker(rateRelay.Start)
+	streamHandler := api.NewStreamHandler(apiHandler, rateUpdates, api.StreamConfig{
+		PingInterval: cfg.StreamPingInterval,
+		MaxPairs:     cfg.StreamMaxPairs,
+	}, apiLogger)
+	// Webhooks are evaluated by the replica that ran each refresh, and delivered by any.
+	webhookStore := webhook.NewRedisStore(redisClient, cfg.WebhookMaxPerKey)
+	webhookDispatcher := webhook.NewDispatcher(webhookStore, apiKeyStore, webhook.Options{
+		MaxAttempts:         cfg.WebhookMaxAttempts,
+		RetryBackoff:        cfg.WebhookRetryBackoff,
+		Timeout:             cfg.WebhookTimeout,
+		Workers:             cfg.WebhookWorkers,
+		AllowPrivateTargets: cfg.WebhookAllowPrivateTargets,
+	}, logging.For("webhooks"))
+	startPrimaryWorker(webhookDispatcher.Start)
+	webhookHandler := api.NewWebhookHandler(apiHandler, webhookStore, apiLogger)
+	// Rate alerts are likewise evaluated after each refresh, by the replica that ran it.
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up alert email: %v", err)
+	}
+	rateAlertStore := ratealert.NewRedisStore(redisClient)
+	rateAlerts := ratealert.NewEngine(rateAlertStore, notifier, logging.For("alerts"))
+	startPrimaryWorker(rateAlerts.Start)
+	rateAlertHandler := api.NewRateAlertHandler(apiHandler, rateAlertStore, cfg.RateAlertCooldown, apiLogger)
+	archiveHandler := api.NewArchiveHandler(rateArchive, rateCache, apiClient, snapshots, apiLogger)
+	refreshUpdates := updates.Fanout{rateRelay, webhookDispatcher, rateAlerts}
+	// Refreshes are also published to NATS and MQTT when configured, again by the replica that ran them.
+	var brokers []*broker.Publisher
+	if cfg.Broker.NATSURL != "" {
+		publisher, err := broker.NewNATSPublisher(cfg.Broker.NATSURL, cfg.Broker.NATSSubject, logging.For("broker"))
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		brokers = append(brokers, publisher)
+	}
+	if cfg.Broker.MQTTBrokerURL != "" {
+		clientID := cfg.Broker.MQTTClientID
+		if clientID == "" {
+			host, _ := os.Hostname()
+			clientID = fmt.Sprintf("currency-exchange-%s-%d", host, os.Getpid())
+		}
+		publisher, err := broker.NewMQTTPublisher(broker.MQTTOptions{
+			BrokerURL: cfg.Broker.MQTTBrokerURL,
+			ClientID:  clientID,
+			Username:  cfg.Broker.MQTTUsername,
+			Password:  cfg.Broker.MQTTPassword,
+			Topic:     cfg.Broker.MQTTTopic,
+			QoS:       byte(cfg.Broker.MQTTQoS),
+			Retain:    cfg.Broker.MQTTRetain,
+		}, logging.For("broker"))
+		if err != nil {
+			log.Fatalf("Failed to connect to MQTT broker: %v", err)
+		}
+		brokers = append(brokers, publisher)
+	}
+	for _, publisher := range brokers {
+		refreshUpdates = append(refreshUpdates, publisher)
+	}
+	var tokenVerifier api.TokenVerifier
+	if cfg.JWT.Enabled() {
+		tokenVerifier = jwtauth.NewVerifier(jwtauth.Options{
+			HMACSecret:    cfg.JWT.HMACSecret,
+			JWKSURL:       cfg.JWT.JWKSURL,
+			JWKSRefresh:   cfg.JWT.JWKSRefresh,
+			Issuer:        cfg.JWT.Issuer,
+			Audience:      cfg.JWT.Audience,
+			SubjectClaim:  cfg.JWT.SubjectClaim,
+			TierClaim:     cfg.JWT.TierClaim,
+			RoleClaim:     cfg.JWT.RoleClaim,
+			ScopeClaim:    cfg.JWT.ScopeClaim,
+			DefaultScopes: []string{apikey.ScopeRatesRead},
+		})
+	}
+	var signatures *api.SignatureVerifier
+	if cfg.RequestSigningEnabled {
+		signatures = api.NewSignatureVerifier(apiKeyStore, nonce.NewRedisCache(redisClient), cfg.RequestSigningMaxSkew, apiLogger)
+	}
+	var authLockout api.AuthFailureTracker
+	if cfg.AuthLockoutThreshold > 0 {
+		authLockout = lockout.NewRedisTracker(redisClient, cfg.AuthLockoutThreshold, cfg.AuthLockoutWindow, cfg.AuthLockoutDuration)
+	}
+	api.SetupRouter(app, apiHandler, healthHandler, adminHandler, auditHandler, debugLogHandler, apiKeyHandler, quotaHandler, usageHandler, graphQLHandler, streamHandler, webhookHandler, rateAlertHandler, archiveHandler, api.RouterConfig{
+		AdminAPIKey:          cfg.AdminAPIKey,
+		APIKeysRequired:      cfg.APIKeysRequired,
+		RateLimits:           cfg.RateLimits,
+		Network:              cfg.Network,
+		CacheBypassEnabled:   cfg.CacheBypassEnabled,
+		MaxQueryLength:       cfg.ServerMaxQueryLength,
+		MetricsEnabled:       cfg.MetricsEnabled,
+		SlowRequestThreshold: cfg.SlowRequestThreshold,
+		Logger:               apiLogger,
+		ErrorReporter:        errorReporter,
+		TokenVerifier:        tokenVerifier,
+		Signatures:           signatures,
+		AuthLockout:          authLockout,
+		Limiter:              rateLimiter,
 	})
+	pendingWrites := func() int { return 0 }
+	if flusher, ok := rateRepo.(repository.Flusher); ok {
+		pendingWrites = flusher.PendingWrites
+	}
+	if cfg.MetricsEnabled {
+		if err := metrics.RegisterRateAge(latestRatesAges(rateCache)); err != nil {
+			log.Fatalf("Failed to register metrics: %v", err)
+		}
+		if err := metrics.RegisterRuntime(redisClient, pendingWrites); err != nil {
+			log.Fatalf("Failed to register metrics: %v", err)
+		}
+	}
+	if cfg.OTLPMetricsEndpoint != "" {
+		pusher, err := metrics.NewOTLPPusher(context.Background(), metrics.OTLPOptions{
+			Endpoint:    cfg.OTLPMetricsEndpoint,
+			Interval:    cfg.OTLPMetricsInterval,
+			ServiceName: "currency-exchange",
+			Environment: cfg.AppEnv,
+			Logger:      logging.For("runtime"),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up OTLP metrics export: %v", err)
+		}
+		startWorker(pusher.Start)
+	}
+	watchdog := metrics.NewRuntimeWatchdog(redisClient, pendingWrites, cfg.CacheWritesWarnThreshold, cfg.RedisPoolWarnUsage, cfg.RuntimeCheckInterval, logging.For("runtime"))
+	startWorker(watchdog.Start)
 
-	app.Use(logger.New())
+	schedularLogger := logging.For("schedular")
+	if cfg.CacheWarmUpEnabled && !fiber.IsChild() {
+		log.Printf("Warming up latest rates cache (timeout %s)...", cfg.CacheWarmUpTimeout)
+		if err := schedular.WarmUpCache(context.Background(), cfg.CacheWarmUpTimeout, refreshBases, apiClient, rateCache, rateService, schedularLogger); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
 
-	api.SetupRouter(app, apiHandler)
+	refreshJitter := schedular.Jitter{Start: cfg.RefreshStartJitter, Cycle: cfg.RefreshJitter}
+	var leader *schedular.LeaderElection
+	if cfg.LeaderElectionEnabled {
+		leader = schedular.NewLeaderElection(redisClient, cfg.LeaderLease, schedularLogger)
+		startPrimaryWorker(leader.Start)
+	}
+	alerter := schedular.NewFailureAlerter(redisClient, cfg.RefreshFailureThreshold, notifier, schedularLogger)
+	startPrimaryWorker(func(ctx context.Context) {
+		schedular.StartBackgroundRefreshWithLock(ctx, schedular.RefreshOptions{
+			Interval:     cfg.RefreshInterval,
+			Jitter:       refreshJitter,
+			Workers:      cfg.RefreshWorkers,
+			MinAge:       cfg.RefreshMinAge,
+			Alerter:      alerter,
+			Bases:        refreshBases,
+			Leader:       leader,
+			Retries:      cfg.RefreshRetries,
+			RetryBackoff: cfg.RefreshRetryDelay,
+			Logger:       schedularLogger,
+			Updates:      refreshUpdates,
+		}, apiClient, rateCache, redisClient, rateService)
+	})
+	if cfg.MetalsEnabled {
+		startPrimaryWorker(func(ctx context.Context) {
+			schedular.StartMetalsRefreshWithLock(ctx, schedular.RefreshOptions{
+				Interval:     cfg.MetalsRefreshInterval,
+				Jitter:       refreshJitter,
+				Workers:      cfg.RefreshWorkers,
+				MinAge:       cfg.RefreshMinAge,
+				Alerter:      alerter,
+				Bases:        refreshBases,
+				Leader:       leader,
+				Retries:      cfg.RefreshRetries,
+				RetryBackoff: cfg.RefreshRetryDelay,
+				Logger:       schedularLogger,
+				Updates:      refreshUpdates,
+			}, apiClient, rateCache, redisClient, rateService)
+		})
+	}
+	if cfg.HistoricalRefreshEnabled {
+		startPrimaryWorker(func(ctx context.Context) {
+			schedular.StartHistoricalRefreshWithLock(ctx, schedular.RefreshOptions{
+				Interval: cfg.HistoricalRefreshInterval,
+				Jitter:   refreshJitter,
+				Bases:    refreshBases,
+				Leader:   leader,
+				Logger:   schedularLogger,
+				Archive:  rateArchive,
+			}, apiClient, rateCache, redisClient, rateService)
+		})
+	}
 
-	go schedular.StartBackgroundRefreshWithLock(context.Background(), cfg.RefreshInterval, apiClient, redisCache, redisClient, rateService)
+	if cfg.CacheHygieneEnabled {
+		startPrimaryWorker(func(ctx context.Context) {
+			schedular.StartCacheHygieneWithLock(ctx, schedular.RefreshOptions{
+				Interval: cfg.CacheHygieneInterval,
+				Jitter:   refreshJitter,
+				Leader:   leader,
+				Logger:   schedularLogger,
+			}, redisClient, cfg.HistoryDaysLimit)
+		})
+	}
+	if cfg.RetentionEnabled {
+		compactor, _ := rateArchive.(archive.Compactor)
+		startPrimaryWorker(func(ctx context.Context) {
+			schedular.StartRetentionWithLock(ctx, schedular.RefreshOptions{
+				Interval: cfg.RetentionInterval,
+				Jitter:   refreshJitter,
+				Leader:   leader,
+				Logger:   schedularLogger,
+			}, redisClient, compactor, auditLog, schedular.RetentionPolicy{
+				DailyRatesDays: cfg.Archive.DailyRetentionDays,
+				AuditLogDays:   cfg.AuditLogRetentionDays,
+			})
+		})
+	}
+	if snapshots != nil {
+		startPrimaryWorker(func(ctx context.Context) {
+			schedular.StartSnapshotsWithLock(ctx, schedular.RefreshOptions{
+				Interval: cfg.Snapshot.Interval,
+				Jitter:   refreshJitter,
+				Leader:   leader,
+				Logger:   schedularLogger,
+			}, redisClient, rateArchive, snapshots, cfg.Snapshot.Days)
+		})
+	}
+
+	var grpcServer *grpcapi.Server
+	if cfg.GRPCPort != "" {
+		grpcServer = grpcapi.NewServer(healthHandler, cfg.GRPCHealthCheckInterval, logging.For("grpc"))
+		grpcServer.RegisterRates(rateService, grpcapi.RatesOptions{
+			Keys:         apiKeyStore,
+			KeysRequired: cfg.APIKeysRequired,
+			Prefetch:     cfg.HistoricalChunkConcurrency,
+			Network:      cfg.Network,
+			RateLimits:   cfg.RateLimits,
+			Limiter:      rateLimiter,
+			Quota:        quotaCounter,
+			Usage:        usageRecorder,
+			AuthLockout:  authLockout,
+		})
+		startWorker(grpcServer.WatchHealth)
+		grpcAddr := net.JoinHostPort(cfg.ServerHost, cfg.GRPCPort)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Could not listen for gRPC on %s: %v", grpcAddr, err)
+		}
+		go func() {
+			log.Printf("gRPC server starting on %s", grpcAddr)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("Could not start gRPC server: %v", err)
+			}
+		}()
+	}
 
 	go func() {
-		log.Printf("Server starting on port %s", cfg.ServerPort)
-		if err := app.Listen(":" + cfg.ServerPort); err != nil {
+		log.Printf("Server starting on %s", listenAddress(cfg))
+		if err := listen(app, cfg); err != nil {
 			log.Fatalf("Could not start server: %v", err)
 		}
 	}()
@@ -73,12 +461,182 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
+	// Shutdown runs in order: fail readiness so load balancers stop routing here, stop accepting
+	// and drain in-flight requests, stop the background workers, then flush cache writes that
+	// requests started in the background.
+	if cfg.ShutdownDrainDelay > 0 {
+		healthHandler.StartDraining()
+		if grpcServer != nil {
+			grpcServer.Drain()
+		}
+		log.Printf("Draining: reporting not ready for %s before closing the listener", cfg.ShutdownDrainDelay)
+		time.Sleep(cfg.ShutdownDrainDelay)
+	}
 
+	// Streams hold their connections open indefinitely, so they are ended rather than drained.
+	rateUpdates.Close()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
 	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
-		log.Fatalf("Server shutdown failed: %v", err)
+		log.Printf("WARNING: in-flight requests did not finish within %s: %v", cfg.ShutdownTimeout, err)
+	}
+	if grpcServer != nil {
+		grpcServer.Stop(shutdownCtx)
+	}
+
+	// Stop scheduling new refreshes and let any in-flight cycle finish writing to the cache.
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+		log.Println("Background workers stopped")
+	case <-time.After(cfg.ShutdownWorkerTimeout):
+		log.Printf("WARNING: background workers still running after %s, exiting anyway", cfg.ShutdownWorkerTimeout)
+	}
+
+	for _, publisher := range brokers {
+		publisher.Close()
+	}
+
+	if flusher, ok := rateRepo.(repository.Flusher); ok {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer flushCancel()
+		if err := flusher.Flush(flushCtx); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+	if rateArchive != nil {
+		rateArchive.Close()
 	}
 
 	log.Println("Server exited gracefully")
 }
+
+// openArchive connects to the rate archive, returning nil when none is configured.
+func openArchive(cfg *config.Config) archive.Store {
+	if !cfg.Archive.Enabled() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var store archive.Store
+	var err error
+	switch cfg.Archive.Backend {
+	case config.ArchiveSQLite:
+		store, err = archive.NewSQLiteStore(ctx, cfg.Archive.SQLitePath)
+	default:
+		store, err = archive.NewPostgresStore(ctx, cfg.Archive.PostgresURL)
+	}
+	if err != nil {
+		log.Fatalf("Failed to open the rate archive: %v", err)
+	}
+	log.Printf("Archiving historical rates to %s", cfg.Archive.Backend)
+	return store
+}
+
+// openSnapshots returns where snapshots of the archive go, or nil when SNAPSHOT_S3_BUCKET
+// isn't set.
+func openSnapshots(cfg *config.Config) *archive.Snapshots {
+	if !cfg.Snapshot.Enabled() {
+		return nil
+	}
+	bucket, err := archive.NewS3Bucket(archive.S3Options{
+		Endpoint:  cfg.Snapshot.Endpoint,
+		Region:    cfg.Snapshot.Region,
+		Bucket:    cfg.Snapshot.Bucket,
+		AccessKey: cfg.Snapshot.AccessKey,
+		SecretKey: cfg.Snapshot.SecretKey,
+		Insecure:  cfg.Snapshot.Insecure,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up rate snapshots: %v", err)
+	}
+	log.Printf("Writing rate snapshots to s3://%s/%s", cfg.Snapshot.Bucket, cfg.Snapshot.Prefix)
+	return archive.NewSnapshots(bucket, cfg.Snapshot.Prefix)
+}
+
+// listen serves on the Unix socket SERVER_SOCKET when it is set and on SERVER_HOST:SERVER_PORT
+// otherwise. HTTPS is served when a certificate is configured, additionally requiring client
+// certificates signed by TLS_CLIENT_CA_FILE when that is set.
+func listen(app *fiber.App, cfg *config.Config) error {
+	if cfg.ServerSocket != "" {
+		// A socket file left behind by an unclean exit would make the bind fail.
+		if err := os.Remove(cfg.ServerSocket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		ln, err := net.Listen("unix", cfg.ServerSocket)
+		if err != nil {
+			return err
+		}
+		return app.Listener(ln)
+	}
+
+	addr := net.JoinHostPort(cfg.ServerHost, cfg.ServerPort)
+	switch {
+	case cfg.TLSClientCAFile != "":
+		return app.ListenMutualTLS(addr, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+	case cfg.TLSCertFile != "":
+		return app.ListenTLS(addr, cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return app.Listen(addr)
+}
+
+// listenAddress describes where listen binds, for the startup log.
+func listenAddress(cfg *config.Config) string {
+	if cfg.ServerSocket != "" {
+		return "unix:" + cfg.ServerSocket
+	}
+	return net.JoinHostPort(cfg.ServerHost, cfg.ServerPort)
+}
+
+// latestRatesAges reports how old the cached latest rates of every supported base are, for the
+// rate_age_seconds metric. Bases with nothing cached are left out.
+func latestRatesAges(c cache.Cache) func() map[domain.Currency]time.Duration {
+	return func() map[domain.Currency]time.Duration {
+		ages := make(map[domain.Currency]time.Duration)
+		reporter, ok := c.(cache.FreshnessReporter)
+		if !ok {
+			return ages
+		}
+		for base := range domain.SupportedCurrencies {
+			if age, ok := reporter.LatestRatesAge(base); ok {
+				ages[base] = age
+			}
+		}
+		return ages
+	}
+}
+
+// newNotifier sends alerts to ALERT_WEBHOOK_URL, or only logs them when it is unset, and also
+// emails them when SMTP_HOST is set.
+func newNotifier(cfg *config.Config) (notify.Notifier, error) {
+	notifier := notify.New(cfg.AlertWebhookURL)
+	if !cfg.Email.Enabled() {
+		return notifier, nil
+	}
+	recipients := make([]notify.EmailRecipient, 0, len(cfg.Email.Recipients))
+	for _, r := range cfg.Email.Recipients {
+		recipients = append(recipients, notify.EmailRecipient{Address: r.Address, Name: r.Name, Kinds: r.Kinds, Resolved: r.Resolved})
+	}
+	email, err := notify.NewEmailNotifier(notify.EmailOptions{
+		Host:       cfg.Email.Host,
+		Port:       cfg.Email.Port,
+		Username:   cfg.Email.Username,
+		Password:   cfg.Email.Password,
+		From:       cfg.Email.From,
+		Recipients: recipients,
+		Subject:    cfg.Email.SubjectTemplate,
+		Body:       cfg.Email.BodyTemplate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AlertWebhookURL == "" {
+		return email, nil
+	}
+	return notify.Multi{notifier, email}, nil
+}
@@ -0,0 +1,55 @@
+package apikey
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Endpoints lists the rate endpoints a key can be restricted to.
+var Endpoints = []string{"latest", "convert", "historical"}
+
+// pairPattern matches "BASE/TARGET", where either side may be "*" for any currency.
+var pairPattern = regexp.MustCompile(`^([A-Z]{3,5}|\*)/([A-Z]{3,5}|\*)$`)
+
+// Restrictions limit a key to some rate endpoints and currency pairs, e.g. a partner who may
+// only query EUR pairs and can't use /historical. Empty lists allow anything.
+type Restrictions struct {
+	Endpoints []string `json:"endpoints,omitempty"`
+	// Pairs are "BASE/TARGET" patterns such as "EUR/USD", "EUR/*" or "*/EUR".
+	Pairs []string `json:"pairs,omitempty"`
+}
+
+// AllowsEndpoint reports whether endpoint, e.g. "convert", may be used.
+func (r Restrictions) AllowsEndpoint(endpoint string) bool {
+	return len(r.Endpoints) == 0 || contains(r.Endpoints, endpoint)
+}
+
+// AllowsPair reports whether rates from base to target may be queried.
+func (r Restrictions) AllowsPair(base, target string) bool {
+	if len(r.Pairs) == 0 {
+		return true
+	}
+	for _, pattern := range r.Pairs {
+		b, t, _ := strings.Cut(pattern, "/")
+		if (b == "*" || b == base) && (t == "*" || t == target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the endpoint names and pair patterns.
+func (r Restrictions) Validate() error {
+	for _, endpoint := range r.Endpoints {
+		if !contains(Endpoints, endpoint) {
+			return fmt.Errorf("unknown endpoint %q, expected one of %s", endpoint, strings.Join(Endpoints, ", "))
+		}
+	}
+	for _, pair := range r.Pairs {
+		if !pairPattern.MatchString(pair) {
+			return fmt.Errorf("pair %q must look like EUR/USD, with * for any currency", pair)
+		}
+	}
+	return nil
+}
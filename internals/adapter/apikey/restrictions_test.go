@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestrictions(t *testing.T) {
+	var none Restrictions
+	assert.True(t, none.AllowsEndpoint("historical"))
+	assert.True(t, none.AllowsPair("USD", "INR"))
+
+	euro := Restrictions{Endpoints: []string{"latest", "convert"}, Pairs: []string{"EUR/*", "*/EUR", "USD/INR"}}
+	assert.True(t, euro.AllowsEndpoint("convert"))
+	assert.False(t, euro.AllowsEndpoint("historical"))
+	assert.True(t, euro.AllowsPair("EUR", "GBP"))
+	assert.True(t, euro.AllowsPair("JPY", "EUR"))
+	assert.True(t, euro.AllowsPair("USD", "INR"))
+	assert.False(t, euro.AllowsPair("INR", "USD"))
+	assert.False(t, euro.AllowsPair("USD", "GBP"))
+}
+
+func TestRestrictions_Validate(t *testing.T) {
+	assert.NoError(t, Restrictions{Endpoints: Endpoints, Pairs: []string{"EUR/*", "USDT/BTC"}}.Validate())
+	for _, r := range []Restrictions{
+		{Endpoints: []string{"rates"}},
+		{Pairs: []string{"EUR"}},
+		{Pairs: []string{"eur/usd"}},
+		{Pairs: []string{"EUR/US1"}},
+	} {
+		assert.Error(t, r.Validate(), r)
+	}
+}
+
+func TestRedisStore_KeepsRestrictions(t *testing.T) {
+	store, _ := setupStore(t)
+	ctx := context.Background()
+	restrictions := Restrictions{Endpoints: []string{"latest"}, Pairs: []string{"EUR/*"}}
+	_, secrets, err := store.Create(ctx, NewKey{ID: "partner-a", Name: "Partner A", Role: RoleReader, Restrictions: restrictions})
+	assert.NoError(t, err)
+
+	key, err := store.Authenticate(ctx, secrets.Secret)
+	assert.NoError(t, err)
+	assert.Equal(t, restrictions, key.Restrictions)
+
+	_, _, err = store.Create(ctx, NewKey{Name: "Partner B", Role: RoleReader, Restrictions: Restrictions{Endpoints: []string{"rates"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,458 @@
+package apikey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Scopes a key can be granted.
+const (
+	ScopeRatesRead = "rates:read" // the /v1 rate endpoints
+	ScopeAdmin     = "admin"      // everything under /admin, like ADMIN_API_KEY
+)
+
+// Scopes lists every scope a key can be granted.
+var Scopes = []string{ScopeRatesRead, ScopeAdmin}
+
+// Roles a key or token can be given instead of, or on top of, individual scopes.
+const (
+	RoleReader = "reader" // the /v1 rate endpoints
+	RoleAdmin  = "admin"  // the /v1 rate endpoints and everything under /admin
+)
+
+// Roles lists every role a key can be given.
+var Roles = []string{RoleReader, RoleAdmin}
+
+var roleScopes = map[string][]string{
+	RoleReader: {ScopeRatesRead},
+	RoleAdmin:  {ScopeRatesRead, ScopeAdmin},
+}
+
+// RoleScopes returns the scopes role grants, and false when the role is unknown.
+func RoleScopes(role string) ([]string, bool) {
+	scopes, ok := roleScopes[role]
+	return scopes, ok
+}
+
+// Grant returns scopes together with those granted by role, without duplicates. Unknown
+// roles grant nothing.
+func Grant(role string, scopes []string) []string {
+	granted := make([]string, 0, len(scopes)+len(roleScopes[role]))
+	for _, scope := range append(append([]string{}, scopes...), roleScopes[role]...) {
+		if !contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return granted
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// secretPrefix marks our keys, so they are easy to recognise in code and secret scanners.
+// signingSecretPrefix does the same for request signing secrets.
+const (
+	secretPrefix        = "cx_"
+	signingSecretPrefix = "cxs_"
+)
+
+const (
+	idsKey = "apikeys"
+	// displayPrefixLength is how much of a secret is kept in clear, so operators can tell
+	// keys apart without the full secret.
+	displayPrefixLength = len(secretPrefix) + 6
+)
+
+var (
+	ErrNotFound = errors.New("api key not found")
+	ErrExists   = errors.New("api key already exists")
+	ErrRevoked  = errors.New("api key is revoked")
+	// ErrInvalid is returned for secrets that are unknown, revoked or expired. Callers are
+	// not told which, so a leaked secret can't be probed.
+	ErrInvalid = errors.New("invalid api key")
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Key describes an API key. The secrets themselves are only returned when the key is created
+// or rotated; afterwards only the hash of the secret is stored.
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	Restrictions
+}
+
+// Grants returns the key's scopes together with those of its role.
+func (k Key) Grants() []string {
+	return Grant(k.Role, k.Scopes)
+}
+
+// HasScope reports whether the key was granted scope, directly or through its role.
+func (k Key) HasScope(scope string) bool {
+	return contains(k.Grants(), scope)
+}
+
+// Active reports whether the key can be used at now.
+func (k Key) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// NewKey holds what the caller chooses when creating a key. An empty ID gets a random one.
+type NewKey struct {
+	ID        string
+	Name      string
+	Role      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	Restrictions
+}
+
+// Validate checks the ID format, the role, the scopes and the restrictions.
+func (n NewKey) Validate() error {
+	if n.ID != "" && !idPattern.MatchString(n.ID) {
+		return fmt.Errorf("id %q must be 1 to 64 lowercase letters, digits or dashes", n.ID)
+	}
+	if n.Name == "" {
+		return errors.New("name is required")
+	}
+	if n.Role != "" && !contains(Roles, n.Role) {
+		return fmt.Errorf("unknown role %q", n.Role)
+	}
+	if n.Role == "" && len(n.Scopes) == 0 {
+		return errors.New("a role or at least one scope is required")
+	}
+	for _, scope := range n.Scopes {
+		if !contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return n.Restrictions.Validate()
+}
+
+// Secrets are what a client authenticates with. They are only returned when a key is created
+// or rotated.
+type Secrets struct {
+	Secret        string // sent as X-API-Key
+	SigningSecret string // signs requests; empty when the store has no signing pepper
+}
+
+// record is what is stored per key: the Key, the hash of its current secret and the seed its
+// signing secret is derived from.
+type record struct {
+	Key
+	Hash        string `json:"hash"`
+	SigningSeed string `json:"signingSeed,omitempty"`
+}
+
+// RedisStore keeps API keys in Redis, shared by every replica. Secrets are stored as SHA-256
+// hashes: they are long random strings, so unlike passwords they need no salt or slow hash.
+//
+// Request signing secrets are separate from the API key secrets. Each key stores a random
+// seed, and its signing secret is the HMAC of that seed under a pepper that only the servers
+// have. Someone who can read Redis therefore can't sign requests.
+type RedisStore struct {
+	client        *redis.Client
+	signingPepper []byte
+	now           func() time.Time
+}
+
+// NewRedisStore returns a store whose keys get signing secrets derived with signingPepper.
+// With an empty pepper, keys get no signing secret and can't sign requests.
+func NewRedisStore(client *redis.Client, signingPepper []byte) *RedisStore {
+	return &RedisStore{client: client, signingPepper: signingPepper, now: time.Now}
+}
+
+func keyKey(id string) string {
+	return "apikey:" + id
+}
+
+func hashKey(hash string) string {
+	return "apikey_hash:" + hash
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret returns 192 random bits, URL safe, behind secretPrefix.
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newSigningSeed returns 256 random bits, hex encoded.
+func newSigningSeed() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate signing secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// signingSecret derives the signing secret of a key from its seed, or returns "" when either
+// the seed or the pepper is missing.
+func (s *RedisStore) signingSecret(seed string) string {
+	if seed == "" || len(s.signingPepper) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.signingPepper)
+	mac.Write([]byte(seed))
+	return signingSecretPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key id: %w", err)
+	}
+	return "key-" + hex.EncodeToString(b), nil
+}
+
+// createScript stores a key unless its ID is taken, writing the record, its secret's hash and
+// its ID in one step, so a failure can't leave the ID reserved without a usable key. It returns
+// 0 when the ID is taken.
+const createScript = `
+if redis.call("SETNX", KEYS[1], ARGV[2]) == 0 then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[1])
+redis.call("SADD", KEYS[3], ARGV[1])
+return 1
+`
+
+// Create stores a new key and returns it with its secrets, which can't be retrieved later.
+func (s *RedisStore) Create(ctx context.Context, newKey NewKey) (Key, Secrets, error) {
+	if err := newKey.Validate(); err != nil {
+		return Key{}, Secrets{}, err
+	}
+	id := newKey.ID
+	if id == "" {
+		var err error
+		if id, err = newID(); err != nil {
+			return Key{}, Secrets{}, err
+		}
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	seed, err := newSigningSeed()
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	scopes := newKey.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	rec := record{
+		Key: Key{
+			ID:           id,
+			Name:         newKey.Name,
+			Role:         newKey.Role,
+			Scopes:       scopes,
+			Restrictions: newKey.Restrictions,
+			Prefix:       secret[:displayPrefixLength],
+			CreatedAt:    s.now().UTC(),
+			ExpiresAt:    newKey.ExpiresAt,
+		},
+		Hash:        hashSecret(secret),
+		SigningSeed: seed,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	created, err := s.client.Eval(ctx, createScript, []string{keyKey(id), hashKey(rec.Hash), idsKey}, id, data).Int()
+	if err != nil {
+		return Key{}, Secrets{}, fmt.Errorf("failed to store api key: %w", err)
+	}
+	if created == 0 {
+		return Key{}, Secrets{}, ErrExists
+	}
+	return rec.Key, Secrets{Secret: secret, SigningSecret: s.signingSecret(seed)}, nil
+}
+
+// List returns every key, revoked and expired ones included, ordered by ID.
+func (s *RedisStore) List(ctx context.Context) ([]Key, error) {
+	ids, err := s.client.SMembers(ctx, idsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	sort.Strings(ids)
+	keys := make([]Key, 0, len(ids))
+	for _, id := range ids {
+		rec, err := s.load(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, rec.Key)
+	}
+	return keys, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (Key, error) {
+	rec, err := s.load(ctx, id)
+	return rec.Key, err
+}
+
+// Rotate gives key id new secrets and returns them. The old secret keeps working for grace,
+// so clients can switch over without downtime; a zero grace invalidates it at once. The old
+// signing secret stops working at once.
+func (s *RedisStore) Rotate(ctx context.Context, id string, grace time.Duration) (Key, Secrets, error) {
+	rec, err := s.load(ctx, id)
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	if rec.RevokedAt != nil {
+		return Key{}, Secrets{}, ErrRevoked
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	seed, err := newSigningSeed()
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+
+	oldHash := rec.Hash
+	now := s.now().UTC()
+	rec.Hash = hashSecret(secret)
+	rec.SigningSeed = seed
+	rec.Prefix = secret[:displayPrefixLength]
+	rec.RotatedAt = &now
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return Key{}, Secrets{}, err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyKey(id), data, 0)
+		pipe.Set(ctx, hashKey(rec.Hash), id, 0)
+		if grace > 0 {
+			pipe.Expire(ctx, hashKey(oldHash), grace)
+		} else {
+			pipe.Del(ctx, hashKey(oldHash))
+		}
+		return nil
+	})
+	if err != nil {
+		return Key{}, Secrets{}, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+	return rec.Key, Secrets{Secret: secret, SigningSecret: s.signingSecret(seed)}, nil
+}
+
+// Revoke disables key id for good. The key stays listed, marked as revoked.
+func (s *RedisStore) Revoke(ctx context.Context, id string) (Key, error) {
+	rec, err := s.load(ctx, id)
+	if err != nil {
+		return Key{}, err
+	}
+	if rec.RevokedAt != nil {
+		return rec.Key, nil
+	}
+	now := s.now().UTC()
+	rec.RevokedAt = &now
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return Key{}, err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyKey(id), data, 0)
+		pipe.Del(ctx, hashKey(rec.Hash))
+		return nil
+	})
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return rec.Key, nil
+}
+
+// Authenticate returns the key secret belongs to, or ErrInvalid when it is unknown, revoked
+// or expired.
+func (s *RedisStore) Authenticate(ctx context.Context, secret string) (Key, error) {
+	id, err := s.client.Get(ctx, hashKey(hashSecret(secret))).Result()
+	if errors.Is(err, redis.Nil) {
+		return Key{}, ErrInvalid
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	rec, err := s.load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return Key{}, ErrInvalid
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	if !rec.Active(s.now()) {
+		return Key{}, ErrInvalid
+	}
+	return rec.Key, nil
+}
+
+// SigningKey returns key id together with the secret its HMAC request signatures are made
+// with. Unknown, revoked and expired keys give ErrInvalid, as do keys without a signing
+// secret: those created before signing secrets existed, or any key when the store has no
+// pepper.
+func (s *RedisStore) SigningKey(ctx context.Context, id string) (Key, []byte, error) {
+	rec, err := s.load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return Key{}, nil, ErrInvalid
+	}
+	if err != nil {
+		return Key{}, nil, err
+	}
+	signingSecret := s.signingSecret(rec.SigningSeed)
+	if !rec.Active(s.now()) || signingSecret == "" {
+		return Key{}, nil, ErrInvalid
+	}
+	return rec.Key, []byte(signingSecret), nil
+}
+
+func (s *RedisStore) load(ctx context.Context, id string) (record, error) {
+	data, err := s.client.Get(ctx, keyKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return record{}, ErrNotFound
+	}
+	if err != nil {
+		return record{}, fmt.Errorf("failed to read api key: %w", err)
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return record{}, fmt.Errorf("invalid api key record %s: %w", id, err)
+	}
+	return rec, nil
+}
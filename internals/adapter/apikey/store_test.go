@@ -0,0 +1,188 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	t.Cleanup(mini.Close)
+	return NewRedisStore(redis.NewClient(&redis.Options{Addr: mini.Addr()}), []byte("pepper-0123456789abcdef0123456789")), mini
+}
+
+func TestRedisStore_CreateAndAuthenticate(t *testing.T) {
+	store, mini := setupStore(t)
+	ctx := context.Background()
+
+	key, secrets, err := store.Create(ctx, NewKey{ID: "partner-a", Name: "Partner A", Scopes: []string{ScopeRatesRead}})
+	assert.NoError(t, err)
+	secret := secrets.Secret
+	assert.True(t, strings.HasPrefix(secret, "cx_"))
+	assert.True(t, strings.HasPrefix(secrets.SigningSecret, "cxs_"))
+	assert.Equal(t, secret[:9], key.Prefix)
+	assert.Equal(t, "partner-a", key.ID)
+
+	// Only the hash of the secret and the seed of the signing secret are stored.
+	for _, k := range mini.Keys() {
+		value, _ := mini.Get(k)
+		assert.NotContains(t, value, secret)
+		assert.NotContains(t, value, strings.TrimPrefix(secrets.SigningSecret, "cxs_"))
+	}
+
+	got, err := store.Authenticate(ctx, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+	assert.True(t, got.HasScope(ScopeRatesRead))
+	assert.False(t, got.HasScope(ScopeAdmin))
+
+	_, err = store.Authenticate(ctx, "cx_unknown")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// A taken ID writes nothing, so the refused secret's hash isn't indexed.
+	before := mini.Keys()
+	_, _, err = store.Create(ctx, NewKey{ID: "partner-a", Name: "Again", Scopes: []string{ScopeRatesRead}})
+	assert.ErrorIs(t, err, ErrExists)
+	assert.Equal(t, before, mini.Keys())
+}
+
+func TestRedisStore_CreateGeneratesID(t *testing.T) {
+	store, _ := setupStore(t)
+	key, _, err := store.Create(context.Background(), NewKey{Name: "CLI", Scopes: []string{ScopeAdmin}})
+	assert.NoError(t, err)
+	assert.Regexp(t, `^key-[0-9a-f]{12}$`, key.ID)
+}
+
+func TestNewKey_Validate(t *testing.T) {
+	for _, newKey := range []NewKey{
+		{ID: "Partner A", Name: "Partner A", Scopes: []string{ScopeRatesRead}},
+		{ID: "partner-a", Scopes: []string{ScopeRatesRead}},
+		{ID: "partner-a", Name: "Partner A"},
+		{ID: "partner-a", Name: "Partner A", Scopes: []string{"rates:write"}},
+		{ID: "partner-a", Name: "Partner A", Role: "owner"},
+	} {
+		assert.Error(t, newKey.Validate(), newKey)
+	}
+	assert.NoError(t, NewKey{Name: "Ops", Role: RoleAdmin}.Validate())
+}
+
+func TestKey_Grants(t *testing.T) {
+	reader := Key{Role: RoleReader}
+	assert.True(t, reader.HasScope(ScopeRatesRead))
+	assert.False(t, reader.HasScope(ScopeAdmin))
+
+	admin := Key{Role: RoleAdmin, Scopes: []string{ScopeRatesRead}}
+	assert.Equal(t, []string{ScopeRatesRead, ScopeAdmin}, admin.Grants())
+
+	assert.Equal(t, []string{ScopeRatesRead}, Key{Role: "owner", Scopes: []string{ScopeRatesRead}}.Grants())
+}
+
+func TestRedisStore_ExpiredKey(t *testing.T) {
+	store, _ := setupStore(t)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+	_, secrets, err := store.Create(ctx, NewKey{Name: "Trial", Scopes: []string{ScopeRatesRead}, ExpiresAt: &expires})
+	assert.NoError(t, err)
+	secret := secrets.Secret
+
+	_, err = store.Authenticate(ctx, secret)
+	assert.NoError(t, err)
+
+	store.now = func() time.Time { return expires }
+	_, err = store.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestRedisStore_Rotate(t *testing.T) {
+	store, mini := setupStore(t)
+	ctx := context.Background()
+	_, old, _ := store.Create(ctx, NewKey{ID: "partner-a", Name: "Partner A", Scopes: []string{ScopeRatesRead}})
+	oldSecret := old.Secret
+
+	key, rotated, err := store.Rotate(ctx, "partner-a", time.Minute)
+	assert.NoError(t, err)
+	newSecret := rotated.Secret
+	assert.NotEqual(t, oldSecret, newSecret)
+	assert.NotEqual(t, old.SigningSecret, rotated.SigningSecret)
+	assert.NotNil(t, key.RotatedAt)
+	assert.Equal(t, newSecret[:9], key.Prefix)
+
+	// Both secrets work during the grace period, only the new one after it.
+	_, err = store.Authenticate(ctx, oldSecret)
+	assert.NoError(t, err)
+	mini.FastForward(time.Minute)
+	_, err = store.Authenticate(ctx, oldSecret)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = store.Authenticate(ctx, newSecret)
+	assert.NoError(t, err)
+
+	_, immediate, err := store.Rotate(ctx, "partner-a", 0)
+	assert.NoError(t, err)
+	_, err = store.Authenticate(ctx, newSecret)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = store.Authenticate(ctx, immediate.Secret)
+	assert.NoError(t, err)
+
+	_, _, err = store.Rotate(ctx, "missing", 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRedisStore_RevokeAndList(t *testing.T) {
+	store, _ := setupStore(t)
+	ctx := context.Background()
+	_, secrets, _ := store.Create(ctx, NewKey{ID: "partner-b", Name: "Partner B", Scopes: []string{ScopeRatesRead}})
+	secret := secrets.Secret
+	_, _, _ = store.Create(ctx, NewKey{ID: "partner-a", Name: "Partner A", Scopes: []string{ScopeRatesRead}})
+
+	key, err := store.Revoke(ctx, "partner-b")
+	assert.NoError(t, err)
+	assert.NotNil(t, key.RevokedAt)
+	_, err = store.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, _, err = store.Rotate(ctx, "partner-b", 0)
+	assert.ErrorIs(t, err, ErrRevoked)
+
+	keys, err := store.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "partner-a", keys[0].ID)
+	assert.Equal(t, "partner-b", keys[1].ID)
+	assert.NotNil(t, keys[1].RevokedAt)
+
+	_, err = store.Revoke(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRedisStore_SigningKey(t *testing.T) {
+	store, _ := setupStore(t)
+	ctx := context.Background()
+	_, secrets, _ := store.Create(ctx, NewKey{ID: "partner-a", Name: "Partner A", Role: RoleReader})
+
+	key, signingKey, err := store.SigningKey(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "partner-a", key.ID)
+	assert.Equal(t, secrets.SigningSecret, string(signingKey))
+	assert.NotContains(t, string(signingKey), strings.TrimPrefix(secrets.Secret, "cx_"))
+
+	// The signing secret can't be worked out from Redis alone: another pepper gives another.
+	otherPepper := &RedisStore{client: store.client, signingPepper: []byte("another-pepper-0123456789abcdef"), now: time.Now}
+	_, otherKey, err := otherPepper.SigningKey(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.NotEqual(t, signingKey, otherKey)
+	noPepper := NewRedisStore(store.client, nil)
+	_, _, err = noPepper.SigningKey(ctx, "partner-a")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, _, err = store.SigningKey(ctx, "missing")
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, _ = store.Revoke(ctx, "partner-a")
+	_, _, err = store.SigningKey(ctx, "partner-a")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
@@ -0,0 +1,21 @@
+package archive
+
+import (
+	"context"
+	"time"
+)
+
+// Compaction reports what a Downsample removed.
+type Compaction struct {
+	Rows int64
+	// Bytes is the storage freed: how much the file shrank for SQLite, and the size of the
+	// deleted rows for PostgreSQL, which reuses their space rather than returning it.
+	Bytes int64
+}
+
+// Compactor is implemented by stores that can thin out old rates.
+type Compactor interface {
+	// Downsample keeps only the last stored day of each week (Monday to Sunday) of every pair
+	// for the days before before, which should be a Monday so that only whole weeks are thinned.
+	Downsample(ctx context.Context, before time.Time) (Compaction, error)
+}
@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+)
+
+// CSVHeader is the header row of rate CSV files. It is optional on import.
+var CSVHeader = []string{"date", "base", "target", "rate"}
+
+// maxCSVProblems caps how many bad rows an import reports.
+const maxCSVProblems = 20
+
+// Day is the rates of one base on one day.
+type Day struct {
+	Date  time.Time
+	Base  domain.Currency
+	Rates map[domain.Currency]float64
+}
+
+// CSVError lists the rows of a CSV file that could not be imported.
+type CSVError struct {
+	Problems []string // e.g. "line 3: invalid date \"2024/01/02\", expected YYYY-MM-DD"
+}
+
+func (e *CSVError) Error() string {
+	return "invalid rates CSV: " + strings.Join(e.Problems, "; ")
+}
+
+// ReadCSV reads rows of date (YYYY-MM-DD), base, target and rate, grouped into days ordered by
+// date and base. Nothing is returned unless every row is valid.
+func ReadCSV(r io.Reader) ([]Day, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(CSVHeader)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	type dayKey struct {
+		date time.Time
+		base domain.Currency
+	}
+	days := make(map[dayKey]map[domain.Currency]float64)
+	var problems []string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			problems = append(problems, fmt.Sprintf("line %d: %v", parseErr.StartLine, parseErr.Err))
+		} else if line, _ := reader.FieldPos(0); line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), CSVHeader[0]) {
+			continue
+		} else if day, base, target, rate, problem := parseCSVRecord(record); problem != "" {
+			problems = append(problems, fmt.Sprintf("line %d: %s", line, problem))
+		} else {
+			key := dayKey{date: day, base: base}
+			if days[key] == nil {
+				days[key] = map[domain.Currency]float64{base: 1}
+			}
+			days[key][target] = rate
+		}
+		if len(problems) == maxCSVProblems {
+			problems = append(problems, "giving up after "+strconv.Itoa(maxCSVProblems)+" bad rows")
+			break
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &CSVError{Problems: problems}
+	}
+
+	result := make([]Day, 0, len(days))
+	for key, rates := range days {
+		result = append(result, Day{Date: key.date, Base: key.base, Rates: rates})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Date.Equal(result[j].Date) {
+			return result[i].Date.Before(result[j].Date)
+		}
+		return result[i].Base < result[j].Base
+	})
+	return result, nil
+}
+
+func parseCSVRecord(record []string) (date time.Time, base, target domain.Currency, rate float64, problem string) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+	if err != nil {
+		return date, "", "", 0, fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", record[0])
+	}
+	base = domain.Currency(strings.ToUpper(strings.TrimSpace(record[1])))
+	target = domain.Currency(strings.ToUpper(strings.TrimSpace(record[2])))
+	for _, currency := range []domain.Currency{base, target} {
+		if !currency.WellFormed() {
+			return date, "", "", 0, fmt.Sprintf("%q is not a currency code of 3 to 5 letters", currency)
+		}
+	}
+	if base == target {
+		return date, "", "", 0, "base and target are both " + string(base)
+	}
+	rate, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return date, "", "", 0, fmt.Sprintf("rate %q is not a positive number", record[3])
+	}
+	return date, base, target, rate, ""
+}
+
+// DayCache is the part of the rate cache imports write to.
+type DayCache interface {
+	GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool)
+	SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64)
+}
+
+// Import saves days to store, and updates the days cache already holds so it doesn't keep
+// serving the old rates. Days cache doesn't hold are left out of it: a file may only have
+// some targets of a day, and a cached day is taken to have them all.
+func Import(ctx context.Context, store Store, cache DayCache, days []Day) error {
+	for _, day := range days {
+		if err := store.SaveDay(ctx, day.Date, day.Base, day.Rates); err != nil {
+			return err
+		}
+		if cache == nil {
+			continue
+		}
+		cached, ok := cache.GetHistoricalRates(day.Date, day.Base)
+		if !ok {
+			continue
+		}
+		merged := make(map[domain.Currency]float64, len(cached)+len(day.Rates))
+		for currency, rate := range cached {
+			merged[currency] = rate
+		}
+		for currency, rate := range day.Rates {
+			merged[currency] = rate
+		}
+		cache.SetHistoricalRates(day.Date, day.Base, merged)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	days, err := ReadCSV(strings.NewReader("date,base,target,rate\n" +
+		"2001-01-03,USD,INR,46.8\n" +
+		"2001-01-02, usd, inr, 46.7\n" +
+		"2001-01-02,USD,EUR,1.06\n" +
+		"2001-01-02,EUR,INR,44.1\n"))
+	assert.NoError(t, err)
+	jan2 := time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []Day{
+		{Date: jan2, Base: "EUR", Rates: map[domain.Currency]float64{"EUR": 1, "INR": 44.1}},
+		{Date: jan2, Base: "USD", Rates: map[domain.Currency]float64{"USD": 1, "INR": 46.7, "EUR": 1.06}},
+		{Date: jan2.AddDate(0, 0, 1), Base: "USD", Rates: map[domain.Currency]float64{"USD": 1, "INR": 46.8}},
+	}, days)
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("2001-01-02,USD,INR,46.7\n" +
+		"2001/01/02,USD,INR,46.7\n" +
+		"2001-01-02,USD,USD,1\n" +
+		"2001-01-02,USD,INR,-1\n" +
+		"2001-01-02,USD\n"))
+	var csvErr *CSVError
+	assert.True(t, errors.As(err, &csvErr))
+	assert.Equal(t, []string{
+		`line 2: invalid date "2001/01/02", expected YYYY-MM-DD`,
+		"line 3: base and target are both USD",
+		`line 4: rate "-1" is not a positive number`,
+		"line 5: wrong number of fields",
+	}, csvErr.Problems)
+}
+
+type mapStore map[string]map[domain.Currency]float64
+
+func (s mapStore) SaveDay(ctx context.Context, date time.Time, base domain.Currency, rates map[domain.Currency]float64) error {
+	s[date.Format("2006-01-02")+"/"+string(base)] = rates
+	return nil
+}
+
+func (s mapStore) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	return nil, nil
+}
+
+func (s mapStore) Export(ctx context.Context, filter Filter, fn func(Row) error) error {
+	return nil
+}
+
+func (s mapStore) Close() error { return nil }
+
+type mapCache map[string]map[domain.Currency]float64
+
+func (c mapCache) GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool) {
+	rates, ok := c[date.Format("2006-01-02")+"/"+string(base)]
+	return rates, ok
+}
+
+func (c mapCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	c[date.Format("2006-01-02")+"/"+string(base)] = rates
+}
+
+func TestImport(t *testing.T) {
+	jan2 := time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC)
+	store := mapStore{}
+	cache := mapCache{"2001-01-02/USD": {"USD": 1, "INR": 46.5, "GBP": 0.67}}
+
+	err := Import(context.Background(), store, cache, []Day{
+		{Date: jan2, Base: "USD", Rates: map[domain.Currency]float64{"USD": 1, "INR": 46.7}},
+		{Date: jan2.AddDate(0, 0, 1), Base: "USD", Rates: map[domain.Currency]float64{"USD": 1, "INR": 46.8}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, store, 2)
+	// The cached day is updated; the uncached one stays out of the cache.
+	assert.Equal(t, mapCache{"2001-01-02/USD": {"USD": 1, "INR": 46.7, "GBP": 0.67}}, cache)
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Export formats.
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// RowWriter writes exported rows in one file format. Nothing is complete until Close.
+type RowWriter interface {
+	Write(row Row) error
+	Close() error
+}
+
+// NewRowWriter returns a writer of format to w: FormatCSV, rows with the CSVHeader that
+// ReadCSV reads back, or FormatParquet.
+func NewRowWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(CSVHeader); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{writer: writer, record: make([]string, len(CSVHeader))}, nil
+	case FormatParquet:
+		return &parquetRowWriter{writer: parquet.NewGenericWriter[parquetRow](w)}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, expected %s or %s", format, FormatCSV, FormatParquet)
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvRowWriter) Write(row Row) error {
+	w.record[0] = row.Date.Format("2006-01-02")
+	w.record[1] = string(row.Base)
+	w.record[2] = string(row.Target)
+	w.record[3] = strconv.FormatFloat(row.Rate, 'g', -1, 64)
+	return w.writer.Write(w.record)
+}
+
+func (w *csvRowWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// parquetRow is the schema of Parquet exports.
+type parquetRow struct {
+	Date   int32   `parquet:"date,date"` // days since 1970-01-01
+	Base   string  `parquet:"base,dict"`
+	Target string  `parquet:"target,dict"`
+	Rate   float64 `parquet:"rate"`
+}
+
+type parquetRowWriter struct {
+	writer *parquet.GenericWriter[parquetRow]
+	buffer []parquetRow
+}
+
+// parquetBatchRows is how many rows are buffered before being handed to the Parquet writer.
+const parquetBatchRows = 1024
+
+func (w *parquetRowWriter) Write(row Row) error {
+	w.buffer = append(w.buffer, parquetRow{
+		Date:   int32(row.Date.Unix() / int64(24*time.Hour/time.Second)),
+		Base:   string(row.Base),
+		Target: string(row.Target),
+		Rate:   row.Rate,
+	})
+	if len(w.buffer) < parquetBatchRows {
+		return nil
+	}
+	return w.flush()
+}
+
+func (w *parquetRowWriter) flush() error {
+	_, err := w.writer.Write(w.buffer)
+	w.buffer = w.buffer[:0]
+	return err
+}
+
+func (w *parquetRowWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.writer.Close()
+}
@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var exportRows = []Row{
+	{Date: time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC), Base: "USD", Target: "EUR", Rate: 1.06},
+	{Date: time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC), Base: "USD", Target: "INR", Rate: 46.7},
+	{Date: time.Date(2001, 1, 3, 0, 0, 0, 0, time.UTC), Base: "USD", Target: "INR", Rate: 46.8},
+}
+
+func writeRows(t *testing.T, format string) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	writer, err := NewRowWriter(format, &out)
+	assert.NoError(t, err)
+	for _, row := range exportRows {
+		assert.NoError(t, writer.Write(row))
+	}
+	assert.NoError(t, writer.Close())
+	return out.Bytes()
+}
+
+func TestRowWriter_CSV(t *testing.T) {
+	out := writeRows(t, FormatCSV)
+	assert.Equal(t, "date,base,target,rate\n2001-01-02,USD,EUR,1.06\n2001-01-02,USD,INR,46.7\n2001-01-03,USD,INR,46.8\n", string(out))
+
+	// Exports can be imported again.
+	days, err := ReadCSV(bytes.NewReader(out))
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.Currency]float64{"USD": 1, "EUR": 1.06, "INR": 46.7}, days[0].Rates)
+}
+
+func TestRowWriter_Parquet(t *testing.T) {
+	out := writeRows(t, FormatParquet)
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(out), int64(len(out)))
+	assert.NoError(t, err)
+	assert.Equal(t, []parquetRow{
+		{Date: 11324, Base: "USD", Target: "EUR", Rate: 1.06},
+		{Date: 11324, Base: "USD", Target: "INR", Rate: 46.7},
+		{Date: 11325, Base: "USD", Target: "INR", Rate: 46.8},
+	}, rows)
+}
+
+func TestNewRowWriter_UnknownFormat(t *testing.T) {
+	_, err := NewRowWriter("xlsx", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown export format "xlsx", expected csv or parquet`)
+}
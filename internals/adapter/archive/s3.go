@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options locate an S3-compatible bucket: AWS S3, MinIO, Cloudflare R2 and the like.
+type S3Options struct {
+	Endpoint  string // host[:port], e.g. s3.amazonaws.com
+	Region    string // looked up from the bucket when empty
+	Bucket    string
+	AccessKey string // with SecretKey; both empty to use the AWS_* environment or instance role
+	SecretKey string
+	Insecure  bool // plain HTTP
+}
+
+// S3Bucket is a Bucket in S3-compatible object storage.
+type S3Bucket struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Bucket returns the bucket opts locates. It doesn't check that the bucket exists.
+func NewS3Bucket(opts S3Options) (*S3Bucket, error) {
+	creds := credentials.NewChainCredentials([]credentials.Provider{&credentials.EnvAWS{}, &credentials.IAM{}})
+	if opts.AccessKey != "" {
+		creds = credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, "")
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{Creds: creds, Secure: !opts.Insecure, Region: opts.Region})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the S3 client: %w", err)
+	}
+	return &S3Bucket{client: client, bucket: opts.Bucket}, nil
+}
+
+func (b *S3Bucket) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := b.client.PutObject(ctx, b.bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (b *S3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := b.client.GetObject(ctx, b.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject only sends the request once the object is read or stat'ed.
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 serves path-style object requests from objects, keyed by path. Uploads, which
+// minio-go sends with chunk signatures over plain HTTP, are stored as sent.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+	headers map[string]http.Header
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodPut {
+		body, _ := io.ReadAll(r.Body)
+		s.objects[r.URL.Path] = string(body)
+		s.headers[r.URL.Path] = r.Header
+		w.Header().Set("ETag", `"etag"`)
+		return
+	}
+	body, ok := s.objects[r.URL.Path]
+	if !ok {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+		return
+	}
+	w.Header().Set("ETag", `"etag"`)
+	w.Header().Set("Last-Modified", "Mon, 14 Apr 2025 10:00:00 GMT")
+	w.Write([]byte(body))
+}
+
+func TestS3Bucket(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{"/backups/rates/2025/04/13.csv.gz": "snapshot"}, headers: map[string]http.Header{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	bucket, err := NewS3Bucket(S3Options{
+		Endpoint: strings.TrimPrefix(server.URL, "http://"), Region: "us-east-1", Bucket: "backups",
+		AccessKey: "key", SecretKey: "secret", Insecure: true,
+	})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, bucket.Put(ctx, "rates/2025/04/14.csv.gz", strings.NewReader("snapshot"), 8, "application/gzip"))
+	put := fake.headers["/backups/rates/2025/04/14.csv.gz"]
+	assert.Equal(t, "application/gzip", put.Get("Content-Type"))
+	assert.Equal(t, "8", put.Get("X-Amz-Decoded-Content-Length"))
+	assert.Contains(t, fake.objects["/backups/rates/2025/04/14.csv.gz"], "\r\nsnapshot\r\n")
+
+	body, err := bucket.Get(ctx, "rates/2025/04/13.csv.gz")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "snapshot", string(data))
+
+	_, err = bucket.Get(ctx, "rates/2025/04/15.csv.gz")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
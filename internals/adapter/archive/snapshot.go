@@ -0,0 +1,119 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrObjectNotFound is returned by Bucket.Get for keys the bucket doesn't hold.
+var ErrObjectNotFound = errors.New("object not found")
+
+// Bucket is the object storage snapshots are kept in, such as an S3 bucket.
+type Bucket interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Snapshots keeps a copy of the archive off-site: the rates of each day are written to a
+// Bucket as a gzip-compressed CSV file, in the format ReadCSV reads, from which they can be
+// restored into a store.
+type Snapshots struct {
+	bucket Bucket
+	prefix string
+}
+
+func NewSnapshots(bucket Bucket, prefix string) *Snapshots {
+	return &Snapshots{bucket: bucket, prefix: prefix}
+}
+
+// Key returns the object key of the snapshot of day, e.g. "rates/2025/04/14.csv.gz".
+func (s *Snapshots) Key(date time.Time) string {
+	return s.prefix + day(date).Format("2006/01/02") + ".csv.gz"
+}
+
+// Write stores every rate store holds for date in its snapshot, replacing any earlier one,
+// and returns how many rows it holds. Days without rates are not written.
+func (s *Snapshots) Write(ctx context.Context, store Store, date time.Time) (int, error) {
+	var body bytes.Buffer
+	compressed := gzip.NewWriter(&body)
+	writer, err := NewRowWriter(FormatCSV, compressed)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	err = store.Export(ctx, Filter{Start: date, End: date}, func(row Row) error {
+		rows++
+		return writer.Write(row)
+	})
+	if err != nil || rows == 0 {
+		return 0, err
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	if err := compressed.Close(); err != nil {
+		return 0, err
+	}
+	key := s.Key(date)
+	if err := s.bucket.Put(ctx, key, &body, int64(body.Len()), "application/gzip"); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot %s: %w", key, err)
+	}
+	return rows, nil
+}
+
+// Restored reports what a Restore imported.
+type Restored struct {
+	Days int // days a snapshot was found for
+	Rows int
+}
+
+// Restore imports the snapshots of the days from start to end into store, updating the days
+// cache holds as Import does. Days without a snapshot are skipped. The days before a failing
+// one stay restored, and restoring them again is harmless.
+func (s *Snapshots) Restore(ctx context.Context, store Store, cache DayCache, start, end time.Time) (Restored, error) {
+	var restored Restored
+	for date := day(start); !date.After(end); date = date.AddDate(0, 0, 1) {
+		days, err := s.read(ctx, date)
+		if errors.Is(err, ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return restored, err
+		}
+		if err := Import(ctx, store, cache, days); err != nil {
+			return restored, err
+		}
+		restored.Days++
+		for _, imported := range days {
+			restored.Rows += len(imported.Rates) - 1 // ReadCSV adds the base's own rate of 1
+		}
+	}
+	return restored, nil
+}
+
+// read returns the rates in the snapshot of date.
+func (s *Snapshots) read(ctx context.Context, date time.Time) ([]Day, error) {
+	key := s.Key(date)
+	body, err := s.bucket.Get(ctx, key)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", key, err)
+	}
+	defer body.Close()
+	decompressed, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s is not gzip-compressed: %w", key, err)
+	}
+	days, err := ReadCSV(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", key, err)
+	}
+	return days, nil
+}
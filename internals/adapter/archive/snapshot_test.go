@@ -0,0 +1,73 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memBucket map[string][]byte
+
+func (b memBucket) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err == nil && int64(len(data)) != size {
+		return io.ErrShortWrite
+	}
+	b[key] = data
+	return err
+}
+
+func (b memBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestSnapshots_WriteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	source, err := NewSQLiteStore(ctx, filepath.Join(t.TempDir(), "source.db"))
+	assert.NoError(t, err)
+	defer source.Close()
+	monday := time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, source.SaveDay(ctx, monday, "USD", map[domain.Currency]float64{"USD": 1, "INR": 85.2, "EUR": 0.88}))
+	assert.NoError(t, source.SaveDay(ctx, monday, "EUR", map[domain.Currency]float64{"INR": 96.8}))
+	assert.NoError(t, source.SaveDay(ctx, monday.AddDate(0, 0, 2), "USD", map[domain.Currency]float64{"INR": 85.6}))
+
+	bucket := memBucket{}
+	snapshots := NewSnapshots(bucket, "rates/")
+	for date, want := range map[time.Time]int{monday: 3, monday.AddDate(0, 0, 1): 0, monday.AddDate(0, 0, 2): 1} {
+		rows, err := snapshots.Write(ctx, source, date)
+		assert.NoError(t, err)
+		assert.Equal(t, want, rows, date)
+	}
+	// Days without rates get no snapshot.
+	assert.Len(t, bucket, 2)
+	compressed, err := gzip.NewReader(bytes.NewReader(bucket["rates/2025/04/14.csv.gz"]))
+	assert.NoError(t, err)
+	csv, _ := io.ReadAll(compressed)
+	assert.Equal(t, "date,base,target,rate\n2025-04-14,EUR,INR,96.8\n2025-04-14,USD,EUR,0.88\n2025-04-14,USD,INR,85.2\n", string(csv))
+
+	restoredStore, err := NewSQLiteStore(ctx, filepath.Join(t.TempDir(), "restored.db"))
+	assert.NoError(t, err)
+	defer restoredStore.Close()
+	restored, err := snapshots.Restore(ctx, restoredStore, nil, monday.AddDate(0, 0, -1), monday.AddDate(0, 0, 6))
+	assert.NoError(t, err)
+	assert.Equal(t, Restored{Days: 2, Rows: 4}, restored)
+	rates, err := restoredStore.Range(ctx, monday, monday.AddDate(0, 0, 2), "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{monday: 85.2, monday.AddDate(0, 0, 2): 85.6}, rates)
+
+	bucket["rates/2025/04/19.csv.gz"] = []byte("date,base,target,rate\n")
+	_, err = snapshots.Restore(ctx, restoredStore, nil, monday, monday.AddDate(0, 0, 6))
+	assert.ErrorContains(t, err, "rates/2025/04/19.csv.gz is not gzip-compressed")
+}
@@ -0,0 +1,183 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS historical_rates (
+	day        TEXT NOT NULL, -- YYYY-MM-DD
+	base       TEXT NOT NULL,
+	target     TEXT NOT NULL,
+	rate       REAL NOT NULL,
+	fetched_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+	PRIMARY KEY (base, target, day)
+) WITHOUT ROWID`
+
+// SQLiteStore archives rates in a SQLite file, for single-node deployments without a
+// PostgreSQL server. It has the same historical_rates table as PostgresStore.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the database file at path, creating it and its directory if needed.
+func NewSQLiteStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the archive directory: %w", err)
+	}
+	// WAL lets requests read while the refresh writes; writers wait for each other rather
+	// than failing with SQLITE_BUSY.
+	pragmas := url.Values{"_pragma": {"journal_mode(WAL)", "busy_timeout(5000)", "synchronous(NORMAL)"}}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the archive database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the historical_rates table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) SaveDay(ctx context.Context, date time.Time, base domain.Currency, rates map[domain.Currency]float64) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO historical_rates (day, base, target, rate) VALUES (?, ?, ?, ?)
+			ON CONFLICT (base, target, day) DO UPDATE SET rate = excluded.rate, fetched_at = excluded.fetched_at`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for target, rate := range rates {
+			if _, err := stmt.ExecContext(ctx, date.Format("2006-01-02"), string(base), string(target), rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s rates for %s: %w", base, date.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT day, rate FROM historical_rates
+		WHERE base = ? AND target = ? AND day BETWEEN ? AND ?`,
+		string(base), string(target), start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived rates: %w", err)
+	}
+	defer rows.Close()
+	rates := make(map[time.Time]float64)
+	for rows.Next() {
+		var date string
+		var rate float64
+		if err := rows.Scan(&date, &rate); err != nil {
+			return nil, fmt.Errorf("failed to read archived rates: %w", err)
+		}
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("archived rates have an invalid day %q", date)
+		}
+		rates[parsed] = rate
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archived rates: %w", err)
+	}
+	return rates, nil
+}
+
+func (s *SQLiteStore) Export(ctx context.Context, filter Filter, fn func(Row) error) error {
+	where, args := filter.where(func(int) string { return "?" }, func(t time.Time) any { return t.Format("2006-01-02") })
+	rows, err := s.db.QueryContext(ctx, "SELECT day, base, target, rate FROM historical_rates WHERE "+where+" ORDER BY day, base, target", args...)
+	if err != nil {
+		return fmt.Errorf("failed to read archived rates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row Row
+		var date, base, target string
+		if err := rows.Scan(&date, &base, &target, &row.Rate); err != nil {
+			return fmt.Errorf("failed to read archived rates: %w", err)
+		}
+		if row.Date, err = time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("archived rates have an invalid day %q", date)
+		}
+		row.Base, row.Target = domain.Currency(base), domain.Currency(target)
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read archived rates: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Downsample(ctx context.Context, before time.Time) (Compaction, error) {
+	sizeBefore, err := s.size(ctx)
+	if err != nil {
+		return Compaction{}, fmt.Errorf("failed to downsample archived rates: %w", err)
+	}
+	// date(day, 'weekday 0', '+1 day') is the Monday after day's week.
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM historical_rates AS h
+		WHERE day < ? AND EXISTS (
+			SELECT 1 FROM historical_rates AS later
+			WHERE later.base = h.base AND later.target = h.target
+				AND later.day > h.day AND later.day < date(h.day, 'weekday 0', '+1 day'))`,
+		before.Format("2006-01-02"))
+	if err != nil {
+		return Compaction{}, fmt.Errorf("failed to downsample archived rates: %w", err)
+	}
+	var compaction Compaction
+	if compaction.Rows, err = result.RowsAffected(); err != nil || compaction.Rows == 0 {
+		return compaction, err
+	}
+	// Deleted rows only free pages inside the file; VACUUM gives them back.
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return compaction, fmt.Errorf("failed to vacuum the archive: %w", err)
+	}
+	sizeAfter, err := s.size(ctx)
+	if err != nil {
+		return compaction, fmt.Errorf("failed to downsample archived rates: %w", err)
+	}
+	compaction.Bytes = sizeBefore - sizeAfter
+	return compaction, nil
+}
+
+// size returns the size of the database file, WAL aside.
+func (s *SQLiteStore) size(ctx context.Context) (int64, error) {
+	var size int64
+	err := s.db.QueryRowContext(ctx, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&size)
+	return size, err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// inTx runs fn in a transaction, committing it when fn succeeds.
+func (s *SQLiteStore) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "archive", "rates.db")
+	store, err := NewSQLiteStore(ctx, path)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.SaveDay(ctx, monday, "USD", map[domain.Currency]float64{"INR": 72.1, "EUR": 0.9}))
+	assert.NoError(t, store.SaveDay(ctx, monday.AddDate(0, 0, 1), "USD", map[domain.Currency]float64{"INR": 72.5}))
+	// Saving a day again replaces its rates.
+	assert.NoError(t, store.SaveDay(ctx, monday, "USD", map[domain.Currency]float64{"INR": 72.2}))
+
+	rates, err := store.Range(ctx, monday.AddDate(0, 0, -3), monday.AddDate(0, 0, 3), "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{monday: 72.2, monday.AddDate(0, 0, 1): 72.5}, rates)
+
+	rates, err = store.Range(ctx, monday, monday, "USD", "GBP")
+	assert.NoError(t, err)
+	assert.Empty(t, rates)
+
+	// The archive outlives the process.
+	assert.NoError(t, store.Close())
+	store, err = NewSQLiteStore(ctx, path)
+	assert.NoError(t, err)
+	defer store.Close()
+	rates, err = store.Range(ctx, monday, monday, "USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{monday: 0.9}, rates)
+}
+
+func TestSQLiteStore_Export(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(ctx, filepath.Join(t.TempDir(), "rates.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	monday := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.SaveDay(ctx, monday, "USD", map[domain.Currency]float64{"INR": 72.1, "EUR": 0.9}))
+	assert.NoError(t, store.SaveDay(ctx, monday, "EUR", map[domain.Currency]float64{"EUR": 1, "INR": 80.1}))
+	assert.NoError(t, store.SaveDay(ctx, monday.AddDate(0, 0, 1), "USD", map[domain.Currency]float64{"INR": 72.5}))
+
+	export := func(filter Filter) []Row {
+		var rows []Row
+		assert.NoError(t, store.Export(ctx, filter, func(row Row) error {
+			rows = append(rows, row)
+			return nil
+		}))
+		return rows
+	}
+	assert.Equal(t, []Row{
+		{Date: monday, Base: "EUR", Target: "INR", Rate: 80.1},
+		{Date: monday, Base: "USD", Target: "EUR", Rate: 0.9},
+		{Date: monday, Base: "USD", Target: "INR", Rate: 72.1},
+		{Date: monday.AddDate(0, 0, 1), Base: "USD", Target: "INR", Rate: 72.5},
+	}, export(Filter{}))
+	assert.Equal(t, []Row{
+		{Date: monday.AddDate(0, 0, 1), Base: "USD", Target: "INR", Rate: 72.5},
+	}, export(Filter{Start: monday.AddDate(0, 0, 1), Base: "USD", Target: "INR"}))
+	assert.Empty(t, export(Filter{End: monday.AddDate(0, 0, -1)}))
+}
+
+func TestSQLiteStore_Downsample(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(ctx, filepath.Join(t.TempDir(), "rates.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	// Three weeks of weekdays, for enough targets that the deleted rows span several pages.
+	monday := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	rates := make(map[domain.Currency]float64)
+	for i := range 200 {
+		rates[domain.Currency(fmt.Sprintf("C%03d", i))] = float64(i)
+	}
+	for week := range 3 {
+		for weekday := range 5 {
+			assert.NoError(t, store.SaveDay(ctx, monday.AddDate(0, 0, 7*week+weekday), "USD", rates))
+		}
+	}
+
+	compaction, err := store.Downsample(ctx, monday.AddDate(0, 0, 14))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*4*200), compaction.Rows)
+	assert.Positive(t, compaction.Bytes)
+
+	kept, err := store.Range(ctx, monday, monday.AddDate(0, 0, 21), "USD", "C007")
+	assert.NoError(t, err)
+	friday := monday.AddDate(0, 0, 4)
+	assert.Equal(t, map[time.Time]float64{
+		// Only the Fridays of the first two weeks, the third is untouched.
+		friday: 7, friday.AddDate(0, 0, 7): 7,
+		monday.AddDate(0, 0, 14): 7, monday.AddDate(0, 0, 15): 7, monday.AddDate(0, 0, 16): 7, monday.AddDate(0, 0, 17): 7, monday.AddDate(0, 0, 18): 7,
+	}, kept)
+
+	// Nothing is left to thin out.
+	compaction, err = store.Downsample(ctx, monday.AddDate(0, 0, 14))
+	assert.NoError(t, err)
+	assert.Equal(t, Compaction{}, compaction)
+}
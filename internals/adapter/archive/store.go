@@ -0,0 +1,69 @@
+// Package archive keeps every daily rate the service fetches, permanently, so historical
+// queries older than what the cache holds and the provider serves can be answered from our
+// own copy.
+package archive
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+)
+
+// Store persists the rates of one base on one day, one row per target.
+type Store interface {
+	// SaveDay stores the rates of base on day, replacing what was stored for those targets.
+	SaveDay(ctx context.Context, day time.Time, base domain.Currency, rates map[domain.Currency]float64) error
+	// Range returns the rates from base to target stored for the days in [start, end], keyed
+	// by day at midnight UTC. Days never stored, e.g. weekends, are missing.
+	Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error)
+	// Export passes every stored row matching filter to fn, ordered by day, base and target,
+	// stopping at the first error fn returns.
+	Export(ctx context.Context, filter Filter, fn func(Row) error) error
+	Close() error
+}
+
+// Row is one stored rate.
+type Row struct {
+	Date   time.Time
+	Base   domain.Currency
+	Target domain.Currency
+	Rate   float64
+}
+
+// Filter selects rows to export. Zero fields match every row, except a base's own rate of 1,
+// which is never exported as ReadCSV rejects it.
+type Filter struct {
+	Start, End   time.Time // days, both included
+	Base, Target domain.Currency
+}
+
+// day truncates t to its date at midnight UTC, as days are keyed.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// where returns the SQL condition selecting the rows f matches and its arguments, with
+// placeholder writing the nth placeholder and dayValue the argument of a day.
+func (f Filter) where(placeholder func(n int) string, dayValue func(time.Time) any) (string, []any) {
+	conditions := []string{"base <> target"}
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" "+placeholder(len(args)))
+	}
+	if f.Base != "" {
+		add("base =", string(f.Base))
+	}
+	if f.Target != "" {
+		add("target =", string(f.Target))
+	}
+	if !f.Start.IsZero() {
+		add("day >=", dayValue(day(f.Start)))
+	}
+	if !f.End.IsZero() {
+		add("day <=", dayValue(day(f.End)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const streamKey = "admin_audit_log"
+
+// Entry records one admin operation.
+type Entry struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor"`
+	Action    string            `json:"action"`
+	Params    map[string]string `json:"params,omitempty"`
+	Status    int               `json:"status"`
+	IP        string            `json:"ip,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// RedisLog appends entries to a Redis stream, so every replica writes to and reads from the
+// same trail. Entries are never modified; once the stream holds more than maxEntries the
+// oldest are trimmed.
+type RedisLog struct {
+	client     *redis.Client
+	maxEntries int64
+}
+
+func NewRedisLog(client *redis.Client, maxEntries int64) *RedisLog {
+	return &RedisLog{client: client, maxEntries: maxEntries}
+}
+
+// Record appends entry. The stream assigns the ID; entry.ID is ignored.
+func (l *RedisLog) Record(ctx context.Context, entry Entry) error {
+	params, err := json.Marshal(entry.Params)
+	if err != nil {
+		return err
+	}
+	err = l.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: l.maxEntries,
+		Approx: true,
+		Values: map[string]any{
+			"time":      entry.Time.UTC().Format(time.RFC3339Nano),
+			"actor":     entry.Actor,
+			"action":    entry.Action,
+			"params":    params,
+			"status":    entry.Status,
+			"ip":        entry.IP,
+			"requestId": entry.RequestID,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit entries, newest first. A non-empty before returns the entries
+// recorded before the entry with that ID, for paging through older history.
+func (l *RedisLog) List(ctx context.Context, before string, limit int) ([]Entry, error) {
+	end := "+"
+	if before != "" {
+		end = "(" + before
+	}
+	messages, err := l.client.XRevRangeN(ctx, streamKey, end, "-", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, decode(message))
+	}
+	return entries, nil
+}
+
+// Prune removes the entries recorded before before, returning how many it removed. Entries
+// are ordered by when they were appended, which is what their stream IDs encode.
+func (l *RedisLog) Prune(ctx context.Context, before time.Time) (int64, error) {
+	removed, err := l.client.XTrimMinID(ctx, streamKey, strconv.FormatInt(before.UnixMilli(), 10)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	return removed, nil
+}
+
+func decode(message redis.XMessage) Entry {
+	field := func(name string) string {
+		value, _ := message.Values[name].(string)
+		return value
+	}
+	entry := Entry{
+		ID:        message.ID,
+		Actor:     field("actor"),
+		Action:    field("action"),
+		IP:        field("ip"),
+		RequestID: field("requestId"),
+	}
+	entry.Time, _ = time.Parse(time.RFC3339Nano, field("time"))
+	entry.Status, _ = strconv.Atoi(field("status"))
+	_ = json.Unmarshal([]byte(field("params")), &entry.Params)
+	return entry
+}
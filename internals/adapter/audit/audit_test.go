@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLog_RecordAndList(t *testing.T) {
+	mini := miniredis.RunT(t)
+	log := NewRedisLog(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 1000)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)
+
+	for _, action := range []string{"POST /admin/scheduler/pause", "POST /admin/scheduler/resume", "POST /admin/scheduler/pause"} {
+		assert.NoError(t, log.Record(ctx, Entry{
+			Time:      at,
+			Actor:     "admin",
+			Action:    action,
+			Params:    map[string]string{"reason": "maintenance"},
+			Status:    200,
+			IP:        "10.0.0.1",
+			RequestID: "req-1",
+		}))
+	}
+
+	entries, err := log.List(ctx, "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.NotEmpty(t, entries[0].ID)
+	assert.Equal(t, Entry{
+		ID:        entries[0].ID,
+		Time:      at,
+		Actor:     "admin",
+		Action:    "POST /admin/scheduler/pause",
+		Params:    map[string]string{"reason": "maintenance"},
+		Status:    200,
+		IP:        "10.0.0.1",
+		RequestID: "req-1",
+	}, entries[0])
+	assert.Equal(t, "POST /admin/scheduler/resume", entries[1].Action)
+
+	older, err := log.List(ctx, entries[1].ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, older, 1)
+	assert.Equal(t, "POST /admin/scheduler/pause", older[0].Action)
+}
+
+func TestRedisLog_ListEmpty(t *testing.T) {
+	mini := miniredis.RunT(t)
+	log := NewRedisLog(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 1000)
+
+	entries, err := log.List(context.Background(), "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRedisLog_Prune(t *testing.T) {
+	mini := miniredis.RunT(t)
+	log := NewRedisLog(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 1000)
+	ctx := context.Background()
+
+	recordedAt := func(at time.Time, action string) {
+		mini.SetTime(at)
+		assert.NoError(t, log.Record(ctx, Entry{Time: at, Actor: "admin", Action: action, Status: 200}))
+	}
+	monday := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+	recordedAt(monday, "POST /admin/keys")
+	recordedAt(monday.AddDate(0, 0, 1), "DELETE /admin/keys/a")
+	recordedAt(monday.AddDate(0, 0, 2), "POST /admin/scheduler/pause")
+
+	removed, err := log.Prune(ctx, monday.AddDate(0, 0, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+	entries, err := log.List(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "POST /admin/scheduler/pause", entries[0].Action)
+}
@@ -0,0 +1,145 @@
+// Package broker publishes refreshed rates to a message broker, NATS or MQTT, for
+// event-driven systems downstream that would otherwise poll the REST API.
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// connectTimeout bounds connecting to the broker at startup.
+	connectTimeout = 10 * time.Second
+	// closeTimeout bounds sending what is still queued on shutdown.
+	closeTimeout = 2 * time.Second
+)
+
+var errMQTTTimeout = errors.New("timed out connecting to the MQTT broker")
+
+// Message is what is published for each refresh of a base.
+type Message struct {
+	Base      domain.Currency             `json:"base"`
+	Rates     map[domain.Currency]float64 `json:"rates"`
+	Timestamp int64                       `json:"timestamp"`
+}
+
+// Publisher publishes every update it is told about to a subject or topic per base. Publishing
+// never blocks the refresh. Both clients reconnect by themselves after the broker goes away;
+// NATS buffers what is published meanwhile, MQTT drops it, and the next refresh supersedes it.
+type Publisher struct {
+	kind   string // "nats" or "mqtt", for logs
+	topic  func(base domain.Currency) string
+	send   func(topic string, data []byte) error
+	close  func()
+	logger *slog.Logger
+}
+
+// NewNATSPublisher connects to the NATS server at url and publishes each base's refreshes on
+// "<subject>.<BASE>", e.g. rates.USD, so consumers can subscribe to rates.> or to one base.
+func NewNATSPublisher(url, subject string, logger *slog.Logger) (*Publisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("currency-exchange"),
+		nats.Timeout(connectTimeout),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("Disconnected from NATS, buffering rate updates", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			logger.Info("Reconnected to NATS")
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		kind:  "nats",
+		topic: func(base domain.Currency) string { return subject + "." + string(base) },
+		send:  conn.Publish,
+		close: func() {
+			_ = conn.FlushTimeout(closeTimeout)
+			conn.Close()
+		},
+		logger: logger,
+	}, nil
+}
+
+// MQTTOptions configures an MQTT publisher.
+type MQTTOptions struct {
+	BrokerURL string // e.g. tcp://mosquitto:1883 or ssl://broker:8883
+	ClientID  string
+	Username  string
+	Password  string
+	Topic     string // prefix of the per-base topics
+	QoS       byte   // 0 or 1
+	// Retain has the broker keep each base's last message for clients that subscribe later,
+	// so they get the current rates straight away.
+	Retain bool
+}
+
+// NewMQTTPublisher connects to the MQTT broker and publishes each base's refreshes on
+// "<topic>/<BASE>", e.g. rates/USD, so consumers can subscribe to rates/# or to one base.
+func NewMQTTPublisher(opts MQTTOptions, logger *slog.Logger) (*Publisher, error) {
+	clientOpts := mqtt.NewClientOptions().
+		AddBroker(opts.BrokerURL).
+		SetClientID(opts.ClientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetConnectTimeout(connectTimeout).
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warn("Disconnected from MQTT broker, buffering rate updates", "error", err)
+		}).
+		SetOnConnectHandler(func(mqtt.Client) {
+			logger.Info("Connected to MQTT broker")
+		})
+	client := mqtt.NewClient(clientOpts)
+	token := client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return nil, errMQTTTimeout
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		kind:  "mqtt",
+		topic: func(base domain.Currency) string { return opts.Topic + "/" + string(base) },
+		send: func(topic string, data []byte) error {
+			token := client.Publish(topic, opts.QoS, opts.Retain, data)
+			go func() {
+				if token.WaitTimeout(connectTimeout) && token.Error() != nil {
+					logger.Warn("Failed to publish rate update", "broker", "mqtt", "topic", topic, "error", token.Error())
+				}
+			}()
+			return nil
+		},
+		close:  func() { client.Disconnect(uint(closeTimeout / time.Millisecond)) },
+		logger: logger,
+	}, nil
+}
+
+func (p *Publisher) Publish(update updates.Update) {
+	data, err := json.Marshal(Message{Base: update.Base, Rates: update.Rates, Timestamp: update.Timestamp.Unix()})
+	if err != nil {
+		p.logger.Error("Failed to encode rate update", "base", update.Base, "error", err)
+		return
+	}
+	topic := p.topic(update.Base)
+	if err := p.send(topic, data); err != nil {
+		p.logger.Warn("Failed to publish rate update", "broker", p.kind, "topic", topic, "error", err)
+	}
+}
+
+// Close flushes what can be flushed and disconnects, on shutdown.
+func (p *Publisher) Close() {
+	p.close()
+}
@@ -0,0 +1,90 @@
+package broker
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type published struct {
+	topic string
+	data  string
+}
+
+// fakeNATSServer speaks just enough of the NATS protocol to accept one client and report what
+// it publishes.
+func fakeNATSServer(t *testing.T) (string, <-chan published) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	messages := make(chan published, 8)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(`INFO {"server_id":"test","version":"2.10.0","max_payload":1048576}` + "\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case fields[0] == "PUB" && len(fields) == 3:
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				messages <- published{topic: fields[1], data: string(payload[:size])}
+			}
+		}
+	}()
+	return "nats://" + lis.Addr().String(), messages
+}
+
+func TestNATSPublisher_PublishesPerBase(t *testing.T) {
+	url, messages := fakeNATSServer(t)
+	publisher, err := NewNATSPublisher(url, "rates", discardLogger)
+	assert.NoError(t, err)
+	defer publisher.Close()
+
+	publisher.Publish(updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}, Timestamp: time.Unix(1744624800, 0)})
+
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "rates.USD", msg.topic)
+		assert.JSONEq(t, `{"base":"USD","rates":{"INR":85.2},"timestamp":1744624800}`, msg.data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was published")
+	}
+}
+
+func TestNewMQTTPublisher_FailsWithoutBroker(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	_, err = NewMQTTPublisher(MQTTOptions{BrokerURL: "tcp://" + addr, ClientID: "test", Topic: "rates"}, discardLogger)
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SweepReport counts the keys a Sweep looked at and deleted, by reason.
+type SweepReport struct {
+	Scanned         int `json:"scanned"`
+	UnsupportedBase int `json:"unsupportedBase"` // rates or bookkeeping for a currency that is no longer served
+	Undecodable     int `json:"undecodable"`     // values written in a format this version can't read
+	MissingTTL      int `json:"missingTTL"`      // entries that would otherwise never expire
+	OutOfRange      int `json:"outOfRange"`      // historical rates older than the history limit
+	StaleLocks      int `json:"staleLocks"`      // locks without a TTL, left behind by crashed instances
+}
+
+// Deleted returns the total number of keys removed.
+func (r SweepReport) Deleted() int {
+	return r.UnsupportedBase + r.Undecodable + r.MissingTTL + r.OutOfRange + r.StaleLocks
+}
+
+// Janitor removes cache keys that nothing will ever read or expire. Normal entries all carry
+// a TTL, so this only catches what slips through: keys for currencies that were disabled,
+// payloads from an older format, keys persisted by hand and locks that lost their TTL.
+type Janitor struct {
+	client      *redis.Client
+	logger      *slog.Logger
+	historyDays int
+	lockKeys    []string
+	now         func() time.Time
+}
+
+// NewJanitor builds a janitor. historyDays is how far back historical rates are still
+// served; lockKeys are the lock keys used outside this package, which are checked on top of
+// the cache's own write lock.
+func NewJanitor(client *redis.Client, logger *slog.Logger, historyDays int, lockKeys ...string) *Janitor {
+	return &Janitor{
+		client:      client,
+		logger:      logger,
+		historyDays: historyDays,
+		lockKeys:    append([]string{cacheWriteLockKey}, lockKeys...),
+		now:         time.Now,
+	}
+}
+
+// Sweep scans the cache once and deletes what it finds, returning what it did.
+func (j *Janitor) Sweep(ctx context.Context) (SweepReport, error) {
+	var report SweepReport
+
+	for _, key := range j.lockKeys {
+		ttl, err := j.client.PTTL(ctx, key).Result()
+		if err != nil {
+			return report, fmt.Errorf("failed to inspect lock %s: %w", key, err)
+		}
+		// PTTL reports -1 for a key that exists without an expiry.
+		if ttl == -1 {
+			if err := j.delete(ctx, key, "lock without TTL"); err != nil {
+				return report, err
+			}
+			report.StaleLocks++
+		}
+	}
+
+	checks := map[string]func(ctx context.Context, key string, report *SweepReport) error{
+		"latest:*":           j.checkLatest,
+		"historical:*":       j.checkHistorical,
+		"stale:latest:*":     j.checkBookkeeping,
+		"refresh_failures:*": j.checkBookkeeping,
+	}
+	for pattern, check := range checks {
+		iter := j.client.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			report.Scanned++
+			if err := check(ctx, iter.Val(), &report); err != nil {
+				return report, err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return report, fmt.Errorf("failed to scan %s: %w", pattern, err)
+		}
+	}
+
+	return report, nil
+}
+
+// checkLatest handles latest:<BASE>.
+func (j *Janitor) checkLatest(ctx context.Context, key string, report *SweepReport) error {
+	base := domain.Currency(strings.TrimPrefix(key, "latest:"))
+	if !base.IsSupported() {
+		report.UnsupportedBase++
+		return j.delete(ctx, key, "unsupported base")
+	}
+
+	return j.checkValue(ctx, key, report, func(data []byte) bool {
+		var value cachedLatestRatesData
+		return json.Unmarshal(data, &value) == nil && value.Rates != nil
+	})
+}
+
+// checkHistorical handles historical:<DATE>:<BASE>.
+func (j *Janitor) checkHistorical(ctx context.Context, key string, report *SweepReport) error {
+	parts := strings.Split(strings.TrimPrefix(key, "historical:"), ":")
+	if len(parts) != 2 {
+		report.Undecodable++
+		return j.delete(ctx, key, "unexpected key format")
+	}
+	date, err := time.Parse("2006-01-02", parts[0])
+	if err != nil {
+		report.Undecodable++
+		return j.delete(ctx, key, "unexpected key format")
+	}
+	if !domain.Currency(parts[1]).IsSupported() {
+		report.UnsupportedBase++
+		return j.delete(ctx, key, "unsupported base")
+	}
+	if j.historyDays > 0 && date.Before(j.now().UTC().AddDate(0, 0, -j.historyDays-1)) {
+		report.OutOfRange++
+		return j.delete(ctx, key, "older than the history limit")
+	}
+
+	return j.checkValue(ctx, key, report, func(data []byte) bool {
+		var rates map[domain.Currency]float64
+		return json.Unmarshal(data, &rates) == nil && rates != nil
+	})
+}
+
+// checkBookkeeping handles the per-base keys the scheduler keeps, which only matter for
+// currencies that are still served.
+func (j *Janitor) checkBookkeeping(ctx context.Context, key string, report *SweepReport) error {
+	base := domain.Currency(key[strings.LastIndex(key, ":")+1:])
+	if base.IsSupported() {
+		return nil
+	}
+	report.UnsupportedBase++
+	return j.delete(ctx, key, "unsupported base")
+}
+
+// checkValue deletes key when it has no TTL or when valid rejects its value.
+func (j *Janitor) checkValue(ctx context.Context, key string, report *SweepReport, valid func(data []byte) bool) error {
+	ttl, err := j.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", key, err)
+	}
+	if ttl == -1 {
+		report.MissingTTL++
+		return j.delete(ctx, key, "no TTL")
+	}
+
+	data, err := j.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil // expired since the scan
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if !valid(data) {
+		report.Undecodable++
+		return j.delete(ctx, key, "undecodable value")
+	}
+	return nil
+}
+
+func (j *Janitor) delete(ctx context.Context, key, reason string) error {
+	if err := j.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	j.logger.Info("Cache hygiene deleted key", "key", key, "reason", reason)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJanitor_Sweep(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	janitor := NewJanitor(client, discardLogger, 90, "refresh_lock", "other_lock")
+	janitor.now = func() time.Time { return time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC) }
+
+	keep := map[string]string{
+		"latest:USD":                `{"rates":{"INR":82.5},"timestamp":"2024-05-07T10:00:00Z"}`,
+		"historical:2024-05-06:USD": `{"INR":82.5}`,
+		"stale:latest:USD":          "1",
+		"refresh_failures:EUR":      "2",
+	}
+	for key, value := range keep {
+		mini.Set(key, value)
+		mini.SetTTL(key, time.Hour)
+	}
+	// A held lock with a TTL belongs to a live instance.
+	mini.Set("other_lock", "owner")
+	mini.SetTTL("other_lock", time.Minute)
+
+	remove := map[string]string{
+		"latest:ZZZ":                `{"rates":{"INR":1},"timestamp":"2024-05-07T10:00:00Z"}`,
+		"latest:EUR":                `[1,2,3]`,
+		"historical:2024-01-01:USD": `{"INR":82.5}`,
+		"historical:not-a-date:USD": `{"INR":82.5}`,
+		"refresh_failures:ZZZ":      "5",
+	}
+	for key, value := range remove {
+		mini.Set(key, value)
+		mini.SetTTL(key, time.Hour)
+	}
+	mini.Set("latest:INR", `{"rates":{"USD":0.012},"timestamp":"2024-05-07T10:00:00Z"}`) // no TTL
+	mini.Set("refresh_lock", "crashed-owner")                                            // no TTL
+
+	report, err := janitor.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, SweepReport{
+		Scanned:         10,
+		UnsupportedBase: 2,
+		Undecodable:     2,
+		MissingTTL:      1,
+		OutOfRange:      1,
+		StaleLocks:      1,
+	}, report)
+	assert.Equal(t, 7, report.Deleted())
+	for key := range keep {
+		assert.True(t, mini.Exists(key), key)
+	}
+	assert.True(t, mini.Exists("other_lock"))
+	for key := range remove {
+		assert.False(t, mini.Exists(key), key)
+	}
+	assert.False(t, mini.Exists("latest:INR"))
+	assert.False(t, mini.Exists("refresh_lock"))
+}
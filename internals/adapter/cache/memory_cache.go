@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/metrics"
+)
+
+// memoryTier labels the in-process cache's lookups in the cache metrics.
+const memoryTier = "memory"
+
+type memoryEntry struct {
+	rates      map[domain.Currency]float64
+	timestamp  time.Time
+	writtenAt  time.Time
+	expiresAt  time.Time
+	provenance *Provenance
+}
+
+type memoryCache struct {
+	mu                sync.Mutex
+	entries           map[string]memoryEntry
+	stale             map[domain.Currency]bool
+	latestRateTTL     time.Duration
+	historicalRateTTL time.Duration
+	now               func() time.Time
+}
+
+// NewMemoryCache builds the cache used with CACHE_BACKEND=memory. It keeps entries in the
+// process, so they are lost on restart and not shared between instances. Expired entries are
+// dropped when they are next read or overwritten.
+func NewMemoryCache(latestTTL, historicalTTL time.Duration) Cache {
+	return &memoryCache{
+		entries:           make(map[string]memoryEntry),
+		stale:             make(map[domain.Currency]bool),
+		latestRateTTL:     latestTTL,
+		historicalRateTTL: historicalTTL,
+		now:               time.Now,
+	}
+}
+
+func (mc *memoryCache) SetLatestRates(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time) {
+	mc.set(latestRatesKey(base), rates, timestamp, mc.latestRateTTL, nil)
+}
+
+func (mc *memoryCache) SetLatestRatesWithProvenance(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time, provenance Provenance) {
+	mc.set(latestRatesKey(base), rates, timestamp, mc.latestRateTTL, &provenance)
+}
+
+func (mc *memoryCache) GetLatestRates(base domain.Currency) (map[domain.Currency]float64, time.Time, bool) {
+	entry, ok := mc.get(latestRatesKey(base))
+	if !ok {
+		metrics.ObserveCacheLookup(memoryTier, "latest", base, "miss")
+		return nil, time.Time{}, false
+	}
+	metrics.ObserveCacheLookup(memoryTier, "latest", base, "hit")
+	return entry.rates, entry.timestamp, true
+}
+
+func (mc *memoryCache) LatestRatesAge(base domain.Currency) (time.Duration, bool) {
+	entry, ok := mc.get(latestRatesKey(base))
+	if !ok {
+		return 0, false
+	}
+	return mc.now().Sub(entry.writtenAt), true
+}
+
+// MarkLatestRatesStale sets or clears the stale flag of base. Like the Redis flag it doesn't
+// expire.
+func (mc *memoryCache) MarkLatestRatesStale(base domain.Currency, stale bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if stale {
+		mc.stale[base] = true
+	} else {
+		delete(mc.stale, base)
+	}
+}
+
+func (mc *memoryCache) LatestRatesStale(base domain.Currency) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.stale[base]
+}
+
+func (mc *memoryCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	mc.set(historicalRatesKey(date, base), rates, time.Time{}, mc.historicalRateTTL, nil)
+}
+
+func (mc *memoryCache) SetHistoricalRatesWithProvenance(date time.Time, base domain.Currency, rates map[domain.Currency]float64, provenance Provenance) {
+	mc.set(historicalRatesKey(date, base), rates, time.Time{}, mc.historicalRateTTL, &provenance)
+}
+
+func (mc *memoryCache) GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool) {
+	entry, ok := mc.get(historicalRatesKey(date, base))
+	if !ok {
+		metrics.ObserveCacheLookup(memoryTier, "historical", base, "miss")
+		return nil, false
+	}
+	metrics.ObserveCacheLookup(memoryTier, "historical", base, "hit")
+	return entry.rates, true
+}
+
+func (mc *memoryCache) LatestRatesProvenance(base domain.Currency) (Provenance, bool) {
+	return mc.provenance(latestRatesKey(base))
+}
+
+func (mc *memoryCache) HistoricalRatesProvenance(date time.Time, base domain.Currency) (Provenance, bool) {
+	return mc.provenance(historicalRatesKey(date, base))
+}
+
+func (mc *memoryCache) provenance(key string) (Provenance, bool) {
+	entry, ok := mc.get(key)
+	if !ok || entry.provenance == nil {
+		return Provenance{}, false
+	}
+	return *entry.provenance, true
+}
+
+// set stores a copy of rates, so later changes by the caller don't reach the cache, the same
+// as with the Redis cache that stores them serialized.
+func (mc *memoryCache) set(key string, rates map[domain.Currency]float64, timestamp time.Time, ttl time.Duration, provenance *Provenance) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	now := mc.now()
+	mc.entries[key] = memoryEntry{
+		rates:      maps.Clone(rates),
+		timestamp:  timestamp,
+		writtenAt:  now,
+		expiresAt:  now.Add(ttl),
+		provenance: provenance,
+	}
+}
+
+// get returns the entry under key with a copy of its rates, dropping it when it has expired.
+func (mc *memoryCache) get(key string) (memoryEntry, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	entry, ok := mc.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !mc.now().Before(entry.expiresAt) {
+		delete(mc.entries, key)
+		return memoryEntry{}, false
+	}
+	entry.rates = maps.Clone(entry.rates)
+	return entry, true
+}
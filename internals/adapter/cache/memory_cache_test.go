@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestMemoryCache(now *time.Time) *memoryCache {
+	mc := NewMemoryCache(time.Minute, time.Hour).(*memoryCache)
+	mc.now = func() time.Time { return *now }
+	return mc
+}
+
+func TestMemoryCache_LatestRates(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	mc := setupTestMemoryCache(&now)
+	rates := map[domain.Currency]float64{"INR": 82.5}
+
+	mc.SetLatestRates("USD", rates, now)
+	rates["INR"] = 1
+
+	got, timestamp, ok := mc.GetLatestRates("USD")
+	assert.True(t, ok)
+	assert.Equal(t, 82.5, got["INR"])
+	assert.Equal(t, now, timestamp)
+
+	now = now.Add(20 * time.Second)
+	age, ok := mc.LatestRatesAge("USD")
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, age)
+
+	now = now.Add(time.Minute)
+	_, _, ok = mc.GetLatestRates("USD")
+	assert.False(t, ok)
+	_, ok = mc.LatestRatesAge("USD")
+	assert.False(t, ok)
+}
+
+func TestMemoryCache_HistoricalRatesAndProvenance(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	mc := setupTestMemoryCache(&now)
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	provenance := Provenance{RunID: "run-1", WrittenAt: now}
+
+	mc.SetHistoricalRatesWithProvenance(date, "USD", map[domain.Currency]float64{"EUR": 0.9}, provenance)
+	got, ok := mc.GetHistoricalRates(date, "USD")
+	assert.True(t, ok)
+	assert.Equal(t, 0.9, got["EUR"])
+	gotProvenance, ok := mc.HistoricalRatesProvenance(date, "USD")
+	assert.True(t, ok)
+	assert.Equal(t, provenance, gotProvenance)
+
+	// A plain write replaces the entry and with it the provenance.
+	mc.SetHistoricalRates(date, "USD", map[domain.Currency]float64{"EUR": 0.95})
+	_, ok = mc.HistoricalRatesProvenance(date, "USD")
+	assert.False(t, ok)
+}
+
+func TestMemoryCache_StaleFlag(t *testing.T) {
+	now := time.Now()
+	mc := setupTestMemoryCache(&now)
+
+	assert.False(t, mc.LatestRatesStale("USD"))
+	mc.MarkLatestRatesStale("USD", true)
+	assert.True(t, mc.LatestRatesStale("USD"))
+	mc.MarkLatestRatesStale("USD", false)
+	assert.False(t, mc.LatestRatesStale("USD"))
+}
@@ -3,10 +3,11 @@ package cache
 import (
 	"context"
 	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/metrics"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -19,50 +20,120 @@ type Cache interface {
 	GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool)
 }
 
+// FreshnessReporter is implemented by caches that can tell how long ago a base's latest
+// rates were written, letting the scheduler skip bases another replica just refreshed.
+type FreshnessReporter interface {
+	LatestRatesAge(base domain.Currency) (time.Duration, bool)
+}
+
+// StalenessTracker is implemented by caches that can flag a base's latest rates as stale,
+// which the scheduler does when refreshes for that base keep failing.
+type StalenessTracker interface {
+	MarkLatestRatesStale(base domain.Currency, stale bool)
+	LatestRatesStale(base domain.Currency) bool
+}
+
+// Provenance records which background refresh run wrote a cache entry.
+type Provenance struct {
+	RunID     string    `json:"runId"`
+	WrittenAt time.Time `json:"writtenAt"`
+}
+
+// ProvenanceRecorder is implemented by caches that can keep the provenance of an entry next
+// to it, so a bad rate can be traced back to the refresh run that cached it. Entries written
+// through the plain Cache methods, e.g. on a request's cache miss, have no provenance.
+type ProvenanceRecorder interface {
+	SetLatestRatesWithProvenance(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time, provenance Provenance)
+	SetHistoricalRatesWithProvenance(date time.Time, base domain.Currency, rates map[domain.Currency]float64, provenance Provenance)
+	LatestRatesProvenance(base domain.Currency) (Provenance, bool)
+	HistoricalRatesProvenance(date time.Time, base domain.Currency) (Provenance, bool)
+}
+
 type redisCache struct {
 	client            *redis.Client
 	latestRateTTL     time.Duration
 	historicalRateTTL time.Duration
+	supervisor        *RedisSupervisor
+	logger            *slog.Logger
 }
 
-func NewRedisCache(client *redis.Client, latestTTL, historicalTTL time.Duration) Cache {
+// NewRedisCache builds the Redis backed cache. supervisor may be nil, in which case
+// every call goes straight to Redis.
+func NewRedisCache(client *redis.Client, latestTTL, historicalTTL time.Duration, supervisor *RedisSupervisor, logger *slog.Logger) Cache {
 	return &redisCache{
 		client:            client,
 		latestRateTTL:     latestTTL,
 		historicalRateTTL: historicalTTL,
+		supervisor:        supervisor,
+		logger:            logger,
+	}
+}
+
+// available lets cache calls fail fast while the supervisor reports Redis as down.
+func (rc *redisCache) available(op string) bool {
+	if rc.supervisor == nil || rc.supervisor.Available() {
+		return true
 	}
+	rc.logger.Warn("Skipping cache call", "op", op, "error", ErrRedisUnavailable)
+	return false
 }
 
+const cacheWriteLockKey = "cache_write_lock"
+
+// cacheTier labels this cache's lookups in the cache metrics.
+const cacheTier = "redis"
+
 func latestRatesKey(base domain.Currency) string {
 	return fmt.Sprintf("latest:%s", base)
 }
 
+func staleLatestRatesKey(base domain.Currency) string {
+	return fmt.Sprintf("stale:latest:%s", base)
+}
+
 func historicalRatesKey(date time.Time, base domain.Currency) string {
 	return fmt.Sprintf("historical:%s:%s", date.Format("2006-01-02"), base)
 }
 
+// provenanceKey holds the provenance of the entry stored under key.
+func provenanceKey(key string) string {
+	return "provenance:" + key
+}
+
 type cachedLatestRatesData struct {
 	Rates     map[domain.Currency]float64 `json:"rates"`
 	Timestamp time.Time                   `json:"timestamp"`
 }
 
 func (rc *redisCache) SetLatestRates(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time) {
-	lock := NewRedisLock(rc.client, "cache_write_lock", 30*time.Second)
+	rc.setLatestRates(base, rates, timestamp, nil)
+}
+
+func (rc *redisCache) SetLatestRatesWithProvenance(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time, provenance Provenance) {
+	rc.setLatestRates(base, rates, timestamp, &provenance)
+}
+
+func (rc *redisCache) setLatestRates(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time, provenance *Provenance) {
+	if !rc.available("SetLatestRates") {
+		return
+	}
+
+	lock := NewRedisLock(rc.client, cacheWriteLockKey, 30*time.Second, rc.logger)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second) // max wait 10s to acquire lock
 	defer cancel()
 
 	acquired, err := lock.Acquire(ctx, 10*time.Second)
 	if err != nil {
-		log.Printf("Error acquiring lock for SetLatestRates: %v", err)
+		rc.logger.Error("Error acquiring lock for SetLatestRates", "error", err)
 		return
 	}
 	if !acquired {
-		log.Println("Could not acquire lock for SetLatestRates after waiting")
+		rc.logger.Warn("Could not acquire lock for SetLatestRates after waiting")
 		return
 	}
 	defer func() {
 		if err := lock.Release(context.Background()); err != nil {
-			log.Printf("Error releasing lock for SetLatestRates: %v", err)
+			rc.logger.Error("Error releasing lock for SetLatestRates", "error", err)
 		}
 	}()
 
@@ -74,19 +145,24 @@ func (rc *redisCache) SetLatestRates(base domain.Currency, rates map[domain.Curr
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error marshaling latest rates: %v", err)
+		rc.logger.Error("Error marshaling latest rates", "base", base, "error", err)
 		return
 	}
 
-	err = rc.client.Set(ctx, key, jsonData, rc.latestRateTTL).Err()
+	err = rc.set(ctx, key, jsonData, rc.latestRateTTL, provenance)
 	if err != nil {
-		log.Printf("Error setting latest rates in Redis: %v", err)
+		rc.logger.Error("Error setting latest rates in Redis", "base", base, "error", err)
 	} else {
-		log.Printf("Cached latest rates for %s in Redis with TTL %s", base, rc.latestRateTTL)
+		rc.logger.Debug("Cached latest rates", "base", base, "ttl", rc.latestRateTTL)
 	}
 }
 
 func (rc *redisCache) GetLatestRates(base domain.Currency) (map[domain.Currency]float64, time.Time, bool) {
+	if !rc.available("GetLatestRates") {
+		metrics.ObserveCacheLookup(cacheTier, "latest", base, "unavailable")
+		return nil, time.Time{}, false
+	}
+
 	key := latestRatesKey(base)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -95,41 +171,120 @@ func (rc *redisCache) GetLatestRates(base domain.Currency) (map[domain.Currency]
 	jsonData, err := rc.client.Get(ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			log.Printf("Cache miss for key %s", key)
+			rc.logger.Debug("Cache miss", "key", key)
+			metrics.ObserveCacheLookup(cacheTier, "latest", base, "miss")
 			return nil, time.Time{}, false
 		}
-		log.Printf("Error getting latest rates from Redis: %v", err)
+		rc.logger.Error("Error getting latest rates from Redis", "base", base, "error", err)
+		metrics.ObserveCacheLookup(cacheTier, "latest", base, "error")
 		return nil, time.Time{}, false
 	}
 
 	var data cachedLatestRatesData
 	err = json.Unmarshal([]byte(jsonData), &data)
 	if err != nil {
-		log.Printf("Error unmarshaling latest rates JSON: %v", err)
+		rc.logger.Error("Error unmarshaling latest rates JSON", "base", base, "error", err)
+		metrics.ObserveCacheLookup(cacheTier, "latest", base, "error")
 		return nil, time.Time{}, false
 	}
 
-	log.Printf("Cache hit for key %s", key)
+	rc.logger.Debug("Cache hit", "key", key)
+	metrics.ObserveCacheLookup(cacheTier, "latest", base, "hit")
 	return data.Rates, data.Timestamp, true
 }
 
+// LatestRatesAge derives the age of the cached latest rates for base from the key's
+// remaining TTL, so it also works for entries written before this was added.
+func (rc *redisCache) LatestRatesAge(base domain.Currency) (time.Duration, bool) {
+	if !rc.available("LatestRatesAge") {
+		return 0, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	remaining, err := rc.client.PTTL(ctx, latestRatesKey(base)).Result()
+	if err != nil {
+		rc.logger.Error("Error reading TTL of latest rates", "base", base, "error", err)
+		return 0, false
+	}
+	// PTTL reports -2 for a missing key and -1 for one without expiry.
+	if remaining < 0 {
+		return 0, false
+	}
+	age := rc.latestRateTTL - remaining
+	if age < 0 {
+		age = 0
+	}
+	return age, true
+}
+
+// MarkLatestRatesStale sets or clears the stale flag of base. The flag has no TTL; it stays
+// until a refresh of base succeeds again.
+func (rc *redisCache) MarkLatestRatesStale(base domain.Currency, stale bool) {
+	if !rc.available("MarkLatestRatesStale") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	if stale {
+		err = rc.client.Set(ctx, staleLatestRatesKey(base), "1", 0).Err()
+	} else {
+		err = rc.client.Del(ctx, staleLatestRatesKey(base)).Err()
+	}
+	if err != nil {
+		rc.logger.Error("Error updating stale flag", "base", base, "error", err)
+	}
+}
+
+func (rc *redisCache) LatestRatesStale(base domain.Currency) bool {
+	if !rc.available("LatestRatesStale") {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n, err := rc.client.Exists(ctx, staleLatestRatesKey(base)).Result()
+	if err != nil {
+		rc.logger.Error("Error reading stale flag", "base", base, "error", err)
+		return false
+	}
+	return n > 0
+}
+
 func (rc *redisCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
-	lock := NewRedisLock(rc.client, "cache_write_lock", 30*time.Second)
+	rc.setHistoricalRates(date, base, rates, nil)
+}
+
+func (rc *redisCache) SetHistoricalRatesWithProvenance(date time.Time, base domain.Currency, rates map[domain.Currency]float64, provenance Provenance) {
+	rc.setHistoricalRates(date, base, rates, &provenance)
+}
+
+func (rc *redisCache) setHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64, provenance *Provenance) {
+	if !rc.available("SetHistoricalRates") {
+		return
+	}
+
+	lock := NewRedisLock(rc.client, cacheWriteLockKey, 30*time.Second, rc.logger)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second) // max wait 10s to acquire lock
 	defer cancel()
 
 	acquired, err := lock.Acquire(ctx, 10*time.Second)
 	if err != nil {
-		log.Printf("Error acquiring lock for SetHistoricalRates: %v", err)
+		rc.logger.Error("Error acquiring lock for SetHistoricalRates", "error", err)
 		return
 	}
 	if !acquired {
-		log.Println("Could not acquire lock for SetHistoricalRates after waiting")
+		rc.logger.Warn("Could not acquire lock for SetHistoricalRates after waiting")
 		return
 	}
 	defer func() {
 		if err := lock.Release(context.Background()); err != nil {
-			log.Printf("Error releasing lock for SetHistoricalRates: %v", err)
+			rc.logger.Error("Error releasing lock for SetHistoricalRates", "error", err)
 		}
 	}()
 
@@ -137,19 +292,24 @@ func (rc *redisCache) SetHistoricalRates(date time.Time, base domain.Currency, r
 
 	jsonData, err := json.Marshal(rates)
 	if err != nil {
-		log.Printf("Error marshaling historical rates: %v", err)
+		rc.logger.Error("Error marshaling historical rates", "base", base, "error", err)
 		return
 	}
 
-	err = rc.client.Set(ctx, key, jsonData, rc.historicalRateTTL).Err()
+	err = rc.set(ctx, key, jsonData, rc.historicalRateTTL, provenance)
 	if err != nil {
-		log.Printf("Error setting historical rates in Redis: %v", err)
+		rc.logger.Error("Error setting historical rates in Redis", "base", base, "error", err)
 	} else {
-		log.Printf("Cached historical rates for %s %s in Redis with TTL %s", base, date.Format("2006-01-02"), rc.historicalRateTTL)
+		rc.logger.Debug("Cached historical rates", "base", base, "date", date.Format("2006-01-02"), "ttl", rc.historicalRateTTL)
 	}
 }
 
 func (rc *redisCache) GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool) {
+	if !rc.available("GetHistoricalRates") {
+		metrics.ObserveCacheLookup(cacheTier, "historical", base, "unavailable")
+		return nil, false
+	}
+
 	key := historicalRatesKey(date, base)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -158,20 +318,78 @@ func (rc *redisCache) GetHistoricalRates(date time.Time, base domain.Currency) (
 	jsonData, err := rc.client.Get(ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			log.Printf("Cache miss for key %s", key)
+			rc.logger.Debug("Cache miss", "key", key)
+			metrics.ObserveCacheLookup(cacheTier, "historical", base, "miss")
 			return nil, false
 		}
-		log.Printf("Error getting historical rates from Redis: %v", err)
+		rc.logger.Error("Error getting historical rates from Redis", "base", base, "error", err)
+		metrics.ObserveCacheLookup(cacheTier, "historical", base, "error")
 		return nil, false
 	}
 
 	var rates map[domain.Currency]float64
 	err = json.Unmarshal([]byte(jsonData), &rates)
 	if err != nil {
-		log.Printf("Error unmarshaling historical rates JSON: %v", err)
+		rc.logger.Error("Error unmarshaling historical rates JSON", "base", base, "error", err)
+		metrics.ObserveCacheLookup(cacheTier, "historical", base, "error")
 		return nil, false
 	}
 
-	log.Printf("Cache hit for key %s", key)
+	rc.logger.Debug("Cache hit", "key", key)
+	metrics.ObserveCacheLookup(cacheTier, "historical", base, "hit")
 	return rates, true
 }
+
+// set stores value under key together with its provenance, which expires with it. Without a
+// provenance any left over from an earlier write is removed, so it never describes a value
+// it didn't write.
+func (rc *redisCache) set(ctx context.Context, key string, value []byte, ttl time.Duration, provenance *Provenance) error {
+	var provenanceData []byte
+	if provenance != nil {
+		var err error
+		if provenanceData, err = json.Marshal(provenance); err != nil {
+			return err
+		}
+	}
+	_, err := rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+		if provenanceData != nil {
+			pipe.Set(ctx, provenanceKey(key), provenanceData, ttl)
+		} else {
+			pipe.Del(ctx, provenanceKey(key))
+		}
+		return nil
+	})
+	return err
+}
+
+func (rc *redisCache) LatestRatesProvenance(base domain.Currency) (Provenance, bool) {
+	return rc.provenance("LatestRatesProvenance", latestRatesKey(base))
+}
+
+func (rc *redisCache) HistoricalRatesProvenance(date time.Time, base domain.Currency) (Provenance, bool) {
+	return rc.provenance("HistoricalRatesProvenance", historicalRatesKey(date, base))
+}
+
+func (rc *redisCache) provenance(op, key string) (Provenance, bool) {
+	if !rc.available(op) {
+		return Provenance{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := rc.client.Get(ctx, provenanceKey(key)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			rc.logger.Error("Error reading cache provenance", "key", key, "error", err)
+		}
+		return Provenance{}, false
+	}
+	var provenance Provenance
+	if err := json.Unmarshal(data, &provenance); err != nil {
+		rc.logger.Error("Error unmarshaling cache provenance", "key", key, "error", err)
+		return Provenance{}, false
+	}
+	return provenance, true
+}
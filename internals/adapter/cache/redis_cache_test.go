@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -12,6 +13,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func setupTestRedisCache(t *testing.T) *redisCache {
 	mini, err := miniredis.Run()
 	assert.NoError(t, err)
@@ -22,6 +26,7 @@ func setupTestRedisCache(t *testing.T) *redisCache {
 		client:            client,
 		latestRateTTL:     1 * time.Minute,
 		historicalRateTTL: 1 * time.Minute,
+		logger:            discardLogger,
 	}
 }
 
@@ -93,3 +98,65 @@ func TestGetHistoricalRates_UnmarshalError(t *testing.T) {
 	assert.False(t, found)
 	assert.Nil(t, gotRates)
 }
+
+func TestLatestRatesAge(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	cache := &redisCache{
+		client:            redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+		latestRateTTL:     time.Hour,
+		historicalRateTTL: time.Hour,
+		logger:            discardLogger,
+	}
+
+	_, found := cache.LatestRatesAge("USD")
+	assert.False(t, found)
+
+	cache.SetLatestRates("USD", map[domain.Currency]float64{"INR": 82.5}, time.Now())
+	mini.FastForward(10 * time.Minute)
+
+	age, found := cache.LatestRatesAge("USD")
+	assert.True(t, found)
+	assert.InDelta(t, float64(10*time.Minute), float64(age), float64(time.Second))
+}
+
+func TestLatestRatesStale(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	cache := &redisCache{client: redis.NewClient(&redis.Options{Addr: mini.Addr()}), latestRateTTL: time.Hour, logger: discardLogger}
+
+	assert.False(t, cache.LatestRatesStale("USD"))
+
+	cache.MarkLatestRatesStale("USD", true)
+	assert.True(t, cache.LatestRatesStale("USD"))
+	assert.False(t, cache.LatestRatesStale("EUR"))
+
+	cache.MarkLatestRatesStale("USD", false)
+	assert.False(t, cache.LatestRatesStale("USD"))
+}
+
+func TestProvenance(t *testing.T) {
+	cache := setupTestRedisCache(t)
+	date := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	rates := map[domain.Currency]float64{"INR": 82.5}
+	provenance := Provenance{RunID: "run-1", WrittenAt: time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)}
+
+	cache.SetLatestRatesWithProvenance("USD", rates, time.Now(), provenance)
+	cache.SetHistoricalRatesWithProvenance(date, "USD", rates, provenance)
+
+	got, found := cache.LatestRatesProvenance("USD")
+	assert.True(t, found)
+	assert.Equal(t, provenance, got)
+	got, found = cache.HistoricalRatesProvenance(date, "USD")
+	assert.True(t, found)
+	assert.Equal(t, provenance, got)
+	ttl, _ := cache.client.TTL(context.Background(), "provenance:latest:USD").Result()
+	assert.Equal(t, time.Minute, ttl)
+
+	// A write without provenance, e.g. on a request's cache miss, drops the old one.
+	cache.SetLatestRates("USD", rates, time.Now())
+	_, found = cache.LatestRatesProvenance("USD")
+	assert.False(t, found)
+	_, found = cache.LatestRatesProvenance("EUR")
+	assert.False(t, found)
+}
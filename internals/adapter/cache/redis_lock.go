@@ -3,7 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,15 +15,17 @@ type RedisLock struct {
 	key    string
 	value  string
 	ttl    time.Duration
+	logger *slog.Logger
 }
 
 // NewRedisLock creates a new lock with a unique value and TTL
-func NewRedisLock(client *redis.Client, key string, ttl time.Duration) *RedisLock {
+func NewRedisLock(client *redis.Client, key string, ttl time.Duration, logger *slog.Logger) *RedisLock {
 	return &RedisLock{
 		client: client,
 		key:    key,
 		value:  uuid.NewString(),
 		ttl:    ttl,
+		logger: logger,
 	}
 }
 
@@ -51,6 +53,11 @@ func (l *RedisLock) Acquire(ctx context.Context, maxWait time.Duration) (bool, e
 	}
 }
 
+// TryAcquire makes a single attempt to take the lock without waiting.
+func (l *RedisLock) TryAcquire(ctx context.Context) (bool, error) {
+	return l.client.SetNX(ctx, l.key, l.value, l.ttl).Result()
+}
+
 // Release releases the lock only if owned by this instance
 func (l *RedisLock) Release(ctx context.Context) error {
 	luaScript := `
@@ -65,7 +72,59 @@ func (l *RedisLock) Release(ctx context.Context) error {
 		return err
 	}
 	if res.(int64) == 0 {
-		log.Println("Lock not released: it was owned by someone else or expired")
+		l.logger.Warn("Lock not released: it was owned by someone else or expired", "lock", l.key)
 	}
 	return nil
 }
+
+// Extend resets the lock's TTL, but only while this instance still owns it.
+func (l *RedisLock) Extend(ctx context.Context) (bool, error) {
+	luaScript := `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	else
+		return 0
+	end
+	`
+	res, err := l.client.Eval(ctx, luaScript, []string{l.key}, l.value, l.ttl.Milliseconds()).Result()
+	if err != nil {
+		return false, err
+	}
+	return res.(int64) == 1, nil
+}
+
+// KeepAlive extends the lock every interval until the returned stop function is called,
+// so work that outlives the TTL doesn't let another instance take the lock midway.
+// stop waits for the heartbeat to exit. A lost lock is logged and ends the heartbeat.
+func (l *RedisLock) KeepAlive(ctx context.Context, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				extended, err := l.Extend(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						l.logger.Error("Error extending lock", "lock", l.key, "error", err)
+					}
+					continue
+				}
+				if !extended {
+					l.logger.Warn("Lock was lost before the work holding it finished", "lock", l.key)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
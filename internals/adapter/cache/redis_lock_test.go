@@ -20,7 +20,7 @@ func setupTestRedis(t *testing.T) *redis.Client {
 
 func TestRedisLock_AcquireAndRelease_Success(t *testing.T) {
 	client := setupTestRedis(t)
-	lock := NewRedisLock(client, "mylock", 2*time.Second)
+	lock := NewRedisLock(client, "mylock", 2*time.Second, discardLogger)
 	ctx := context.Background()
 
 	acquired, err := lock.Acquire(ctx, 2*time.Second)
@@ -35,12 +35,12 @@ func TestRedisLock_Acquire_Timeout(t *testing.T) {
 	client := setupTestRedis(t)
 	ctx := context.Background()
 
-	lock1 := NewRedisLock(client, "mylock", 5*time.Second)
+	lock1 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
 	acquired, err := lock1.Acquire(ctx, 1*time.Second)
 	assert.NoError(t, err)
 	assert.True(t, acquired)
 
-	lock2 := NewRedisLock(client, "mylock", 5*time.Second)
+	lock2 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
 	start := time.Now()
 	acquired2, err := lock2.Acquire(ctx, 500*time.Millisecond)
 	elapsed := time.Since(start)
@@ -55,12 +55,12 @@ func TestRedisLock_Release_NotOwner(t *testing.T) {
 	client := setupTestRedis(t)
 	ctx := context.Background()
 
-	lock1 := NewRedisLock(client, "mylock", 5*time.Second)
+	lock1 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
 	acquired, err := lock1.Acquire(ctx, 1*time.Second)
 	assert.NoError(t, err)
 	assert.True(t, acquired)
 
-	lock2 := NewRedisLock(client, "mylock", 5*time.Second)
+	lock2 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
 	err = lock2.Release(ctx)
 	assert.NoError(t, err)
 
@@ -79,17 +79,106 @@ func TestRedisLock_Acquire_ReacquireAfterTTL(t *testing.T) {
 	})
 	ctx := context.Background()
 
-	lock1 := NewRedisLock(client, "mylock", 500*time.Millisecond)
+	lock1 := NewRedisLock(client, "mylock", 500*time.Millisecond, discardLogger)
 	acquired, err := lock1.Acquire(ctx, 1*time.Second)
 	assert.NoError(t, err)
 	assert.True(t, acquired)
 
 	mini.FastForward(600 * time.Millisecond)
 
-	lock2 := NewRedisLock(client, "mylock", 1*time.Second)
+	lock2 := NewRedisLock(client, "mylock", 1*time.Second, discardLogger)
 	acquired2, err := lock2.Acquire(ctx, 1*time.Second)
 	assert.NoError(t, err)
 	assert.True(t, acquired2)
 
 	_ = lock2.Release(ctx)
 }
+
+func TestRedisLock_Extend(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	ctx := context.Background()
+
+	lock := NewRedisLock(client, "mylock", 10*time.Second, discardLogger)
+	acquired, err := lock.Acquire(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	mini.FastForward(8 * time.Second)
+	extended, err := lock.Extend(ctx)
+	assert.NoError(t, err)
+	assert.True(t, extended)
+	assert.Equal(t, 10*time.Second, mini.TTL("mylock"))
+
+	other := NewRedisLock(client, "mylock", 10*time.Second, discardLogger)
+	extended, err = other.Extend(ctx)
+	assert.NoError(t, err)
+	assert.False(t, extended)
+}
+
+func TestRedisLock_KeepAlive(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	ctx := context.Background()
+
+	lock := NewRedisLock(client, "mylock", 10*time.Second, discardLogger)
+	acquired, err := lock.Acquire(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	mini.FastForward(8 * time.Second)
+	stop := lock.KeepAlive(ctx, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return mini.TTL("mylock") == 10*time.Second
+	}, time.Second, 5*time.Millisecond)
+	stop()
+
+	// Once stopped the lock expires as usual.
+	mini.FastForward(11 * time.Second)
+	assert.False(t, mini.Exists("mylock"))
+}
+
+func TestRedisLock_KeepAliveStopsWhenLockIsLost(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	ctx := context.Background()
+
+	lock := NewRedisLock(client, "mylock", 10*time.Second, discardLogger)
+	_, err = lock.Acquire(ctx, time.Second)
+	assert.NoError(t, err)
+	mini.Set("mylock", "someone-else")
+
+	stop := lock.KeepAlive(ctx, 10*time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("keep alive did not stop")
+	}
+	value, _ := mini.Get("mylock")
+	assert.Equal(t, "someone-else", value)
+}
+
+func TestRedisLock_TryAcquire(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+
+	lock1 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
+	acquired, err := lock1.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	lock2 := NewRedisLock(client, "mylock", 5*time.Second, discardLogger)
+	start := time.Now()
+	acquired, err = lock2.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrRedisUnavailable = errors.New("redis is unavailable")
+
+// RedisStatus is a point-in-time snapshot of the supervisor's view of Redis.
+type RedisStatus struct {
+	Up                  bool      `json:"up"`
+	LastChecked         time.Time `json:"lastChecked"`
+	LastError           string    `json:"lastError,omitempty"`
+	LatencyMs           float64   `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	NextCheck           time.Time `json:"nextCheck"`
+}
+
+// RedisSupervisor pings Redis periodically and keeps track of whether it is reachable.
+// While Redis is down it backs off exponentially between probes, and cache calls
+// short-circuit on Available() instead of each waiting out its own timeout.
+type RedisSupervisor struct {
+	client      *redis.Client
+	logger      *slog.Logger
+	interval    time.Duration
+	pingTimeout time.Duration
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	mu          sync.RWMutex
+	up          bool
+	lastChecked time.Time
+	lastErr     error
+	lastLatency time.Duration
+	failures    int
+	nextCheck   time.Time
+}
+
+func NewRedisSupervisor(client *redis.Client, interval, baseBackoff, maxBackoff time.Duration, logger *slog.Logger) *RedisSupervisor {
+	return &RedisSupervisor{
+		client:      client,
+		logger:      logger,
+		interval:    interval,
+		pingTimeout: 2 * time.Second,
+		baseBackoff: baseBackoff,
+		maxBackoff:  maxBackoff,
+		up:          true, // optimistic until the first probe says otherwise
+	}
+}
+
+// Start runs the probe loop until ctx is cancelled.
+func (s *RedisSupervisor) Start(ctx context.Context) {
+	s.logger.Info("Redis supervisor started", "interval", s.interval)
+	for {
+		wait := s.Check(ctx)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			s.logger.Info("Redis supervisor stopping")
+			return
+		}
+	}
+}
+
+// Check pings Redis once, records the outcome and returns how long to wait before the next probe.
+func (s *RedisSupervisor) Check(ctx context.Context) time.Duration {
+	pingCtx, cancel := context.WithTimeout(ctx, s.pingTimeout)
+	defer cancel()
+	start := time.Now()
+	err := s.client.Ping(pingCtx).Err()
+	latency := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastChecked = time.Now()
+	s.lastErr = err
+	s.lastLatency = latency
+	if err == nil {
+		if !s.up {
+			s.logger.Info("Redis connection restored", "failed_checks", s.failures)
+		}
+		s.up = true
+		s.failures = 0
+		s.nextCheck = s.lastChecked.Add(s.interval)
+		return s.interval
+	}
+
+	if s.up {
+		s.logger.Error("Redis connection lost", "error", err)
+	}
+	s.up = false
+	s.failures++
+	wait := s.backoff(s.failures)
+	s.nextCheck = s.lastChecked.Add(wait)
+	s.logger.Warn("Redis still unavailable", "attempt", s.failures, "next_check_in", wait)
+	return wait
+}
+
+func (s *RedisSupervisor) backoff(failures int) time.Duration {
+	wait := s.baseBackoff
+	for i := 1; i < failures && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+	return wait
+}
+
+// Available reports whether Redis answered the most recent probe.
+func (s *RedisSupervisor) Available() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.up
+}
+
+func (s *RedisSupervisor) Status() RedisStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := RedisStatus{
+		Up:                  s.up,
+		LastChecked:         s.lastChecked,
+		LatencyMs:           float64(s.lastLatency.Microseconds()) / 1000,
+		ConsecutiveFailures: s.failures,
+		NextCheck:           s.nextCheck,
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	return status
+}
+
+// Name and Ready let the supervisor be plugged into the /readyz endpoint.
+func (s *RedisSupervisor) Name() string {
+	return "redis"
+}
+
+func (s *RedisSupervisor) Ready() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.up {
+		return nil
+	}
+	if s.lastErr != nil {
+		return s.lastErr
+	}
+	return ErrRedisUnavailable
+}
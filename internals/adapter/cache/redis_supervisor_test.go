@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisSupervisor_Check_Up(t *testing.T) {
+	client := setupTestRedis(t)
+	supervisor := NewRedisSupervisor(client, 5*time.Second, time.Second, 30*time.Second, discardLogger)
+
+	wait := supervisor.Check(context.Background())
+
+	assert.Equal(t, 5*time.Second, wait)
+	assert.True(t, supervisor.Available())
+	assert.NoError(t, supervisor.Ready())
+	assert.Equal(t, 0, supervisor.Status().ConsecutiveFailures)
+}
+
+func TestRedisSupervisor_Check_DownBacksOff(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr(), MaxRetries: -1})
+	mini.Close()
+
+	supervisor := NewRedisSupervisor(client, 5*time.Second, time.Second, 3*time.Second, discardLogger)
+
+	assert.Equal(t, 1*time.Second, supervisor.Check(context.Background()))
+	assert.Equal(t, 2*time.Second, supervisor.Check(context.Background()))
+	assert.Equal(t, 3*time.Second, supervisor.Check(context.Background()))
+
+	status := supervisor.Status()
+	assert.False(t, status.Up)
+	assert.Equal(t, 3, status.ConsecutiveFailures)
+	assert.NotEmpty(t, status.LastError)
+	assert.False(t, supervisor.Available())
+	assert.Error(t, supervisor.Ready())
+}
+
+func TestRedisSupervisor_Check_Recovers(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	addr := mini.Addr()
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	mini.Close()
+
+	supervisor := NewRedisSupervisor(client, 5*time.Second, time.Second, 30*time.Second, discardLogger)
+	supervisor.Check(context.Background())
+	assert.False(t, supervisor.Available())
+
+	restarted := miniredis.NewMiniRedis()
+	assert.NoError(t, restarted.StartAddr(addr))
+	defer restarted.Close()
+
+	supervisor.Check(context.Background())
+	assert.True(t, supervisor.Available())
+	assert.Equal(t, 0, supervisor.Status().ConsecutiveFailures)
+}
+
+func TestRedisCache_SkipsCallsWhileRedisDown(t *testing.T) {
+	cache := setupTestRedisCache(t)
+	cache.supervisor = NewRedisSupervisor(cache.client, time.Minute, time.Second, time.Minute, discardLogger)
+	cache.supervisor.up = false
+
+	cache.SetLatestRates("USD", map[domain.Currency]float64{"INR": 82.5}, time.Now())
+
+	// Bypass the supervisor to confirm nothing was written.
+	cache.supervisor.up = true
+	_, _, found := cache.GetLatestRates("USD")
+	assert.False(t, found)
+}
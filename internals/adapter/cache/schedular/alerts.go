@@ -0,0 +1,94 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/adapter/notify"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FailureAlerter is a dead-man switch for the refresh loops. It counts consecutive failed
+// refreshes per base in Redis, so the count carries over whichever replica holds the lock,
+// and once a base reaches the threshold it raises an alert and flags the base's cached
+// rates as stale. The next successful refresh clears the flag and reports the recovery.
+type FailureAlerter struct {
+	client    *redis.Client
+	threshold int
+	notifier  notify.Notifier
+	logger    *slog.Logger
+}
+
+// NewFailureAlerter returns nil when threshold is below 1, which disables alerting.
+func NewFailureAlerter(client *redis.Client, threshold int, notifier notify.Notifier, logger *slog.Logger) *FailureAlerter {
+	if threshold < 1 {
+		return nil
+	}
+	return &FailureAlerter{client: client, threshold: threshold, notifier: notifier, logger: logger}
+}
+
+func refreshFailuresKey(base domain.Currency) string {
+	return fmt.Sprintf("refresh_failures:%s", base)
+}
+
+// observe records the outcome of one refresh cycle of loop.
+func (a *FailureAlerter) observe(ctx context.Context, loop string, cacheObject cache.Cache, results map[domain.Currency]error) {
+	if a == nil {
+		return
+	}
+	marker, _ := cacheObject.(cache.StalenessTracker)
+
+	for base, refreshErr := range results {
+		if refreshErr != nil {
+			failures, err := a.client.Incr(ctx, refreshFailuresKey(base)).Result()
+			if err != nil {
+				a.logger.Error("Error counting refresh failures", "base", base, "error", err)
+				continue
+			}
+			if failures != int64(a.threshold) {
+				continue
+			}
+			if marker != nil {
+				marker.MarkLatestRatesStale(base, true)
+			}
+			a.notify(ctx, notify.Alert{
+				Kind:    notify.KindRefresh,
+				Title:   fmt.Sprintf("%s failing for %s", loop, base),
+				Message: fmt.Sprintf("%d consecutive refreshes of %s failed, cached rates are now served as stale. Last error: %v", failures, base, refreshErr),
+			})
+			continue
+		}
+
+		failures, err := a.client.GetDel(ctx, refreshFailuresKey(base)).Int64()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				a.logger.Error("Error resetting refresh failures", "base", base, "error", err)
+			}
+			continue
+		}
+		if failures < int64(a.threshold) {
+			continue
+		}
+		if marker != nil {
+			marker.MarkLatestRatesStale(base, false)
+		}
+		a.notify(ctx, notify.Alert{
+			Kind:     notify.KindRefresh,
+			Title:    fmt.Sprintf("%s recovered for %s", loop, base),
+			Message:  fmt.Sprintf("%s refreshed successfully after %d consecutive failures.", base, failures),
+			Resolved: true,
+		})
+	}
+}
+
+func (a *FailureAlerter) notify(ctx context.Context, alert notify.Alert) {
+	alert.Time = time.Now().UTC()
+	if err := a.notifier.Notify(ctx, alert); err != nil {
+		a.logger.Error("Error sending alert", "alert", alert.Title, "error", err)
+	}
+}
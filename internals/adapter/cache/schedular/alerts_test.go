@@ -0,0 +1,85 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"currency-exchange/internals/adapter/notify"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	alerts []notify.Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+type staleCache struct {
+	mockCache
+	stale map[domain.Currency]bool
+}
+
+func (c *staleCache) MarkLatestRatesStale(base domain.Currency, stale bool) {
+	c.stale[base] = stale
+}
+
+func (c *staleCache) LatestRatesStale(base domain.Currency) bool {
+	return c.stale[base]
+}
+
+func TestFailureAlerter_AlertsOnceAtThresholdAndOnRecovery(t *testing.T) {
+	mini, _ := miniredis.Run()
+	notifier := &recordingNotifier{}
+	alerter := NewFailureAlerter(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 3, notifier, discardLogger)
+	cacheObject := &staleCache{stale: make(map[domain.Currency]bool)}
+	ctx := context.Background()
+	failed := map[domain.Currency]error{"USD": errors.New("timeout"), "EUR": nil}
+
+	alerter.observe(ctx, backgroundRefreshLoop, cacheObject, failed)
+	alerter.observe(ctx, backgroundRefreshLoop, cacheObject, failed)
+	assert.Empty(t, notifier.alerts)
+	assert.False(t, cacheObject.stale["USD"])
+
+	alerter.observe(ctx, backgroundRefreshLoop, cacheObject, failed)
+	alerter.observe(ctx, backgroundRefreshLoop, cacheObject, failed)
+	assert.Len(t, notifier.alerts, 1)
+	assert.Contains(t, notifier.alerts[0].Message, "timeout")
+	assert.False(t, notifier.alerts[0].Resolved)
+	assert.True(t, cacheObject.stale["USD"])
+	assert.False(t, cacheObject.stale["EUR"])
+
+	alerter.observe(ctx, backgroundRefreshLoop, cacheObject, map[domain.Currency]error{"USD": nil})
+	assert.Len(t, notifier.alerts, 2)
+	assert.True(t, notifier.alerts[1].Resolved)
+	assert.False(t, cacheObject.stale["USD"])
+	assert.False(t, mini.Exists(refreshFailuresKey("USD")))
+}
+
+func TestFailureAlerter_SuccessResetsCount(t *testing.T) {
+	mini, _ := miniredis.Run()
+	notifier := &recordingNotifier{}
+	alerter := NewFailureAlerter(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 2, notifier, discardLogger)
+	ctx := context.Background()
+
+	alerter.observe(ctx, backgroundRefreshLoop, &mockCache{}, map[domain.Currency]error{"USD": errors.New("timeout")})
+	alerter.observe(ctx, backgroundRefreshLoop, &mockCache{}, map[domain.Currency]error{"USD": nil})
+	alerter.observe(ctx, backgroundRefreshLoop, &mockCache{}, map[domain.Currency]error{"USD": errors.New("timeout")})
+
+	assert.Empty(t, notifier.alerts)
+}
+
+func TestNewFailureAlerter_Disabled(t *testing.T) {
+	alerter := NewFailureAlerter(nil, 0, &recordingNotifier{}, discardLogger)
+	assert.Nil(t, alerter)
+
+	// A nil alerter is safe to use.
+	alerter.observe(context.Background(), backgroundRefreshLoop, &mockCache{}, map[domain.Currency]error{"USD": errors.New("x")})
+}
@@ -2,81 +2,367 @@ package schedular
 
 import (
 	"context"
+	"currency-exchange/internals/adapter/archive"
 	"currency-exchange/internals/adapter/cache"
 	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/adapter/updates"
 	"currency-exchange/internals/core/domain"
 	"currency-exchange/internals/service"
-	"log"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-func StartBackgroundRefreshWithLock(ctx context.Context, interval time.Duration, apiClient exchangerateapi.RateAPIClient, cache cache.Cache, redisClient *redis.Client, rateService service.RateService) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+const (
+	refreshLockKey       = "exchange_rate_cache_refresh_lock"
+	metalsRefreshLockKey = "metal_rate_cache_refresh_lock"
 
-	log.Printf("Background refresh worker started. Refresh interval: %s", interval)
+	backgroundRefreshLoop = "Background refresh"
+	metalsRefreshLoop     = "Metals refresh"
+)
+
+// Jitter keeps replicas that started together from waking at the same instant and all
+// contending for the refresh lock (and the provider) at once.
+type Jitter struct {
+	Start time.Duration // the first cycle runs after a random delay of up to Start
+	Cycle time.Duration // every later cycle runs up to Cycle early or late
+}
 
-	refreshCacheWithLockRetry(ctx, apiClient, cache, redisClient, interval, rateService)
+// firstDelay returns the random delay before the first cycle.
+func (j Jitter) firstDelay() time.Duration {
+	if j.Start <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(j.Start)))
+}
 
+// nextDelay returns interval shifted by up to ±Cycle. Cycle is capped at half the interval
+// so cycles never run back to back.
+func (j Jitter) nextDelay(interval time.Duration) time.Duration {
+	spread := j.Cycle
+	if spread > interval/2 {
+		spread = interval / 2
+	}
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + time.Duration(rand.Int63n(int64(2*spread)+1))
+}
+
+// RefreshOptions controls a background refresh loop.
+type RefreshOptions struct {
+	Interval time.Duration
+	Jitter   Jitter
+	Workers  int               // bases fetched concurrently; anything below 1 means one at a time
+	MinAge   time.Duration     // bases cached more recently than this are skipped; 0 refreshes every base
+	Tracker  *Tracker          // where runs are reported; nil means DefaultTracker
+	Alerter  *FailureAlerter   // raises alerts for bases that keep failing; nil disables alerting
+	Bases    []domain.Currency // bases kept warm; empty means every supported base
+	Leader   *LeaderElection   // only the elected replica refreshes; nil lets every replica contend for the lock
+	Logger   *slog.Logger      // nil means slog.Default()
+	Updates  updates.Publisher // told about every base refreshed, for streaming clients; may be nil
+	Archive  archive.Store     // keeps every day of historical rates fetched; may be nil
+
+	Retries      int           // extra attempts for bases that failed earlier in the same cycle
+	RetryBackoff time.Duration // wait before the first retry, doubling for each one after
+}
+
+// retryDelay returns the jittered wait before retry attempt (counting from 0).
+func (o RefreshOptions) retryDelay(attempt int) time.Duration {
+	delay := o.RetryBackoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// refreshes reports whether base is one of the bases the loop keeps warm. Other bases are
+// still cached on demand when a request needs them.
+func (o RefreshOptions) refreshes(base domain.Currency) bool {
+	return inBases(o.Bases, base)
+}
+
+func inBases(bases []domain.Currency, base domain.Currency) bool {
+	if len(bases) == 0 {
+		return true
+	}
+	for _, b := range bases {
+		if b == base {
+			return true
+		}
+	}
+	return false
+}
+
+func (o RefreshOptions) tracker() *Tracker {
+	if o.Tracker == nil {
+		return DefaultTracker
+	}
+	return o.Tracker
+}
+
+func (o RefreshOptions) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
+
+// StartBackgroundRefreshWithLock refreshes the latest rates every opts.Interval until ctx is
+// cancelled. A cycle already running when ctx is cancelled is allowed to finish, so callers
+// can wait for this to return to know no refresh is in flight.
+func StartBackgroundRefreshWithLock(ctx context.Context, opts RefreshOptions, apiClient exchangerateapi.RateAPIClient, cache cache.Cache, redisClient *redis.Client, rateService service.RateService) {
+	workCtx := context.WithoutCancel(ctx)
+	runRefreshLoop(ctx, opts.tracker().loop(backgroundRefreshLoop, opts.Interval), opts, func() {
+		refreshCacheWithLockRetry(workCtx, apiClient, cache, redisClient, opts, rateService)
+	})
+}
+
+// StartMetalsRefreshWithLock keeps precious metal bases warm on their own cadence. Metal prices
+// move less than fiat crosses and metal APIs tend to have tight quotas, so this usually runs
+// less often than the main refresh.
+func StartMetalsRefreshWithLock(ctx context.Context, opts RefreshOptions, apiClient exchangerateapi.RateAPIClient, cacheObject cache.Cache, redisClient *redis.Client, rateService service.RateService) {
+	workCtx := context.WithoutCancel(ctx)
+	loop := opts.tracker().loop(metalsRefreshLoop, opts.Interval)
+	runRefreshLoop(ctx, loop, opts, func() {
+		runID, runCtx, runOpts := beginRun(workCtx, opts)
+		withRefreshLock(runCtx, redisClient, runOpts, metalsRefreshLockKey, func() {
+			loop.run(runID, func() map[domain.Currency]error {
+				results := refreshBases(runCtx, apiClient, cacheObject, rateService, runOpts, domain.Currency.IsMetal)
+				runOpts.Alerter.observe(runCtx, loop.name, cacheObject, results)
+				return results
+			})
+		})
+	})
+}
+
+func runRefreshLoop(ctx context.Context, loop *loopTracker, opts RefreshOptions, refresh func()) {
+	logger := opts.logger().With("loop", loop.name)
+	delay := opts.Jitter.firstDelay()
+	logger.Info("Worker started", "interval", loop.interval, "first_run_in", delay.Round(time.Millisecond))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	loop.scheduled(delay)
 	for {
 		select {
-		case <-ticker.C:
-			log.Println("Background refresh triggered.")
-			refreshCacheWithLockRetry(ctx, apiClient, cache, redisClient, interval, rateService)
+		case <-timer.C:
+			logger.Info("Worker triggered")
+			refresh()
+			delay = opts.Jitter.nextDelay(loop.interval)
+			timer.Reset(delay)
+			loop.scheduled(delay)
 		case <-ctx.Done():
-			log.Println("Background refresh worker stopping.")
+			logger.Info("Worker stopping")
 			return
 		}
 	}
 }
 
-func refreshCacheWithLockRetry(ctx context.Context, apiClient exchangerateapi.RateAPIClient, cacheObject cache.Cache, redisClient *redis.Client, interval time.Duration, rateService service.RateService) {
-	const lockKey = "exchange_rate_cache_refresh_lock"
+func refreshCacheWithLockRetry(ctx context.Context, apiClient exchangerateapi.RateAPIClient, cacheObject cache.Cache, redisClient *redis.Client, opts RefreshOptions, rateService service.RateService) {
+	loop := opts.tracker().loop(backgroundRefreshLoop, opts.Interval)
+	runID, ctx, opts := beginRun(ctx, opts)
+	withRefreshLock(ctx, redisClient, opts, refreshLockKey, func() {
+		loop.run(runID, func() map[domain.Currency]error {
+			results := refreshCache(ctx, apiClient, cacheObject, rateService, opts)
+			opts.Alerter.observe(ctx, loop.name, cacheObject, results)
+			return results
+		})
+	})
+}
+
+// withRefreshLock runs refresh while holding the distributed lock lockKey, so only one
+// instance refreshes at a time. The lock is extended in the background for as long as
+// refresh runs, however long that takes. Nothing runs while refreshes are paused, or on
+// replicas that aren't the elected opts.Leader. The leader still takes the lock, so a cycle
+// started just before leadership changed hands can't overlap with the new leader's.
+func withRefreshLock(ctx context.Context, redisClient *redis.Client, opts RefreshOptions, lockKey string, refresh func()) {
 	lockTTL := 2 * time.Minute
 	maxWait := 15 * time.Second
+	logger := opts.logger().With("lock", lockKey)
+
+	if opts.Leader.follower() {
+		logger.Info("Another instance is the scheduler leader, skipping this cycle")
+		return
+	}
+
+	// If Redis can't tell us, carry on: the lock below needs Redis anyway.
+	if pause, err := NewPauseSwitch(redisClient).State(ctx); err == nil && pause.Paused {
+		logger.Info("Background refreshes are paused, skipping this cycle", "reason", pause.Reason)
+		return
+	}
 
-	lock := cache.NewRedisLock(redisClient, lockKey, lockTTL)
+	lock := cache.NewRedisLock(redisClient, lockKey, lockTTL, logger)
 	acquired, err := lock.Acquire(ctx, maxWait)
 	if err != nil {
-		log.Printf("Error acquiring distributed lock for cache refresh: %v", err)
+		logger.Error("Error acquiring distributed lock for cache refresh", "error", err)
 		return
 	}
 	if !acquired {
-		log.Println("Could not acquire lock for cache refresh after waiting, skipping this cycle")
+		logger.Warn("Could not acquire lock for cache refresh after waiting, skipping this cycle")
 		return
 	}
 	defer func() {
 		if err := lock.Release(context.Background()); err != nil {
-			log.Printf("Error releasing distributed lock: %v", err)
+			logger.Error("Error releasing distributed lock", "error", err)
 		}
 	}()
+	stopHeartbeat := lock.KeepAlive(ctx, lockTTL/3)
+	defer stopHeartbeat()
 
-	refreshCache(ctx, apiClient, cacheObject, rateService)
+	refresh()
 }
 
-func refreshCache(ctx context.Context, client exchangerateapi.RateAPIClient, cache cache.Cache, rateService service.RateService) {
+// refreshCache refreshes every base except the precious metals, which have their own loop.
+func refreshCache(ctx context.Context, client exchangerateapi.RateAPIClient, cache cache.Cache, rateService service.RateService, opts RefreshOptions) map[domain.Currency]error {
+	return refreshBases(ctx, client, cache, rateService, opts, func(base domain.Currency) bool {
+		return !base.IsMetal()
+	})
+}
+
+// refreshBases refreshes the supported bases include and opts.Bases accept, each against
+// every supported currency, using up to opts.Workers concurrent fetches. Bases cached within
+// opts.MinAge are left alone. A failing base is logged and does not hold up the others, and
+// is retried up to opts.Retries times with backoff before the cycle gives up on it. It
+// returns the final outcome for each base it refreshed.
+func refreshBases(ctx context.Context, client exchangerateapi.RateAPIClient, cacheObject cache.Cache, rateService service.RateService, opts RefreshOptions, include func(domain.Currency) bool) map[domain.Currency]error {
 	allCurrencies := rateService.GetSupportedCurrencies()
+	pending := make([]domain.Currency, 0, len(allCurrencies))
 	for _, base := range allCurrencies {
-		targets := make([]domain.Currency, 0, len(allCurrencies)-1)
-		for _, target := range allCurrencies {
-			if target != base {
-				targets = append(targets, domain.Currency(target))
+		if !include(domain.Currency(base)) || !opts.refreshes(domain.Currency(base)) || isFresh(cacheObject, domain.Currency(base), opts.MinAge, opts.logger()) {
+			continue
+		}
+		pending = append(pending, domain.Currency(base))
+	}
+
+	results := refreshConcurrently(ctx, client, cacheObject, pending, allCurrencies, opts)
+	for attempt := 0; attempt < opts.Retries; attempt++ {
+		failed := make([]domain.Currency, 0)
+		for base, err := range results {
+			if err != nil {
+				failed = append(failed, base)
 			}
 		}
-		if len(targets) == 0 {
-			continue
+		if len(failed) == 0 {
+			break
 		}
 
-		rates, timestamp, err := client.FetchLatestRates(ctx, domain.Currency(base), targets)
-		if err != nil {
-			log.Printf("ERROR refreshing cache for base %s: %v", base, err)
-			continue
+		wait := opts.retryDelay(attempt)
+		opts.logger().Info("Retrying failed bases", "bases", failed, "wait", wait.Round(time.Millisecond), "retry", attempt+1, "retries", opts.Retries)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return results
+		}
+		// Retries go through the same client, so they are throttled by the provider's
+		// outbound rate limiter like any other call.
+		for base, err := range refreshConcurrently(ctx, client, cacheObject, failed, allCurrencies, opts) {
+			results[base] = err
 		}
+	}
+	return results
+}
+
+// refreshConcurrently refreshes bases using up to opts.Workers concurrent fetches and returns
+// the outcome for each.
+func refreshConcurrently(ctx context.Context, client exchangerateapi.RateAPIClient, cacheObject cache.Cache, bases []domain.Currency, allCurrencies []string, opts RefreshOptions) map[domain.Currency]error {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan domain.Currency)
+
+	results := make(map[domain.Currency]error, len(bases))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for base := range queue {
+				err := refreshBaseIsolated(ctx, client, cacheObject, base, allCurrencies, opts)
+				mu.Lock()
+				results[base] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, base := range bases {
+		queue <- base
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
 
-		rates[domain.Currency(base)] = 1.0
-		cache.SetLatestRates(domain.Currency(base), rates, timestamp)
-		log.Printf("Cache refreshed successfully for base %s", base)
+// isFresh reports whether base was cached less than minAge ago, e.g. by another replica
+// or an admin triggered refresh.
+func isFresh(cacheObject cache.Cache, base domain.Currency, minAge time.Duration, logger *slog.Logger) bool {
+	if minAge <= 0 {
+		return false
 	}
+	reporter, ok := cacheObject.(cache.FreshnessReporter)
+	if !ok {
+		return false
+	}
+	age, found := reporter.LatestRatesAge(base)
+	if !found || age >= minAge {
+		return false
+	}
+	logger.Info("Skipping refresh, cached recently", "base", base, "age", age.Round(time.Second))
+	return true
+}
+
+// refreshBaseIsolated refreshes one base, logging rather than propagating failures (panics included)
+// so one bad base cannot take down the rest of the cycle. Fresh rates are published to opts.Updates.
+func refreshBaseIsolated(ctx context.Context, client exchangerateapi.RateAPIClient, cache cache.Cache, base domain.Currency, allCurrencies []string, opts RefreshOptions) (err error) {
+	logger := opts.logger()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			logger.Error("Error refreshing cache", "base", base, "duration", time.Since(start), "error", err)
+		}
+	}()
+
+	rates, timestamp, err := refreshBase(ctx, client, cache, base, allCurrencies)
+	if err != nil {
+		logger.Error("Error refreshing cache", "base", base, "duration", time.Since(start), "error", err)
+		return err
+	}
+	logger.Info("Cache refreshed", "base", base, "duration", time.Since(start))
+	if opts.Updates != nil && rates != nil {
+		opts.Updates.Publish(updates.Update{Base: base, Rates: rates, Timestamp: timestamp})
+	}
+	return nil
+}
+
+// refreshBase fetches the latest rates of base against every other supported currency, caches
+// them and returns what it cached. It caches nothing, and returns nil rates, when base is the
+// only supported currency.
+func refreshBase(ctx context.Context, client exchangerateapi.RateAPIClient, cache cache.Cache, base domain.Currency, allCurrencies []string) (map[domain.Currency]float64, time.Time, error) {
+	targets := make([]domain.Currency, 0, len(allCurrencies))
+	for _, target := range allCurrencies {
+		if domain.Currency(target) != base {
+			targets = append(targets, domain.Currency(target))
+		}
+	}
+	if len(targets) == 0 {
+		return nil, time.Time{}, nil
+	}
+
+	rates, timestamp, err := client.FetchLatestRates(ctx, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rates[base] = 1.0
+	setLatestRates(ctx, cache, base, rates, timestamp)
+	return rates, timestamp, nil
 }
@@ -3,9 +3,13 @@ package schedular
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"currency-exchange/internals/adapter/updates"
 	"currency-exchange/internals/core/domain"
 
 	"github.com/alicebob/miniredis/v2"
@@ -13,8 +17,13 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 // --- Mock Cache ---
 type mockCache struct {
+	mu                  sync.Mutex
+	warmBases           map[domain.Currency]bool
 	setLatestRatesCalls []struct {
 		base      domain.Currency
 		rates     map[domain.Currency]float64
@@ -23,6 +32,8 @@ type mockCache struct {
 }
 
 func (m *mockCache) SetLatestRates(base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.setLatestRatesCalls = append(m.setLatestRatesCalls, struct {
 		base      domain.Currency
 		rates     map[domain.Currency]float64
@@ -30,6 +41,9 @@ func (m *mockCache) SetLatestRates(base domain.Currency, rates map[domain.Curren
 	}{base, rates, timestamp})
 }
 func (m *mockCache) GetLatestRates(base domain.Currency) (map[domain.Currency]float64, time.Time, bool) {
+	if m.warmBases[base] {
+		return map[domain.Currency]float64{base: 1.0}, time.Now(), true
+	}
 	return nil, time.Time{}, false
 }
 func (m *mockCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
@@ -83,7 +97,7 @@ func TestRefreshCache_AllSuccess(t *testing.T) {
 	}
 	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
 
-	refreshCache(context.Background(), api, cache, rateSvc)
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2})
 
 	assert.Equal(t, 2, len(cache.setLatestRatesCalls))
 	for _, call := range cache.setLatestRatesCalls {
@@ -102,11 +116,239 @@ func TestRefreshCache_APIError(t *testing.T) {
 	}
 	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
 
-	refreshCache(context.Background(), api, cache, rateSvc)
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2})
 
 	assert.Equal(t, 0, len(cache.setLatestRatesCalls))
 }
 
+func TestRefreshCache_SkipsMetalBases(t *testing.T) {
+	cache := &mockCache{}
+	var targetsSeen [][]domain.Currency
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			targetsSeen = append(targetsSeen, targets)
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "XAU"}}
+
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2})
+
+	assert.Len(t, cache.setLatestRatesCalls, 1)
+	assert.Equal(t, domain.Currency("USD"), cache.setLatestRatesCalls[0].base)
+	assert.Equal(t, [][]domain.Currency{{"XAU"}}, targetsSeen)
+}
+
+func TestRefreshCache_OnlyConfiguredBases(t *testing.T) {
+	cache := &mockCache{}
+	var targetsSeen [][]domain.Currency
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			targetsSeen = append(targetsSeen, targets)
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+
+	results := refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2, Bases: []domain.Currency{"EUR"}})
+
+	assert.Equal(t, map[domain.Currency]error{"EUR": nil}, results)
+	// The configured base is still refreshed against every supported currency.
+	assert.Equal(t, [][]domain.Currency{{"USD", "INR"}}, targetsSeen)
+}
+
+func TestRefreshBases_MetalsOnly(t *testing.T) {
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return map[domain.Currency]float64{"USD": 2300}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "XAU", "XAG"}}
+
+	refreshBases(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2}, domain.Currency.IsMetal)
+
+	assert.Len(t, cache.setLatestRatesCalls, 2)
+	for _, call := range cache.setLatestRatesCalls {
+		assert.True(t, call.base.IsMetal())
+	}
+}
+
+func TestRefreshBases_PublishesUpdates(t *testing.T) {
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			if base == "EUR" {
+				return nil, time.Time{}, errors.New("provider down")
+			}
+			return map[domain.Currency]float64{"INR": 85.2}, time.Unix(1744624800, 0), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "EUR", "INR"}}
+	bus := updates.NewBus(8)
+	sub := bus.Subscribe()
+	defer sub.Close()
+
+	refreshCache(context.Background(), api, &mockCache{}, rateSvc, RefreshOptions{Bases: []domain.Currency{"USD", "EUR"}, Updates: bus})
+
+	// Only bases that were refreshed are published.
+	assert.Equal(t, updates.Update{
+		Base:      "USD",
+		Rates:     map[domain.Currency]float64{"INR": 85.2, "USD": 1},
+		Timestamp: time.Unix(1744624800, 0),
+	}, <-sub.Updates())
+	assert.Empty(t, sub.Updates())
+}
+
+func TestRefreshBases_BoundedConcurrency(t *testing.T) {
+	cache := &mockCache{}
+	var inFlight, peak int32
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&peak)
+				if current <= max || atomic.CompareAndSwapInt32(&peak, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR", "JPY", "GBP"}}
+
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2})
+
+	assert.Len(t, cache.setLatestRatesCalls, 5)
+	assert.Equal(t, int32(2), peak)
+}
+
+func TestRefreshBases_IsolatesFailingBases(t *testing.T) {
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			switch base {
+			case "INR":
+				return nil, time.Time{}, errors.New("api error")
+			case "EUR":
+				panic("unexpected payload")
+			}
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR", "GBP"}}
+
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 3})
+
+	refreshed := make([]domain.Currency, 0, len(cache.setLatestRatesCalls))
+	for _, call := range cache.setLatestRatesCalls {
+		refreshed = append(refreshed, call.base)
+	}
+	assert.ElementsMatch(t, []domain.Currency{"USD", "GBP"}, refreshed)
+}
+
+// agingCache reports how long ago each base was cached.
+type agingCache struct {
+	mockCache
+	ages map[domain.Currency]time.Duration
+}
+
+func (m *agingCache) LatestRatesAge(base domain.Currency) (time.Duration, bool) {
+	age, ok := m.ages[base]
+	return age, ok
+}
+
+func TestRefreshBases_RetriesFailedBases(t *testing.T) {
+	cache := &mockCache{}
+	var mu sync.Mutex
+	calls := make(map[domain.Currency]int)
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[base]++
+			if base == "INR" && calls[base] < 3 {
+				return nil, time.Time{}, errors.New("api error")
+			}
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	results := refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 2, Retries: 3, RetryBackoff: time.Millisecond})
+
+	assert.Equal(t, map[domain.Currency]error{"USD": nil, "INR": nil}, results)
+	assert.Equal(t, map[domain.Currency]int{"USD": 1, "INR": 3}, calls)
+}
+
+func TestRefreshBases_GivesUpAfterRetries(t *testing.T) {
+	cache := &mockCache{}
+	var attempts int32
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			if base == "INR" {
+				return map[domain.Currency]float64{}, time.Now(), nil
+			}
+			atomic.AddInt32(&attempts, 1)
+			return nil, time.Time{}, errors.New("api error")
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	results := refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Retries: 2, RetryBackoff: time.Millisecond})
+
+	assert.EqualError(t, results["USD"], "api error")
+	assert.NoError(t, results["INR"])
+	assert.Equal(t, int32(3), attempts)
+	assert.Len(t, cache.setLatestRatesCalls, 1)
+}
+
+func TestRetryDelay(t *testing.T) {
+	opts := RefreshOptions{RetryBackoff: time.Second}
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		delay := opts.retryDelay(attempt)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+	assert.Zero(t, RefreshOptions{}.retryDelay(0))
+}
+
+func TestRefreshCache_SkipsFreshBases(t *testing.T) {
+	cache := &agingCache{ages: map[domain.Currency]time.Duration{
+		"USD": 5 * time.Minute,
+		"INR": 50 * time.Minute,
+	}}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 1, MinAge: 30 * time.Minute})
+
+	refreshed := make([]domain.Currency, 0, len(cache.setLatestRatesCalls))
+	for _, call := range cache.setLatestRatesCalls {
+		refreshed = append(refreshed, call.base)
+	}
+	assert.ElementsMatch(t, []domain.Currency{"INR", "EUR"}, refreshed)
+}
+
+func TestRefreshCache_MinAgeDisabled(t *testing.T) {
+	cache := &agingCache{ages: map[domain.Currency]time.Duration{"USD": time.Second, "INR": time.Second}}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	refreshCache(context.Background(), api, cache, rateSvc, RefreshOptions{Workers: 1})
+
+	assert.Len(t, cache.setLatestRatesCalls, 2)
+}
+
 func TestRefreshCacheWithLockRetry_LockAcquired(t *testing.T) {
 	mini, _ := miniredis.Run()
 	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
@@ -119,7 +361,7 @@ func TestRefreshCacheWithLockRetry_LockAcquired(t *testing.T) {
 	}
 	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
 
-	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, time.Minute, rateSvc)
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Workers: 2}, rateSvc)
 
 	assert.Equal(t, 2, len(cache.setLatestRatesCalls))
 }
@@ -134,7 +376,87 @@ func TestRefreshCacheWithLockRetry_LockNotAcquired(t *testing.T) {
 	api := &mockAPIClient{}
 	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
 
-	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, time.Minute, rateSvc)
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Workers: 2}, rateSvc)
 
 	assert.Equal(t, 0, len(cache.setLatestRatesCalls))
 }
+
+func TestJitter_FirstDelay(t *testing.T) {
+	assert.Zero(t, Jitter{}.firstDelay())
+	for i := 0; i < 100; i++ {
+		delay := Jitter{Start: time.Second}.firstDelay()
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, time.Second)
+	}
+}
+
+func TestJitter_NextDelay(t *testing.T) {
+	assert.Equal(t, time.Hour, Jitter{}.nextDelay(time.Hour))
+	for i := 0; i < 100; i++ {
+		delay := Jitter{Cycle: time.Minute}.nextDelay(time.Hour)
+		assert.GreaterOrEqual(t, delay, 59*time.Minute)
+		assert.LessOrEqual(t, delay, 61*time.Minute)
+	}
+	// Cycle jitter is capped at half the interval.
+	for i := 0; i < 100; i++ {
+		delay := Jitter{Cycle: time.Hour}.nextDelay(time.Minute)
+		assert.GreaterOrEqual(t, delay, 30*time.Second)
+		assert.LessOrEqual(t, delay, 90*time.Second)
+	}
+}
+
+func TestRunRefreshLoop_StopsDuringStartJitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	calls := 0
+	go func() {
+		runRefreshLoop(ctx, NewTracker().loop("test", time.Hour), RefreshOptions{Jitter: Jitter{Start: time.Hour}}, func() { calls++ })
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("refresh loop did not stop")
+	}
+	assert.Zero(t, calls)
+}
+
+func TestStartBackgroundRefreshWithLock_FinishesInFlightCycleOnCancel(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			started <- struct{}{}
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, time.Time{}, err
+			}
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		StartBackgroundRefreshWithLock(ctx, RefreshOptions{Interval: time.Hour, Workers: 2}, api, cache, redisClient, rateSvc)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh loop did not stop")
+	}
+	assert.Len(t, cache.setLatestRatesCalls, 2)
+}
@@ -0,0 +1,56 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/service"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// WarmUpCache makes sure latest rates for bases (every supported base when empty) are cached
+// before the server starts taking traffic. Bases that are already cached (e.g. by another
+// replica) are left alone. It gives up after timeout and reports the bases that are still cold.
+// The warm-up counts as a refresh run of its own, with its own run ID.
+func WarmUpCache(ctx context.Context, timeout time.Duration, bases []domain.Currency, apiClient exchangerateapi.RateAPIClient, cacheObject cache.Cache, rateService service.RateService, logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, ctx, opts := beginRun(ctx, RefreshOptions{Logger: logger})
+	logger = opts.Logger
+
+	start := time.Now()
+	allCurrencies := rateService.GetSupportedCurrencies()
+	cold := make([]string, 0)
+	for _, base := range allCurrencies {
+		if !inBases(bases, domain.Currency(base)) {
+			continue
+		}
+		if ctx.Err() != nil {
+			cold = append(cold, base)
+			continue
+		}
+
+		if _, _, found := cacheObject.GetLatestRates(domain.Currency(base)); found {
+			logger.Info("Cache already warm", "base", base)
+			continue
+		}
+
+		if _, _, err := refreshBase(ctx, apiClient, cacheObject, domain.Currency(base), allCurrencies); err != nil {
+			logger.Error("Error warming cache", "base", base, "error", err)
+			cold = append(cold, base)
+			continue
+		}
+		logger.Info("Cache warmed", "base", base)
+	}
+
+	if len(cold) > 0 {
+		return fmt.Errorf("cache warm-up incomplete after %s, cold bases: %s", time.Since(start).Round(time.Millisecond), strings.Join(cold, ","))
+	}
+
+	logger.Info("Cache warm-up finished", "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
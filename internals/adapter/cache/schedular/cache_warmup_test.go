@@ -0,0 +1,90 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUpCache_FetchesColdBases(t *testing.T) {
+	cache := &mockCache{warmBases: map[domain.Currency]bool{"USD": true}}
+	fetched := make([]domain.Currency, 0)
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			fetched = append(fetched, base)
+			return map[domain.Currency]float64{"USD": 0.012}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	err := WarmUpCache(context.Background(), time.Second, nil, api, cache, rateSvc, discardLogger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Currency{"INR"}, fetched)
+	assert.Equal(t, 1, len(cache.setLatestRatesCalls))
+	assert.Equal(t, domain.Currency("INR"), cache.setLatestRatesCalls[0].base)
+}
+
+func TestWarmUpCache_OnlyConfiguredBases(t *testing.T) {
+	cache := &mockCache{}
+	fetched := make([]domain.Currency, 0)
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			fetched = append(fetched, base)
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+
+	err := WarmUpCache(context.Background(), time.Second, []domain.Currency{"USD", "EUR"}, api, cache, rateSvc, discardLogger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Currency{"USD", "EUR"}, fetched)
+}
+
+func TestWarmUpCache_AlreadyWarm(t *testing.T) {
+	cache := &mockCache{warmBases: map[domain.Currency]bool{"USD": true, "INR": true}}
+	api := &mockAPIClient{}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	err := WarmUpCache(context.Background(), time.Second, nil, api, cache, rateSvc, discardLogger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(cache.setLatestRatesCalls))
+}
+
+func TestWarmUpCache_ReportsColdBasesOnError(t *testing.T) {
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return nil, time.Time{}, errors.New("api error")
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	err := WarmUpCache(context.Background(), time.Second, nil, api, cache, rateSvc, discardLogger)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "USD,INR")
+}
+
+func TestWarmUpCache_Timeout(t *testing.T) {
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			<-ctx.Done()
+			return nil, time.Time{}, ctx.Err()
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+
+	err := WarmUpCache(context.Background(), 50*time.Millisecond, nil, api, cache, rateSvc, discardLogger)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "USD,INR,EUR")
+}
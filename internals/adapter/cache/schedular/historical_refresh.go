@@ -0,0 +1,111 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/service"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	historicalRefreshLockKey = "historical_rate_cache_refresh_lock"
+	historicalRefreshLoop    = "Historical refresh"
+)
+
+// StartHistoricalRefreshWithLock caches the previous business day's rates for every base once
+// the provider has published them, so /v1/historical queries for recent dates are served from
+// Redis. Bases already cached for that day are skipped, so although the loop runs every
+// interval each day is only fetched once.
+func StartHistoricalRefreshWithLock(ctx context.Context, opts RefreshOptions, apiClient exchangerateapi.RateAPIClient, cacheObject cache.Cache, redisClient *redis.Client, rateService service.RateService) {
+	workCtx := context.WithoutCancel(ctx)
+	loop := opts.tracker().loop(historicalRefreshLoop, opts.Interval)
+	runRefreshLoop(ctx, loop, opts, func() {
+		runID, runCtx, runOpts := beginRun(workCtx, opts)
+		withRefreshLock(runCtx, redisClient, runOpts, historicalRefreshLockKey, func() {
+			loop.run(runID, func() map[domain.Currency]error {
+				return refreshHistorical(runCtx, apiClient, cacheObject, rateService, runOpts, previousBusinessDay(time.Now()))
+			})
+		})
+	})
+}
+
+// previousBusinessDay returns the last weekday before now, at midnight UTC.
+func previousBusinessDay(now time.Time) time.Time {
+	day := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// refreshHistorical caches the rates published for day for every base in opts.Bases (or every
+// supported base) that is not cached yet, returning the outcome for each base it fetched.
+func refreshHistorical(ctx context.Context, client exchangerateapi.RateAPIClient, cacheObject cache.Cache, rateService service.RateService, opts RefreshOptions, day time.Time) map[domain.Currency]error {
+	results := make(map[domain.Currency]error)
+	allCurrencies := rateService.GetSupportedCurrencies()
+	for _, base := range allCurrencies {
+		if ctx.Err() != nil {
+			return results
+		}
+		if !opts.refreshes(domain.Currency(base)) {
+			continue
+		}
+		if _, found := cacheObject.GetHistoricalRates(day, domain.Currency(base)); found {
+			continue
+		}
+		start := time.Now()
+		err := refreshHistoricalBase(ctx, client, cacheObject, opts.Archive, domain.Currency(base), allCurrencies, day, opts.logger())
+		results[domain.Currency(base)] = err
+		if err != nil {
+			opts.logger().Error("Error caching historical rates", "base", base, "date", day.Format("2006-01-02"), "duration", time.Since(start), "error", err)
+			continue
+		}
+		opts.logger().Info("Historical rates cached", "base", base, "date", day.Format("2006-01-02"), "duration", time.Since(start))
+	}
+	return results
+}
+
+// refreshHistoricalBase fetches and caches the rates of base on day, archiving them too when
+// store isn't nil. A failed archive write is logged but doesn't fail the refresh.
+func refreshHistoricalBase(ctx context.Context, client exchangerateapi.RateAPIClient, cacheObject cache.Cache, store archive.Store, base domain.Currency, allCurrencies []string, day time.Time, logger *slog.Logger) error {
+	targets := make([]domain.Currency, 0, len(allCurrencies))
+	for _, target := range allCurrencies {
+		if domain.Currency(target) != base {
+			targets = append(targets, domain.Currency(target))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	response, err := client.FetchHistoricalTimeSeriesRates(ctx, day, day, base, targets)
+	if err != nil {
+		return err
+	}
+
+	for date, dayRates := range response.Rates {
+		parsedDate, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			logger.Warn("Skipping historical rates with unexpected date", "base", base, "date", date)
+			continue
+		}
+		rates := make(map[domain.Currency]float64, len(dayRates)+1)
+		for currency, rate := range dayRates {
+			rates[domain.Currency(currency)] = rate
+		}
+		rates[base] = 1.0
+		setHistoricalRates(ctx, cacheObject, parsedDate, base, rates)
+		if store != nil {
+			if err := store.SaveDay(ctx, parsedDate, base, rates); err != nil {
+				logger.Warn("Failed to archive historical rates", "base", base, "date", date, "error", err)
+			}
+		}
+	}
+	return nil
+}
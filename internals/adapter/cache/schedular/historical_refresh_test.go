@@ -0,0 +1,132 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// historicalCache records historical writes and reports the bases listed in cached as hits.
+type historicalCache struct {
+	mockCache
+	cached map[domain.Currency]bool
+	writes map[domain.Currency]map[string]map[domain.Currency]float64
+}
+
+func (m *historicalCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	if m.writes == nil {
+		m.writes = make(map[domain.Currency]map[string]map[domain.Currency]float64)
+	}
+	if m.writes[base] == nil {
+		m.writes[base] = make(map[string]map[domain.Currency]float64)
+	}
+	m.writes[base][date.Format("2006-01-02")] = rates
+}
+
+func (m *historicalCache) GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool) {
+	return nil, m.cached[base]
+}
+
+type historicalAPIClient struct {
+	mockAPIClient
+	requested []domain.Currency
+	err       error
+}
+
+func (m *historicalAPIClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	m.requested = append(m.requested, base)
+	if m.err != nil {
+		return nil, m.err
+	}
+	day := map[string]float64{}
+	for _, target := range targets {
+		day[string(target)] = 2
+	}
+	return &domain.HistoricalTimeSeriesRatesResponse{
+		Base:  string(base),
+		Rates: map[string]map[string]float64{startDate.Format("2006-01-02"): day},
+	}, nil
+}
+
+func TestPreviousBusinessDay(t *testing.T) {
+	tests := map[string]string{
+		"2024-05-08T10:00:00Z": "2024-05-07", // Wednesday -> Tuesday
+		"2024-05-06T10:00:00Z": "2024-05-03", // Monday -> Friday
+		"2024-05-05T10:00:00Z": "2024-05-03", // Sunday -> Friday
+		"2024-05-04T10:00:00Z": "2024-05-03", // Saturday -> Friday
+	}
+	for now, want := range tests {
+		parsed, _ := time.Parse(time.RFC3339, now)
+		assert.Equal(t, want, previousBusinessDay(parsed).Format("2006-01-02"), now)
+	}
+}
+
+func TestRefreshHistorical_CachesMissingBases(t *testing.T) {
+	cache := &historicalCache{cached: map[domain.Currency]bool{"USD": true}}
+	api := &historicalAPIClient{}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+	day := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+
+	refreshHistorical(context.Background(), api, cache, rateSvc, RefreshOptions{}, day)
+
+	assert.ElementsMatch(t, []domain.Currency{"INR", "EUR"}, api.requested)
+	assert.Equal(t, 1.0, cache.writes["INR"]["2024-05-07"]["INR"])
+	assert.Equal(t, 2.0, cache.writes["INR"]["2024-05-07"]["USD"])
+	assert.NotContains(t, cache.writes, domain.Currency("USD"))
+}
+
+func TestRefreshHistorical_OnlyConfiguredBases(t *testing.T) {
+	cache := &historicalCache{}
+	api := &historicalAPIClient{}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR", "EUR"}}
+
+	refreshHistorical(context.Background(), api, cache, rateSvc, RefreshOptions{Bases: []domain.Currency{"INR"}}, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []domain.Currency{"INR"}, api.requested)
+}
+
+func TestRefreshHistorical_ProviderError(t *testing.T) {
+	cache := &historicalCache{}
+	api := &historicalAPIClient{err: errors.New("api error")}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	refreshHistorical(context.Background(), api, cache, rateSvc, RefreshOptions{}, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC))
+
+	assert.Len(t, api.requested, 2)
+	assert.Empty(t, cache.writes)
+}
+
+// recordingArchive records the bases and days saved to it.
+type recordingArchive struct {
+	saved map[domain.Currency][]string
+}
+
+func (m *recordingArchive) SaveDay(ctx context.Context, day time.Time, base domain.Currency, rates map[domain.Currency]float64) error {
+	m.saved[base] = append(m.saved[base], day.Format("2006-01-02"))
+	return nil
+}
+
+func (m *recordingArchive) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	return nil, nil
+}
+
+func (m *recordingArchive) Export(ctx context.Context, filter archive.Filter, fn func(archive.Row) error) error {
+	return nil
+}
+
+func (m *recordingArchive) Close() error { return nil }
+
+func TestRefreshHistorical_Archives(t *testing.T) {
+	store := &recordingArchive{saved: map[domain.Currency][]string{}}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	refreshHistorical(context.Background(), &historicalAPIClient{}, &historicalCache{}, rateSvc, RefreshOptions{Archive: store}, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, map[domain.Currency][]string{"USD": {"2024-05-07"}, "INR": {"2024-05-07"}}, store.saved)
+}
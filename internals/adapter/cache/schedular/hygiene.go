@@ -0,0 +1,46 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/core/domain"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	hygieneLockKey = "cache_hygiene_lock"
+	hygieneLoop    = "Cache hygiene"
+)
+
+// StartCacheHygieneWithLock sweeps the cache every opts.Interval for keys nothing will read
+// or expire (see cache.Janitor), including locks the refresh loops left behind without a
+// TTL. historyDays is how far back historical rates are still served.
+func StartCacheHygieneWithLock(ctx context.Context, opts RefreshOptions, redisClient *redis.Client, historyDays int) {
+	janitor := cache.NewJanitor(redisClient, opts.logger(), historyDays, refreshLockKey, metalsRefreshLockKey, historicalRefreshLockKey, hygieneLockKey, retentionLockKey, snapshotLockKey, leaderKey)
+	workCtx := context.WithoutCancel(ctx)
+	loop := opts.tracker().loop(hygieneLoop, opts.Interval)
+	runRefreshLoop(ctx, loop, opts, func() {
+		runID, runCtx, runOpts := beginRun(workCtx, opts)
+		withRefreshLock(runCtx, redisClient, runOpts, hygieneLockKey, func() {
+			loop.run(runID, func() map[domain.Currency]error {
+				sweepCache(runCtx, janitor, runOpts.logger())
+				return nil
+			})
+		})
+	})
+}
+
+func sweepCache(ctx context.Context, janitor *cache.Janitor, logger *slog.Logger) {
+	start := time.Now()
+	report, err := janitor.Sweep(ctx)
+	if err != nil {
+		logger.Error("Error during cache hygiene sweep", "error", err)
+	}
+	logger.Info("Cache hygiene sweep finished",
+		"duration", time.Since(start).Round(time.Millisecond), "scanned", report.Scanned, "deleted", report.Deleted(),
+		"unsupported_base", report.UnsupportedBase, "undecodable", report.Undecodable, "missing_ttl", report.MissingTTL,
+		"out_of_range", report.OutOfRange, "stale_locks", report.StaleLocks)
+}
@@ -0,0 +1,38 @@
+package schedular
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartCacheHygieneWithLock_SweepsStaleLocks(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	mini.Set(refreshLockKey, "crashed-instance") // no TTL
+	mini.Set("latest:ZZZ", `{"rates":{},"timestamp":"2024-05-07T10:00:00Z"}`)
+	mini.SetTTL("latest:ZZZ", time.Hour)
+	tracker := NewTracker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		StartCacheHygieneWithLock(ctx, RefreshOptions{Interval: time.Hour, Tracker: tracker}, redisClient, 90)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		statuses := tracker.Statuses()
+		return len(statuses) == 1 && statuses[0].LastRunAt != nil && !statuses[0].HoldsLock
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.False(t, mini.Exists(refreshLockKey))
+	assert.False(t, mini.Exists("latest:ZZZ"))
+	assert.False(t, mini.Exists(hygieneLockKey), "the sweep releases its own lock")
+}
@@ -0,0 +1,107 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const leaderKey = "scheduler_leader"
+
+// LeaderElection picks one replica to own the background refreshes for as long as it stays
+// healthy. The leader holds a Redis lease that it renews every third of the lease; if it
+// stops renewing (crash, network split) the lease expires and another replica takes over
+// on its next attempt. Followers skip refresh cycles instead of all racing for the
+// per-cycle lock every interval.
+type LeaderElection struct {
+	lock   *cache.RedisLock
+	lease  time.Duration
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	leader bool
+	since  time.Time
+}
+
+func NewLeaderElection(client *redis.Client, lease time.Duration, logger *slog.Logger) *LeaderElection {
+	return &LeaderElection{
+		lock:   cache.NewRedisLock(client, leaderKey, lease, logger),
+		lease:  lease,
+		logger: logger,
+	}
+}
+
+// Start campaigns for leadership until ctx is cancelled, then gives up the lease if it
+// holds it so another replica can take over straight away.
+func (e *LeaderElection) Start(ctx context.Context) {
+	e.logger.Info("Leader election started", "lease", e.lease)
+	ticker := time.NewTicker(e.lease / 3)
+	defer ticker.Stop()
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if e.IsLeader() {
+				if err := e.lock.Release(context.Background()); err != nil {
+					e.logger.Error("Error releasing scheduler leadership", "error", err)
+				}
+				e.setLeader(false)
+			}
+			e.logger.Info("Leader election stopping")
+			return
+		}
+	}
+}
+
+// campaign renews the lease when this replica leads and tries to take it otherwise.
+func (e *LeaderElection) campaign(ctx context.Context) {
+	var (
+		leader bool
+		err    error
+	)
+	if e.IsLeader() {
+		leader, err = e.lock.Extend(ctx)
+	} else {
+		leader, err = e.lock.TryAcquire(ctx)
+	}
+	if err != nil {
+		// Without Redis nobody can renew or take the lease. Step down so this replica doesn't
+		// keep refreshing on a lease that may already have expired.
+		e.logger.Error("Error renewing scheduler leadership", "error", err)
+		leader = false
+	}
+	e.setLeader(leader)
+}
+
+func (e *LeaderElection) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if leader == e.leader {
+		return
+	}
+	e.leader = leader
+	if leader {
+		e.since = time.Now()
+		e.logger.Info("This instance is now the scheduler leader")
+	} else {
+		e.logger.Info("This instance is no longer the scheduler leader", "led_for", time.Since(e.since).Round(time.Second))
+	}
+}
+
+// IsLeader reports whether this replica currently holds the lease.
+func (e *LeaderElection) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// follower reports whether refreshes should be left to another replica. A nil election
+// means leader election is off and every replica contends for the per-cycle lock.
+func (e *LeaderElection) follower() bool {
+	return e != nil && !e.IsLeader()
+}
@@ -0,0 +1,95 @@
+package schedular
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderElection_OneLeaderAtATime(t *testing.T) {
+	mini, _ := miniredis.Run()
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	ctx := context.Background()
+
+	first := NewLeaderElection(client, 30*time.Second, discardLogger)
+	second := NewLeaderElection(client, 30*time.Second, discardLogger)
+
+	first.campaign(ctx)
+	second.campaign(ctx)
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+
+	// Renewing keeps the lease well past its original expiry.
+	mini.FastForward(20 * time.Second)
+	first.campaign(ctx)
+	mini.FastForward(20 * time.Second)
+	second.campaign(ctx)
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+}
+
+func TestLeaderElection_TakeoverAfterLeaseExpires(t *testing.T) {
+	mini, _ := miniredis.Run()
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	ctx := context.Background()
+
+	first := NewLeaderElection(client, 30*time.Second, discardLogger)
+	second := NewLeaderElection(client, 30*time.Second, discardLogger)
+	first.campaign(ctx)
+
+	// first stops renewing, e.g. because it crashed.
+	mini.FastForward(31 * time.Second)
+	second.campaign(ctx)
+	assert.True(t, second.IsLeader())
+
+	first.campaign(ctx)
+	assert.False(t, first.IsLeader())
+}
+
+func TestLeaderElection_ReleasesOnStop(t *testing.T) {
+	mini, _ := miniredis.Run()
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+
+	first := NewLeaderElection(client, 30*time.Second, discardLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		first.Start(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, first.IsLeader, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.False(t, first.IsLeader())
+	assert.False(t, mini.Exists(leaderKey))
+}
+
+func TestRefreshCacheWithLockRetry_FollowerSkips(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	leader := NewLeaderElection(redisClient, 30*time.Second, discardLogger)
+	follower := NewLeaderElection(redisClient, 30*time.Second, discardLogger)
+	leader.campaign(context.Background())
+	follower.campaign(context.Background())
+
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Tracker: NewTracker(), Leader: follower}, rateSvc)
+	assert.Empty(t, cache.setLatestRatesCalls)
+
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Tracker: NewTracker(), Leader: leader}, rateSvc)
+	assert.Len(t, cache.setLatestRatesCalls, 2)
+}
@@ -0,0 +1,65 @@
+package schedular
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const pauseKey = "scheduler_paused"
+
+// PauseState is stored in Redis so every replica sees the same pause.
+type PauseState struct {
+	Paused bool       `json:"paused"`
+	Reason string     `json:"reason,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+// PauseSwitch pauses and resumes background refreshes across all replicas, e.g. during
+// a provider maintenance window. Paused loops keep their schedule but skip every cycle.
+type PauseSwitch struct {
+	client *redis.Client
+}
+
+func NewPauseSwitch(client *redis.Client) *PauseSwitch {
+	return &PauseSwitch{client: client}
+}
+
+func (p *PauseSwitch) Pause(ctx context.Context, reason string) error {
+	now := time.Now().UTC()
+	data, err := json.Marshal(PauseState{Paused: true, Reason: reason, Since: &now})
+	if err != nil {
+		return err
+	}
+	if err := p.client.Set(ctx, pauseKey, data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to pause scheduler: %w", err)
+	}
+	return nil
+}
+
+func (p *PauseSwitch) Resume(ctx context.Context) error {
+	if err := p.client.Del(ctx, pauseKey).Err(); err != nil {
+		return fmt.Errorf("failed to resume scheduler: %w", err)
+	}
+	return nil
+}
+
+func (p *PauseSwitch) State(ctx context.Context) (PauseState, error) {
+	data, err := p.client.Get(ctx, pauseKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return PauseState{}, nil
+	}
+	if err != nil {
+		return PauseState{}, fmt.Errorf("failed to read scheduler pause state: %w", err)
+	}
+
+	var state PauseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return PauseState{}, fmt.Errorf("invalid scheduler pause state: %w", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,65 @@
+package schedular
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseSwitch_PauseAndResume(t *testing.T) {
+	mini, _ := miniredis.Run()
+	pause := NewPauseSwitch(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+	ctx := context.Background()
+
+	state, err := pause.State(ctx)
+	assert.NoError(t, err)
+	assert.False(t, state.Paused)
+
+	assert.NoError(t, pause.Pause(ctx, "provider maintenance"))
+	state, err = pause.State(ctx)
+	assert.NoError(t, err)
+	assert.True(t, state.Paused)
+	assert.Equal(t, "provider maintenance", state.Reason)
+	assert.WithinDuration(t, time.Now(), *state.Since, time.Second)
+
+	assert.NoError(t, pause.Resume(ctx))
+	state, err = pause.State(ctx)
+	assert.NoError(t, err)
+	assert.False(t, state.Paused)
+}
+
+func TestPauseSwitch_InvalidState(t *testing.T) {
+	mini, _ := miniredis.Run()
+	mini.Set(pauseKey, "not json")
+	pause := NewPauseSwitch(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+
+	_, err := pause.State(context.Background())
+	assert.ErrorContains(t, err, "invalid scheduler pause state")
+}
+
+func TestRefreshCacheWithLockRetry_SkipsWhilePaused(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	assert.NoError(t, NewPauseSwitch(redisClient).Pause(context.Background(), "incident"))
+
+	cache := &mockCache{}
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Workers: 1, Tracker: NewTracker()}, rateSvc)
+	assert.Empty(t, cache.setLatestRatesCalls)
+
+	assert.NoError(t, NewPauseSwitch(redisClient).Resume(context.Background()))
+	refreshCacheWithLockRetry(context.Background(), api, cache, redisClient, RefreshOptions{Workers: 1, Tracker: NewTracker()}, rateSvc)
+	assert.Len(t, cache.setLatestRatesCalls, 2)
+}
@@ -0,0 +1,72 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/core/domain"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	retentionLockKey = "retention_lock"
+	retentionLoop    = "Retention"
+)
+
+// AuditPruner removes audit entries older than a cutoff.
+type AuditPruner interface {
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RetentionPolicy says how long data is kept in full. Zero keeps it forever.
+type RetentionPolicy struct {
+	DailyRatesDays int // archived rates older than this are thinned to weekly closes
+	AuditLogDays   int // audit entries older than this are removed
+}
+
+// StartRetentionWithLock applies policy every opts.Interval: it thins out the rates in
+// store, which may be nil, and prunes auditLog, reporting how much was removed.
+func StartRetentionWithLock(ctx context.Context, opts RefreshOptions, redisClient *redis.Client, store archive.Compactor, auditLog AuditPruner, policy RetentionPolicy) {
+	workCtx := context.WithoutCancel(ctx)
+	loop := opts.tracker().loop(retentionLoop, opts.Interval)
+	runRefreshLoop(ctx, loop, opts, func() {
+		runID, runCtx, runOpts := beginRun(workCtx, opts)
+		withRefreshLock(runCtx, redisClient, runOpts, retentionLockKey, func() {
+			loop.run(runID, func() map[domain.Currency]error {
+				applyRetention(runCtx, store, auditLog, policy, time.Now(), runOpts.logger())
+				return nil
+			})
+		})
+	})
+}
+
+func applyRetention(ctx context.Context, store archive.Compactor, auditLog AuditPruner, policy RetentionPolicy, now time.Time, logger *slog.Logger) {
+	start := time.Now()
+	var compaction archive.Compaction
+	if store != nil && policy.DailyRatesDays > 0 {
+		before := startOfWeek(now.AddDate(0, 0, -policy.DailyRatesDays))
+		var err error
+		if compaction, err = store.Downsample(ctx, before); err != nil {
+			logger.Error("Error downsampling archived rates", "before", before.Format("2006-01-02"), "error", err)
+		}
+	}
+	var auditRemoved int64
+	if auditLog != nil && policy.AuditLogDays > 0 {
+		var err error
+		if auditRemoved, err = auditLog.Prune(ctx, now.AddDate(0, 0, -policy.AuditLogDays)); err != nil {
+			logger.Error("Error pruning audit log", "error", err)
+		}
+	}
+	logger.Info("Retention run finished",
+		"duration", time.Since(start).Round(time.Millisecond), "rates_deleted", compaction.Rows,
+		"bytes_reclaimed", compaction.Bytes, "audit_entries_deleted", auditRemoved)
+}
+
+// startOfWeek returns the Monday of t's week, at midnight UTC, so that only whole weeks are
+// downsampled.
+func startOfWeek(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -0,0 +1,58 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/archive"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCompactor struct {
+	before time.Time
+	err    error
+}
+
+func (f *fakeCompactor) Downsample(ctx context.Context, before time.Time) (archive.Compaction, error) {
+	f.before = before
+	return archive.Compaction{Rows: 800, Bytes: 65536}, f.err
+}
+
+type fakePruner struct {
+	before time.Time
+}
+
+func (f *fakePruner) Prune(ctx context.Context, before time.Time) (int64, error) {
+	f.before = before
+	return 3, nil
+}
+
+func TestApplyRetention(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	now := time.Date(2025, 4, 17, 10, 0, 0, 0, time.UTC) // a Thursday
+	store, auditLog := &fakeCompactor{}, &fakePruner{}
+
+	applyRetention(context.Background(), store, auditLog, RetentionPolicy{DailyRatesDays: 365, AuditLogDays: 90}, now, logger)
+	// A year back is Wednesday 2024-04-17; only the weeks before it are thinned.
+	assert.Equal(t, time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), store.before)
+	assert.Equal(t, now.AddDate(0, 0, -90), auditLog.before)
+
+	// A failing step doesn't stop the other, and zero keeps everything.
+	store, auditLog = &fakeCompactor{err: errors.New("database is locked")}, &fakePruner{}
+	applyRetention(context.Background(), store, auditLog, RetentionPolicy{DailyRatesDays: 365}, now, logger)
+	assert.False(t, store.before.IsZero())
+	assert.True(t, auditLog.before.IsZero())
+
+	applyRetention(context.Background(), nil, nil, RetentionPolicy{DailyRatesDays: 365, AuditLogDays: 90}, now, logger)
+}
+
+func TestStartOfWeek(t *testing.T) {
+	monday := time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)
+	for offset := range 7 {
+		assert.Equal(t, monday, startOfWeek(monday.AddDate(0, 0, offset).Add(13*time.Hour)))
+	}
+}
@@ -0,0 +1,52 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// beginRun starts one refresh cycle under a new run ID. The ID is attached to ctx, which
+// carries it to the provider metrics and the provenance of the cache entries the run writes,
+// and to the logger of the returned options, so every log line of the cycle has a run_id.
+func beginRun(ctx context.Context, opts RefreshOptions) (string, context.Context, RefreshOptions) {
+	runID := uuid.NewString()
+	opts.Logger = opts.logger().With("run_id", runID)
+	return runID, helpers.WithRunID(ctx, runID), opts
+}
+
+// runProvenance returns the provenance for entries written by the run in ctx, if any.
+func runProvenance(ctx context.Context) (cache.Provenance, bool) {
+	runID := helpers.RunID(ctx)
+	if runID == "" {
+		return cache.Provenance{}, false
+	}
+	return cache.Provenance{RunID: runID, WrittenAt: time.Now()}, true
+}
+
+// setLatestRates caches rates, recording the run in ctx as their provenance when the cache
+// supports it.
+func setLatestRates(ctx context.Context, cacheObject cache.Cache, base domain.Currency, rates map[domain.Currency]float64, timestamp time.Time) {
+	recorder, ok := cacheObject.(cache.ProvenanceRecorder)
+	provenance, fromRun := runProvenance(ctx)
+	if !ok || !fromRun {
+		cacheObject.SetLatestRates(base, rates, timestamp)
+		return
+	}
+	recorder.SetLatestRatesWithProvenance(base, rates, timestamp, provenance)
+}
+
+// setHistoricalRates is setLatestRates for the rates of one day.
+func setHistoricalRates(ctx context.Context, cacheObject cache.Cache, date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	recorder, ok := cacheObject.(cache.ProvenanceRecorder)
+	provenance, fromRun := runProvenance(ctx)
+	if !ok || !fromRun {
+		cacheObject.SetHistoricalRates(date, base, rates)
+		return
+	}
+	recorder.SetHistoricalRatesWithProvenance(date, base, rates, provenance)
+}
@@ -0,0 +1,71 @@
+package schedular
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshRun_TagsLogsStatusAndProvenance(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	cacheObject := cache.NewRedisCache(redisClient, time.Hour, time.Hour, nil, discardLogger)
+
+	var mu sync.Mutex
+	runIDs := make(map[string]bool)
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			mu.Lock()
+			runIDs[helpers.RunID(ctx)] = true
+			mu.Unlock()
+			return map[domain.Currency]float64{"INR": 82.5}, time.Now(), nil
+		},
+	}
+	var logs bytes.Buffer
+	tracker := NewTracker()
+	opts := RefreshOptions{Workers: 2, Tracker: tracker, Logger: slog.New(slog.NewJSONHandler(&logs, nil))}
+
+	refreshCacheWithLockRetry(context.Background(), api, cacheObject, redisClient, opts, &mockRateService{supportedCurrencies: []string{"USD", "INR"}})
+
+	runID := tracker.Statuses()[0].LastRunID
+	assert.NotEmpty(t, runID)
+	assert.Equal(t, map[string]bool{runID: true}, runIDs)
+	for _, base := range []domain.Currency{"USD", "INR"} {
+		provenance, found := cacheObject.(cache.ProvenanceRecorder).LatestRatesProvenance(base)
+		assert.True(t, found)
+		assert.Equal(t, runID, provenance.RunID)
+	}
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal(line, &record))
+		assert.Equal(t, runID, record["run_id"], string(line))
+	}
+
+	// The next cycle is a new run.
+	refreshCacheWithLockRetry(context.Background(), api, cacheObject, redisClient, opts, &mockRateService{supportedCurrencies: []string{"USD"}})
+	assert.NotEqual(t, runID, tracker.Statuses()[0].LastRunID)
+}
+
+func TestSetLatestRates_WithoutRunOrRecorder(t *testing.T) {
+	mockCache := &mockCache{}
+	setLatestRates(helpers.WithRunID(context.Background(), "run-1"), mockCache, "USD", map[domain.Currency]float64{"USD": 1}, time.Now())
+	assert.Len(t, mockCache.setLatestRatesCalls, 1)
+
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	cacheObject := cache.NewRedisCache(redisClient, time.Hour, time.Hour, nil, discardLogger)
+	setLatestRates(context.Background(), cacheObject, "USD", map[domain.Currency]float64{"USD": 1}, time.Now())
+	_, found := cacheObject.(cache.ProvenanceRecorder).LatestRatesProvenance("USD")
+	assert.False(t, found)
+}
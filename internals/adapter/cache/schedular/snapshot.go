@@ -0,0 +1,61 @@
+package schedular
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/core/domain"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	snapshotLockKey = "snapshot_lock"
+	snapshotLoop    = "Snapshot"
+)
+
+// SnapshotWriter writes the off-site snapshot of one day of archived rates.
+type SnapshotWriter interface {
+	Write(ctx context.Context, store archive.Store, date time.Time) (int, error)
+}
+
+// StartSnapshotsWithLock writes the snapshots of the days days before today every
+// opts.Interval. Days are written again on every run, so rates archived or imported late
+// still make it into their day's snapshot.
+func StartSnapshotsWithLock(ctx context.Context, opts RefreshOptions, redisClient *redis.Client, store archive.Store, snapshots SnapshotWriter, days int) {
+	workCtx := context.WithoutCancel(ctx)
+	loop := opts.tracker().loop(snapshotLoop, opts.Interval)
+	runRefreshLoop(ctx, loop, opts, func() {
+		runID, runCtx, runOpts := beginRun(workCtx, opts)
+		withRefreshLock(runCtx, redisClient, runOpts, snapshotLockKey, func() {
+			loop.run(runID, func() map[domain.Currency]error {
+				writeSnapshots(runCtx, store, snapshots, days, time.Now(), runOpts.logger())
+				return nil
+			})
+		})
+	})
+}
+
+// writeSnapshots writes the snapshots of the days days before now, oldest first. A day that
+// fails is logged and left for the next run.
+func writeSnapshots(ctx context.Context, store archive.Store, snapshots SnapshotWriter, days int, now time.Time, logger *slog.Logger) {
+	start := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+	written, rows, failed := 0, 0, 0
+	for ago := days; ago >= 1; ago-- {
+		date := today.AddDate(0, 0, -ago)
+		n, err := snapshots.Write(ctx, store, date)
+		if err != nil {
+			logger.Error("Error writing rate snapshot", "date", date.Format("2006-01-02"), "error", err)
+			failed++
+			continue
+		}
+		if n > 0 {
+			written++
+			rows += n
+		}
+	}
+	logger.Info("Snapshot run finished",
+		"duration", time.Since(start).Round(time.Millisecond), "days_written", written, "rows", rows, "days_failed", failed)
+}
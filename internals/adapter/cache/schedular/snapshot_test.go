@@ -0,0 +1,40 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/archive"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSnapshots struct {
+	dates []time.Time
+	fail  time.Time
+}
+
+func (f *fakeSnapshots) Write(ctx context.Context, store archive.Store, date time.Time) (int, error) {
+	f.dates = append(f.dates, date)
+	if date.Equal(f.fail) {
+		return 0, errors.New("connection reset")
+	}
+	return 40, nil
+}
+
+func TestWriteSnapshots(t *testing.T) {
+	now := time.Date(2025, 4, 17, 10, 0, 0, 0, time.UTC)
+	snapshots := &fakeSnapshots{fail: time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)}
+
+	writeSnapshots(context.Background(), nil, snapshots, 3, now, slog.New(slog.DiscardHandler))
+
+	// Today isn't over, so it is left for tomorrow's run; a failing day doesn't stop the rest.
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 16, 0, 0, 0, 0, time.UTC),
+	}, snapshots.dates)
+}
@@ -0,0 +1,130 @@
+package schedular
+
+import (
+	"currency-exchange/internals/core/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BaseStatus counts refresh outcomes for one base since this instance started.
+type BaseStatus struct {
+	Successes   int        `json:"successes"`
+	Failures    int        `json:"failures"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// LoopStatus describes one refresh loop as seen by this instance. Runs skipped because
+// another replica held the lock don't count as runs here.
+type LoopStatus struct {
+	Name           string                `json:"name"`
+	Interval       string                `json:"interval"`
+	LastRunAt      *time.Time            `json:"lastRunAt,omitempty"`
+	LastRunID      string                `json:"lastRunId,omitempty"`
+	LastDurationMs int64                 `json:"lastDurationMs"`
+	NextRunAt      *time.Time            `json:"nextRunAt,omitempty"`
+	HoldsLock      bool                  `json:"holdsLock"`
+	Bases          map[string]BaseStatus `json:"bases"`
+}
+
+// Tracker records what the refresh loops of this instance have been doing.
+type Tracker struct {
+	mu    sync.Mutex
+	loops map[string]*LoopStatus
+	now   func() time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		loops: make(map[string]*LoopStatus),
+		now:   time.Now,
+	}
+}
+
+// DefaultTracker is used by the refresh loops unless RefreshOptions says otherwise.
+var DefaultTracker = NewTracker()
+
+// Statuses returns a snapshot of every loop, ordered by name.
+func (t *Tracker) Statuses() []LoopStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]LoopStatus, 0, len(t.loops))
+	for _, loop := range t.loops {
+		status := *loop
+		status.Bases = make(map[string]BaseStatus, len(loop.Bases))
+		for base, baseStatus := range loop.Bases {
+			status.Bases[base] = baseStatus
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// loop registers a refresh loop and returns the handle it reports through.
+func (t *Tracker) loop(name string, interval time.Duration) *loopTracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.loops[name]; !ok {
+		t.loops[name] = &LoopStatus{
+			Name:     name,
+			Interval: interval.String(),
+			Bases:    make(map[string]BaseStatus),
+		}
+	}
+	return &loopTracker{tracker: t, name: name, interval: interval}
+}
+
+type loopTracker struct {
+	tracker  *Tracker
+	name     string
+	interval time.Duration
+}
+
+func (l *loopTracker) update(fn func(status *LoopStatus, now time.Time)) {
+	l.tracker.mu.Lock()
+	defer l.tracker.mu.Unlock()
+	fn(l.tracker.loops[l.name], l.tracker.now())
+}
+
+// scheduled records when the loop will next run.
+func (l *loopTracker) scheduled(in time.Duration) {
+	l.update(func(status *LoopStatus, now time.Time) {
+		next := now.Add(in)
+		status.NextRunAt = &next
+	})
+}
+
+// run records refresh, which is called while holding the refresh lock as run runID, and its
+// per-base results.
+func (l *loopTracker) run(runID string, refresh func() map[domain.Currency]error) {
+	var started time.Time
+	l.update(func(status *LoopStatus, now time.Time) {
+		started = now
+		status.HoldsLock = true
+		status.LastRunAt = &started
+		status.LastRunID = runID
+	})
+
+	results := refresh()
+
+	l.update(func(status *LoopStatus, now time.Time) {
+		status.HoldsLock = false
+		status.LastDurationMs = now.Sub(started).Milliseconds()
+		for base, err := range results {
+			baseStatus := status.Bases[string(base)]
+			if err != nil {
+				baseStatus.Failures++
+				baseStatus.LastError = err.Error()
+				baseStatus.LastErrorAt = &now
+			} else {
+				baseStatus.Successes++
+				baseStatus.LastSuccess = &now
+			}
+			status.Bases[string(base)] = baseStatus
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package schedular
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_RecordsRuns(t *testing.T) {
+	now := time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	loop := tracker.loop("Background refresh", time.Hour)
+	loop.scheduled(30 * time.Second)
+	loop.run("run-1", func() map[domain.Currency]error {
+		status := tracker.Statuses()[0]
+		assert.True(t, status.HoldsLock)
+		now = now.Add(1500 * time.Millisecond)
+		return map[domain.Currency]error{"USD": nil, "INR": errors.New("api error")}
+	})
+	loop.run("run-2", func() map[domain.Currency]error {
+		return map[domain.Currency]error{"USD": nil}
+	})
+
+	statuses := tracker.Statuses()
+	assert.Len(t, statuses, 1)
+	status := statuses[0]
+	assert.Equal(t, "Background refresh", status.Name)
+	assert.Equal(t, "1h0m0s", status.Interval)
+	assert.False(t, status.HoldsLock)
+	assert.Equal(t, time.Date(2024, 5, 7, 10, 0, 30, 0, time.UTC), *status.NextRunAt)
+	assert.Equal(t, now, *status.LastRunAt)
+	assert.Equal(t, "run-2", status.LastRunID)
+	assert.Equal(t, 2, status.Bases["USD"].Successes)
+	assert.Equal(t, 1, status.Bases["INR"].Failures)
+	assert.Equal(t, "api error", status.Bases["INR"].LastError)
+}
+
+func TestTracker_StatusesAreSnapshots(t *testing.T) {
+	tracker := NewTracker()
+	loop := tracker.loop("Metals refresh", 6*time.Hour)
+	loop.run("run-1", func() map[domain.Currency]error { return map[domain.Currency]error{"XAU": nil} })
+
+	snapshot := tracker.Statuses()
+	loop.run("run-1", func() map[domain.Currency]error { return map[domain.Currency]error{"XAU": nil} })
+
+	assert.Equal(t, 1, snapshot[0].Bases["XAU"].Successes)
+	assert.Equal(t, 2, tracker.Statuses()[0].Bases["XAU"].Successes)
+}
+
+func TestRefreshCacheWithLockRetry_ReportsToTracker(t *testing.T) {
+	mini, _ := miniredis.Run()
+	redisClient := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+
+	api := &mockAPIClient{
+		fetchLatestRates: func(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+			if base == "INR" {
+				return nil, time.Time{}, errors.New("api error")
+			}
+			return map[domain.Currency]float64{}, time.Now(), nil
+		},
+	}
+	rateSvc := &mockRateService{supportedCurrencies: []string{"USD", "INR"}}
+	tracker := NewTracker()
+
+	refreshCacheWithLockRetry(context.Background(), api, &mockCache{}, redisClient, RefreshOptions{Workers: 1, Tracker: tracker}, rateSvc)
+
+	status := tracker.Statuses()[0]
+	assert.Equal(t, backgroundRefreshLoop, status.Name)
+	assert.NotNil(t, status.LastRunAt)
+	assert.Equal(t, 1, status.Bases["USD"].Successes)
+	assert.Equal(t, 1, status.Bases["INR"].Failures)
+}
@@ -0,0 +1,65 @@
+package debuglog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const switchKey = "debug_body_logging"
+
+// State says whether request/response debug logging is on, and until when.
+type State struct {
+	Enabled bool       `json:"enabled"`
+	Until   *time.Time `json:"until,omitempty"`
+}
+
+// RedisSwitch turns debug logging on for every replica at once. The flag is stored with a
+// TTL, so a troubleshooting window closes by itself even if nobody turns it off.
+type RedisSwitch struct {
+	client *redis.Client
+}
+
+func NewRedisSwitch(client *redis.Client) *RedisSwitch {
+	return &RedisSwitch{client: client}
+}
+
+// Enable turns debug logging on for d.
+func (s *RedisSwitch) Enable(ctx context.Context, d time.Duration) error {
+	until := time.Now().Add(d).UTC()
+	data, err := json.Marshal(State{Enabled: true, Until: &until})
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, switchKey, data, d).Err(); err != nil {
+		return fmt.Errorf("failed to enable debug logging: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisSwitch) Disable(ctx context.Context) error {
+	if err := s.client.Del(ctx, switchKey).Err(); err != nil {
+		return fmt.Errorf("failed to disable debug logging: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisSwitch) State(ctx context.Context) (State, error) {
+	data, err := s.client.Get(ctx, switchKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read debug logging state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("invalid debug logging state: %w", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,48 @@
+package debuglog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisSwitch_EnableExpiresAndDisable(t *testing.T) {
+	mini, _ := miniredis.Run()
+	sw := NewRedisSwitch(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+	ctx := context.Background()
+
+	state, err := sw.State(ctx)
+	assert.NoError(t, err)
+	assert.False(t, state.Enabled)
+
+	assert.NoError(t, sw.Enable(ctx, 15*time.Minute))
+	state, err = sw.State(ctx)
+	assert.NoError(t, err)
+	assert.True(t, state.Enabled)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), *state.Until, time.Second)
+
+	// The window closes by itself.
+	mini.FastForward(15 * time.Minute)
+	state, err = sw.State(ctx)
+	assert.NoError(t, err)
+	assert.False(t, state.Enabled)
+
+	assert.NoError(t, sw.Enable(ctx, time.Hour))
+	assert.NoError(t, sw.Disable(ctx))
+	state, err = sw.State(ctx)
+	assert.NoError(t, err)
+	assert.False(t, state.Enabled)
+}
+
+func TestRedisSwitch_InvalidState(t *testing.T) {
+	mini, _ := miniredis.Run()
+	mini.Set(switchKey, "not json")
+	sw := NewRedisSwitch(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+
+	_, err := sw.State(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/helpers"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Report describes an unexpected failure, such as a recovered panic, for an error tracker.
+type Report struct {
+	Message   string
+	Stack     string
+	RequestID string
+	Method    string
+	Path      string
+	Time      time.Time
+}
+
+// Reporter forwards reports to an error tracker.
+type Reporter interface {
+	Report(ctx context.Context, report Report) error
+}
+
+// New returns a reporter for dsn, or nil when dsn is empty. DSNs on rollbar.com are sent to
+// Rollbar with the user part as access token, e.g. https://TOKEN@api.rollbar.com; any other
+// DSN is treated as a Sentry DSN.
+func New(dsn, environment string) (Reporter, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%q is not a valid DSN", dsn)
+	}
+	if u.Hostname() == "rollbar.com" || strings.HasSuffix(u.Hostname(), ".rollbar.com") {
+		token := u.User.Username()
+		if token == "" {
+			return nil, errors.New("rollbar DSN needs the access token as user, e.g. https://TOKEN@api.rollbar.com")
+		}
+		return NewRollbarReporter(u.Scheme+"://"+u.Host, token, environment, nil), nil
+	}
+	return NewSentryReporter(dsn, environment)
+}
+
+// SentryReporter sends reports to Sentry as error events.
+type SentryReporter struct {
+	hub *sentry.Hub
+}
+
+// NewSentryReporter builds a reporter for a Sentry DSN. Events are sent synchronously, so
+// callers that must not block should report from their own goroutine.
+func NewSentryReporter(dsn, environment string) (*SentryReporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		Transport:   sentry.NewHTTPSyncTransport(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Sentry: %w", err)
+	}
+	return &SentryReporter{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+func (s *SentryReporter) Report(ctx context.Context, report Report) error {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = report.Message
+	event.Timestamp = report.Time
+	event.Exception = []sentry.Exception{{Type: "panic", Value: report.Message}}
+	event.Extra["stack"] = report.Stack
+	if report.RequestID != "" {
+		event.Tags["request_id"] = report.RequestID
+	}
+	if report.Path != "" {
+		event.Request = &sentry.Request{Method: report.Method, URL: report.Path}
+	}
+
+	if id := s.hub.CaptureEvent(event); id == nil {
+		return errors.New("sentry dropped the event")
+	}
+	return nil
+}
+
+// RollbarReporter sends reports to the Rollbar item API.
+type RollbarReporter struct {
+	endpoint    string
+	token       string
+	environment string
+	httpClient  *http.Client
+}
+
+// NewRollbarReporter builds a Rollbar reporter for the API at baseURL. httpClient may be nil,
+// in which case helpers.DefaultHTTPClient is used.
+func NewRollbarReporter(baseURL, token, environment string, httpClient *http.Client) *RollbarReporter {
+	if httpClient == nil {
+		httpClient = helpers.DefaultHTTPClient
+	}
+	return &RollbarReporter{
+		endpoint:    strings.TrimSuffix(baseURL, "/") + "/api/1/item/",
+		token:       token,
+		environment: environment,
+		httpClient:  httpClient,
+	}
+}
+
+type rollbarItem struct {
+	Data rollbarData `json:"data"`
+}
+
+type rollbarData struct {
+	Environment string          `json:"environment"`
+	Level       string          `json:"level"`
+	Platform    string          `json:"platform"`
+	Language    string          `json:"language"`
+	Timestamp   int64           `json:"timestamp"`
+	Body        rollbarBody     `json:"body"`
+	Request     *rollbarRequest `json:"request,omitempty"`
+	Custom      map[string]any  `json:"custom,omitempty"`
+}
+
+type rollbarBody struct {
+	Message rollbarMessage `json:"message"`
+}
+
+type rollbarMessage struct {
+	Body  string `json:"body"`
+	Stack string `json:"stack,omitempty"`
+}
+
+type rollbarRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+func (r *RollbarReporter) Report(ctx context.Context, report Report) error {
+	data := rollbarData{
+		Environment: r.environment,
+		Level:       "error",
+		Platform:    "go",
+		Language:    "go",
+		Timestamp:   report.Time.Unix(),
+		Body:        rollbarBody{Message: rollbarMessage{Body: report.Message, Stack: report.Stack}},
+	}
+	if report.Path != "" {
+		data.Request = &rollbarRequest{Method: report.Method, URL: report.Path}
+	}
+	if report.RequestID != "" {
+		data.Custom = map[string]any{"request_id": report.RequestID}
+	}
+	body, err := json.Marshal(rollbarItem{Data: data})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build Rollbar request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rollbar-Access-Token", r.token)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send report to Rollbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("rollbar returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testReport = Report{
+	Message:   "runtime error: index out of range",
+	Stack:     "goroutine 1 [running]:\nmain.main()",
+	RequestID: "req-1",
+	Method:    "GET",
+	Path:      "/v1/latest",
+	Time:      time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC),
+}
+
+func TestRollbarReporter(t *testing.T) {
+	var item rollbarItem
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/1/item/", r.URL.Path)
+		assert.Equal(t, "token", r.Header.Get("X-Rollbar-Access-Token"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&item))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := NewRollbarReporter(server.URL, "token", "prod", nil).Report(context.Background(), testReport)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", item.Data.Environment)
+	assert.Equal(t, testReport.Message, item.Data.Body.Message.Body)
+	assert.Equal(t, testReport.Stack, item.Data.Body.Message.Stack)
+	assert.Equal(t, &rollbarRequest{Method: "GET", URL: "/v1/latest"}, item.Data.Request)
+	assert.Equal(t, map[string]any{"request_id": "req-1"}, item.Data.Custom)
+}
+
+func TestRollbarReporter_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewRollbarReporter(server.URL, "bad", "", nil).Report(context.Background(), testReport)
+	assert.ErrorContains(t, err, "status 401")
+}
+
+func TestSentryReporter(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/42"
+	reporter, err := New(dsn, "staging")
+	assert.NoError(t, err)
+	assert.IsType(t, &SentryReporter{}, reporter)
+	assert.NoError(t, reporter.Report(context.Background(), testReport))
+
+	select {
+	case body := <-received:
+		assert.Contains(t, body, testReport.Message)
+		assert.Contains(t, body, `"request_id":"req-1"`)
+		assert.Contains(t, body, `"environment":"staging"`)
+	case <-time.After(time.Second):
+		t.Fatal("Sentry received no event")
+	}
+}
+
+func TestNew(t *testing.T) {
+	reporter, err := New("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, reporter)
+
+	reporter, err = New("https://token@api.rollbar.com", "prod")
+	assert.NoError(t, err)
+	assert.IsType(t, &RollbarReporter{}, reporter)
+
+	_, err = New("https://api.rollbar.com", "prod")
+	assert.ErrorContains(t, err, "access token")
+
+	_, err = New("not a dsn", "prod")
+	assert.ErrorContains(t, err, "not a valid DSN")
+}
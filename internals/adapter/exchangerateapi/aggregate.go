@@ -0,0 +1,248 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AggregationMethod decides how the rates reported by several providers are combined into one.
+type AggregationMethod string
+
+const (
+	AggregateMedian      AggregationMethod = "median"
+	AggregateMean        AggregationMethod = "mean"
+	AggregateTrimmedMean AggregationMethod = "trimmed-mean"
+)
+
+var ErrNotEnoughProviders = errors.New("not enough providers answered")
+
+func init() {
+	Register("aggregate", func(cfg *config.Config) (RateAPIClient, error) {
+		if len(cfg.AggregateProviders) < 2 {
+			return nil, errors.New("AGGREGATE_PROVIDERS must list at least two providers")
+		}
+		providers := make(map[string]RateAPIClient, len(cfg.AggregateProviders))
+		for _, name := range cfg.AggregateProviders {
+			if strings.EqualFold(name, "aggregate") {
+				return nil, errors.New("the aggregate provider cannot aggregate itself")
+			}
+			client, err := buildProvider(name, cfg)
+			if err != nil {
+				return nil, err
+			}
+			providers[name] = client
+		}
+		return NewAggregateClient(providers, AggregationMethod(cfg.AggregationMethod), cfg.AggregationTrim, cfg.AggregationMinProviders, DefaultMonitor)
+	})
+}
+
+// AggregateClient queries several providers concurrently and combines their answers, so a
+// single provider publishing a bad rate cannot skew the result on its own. Providers that
+// health reports as deprioritized sit out unless too few healthy ones remain.
+type AggregateClient struct {
+	providers    map[string]RateAPIClient
+	method       AggregationMethod
+	trim         float64
+	minProviders int
+	health       ProviderHealth
+}
+
+// NewAggregateClient combines providers with method. health may be nil, in which case every
+// provider is always asked.
+func NewAggregateClient(providers map[string]RateAPIClient, method AggregationMethod, trim float64, minProviders int, health ProviderHealth) (RateAPIClient, error) {
+	switch method {
+	case AggregateMedian, AggregateMean, AggregateTrimmedMean:
+	default:
+		return nil, fmt.Errorf("unknown aggregation method %q", method)
+	}
+	if trim < 0 || trim >= 0.5 {
+		return nil, fmt.Errorf("aggregation trim must be in [0, 0.5), got %v", trim)
+	}
+	if minProviders < 1 {
+		minProviders = 1
+	}
+	return &AggregateClient{
+		providers:    providers,
+		method:       method,
+		trim:         trim,
+		minProviders: minProviders,
+		health:       health,
+	}, nil
+}
+
+// members returns the providers to ask, leaving out deprioritized ones while enough
+// healthy providers remain to reach the quorum.
+func (c *AggregateClient) members() map[string]RateAPIClient {
+	if c.health == nil {
+		return c.providers
+	}
+	healthy := make(map[string]RateAPIClient, len(c.providers))
+	for name, provider := range c.providers {
+		if c.health.Healthy(name) {
+			healthy[name] = provider
+		}
+	}
+	if len(healthy) < c.minProviders {
+		providerLog().Warn("Too few healthy providers to aggregate, asking deprioritized ones too", "healthy", len(healthy))
+		return c.providers
+	}
+	return healthy
+}
+
+type latestResult struct {
+	name      string
+	rates     map[domain.Currency]float64
+	timestamp time.Time
+	err       error
+}
+
+func (c *AggregateClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	members := c.members()
+	results := make(chan latestResult, len(members))
+	var wg sync.WaitGroup
+	for name, provider := range members {
+		wg.Add(1)
+		go func(name string, provider RateAPIClient) {
+			defer wg.Done()
+			rates, timestamp, err := provider.FetchLatestRates(ctx, base, targets)
+			results <- latestResult{name: name, rates: rates, timestamp: timestamp, err: err}
+		}(name, provider)
+	}
+	wg.Wait()
+	close(results)
+
+	samples := make(map[domain.Currency][]float64, len(targets))
+	var latest time.Time
+	var errs []error
+	answered := 0
+	for result := range results {
+		if result.err != nil {
+			providerLog().Warn("Aggregated provider failed", "provider", result.name, "error", result.err)
+			errs = append(errs, fmt.Errorf("%s: %w", result.name, result.err))
+			continue
+		}
+		answered++
+		if result.timestamp.After(latest) {
+			latest = result.timestamp
+		}
+		for currency, rate := range result.rates {
+			samples[currency] = append(samples[currency], rate)
+		}
+	}
+	if answered < c.minProviders {
+		return nil, time.Time{}, fmt.Errorf("%w (%d of %d required): %w", ErrNotEnoughProviders, answered, c.minProviders, errors.Join(errs...))
+	}
+
+	rates := make(map[domain.Currency]float64, len(samples))
+	for currency, values := range samples {
+		rates[currency] = c.combine(values)
+	}
+	return rates, latest, nil
+}
+
+type historicalResult struct {
+	name     string
+	response *domain.HistoricalTimeSeriesRatesResponse
+	err      error
+}
+
+func (c *AggregateClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	members := c.members()
+	results := make(chan historicalResult, len(members))
+	var wg sync.WaitGroup
+	for name, provider := range members {
+		wg.Add(1)
+		go func(name string, provider RateAPIClient) {
+			defer wg.Done()
+			response, err := provider.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+			if err == nil && response == nil {
+				err = errors.New("empty response")
+			}
+			results <- historicalResult{name: name, response: response, err: err}
+		}(name, provider)
+	}
+	wg.Wait()
+	close(results)
+
+	samples := make(map[string]map[string][]float64)
+	var first *domain.HistoricalTimeSeriesRatesResponse
+	var errs []error
+	answered := 0
+	for result := range results {
+		if result.err != nil {
+			providerLog().Warn("Aggregated provider failed", "provider", result.name, "error", result.err)
+			errs = append(errs, fmt.Errorf("%s: %w", result.name, result.err))
+			continue
+		}
+		answered++
+		if first == nil {
+			first = result.response
+		}
+		for date, dayRates := range result.response.Rates {
+			if samples[date] == nil {
+				samples[date] = make(map[string][]float64, len(dayRates))
+			}
+			for currency, rate := range dayRates {
+				samples[date][currency] = append(samples[date][currency], rate)
+			}
+		}
+	}
+	if answered < c.minProviders {
+		return nil, fmt.Errorf("%w (%d of %d required): %w", ErrNotEnoughProviders, answered, c.minProviders, errors.Join(errs...))
+	}
+
+	response := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    first.Amount,
+		Base:      first.Base,
+		StartDate: first.StartDate,
+		EndDate:   first.EndDate,
+		Rates:     make(map[string]map[string]float64, len(samples)),
+	}
+	for date, dayRates := range samples {
+		response.Rates[date] = make(map[string]float64, len(dayRates))
+		for currency, values := range dayRates {
+			response.Rates[date][currency] = c.combine(values)
+		}
+	}
+	return response, nil
+}
+
+func (c *AggregateClient) combine(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	switch c.method {
+	case AggregateMean:
+		return mean(sorted)
+	case AggregateTrimmedMean:
+		drop := int(math.Floor(float64(len(sorted)) * c.trim))
+		return mean(sorted[drop : len(sorted)-drop])
+	default:
+		return median(sorted)
+	}
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// median expects values to be sorted.
+func median(sorted []float64) float64 {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,145 @@
+package exchangerateapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingProvider struct{}
+
+func (f *failingProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return nil, time.Time{}, errors.New("provider down")
+}
+func (f *failingProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	return nil, errors.New("provider down")
+}
+
+func TestAggregateClient_Median(t *testing.T) {
+	client, err := NewAggregateClient(map[string]RateAPIClient{
+		"a": &recordingProvider{rate: 80},
+		"b": &recordingProvider{rate: 81},
+		"c": &recordingProvider{rate: 500},
+	}, AggregateMedian, 0, 2, nil)
+	assert.NoError(t, err)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, rates["INR"])
+}
+
+func TestAggregateClient_MeanAndTrimmedMean(t *testing.T) {
+	providers := map[string]RateAPIClient{
+		"a": &recordingProvider{rate: 1},
+		"b": &recordingProvider{rate: 2},
+		"c": &recordingProvider{rate: 3},
+		"d": &recordingProvider{rate: 4},
+		"e": &recordingProvider{rate: 100},
+	}
+
+	meanClient, err := NewAggregateClient(providers, AggregateMean, 0, 1, nil)
+	assert.NoError(t, err)
+	rates, _, err := meanClient.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, rates["INR"])
+
+	trimmedClient, err := NewAggregateClient(providers, AggregateTrimmedMean, 0.2, 1, nil)
+	assert.NoError(t, err)
+	rates, _, err = trimmedClient.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, rates["INR"])
+}
+
+func TestAggregateClient_ToleratesFailuresAboveQuorum(t *testing.T) {
+	client, err := NewAggregateClient(map[string]RateAPIClient{
+		"a":    &recordingProvider{rate: 80},
+		"b":    &recordingProvider{rate: 82},
+		"down": &failingProvider{},
+	}, AggregateMedian, 0, 2, nil)
+	assert.NoError(t, err)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, rates["INR"])
+}
+
+func TestAggregateClient_BelowQuorum(t *testing.T) {
+	client, err := NewAggregateClient(map[string]RateAPIClient{
+		"a":    &recordingProvider{rate: 80},
+		"down": &failingProvider{},
+	}, AggregateMedian, 0, 2, nil)
+	assert.NoError(t, err)
+
+	_, _, err = client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrNotEnoughProviders)
+	assert.Contains(t, err.Error(), "provider down")
+}
+
+func TestAggregateClient_Historical(t *testing.T) {
+	client, err := NewAggregateClient(map[string]RateAPIClient{
+		"a": &recordingProvider{rate: 80},
+		"b": &recordingProvider{rate: 84},
+	}, AggregateMedian, 0, 2, nil)
+	assert.NoError(t, err)
+
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 82.0, resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestAggregateClient_InvalidSettings(t *testing.T) {
+	_, err := NewAggregateClient(nil, "mode", 0, 1, nil)
+	assert.Error(t, err)
+	_, err = NewAggregateClient(nil, AggregateTrimmedMean, 0.5, 1, nil)
+	assert.Error(t, err)
+}
+
+func TestAggregateRegistry_RequiresProviders(t *testing.T) {
+	_, err := NewProvider("aggregate", &config.Config{AggregateProviders: []string{"frankfurter"}, AggregationMethod: "median"})
+	assert.Error(t, err)
+
+	_, err = NewProvider("aggregate", &config.Config{AggregateProviders: []string{"frankfurter", "aggregate"}, AggregationMethod: "median"})
+	assert.Error(t, err)
+
+	client, err := NewProvider("aggregate", &config.Config{AggregateProviders: []string{"frankfurter", "ecb"}, AggregationMethod: "median", DateFmt: "2006-01-02"})
+	assert.NoError(t, err)
+	assert.IsType(t, &AggregateClient{}, client)
+}
+
+type deprioritizedSet map[string]bool
+
+func (h deprioritizedSet) Healthy(name string) bool { return !h[name] }
+
+func TestAggregateClient_SkipsDeprioritized(t *testing.T) {
+	providers := map[string]RateAPIClient{
+		"a":   &recordingProvider{rate: 80},
+		"b":   &recordingProvider{rate: 82},
+		"bad": &recordingProvider{rate: 500},
+	}
+	client, err := NewAggregateClient(providers, AggregateMean, 0, 2, deprioritizedSet{"bad": true})
+	assert.NoError(t, err)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, rates["INR"])
+	assert.Len(t, providers["bad"].(*recordingProvider).calls, 0)
+}
+
+func TestAggregateClient_UsesDeprioritizedBelowQuorum(t *testing.T) {
+	providers := map[string]RateAPIClient{
+		"a":   &recordingProvider{rate: 80},
+		"bad": &recordingProvider{rate: 84},
+	}
+	client, err := NewAggregateClient(providers, AggregateMean, 0, 2, deprioritizedSet{"bad": true})
+	assert.NoError(t, err)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 82.0, rates["INR"])
+}
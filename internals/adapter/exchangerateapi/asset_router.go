@@ -0,0 +1,118 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"time"
+)
+
+// AssetRoute sends every currency Matches accepts to Client.
+type AssetRoute struct {
+	Matches func(domain.Currency) bool
+	Client  RateAPIClient
+}
+
+// assetRouter sends each pair to the provider responsible for its asset class (fiat, crypto,
+// precious metals) and merges the results so callers see a single provider. Requests with a
+// non-fiat base go wholly to that base's provider.
+type assetRouter struct {
+	fiat   RateAPIClient
+	routes []AssetRoute
+}
+
+func NewAssetRouter(fiat RateAPIClient, routes ...AssetRoute) RateAPIClient {
+	return &assetRouter{
+		fiat:   fiat,
+		routes: routes,
+	}
+}
+
+func (r *assetRouter) providerFor(currency domain.Currency) RateAPIClient {
+	for _, route := range r.routes {
+		if route.Matches(currency) {
+			return route.Client
+		}
+	}
+	return r.fiat
+}
+
+// group partitions targets by the provider responsible for them, keeping the fiat group first.
+func (r *assetRouter) group(targets []domain.Currency) ([]RateAPIClient, map[RateAPIClient][]domain.Currency) {
+	order := []RateAPIClient{}
+	groups := make(map[RateAPIClient][]domain.Currency)
+	for _, target := range targets {
+		provider := r.providerFor(target)
+		if _, seen := groups[provider]; !seen {
+			order = append(order, provider)
+		}
+		groups[provider] = append(groups[provider], target)
+	}
+	for i, provider := range order {
+		if provider == r.fiat && i > 0 {
+			order[0], order[i] = order[i], order[0]
+		}
+	}
+	return order, groups
+}
+
+func (r *assetRouter) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	if provider := r.providerFor(base); provider != r.fiat {
+		return provider.FetchLatestRates(ctx, base, targets)
+	}
+
+	order, groups := r.group(targets)
+	if len(order) <= 1 {
+		return r.fiat.FetchLatestRates(ctx, base, targets)
+	}
+
+	rates := make(map[domain.Currency]float64, len(targets))
+	var timestamp time.Time
+	for i, provider := range order {
+		groupRates, groupTimestamp, err := provider.FetchLatestRates(ctx, base, groups[provider])
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if i == 0 {
+			timestamp = groupTimestamp
+		}
+		for currency, rate := range groupRates {
+			rates[currency] = rate
+		}
+	}
+	return rates, timestamp, nil
+}
+
+func (r *assetRouter) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	if provider := r.providerFor(baseCurrency); provider != r.fiat {
+		return provider.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	}
+
+	order, groups := r.group(targetCurrencies)
+	if len(order) <= 1 {
+		return r.fiat.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	}
+
+	var response *domain.HistoricalTimeSeriesRatesResponse
+	for _, provider := range order {
+		groupResponse, err := provider.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, groups[provider])
+		if err != nil {
+			return nil, err
+		}
+		if response == nil {
+			response = groupResponse
+			if response.Rates == nil {
+				response.Rates = make(map[string]map[string]float64)
+			}
+			continue
+		}
+		for date, dayRates := range groupResponse.Rates {
+			if response.Rates[date] == nil {
+				response.Rates[date] = make(map[string]float64, len(dayRates))
+			}
+			for currency, rate := range dayRates {
+				response.Rates[date][currency] = rate
+			}
+		}
+	}
+	return response, nil
+}
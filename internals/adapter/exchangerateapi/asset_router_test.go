@@ -0,0 +1,114 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingProvider returns a fixed rate for every requested target and remembers what it was asked.
+type recordingProvider struct {
+	rate    float64
+	calls   [][]domain.Currency
+	updated time.Time
+}
+
+func (p *recordingProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	p.calls = append(p.calls, targets)
+	rates := make(map[domain.Currency]float64)
+	for _, target := range targets {
+		rates[target] = p.rate
+	}
+	return rates, p.updated, nil
+}
+
+func (p *recordingProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	p.calls = append(p.calls, targets)
+	day := make(map[string]float64)
+	for _, target := range targets {
+		day[string(target)] = p.rate
+	}
+	return &domain.HistoricalTimeSeriesRatesResponse{Base: string(base), Rates: map[string]map[string]float64{"2024-05-07": day}}, nil
+}
+
+func TestAssetRouter_FiatOnly(t *testing.T) {
+	fiat := &recordingProvider{rate: 80}
+	crypto := &recordingProvider{rate: 0.0001}
+	router := NewAssetRouter(fiat, AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto})
+
+	rates, _, err := router.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+	assert.Len(t, crypto.calls, 0)
+}
+
+func TestAssetRouter_MixedTargetsMerged(t *testing.T) {
+	fiatUpdated := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	fiat := &recordingProvider{rate: 80, updated: fiatUpdated}
+	crypto := &recordingProvider{rate: 0.0001, updated: time.Now()}
+	router := NewAssetRouter(fiat, AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto})
+
+	rates, ts, err := router.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "BTC"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+	assert.Equal(t, 0.0001, rates["BTC"])
+	assert.Equal(t, fiatUpdated, ts)
+	assert.Equal(t, [][]domain.Currency{{"INR"}}, fiat.calls)
+	assert.Equal(t, [][]domain.Currency{{"BTC"}}, crypto.calls)
+}
+
+func TestAssetRouter_CryptoBaseGoesToCrypto(t *testing.T) {
+	fiat := &recordingProvider{rate: 80}
+	crypto := &recordingProvider{rate: 60000}
+	router := NewAssetRouter(fiat, AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto})
+
+	resp, err := router.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "BTC", []domain.Currency{"USD", "ETH"})
+	assert.NoError(t, err)
+	assert.Equal(t, 60000.0, resp.Rates["2024-05-07"]["USD"])
+	assert.Len(t, fiat.calls, 0)
+}
+
+func TestAssetRouter_HistoricalMixedMerged(t *testing.T) {
+	fiat := &recordingProvider{rate: 80}
+	crypto := &recordingProvider{rate: 0.0001}
+	router := NewAssetRouter(fiat, AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto})
+
+	resp, err := router.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR", "BTC"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, resp.Rates["2024-05-07"]["INR"])
+	assert.Equal(t, 0.0001, resp.Rates["2024-05-07"]["BTC"])
+}
+
+func TestAssetRouter_MetalsAndCryptoRoutedSeparately(t *testing.T) {
+	fiat := &recordingProvider{rate: 80}
+	crypto := &recordingProvider{rate: 0.0001}
+	metals := &recordingProvider{rate: 0.0004}
+	router := NewAssetRouter(fiat,
+		AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto},
+		AssetRoute{Matches: domain.Currency.IsMetal, Client: metals},
+	)
+
+	rates, _, err := router.FetchLatestRates(context.Background(), "USD", []domain.Currency{"XAU", "INR", "BTC", "XAG"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+	assert.Equal(t, 0.0001, rates["BTC"])
+	assert.Equal(t, 0.0004, rates["XAU"])
+	assert.Equal(t, [][]domain.Currency{{"INR"}}, fiat.calls)
+	assert.Equal(t, [][]domain.Currency{{"BTC"}}, crypto.calls)
+	assert.Equal(t, [][]domain.Currency{{"XAU", "XAG"}}, metals.calls)
+}
+
+func TestAssetRouter_MetalBaseGoesToMetals(t *testing.T) {
+	fiat := &recordingProvider{rate: 80}
+	metals := &recordingProvider{rate: 2300}
+	router := NewAssetRouter(fiat, AssetRoute{Matches: domain.Currency.IsMetal, Client: metals})
+
+	rates, _, err := router.FetchLatestRates(context.Background(), "XAU", []domain.Currency{"USD", "INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2300.0, rates["USD"])
+	assert.Len(t, fiat.calls, 0)
+}
@@ -0,0 +1,97 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+// BreakerSettings configures the circuit breaker placed in front of each provider.
+type BreakerSettings struct {
+	FailureThreshold uint32        // consecutive failures that open the breaker
+	OpenTimeout      time.Duration // how long the breaker stays open before probing again
+	HalfOpenRequests uint32        // probe requests allowed while half-open
+}
+
+// breakerClient stops calling a provider that keeps failing, so requests fail fast instead
+// of each one waiting out the full retry loop against an upstream that is down.
+type breakerClient struct {
+	name    string
+	client  RateAPIClient
+	breaker *gobreaker.CircuitBreaker
+}
+
+func WithCircuitBreaker(name string, client RateAPIClient, settings BreakerSettings) RateAPIClient {
+	return &breakerClient{
+		name:   name,
+		client: client,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: settings.HalfOpenRequests,
+			Timeout:     settings.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= settings.FailureThreshold
+			},
+			IsSuccessful: breakerSuccess,
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				providerLog().Warn("Circuit breaker changed state", "provider", name, "from", from.String(), "to", to.String())
+			},
+		}),
+	}
+}
+
+// breakerSuccess keeps errors that say nothing about the provider's health, such as an
+// unsupported currency, our own outbound throttling or a caller cancelling, from tripping the
+// breaker. Running out of time is a failure whatever else the error wraps: a provider that
+// doesn't answer within the timeout is as unhealthy as one that answers with an error.
+func breakerSuccess(err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return errors.Is(err, ErrProviderUnsupportedCurrency) ||
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, context.Canceled)
+}
+
+func (c *breakerClient) wrapErr(err error) error {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, c.name)
+	}
+	return err
+}
+
+type latestRates struct {
+	rates     map[domain.Currency]float64
+	timestamp time.Time
+}
+
+func (c *breakerClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	result, err := c.breaker.Execute(func() (interface{}, error) {
+		rates, timestamp, err := c.client.FetchLatestRates(ctx, base, targets)
+		return latestRates{rates: rates, timestamp: timestamp}, err
+	})
+	if err != nil {
+		return nil, time.Time{}, c.wrapErr(err)
+	}
+	latest := result.(latestRates)
+	return latest.rates, latest.timestamp, nil
+}
+
+func (c *breakerClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	result, err := c.breaker.Execute(func() (interface{}, error) {
+		return c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	})
+	if err != nil {
+		return nil, c.wrapErr(err)
+	}
+	return result.(*domain.HistoricalTimeSeriesRatesResponse), nil
+}
@@ -0,0 +1,90 @@
+package exchangerateapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider fails while err is set and counts how often it was actually called.
+type countingProvider struct {
+	err   error
+	calls int
+}
+
+func (p *countingProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, time.Time{}, p.err
+	}
+	return map[domain.Currency]float64{"INR": 80}, time.Now(), nil
+}
+
+func (p *countingProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &domain.HistoricalTimeSeriesRatesResponse{Base: string(base)}, nil
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	provider := &countingProvider{err: assert.AnError}
+	client := WithCircuitBreaker("test", provider, BreakerSettings{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+
+	for i := 0; i < 3; i++ {
+		_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+		assert.ErrorIs(t, err, assert.AnError)
+	}
+
+	_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = client.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3, provider.calls)
+}
+
+func TestCircuitBreaker_RecoversAfterTimeout(t *testing.T) {
+	provider := &countingProvider{err: assert.AnError}
+	client := WithCircuitBreaker("test", provider, BreakerSettings{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond, HalfOpenRequests: 1})
+
+	_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, assert.AnError)
+	_, _, err = client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	provider.err = nil
+	time.Sleep(30 * time.Millisecond)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+}
+
+func TestCircuitBreaker_UnsupportedCurrencyDoesNotTrip(t *testing.T) {
+	provider := &countingProvider{err: fmt.Errorf("%w: no rate published for XAU", ErrProviderUnsupportedCurrency)}
+	client := WithCircuitBreaker("test", provider, BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+
+	for i := 0; i < 3; i++ {
+		_, _, err := client.FetchLatestRates(context.Background(), "XAU", []domain.Currency{"INR"})
+		assert.ErrorIs(t, err, ErrProviderUnsupportedCurrency)
+	}
+	assert.Equal(t, 3, provider.calls)
+}
+
+func TestBreakerSuccess(t *testing.T) {
+	assert.True(t, breakerSuccess(nil))
+	assert.True(t, breakerSuccess(fmt.Errorf("%w: XAU", ErrProviderUnsupportedCurrency)))
+	assert.True(t, breakerSuccess(fmt.Errorf("%w for provider test: too slow", ErrRateLimited)))
+	assert.True(t, breakerSuccess(fmt.Errorf("Get latest: %w", context.Canceled)))
+
+	assert.False(t, breakerSuccess(fmt.Errorf("http status 503")))
+	assert.False(t, breakerSuccess(fmt.Errorf("Get latest: %w", context.DeadlineExceeded)))
+	// A deadline is a failure even alongside an error that is otherwise ignored.
+	assert.False(t, breakerSuccess(fmt.Errorf("%w: %w", context.Canceled, context.DeadlineExceeded)))
+}
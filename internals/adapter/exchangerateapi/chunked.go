@@ -0,0 +1,119 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// chunkedClient splits historical ranges spanning more than one calendar month into
+// monthly requests, fetched at most concurrency at a time and merged back into one
+// response, so a one-year query doesn't hinge on a single giant upstream request.
+type chunkedClient struct {
+	name        string
+	client      RateAPIClient
+	concurrency int
+	dateFmt     string
+}
+
+func WithChunking(name string, client RateAPIClient, concurrency int, dateFmt string) RateAPIClient {
+	return &chunkedClient{
+		name:        name,
+		client:      client,
+		concurrency: concurrency,
+		dateFmt:     dateFmt,
+	}
+}
+
+func (c *chunkedClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return c.client.FetchLatestRates(ctx, base, targets)
+}
+
+type dateRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// monthlyChunks splits [start, end] at calendar month boundaries.
+func monthlyChunks(start, end time.Time) []dateRange {
+	var chunks []dateRange
+	for chunkStart := start; !chunkStart.After(end); {
+		nextMonth := time.Date(chunkStart.Year(), chunkStart.Month()+1, 1, 0, 0, 0, 0, chunkStart.Location())
+		chunkEnd := nextMonth.AddDate(0, 0, -1)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		chunks = append(chunks, dateRange{start: chunkStart, end: chunkEnd})
+		chunkStart = nextMonth
+	}
+	return chunks
+}
+
+func (c *chunkedClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	chunks := monthlyChunks(startDate, endDate)
+	if len(chunks) <= 1 {
+		return c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	}
+	providerLog().Debug("Splitting historical request into monthly chunks", "provider", c.name, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt), "chunks", len(chunks))
+
+	// The first failing chunk cancels the rest; a partial series is no use to the caller.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*domain.HistoricalTimeSeriesRatesResponse, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk dateRange) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+
+			response, err := c.client.FetchHistoricalTimeSeriesRates(ctx, chunk.start, chunk.end, baseCurrency, targetCurrencies)
+			if err == nil && response == nil {
+				err = errors.New("empty response")
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %s..%s: %w", chunk.start.Format(c.dateFmt), chunk.end.Format(c.dateFmt), err)
+				cancel()
+				return
+			}
+			responses[i] = response
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		// Chunks cancelled because another one failed only add noise.
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	merged := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    responses[0].Amount,
+		Base:      responses[0].Base,
+		StartDate: responses[0].StartDate,
+		EndDate:   responses[len(responses)-1].EndDate,
+		Rates:     make(map[string]map[string]float64),
+	}
+	for _, response := range responses {
+		for date, dayRates := range response.Rates {
+			merged.Rates[date] = dayRates
+		}
+	}
+	return merged, nil
+}
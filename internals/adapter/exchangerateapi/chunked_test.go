@@ -0,0 +1,108 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seriesProvider answers every historical request with one rate per requested day.
+type seriesProvider struct {
+	mu       sync.Mutex
+	ranges   []dateRange
+	inFlight int32
+	peak     int32
+	failOn   time.Month
+}
+
+func (p *seriesProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return map[domain.Currency]float64{"EUR": 0.9}, time.Now(), nil
+}
+
+func (p *seriesProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	current := atomic.AddInt32(&p.inFlight, 1)
+	defer atomic.AddInt32(&p.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&p.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&p.peak, peak, current) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	p.mu.Lock()
+	p.ranges = append(p.ranges, dateRange{start: startDate, end: endDate})
+	p.mu.Unlock()
+
+	if startDate.Month() == p.failOn {
+		return nil, errors.New("upstream error")
+	}
+	response := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(base),
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Rates:     make(map[string]map[string]float64),
+	}
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		response.Rates[day.Format("2006-01-02")] = map[string]float64{"EUR": 0.9}
+	}
+	return response, nil
+}
+
+func TestMonthlyChunks(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	chunks := monthlyChunks(start, end)
+	assert.Equal(t, []dateRange{
+		{start: start, end: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), end: end},
+	}, chunks)
+}
+
+func TestWithChunking_SingleMonthPassesThrough(t *testing.T) {
+	provider := &seriesProvider{}
+	client := WithChunking("test", provider, 2, "2006-01-02")
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"EUR"})
+	assert.NoError(t, err)
+	assert.Len(t, provider.ranges, 1)
+	assert.Len(t, resp.Rates, 7)
+}
+
+func TestWithChunking_MergesMonthlyChunks(t *testing.T) {
+	provider := &seriesProvider{}
+	client := WithChunking("test", provider, 2, "2006-01-02")
+
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"EUR"})
+	assert.NoError(t, err)
+	assert.Len(t, provider.ranges, 12)
+	assert.LessOrEqual(t, provider.peak, int32(2))
+	assert.Len(t, resp.Rates, 366)
+	assert.Equal(t, "2023-06-01", resp.StartDate)
+	assert.Equal(t, "2024-05-31", resp.EndDate)
+	assert.Equal(t, "USD", resp.Base)
+}
+
+func TestWithChunking_FailsWhenAChunkFails(t *testing.T) {
+	provider := &seriesProvider{failOn: time.February}
+	client := WithChunking("test", provider, 1, "2006-01-02")
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"EUR"})
+	assert.ErrorContains(t, err, "chunk 2024-02-01..2024-02-29: upstream error")
+	assert.Nil(t, resp)
+}
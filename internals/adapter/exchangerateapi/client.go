@@ -3,17 +3,33 @@ package exchangerateapi
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"currency-exchange/internals/config"
 	"currency-exchange/internals/core/domain"
 	"currency-exchange/internals/helpers"
 )
 
+func init() {
+	Register("frankfurter", func(cfg *config.Config) (RateAPIClient, error) {
+		httpClient, err := helpers.NewHTTPClient(helpers.HTTPClientOptions{
+			Timeout:             cfg.ExternalAPITimeout,
+			ProxyURL:            cfg.ExternalAPIProxyURL,
+			MaxIdleConns:        cfg.ExternalAPIMaxIdleConns,
+			MaxIdleConnsPerHost: cfg.ExternalAPIMaxIdleConnsPerHost,
+			IdleConnTimeout:     cfg.ExternalAPIIdleConnTimeout,
+			DisableKeepAlives:   cfg.ExternalAPIDisableKeepAlives,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(helpers.NewFrankFurterAPI(cfg.Provider("frankfurter").BaseURL, cfg.DateFmt, httpClient)), nil
+	})
+}
+
 // RateAPIClient defines the interface for fetching exchange rates.
 type RateAPIClient interface {
 	FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error)
-	//FetchHistoricalRates(ctx context.Context, date time.Time, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, error)
 	FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error)
 }
 
@@ -33,10 +49,10 @@ func (c *ExRatesClient) FetchLatestRates(ctx context.Context, base domain.Curren
 		targetStrings[i] = string(t)
 	}
 
-	log.Printf("Fetching latest rates from API: Base=%s, Targets=%v", base, targetStrings)
-	exchangeRates, err := c.frankFurterAPI.GetLatest(string(base), targetStrings)
+	providerLog().Debug("Fetching latest rates", "provider", "frankfurter", "base", base, "targets", targetStrings)
+	exchangeRates, err := c.frankFurterAPI.GetLatest(ctx, string(base), targetStrings)
 	if err != nil {
-		log.Printf("Error fetching latest rates from API: %v", err)
+		providerLog().Warn("Failed to fetch latest rates", "provider", "frankfurter", "base", base, "error", err)
 		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from external API: %w", err)
 	}
 
@@ -47,42 +63,55 @@ func (c *ExRatesClient) FetchLatestRates(ctx context.Context, base domain.Curren
 
 	rateTime := exchangeRates.Date.ToTime()
 
-	log.Printf("Successfully fetched latest rates from API for %s on %s", exchangeRates.Base, exchangeRates.Date.ToTime())
+	providerLog().Debug("Fetched latest rates", "provider", "frankfurter", "base", exchangeRates.Base, "date", exchangeRates.Date.ToTime())
 	return result, rateTime, nil
 }
 
-// func (c *ExRatesClient) FetchHistoricalRates(ctx context.Context, date time.Time, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, error) {
-// 	targetStrings := make([]string, len(targets))
-// 	for i, t := range targets {
-// 		targetStrings[i] = string(t)
-// 	}
-
-// 	log.Printf("Fetching historical rates from API: Date=%s, Base=%s, Targets=%v", date.Format("2006-01-02"), base, targetStrings)
-// 	rates, err := exrates.On(string(base), date, targetStrings)
-// 	if err != nil {
-// 		log.Printf("Error fetching historical rates from API: %v", err)
-// 		return nil, fmt.Errorf("failed to fetch historical rates from external API: %w", err)
-// 	}
+// fetchHistoricalDay returns the rates for a single day using Frankfurter's /{date} endpoint.
+func (c *ExRatesClient) fetchHistoricalDay(ctx context.Context, date time.Time, base domain.Currency, targets []domain.Currency) (*domain.ExchangeResponse, error) {
+	targetStrings := make([]string, len(targets))
+	for i, t := range targets {
+		targetStrings[i] = string(t)
+	}
 
-// 	result := make(map[domain.Currency]float64)
-// 	for currencyStr, rate := range rates.Values {
-// 		result[domain.Currency(currencyStr)] = rate
-// 	}
+	providerLog().Debug("Fetching historical rates", "provider", "frankfurter", "base", base, "targets", targetStrings, "date", date.Format("2006-01-02"))
+	rates, err := c.frankFurterAPI.GetHistorical(ctx, string(base), targetStrings, date)
+	if err != nil {
+		providerLog().Warn("Failed to fetch historical rates", "provider", "frankfurter", "base", base, "date", date.Format("2006-01-02"), "error", err)
+		return nil, fmt.Errorf("failed to fetch historical rates from external API: %w", err)
+	}
 
-// 	log.Printf("Successfully fetched historical rates from API for %s on %s", rates.Base, rates.Date)
-// 	return result, nil
-// }
+	providerLog().Debug("Fetched historical rates", "provider", "frankfurter", "base", rates.Base, "date", rates.Date.ToTime())
+	return rates, nil
+}
 
+// FetchHistoricalTimeSeriesRates serves single-day ranges from the /{date} endpoint rather
+// than asking Frankfurter for a one-day time series.
 func (c *ExRatesClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	if startDate.Equal(endDate) {
+		rates, err := c.fetchHistoricalDay(ctx, startDate, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		date := rates.Date.ToTime().Format("2006-01-02")
+		return &domain.HistoricalTimeSeriesRatesResponse{
+			Amount:    rates.Amount,
+			Base:      rates.Base,
+			StartDate: date,
+			EndDate:   date,
+			Rates:     map[string]map[string]float64{date: rates.Rates},
+		}, nil
+	}
+
 	targetStrings := make([]string, len(targetCurrencies))
 	for i, t := range targetCurrencies {
 		targetStrings[i] = string(t)
 	}
 
-	log.Printf("Fetching historical rates from API: Date=%s TO Date = %s, Base=%s, Targets=%v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), baseCurrency, targetStrings)
-	rates, err := c.frankFurterAPI.GetHistoricalTimeSeries(string(baseCurrency), targetStrings, startDate, endDate)
+	providerLog().Debug("Fetching historical rates", "provider", "frankfurter", "base", baseCurrency, "targets", targetStrings, "start", startDate.Format("2006-01-02"), "end", endDate.Format("2006-01-02"))
+	rates, err := c.frankFurterAPI.GetHistoricalTimeSeries(ctx, string(baseCurrency), targetStrings, startDate, endDate)
 	if err != nil {
-		log.Printf("Error fetching historical time series rates from API: %v", err)
+		providerLog().Warn("Failed to fetch historical time series", "provider", "frankfurter", "base", baseCurrency, "error", err)
 		return nil, fmt.Errorf("failed to fetch historical timeseries rates from external API: %w", err)
 	}
 
@@ -17,12 +17,23 @@ type mockFrankFurterAPI struct {
 	latestErr  error
 	histResp   *domain.HistoricalTimeSeriesRatesResponse
 	histErr    error
+	dayResp    *domain.ExchangeResponse
+	dayErr     error
+	dayCalls   int
 }
 
-func (m *mockFrankFurterAPI) GetLatest(from string, to []string) (*domain.ExchangeResponse, error) {
+func (m *mockFrankFurterAPI) GetCurrencies(ctx context.Context) (map[string]string, error) {
+	return nil, nil
+}
+
+func (m *mockFrankFurterAPI) GetLatest(ctx context.Context, from string, to []string) (*domain.ExchangeResponse, error) {
 	return m.latestResp, m.latestErr
 }
-func (m *mockFrankFurterAPI) GetHistoricalTimeSeries(from string, to []string, start, end time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+func (m *mockFrankFurterAPI) GetHistorical(ctx context.Context, from string, to []string, date time.Time) (*domain.ExchangeResponse, error) {
+	m.dayCalls++
+	return m.dayResp, m.dayErr
+}
+func (m *mockFrankFurterAPI) GetHistoricalTimeSeries(ctx context.Context, from string, to []string, start, end time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error) {
 	return m.histResp, m.histErr
 }
 
@@ -86,3 +97,31 @@ func TestFetchHistoricalTimeSeriesRates_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, resp)
 }
+
+func TestFetchHistoricalTimeSeriesRates_SingleDayUsesDateEndpoint(t *testing.T) {
+	mockAPI := &mockFrankFurterAPI{
+		dayResp: &domain.ExchangeResponse{
+			Amount: 1,
+			Base:   "USD",
+			Date:   domain.CustomDate(time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)),
+			Rates:  map[string]float64{"INR": 82.5},
+		},
+		histErr: errors.New("time series should not be called"),
+	}
+	client := NewClient(mockAPI)
+	day := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), day, day, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mockAPI.dayCalls)
+	assert.Equal(t, "2024-05-07", resp.StartDate)
+	assert.Equal(t, 82.5, resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestFetchHistoricalTimeSeriesRates_SingleDayError(t *testing.T) {
+	mockAPI := &mockFrankFurterAPI{dayErr: errors.New("api error")}
+	client := NewClient(mockAPI)
+	day := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), day, day, "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+}
@@ -0,0 +1,234 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coinGeckoReference is always requested so fiat-only pairs can be crossed through it.
+const coinGeckoReference = "bitcoin"
+
+var coinGeckoIDs = map[domain.Currency]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+}
+
+func init() {
+	Register("coingecko", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("coingecko")
+		return NewCoinGeckoClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+// coinGeckoSimplePrice maps coin id -> lower case vs currency (plus "last_updated_at") -> value.
+type coinGeckoSimplePrice map[string]map[string]float64
+
+type coinGeckoMarketChart struct {
+	Prices [][2]float64 `json:"prices"`
+}
+
+// CoinGeckoClient quotes crypto assets against fiat using the CoinGecko API. Every rate is
+// derived from coin prices, so fiat/fiat pairs work too but are better served by a fiat provider.
+type CoinGeckoClient struct {
+	baseURL string
+	apiKey  string
+	dateFmt string
+}
+
+func NewCoinGeckoClient(baseURL, apiKey, dateFmt string) RateAPIClient {
+	return &CoinGeckoClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		dateFmt: dateFmt,
+	}
+}
+
+func (c *CoinGeckoClient) params() url.Values {
+	params := url.Values{}
+	if c.apiKey != "" {
+		params.Set("x_cg_demo_api_key", c.apiKey)
+	}
+	return params
+}
+
+// split partitions the requested currencies into coin ids and lower case fiat codes.
+func (c *CoinGeckoClient) split(currencies []domain.Currency) ([]string, []string, error) {
+	ids := map[string]bool{coinGeckoReference: true}
+	fiats := map[string]bool{"usd": true}
+	for _, currency := range currencies {
+		if !currency.IsCrypto() {
+			fiats[strings.ToLower(string(currency))] = true
+			continue
+		}
+		id, ok := coinGeckoIDs[currency]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: CoinGecko has no id mapping for %s", ErrProviderUnsupportedCurrency, currency)
+		}
+		ids[id] = true
+	}
+	return sortedKeys(ids), sortedKeys(fiats), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// usdValue returns the USD value of one unit of currency using the given coin price table.
+func usdValue(currency domain.Currency, prices map[string]map[string]float64) (float64, bool) {
+	if currency.IsCrypto() {
+		value, ok := prices[coinGeckoIDs[currency]]["usd"]
+		return value, ok && value > 0
+	}
+	ref := prices[coinGeckoReference]
+	inFiat, ok := ref[strings.ToLower(string(currency))]
+	if !ok || inFiat == 0 {
+		return 0, false
+	}
+	return ref["usd"] / inFiat, true
+}
+
+func pricesToRates(prices map[string]map[string]float64, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, error) {
+	baseUSD, ok := usdValue(base, prices)
+	if !ok {
+		return nil, fmt.Errorf("%w: CoinGecko returned no price for %s", ErrProviderUnsupportedCurrency, base)
+	}
+	rates := make(map[domain.Currency]float64, len(targets))
+	for _, target := range targets {
+		targetUSD, ok := usdValue(target, prices)
+		if !ok {
+			providerLog().Debug("No price returned, skipping", "provider", "coingecko", "target", target)
+			continue
+		}
+		rates[target] = baseUSD / targetUSD
+	}
+	return rates, nil
+}
+
+func (c *CoinGeckoClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "coingecko", "base", base, "targets", targets)
+	ids, fiats, err := c.split(append([]domain.Currency{base}, targets...))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	params := c.params()
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("vs_currencies", strings.Join(fiats, ","))
+	params.Set("include_last_updated_at", "true")
+
+	response := coinGeckoSimplePrice{}
+	if err := helpers.GetJSON(ctx, c.baseURL+"simple/price", params, &response); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from CoinGecko: %w", err)
+	}
+
+	rates, err := pricesToRates(response, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var lastUpdated int64
+	for _, prices := range response {
+		if ts := int64(prices["last_updated_at"]); ts > lastUpdated {
+			lastUpdated = ts
+		}
+	}
+	return rates, time.Unix(lastUpdated, 0).UTC(), nil
+}
+
+// FetchHistoricalTimeSeriesRates builds daily closes from CoinGecko market charts. A chart is
+// needed per coin in USD plus one per non-USD fiat for the reference coin.
+func (c *CoinGeckoClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "coingecko", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	ids, fiats, err := c.split(append([]domain.Currency{baseCurrency}, targetCurrencies...))
+	if err != nil {
+		return nil, err
+	}
+
+	// date -> coin id -> vs currency -> closing price
+	daily := make(map[string]map[string]map[string]float64)
+	record := func(id, vs string, closes map[string]float64) {
+		for date, price := range closes {
+			if daily[date] == nil {
+				daily[date] = make(map[string]map[string]float64)
+			}
+			if daily[date][id] == nil {
+				daily[date][id] = make(map[string]float64)
+			}
+			daily[date][id][vs] = price
+		}
+	}
+
+	for _, id := range ids {
+		closes, err := c.dailyCloses(ctx, id, "usd", startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		record(id, "usd", closes)
+	}
+	for _, fiat := range fiats {
+		if fiat == "usd" {
+			continue
+		}
+		closes, err := c.dailyCloses(ctx, coinGeckoReference, fiat, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		record(coinGeckoReference, fiat, closes)
+	}
+
+	response := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: startDate.Format(c.dateFmt),
+		EndDate:   endDate.Format(c.dateFmt),
+		Rates:     make(map[string]map[string]float64, len(daily)),
+	}
+	for date, prices := range daily {
+		rates, err := pricesToRates(prices, baseCurrency, targetCurrencies)
+		if err != nil {
+			providerLog().Debug("Skipping day in series", "provider", "coingecko", "date", date, "error", err)
+			continue
+		}
+		response.Rates[date] = toStringMap(rates)
+	}
+	return response, nil
+}
+
+// dailyCloses returns the last price of each UTC day in the range.
+func (c *CoinGeckoClient) dailyCloses(ctx context.Context, id, vsCurrency string, startDate, endDate time.Time) (map[string]float64, error) {
+	params := c.params()
+	params.Set("vs_currency", vsCurrency)
+	params.Set("from", strconv.FormatInt(startDate.Unix(), 10))
+	params.Set("to", strconv.FormatInt(endDate.AddDate(0, 0, 1).Unix()-1, 10))
+
+	chart := &coinGeckoMarketChart{}
+	if err := helpers.GetJSON(ctx, c.baseURL+"coins/"+id+"/market_chart/range", params, chart); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s/%s market chart from CoinGecko: %w", id, vsCurrency, err)
+	}
+
+	closes := make(map[string]float64)
+	lastSeen := make(map[string]int64)
+	for _, point := range chart.Prices {
+		ms := int64(point[0])
+		date := time.UnixMilli(ms).UTC().Format(c.dateFmt)
+		if ms >= lastSeen[date] {
+			lastSeen[date] = ms
+			closes[date] = point[1]
+		}
+	}
+	return closes, nil
+}
@@ -0,0 +1,79 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoinGeckoFetchLatestRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/simple/price", r.URL.Path)
+		assert.Equal(t, "bitcoin,ethereum", r.URL.Query().Get("ids"))
+		assert.Equal(t, "inr,usd", r.URL.Query().Get("vs_currencies"))
+		w.Write([]byte(`{
+			"bitcoin": {"usd": 60000, "inr": 5000000, "last_updated_at": 1715040000},
+			"ethereum": {"usd": 3000, "inr": 250000, "last_updated_at": 1715040060}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewCoinGeckoClient(server.URL+"/", "", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "BTC", []domain.Currency{"USD", "INR", "ETH"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 60000.0, rates["USD"], 1e-6)
+	assert.InDelta(t, 5000000.0, rates["INR"], 1e-6)
+	assert.InDelta(t, 20.0, rates["ETH"], 1e-9)
+	assert.Equal(t, time.Unix(1715040060, 0).UTC(), ts)
+}
+
+func TestCoinGeckoFetchLatestRates_FiatBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bitcoin": {"usd": 50000, "eur": 40000, "last_updated_at": 1715040000}}`))
+	}))
+	defer server.Close()
+
+	client := NewCoinGeckoClient(server.URL+"/", "", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "EUR", []domain.Currency{"BTC", "USD"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0/40000, rates["BTC"], 1e-12)
+	assert.InDelta(t, 1.25, rates["USD"], 1e-9)
+}
+
+func TestCoinGeckoFetchLatestRates_UnmappedCoin(t *testing.T) {
+	domain.CryptoCurrencies["DOGE"] = true
+	defer delete(domain.CryptoCurrencies, "DOGE")
+
+	client := NewCoinGeckoClient("http://unused/", "", "2006-01-02")
+	_, _, err := client.FetchLatestRates(context.Background(), "DOGE", []domain.Currency{"USD"})
+	assert.ErrorIs(t, err, ErrProviderUnsupportedCurrency)
+}
+
+func TestCoinGeckoFetchHistoricalTimeSeriesRates(t *testing.T) {
+	day1 := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.Query().Get("vs_currency") {
+		case "/coins/bitcoin/market_chart/range?usd":
+			w.Write([]byte(`{"prices": [[1714953600000, 60000], [1714993200000, 62000], [1715040000000, 63000]]}`))
+		case "/coins/bitcoin/market_chart/range?inr":
+			w.Write([]byte(`{"prices": [[1714993200000, 5166000], [1715040000000, 5260500]]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCoinGeckoClient(server.URL+"/", "", "2006-01-02")
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), day1, day2, "BTC", []domain.Currency{"INR", "USD"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 62000.0, resp.Rates["2024-05-06"]["USD"], 1e-6)
+	assert.InDelta(t, 5166000.0, resp.Rates["2024-05-06"]["INR"], 1e-6)
+	assert.InDelta(t, 63000.0, resp.Rates["2024-05-07"]["USD"], 1e-6)
+}
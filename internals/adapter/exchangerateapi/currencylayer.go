@@ -0,0 +1,131 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// currencyLayerPivot is the only source currency on CurrencyLayer's free plan.
+const currencyLayerPivot domain.Currency = "USD"
+
+func init() {
+	Register("currencylayer", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("currencylayer")
+		if provider.APIKey == "" {
+			return nil, errors.New("CURRENCYLAYER_API_KEY (or PROVIDERS.currencylayer.apiKey) is required for the currencylayer provider")
+		}
+		return NewCurrencyLayerClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+// CurrencyLayer quotes are keyed by the concatenated pair, e.g. "USDINR".
+type currencyLayerLiveResponse struct {
+	apilayerStatus
+	Timestamp int64              `json:"timestamp"`
+	Source    string             `json:"source"`
+	Quotes    map[string]float64 `json:"quotes"`
+}
+
+type currencyLayerTimeframeResponse struct {
+	apilayerStatus
+	StartDate string                        `json:"start_date"`
+	EndDate   string                        `json:"end_date"`
+	Source    string                        `json:"source"`
+	Quotes    map[string]map[string]float64 `json:"quotes"`
+}
+
+// CurrencyLayerClient talks to the currencylayer.com API.
+type CurrencyLayerClient struct {
+	baseURL   string
+	accessKey string
+	dateFmt   string
+}
+
+func NewCurrencyLayerClient(baseURL, accessKey, dateFmt string) RateAPIClient {
+	return &CurrencyLayerClient{
+		baseURL:   baseURL,
+		accessKey: accessKey,
+		dateFmt:   dateFmt,
+	}
+}
+
+func (c *CurrencyLayerClient) params(base domain.Currency, targets []domain.Currency) url.Values {
+	currencies := append(currencyStrings(targets), string(base))
+	params := url.Values{}
+	params.Set("access_key", c.accessKey)
+	params.Set("source", string(currencyLayerPivot))
+	params.Set("currencies", strings.Join(currencies, ","))
+	return params
+}
+
+// quotesToRates strips the source prefix from CurrencyLayer's pair keys.
+func quotesToRates(source string, quotes map[string]float64) map[domain.Currency]float64 {
+	rates := make(map[domain.Currency]float64, len(quotes))
+	for pair, rate := range quotes {
+		if !strings.HasPrefix(pair, source) || len(pair) == len(source) {
+			providerLog().Warn("Ignoring unexpected quote", "provider", "currencylayer", "quote", pair, "source", source)
+			continue
+		}
+		rates[domain.Currency(strings.TrimPrefix(pair, source))] = rate
+	}
+	return rates
+}
+
+func (c *CurrencyLayerClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "currencylayer", "base", base, "targets", targets)
+	response := &currencyLayerLiveResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"live", c.params(base, targets), response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from currencylayer: %w", err)
+	}
+
+	rates, err := rebase(quotesToRates(response.Source, response.Quotes), currencyLayerPivot, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return rates, time.Unix(response.Timestamp, 0).UTC(), nil
+}
+
+func (c *CurrencyLayerClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "currencylayer", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	params := c.params(baseCurrency, targetCurrencies)
+	params.Set("start_date", startDate.Format(c.dateFmt))
+	params.Set("end_date", endDate.Format(c.dateFmt))
+
+	response := &currencyLayerTimeframeResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"timeframe", params, response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical timeseries rates from currencylayer: %w", err)
+	}
+
+	result := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: response.StartDate,
+		EndDate:   response.EndDate,
+		Rates:     make(map[string]map[string]float64, len(response.Quotes)),
+	}
+	for date, quotes := range response.Quotes {
+		rates, err := rebase(quotesToRates(response.Source, quotes), currencyLayerPivot, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		result.Rates[date] = toStringMap(rates)
+	}
+
+	return result, nil
+}
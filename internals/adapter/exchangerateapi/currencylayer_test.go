@@ -0,0 +1,76 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCurrencyLayerTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_key") != "test-key" {
+			w.Write([]byte(`{"success":false,"error":{"code":101,"type":"invalid_access_key","info":"You have not supplied a valid API Access Key."}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/live":
+			w.Write([]byte(`{"success":true,"timestamp":1715040000,"source":"USD","quotes":{"USDEUR":0.8,"USDINR":80.0}}`))
+		case "/timeframe":
+			w.Write([]byte(`{"success":true,"timeframe":true,"start_date":"2024-05-06","end_date":"2024-05-07","source":"USD","quotes":{"2024-05-06":{"USDEUR":0.9,"USDINR":81.0},"2024-05-07":{"USDEUR":0.8,"USDINR":80.0}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestQuotesToRates(t *testing.T) {
+	rates := quotesToRates("USD", map[string]float64{"USDINR": 80.0, "USDEUR": 0.8, "USD": 1, "EURINR": 90})
+	assert.Equal(t, map[domain.Currency]float64{"INR": 80.0, "EUR": 0.8}, rates)
+}
+
+func TestCurrencyLayerFetchLatestRates(t *testing.T) {
+	server := newCurrencyLayerTestServer(t)
+	defer server.Close()
+
+	client := NewCurrencyLayerClient(server.URL+"/", "test-key", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "EUR", []domain.Currency{"INR", "USD"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 100.0, rates["INR"], 1e-9)
+	assert.InDelta(t, 1.25, rates["USD"], 1e-9)
+	assert.Equal(t, time.Unix(1715040000, 0).UTC(), ts)
+}
+
+func TestCurrencyLayerFetchLatestRates_UnsuccessfulPayload(t *testing.T) {
+	server := newCurrencyLayerTestServer(t)
+	defer server.Close()
+
+	client := NewCurrencyLayerClient(server.URL+"/", "wrong", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Nil(t, rates)
+}
+
+func TestCurrencyLayerFetchHistoricalTimeSeriesRates(t *testing.T) {
+	server := newCurrencyLayerTestServer(t)
+	defer server.Close()
+
+	client := NewCurrencyLayerClient(server.URL+"/", "test-key", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, resp.Rates["2024-05-06"]["INR"])
+	assert.Equal(t, 80.0, resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestCurrencyLayerRegistry_RequiresAPIKey(t *testing.T) {
+	_, err := NewProvider("currencylayer", &config.Config{})
+	assert.Error(t, err)
+}
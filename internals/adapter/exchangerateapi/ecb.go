@@ -0,0 +1,122 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"fmt"
+	"time"
+)
+
+const (
+	ecbDailyFeed      = "eurofxref-daily.xml"
+	ecbNinetyDayFeed  = "eurofxref-hist-90d.xml"
+	ecbFullFeed       = "eurofxref-hist.xml"
+	ecbNinetyDayRange = 90
+)
+
+func init() {
+	Register("ecb", func(cfg *config.Config) (RateAPIClient, error) {
+		return NewECBClient(cfg.Provider("ecb").BaseURL, cfg.DateFmt), nil
+	})
+}
+
+// ecbEnvelope mirrors the gesmes envelope the ECB publishes its reference rates in.
+// All rates are quoted against EUR.
+type ecbEnvelope struct {
+	Days []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string  `xml:"currency,attr"`
+	Rate     float64 `xml:"rate,attr"`
+}
+
+// ECBClient reads the European Central Bank reference rate feeds directly. It needs no API
+// key, which makes it a handy fallback when other providers are unavailable.
+type ECBClient struct {
+	feedURL string
+	dateFmt string
+}
+
+func NewECBClient(feedURL, dateFmt string) RateAPIClient {
+	return &ECBClient{
+		feedURL: feedURL,
+		dateFmt: dateFmt,
+	}
+}
+
+func (c *ECBClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "ecb", "base", base, "targets", targets)
+	envelope := &ecbEnvelope{}
+	if err := helpers.GetXML(ctx, c.feedURL+ecbDailyFeed, nil, envelope); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from ECB: %w", err)
+	}
+	if len(envelope.Days) == 0 {
+		return nil, time.Time{}, fmt.Errorf("ECB daily feed contained no rates")
+	}
+
+	day := envelope.Days[0]
+	date, err := time.Parse(c.dateFmt, day.Time)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unexpected date %q in ECB feed: %w", day.Time, err)
+	}
+
+	rates, err := crossRates(day.Rates, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	providerLog().Debug("Fetched latest rates", "provider", "ecb", "base", base, "date", day.Time)
+	return rates, date, nil
+}
+
+func (c *ECBClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	feed := ecbNinetyDayFeed
+	if startDate.Before(time.Now().UTC().AddDate(0, 0, -ecbNinetyDayRange)) {
+		feed = ecbFullFeed
+	}
+
+	providerLog().Debug("Fetching historical rates", "provider", "ecb", "feed", feed, "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	envelope := &ecbEnvelope{}
+	if err := helpers.GetXML(ctx, c.feedURL+feed, nil, envelope); err != nil {
+		return nil, fmt.Errorf("failed to fetch historical rates from ECB: %w", err)
+	}
+
+	response := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: startDate.Format(c.dateFmt),
+		EndDate:   endDate.Format(c.dateFmt),
+		Rates:     make(map[string]map[string]float64),
+	}
+	for _, day := range envelope.Days {
+		date, err := time.Parse(c.dateFmt, day.Time)
+		if err != nil || date.Before(startDate) || date.After(endDate) {
+			continue
+		}
+
+		rates, err := crossRates(day.Rates, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		response.Rates[day.Time] = toStringMap(rates)
+	}
+
+	return response, nil
+}
+
+// crossRates converts EUR quoted ECB rates into rates for base.
+func crossRates(eurRates []ecbRate, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, error) {
+	perEUR := make(map[domain.Currency]float64, len(eurRates))
+	for _, r := range eurRates {
+		perEUR[domain.Currency(r.Currency)] = r.Rate
+	}
+	return rebase(perEUR, "EUR", base, targets)
+}
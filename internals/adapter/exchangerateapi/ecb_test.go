@@ -0,0 +1,114 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ecbDailyXML = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time='2024-05-07'>
+			<Cube currency='USD' rate='1.25'/>
+			<Cube currency='INR' rate='100.0'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbHistXML = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time='2024-05-07'>
+			<Cube currency='USD' rate='1.25'/>
+			<Cube currency='INR' rate='100.0'/>
+		</Cube>
+		<Cube time='2024-05-06'>
+			<Cube currency='USD' rate='1.0'/>
+			<Cube currency='INR' rate='90.0'/>
+		</Cube>
+		<Cube time='2024-05-01'>
+			<Cube currency='USD' rate='1.0'/>
+			<Cube currency='INR' rate='80.0'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func newECBTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + ecbDailyFeed:
+			w.Write([]byte(ecbDailyXML))
+		case "/" + ecbNinetyDayFeed, "/" + ecbFullFeed:
+			w.Write([]byte(ecbHistXML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestECBFetchLatestRates_CrossRates(t *testing.T) {
+	server := newECBTestServer(t)
+	defer server.Close()
+
+	client := NewECBClient(server.URL+"/", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR", "JPY"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 80.0, rates["INR"], 1e-9)
+	assert.InDelta(t, 0.8, rates["EUR"], 1e-9)
+	assert.NotContains(t, rates, domain.Currency("JPY"))
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC), ts)
+}
+
+func TestECBFetchLatestRates_EURBase(t *testing.T) {
+	server := newECBTestServer(t)
+	defer server.Close()
+
+	client := NewECBClient(server.URL+"/", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "EUR", []domain.Currency{"USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.25, rates["USD"])
+}
+
+func TestECBFetchLatestRates_UnsupportedBase(t *testing.T) {
+	server := newECBTestServer(t)
+	defer server.Close()
+
+	client := NewECBClient(server.URL+"/", "2006-01-02")
+	_, _, err := client.FetchLatestRates(context.Background(), "GBP", []domain.Currency{"USD"})
+	assert.ErrorIs(t, err, ErrProviderUnsupportedCurrency)
+}
+
+func TestECBFetchLatestRates_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "fail", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewECBClient(server.URL+"/", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Nil(t, rates)
+}
+
+func TestECBFetchHistoricalTimeSeriesRates_FiltersRange(t *testing.T) {
+	server := newECBTestServer(t)
+	defer server.Close()
+
+	client := NewECBClient(server.URL+"/", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Base)
+	assert.Len(t, resp.Rates, 2)
+	assert.InDelta(t, 80.0, resp.Rates["2024-05-07"]["INR"], 1e-9)
+	assert.InDelta(t, 90.0, resp.Rates["2024-05-06"]["INR"], 1e-9)
+}
@@ -0,0 +1,98 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("exchangeratehost", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("exchangeratehost")
+		return NewExchangeRateHostClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+type exchangeRateHostLatestResponse struct {
+	apilayerStatus
+	Base  string             `json:"base"`
+	Date  domain.CustomDate  `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+type exchangeRateHostTimeSeriesResponse struct {
+	apilayerStatus
+	Base      string                        `json:"base"`
+	StartDate string                        `json:"start_date"`
+	EndDate   string                        `json:"end_date"`
+	Rates     map[string]map[string]float64 `json:"rates"`
+}
+
+// ExchangeRateHostClient talks to exchangerate.host, a free high-limit API that accepts any base
+// currency directly. The access key is optional.
+type ExchangeRateHostClient struct {
+	baseURL   string
+	accessKey string
+	dateFmt   string
+}
+
+func NewExchangeRateHostClient(baseURL, accessKey, dateFmt string) RateAPIClient {
+	return &ExchangeRateHostClient{
+		baseURL:   baseURL,
+		accessKey: accessKey,
+		dateFmt:   dateFmt,
+	}
+}
+
+func (c *ExchangeRateHostClient) params(base domain.Currency, targets []domain.Currency) url.Values {
+	params := url.Values{}
+	if c.accessKey != "" {
+		params.Set("access_key", c.accessKey)
+	}
+	params.Set("base", string(base))
+	params.Set("symbols", strings.Join(currencyStrings(targets), ","))
+	return params
+}
+
+func (c *ExchangeRateHostClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "exchangeratehost", "base", base, "targets", targets)
+	response := &exchangeRateHostLatestResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"latest", c.params(base, targets), response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from exchangerate.host: %w", err)
+	}
+
+	return toCurrencyMap(response.Rates), response.Date.ToTime(), nil
+}
+
+func (c *ExchangeRateHostClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "exchangeratehost", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	params := c.params(baseCurrency, targetCurrencies)
+	params.Set("start_date", startDate.Format(c.dateFmt))
+	params.Set("end_date", endDate.Format(c.dateFmt))
+
+	response := &exchangeRateHostTimeSeriesResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"timeseries", params, response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical timeseries rates from exchangerate.host: %w", err)
+	}
+
+	return &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      response.Base,
+		StartDate: response.StartDate,
+		EndDate:   response.EndDate,
+		Rates:     response.Rates,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRateHostFetchLatestRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		assert.Equal(t, "INR,EUR", r.URL.Query().Get("symbols"))
+		assert.Empty(t, r.URL.Query().Get("access_key"))
+		w.Write([]byte(`{"success":true,"base":"USD","date":"2024-05-07","rates":{"INR":83.4,"EUR":0.93}}`))
+	}))
+	defer server.Close()
+
+	client := NewExchangeRateHostClient(server.URL+"/", "", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 83.4, rates["INR"])
+	assert.Equal(t, 0.93, rates["EUR"])
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC), ts)
+}
+
+func TestExchangeRateHostFetchLatestRates_UnsuccessfulPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.URL.Query().Get("access_key"))
+		w.Write([]byte(`{"success":false,"error":{"code":104,"type":"usage_limit_reached","info":"Your monthly usage limit has been reached."}}`))
+	}))
+	defer server.Close()
+
+	client := NewExchangeRateHostClient(server.URL+"/", "key", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "usage_limit_reached")
+	assert.Nil(t, rates)
+}
+
+func TestExchangeRateHostFetchHistoricalTimeSeriesRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/timeseries", r.URL.Path)
+		assert.Equal(t, "2024-05-06", r.URL.Query().Get("start_date"))
+		assert.Equal(t, "2024-05-07", r.URL.Query().Get("end_date"))
+		w.Write([]byte(`{"success":true,"timeseries":true,"base":"USD","start_date":"2024-05-06","end_date":"2024-05-07","rates":{"2024-05-06":{"INR":83.1},"2024-05-07":{"INR":83.4}}}`))
+	}))
+	defer server.Close()
+
+	client := NewExchangeRateHostClient(server.URL+"/", "", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Base)
+	assert.Equal(t, 83.1, resp.Rates["2024-05-06"]["INR"])
+	assert.Equal(t, 83.4, resp.Rates["2024-05-07"]["INR"])
+}
@@ -0,0 +1,102 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("failover", func(cfg *config.Config) (RateAPIClient, error) {
+		if len(cfg.FailoverProviders) < 2 {
+			return nil, errors.New("FAILOVER_PROVIDERS must list at least two providers")
+		}
+		members := make([]FailoverMember, 0, len(cfg.FailoverProviders))
+		for _, name := range cfg.FailoverProviders {
+			if strings.EqualFold(name, "failover") {
+				return nil, errors.New("the failover provider cannot fail over to itself")
+			}
+			client, err := buildProvider(name, cfg)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, FailoverMember{Name: name, Client: client})
+		}
+		return NewFailoverClient(members, DefaultMonitor), nil
+	})
+}
+
+// FailoverMember is one provider in a failover chain.
+type FailoverMember struct {
+	Name   string
+	Client RateAPIClient
+}
+
+// FailoverClient tries its providers in order and returns the first successful answer.
+// Providers that health reports as deprioritized move to the back of the line instead of
+// being dropped, so they are still used when everything else fails.
+type FailoverClient struct {
+	members []FailoverMember
+	health  ProviderHealth
+}
+
+// NewFailoverClient builds a failover chain. health may be nil, in which case the configured
+// order is always used.
+func NewFailoverClient(members []FailoverMember, health ProviderHealth) RateAPIClient {
+	return &FailoverClient{
+		members: members,
+		health:  health,
+	}
+}
+
+func (c *FailoverClient) order() []FailoverMember {
+	if c.health == nil {
+		return c.members
+	}
+	ordered := make([]FailoverMember, 0, len(c.members))
+	var demoted []FailoverMember
+	for _, member := range c.members {
+		if c.health.Healthy(member.Name) {
+			ordered = append(ordered, member)
+		} else {
+			demoted = append(demoted, member)
+		}
+	}
+	return append(ordered, demoted...)
+}
+
+func (c *FailoverClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	var errs []error
+	for _, member := range c.order() {
+		rates, timestamp, err := member.Client.FetchLatestRates(ctx, base, targets)
+		if err == nil {
+			return rates, timestamp, nil
+		}
+		providerLog().Warn("Failover provider failed, trying the next one", "provider", member.Name, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", member.Name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, time.Time{}, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+func (c *FailoverClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	var errs []error
+	for _, member := range c.order() {
+		response, err := member.Client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+		if err == nil {
+			return response, nil
+		}
+		providerLog().Warn("Failover provider failed, trying the next one", "provider", member.Name, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", member.Name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
@@ -0,0 +1,61 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailoverClient_FirstSuccessWins(t *testing.T) {
+	primary := &recordingProvider{rate: 80}
+	secondary := &recordingProvider{rate: 81}
+	client := NewFailoverClient([]FailoverMember{{"primary", primary}, {"secondary", secondary}}, nil)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+	assert.Len(t, secondary.calls, 0)
+}
+
+func TestFailoverClient_FallsThroughFailures(t *testing.T) {
+	secondary := &recordingProvider{rate: 81}
+	client := NewFailoverClient([]FailoverMember{{"primary", &failingProvider{}}, {"secondary", secondary}}, nil)
+
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestFailoverClient_AllFail(t *testing.T) {
+	client := NewFailoverClient([]FailoverMember{{"a", &failingProvider{}}, {"b", &failingProvider{}}}, nil)
+
+	_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a: provider down")
+	assert.Contains(t, err.Error(), "b: provider down")
+}
+
+func TestFailoverClient_DeprioritizedMovedLast(t *testing.T) {
+	primary := &recordingProvider{rate: 80}
+	secondary := &recordingProvider{rate: 81}
+	client := NewFailoverClient([]FailoverMember{{"primary", primary}, {"secondary", secondary}}, deprioritizedSet{"primary": true})
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, rates["INR"])
+	assert.Len(t, primary.calls, 0)
+}
+
+func TestFailoverRegistry_RequiresProviders(t *testing.T) {
+	_, err := NewProvider("failover", &config.Config{FailoverProviders: []string{"frankfurter"}})
+	assert.Error(t, err)
+
+	client, err := NewProvider("failover", &config.Config{FailoverProviders: []string{"frankfurter", "ecb"}, DateFmt: "2006-01-02"})
+	assert.NoError(t, err)
+	assert.IsType(t, &FailoverClient{}, client)
+}
@@ -0,0 +1,118 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// fixerPivot is the only base currency on Fixer's basic plans.
+const fixerPivot domain.Currency = "EUR"
+
+func init() {
+	Register("fixer", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("fixer")
+		if provider.APIKey == "" {
+			return nil, errors.New("FIXER_API_KEY (or PROVIDERS.fixer.apiKey) is required for the fixer provider")
+		}
+		return NewFixerClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+type fixerLatestResponse struct {
+	apilayerStatus
+	Timestamp int64              `json:"timestamp"`
+	Base      string             `json:"base"`
+	Date      string             `json:"date"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+type fixerTimeSeriesResponse struct {
+	apilayerStatus
+	StartDate string                        `json:"start_date"`
+	EndDate   string                        `json:"end_date"`
+	Base      string                        `json:"base"`
+	Rates     map[string]map[string]float64 `json:"rates"`
+}
+
+// FixerClient talks to the fixer.io API.
+type FixerClient struct {
+	baseURL   string
+	accessKey string
+	dateFmt   string
+}
+
+func NewFixerClient(baseURL, accessKey, dateFmt string) RateAPIClient {
+	return &FixerClient{
+		baseURL:   baseURL,
+		accessKey: accessKey,
+		dateFmt:   dateFmt,
+	}
+}
+
+func (c *FixerClient) params(base domain.Currency, targets []domain.Currency) url.Values {
+	symbols := append(currencyStrings(targets), string(base))
+	params := url.Values{}
+	params.Set("access_key", c.accessKey)
+	params.Set("base", string(fixerPivot))
+	params.Set("symbols", strings.Join(symbols, ","))
+	return params
+}
+
+func (c *FixerClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "fixer", "base", base, "targets", targets)
+	response := &fixerLatestResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"latest", c.params(base, targets), response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from fixer.io: %w", err)
+	}
+
+	rates, err := rebase(toCurrencyMap(response.Rates), fixerPivot, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return rates, time.Unix(response.Timestamp, 0).UTC(), nil
+}
+
+func (c *FixerClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "fixer", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	params := c.params(baseCurrency, targetCurrencies)
+	params.Set("start_date", startDate.Format(c.dateFmt))
+	params.Set("end_date", endDate.Format(c.dateFmt))
+
+	response := &fixerTimeSeriesResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"timeseries", params, response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical timeseries rates from fixer.io: %w", err)
+	}
+
+	result := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: response.StartDate,
+		EndDate:   response.EndDate,
+		Rates:     make(map[string]map[string]float64, len(response.Rates)),
+	}
+	for date, dayRates := range response.Rates {
+		rates, err := rebase(toCurrencyMap(dayRates), fixerPivot, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		result.Rates[date] = toStringMap(rates)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFixerTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_key") != "test-key" {
+			w.Write([]byte(`{"success":false,"error":{"code":101,"type":"invalid_access_key","info":"You have not supplied a valid API Access Key."}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/latest":
+			w.Write([]byte(`{"success":true,"timestamp":1715040000,"base":"EUR","date":"2024-05-07","rates":{"USD":1.25,"INR":100.0}}`))
+		case "/timeseries":
+			w.Write([]byte(`{"success":true,"timeseries":true,"start_date":"2024-05-06","end_date":"2024-05-07","base":"EUR","rates":{"2024-05-06":{"USD":1.0,"INR":90.0},"2024-05-07":{"USD":1.25,"INR":100.0}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestFixerFetchLatestRates(t *testing.T) {
+	server := newFixerTestServer(t)
+	defer server.Close()
+
+	client := NewFixerClient(server.URL+"/", "test-key", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 80.0, rates["INR"], 1e-9)
+	assert.InDelta(t, 0.8, rates["EUR"], 1e-9)
+	assert.Equal(t, time.Unix(1715040000, 0).UTC(), ts)
+}
+
+func TestFixerFetchLatestRates_UnsuccessfulPayload(t *testing.T) {
+	server := newFixerTestServer(t)
+	defer server.Close()
+
+	client := NewFixerClient(server.URL+"/", "wrong-key", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_access_key")
+	assert.Nil(t, rates)
+}
+
+func TestFixerFetchHistoricalTimeSeriesRates(t *testing.T) {
+	server := newFixerTestServer(t)
+	defer server.Close()
+
+	client := NewFixerClient(server.URL+"/", "test-key", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 90.0, resp.Rates["2024-05-06"]["INR"], 1e-9)
+	assert.InDelta(t, 80.0, resp.Rates["2024-05-07"]["INR"], 1e-9)
+}
+
+func TestFixerRegistry_RequiresAPIKey(t *testing.T) {
+	_, err := NewProvider("fixer", &config.Config{})
+	assert.Error(t, err)
+}
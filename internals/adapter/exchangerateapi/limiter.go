@@ -0,0 +1,55 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrRateLimited = errors.New("outbound rate limit reached")
+
+// RateLimit is a token bucket: RPS tokens are added per second up to Burst.
+type RateLimit = config.RateLimit
+
+// rateLimitedClient throttles outbound calls to a provider so cache stampedes or an eager
+// scheduler cannot push us over the upstream's limits. Callers wait for a token until
+// their context is done.
+type rateLimitedClient struct {
+	name    string
+	client  RateAPIClient
+	limiter *rate.Limiter
+}
+
+func WithRateLimit(name string, client RateAPIClient, limit RateLimit) RateAPIClient {
+	return &rateLimitedClient{
+		name:    name,
+		client:  client,
+		limiter: rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst),
+	}
+}
+
+func (c *rateLimitedClient) wait(ctx context.Context) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w for provider %s: %v", ErrRateLimited, c.name, err)
+	}
+	return nil
+}
+
+func (c *rateLimitedClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, time.Time{}, err
+	}
+	return c.client.FetchLatestRates(ctx, base, targets)
+}
+
+func (c *rateLimitedClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+}
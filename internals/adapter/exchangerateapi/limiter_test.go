@@ -0,0 +1,39 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_ThrottlesBeyondBurst(t *testing.T) {
+	provider := &countingProvider{}
+	client := WithRateLimit("test", provider, RateLimit{RPS: 20, Burst: 2})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+		assert.NoError(t, err)
+	}
+	// Two calls ride the burst, the other two wait ~50ms each for a token.
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, 4, provider.calls)
+}
+
+func TestRateLimit_GivesUpWhenContextEnds(t *testing.T) {
+	provider := &countingProvider{}
+	client := WithRateLimit("test", provider, RateLimit{RPS: 0.1, Burst: 1})
+
+	_, err := client.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err = client.FetchLatestRates(ctx, "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 1, provider.calls)
+}
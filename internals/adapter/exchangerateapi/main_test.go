@@ -0,0 +1,16 @@
+package exchangerateapi
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/helpers"
+)
+
+func TestMain(m *testing.M) {
+	// Keep retrying provider tests fast.
+	helpers.DefaultRetryPolicy.BaseDelay = time.Millisecond
+	helpers.DefaultRetryPolicy.MaxDelay = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
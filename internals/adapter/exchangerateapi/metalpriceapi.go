@@ -0,0 +1,133 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const metalPricePivot domain.Currency = "USD"
+
+func init() {
+	Register("metalpriceapi", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("metalpriceapi")
+		if provider.APIKey == "" {
+			return nil, errors.New("METALPRICE_API_KEY (or PROVIDERS.metalpriceapi.apiKey) is required for the metalpriceapi provider")
+		}
+		return NewMetalPriceClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+type metalPriceStatus struct {
+	Success bool `json:"success"`
+	Error   *struct {
+		StatusCode int    `json:"statusCode"`
+		Message    string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (s metalPriceStatus) err() error {
+	if s.Success {
+		return nil
+	}
+	if s.Error == nil {
+		return errors.New("request was not successful")
+	}
+	return fmt.Errorf("error %d: %s", s.Error.StatusCode, s.Error.Message)
+}
+
+// Metal rates are troy ounces per unit of base, e.g. USD->XAU 0.00043.
+type metalPriceLatestResponse struct {
+	metalPriceStatus
+	Base      string             `json:"base"`
+	Timestamp int64              `json:"timestamp"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+type metalPriceTimeframeResponse struct {
+	metalPriceStatus
+	Base      string                        `json:"base"`
+	StartDate string                        `json:"start_date"`
+	EndDate   string                        `json:"end_date"`
+	Rates     map[string]map[string]float64 `json:"rates"`
+}
+
+// MetalPriceClient prices gold, silver and platinum through metalpriceapi.com.
+type MetalPriceClient struct {
+	baseURL string
+	apiKey  string
+	dateFmt string
+}
+
+func NewMetalPriceClient(baseURL, apiKey, dateFmt string) RateAPIClient {
+	return &MetalPriceClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		dateFmt: dateFmt,
+	}
+}
+
+func (c *MetalPriceClient) params(base domain.Currency, targets []domain.Currency) url.Values {
+	currencies := append(currencyStrings(targets), string(base))
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	params.Set("base", string(metalPricePivot))
+	params.Set("currencies", strings.Join(currencies, ","))
+	return params
+}
+
+func (c *MetalPriceClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "metalpriceapi", "base", base, "targets", targets)
+	response := &metalPriceLatestResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"latest", c.params(base, targets), response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from metalpriceapi: %w", err)
+	}
+
+	rates, err := rebase(toCurrencyMap(response.Rates), metalPricePivot, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return rates, time.Unix(response.Timestamp, 0).UTC(), nil
+}
+
+func (c *MetalPriceClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "metalpriceapi", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	params := c.params(baseCurrency, targetCurrencies)
+	params.Set("start_date", startDate.Format(c.dateFmt))
+	params.Set("end_date", endDate.Format(c.dateFmt))
+
+	response := &metalPriceTimeframeResponse{}
+	err := helpers.GetJSON(ctx, c.baseURL+"timeframe", params, response)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical timeseries rates from metalpriceapi: %w", err)
+	}
+
+	result := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: response.StartDate,
+		EndDate:   response.EndDate,
+		Rates:     make(map[string]map[string]float64, len(response.Rates)),
+	}
+	for date, dayRates := range response.Rates {
+		rates, err := rebase(toCurrencyMap(dayRates), metalPricePivot, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		result.Rates[date] = toStringMap(rates)
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package exchangerateapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetalPriceTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "test-key" {
+			w.Write([]byte(`{"success":false,"error":{"statusCode":101,"message":"Invalid API Key."}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/latest":
+			w.Write([]byte(`{"success":true,"base":"USD","timestamp":1715040000,"rates":{"XAU":0.0005,"XAG":0.04,"INR":80.0}}`))
+		case "/timeframe":
+			w.Write([]byte(`{"success":true,"base":"USD","start_date":"2024-05-06","end_date":"2024-05-07","rates":{"2024-05-06":{"XAU":0.0004,"INR":81.0},"2024-05-07":{"XAU":0.0005,"INR":80.0}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestMetalPriceFetchLatestRates(t *testing.T) {
+	server := newMetalPriceTestServer(t)
+	defer server.Close()
+
+	client := NewMetalPriceClient(server.URL+"/", "test-key", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "XAU", []domain.Currency{"USD", "INR", "XAG"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 2000.0, rates["USD"], 1e-9)
+	assert.InDelta(t, 160000.0, rates["INR"], 1e-6)
+	assert.InDelta(t, 80.0, rates["XAG"], 1e-9)
+	assert.Equal(t, time.Unix(1715040000, 0).UTC(), ts)
+}
+
+func TestMetalPriceFetchLatestRates_UnsuccessfulPayload(t *testing.T) {
+	server := newMetalPriceTestServer(t)
+	defer server.Close()
+
+	client := NewMetalPriceClient(server.URL+"/", "wrong", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"XAU"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid API Key")
+	assert.Nil(t, rates)
+}
+
+func TestMetalPriceFetchHistoricalTimeSeriesRates(t *testing.T) {
+	server := newMetalPriceTestServer(t)
+	defer server.Close()
+
+	client := NewMetalPriceClient(server.URL+"/", "test-key", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"XAU"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0004, resp.Rates["2024-05-06"]["XAU"])
+	assert.Equal(t, 0.0005, resp.Rates["2024-05-07"]["XAU"])
+}
+
+func TestMetalPriceRegistry_RequiresAPIKey(t *testing.T) {
+	_, err := NewProvider("metalpriceapi", &config.Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/metrics"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const monitorWindow = 100
+
+// ProviderStatus summarises how a provider has behaved over its most recent calls.
+type ProviderStatus struct {
+	Name               string     `json:"name"`
+	LastSuccess        *time.Time `json:"lastSuccess,omitempty"`
+	LastError          string     `json:"lastError,omitempty"`
+	LastErrorAt        *time.Time `json:"lastErrorAt,omitempty"`
+	Calls              int        `json:"calls"`
+	ErrorRate          float64    `json:"errorRate"`
+	SuccessRate        float64    `json:"successRate"`
+	AvgLatencyMs       float64    `json:"avgLatencyMs"`
+	P95LatencyMs       float64    `json:"p95LatencyMs"`
+	Deprioritized      bool       `json:"deprioritized"`
+	DeprioritizedUntil *time.Time `json:"deprioritizedUntil,omitempty"`
+}
+
+// HealthPolicy is the SLO a provider must meet to stay in rotation in the failover and
+// aggregate modes. The zero value never deprioritizes anyone.
+type HealthPolicy struct {
+	MinSuccessRate float64       // deprioritize below this success rate
+	MaxP95Latency  time.Duration // deprioritize above this p95 latency, 0 to ignore latency
+	MinCalls       int           // calls needed in the window before the SLO is judged
+	Probation      time.Duration // how long a deprioritized provider sits out
+}
+
+// ProviderHealth tells composite providers which of their members are currently in rotation.
+type ProviderHealth interface {
+	Healthy(name string) bool
+}
+
+type callOutcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+type providerStats struct {
+	lastSuccess        time.Time
+	lastError          string
+	lastErrorAt        time.Time
+	window             []callOutcome // ring buffer of the last monitorWindow calls
+	next               int
+	deprioritizedUntil time.Time
+}
+
+func (s *providerStats) successRate() float64 {
+	if len(s.window) == 0 {
+		return 1
+	}
+	failures := 0
+	for _, outcome := range s.window {
+		if outcome.failed {
+			failures++
+		}
+	}
+	return 1 - float64(failures)/float64(len(s.window))
+}
+
+func (s *providerStats) p95Latency() time.Duration {
+	if len(s.window) == 0 {
+		return 0
+	}
+	latencies := make([]time.Duration, len(s.window))
+	for i, outcome := range s.window {
+		latencies[i] = outcome.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	index := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+	return latencies[index]
+}
+
+// Monitor keeps rolling call statistics for every instrumented provider and deprioritizes
+// providers that breach the HealthPolicy until their probation ends.
+type Monitor struct {
+	mu        sync.RWMutex
+	providers map[string]*providerStats
+	policy    HealthPolicy
+	now       func() time.Time
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{
+		providers: make(map[string]*providerStats),
+		now:       time.Now,
+	}
+}
+
+// DefaultMonitor collects the statistics of the providers built by NewFromConfig.
+var DefaultMonitor = NewMonitor()
+
+func (m *Monitor) SetHealthPolicy(policy HealthPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = policy
+}
+
+func (m *Monitor) record(name string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.providers[name]
+	if !ok {
+		stats = &providerStats{window: make([]callOutcome, 0, monitorWindow)}
+		m.providers[name] = stats
+	}
+
+	now := m.now()
+	if err != nil {
+		stats.lastError = err.Error()
+		stats.lastErrorAt = now
+	} else {
+		stats.lastSuccess = now
+	}
+
+	outcome := callOutcome{failed: err != nil, latency: latency}
+	if len(stats.window) < monitorWindow {
+		stats.window = append(stats.window, outcome)
+	} else {
+		stats.window[stats.next] = outcome
+		stats.next = (stats.next + 1) % monitorWindow
+	}
+
+	m.evaluate(name, stats, now)
+}
+
+// evaluate deprioritizes a provider that breaches the policy. Its window is cleared so that,
+// once probation ends, it is judged on fresh calls only.
+func (m *Monitor) evaluate(name string, stats *providerStats, now time.Time) {
+	if m.policy.Probation <= 0 || len(stats.window) < m.policy.MinCalls || now.Before(stats.deprioritizedUntil) {
+		return
+	}
+
+	successRate := stats.successRate()
+	p95 := stats.p95Latency()
+	breached := successRate < m.policy.MinSuccessRate || (m.policy.MaxP95Latency > 0 && p95 > m.policy.MaxP95Latency)
+	if !breached {
+		return
+	}
+
+	stats.deprioritizedUntil = now.Add(m.policy.Probation)
+	stats.window = stats.window[:0]
+	stats.next = 0
+	providerLog().Warn("Deprioritizing provider", "provider", name, "until", stats.deprioritizedUntil.Format(time.RFC3339), "success_rate", successRate, "p95_latency", p95)
+}
+
+// Healthy reports whether name is in rotation. Providers that have not been called yet are healthy.
+func (m *Monitor) Healthy(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats, ok := m.providers[name]
+	return !ok || !m.now().Before(stats.deprioritizedUntil)
+}
+
+// Statuses returns a snapshot for every provider that has been called, sorted by name.
+func (m *Monitor) Statuses() []ProviderStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	statuses := make([]ProviderStatus, 0, len(m.providers))
+	for name, stats := range m.providers {
+		status := ProviderStatus{
+			Name:         name,
+			LastError:    stats.lastError,
+			Calls:        len(stats.window),
+			SuccessRate:  stats.successRate(),
+			P95LatencyMs: float64(stats.p95Latency().Microseconds()) / 1000,
+		}
+		status.ErrorRate = 1 - status.SuccessRate
+		if !stats.lastSuccess.IsZero() {
+			lastSuccess := stats.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		if !stats.lastErrorAt.IsZero() {
+			lastErrorAt := stats.lastErrorAt
+			status.LastErrorAt = &lastErrorAt
+		}
+		if now.Before(stats.deprioritizedUntil) {
+			until := stats.deprioritizedUntil
+			status.Deprioritized = true
+			status.DeprioritizedUntil = &until
+		}
+		var total time.Duration
+		for _, outcome := range stats.window {
+			total += outcome.latency
+		}
+		if status.Calls > 0 {
+			status.AvgLatencyMs = float64(total.Microseconds()) / 1000 / float64(status.Calls)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// instrumentedClient times every call to the wrapped provider and reports it to a Monitor and
+// to the provider metrics.
+type instrumentedClient struct {
+	name    string
+	client  RateAPIClient
+	monitor *Monitor
+}
+
+// Instrument wraps client so its calls show up in monitor under name.
+func Instrument(name string, client RateAPIClient, monitor *Monitor) RateAPIClient {
+	return &instrumentedClient{
+		name:    name,
+		client:  client,
+		monitor: monitor,
+	}
+}
+
+func (c *instrumentedClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	start := time.Now()
+	rates, timestamp, err := c.client.FetchLatestRates(ctx, base, targets)
+	c.monitor.record(c.name, time.Since(start), err)
+	metrics.ObserveProviderCall(ctx, c.name, "latest", time.Since(start), err)
+	return rates, timestamp, err
+}
+
+func (c *instrumentedClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	start := time.Now()
+	response, err := c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	c.monitor.record(c.name, time.Since(start), err)
+	metrics.ObserveProviderCall(ctx, c.name, "historical", time.Since(start), err)
+	return response, err
+}
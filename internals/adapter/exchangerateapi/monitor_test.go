@@ -0,0 +1,96 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrument_RecordsSuccessAndFailure(t *testing.T) {
+	monitor := NewMonitor()
+	ok := Instrument("ok", &recordingProvider{rate: 80}, monitor)
+	down := Instrument("down", &failingProvider{}, monitor)
+
+	_, _, err := ok.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	_, err = ok.FetchHistoricalTimeSeriesRates(context.Background(), time.Now(), time.Now(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	_, _, err = down.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+
+	statuses := monitor.Statuses()
+	assert.Len(t, statuses, 2)
+
+	assert.Equal(t, "down", statuses[0].Name)
+	assert.Equal(t, 1, statuses[0].Calls)
+	assert.Equal(t, 1.0, statuses[0].ErrorRate)
+	assert.Equal(t, "provider down", statuses[0].LastError)
+	assert.NotNil(t, statuses[0].LastErrorAt)
+	assert.Nil(t, statuses[0].LastSuccess)
+
+	assert.Equal(t, "ok", statuses[1].Name)
+	assert.Equal(t, 2, statuses[1].Calls)
+	assert.Equal(t, 0.0, statuses[1].ErrorRate)
+	assert.NotNil(t, statuses[1].LastSuccess)
+	assert.Empty(t, statuses[1].LastError)
+}
+
+func TestMonitor_RollingWindow(t *testing.T) {
+	monitor := NewMonitor()
+	for i := 0; i < monitorWindow; i++ {
+		monitor.record("p", time.Millisecond, assert.AnError)
+	}
+	for i := 0; i < monitorWindow/2; i++ {
+		monitor.record("p", 3*time.Millisecond, nil)
+	}
+
+	status := monitor.Statuses()[0]
+	assert.Equal(t, monitorWindow, status.Calls)
+	assert.Equal(t, 0.5, status.ErrorRate)
+	assert.InDelta(t, 2.0, status.AvgLatencyMs, 1e-9)
+}
+
+func TestMonitor_DeprioritizesAndRestores(t *testing.T) {
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+	monitor := NewMonitor()
+	monitor.now = func() time.Time { return now }
+	monitor.SetHealthPolicy(HealthPolicy{MinSuccessRate: 0.8, MinCalls: 5, Probation: time.Minute})
+
+	for i := 0; i < 4; i++ {
+		monitor.record("flaky", time.Millisecond, assert.AnError)
+	}
+	assert.True(t, monitor.Healthy("flaky"), "not judged before MinCalls")
+
+	monitor.record("flaky", time.Millisecond, assert.AnError)
+	assert.False(t, monitor.Healthy("flaky"))
+	assert.True(t, monitor.Healthy("unknown"))
+
+	status := monitor.Statuses()[0]
+	assert.True(t, status.Deprioritized)
+	assert.NotNil(t, status.DeprioritizedUntil)
+
+	now = now.Add(2 * time.Minute)
+	assert.True(t, monitor.Healthy("flaky"))
+	monitor.record("flaky", time.Millisecond, nil)
+	assert.True(t, monitor.Healthy("flaky"))
+}
+
+func TestMonitor_DeprioritizesOnP95Latency(t *testing.T) {
+	monitor := NewMonitor()
+	monitor.SetHealthPolicy(HealthPolicy{MaxP95Latency: 100 * time.Millisecond, MinCalls: 20, Probation: time.Minute})
+
+	for i := 0; i < 19; i++ {
+		monitor.record("slow", 10*time.Millisecond, nil)
+	}
+	// One outlier in twenty calls stays above the 95th percentile.
+	monitor.record("slow", time.Second, nil)
+	assert.True(t, monitor.Healthy("slow"))
+	assert.Equal(t, 10.0, monitor.Statuses()[0].P95LatencyMs)
+
+	monitor.record("slow", time.Second, nil)
+	assert.False(t, monitor.Healthy("slow"))
+}
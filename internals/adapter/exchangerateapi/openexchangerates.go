@@ -0,0 +1,108 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oxrPivot is the only base available on the free openexchangerates.org plan, so every request
+// is made against it and rebased locally.
+const oxrPivot domain.Currency = "USD"
+
+func init() {
+	Register("openexchangerates", func(cfg *config.Config) (RateAPIClient, error) {
+		provider := cfg.Provider("openexchangerates")
+		if provider.APIKey == "" {
+			return nil, errors.New("OXR_APP_ID (or PROVIDERS.openexchangerates.apiKey) is required for the openexchangerates provider")
+		}
+		return NewOpenExchangeRatesClient(provider.BaseURL, provider.APIKey, cfg.DateFmt), nil
+	})
+}
+
+type oxrLatestResponse struct {
+	Timestamp int64              `json:"timestamp"`
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+type oxrTimeSeriesResponse struct {
+	StartDate string                        `json:"start_date"`
+	EndDate   string                        `json:"end_date"`
+	Base      string                        `json:"base"`
+	Rates     map[string]map[string]float64 `json:"rates"`
+}
+
+// OpenExchangeRatesClient talks to openexchangerates.org, which covers 170+ currencies.
+type OpenExchangeRatesClient struct {
+	baseURL string
+	appID   string
+	dateFmt string
+}
+
+func NewOpenExchangeRatesClient(baseURL, appID, dateFmt string) RateAPIClient {
+	return &OpenExchangeRatesClient{
+		baseURL: baseURL,
+		appID:   appID,
+		dateFmt: dateFmt,
+	}
+}
+
+func (c *OpenExchangeRatesClient) params(base domain.Currency, targets []domain.Currency) url.Values {
+	symbols := append(currencyStrings(targets), string(base))
+	params := url.Values{}
+	params.Set("app_id", c.appID)
+	params.Set("base", string(oxrPivot))
+	params.Set("symbols", strings.Join(symbols, ","))
+	return params
+}
+
+func (c *OpenExchangeRatesClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	providerLog().Debug("Fetching latest rates", "provider", "openexchangerates", "base", base, "targets", targets)
+	response := &oxrLatestResponse{}
+	if err := helpers.GetJSON(ctx, c.baseURL+"latest.json", c.params(base, targets), response); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from openexchangerates.org: %w", err)
+	}
+
+	rates, err := rebase(toCurrencyMap(response.Rates), oxrPivot, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return rates, time.Unix(response.Timestamp, 0).UTC(), nil
+}
+
+func (c *OpenExchangeRatesClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	providerLog().Debug("Fetching historical rates", "provider", "openexchangerates", "base", baseCurrency, "start", startDate.Format(c.dateFmt), "end", endDate.Format(c.dateFmt))
+	params := c.params(baseCurrency, targetCurrencies)
+	params.Set("start", startDate.Format(c.dateFmt))
+	params.Set("end", endDate.Format(c.dateFmt))
+
+	response := &oxrTimeSeriesResponse{}
+	if err := helpers.GetJSON(ctx, c.baseURL+"time-series.json", params, response); err != nil {
+		return nil, fmt.Errorf("failed to fetch historical timeseries rates from openexchangerates.org: %w", err)
+	}
+
+	result := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: response.StartDate,
+		EndDate:   response.EndDate,
+		Rates:     make(map[string]map[string]float64, len(response.Rates)),
+	}
+	for date, dayRates := range response.Rates {
+		rates, err := rebase(toCurrencyMap(dayRates), oxrPivot, baseCurrency, targetCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		result.Rates[date] = toStringMap(rates)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package exchangerateapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOXRFetchLatestRates_Rebased(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest.json", r.URL.Path)
+		assert.Equal(t, "test-app", r.URL.Query().Get("app_id"))
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		json.NewEncoder(w).Encode(oxrLatestResponse{
+			Timestamp: 1715040000,
+			Base:      "USD",
+			Rates:     map[string]float64{"EUR": 0.8, "INR": 80.0},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOpenExchangeRatesClient(server.URL+"/", "test-app", "2006-01-02")
+	rates, ts, err := client.FetchLatestRates(context.Background(), "EUR", []domain.Currency{"INR", "USD"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 100.0, rates["INR"], 1e-9)
+	assert.InDelta(t, 1.25, rates["USD"], 1e-9)
+	assert.Equal(t, time.Unix(1715040000, 0).UTC(), ts)
+}
+
+func TestOXRFetchLatestRates_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":true,"status":401,"message":"invalid_app_id"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewOpenExchangeRatesClient(server.URL+"/", "bad", "2006-01-02")
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+	assert.Nil(t, rates)
+}
+
+func TestOXRFetchHistoricalTimeSeriesRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/time-series.json", r.URL.Path)
+		assert.Equal(t, "2024-05-06", r.URL.Query().Get("start"))
+		assert.Equal(t, "2024-05-07", r.URL.Query().Get("end"))
+		json.NewEncoder(w).Encode(oxrTimeSeriesResponse{
+			StartDate: "2024-05-06",
+			EndDate:   "2024-05-07",
+			Base:      "USD",
+			Rates: map[string]map[string]float64{
+				"2024-05-06": {"INR": 83.0},
+				"2024-05-07": {"INR": 83.5},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOpenExchangeRatesClient(server.URL+"/", "test-app", "2006-01-02")
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 83.0, resp.Rates["2024-05-06"]["INR"])
+	assert.Equal(t, 83.5, resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestOXRRegistry_RequiresAppID(t *testing.T) {
+	_, err := NewProvider("openexchangerates", &config.Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package exchangerateapi
+
+import (
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+)
+
+// apilayer products (fixer.io, currencylayer) answer 200 even for failed calls, so every
+// payload carries its own success flag.
+type apilayerError struct {
+	Code int    `json:"code"`
+	Type string `json:"type"`
+	Info string `json:"info"`
+}
+
+type apilayerStatus struct {
+	Success bool           `json:"success"`
+	Error   *apilayerError `json:"error,omitempty"`
+}
+
+func (s apilayerStatus) err() error {
+	if s.Success {
+		return nil
+	}
+	if s.Error == nil {
+		return errors.New("request was not successful")
+	}
+	return fmt.Errorf("error %d (%s): %s", s.Error.Code, s.Error.Type, s.Error.Info)
+}
+
+// rebase converts rates quoted against pivot into rates for base, for providers that only
+// publish (or only allow on their cheaper plans) a single base currency.
+func rebase(pivotRates map[domain.Currency]float64, pivot, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, error) {
+	perPivot := make(map[domain.Currency]float64, len(pivotRates)+1)
+	for currency, rate := range pivotRates {
+		perPivot[currency] = rate
+	}
+	perPivot[pivot] = 1.0
+
+	baseRate, ok := perPivot[base]
+	if !ok || baseRate == 0 {
+		return nil, fmt.Errorf("%w: no rate published for %s", ErrProviderUnsupportedCurrency, base)
+	}
+
+	result := make(map[domain.Currency]float64, len(targets))
+	for _, target := range targets {
+		targetRate, ok := perPivot[target]
+		if !ok {
+			providerLog().Debug("No rate published, skipping", "target", target)
+			continue
+		}
+		result[target] = targetRate / baseRate
+	}
+	return result, nil
+}
+
+func currencyStrings(currencies []domain.Currency) []string {
+	result := make([]string, len(currencies))
+	for i, c := range currencies {
+		result[i] = string(c)
+	}
+	return result
+}
+
+func toCurrencyMap(rates map[string]float64) map[domain.Currency]float64 {
+	result := make(map[domain.Currency]float64, len(rates))
+	for currency, rate := range rates {
+		result[domain.Currency(currency)] = rate
+	}
+	return result
+}
+
+func toStringMap(rates map[domain.Currency]float64) map[string]float64 {
+	result := make(map[string]float64, len(rates))
+	for currency, rate := range rates {
+		result[string(currency)] = rate
+	}
+	return result
+}
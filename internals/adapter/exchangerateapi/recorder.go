@@ -0,0 +1,131 @@
+package exchangerateapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"currency-exchange/internals/core/domain"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	RecordModeOff    = ""
+	RecordModeRecord = "record"
+	RecordModeReplay = "replay"
+)
+
+var ErrNoRecording = errors.New("no recorded response")
+
+type recordedLatest struct {
+	Rates     map[domain.Currency]float64 `json:"rates"`
+	Timestamp time.Time                   `json:"timestamp"`
+}
+
+// recordingStore maps provider calls to JSON files under dir/<provider>/.
+type recordingStore struct {
+	dir     string
+	dateFmt string
+}
+
+func targetsKey(targets []domain.Currency) string {
+	sorted := currencyStrings(targets)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func (s recordingStore) latestPath(base domain.Currency, targets []domain.Currency) string {
+	return filepath.Join(s.dir, fmt.Sprintf("latest_%s_%s.json", base, targetsKey(targets)))
+}
+
+func (s recordingStore) historicalPath(startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) string {
+	return filepath.Join(s.dir, fmt.Sprintf("historical_%s_%s_%s_%s.json", base, startDate.Format(s.dateFmt), endDate.Format(s.dateFmt), targetsKey(targets)))
+}
+
+func (s recordingStore) save(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, data, 0o644)
+		}
+	}
+	if err != nil {
+		providerLog().Warn("Failed to record provider response", "path", path, "error", err)
+	}
+}
+
+func (s recordingStore) load(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w at %s", ErrNoRecording, path)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// recorderClient passes calls through to the real provider and saves every successful
+// response to disk so it can be replayed later.
+type recorderClient struct {
+	client RateAPIClient
+	store  recordingStore
+}
+
+// WithRecording saves the responses of client under dir/name.
+func WithRecording(name string, client RateAPIClient, dir, dateFmt string) RateAPIClient {
+	return &recorderClient{
+		client: client,
+		store:  recordingStore{dir: filepath.Join(dir, name), dateFmt: dateFmt},
+	}
+}
+
+func (c *recorderClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	rates, timestamp, err := c.client.FetchLatestRates(ctx, base, targets)
+	if err == nil {
+		c.store.save(c.store.latestPath(base, targets), recordedLatest{Rates: rates, Timestamp: timestamp})
+	}
+	return rates, timestamp, err
+}
+
+func (c *recorderClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	response, err := c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	if err == nil {
+		c.store.save(c.store.historicalPath(startDate, endDate, baseCurrency, targetCurrencies), response)
+	}
+	return response, err
+}
+
+// replayClient serves responses previously saved by WithRecording without touching the network.
+// Calls that were never recorded fail with ErrNoRecording.
+type replayClient struct {
+	store recordingStore
+}
+
+// NewReplayClient replays the responses recorded for provider name under dir.
+func NewReplayClient(name, dir, dateFmt string) RateAPIClient {
+	return &replayClient{store: recordingStore{dir: filepath.Join(dir, name), dateFmt: dateFmt}}
+}
+
+func (c *replayClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	recorded := recordedLatest{}
+	if err := c.store.load(c.store.latestPath(base, targets), &recorded); err != nil {
+		return nil, time.Time{}, err
+	}
+	return recorded.Rates, recorded.Timestamp, nil
+}
+
+func (c *replayClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	response := &domain.HistoricalTimeSeriesRatesResponse{}
+	if err := c.store.load(c.store.historicalPath(startDate, endDate, baseCurrency, targetCurrencies), response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
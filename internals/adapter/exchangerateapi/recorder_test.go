@@ -0,0 +1,57 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay_Latest(t *testing.T) {
+	dir := t.TempDir()
+	updated := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	recorder := WithRecording("frankfurter", &recordingProvider{rate: 80, updated: updated}, dir, "2006-01-02")
+
+	_, _, err := recorder.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR"})
+	assert.NoError(t, err)
+
+	replay := NewReplayClient("frankfurter", dir, "2006-01-02")
+	// Target order does not matter when looking a recording up.
+	rates, ts, err := replay.FetchLatestRates(context.Background(), "USD", []domain.Currency{"EUR", "INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.Currency]float64{"INR": 80, "EUR": 80}, rates)
+	assert.True(t, updated.Equal(ts))
+}
+
+func TestRecordAndReplay_Historical(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	recorder := WithRecording("ecb", &recordingProvider{rate: 0.9}, dir, "2006-01-02")
+
+	_, err := recorder.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"EUR"})
+	assert.NoError(t, err)
+
+	resp, err := NewReplayClient("ecb", dir, "2006-01-02").FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.9, resp.Rates["2024-05-07"]["EUR"])
+}
+
+func TestReplay_MissingRecording(t *testing.T) {
+	replay := NewReplayClient("frankfurter", t.TempDir(), "2006-01-02")
+	_, _, err := replay.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
+
+func TestRecorder_FailuresNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	recorder := WithRecording("down", &failingProvider{}, dir, "2006-01-02")
+	_, _, err := recorder.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Error(t, err)
+
+	_, _, err = NewReplayClient("down", dir, "2006-01-02").FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
@@ -0,0 +1,67 @@
+package exchangerateapi
+
+import (
+	"currency-exchange/internals/config"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownProvider             = errors.New("unknown rate provider")
+	ErrProviderUnsupportedCurrency = errors.New("currency not offered by rate provider")
+)
+
+// ProviderFactory builds a RateAPIClient from the application config.
+type ProviderFactory func(cfg *config.Config) (RateAPIClient, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ProviderFactory)
+)
+
+// Register makes a provider selectable through RATE_PROVIDER. Implementations call it from init().
+func Register(name string, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name = strings.ToLower(name)
+	if factory == nil {
+		panic("exchangerateapi: Register factory is nil for provider " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("exchangerateapi: Register called twice for provider " + name)
+	}
+	registry[name] = factory
+}
+
+// NewProvider builds the provider registered under name.
+func NewProvider(name string, cfg *config.Config) (RateAPIClient, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, name, strings.Join(Providers(), ", "))
+	}
+
+	client, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise rate provider %q: %w", name, err)
+	}
+	return client, nil
+}
+
+// Providers lists the registered provider names in alphabetical order.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package exchangerateapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubProvider struct{}
+
+func (s *stubProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return nil, time.Time{}, nil
+}
+func (s *stubProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	return nil, nil
+}
+
+func TestRegistry_FrankfurterRegistered(t *testing.T) {
+	assert.Contains(t, Providers(), "frankfurter")
+
+	client, err := NewProvider("Frankfurter", &config.Config{ExternalAPIURL: "http://localhost/", DateFmt: "2006-01-02"})
+	assert.NoError(t, err)
+	assert.IsType(t, &ExRatesClient{}, client)
+}
+
+func TestRegistry_UnknownProvider(t *testing.T) {
+	client, err := NewProvider("nope", &config.Config{})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+	assert.Nil(t, client)
+}
+
+func TestRegistry_RegisterAndBuild(t *testing.T) {
+	Register("stub-ok", func(cfg *config.Config) (RateAPIClient, error) { return &stubProvider{}, nil })
+	client, err := NewProvider("stub-ok", &config.Config{})
+	assert.NoError(t, err)
+	assert.IsType(t, &stubProvider{}, client)
+}
+
+func TestRegistry_FactoryError(t *testing.T) {
+	Register("stub-err", func(cfg *config.Config) (RateAPIClient, error) { return nil, errors.New("missing api key") })
+	client, err := NewProvider("stub-err", &config.Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing api key")
+	assert.Nil(t, client)
+}
+
+func TestRegistry_DuplicatePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		Register("frankfurter", func(cfg *config.Config) (RateAPIClient, error) { return &stubProvider{}, nil })
+	})
+}
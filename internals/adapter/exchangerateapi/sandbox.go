@@ -0,0 +1,116 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+func init() {
+	Register("sandbox", func(cfg *config.Config) (RateAPIClient, error) {
+		return NewSandboxClient(cfg.SandboxSeed, cfg.DateFmt), nil
+	})
+}
+
+// sandboxAnchors are rough USD prices that keep sandbox rates plausible. Currencies not
+// listed get a seed-derived anchor.
+var sandboxAnchors = map[domain.Currency]float64{
+	"USD":  1,
+	"EUR":  1.08,
+	"GBP":  1.27,
+	"INR":  0.012,
+	"JPY":  0.0066,
+	"BTC":  60000,
+	"ETH":  3000,
+	"USDT": 1,
+	"XAU":  2300,
+	"XAG":  27,
+	"XPT":  950,
+}
+
+const (
+	sandboxCycleDays = 30    // length of the slow oscillation every currency follows
+	sandboxAmplitude = 0.05  // +/-5% around the anchor over a cycle
+	sandboxNoise     = 0.005 // +/-0.5% day to day
+)
+
+// SandboxClient generates fake but stable rates for test environments. The same seed always
+// yields the same rate for a given currency pair and day, and rates drift from day to day so
+// charts and historical queries look realistic. It never touches the network.
+type SandboxClient struct {
+	seed    int64
+	dateFmt string
+	now     func() time.Time
+}
+
+func NewSandboxClient(seed int64, dateFmt string) RateAPIClient {
+	return &SandboxClient{
+		seed:    seed,
+		dateFmt: dateFmt,
+		now:     time.Now,
+	}
+}
+
+// unit returns a deterministic value in [0, 1) for the given parts.
+func (c *SandboxClient) unit(parts ...interface{}) float64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, c.seed)
+	for _, part := range parts {
+		fmt.Fprint(h, ":", part)
+	}
+	return float64(h.Sum64()>>11) / float64(1<<53)
+}
+
+// usdValue is the sandbox price of one unit of currency in USD on day.
+func (c *SandboxClient) usdValue(currency domain.Currency, day time.Time) float64 {
+	anchor, ok := sandboxAnchors[currency]
+	if !ok {
+		// Spread unknown currencies between 0.001 and 10 USD.
+		anchor = math.Pow(10, c.unit("anchor", currency)*4-3)
+	}
+	if currency == "USD" {
+		return 1
+	}
+
+	dayNumber := float64(day.Unix() / 86400)
+	phase := c.unit("phase", currency) * 2 * math.Pi
+	cycle := sandboxAmplitude * math.Sin(2*math.Pi*dayNumber/sandboxCycleDays+phase)
+	noise := sandboxNoise * (2*c.unit("noise", currency, day.Format(c.dateFmt)) - 1)
+	return anchor * (1 + cycle + noise)
+}
+
+func (c *SandboxClient) ratesOn(day time.Time, base domain.Currency, targets []domain.Currency) map[domain.Currency]float64 {
+	baseValue := c.usdValue(base, day)
+	rates := make(map[domain.Currency]float64, len(targets))
+	for _, target := range targets {
+		if target == base {
+			rates[target] = 1
+			continue
+		}
+		rates[target] = baseValue / c.usdValue(target, day)
+	}
+	return rates
+}
+
+func (c *SandboxClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	today := c.now().UTC().Truncate(24 * time.Hour)
+	return c.ratesOn(today, base, targets), today, nil
+}
+
+func (c *SandboxClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	response := &domain.HistoricalTimeSeriesRatesResponse{
+		Amount:    1,
+		Base:      string(baseCurrency),
+		StartDate: startDate.Format(c.dateFmt),
+		EndDate:   endDate.Format(c.dateFmt),
+		Rates:     make(map[string]map[string]float64),
+	}
+	for day := startDate.UTC().Truncate(24 * time.Hour); !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		response.Rates[day.Format(c.dateFmt)] = toStringMap(c.ratesOn(day, baseCurrency, targetCurrencies))
+	}
+	return response, nil
+}
@@ -0,0 +1,59 @@
+package exchangerateapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSandbox(seed int64, now time.Time) *SandboxClient {
+	client := NewSandboxClient(seed, "2006-01-02").(*SandboxClient)
+	client.now = func() time.Time { return now }
+	return client
+}
+
+func TestSandbox_StableForSeedAndDay(t *testing.T) {
+	now := time.Date(2024, 5, 7, 15, 30, 0, 0, time.UTC)
+	first, ts, err := newTestSandbox(42, now).FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR", "ZAR"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC), ts)
+
+	second, _, _ := newTestSandbox(42, now.Add(time.Hour)).FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR", "ZAR"})
+	assert.Equal(t, first, second)
+
+	other, _, _ := newTestSandbox(7, now).FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NotEqual(t, first["INR"], other["INR"])
+}
+
+func TestSandbox_PlausibleAndConsistent(t *testing.T) {
+	sandbox := newTestSandbox(1, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC))
+	usd, _, _ := sandbox.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR", "EUR"})
+	eur, _, _ := sandbox.FetchLatestRates(context.Background(), "EUR", []domain.Currency{"INR", "USD"})
+
+	assert.InDelta(t, 83, usd["INR"], 83*0.1)
+	assert.InDelta(t, usd["INR"]/usd["EUR"], eur["INR"], 1e-9)
+	assert.InDelta(t, 1/usd["EUR"], eur["USD"], 1e-9)
+}
+
+func TestSandbox_DriftsDaily(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	resp, err := newTestSandbox(1, end).FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Rates, 7)
+	assert.NotEqual(t, resp.Rates["2024-05-01"]["INR"], resp.Rates["2024-05-07"]["INR"])
+
+	latest, _, _ := newTestSandbox(1, end).FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.Equal(t, latest["INR"], resp.Rates["2024-05-07"]["INR"])
+}
+
+func TestSandbox_Registered(t *testing.T) {
+	client, err := NewProvider("sandbox", &config.Config{SandboxSeed: 3, DateFmt: "2006-01-02"})
+	assert.NoError(t, err)
+	assert.IsType(t, &SandboxClient{}, client)
+}
@@ -0,0 +1,114 @@
+package exchangerateapi
+
+import (
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/logging"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// providerLog returns the logger providers and their decorators log through. It is looked up
+// on every use, so it picks up the level configured at startup after this package loaded.
+func providerLog() *slog.Logger {
+	return logging.For("providers")
+}
+
+// NewFromConfig builds the provider selected by RATE_PROVIDER and layers the optional
+// behaviour enabled in config on top of it.
+func NewFromConfig(cfg *config.Config) (RateAPIClient, error) {
+	helpers.UserAgent = cfg.ExternalAPIUserAgent
+	DefaultMonitor.SetHealthPolicy(HealthPolicy{
+		MinSuccessRate: cfg.ProviderSLOMinSuccessRate,
+		MaxP95Latency:  cfg.ProviderSLOMaxP95Latency,
+		MinCalls:       cfg.ProviderSLOMinCalls,
+		Probation:      cfg.ProviderProbation,
+	})
+
+	client, err := buildProvider(cfg.RateProvider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	providerLog().Info("Using rate provider", "provider", cfg.RateProvider)
+
+	routes := make([]AssetRoute, 0, 2)
+	if cfg.CryptoEnabled && cfg.RateProvider != "coingecko" {
+		crypto, err := buildProvider("coingecko", cfg)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, AssetRoute{Matches: domain.Currency.IsCrypto, Client: crypto})
+		providerLog().Info("Crypto rates enabled, routing crypto pairs to CoinGecko")
+	}
+	if cfg.MetalsEnabled && cfg.RateProvider != "metalpriceapi" {
+		metals, err := buildProvider("metalpriceapi", cfg)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, AssetRoute{Matches: domain.Currency.IsMetal, Client: metals})
+		providerLog().Info("Precious metals enabled, routing metal pairs to metalpriceapi")
+	}
+	if len(routes) > 0 {
+		client = NewAssetRouter(client, routes...)
+	}
+
+	return client, nil
+}
+
+// buildProvider builds the provider registered under name (or replays its recordings),
+// bounds its calls by the PROVIDERS timeout, sanity checks and optionally records its responses, reports its calls to DefaultMonitor,
+// throttles it to its outbound rate limit, when enabled puts a circuit breaker in front of it
+// and finally splits long historical ranges into monthly chunks that each pass through the above.
+func buildProvider(name string, cfg *config.Config) (RateAPIClient, error) {
+	var client RateAPIClient
+	switch cfg.ProviderRecordMode {
+	case RecordModeReplay:
+		// Recordings were validated when they were made and may be older than RESPONSE_MAX_AGE.
+		client = NewReplayClient(name, cfg.ProviderRecordDir, cfg.DateFmt)
+	case RecordModeOff, RecordModeRecord:
+		var err error
+		client, err = NewProvider(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if timeout := cfg.Provider(name).Timeout; timeout > 0 {
+			client = WithTimeout(client, timeout)
+		}
+		if cfg.ResponseValidationEnabled {
+			client = WithValidation(name, client, cfg.ResponseMaxAge, cfg.DateFmt)
+		}
+		if cfg.ProviderRecordMode == RecordModeRecord {
+			client = WithRecording(name, client, cfg.ProviderRecordDir, cfg.DateFmt)
+		}
+	default:
+		return nil, fmt.Errorf("unknown PROVIDER_RECORD_MODE %q", cfg.ProviderRecordMode)
+	}
+	client = Instrument(name, client, DefaultMonitor)
+
+	limits, err := config.ParseRateLimits(cfg.ProviderRateLimits)
+	if err != nil {
+		return nil, err
+	}
+	limit, ok := limits[strings.ToLower(name)]
+	if !ok {
+		limit = RateLimit{RPS: cfg.ProviderRateLimitRPS, Burst: cfg.ProviderRateLimitBurst}
+	}
+	if limit.RPS > 0 {
+		client = WithRateLimit(name, client, limit)
+	}
+
+	if cfg.CircuitBreakerEnabled {
+		client = WithCircuitBreaker(name, client, BreakerSettings{
+			FailureThreshold: cfg.CircuitBreakerFailureThreshold,
+			OpenTimeout:      cfg.CircuitBreakerOpenTimeout,
+			HalfOpenRequests: cfg.CircuitBreakerHalfOpenRequests,
+		})
+	}
+
+	if cfg.HistoricalChunkConcurrency > 0 {
+		client = WithChunking(name, client, cfg.HistoricalChunkConcurrency, cfg.DateFmt)
+	}
+	return client, nil
+}
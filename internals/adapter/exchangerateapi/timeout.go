@@ -0,0 +1,30 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"time"
+)
+
+// timeoutClient bounds every call to a provider, retries included, so a slow upstream
+// cannot hold a request or a refresh cycle for longer than its configured timeout.
+type timeoutClient struct {
+	client  RateAPIClient
+	timeout time.Duration
+}
+
+func WithTimeout(client RateAPIClient, timeout time.Duration) RateAPIClient {
+	return &timeoutClient{client: client, timeout: timeout}
+}
+
+func (c *timeoutClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.FetchLatestRates(ctx, base, targets)
+}
+
+func (c *timeoutClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+}
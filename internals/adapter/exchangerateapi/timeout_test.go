@@ -0,0 +1,41 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingProvider struct{}
+
+func (blockingProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	<-ctx.Done()
+	return nil, time.Time{}, ctx.Err()
+}
+
+func (blockingProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithTimeout_CancelsSlowCalls(t *testing.T) {
+	client := WithTimeout(blockingProvider{}, 10*time.Millisecond)
+
+	_, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"EUR"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	now := time.Now()
+	_, err = client.FetchHistoricalTimeSeriesRates(context.Background(), now.AddDate(0, 0, -1), now, "USD", []domain.Currency{"EUR"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithTimeout_PassesThroughFastCalls(t *testing.T) {
+	client := WithTimeout(&recordingProvider{rate: 0.9}, time.Second)
+
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.9, rates["EUR"])
+}
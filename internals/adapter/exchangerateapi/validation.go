@@ -0,0 +1,129 @@
+package exchangerateapi
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrInvalidProviderResponse = errors.New("invalid provider response")
+
+const (
+	// clockSkew tolerates provider dates slightly ahead of us, e.g. a date-only stamp
+	// published in a time zone ahead of UTC.
+	clockSkew = 24 * time.Hour
+	// historicalLookback allows a time series to start before the requested start date,
+	// as providers return the last business day for ranges starting on a weekend or holiday.
+	historicalLookback = 7 * 24 * time.Hour
+)
+
+// validatingClient checks provider payloads before they reach the cache. A response that
+// fails any check is rejected as a whole and logged, so a single malformed upstream answer
+// cannot pollute the cache.
+type validatingClient struct {
+	name    string
+	client  RateAPIClient
+	maxAge  time.Duration
+	dateFmt string
+	now     func() time.Time
+}
+
+// WithValidation wraps client so its responses are sanity checked. Latest rates older than
+// maxAge are rejected.
+func WithValidation(name string, client RateAPIClient, maxAge time.Duration, dateFmt string) RateAPIClient {
+	return &validatingClient{
+		name:    name,
+		client:  client,
+		maxAge:  maxAge,
+		dateFmt: dateFmt,
+		now:     time.Now,
+	}
+}
+
+func (c *validatingClient) reject(format string, args ...interface{}) error {
+	err := fmt.Errorf("%w from %s: %s", ErrInvalidProviderResponse, c.name, fmt.Sprintf(format, args...))
+	providerLog().Warn("Rejecting provider response", "error", err)
+	return err
+}
+
+func validRate(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 0) && !math.IsNaN(rate)
+}
+
+func (c *validatingClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	rates, timestamp, err := c.client.FetchLatestRates(ctx, base, targets)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if err := c.validateLatest(base, targets, rates, timestamp); err != nil {
+		return nil, time.Time{}, err
+	}
+	return rates, timestamp, nil
+}
+
+func (c *validatingClient) validateLatest(base domain.Currency, targets []domain.Currency, rates map[domain.Currency]float64, timestamp time.Time) error {
+	if len(targets) > 0 && len(rates) == 0 {
+		return c.reject("no rates for base %s", base)
+	}
+	for currency, rate := range rates {
+		if !validRate(rate) {
+			return c.reject("rate %s/%s is %v", base, currency, rate)
+		}
+	}
+	if rate, ok := rates[base]; ok && math.Abs(rate-1) > 1e-9 {
+		return c.reject("rate of base %s against itself is %v, response is probably for another base", base, rate)
+	}
+
+	now := c.now()
+	if timestamp.IsZero() {
+		return c.reject("missing timestamp for base %s", base)
+	}
+	if timestamp.After(now.Add(clockSkew)) {
+		return c.reject("timestamp %s for base %s is in the future", timestamp, base)
+	}
+	if c.maxAge > 0 && timestamp.Before(now.Add(-c.maxAge)) {
+		return c.reject("timestamp %s for base %s is older than %s", timestamp, base, c.maxAge)
+	}
+	return nil
+}
+
+func (c *validatingClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate time.Time, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	response, err := c.client.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, baseCurrency, targetCurrencies)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateHistorical(startDate, endDate, baseCurrency, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *validatingClient) validateHistorical(startDate, endDate time.Time, base domain.Currency, response *domain.HistoricalTimeSeriesRatesResponse) error {
+	if response == nil || len(response.Rates) == 0 {
+		return c.reject("no historical rates for base %s", base)
+	}
+	if response.Base != "" && !strings.EqualFold(response.Base, string(base)) {
+		return c.reject("asked for base %s but got %s", base, response.Base)
+	}
+
+	earliest := startDate.Add(-historicalLookback)
+	for day, dayRates := range response.Rates {
+		date, err := time.Parse(c.dateFmt, day)
+		if err != nil {
+			return c.reject("unparseable date %q", day)
+		}
+		if date.Before(earliest) || date.After(endDate) {
+			return c.reject("date %s outside requested range %s..%s", day, startDate.Format(c.dateFmt), endDate.Format(c.dateFmt))
+		}
+		for currency, rate := range dayRates {
+			if !validRate(rate) {
+				return c.reject("rate %s/%s on %s is %v", base, currency, day, rate)
+			}
+		}
+	}
+	return nil
+}
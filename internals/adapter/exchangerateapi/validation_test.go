@@ -0,0 +1,89 @@
+package exchangerateapi
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedProvider returns canned responses.
+type fixedProvider struct {
+	rates     map[domain.Currency]float64
+	timestamp time.Time
+	history   *domain.HistoricalTimeSeriesRatesResponse
+}
+
+func (p *fixedProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return p.rates, p.timestamp, nil
+}
+
+func (p *fixedProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	return p.history, nil
+}
+
+var validationNow = time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+func newTestValidator(provider RateAPIClient) *validatingClient {
+	client := WithValidation("test", provider, 7*24*time.Hour, "2006-01-02").(*validatingClient)
+	client.now = func() time.Time { return validationNow }
+	return client
+}
+
+func TestValidation_LatestAccepted(t *testing.T) {
+	client := newTestValidator(&fixedProvider{rates: map[domain.Currency]float64{"INR": 80, "USD": 1}, timestamp: validationNow.Add(-time.Hour)})
+	rates, _, err := client.FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, rates["INR"])
+}
+
+func TestValidation_LatestRejected(t *testing.T) {
+	fresh := validationNow.Add(-time.Hour)
+	cases := map[string]*fixedProvider{
+		"empty":        {rates: map[domain.Currency]float64{}, timestamp: fresh},
+		"zero rate":    {rates: map[domain.Currency]float64{"INR": 0}, timestamp: fresh},
+		"negative":     {rates: map[domain.Currency]float64{"INR": -1}, timestamp: fresh},
+		"NaN":          {rates: map[domain.Currency]float64{"INR": math.NaN()}, timestamp: fresh},
+		"infinite":     {rates: map[domain.Currency]float64{"INR": math.Inf(1)}, timestamp: fresh},
+		"wrong base":   {rates: map[domain.Currency]float64{"INR": 80, "USD": 1.08}, timestamp: fresh},
+		"no timestamp": {rates: map[domain.Currency]float64{"INR": 80}},
+		"future":       {rates: map[domain.Currency]float64{"INR": 80}, timestamp: validationNow.Add(48 * time.Hour)},
+		"stale":        {rates: map[domain.Currency]float64{"INR": 80}, timestamp: validationNow.AddDate(0, 0, -30)},
+	}
+	for name, provider := range cases {
+		_, _, err := newTestValidator(provider).FetchLatestRates(context.Background(), "USD", []domain.Currency{"INR"})
+		assert.ErrorIs(t, err, ErrInvalidProviderResponse, name)
+	}
+}
+
+func TestValidation_HistoricalAcceptsWeekendLookback(t *testing.T) {
+	client := newTestValidator(&fixedProvider{history: &domain.HistoricalTimeSeriesRatesResponse{
+		Base:  "USD",
+		Rates: map[string]map[string]float64{"2024-05-03": {"INR": 80}, "2024-05-06": {"INR": 81}},
+	}})
+	start := time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	resp, err := client.FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Rates, 2)
+}
+
+func TestValidation_HistoricalRejected(t *testing.T) {
+	cases := map[string]*domain.HistoricalTimeSeriesRatesResponse{
+		"empty":        {Base: "USD"},
+		"wrong base":   {Base: "EUR", Rates: map[string]map[string]float64{"2024-05-06": {"INR": 90}}},
+		"bad date":     {Base: "USD", Rates: map[string]map[string]float64{"06/05/2024": {"INR": 80}}},
+		"out of range": {Base: "USD", Rates: map[string]map[string]float64{"2024-06-01": {"INR": 80}}},
+		"bad rate":     {Base: "USD", Rates: map[string]map[string]float64{"2024-05-06": {"INR": -80}}},
+	}
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	for name, history := range cases {
+		_, err := newTestValidator(&fixedProvider{history: history}).FetchHistoricalTimeSeriesRates(context.Background(), start, end, "USD", []domain.Currency{"INR"})
+		assert.ErrorIs(t, err, ErrInvalidProviderResponse, name)
+	}
+}
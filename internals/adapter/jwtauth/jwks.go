@@ -0,0 +1,208 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefetchInterval bounds how often a token with an unknown key ID can make us fetch the
+// JWKS again, so forged key IDs can't be used to hammer the key server.
+const minRefetchInterval = time.Minute
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// keySet caches the public keys published at a JWKS URL. They are fetched again every
+// refresh, or sooner when a token names a key we don't have yet, as after a key rotation.
+// Fetches happen outside the lock, and concurrent ones are collapsed into one, so requests
+// never queue behind a slow key server.
+type keySet struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+	now     func() time.Time
+	fetches singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time
+	fetchErr  error // why the last fetch failed, returned while no keys are known
+}
+
+func newKeySet(url string, refresh time.Duration, client *http.Client) *keySet {
+	return &keySet{url: url, refresh: refresh, client: client, now: time.Now}
+}
+
+// key returns the key with ID kid. An empty kid matches the only key of a single-key set.
+func (s *keySet) key(ctx context.Context, kid string) (any, error) {
+	s.mu.Lock()
+	keys, fetchedAt, fetchErr := s.keys, s.fetchedAt, s.fetchErr
+	s.mu.Unlock()
+
+	age := s.now().Sub(fetchedAt)
+	_, known := lookup(keys, kid)
+	firstFetch := keys == nil && fetchErr == nil
+	if firstFetch || age >= s.refresh || (!known && age >= minRefetchInterval) {
+		_, err, _ := s.fetches.Do("jwks", func() (any, error) {
+			s.mu.Lock()
+			if !s.fetchedAt.Equal(fetchedAt) {
+				// Another fetch ran since we looked; its outcome stands until the next one is due.
+				err := s.fetchErr
+				s.mu.Unlock()
+				return nil, err
+			}
+			s.mu.Unlock()
+			// A caller that gives up mustn't fail the fetch the others are waiting on.
+			return nil, s.fetch(context.WithoutCancel(ctx))
+		})
+		s.mu.Lock()
+		keys = s.keys
+		s.mu.Unlock()
+		fetchErr = err
+	}
+	if keys == nil && fetchErr != nil {
+		return nil, fetchErr
+	}
+	if key, ok := lookup(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnknownKey, kid)
+}
+
+func lookup(keys map[string]any, kid string) (any, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch replaces the cached keys with those currently published. Keys of a type we don't
+// support are skipped. On failure the old keys are kept, and tried again no sooner than
+// minRefetchInterval, even while there are none yet.
+func (s *keySet) fetch(ctx context.Context) error {
+	s.mu.Lock()
+	s.fetchedAt = s.now()
+	s.mu.Unlock()
+
+	keys, err := s.download(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchErr = err
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+func (s *keySet) download(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,141 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// leeway absorbs clock drift between the token issuer and this service.
+const leeway = 30 * time.Second
+
+// ErrInvalid is returned for tokens that are malformed, badly signed, expired or meant for
+// someone else.
+var ErrInvalid = errors.New("invalid token")
+
+var (
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	jwksMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// Identity is who a valid token says the caller is.
+type Identity struct {
+	Subject string
+	Tier    string
+	Role    string
+	Scopes  []string
+}
+
+// Options configures a Verifier. Exactly one of HMACSecret and JWKSURL is set.
+type Options struct {
+	HMACSecret   string
+	JWKSURL      string
+	JWKSRefresh  time.Duration
+	Issuer       string
+	Audience     string
+	SubjectClaim string
+	TierClaim    string
+	RoleClaim    string
+	ScopeClaim   string
+	// DefaultScopes are granted to tokens with neither a role nor a scope claim.
+	DefaultScopes []string
+	HTTPClient    *http.Client
+}
+
+// Verifier checks JWT bearer tokens and maps their claims to an Identity.
+type Verifier struct {
+	opts   Options
+	parser *jwt.Parser
+	keys   *keySet
+}
+
+func NewVerifier(opts Options) *Verifier {
+	methods := hmacMethods
+	var keys *keySet
+	if opts.JWKSURL != "" {
+		methods = jwksMethods
+		client := opts.HTTPClient
+		if client == nil {
+			client = &http.Client{Timeout: 10 * time.Second}
+		}
+		keys = newKeySet(opts.JWKSURL, opts.JWKSRefresh, client)
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	return &Verifier{opts: opts, parser: jwt.NewParser(parserOpts...), keys: keys}
+}
+
+// Verify checks token and returns the identity it carries. Tokens that fail validation give
+// an error wrapping ErrInvalid; other errors mean the signing keys couldn't be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (Identity, error) {
+	var keyErr error
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if v.keys == nil {
+			return []byte(v.opts.HMACSecret), nil
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.key(ctx, kid)
+		if err != nil && !errors.Is(err, errUnknownKey) {
+			keyErr = err
+		}
+		return key, err
+	})
+	if keyErr != nil {
+		return Identity{}, keyErr
+	}
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	subject, _ := claims[v.opts.SubjectClaim].(string)
+	if subject == "" {
+		return Identity{}, fmt.Errorf("%w: no %q claim", ErrInvalid, v.opts.SubjectClaim)
+	}
+	identity := Identity{Subject: subject}
+	if v.opts.TierClaim != "" {
+		identity.Tier, _ = claims[v.opts.TierClaim].(string)
+	}
+	if v.opts.RoleClaim != "" {
+		identity.Role, _ = claims[v.opts.RoleClaim].(string)
+	}
+	scopes, ok := scopeClaim(claims[v.opts.ScopeClaim])
+	if !ok && identity.Role == "" {
+		scopes = v.opts.DefaultScopes
+	}
+	identity.Scopes = scopes
+	return identity, nil
+}
+
+// scopeClaim reads scopes written either OAuth style, as one space separated string, or as
+// an array of strings.
+func scopeClaim(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v), true
+	case []any:
+		scopes := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes, true
+	}
+	return nil, false
+}
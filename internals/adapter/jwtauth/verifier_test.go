@@ -0,0 +1,228 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func hmacOptions() Options {
+	return Options{
+		HMACSecret:    testSecret,
+		Issuer:        "https://auth.example.com",
+		Audience:      "currency-exchange",
+		SubjectClaim:  "sub",
+		TierClaim:     "tier",
+		RoleClaim:     "role",
+		ScopeClaim:    "scope",
+		DefaultScopes: []string{"rates:read"},
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "partner-a",
+		"iss":  "https://auth.example.com",
+		"aud":  "currency-exchange",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"tier": "gold",
+	}
+}
+
+func sign(t *testing.T, claims jwt.MapClaims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+func TestVerifier_HMAC(t *testing.T) {
+	v := NewVerifier(hmacOptions())
+
+	identity, err := v.Verify(context.Background(), sign(t, validClaims(), testSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "partner-a", Tier: "gold", Scopes: []string{"rates:read"}}, identity)
+
+	claims := validClaims()
+	claims["scope"] = "rates:read admin"
+	identity, err = v.Verify(context.Background(), sign(t, claims, testSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rates:read", "admin"}, identity.Scopes)
+
+	claims["scope"] = []any{"admin"}
+	identity, err = v.Verify(context.Background(), sign(t, claims, testSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, identity.Scopes)
+
+	// A role replaces the default scopes.
+	claims = validClaims()
+	claims["role"] = "admin"
+	identity, err = v.Verify(context.Background(), sign(t, claims, testSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", identity.Role)
+	assert.Empty(t, identity.Scopes)
+}
+
+func TestVerifier_RejectsInvalidTokens(t *testing.T) {
+	v := NewVerifier(hmacOptions())
+	with := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	for name, token := range map[string]string{
+		"wrong secret":   sign(t, validClaims(), "another-secret-another-secret-xx"),
+		"expired":        sign(t, with("exp", time.Now().Add(-time.Minute).Unix()), testSecret),
+		"no expiry":      sign(t, with("exp", nil), testSecret),
+		"wrong issuer":   sign(t, with("iss", "https://evil.example.com"), testSecret),
+		"wrong audience": sign(t, with("aud", "another-service"), testSecret),
+		"no subject":     sign(t, with("sub", nil), testSecret),
+		"garbage":        "not-a-token",
+	} {
+		_, err := v.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+
+	// Unsigned tokens are never accepted.
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	_, err := v.Verify(context.Background(), unsigned)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kid": kid,
+		"kty": "RSA",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func TestVerifier_JWKS(t *testing.T) {
+	first, _ := rsa.GenerateKey(rand.Reader, 2048)
+	second, _ := rsa.GenerateKey(rand.Reader, 2048)
+	published := []map[string]string{rsaJWK("k1", first)}
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": published})
+	}))
+	defer server.Close()
+
+	opts := hmacOptions()
+	opts.HMACSecret, opts.JWKSURL, opts.JWKSRefresh = "", server.URL, time.Hour
+	v := NewVerifier(opts)
+	now := time.Now()
+	v.keys.now = func() time.Time { return now }
+
+	signRSA := func(kid string, key *rsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	identity, err := v.Verify(context.Background(), signRSA("k1", first))
+	assert.NoError(t, err)
+	assert.Equal(t, "partner-a", identity.Subject)
+	_, err = v.Verify(context.Background(), signRSA("k1", first))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// HMAC tokens are refused once keys come from a JWKS.
+	_, err = v.Verify(context.Background(), sign(t, validClaims(), testSecret))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// After a rotation, a new key ID is picked up, but no more than once a minute.
+	published = append(published, rsaJWK("k2", second))
+	_, err = v.Verify(context.Background(), signRSA("k2", second))
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(minRefetchInterval)
+	_, err = v.Verify(context.Background(), signRSA("k2", second))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestVerifier_JWKSUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims()).SignedString(key)
+	opts := hmacOptions()
+	opts.HMACSecret, opts.JWKSURL, opts.JWKSRefresh = "", server.URL, time.Hour
+
+	_, err := NewVerifier(opts).Verify(context.Background(), token)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalid)
+}
+
+func TestVerifier_JWKSDownAtBoot(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims()).SignedString(key)
+	opts := hmacOptions()
+	opts.HMACSecret, opts.JWKSURL, opts.JWKSRefresh = "", server.URL, time.Hour
+	v := NewVerifier(opts)
+	now := time.Now()
+	v.keys.now = func() time.Time { return now }
+
+	// Requests arriving while the key server hangs share a single fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify(context.Background(), token)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrInvalid)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Without any keys, the failure is remembered instead of retried on every request.
+	_, err := v.Verify(context.Background(), token)
+	assert.ErrorContains(t, err, "status 502")
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(minRefetchInterval)
+	_, err = v.Verify(context.Background(), token)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
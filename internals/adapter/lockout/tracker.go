@@ -0,0 +1,73 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTracker counts failed authentication attempts per source, such as a client IP,
+// and blocks sources that fail too often. State lives in Redis, so a source is blocked by
+// every replica at once.
+type RedisTracker struct {
+	client    *redis.Client
+	threshold int64
+	window    time.Duration
+	blockFor  time.Duration
+}
+
+// NewRedisTracker blocks a source for blockFor once it has failed threshold times within
+// window.
+func NewRedisTracker(client *redis.Client, threshold int, window, blockFor time.Duration) *RedisTracker {
+	return &RedisTracker{client: client, threshold: int64(threshold), window: window, blockFor: blockFor}
+}
+
+func failuresKey(source string) string {
+	return "authfail:" + source
+}
+
+func blockKey(source string) string {
+	return "authblock:" + source
+}
+
+// Fail counts one failure of source. It reports whether that blocked the source, in which
+// case its count starts over for when the block ends.
+func (t *RedisTracker) Fail(ctx context.Context, source string) (bool, error) {
+	var count *redis.IntCmd
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, failuresKey(source))
+		// NX keeps the window fixed from the first failure instead of sliding with each one.
+		pipe.ExpireNX(ctx, failuresKey(source), t.window)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to count authentication failure: %w", err)
+	}
+	if count.Val() < t.threshold {
+		return false, nil
+	}
+	_, err = t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, blockKey(source), 1, t.blockFor)
+		pipe.Del(ctx, failuresKey(source))
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to block authentication source: %w", err)
+	}
+	return true, nil
+}
+
+// BlockedFor returns how much longer source is blocked, 0 when it isn't.
+func (t *RedisTracker) BlockedFor(ctx context.Context, source string) (time.Duration, error) {
+	ttl, err := t.client.PTTL(ctx, blockKey(source)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check authentication block: %w", err)
+	}
+	// PTTL is negative for keys that don't exist or, which never happens here, don't expire.
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
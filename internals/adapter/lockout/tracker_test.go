@@ -0,0 +1,68 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTracker(t *testing.T) (*RedisTracker, *miniredis.Miniredis) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	t.Cleanup(mini.Close)
+	return NewRedisTracker(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 3, time.Minute, 10*time.Minute), mini
+}
+
+func TestRedisTracker(t *testing.T) {
+	tracker, mini := setupTracker(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		blocked, err := tracker.Fail(ctx, "ip:203.0.113.7")
+		assert.NoError(t, err)
+		assert.False(t, blocked)
+	}
+	blockedFor, err := tracker.BlockedFor(ctx, "ip:203.0.113.7")
+	assert.NoError(t, err)
+	assert.Zero(t, blockedFor)
+
+	blocked, err := tracker.Fail(ctx, "ip:203.0.113.7")
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+	blockedFor, _ = tracker.BlockedFor(ctx, "ip:203.0.113.7")
+	assert.Equal(t, 10*time.Minute, blockedFor)
+
+	// Other sources are unaffected, and the block lifts on its own.
+	blockedFor, _ = tracker.BlockedFor(ctx, "ip:198.51.100.1")
+	assert.Zero(t, blockedFor)
+	mini.FastForward(10 * time.Minute)
+	blockedFor, _ = tracker.BlockedFor(ctx, "ip:203.0.113.7")
+	assert.Zero(t, blockedFor)
+}
+
+func TestRedisTracker_WindowExpires(t *testing.T) {
+	tracker, mini := setupTracker(t)
+	ctx := context.Background()
+
+	_, _ = tracker.Fail(ctx, "key:partner-a")
+	_, _ = tracker.Fail(ctx, "key:partner-a")
+	// Failures spread out over more than the window never add up to a block.
+	mini.FastForward(time.Minute)
+	blocked, err := tracker.Fail(ctx, "key:partner-a")
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func TestRedisTracker_RedisDown(t *testing.T) {
+	tracker, mini := setupTracker(t)
+	mini.Close()
+
+	_, err := tracker.Fail(context.Background(), "ip:203.0.113.7")
+	assert.Error(t, err)
+	_, err = tracker.BlockedFor(context.Background(), "ip:203.0.113.7")
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache remembers the nonces of signed requests, shared by every replica, so a captured
+// request can't be sent again.
+type RedisCache struct {
+	client *redis.Client
+}
+
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+func nonceKey(keyID, nonce string) string {
+	return "nonce:" + keyID + ":" + nonce
+}
+
+// Claim records nonce as used by keyID for ttl. It reports false when the nonce was already
+// used within that time.
+func (c *RedisCache) Claim(ctx context.Context, keyID, nonce string, ttl time.Duration) (bool, error) {
+	claimed, err := c.client.SetNX(ctx, nonceKey(keyID, nonce), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record request nonce: %w", err)
+	}
+	return claimed, nil
+}
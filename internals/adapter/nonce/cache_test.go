@@ -0,0 +1,38 @@
+package nonce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisCache_Claim(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mini.Close()
+	cache := NewRedisCache(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+	ctx := context.Background()
+
+	claimed, err := cache.Claim(ctx, "partner-a", "n-1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = cache.Claim(ctx, "partner-a", "n-1", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// Nonces are per key, and can be used again once forgotten.
+	claimed, _ = cache.Claim(ctx, "partner-b", "n-1", time.Minute)
+	assert.True(t, claimed)
+	mini.FastForward(time.Minute)
+	claimed, _ = cache.Claim(ctx, "partner-a", "n-1", time.Minute)
+	assert.True(t, claimed)
+
+	mini.Close()
+	_, err = cache.Claim(ctx, "partner-a", "n-2", time.Minute)
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Default templates of alert emails, executed with an EmailData.
+const (
+	DefaultEmailSubject = `{{if .Alert.Resolved}}[RESOLVED]{{else}}[ALERT]{{end}} {{.Alert.Title}}`
+	DefaultEmailBody    = `{{if .Recipient.Name}}Hi {{.Recipient.Name}},
+
+{{end}}{{.Alert.Message}}
+
+Raised at {{.Alert.Time.Format "2006-01-02 15:04:05 MST"}} by the exchange rate service.
+`
+)
+
+// smtpTimeout bounds sending one email when the context has no deadline of its own.
+const smtpTimeout = 30 * time.Second
+
+// EmailRecipient is someone alerts are emailed to, and which of them they want.
+type EmailRecipient struct {
+	Address string
+	Name    string
+	// Kinds limits the recipient to alerts of these kinds; empty means every kind.
+	Kinds []string
+	// Resolved also sends the recipient alerts that an earlier one is resolved.
+	Resolved bool
+}
+
+func (r EmailRecipient) wants(alert Alert) bool {
+	if alert.Resolved && !r.Resolved {
+		return false
+	}
+	return len(r.Kinds) == 0 || slices.Contains(r.Kinds, alert.Kind)
+}
+
+// EmailOptions configures an EmailNotifier.
+type EmailOptions struct {
+	Host     string
+	Port     int
+	Username string // authenticates with PLAIN when set
+	Password string
+	From     string
+
+	Recipients []EmailRecipient
+	// Subject and Body are text/template sources executed with an EmailData; empty ones use
+	// DefaultEmailSubject and DefaultEmailBody.
+	Subject string
+	Body    string
+}
+
+// EmailData is what the subject and body templates are executed with.
+type EmailData struct {
+	Alert     Alert
+	Recipient EmailRecipient
+}
+
+// EmailNotifier emails alerts over SMTP, one message per recipient so templates can address
+// each by name.
+type EmailNotifier struct {
+	opts    EmailOptions
+	subject *template.Template
+	body    *template.Template
+	send    func(ctx context.Context, to string, msg []byte) error
+}
+
+// NewEmailNotifier fails when a template doesn't parse.
+func NewEmailNotifier(opts EmailOptions) (*EmailNotifier, error) {
+	if opts.Subject == "" {
+		opts.Subject = DefaultEmailSubject
+	}
+	if opts.Body == "" {
+		opts.Body = DefaultEmailBody
+	}
+	subject, err := template.New("subject").Parse(opts.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(opts.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email body template: %w", err)
+	}
+	n := &EmailNotifier{opts: opts, subject: subject, body: body}
+	n.send = n.sendSMTP
+	return n, nil
+}
+
+// Notify emails every recipient that wants alert, and reports the recipients it failed for.
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, recipient := range n.opts.Recipients {
+		if !recipient.wants(alert) {
+			continue
+		}
+		msg, err := n.message(alert, recipient)
+		if err == nil {
+			err = n.send(ctx, recipient.Address, msg)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to email %s: %w", recipient.Address, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// message renders the email to recipient, headers included.
+func (n *EmailNotifier) message(alert Alert, recipient EmailRecipient) ([]byte, error) {
+	data := EmailData{Alert: alert, Recipient: recipient}
+	var subject, body bytes.Buffer
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := n.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+
+	var msg bytes.Buffer
+	to := mail.Address{Name: recipient.Name, Address: recipient.Address}
+	date := alert.Time
+	if date.IsZero() {
+		date = time.Now()
+	}
+	fmt.Fprintf(&msg, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body.String(), "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// sendSMTP delivers msg to one recipient, upgrading to TLS when the server offers STARTTLS.
+func (n *EmailNotifier) sendSMTP(ctx context.Context, to string, msg []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+	addr := net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.opts.Host}); err != nil {
+			return err
+		}
+	}
+	if n.opts.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)); err != nil {
+			return err
+		}
+	}
+	from, err := mail.ParseAddress(n.opts.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Multi sends every alert through each of its notifiers, and reports those that failed.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
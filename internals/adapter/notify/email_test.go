@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailNotifier_RendersPerRecipient(t *testing.T) {
+	n, err := NewEmailNotifier(EmailOptions{
+		From: "Exchange Rates <alerts@example.com>",
+		Recipients: []EmailRecipient{
+			{Address: "ops@example.com", Resolved: true},
+			{Address: "fx@example.com", Name: "FX desk", Kinds: []string{KindRate}},
+		},
+	})
+	assert.NoError(t, err)
+	sent := map[string]string{}
+	n.send = func(ctx context.Context, to string, msg []byte) error {
+		sent[to] = string(msg)
+		return nil
+	}
+	at := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, n.Notify(context.Background(), Alert{Kind: KindRate, Title: "USD/INR above 84", Message: "The rate is 84.2.", Time: at}))
+	assert.Equal(t, "From: Exchange Rates <alerts@example.com>\r\n"+
+		"To: \"FX desk\" <fx@example.com>\r\n"+
+		"Subject: [ALERT] USD/INR above 84\r\n"+
+		"Date: Mon, 14 Apr 2025 10:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"Hi FX desk,\r\n\r\nThe rate is 84.2.\r\n\r\nRaised at 2025-04-14 10:00:00 UTC by the exchange rate service.\r\n", sent["fx@example.com"])
+	assert.Contains(t, sent["ops@example.com"], "To: <ops@example.com>\r\n")
+
+	// The FX desk only wants rate alerts, and no resolved ones.
+	clear(sent)
+	assert.NoError(t, n.Notify(context.Background(), Alert{Kind: KindRefresh, Title: "refresh failing for USD", Time: at}))
+	assert.NoError(t, n.Notify(context.Background(), Alert{Kind: KindRate, Title: "USD/INR resolved", Resolved: true, Time: at}))
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent["ops@example.com"], "Subject: [RESOLVED] USD/INR resolved\r\n")
+}
+
+func TestEmailNotifier_RejectsBadTemplates(t *testing.T) {
+	_, err := NewEmailNotifier(EmailOptions{Subject: "{{.Alert.Title"})
+	assert.ErrorContains(t, err, "invalid email subject template")
+}
+
+// fakeSMTPServer accepts one session and returns the DATA it received.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- data.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return lis.Addr().String(), received
+}
+
+func TestEmailNotifier_SendsOverSMTP(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNumber, _ := strconv.Atoi(port)
+	n, err := NewEmailNotifier(EmailOptions{Host: host, Port: portNumber, From: "alerts@example.com", Recipients: []EmailRecipient{{Address: "ops@example.com"}}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, n.Notify(context.Background(), Alert{Title: "Refresh failing", Message: "USD failed 3 times"}))
+	assert.Contains(t, <-received, "Subject: [ALERT] Refresh failing\r\n")
+}
+
+func TestMulti_ReportsEveryFailure(t *testing.T) {
+	n := Multi{LogNotifier{}, NewWebhookNotifier("http://127.0.0.1:0/hook", nil)}
+	assert.ErrorContains(t, n.Notify(context.Background(), Alert{Title: "x"}), "failed to send alert")
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/logging"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Kinds of alert, which recipients can choose between.
+const (
+	KindRefresh = "refresh" // background refreshes failing or recovering
+	KindRate    = "rate"    // rate alert rules firing or resolving
+)
+
+// Alert is an operational event worth telling a human about.
+type Alert struct {
+	Kind     string    `json:"kind,omitempty"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Resolved bool      `json:"resolved"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier delivers alerts to wherever operators will see them.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// New returns a webhook notifier for webhookURL, or one that only logs when no URL is configured.
+func New(webhookURL string) Notifier {
+	if webhookURL == "" {
+		return LogNotifier{}
+	}
+	return NewWebhookNotifier(webhookURL, nil)
+}
+
+// LogNotifier writes alerts to the service log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	logging.For("alerts").Warn("ALERT "+alert.Title, "message", alert.Message)
+	return nil
+}
+
+// webhookPayload carries the alert both as Slack compatible text and as structured fields,
+// so the same URL setting works for Slack incoming webhooks and generic receivers.
+type webhookPayload struct {
+	Text  string `json:"text"`
+	Alert Alert  `json:"alert"`
+}
+
+// WebhookNotifier POSTs alerts as JSON to a webhook URL.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier builds a webhook notifier. httpClient may be nil, in which case
+// helpers.DefaultHTTPClient is used.
+func NewWebhookNotifier(url string, httpClient *http.Client) *WebhookNotifier {
+	if httpClient == nil {
+		httpClient = helpers.DefaultHTTPClient
+	}
+	return &WebhookNotifier{url: url, httpClient: httpClient}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(webhookPayload{
+		Text:  fmt.Sprintf("*%s*\n%s", alert.Title, alert.Message),
+		Alert: alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier_PostsAlert(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	alert := Alert{Title: "Refresh failing", Message: "USD failed 3 times", Time: time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)}
+	err := NewWebhookNotifier(server.URL, nil).Notify(context.Background(), alert)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "*Refresh failing*\nUSD failed 3 times", payload.Text)
+	assert.Equal(t, alert, payload.Alert)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, nil).Notify(context.Background(), Alert{Title: "x"})
+	assert.ErrorContains(t, err, "status 500")
+}
+
+func TestNew(t *testing.T) {
+	assert.IsType(t, LogNotifier{}, New(""))
+	assert.IsType(t, &WebhookNotifier{}, New("http://example.com/hook"))
+}
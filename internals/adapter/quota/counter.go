@@ -0,0 +1,72 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// retention is how long a month's count is kept after the month ends, so last month's usage
+// can still be looked up.
+const retention = 62 * 24 * time.Hour
+
+// Period returns the calendar month (UTC) t falls in, as "2006-01", and when it ends.
+func Period(t time.Time) (string, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01"), start.AddDate(0, 1, 0)
+}
+
+// RedisCounter counts requests per API key per calendar month, shared by every replica.
+type RedisCounter struct {
+	client *redis.Client
+}
+
+func NewRedisCounter(client *redis.Client) *RedisCounter {
+	return &RedisCounter{client: client}
+}
+
+func counterKey(keyID, period string) string {
+	return "quota:" + keyID + ":" + period
+}
+
+// Increment counts one request of keyID in the month of now and returns the month's total.
+func (c *RedisCounter) Increment(ctx context.Context, keyID string, now time.Time) (int64, error) {
+	period, end := Period(now)
+	key := counterKey(keyID, period)
+	var incr *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireAt(ctx, key, end.Add(retention))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count request for quota: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// Decrement takes back a request counted by Increment, for requests that were rejected.
+func (c *RedisCounter) Decrement(ctx context.Context, keyID string, now time.Time) error {
+	period, _ := Period(now)
+	if err := c.client.Decr(ctx, counterKey(keyID, period)).Err(); err != nil {
+		return fmt.Errorf("failed to uncount request for quota: %w", err)
+	}
+	return nil
+}
+
+// Usage returns how many requests keyID made in the month of now.
+func (c *RedisCounter) Usage(ctx context.Context, keyID string, now time.Time) (int64, error) {
+	period, _ := Period(now)
+	count, err := c.client.Get(ctx, counterKey(keyID, period)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read quota usage: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,53 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriod(t *testing.T) {
+	// Months follow UTC, whatever the zone of the time passed in.
+	period, end := Period(time.Date(2025, 12, 31, 23, 30, 0, 0, time.FixedZone("IST", 5*3600+1800)))
+	assert.Equal(t, "2025-12", period)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+	period, _ = Period(time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC))
+	assert.Equal(t, "2026-01", period)
+}
+
+func TestRedisCounter(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mini.Close()
+	counter := NewRedisCounter(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+	ctx := context.Background()
+	// Counters expire relative to the real clock, so the months are this one and the next.
+	thisMonth := time.Now()
+	period, nextMonth := Period(thisMonth)
+
+	used, err := counter.Usage(ctx, "partner-a", thisMonth)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), used)
+
+	for i := 1; i <= 3; i++ {
+		count, err := counter.Increment(ctx, "partner-a", thisMonth)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), count)
+	}
+	assert.NoError(t, counter.Decrement(ctx, "partner-a", thisMonth))
+
+	used, _ = counter.Usage(ctx, "partner-a", thisMonth)
+	assert.Equal(t, int64(2), used)
+	used, _ = counter.Usage(ctx, "partner-b", thisMonth)
+	assert.Equal(t, int64(0), used)
+
+	// A new month starts from zero; the old count is kept for a while.
+	count, _ := counter.Increment(ctx, "partner-a", nextMonth)
+	assert.Equal(t, int64(1), count)
+	assert.True(t, mini.Exists("quota:partner-a:"+period))
+}
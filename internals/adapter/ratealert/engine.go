@@ -0,0 +1,93 @@
+package ratealert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"currency-exchange/internals/adapter/notify"
+	"currency-exchange/internals/adapter/updates"
+)
+
+// queueSize is how many refreshes can wait to be evaluated before new ones are dropped.
+const queueSize = 64
+
+// Engine evaluates the rules against every refresh it is told about and sends their alerts
+// through a notifier. Only the replica that ran a refresh publishes it, so each is evaluated
+// once.
+type Engine struct {
+	store    *RedisStore
+	notifier notify.Notifier
+	logger   *slog.Logger
+	queue    *updates.Queue
+	now      func() time.Time
+}
+
+func NewEngine(store *RedisStore, notifier notify.Notifier, logger *slog.Logger) *Engine {
+	return &Engine{store: store, notifier: notifier, logger: logger, queue: updates.NewQueue("rate alerts", queueSize, logger), now: time.Now}
+}
+
+// Publish hands update to Start, which checks every armed rule against its rates.
+func (e *Engine) Publish(update updates.Update) {
+	e.queue.Publish(update)
+}
+
+// Start evaluates queued updates until ctx is cancelled.
+func (e *Engine) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-e.queue.Updates():
+			if err := e.evaluate(ctx, update); err != nil {
+				e.logger.Error("Failed to evaluate rate alerts", "base", update.Base, "error", err)
+			}
+		}
+	}
+}
+
+func (e *Engine) evaluate(ctx context.Context, update updates.Update) error {
+	rules, err := e.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	now := e.now()
+	for _, rule := range rules {
+		if rule.Base != update.Base {
+			continue
+		}
+		rate, ok := update.Rates[rule.Target]
+		if !ok {
+			continue
+		}
+		switch rule.step(rate, now) {
+		case fired:
+			e.logger.Info("Rate alert fired", "rule", rule.ID, "name", rule.Name, "rate", rate)
+			e.notify(ctx, notify.Alert{
+				Kind:    notify.KindRate,
+				Title:   fmt.Sprintf("Rate alert %q fired", rule.Name),
+				Message: fmt.Sprintf("%s: the rate is %v.", rule.describe(true), rate),
+			})
+		case resolved:
+			e.logger.Info("Rate alert resolved", "rule", rule.ID, "name", rule.Name, "rate", rate)
+			e.notify(ctx, notify.Alert{
+				Kind:     notify.KindRate,
+				Title:    fmt.Sprintf("Rate alert %q resolved", rule.Name),
+				Message:  fmt.Sprintf("%s: the rate is %v. The alert is armed again after %s.", rule.describe(false), rate, time.Duration(rule.CooldownSeconds)*time.Second),
+				Resolved: true,
+			})
+		}
+		if err := e.store.save(ctx, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) notify(ctx context.Context, alert notify.Alert) {
+	alert.Time = e.now().UTC()
+	if err := e.notifier.Notify(ctx, alert); err != nil {
+		e.logger.Error("Error sending alert", "alert", alert.Title, "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package ratealert
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/notify"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type recordingNotifier struct {
+	alerts []notify.Alert
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestRule_StateMachine(t *testing.T) {
+	rule := Rule{Condition: ConditionAbove, Threshold: 84, CooldownSeconds: 3600, State: StateArmed}
+	now := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after time.Duration
+		rate  float64
+		want  transition
+		state string
+	}{
+		{0, 83.9, unchanged, StateArmed},
+		{time.Minute, 84.1, fired, StateFired},
+		{time.Minute, 84.5, unchanged, StateFired},
+		{time.Minute, 83.8, resolved, StateCooldown},
+		{time.Minute, 84.2, unchanged, StateCooldown}, // cooling down
+		{time.Hour, 83.9, unchanged, StateArmed},
+		{time.Minute, 84.3, fired, StateFired},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		assert.Equal(t, step.want, rule.step(step.rate, now), "step %d", i)
+		assert.Equal(t, step.state, rule.State, "step %d", i)
+	}
+}
+
+func TestRule_DailyChange(t *testing.T) {
+	rule := Rule{Condition: ConditionDailyChange, Threshold: 1, State: StateArmed}
+	day := time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, unchanged, rule.step(100, day.Add(time.Hour)))
+	assert.Equal(t, unchanged, rule.step(100.9, day.Add(2*time.Hour)))
+	assert.Equal(t, fired, rule.step(101.1, day.Add(3*time.Hour)))
+	// The next day is measured from the last rate of the one before.
+	assert.Equal(t, resolved, rule.step(101.5, day.Add(25*time.Hour)))
+	assert.Equal(t, 101.1, rule.DayRate)
+	assert.Equal(t, "2025-04-15", rule.Day)
+}
+
+func TestEngine_NotifiesAndPersistsState(t *testing.T) {
+	mini := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: mini.Addr()}))
+	ctx := context.Background()
+	rule, err := store.Create(ctx, NewRule{Name: "rupee watch", Base: "USD", Target: "INR", Condition: ConditionAbove, Threshold: 84, Cooldown: time.Hour})
+	assert.NoError(t, err)
+	_, err = store.Create(ctx, NewRule{Name: "euro watch", Base: "EUR", Target: "INR", Condition: ConditionAbove, Threshold: 1})
+	assert.NoError(t, err)
+
+	notifier := &recordingNotifier{}
+	engine := NewEngine(store, notifier, discardLogger)
+	now := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	usdInr := func(rate float64) updates.Update {
+		return updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": rate}, Timestamp: now}
+	}
+
+	assert.NoError(t, engine.evaluate(ctx, usdInr(84.2)))
+	assert.NoError(t, engine.evaluate(ctx, usdInr(84.3)))
+	now = now.Add(time.Minute)
+	assert.NoError(t, engine.evaluate(ctx, usdInr(83.9)))
+	assert.Equal(t, []notify.Alert{
+		{Kind: notify.KindRate, Title: `Rate alert "rupee watch" fired`, Message: "USD/INR is above 84: the rate is 84.2.", Time: now.Add(-time.Minute)},
+		{Kind: notify.KindRate, Title: `Rate alert "rupee watch" resolved`, Message: "USD/INR is back at or below 84: the rate is 83.9. The alert is armed again after 1h0m0s.", Resolved: true, Time: now},
+	}, notifier.alerts)
+
+	rules, err := store.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, rule.ID, rules[0].ID)
+	assert.Equal(t, StateCooldown, rules[0].State)
+	assert.Equal(t, now.Add(time.Hour), *rules[0].CooldownUntil)
+	assert.Equal(t, StateArmed, rules[1].State)
+
+	assert.NoError(t, store.Delete(ctx, rule.ID))
+	assert.ErrorIs(t, store.Delete(ctx, rule.ID), ErrNotFound)
+}
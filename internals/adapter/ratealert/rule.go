@@ -0,0 +1,162 @@
+package ratealert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+)
+
+// Conditions a rule can watch for.
+const (
+	ConditionAbove       = "above"        // the rate is above Threshold
+	ConditionBelow       = "below"        // the rate is below Threshold
+	ConditionDailyChange = "daily_change" // the rate moved by more than Threshold percent today
+)
+
+// States of a rule. An armed rule fires when its condition holds, and stays fired, without
+// alerting again, until it stops holding. It then cools down for its cooldown before it is
+// armed again, so a rate hovering around the threshold doesn't flood the channel.
+const (
+	StateArmed    = "armed"
+	StateFired    = "fired"
+	StateCooldown = "cooldown"
+)
+
+// Rule is an operator alert on a pair's rate.
+type Rule struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Base            domain.Currency `json:"base"`
+	Target          domain.Currency `json:"target"`
+	Condition       string          `json:"condition"`
+	Threshold       float64         `json:"threshold"`
+	CooldownSeconds int64           `json:"cooldownSeconds"`
+	CreatedAt       time.Time       `json:"createdAt"`
+
+	State         string     `json:"state"`
+	LastRate      float64    `json:"lastRate,omitempty"`
+	FiredAt       *time.Time `json:"firedAt,omitempty"`
+	CooldownUntil *time.Time `json:"cooldownUntil,omitempty"`
+	// DayRate is the rate daily_change is measured from: the last one before Day began, in UTC.
+	DayRate float64 `json:"dayRate,omitempty"`
+	Day     string  `json:"day,omitempty"`
+}
+
+// NewRule holds what an operator chooses when adding a rule.
+type NewRule struct {
+	Name      string
+	Base      domain.Currency
+	Target    domain.Currency
+	Condition string
+	Threshold float64
+	Cooldown  time.Duration
+}
+
+// Validate checks the name, condition, threshold and cooldown. The pair is the caller's to check.
+func (n NewRule) Validate() error {
+	if n.Name == "" {
+		return errors.New("name is required")
+	}
+	switch n.Condition {
+	case ConditionAbove, ConditionBelow:
+		if !(n.Threshold > 0) || math.IsInf(n.Threshold, 0) {
+			return errors.New("threshold must be a rate greater than 0")
+		}
+	case ConditionDailyChange:
+		if !(n.Threshold > 0) || math.IsInf(n.Threshold, 0) {
+			return errors.New("threshold must be a percentage greater than 0")
+		}
+	default:
+		return fmt.Errorf("unknown condition %q, expected above, below or daily_change", n.Condition)
+	}
+	if n.Cooldown < 0 {
+		return errors.New("cooldown must not be negative")
+	}
+	return nil
+}
+
+// transition is what a refresh did to a rule worth telling operators about.
+type transition int
+
+const (
+	unchanged transition = iota
+	fired
+	resolved
+)
+
+// step moves r's state machine on with a refreshed rate.
+func (r *Rule) step(rate float64, now time.Time) transition {
+	holds := r.holds(rate, now)
+	r.LastRate = rate
+
+	if r.State == StateCooldown && r.CooldownUntil != nil && now.Before(*r.CooldownUntil) {
+		return unchanged
+	}
+	switch r.State {
+	case StateFired:
+		if holds {
+			return unchanged
+		}
+		until := now.Add(time.Duration(r.CooldownSeconds) * time.Second).UTC()
+		r.State, r.CooldownUntil = StateCooldown, &until
+		return resolved
+	default: // armed, or cooled down
+		r.State, r.CooldownUntil = StateArmed, nil
+		if !holds {
+			return unchanged
+		}
+		firedAt := now.UTC()
+		r.State, r.FiredAt = StateFired, &firedAt
+		return fired
+	}
+}
+
+// holds reports whether the condition holds at rate. It is called before LastRate is
+// updated, which daily_change takes the day's reference rate from.
+func (r *Rule) holds(rate float64, now time.Time) bool {
+	switch r.Condition {
+	case ConditionAbove:
+		return rate > r.Threshold
+	case ConditionBelow:
+		return rate < r.Threshold
+	case ConditionDailyChange:
+		if day := now.UTC().Format("2006-01-02"); r.Day != day {
+			r.Day, r.DayRate = day, r.LastRate
+			if r.DayRate == 0 {
+				r.DayRate = rate
+			}
+		}
+		return r.dailyChange(rate) > r.Threshold
+	}
+	return false
+}
+
+// dailyChange is how far rate is from the day's reference rate, in percent.
+func (r *Rule) dailyChange(rate float64) float64 {
+	return math.Abs(rate-r.DayRate) / r.DayRate * 100
+}
+
+// describe words the condition, e.g. "USD/INR is above 84".
+func (r *Rule) describe(holds bool) string {
+	pair := string(r.Base) + "/" + string(r.Target)
+	switch r.Condition {
+	case ConditionAbove:
+		if holds {
+			return fmt.Sprintf("%s is above %v", pair, r.Threshold)
+		}
+		return fmt.Sprintf("%s is back at or below %v", pair, r.Threshold)
+	case ConditionBelow:
+		if holds {
+			return fmt.Sprintf("%s is below %v", pair, r.Threshold)
+		}
+		return fmt.Sprintf("%s is back at or above %v", pair, r.Threshold)
+	default:
+		if holds {
+			return fmt.Sprintf("%s moved more than %v%% today", pair, r.Threshold)
+		}
+		return fmt.Sprintf("%s is back within %v%% of today's opening rate", pair, r.Threshold)
+	}
+}
@@ -0,0 +1,122 @@
+package ratealert
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("alert rule not found")
+
+// rulesKey is a set of the IDs of every rule.
+const rulesKey = "rate_alerts"
+
+func ruleKey(id string) string {
+	return "rate_alert:" + id
+}
+
+// RedisStore keeps rules and their state in Redis, so state carries over whichever replica
+// evaluates the next refresh.
+type RedisStore struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, now: time.Now}
+}
+
+// Create adds an armed rule.
+func (s *RedisStore) Create(ctx context.Context, newRule NewRule) (Rule, error) {
+	if err := newRule.Validate(); err != nil {
+		return Rule{}, err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return Rule{}, fmt.Errorf("failed to generate alert rule id: %w", err)
+	}
+	rule := Rule{
+		ID:              "ra-" + hex.EncodeToString(b),
+		Name:            newRule.Name,
+		Base:            newRule.Base,
+		Target:          newRule.Target,
+		Condition:       newRule.Condition,
+		Threshold:       newRule.Threshold,
+		CooldownSeconds: int64(newRule.Cooldown / time.Second),
+		CreatedAt:       s.now().UTC(),
+		State:           StateArmed,
+	}
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, ruleKey(rule.ID), data, 0)
+		pipe.SAdd(ctx, rulesKey, rule.ID)
+		return nil
+	})
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to store alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// List returns every rule with its state, oldest first.
+func (s *RedisStore) List(ctx context.Context) ([]Rule, error) {
+	ids, err := s.client.SMembers(ctx, rulesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	rules := make([]Rule, 0, len(ids))
+	for _, id := range ids {
+		data, err := s.client.Get(ctx, ruleKey(id)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue // deleted meanwhile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alert rule: %w", err)
+		}
+		var rule Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("invalid alert rule %s: %w", id, err)
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+	return rules, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	var deleted *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, rulesKey, id)
+		deleted = pipe.Del(ctx, ruleKey(id))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	if deleted.Val() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// save writes back rule's state, unless it was deleted meanwhile.
+func (s *RedisStore) save(ctx context.Context, rule Rule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	if err := s.client.SetXX(ctx, ruleKey(rule.ID), data, redis.KeepTTL).Err(); err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package updates
+
+import (
+	"currency-exchange/internals/core/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Update is a base's latest rates as just written to the cache by a refresh.
+type Update struct {
+	Base  domain.Currency
+	Rates map[domain.Currency]float64
+	// Changed lists the targets whose rate differs from the base's previous update. Nil when
+	// that isn't known, e.g. for the first update after a restart: treat every target as changed.
+	Changed   []domain.Currency
+	Timestamp time.Time
+}
+
+// HasChanged reports whether target's rate may differ from the base's previous update.
+func (u Update) HasChanged(target domain.Currency) bool {
+	if u.Changed == nil {
+		return true
+	}
+	return slices.Contains(u.Changed, target)
+}
+
+// Publisher is told about every refresh of a base's latest rates.
+type Publisher interface {
+	Publish(update Update)
+}
+
+// Bus fans updates out to the streams subscribed to them. Publishing never blocks: a
+// subscriber that falls behind loses its oldest queued update, which the next one for the
+// same base supersedes anyway.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	buffer      int
+	closed      bool
+}
+
+// NewBus queues up to buffer updates per subscriber. Anything below 1 means 1.
+func NewBus(buffer int) *Bus {
+	if buffer < 1 {
+		buffer = 1
+	}
+	return &Bus{subscribers: make(map[*Subscription]struct{}), buffer: buffer}
+}
+
+// Subscription receives every update published after it was made, until it is closed.
+type Subscription struct {
+	bus     *Bus
+	updates chan Update
+	closed  bool // guarded by bus.mu
+}
+
+// Subscribe on a closed bus returns a subscription that is already closed.
+func (b *Bus) Subscribe() *Subscription {
+	s := &Subscription{bus: b, updates: make(chan Update, b.buffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.close()
+		return s
+	}
+	b.subscribers[s] = struct{}{}
+	return s
+}
+
+// Updates is closed when the subscription is.
+func (s *Subscription) Updates() <-chan Update {
+	return s.updates
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.subscribers, s)
+	s.close()
+}
+
+// close needs bus.mu held.
+func (s *Subscription) close() {
+	if !s.closed {
+		s.closed = true
+		close(s.updates)
+	}
+}
+
+func (b *Bus) Publish(update Update) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subscribers {
+		select {
+		case s.updates <- update:
+			continue
+		default:
+		}
+		// Full: make room by dropping the oldest update. The subscriber may have drained
+		// the queue in between, in which case nothing is dropped.
+		select {
+		case <-s.updates:
+		default:
+		}
+		select {
+		case s.updates <- update:
+		default:
+		}
+	}
+}
+
+// Close closes every subscription, telling streams to end, e.g. when the server shuts down.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subscribers {
+		delete(b.subscribers, s)
+		s.close()
+	}
+}
+
+// Subscribers reports how many subscriptions are open.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Fanout publishes every update to each of its publishers in turn.
+type Fanout []Publisher
+
+func (f Fanout) Publish(update Update) {
+	for _, p := range f {
+		p.Publish(update)
+	}
+}
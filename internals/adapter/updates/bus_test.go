@@ -0,0 +1,59 @@
+package updates
+
+import (
+	"currency-exchange/internals/core/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func update(base domain.Currency, rate float64) Update {
+	return Update{Base: base, Rates: map[domain.Currency]float64{"INR": rate}, Timestamp: time.Unix(1744624800, 0)}
+}
+
+func TestBus_FansOut(t *testing.T) {
+	bus := NewBus(4)
+	first, second := bus.Subscribe(), bus.Subscribe()
+	assert.Equal(t, 2, bus.Subscribers())
+
+	bus.Publish(update("USD", 85.2))
+	assert.Equal(t, update("USD", 85.2), <-first.Updates())
+	assert.Equal(t, update("USD", 85.2), <-second.Updates())
+
+	second.Close()
+	second.Close()
+	assert.Equal(t, 1, bus.Subscribers())
+	_, open := <-second.Updates()
+	assert.False(t, open)
+
+	bus.Publish(update("EUR", 92.1))
+	assert.Equal(t, update("EUR", 92.1), <-first.Updates())
+}
+
+func TestBus_SlowSubscriberLosesOldest(t *testing.T) {
+	bus := NewBus(2)
+	s := bus.Subscribe()
+	defer s.Close()
+
+	// Publishing never waits for the subscriber.
+	for _, rate := range []float64{1, 2, 3} {
+		bus.Publish(update("USD", rate))
+	}
+	assert.Equal(t, update("USD", 2), <-s.Updates())
+	assert.Equal(t, update("USD", 3), <-s.Updates())
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(1)
+	s := bus.Subscribe()
+	bus.Close()
+	_, open := <-s.Updates()
+	assert.False(t, open)
+	s.Close()
+
+	_, open = <-bus.Subscribe().Updates()
+	assert.False(t, open)
+	assert.Equal(t, 0, bus.Subscribers())
+	bus.Publish(update("USD", 1))
+}
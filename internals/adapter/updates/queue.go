@@ -0,0 +1,30 @@
+package updates
+
+import "log/slog"
+
+// Queue hands updates to one consumer that evaluates them in the background. Publishing never
+// blocks the refresh: once size updates are waiting, new ones are dropped, since the next
+// refresh of their base supersedes them.
+type Queue struct {
+	name    string
+	updates chan Update
+	logger  *slog.Logger
+}
+
+// NewQueue names the queue in the warnings it logs when dropping updates.
+func NewQueue(name string, size int, logger *slog.Logger) *Queue {
+	return &Queue{name: name, updates: make(chan Update, size), logger: logger}
+}
+
+func (q *Queue) Publish(update Update) {
+	select {
+	case q.updates <- update:
+	default:
+		q.logger.Warn("Queue full, dropping rate update", "queue", q.name, "base", update.Base)
+	}
+}
+
+// Updates delivers the queued updates in the order they were published.
+func (q *Queue) Updates() <-chan Update {
+	return q.updates
+}
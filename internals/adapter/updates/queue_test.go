@@ -0,0 +1,22 @@
+package updates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue_DropsWhenFull(t *testing.T) {
+	q := NewQueue("test", 2, discardLogger)
+	q.Publish(update("USD", 85))
+	q.Publish(update("EUR", 92))
+	q.Publish(update("GBP", 110))
+
+	assert.Equal(t, update("USD", 85), <-q.Updates())
+	assert.Equal(t, update("EUR", 92), <-q.Updates())
+	select {
+	case dropped := <-q.Updates():
+		t.Errorf("got %v, want it dropped", dropped)
+	default:
+	}
+}
@@ -0,0 +1,110 @@
+package updates
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Channel is the Redis channel refreshes are published on.
+const Channel = "rate_updates"
+
+// publishTimeout bounds publishing one update, so a slow Redis doesn't hold up the refresh.
+const publishTimeout = 2 * time.Second
+
+// message is an Update on the wire.
+type message struct {
+	Base      domain.Currency             `json:"base"`
+	Rates     map[domain.Currency]float64 `json:"rates"`
+	Changed   []domain.Currency           `json:"changed"`
+	Timestamp int64                       `json:"timestamp"`
+}
+
+// RedisRelay carries updates between replicas. Only the replica that ran a refresh publishes it,
+// so every replica, that one included, hands what arrives on Channel to its local subscribers:
+// its streams now push refreshes whichever replica ran them.
+type RedisRelay struct {
+	client *redis.Client
+	local  Publisher
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	last map[domain.Currency]map[domain.Currency]float64 // rates last seen on Channel per base
+}
+
+// NewRedisRelay hands the updates it receives to local, typically a Bus.
+func NewRedisRelay(client *redis.Client, local Publisher, logger *slog.Logger) *RedisRelay {
+	return &RedisRelay{client: client, local: local, logger: logger, last: make(map[domain.Currency]map[domain.Currency]float64)}
+}
+
+// Publish sends update to every replica, filling in which targets changed since the last update
+// of its base seen on Channel, whichever replica published it, so streams only push rates that
+// moved. When Redis can't be reached the update is still handed to
+// local subscribers, so this replica's streams keep up.
+func (r *RedisRelay) Publish(update Update) {
+	update.Changed = r.changed(update)
+	data, err := json.Marshal(message{Base: update.Base, Rates: update.Rates, Changed: update.Changed, Timestamp: update.Timestamp.Unix()})
+	if err != nil {
+		r.logger.Error("Failed to encode rate update", "base", update.Base, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := r.client.Publish(ctx, Channel, data).Err(); err != nil {
+		r.logger.Warn("Failed to publish rate update, only streams on this replica get it", "base", update.Base, "error", err)
+		r.local.Publish(update)
+	}
+}
+
+func (r *RedisRelay) changed(update Update) []domain.Currency {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, ok := r.last[update.Base]
+	r.last[update.Base] = update.Rates
+	if !ok {
+		return nil
+	}
+	changed := []domain.Currency{}
+	for target, rate := range update.Rates {
+		if last, ok := previous[target]; !ok || last != rate {
+			changed = append(changed, target)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i] < changed[j] })
+	return changed
+}
+
+// Start subscribes to Channel until ctx is cancelled. The subscription reconnects by itself
+// after Redis outages; refreshes published meanwhile are lost, as the next ones supersede them.
+func (r *RedisRelay) Start(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, Channel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var m message
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				r.logger.Warn("Ignoring malformed rate update", "error", err)
+				continue
+			}
+			// Remember the rates whoever published them, so a refresh this replica runs after
+			// leadership moves is compared with what its streams last got.
+			r.mu.Lock()
+			r.last[m.Base] = m.Rates
+			r.mu.Unlock()
+			r.local.Publish(Update{Base: m.Base, Rates: m.Rates, Changed: m.Changed, Timestamp: time.Unix(m.Timestamp, 0)})
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package updates
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRedisRelay_FansOutToEveryReplica(t *testing.T) {
+	mini := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two replicas, each with its own streams.
+	publisherBus, otherBus := NewBus(4), NewBus(4)
+	publisher := NewRedisRelay(redis.NewClient(&redis.Options{Addr: mini.Addr()}), publisherBus, discardLogger)
+	other := NewRedisRelay(redis.NewClient(&redis.Options{Addr: mini.Addr()}), otherBus, discardLogger)
+	go publisher.Start(ctx)
+	go other.Start(ctx)
+	assert.Eventually(t, func() bool { return mini.PubSubNumSub(Channel)[Channel] == 2 }, time.Second, 10*time.Millisecond)
+	fromPublisher, fromOther := publisherBus.Subscribe(), otherBus.Subscribe()
+
+	at := time.Unix(1744624800, 0)
+	publisher.Publish(Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.9}, Timestamp: at})
+	first := Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.9}, Timestamp: at}
+	assert.Equal(t, first, <-fromPublisher.Updates())
+	assert.Equal(t, first, <-fromOther.Updates())
+
+	publisher.Publish(Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2, "EUR": 0.9}, Timestamp: at.Add(time.Hour)})
+	second := <-fromOther.Updates()
+	assert.Equal(t, []domain.Currency{"INR"}, second.Changed)
+	assert.Equal(t, 85.2, second.Rates["INR"])
+	assert.Equal(t, at.Add(time.Hour), second.Timestamp)
+}
+
+func TestRedisRelay_ChangedAfterLeadershipMoves(t *testing.T) {
+	mini := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	firstBus, secondBus := NewBus(4), NewBus(4)
+	first := NewRedisRelay(redis.NewClient(&redis.Options{Addr: mini.Addr()}), firstBus, discardLogger)
+	second := NewRedisRelay(redis.NewClient(&redis.Options{Addr: mini.Addr()}), secondBus, discardLogger)
+	go first.Start(ctx)
+	go second.Start(ctx)
+	assert.Eventually(t, func() bool { return mini.PubSubNumSub(Channel)[Channel] == 2 }, time.Second, 10*time.Millisecond)
+	streams := secondBus.Subscribe()
+
+	at := time.Unix(1744624800, 0)
+	first.Publish(Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.9}, Timestamp: at})
+	<-streams.Updates()
+	// The second replica never published USD, but knows what its streams last got.
+	second.Publish(Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.91}, Timestamp: at.Add(time.Hour)})
+	update := <-streams.Updates()
+	assert.Equal(t, []domain.Currency{"EUR"}, update.Changed)
+	assert.True(t, update.HasChanged("EUR"))
+	assert.False(t, update.HasChanged("INR"))
+}
+
+func TestRedisRelay_DeliversLocallyWithoutRedis(t *testing.T) {
+	mini := miniredis.RunT(t)
+	bus := NewBus(1)
+	relay := NewRedisRelay(redis.NewClient(&redis.Options{Addr: mini.Addr()}), bus, discardLogger)
+	s := bus.Subscribe()
+	mini.Close()
+
+	relay.Publish(update("USD", 85))
+	assert.Equal(t, update("USD", 85), <-s.Updates())
+}
@@ -0,0 +1,143 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const dayLayout = "2006-01-02"
+
+// Hit is one request to count.
+type Hit struct {
+	KeyID    string
+	Endpoint string // e.g. "convert"
+	Pair     string // e.g. "USD/INR", empty when the request named no valid pair
+}
+
+// Entry is how many requests a key made to an endpoint for a currency pair on a day (UTC).
+type Entry struct {
+	Day      string `json:"day"`
+	KeyID    string `json:"keyId"`
+	Endpoint string `json:"endpoint"`
+	Pair     string `json:"pair"`
+	Count    int64  `json:"count"`
+}
+
+// RedisRecorder aggregates request counts per key, endpoint, currency pair and day in Redis,
+// shared by every replica. Each day of a key is one hash, kept for retention after the day
+// ends.
+type RedisRecorder struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+func NewRedisRecorder(client *redis.Client, retention time.Duration) *RedisRecorder {
+	return &RedisRecorder{client: client, retention: retention}
+}
+
+func dayKey(day, keyID string) string {
+	return "usage:" + day + ":" + keyID
+}
+
+// keysKey holds the IDs of the keys with usage on day, so a day can be read without SCAN.
+func keysKey(day string) string {
+	return "usage_keys:" + day
+}
+
+func field(endpoint, pair string) string {
+	return endpoint + " " + pair
+}
+
+// Record counts hit on the day of now.
+func (r *RedisRecorder) Record(ctx context.Context, hit Hit, now time.Time) error {
+	now = now.UTC()
+	day := now.Format(dayLayout)
+	expireAt := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Add(r.retention)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, dayKey(day, hit.KeyID), field(hit.Endpoint, hit.Pair), 1)
+		pipe.ExpireAt(ctx, dayKey(day, hit.KeyID), expireAt)
+		pipe.SAdd(ctx, keysKey(day), hit.KeyID)
+		pipe.ExpireAt(ctx, keysKey(day), expireAt)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// Breakdown returns the entries of the days from through to, for keyID or, when it is empty,
+// every key. Entries are sorted by day, key, endpoint and pair.
+func (r *RedisRecorder) Breakdown(ctx context.Context, keyID string, from, to time.Time) ([]Entry, error) {
+	var days []string
+	for d := from.UTC(); !d.After(to.UTC()); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(dayLayout))
+	}
+
+	keyIDs := make(map[string][]string, len(days))
+	if keyID != "" {
+		for _, day := range days {
+			keyIDs[day] = []string{keyID}
+		}
+	} else {
+		members := make(map[string]*redis.StringSliceCmd, len(days))
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, day := range days {
+				members[day] = pipe.SMembers(ctx, keysKey(day))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys with usage: %w", err)
+		}
+		for day, cmd := range members {
+			keyIDs[day] = cmd.Val()
+		}
+	}
+
+	type dayOfKey struct{ day, keyID string }
+	counts := make(map[dayOfKey]*redis.MapStringStringCmd)
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for day, ids := range keyIDs {
+			for _, id := range ids {
+				counts[dayOfKey{day, id}] = pipe.HGetAll(ctx, dayKey(day, id))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	entries := []Entry{}
+	for k, cmd := range counts {
+		for f, value := range cmd.Val() {
+			endpoint, pair, _ := strings.Cut(f, " ")
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, Entry{Day: k.day, KeyID: k.keyID, Endpoint: endpoint, Pair: pair, Count: count})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Pair < b.Pair
+	})
+	return entries, nil
+}
@@ -0,0 +1,69 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRecorder(t *testing.T) (*RedisRecorder, *miniredis.Miniredis) {
+	mini, err := miniredis.Run()
+	assert.NoError(t, err)
+	t.Cleanup(mini.Close)
+	return NewRedisRecorder(redis.NewClient(&redis.Options{Addr: mini.Addr()}), 31*24*time.Hour), mini
+}
+
+func TestRedisRecorder(t *testing.T) {
+	recorder, _ := setupRecorder(t)
+	ctx := context.Background()
+	// Entries expire relative to the real clock, so the days are recent ones.
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+
+	for _, hit := range []struct {
+		hit Hit
+		at  time.Time
+	}{
+		{Hit{KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR"}, yesterday},
+		{Hit{KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR"}, today},
+		{Hit{KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR"}, today},
+		{Hit{KeyID: "partner-a", Endpoint: "latest", Pair: "EUR/GBP"}, today},
+		{Hit{KeyID: "partner-b", Endpoint: "historical", Pair: ""}, today},
+	} {
+		assert.NoError(t, recorder.Record(ctx, hit.hit, hit.at))
+	}
+
+	d0, d1 := yesterday.Format(dayLayout), today.Format(dayLayout)
+	entries, err := recorder.Breakdown(ctx, "", yesterday, today)
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Day: d0, KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR", Count: 1},
+		{Day: d1, KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR", Count: 2},
+		{Day: d1, KeyID: "partner-a", Endpoint: "latest", Pair: "EUR/GBP", Count: 1},
+		{Day: d1, KeyID: "partner-b", Endpoint: "historical", Pair: "", Count: 1},
+	}, entries)
+
+	entries, err = recorder.Breakdown(ctx, "partner-b", yesterday, today)
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{{Day: d1, KeyID: "partner-b", Endpoint: "historical", Pair: "", Count: 1}}, entries)
+
+	entries, err = recorder.Breakdown(ctx, "partner-c", yesterday, today)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRedisRecorder_Expiry(t *testing.T) {
+	recorder, mini := setupRecorder(t)
+	ctx := context.Background()
+	today := time.Now().UTC()
+	assert.NoError(t, recorder.Record(ctx, Hit{KeyID: "partner-a", Endpoint: "latest", Pair: "USD/INR"}, today))
+
+	mini.FastForward(33 * 24 * time.Hour)
+	entries, err := recorder.Breakdown(ctx, "", today, today)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,452 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	IDHeader        = "X-Webhook-ID"
+)
+
+// deliveriesKey is a sorted set of pending deliveries, scored by when they are due in Unix
+// milliseconds. Replicas claim a delivery by removing it, so each is attempted once.
+const deliveriesKey = "webhook_deliveries"
+
+const (
+	// pollInterval is how often due deliveries are looked for.
+	pollInterval = time.Second
+	// pollBatch caps the deliveries attempted per poll.
+	pollBatch = 50
+	// maxBackoff caps the wait between attempts, however many there were.
+	maxBackoff = time.Hour
+	// queueSize is how many updates can wait to be evaluated before new ones are dropped.
+	queueSize = 64
+	// maxErrorBody caps how much of a failed response is kept as the webhook's last error.
+	maxErrorBody = 256
+)
+
+// Event is the JSON body POSTed when a webhook fires.
+type Event struct {
+	ID        string          `json:"id"`
+	WebhookID string          `json:"webhookId"`
+	Base      domain.Currency `json:"base"`
+	Target    domain.Currency `json:"target"`
+	Condition string          `json:"condition"`
+	Threshold float64         `json:"threshold"`
+	Rate      float64         `json:"rate"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// delivery is an Event waiting in deliveriesKey to be POSTed.
+type delivery struct {
+	Event   Event `json:"event"`
+	Attempt int   `json:"attempt"`
+}
+
+// Options tunes deliveries.
+type Options struct {
+	// MaxAttempts is how many times a delivery is tried before it is given up on.
+	MaxAttempts int
+	// RetryBackoff is the wait after the first failed attempt, doubled after each further one.
+	RetryBackoff time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// Workers caps the deliveries attempted at once, so a slow receiver holds up at most one
+	// of them.
+	Workers int
+	// AllowPrivateTargets lets webhooks reach loopback, private and link-local addresses,
+	// which are otherwise refused so clients can't probe the service's own network.
+	AllowPrivateTargets bool
+}
+
+// OwnerKeys looks up the API keys webhooks belong to.
+type OwnerKeys interface {
+	Get(ctx context.Context, id string) (apikey.Key, error)
+}
+
+// Dispatcher evaluates webhooks against every refresh it is told about and delivers the events
+// that fire. Only the replica that ran a refresh publishes it, so each is evaluated once.
+type Dispatcher struct {
+	store      *RedisStore
+	keys       OwnerKeys
+	httpClient *http.Client
+	opts       Options
+	logger     *slog.Logger
+	queue      *updates.Queue
+	now        func() time.Time
+}
+
+// NewDispatcher stops delivering the webhooks of API keys that keys reports revoked or
+// expired. keys may be nil.
+func NewDispatcher(store *RedisStore, keys OwnerKeys, opts Options, logger *slog.Logger) *Dispatcher {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	return &Dispatcher{
+		store:      store,
+		keys:       keys,
+		httpClient: newHTTPClient(opts),
+		opts:       opts,
+		logger:     logger,
+		queue:      updates.NewQueue("webhooks", queueSize, logger),
+		now:        time.Now,
+	}
+}
+
+// newHTTPClient doesn't follow redirects, which could lead a delivery somewhere its URL didn't,
+// and unless opts allow it refuses to connect to private addresses, whatever the URL's host
+// resolves to.
+func newHTTPClient(opts Options) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateTargets {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicPrefixes are the IANA special-purpose ranges that aren't globally reachable, plus
+// multicast and the reserved space, none of which a webhook should be delivered to.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space (CGNAT)
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, including cloud metadata endpoints
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // deprecated 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and the limited broadcast address
+	netip.MustParsePrefix("::/128"),          // unspecified
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which may translate to private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, including Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, which may embed private IPv4
+	netip.MustParsePrefix("3fff::/20"),       // documentation
+	netip.MustParsePrefix("5f00::/16"),       // segment routing SIDs
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// isPublic reports whether addr is outside every non-public range. IPv4-mapped IPv6
+// addresses are checked as the IPv4 address they carry.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if addr, err := netip.ParseAddr(host); err != nil || !isPublic(addr) {
+		return fmt.Errorf("webhook target %s is not a public address", host)
+	}
+	return nil
+}
+
+// Publish queues update for Start to check the webhooks on its base against.
+func (d *Dispatcher) Publish(update updates.Update) {
+	d.queue.Publish(update)
+}
+
+// Start evaluates queued updates and delivers due events until ctx is cancelled. Deliveries
+// run apart from evaluation, so slow receivers don't delay the next refresh's events.
+func (d *Dispatcher) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.deliverLoop(ctx)
+	}()
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-d.queue.Updates():
+			if err := d.evaluate(ctx, update); err != nil {
+				d.logger.Error("Failed to evaluate webhooks", "base", update.Base, "error", err)
+			}
+		}
+	}
+}
+
+// deliverLoop polls for due deliveries until ctx is cancelled.
+func (d *Dispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.deliverDue(ctx); err != nil {
+				d.logger.Error("Failed to deliver webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// evaluate checks every webhook on update's base and schedules an event for each that fires.
+func (d *Dispatcher) evaluate(ctx context.Context, update updates.Update) error {
+	records, err := d.store.loadAll(ctx, baseKey(update.Base))
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	active := make(map[string]bool)
+	for _, rec := range records {
+		rate, ok := update.Rates[rec.Target]
+		if !ok || rate == rec.LastRate {
+			continue
+		}
+		if _, known := active[rec.Owner]; !known {
+			if active[rec.Owner], err = d.ownerActive(ctx, rec.Owner); err != nil {
+				return err
+			}
+		}
+		if !active[rec.Owner] {
+			continue
+		}
+		fired := rec.check(rate)
+		if fired {
+			firedAt := now.UTC()
+			rec.LastFiredAt = &firedAt
+			event := Event{
+				WebhookID: rec.ID,
+				Base:      rec.Base,
+				Target:    rec.Target,
+				Condition: rec.Condition,
+				Threshold: rec.Threshold,
+				Rate:      rate,
+				Timestamp: update.Timestamp.Unix(),
+			}
+			if event.ID, err = newID(); err != nil {
+				return err
+			}
+			if err := d.schedule(ctx, delivery{Event: event, Attempt: 1}, now); err != nil {
+				return err
+			}
+		}
+		if err := d.store.save(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) schedule(ctx context.Context, del delivery, due time.Time) error {
+	data, err := json.Marshal(del)
+	if err != nil {
+		return err
+	}
+	if err := d.store.client.ZAdd(ctx, deliveriesKey, redis.Z{Score: float64(due.UnixMilli()), Member: data}).Err(); err != nil {
+		return fmt.Errorf("failed to schedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// deliverDue attempts the deliveries that are due, up to opts.Workers at once, rescheduling the
+// ones that fail. It returns once every delivery it claimed has been attempted.
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	now := d.now()
+	due, err := d.store.client.ZRangeByScore(ctx, deliveriesKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: pollBatch,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read due webhook deliveries: %w", err)
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	workers := make(chan struct{}, d.opts.Workers)
+	for _, member := range due {
+		claimed, err := d.store.client.ZRem(ctx, deliveriesKey, member).Result()
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+		if claimed == 0 {
+			continue // another replica got it first
+		}
+		var del delivery
+		if err := json.Unmarshal([]byte(member), &del); err != nil {
+			d.logger.Warn("Dropping malformed webhook delivery", "error", err)
+			continue
+		}
+		workers <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			d.attempt(ctx, del)
+		}()
+	}
+	return nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, del delivery) {
+	logger := d.logger.With("webhook", del.Event.WebhookID, "event", del.Event.ID, "attempt", del.Attempt)
+	rec, err := d.store.load(ctx, del.Event.WebhookID)
+	if errors.Is(err, ErrNotFound) {
+		return // deleted since it fired
+	}
+	if err != nil {
+		logger.Error("Failed to load webhook, retrying later", "error", err)
+		d.retry(ctx, del, logger)
+		return
+	}
+	active, err := d.ownerActive(ctx, rec.Owner)
+	if err != nil {
+		logger.Error("Failed to check webhook owner, retrying later", "error", err)
+		d.retry(ctx, del, logger)
+		return
+	}
+	if !active {
+		logger.Info("Dropping delivery of a webhook whose API key is no longer active")
+		return
+	}
+
+	deliveryErr := d.post(ctx, rec, del.Event)
+	if deliveryErr == nil {
+		logger.Debug("Delivered webhook")
+		if rec.LastError != "" {
+			rec.LastError = ""
+			if err := d.store.save(ctx, rec); err != nil {
+				logger.Error("Failed to clear webhook error", "error", err)
+			}
+		}
+		return
+	}
+	if del.Attempt < d.opts.MaxAttempts {
+		logger.Info("Webhook delivery failed, retrying later", "error", deliveryErr)
+		d.retry(ctx, del, logger)
+		return
+	}
+	logger.Warn("Giving up on webhook delivery", "error", deliveryErr)
+	rec.LastError = fmt.Sprintf("delivery of %s failed after %d attempts: %v", del.Event.ID, del.Attempt, deliveryErr)
+	if err := d.store.save(ctx, rec); err != nil {
+		logger.Error("Failed to record webhook error", "error", err)
+	}
+}
+
+// ownerActive reports whether webhooks owned by owner may still fire. Owners that are API keys
+// must be neither revoked nor expired; other owners, such as JWT subjects, always may.
+func (d *Dispatcher) ownerActive(ctx context.Context, owner string) (bool, error) {
+	if d.keys == nil {
+		return true, nil
+	}
+	key, err := d.keys.Get(ctx, owner)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up webhook owner: %w", err)
+	}
+	return key.Active(d.now()), nil
+}
+
+// retry schedules del's next attempt, backing off exponentially.
+func (d *Dispatcher) retry(ctx context.Context, del delivery, logger *slog.Logger) {
+	backoff := d.opts.RetryBackoff << (del.Attempt - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	del.Attempt++
+	if err := d.schedule(ctx, del, d.now().Add(backoff)); err != nil {
+		logger.Error("Failed to reschedule webhook delivery, dropping it", "error", err)
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, rec record, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rec.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "currency-exchange-webhooks")
+	req.Header.Set(IDHeader, event.ID)
+	req.Header.Set(SignatureHeader, Sign(rec.Secret, d.now(), body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Sign returns the signature header of a delivery of body at time at: "t=<unix seconds>,
+// v1=<hex HMAC-SHA256 of "<unix seconds>.<body>" keyed by secret>". Receivers recompute it to
+// check the delivery came from this service, and reject old timestamps to stop replays.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
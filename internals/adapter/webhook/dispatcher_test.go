@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestStore(t *testing.T, maxOwned int) *RedisStore {
+	t.Helper()
+	mini := miniredis.RunT(t)
+	return NewRedisStore(redis.NewClient(&redis.Options{Addr: mini.Addr()}), maxOwned)
+}
+
+func usdInr(rate float64, at time.Time) updates.Update {
+	return updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": rate}, Timestamp: at}
+}
+
+func TestRecord_Check(t *testing.T) {
+	above := record{Webhook: Webhook{Condition: ConditionAbove, Threshold: 86}}
+	var fired []bool
+	for _, rate := range []float64{85, 86.5, 87, 85.5, 86.1} {
+		fired = append(fired, above.check(rate))
+	}
+	assert.Equal(t, []bool{false, true, false, false, true}, fired)
+
+	below := record{Webhook: Webhook{Condition: ConditionBelow, Threshold: 85}}
+	fired = nil
+	for _, rate := range []float64{84, 84.5, 85, 84.9} {
+		fired = append(fired, below.check(rate))
+	}
+	assert.Equal(t, []bool{true, false, false, true}, fired)
+
+	change := record{Webhook: Webhook{Condition: ConditionChange, Threshold: 1}}
+	fired = nil
+	for _, rate := range []float64{100, 100.5, 101, 101.5, 102.1} {
+		fired = append(fired, change.check(rate))
+	}
+	assert.Equal(t, []bool{false, false, true, false, true}, fired)
+}
+
+func TestRedisStore_CreateListDelete(t *testing.T) {
+	store := newTestStore(t, 1)
+	ctx := context.Background()
+	newWebhook := NewWebhook{Owner: "partner-a", URL: "https://example.com/hook", Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionAbove, Threshold: 86}
+
+	created, err := store.Create(ctx, newWebhook)
+	assert.NoError(t, err)
+	assert.Equal(t, "partner-a", created.Owner)
+	_, err = store.Create(ctx, newWebhook)
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []Webhook{created}, listed)
+	data, err := json.Marshal(listed[0])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), newWebhook.Secret)
+
+	assert.ErrorIs(t, store.Delete(ctx, "partner-b", created.ID), ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "partner-a", created.ID))
+	listed, err = store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func TestRedisStore_CreateLimitUnderConcurrency(t *testing.T) {
+	store := newTestStore(t, 3)
+	ctx := context.Background()
+	newWebhook := NewWebhook{Owner: "partner-a", URL: "https://example.com/hook", Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionAbove, Threshold: 86}
+
+	var wg sync.WaitGroup
+	var created, refused atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Create(ctx, newWebhook)
+			if errors.Is(err, ErrTooMany) {
+				refused.Add(1)
+			} else if assert.NoError(t, err) {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), created.Load())
+	assert.Equal(t, int32(7), refused.Load())
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Len(t, listed, 3)
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	var received atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received.Store(r.Header.Get(SignatureHeader) + "\n" + string(body))
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, 5)
+	ctx := context.Background()
+	created, err := store.Create(ctx, NewWebhook{Owner: "partner-a", URL: server.URL, Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionAbove, Threshold: 86})
+	assert.NoError(t, err)
+	d := NewDispatcher(store, nil, Options{MaxAttempts: 3, RetryBackoff: time.Minute, Timeout: time.Second, AllowPrivateTargets: true}, discardLogger)
+	now := time.Unix(1744624800, 0)
+	d.now = func() time.Time { return now }
+
+	assert.NoError(t, d.evaluate(ctx, usdInr(85, now)))
+	assert.NoError(t, d.evaluate(ctx, usdInr(86.5, now)))
+	assert.NoError(t, d.deliverDue(ctx))
+
+	signature, body, _ := strings.Cut(received.Load().(string), "\n")
+	assert.Equal(t, Sign("0123456789abcdef", now, []byte(body)), signature)
+	assert.True(t, strings.HasPrefix(signature, "t="+strconv.FormatInt(now.Unix(), 10)+",v1="))
+	var event Event
+	assert.NoError(t, json.Unmarshal([]byte(body), &event))
+	assert.Equal(t, created.ID, event.WebhookID)
+	assert.Equal(t, 86.5, event.Rate)
+
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 86.5, listed[0].LastRate)
+	assert.Equal(t, now.UTC(), *listed[0].LastFiredAt)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, 5)
+	ctx := context.Background()
+	_, err := store.Create(ctx, NewWebhook{Owner: "partner-a", URL: server.URL, Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionBelow, Threshold: 86})
+	assert.NoError(t, err)
+	d := NewDispatcher(store, nil, Options{MaxAttempts: 3, RetryBackoff: time.Minute, Timeout: time.Second, AllowPrivateTargets: true}, discardLogger)
+	now := time.Unix(1744624800, 0)
+	d.now = func() time.Time { return now }
+	assert.NoError(t, d.evaluate(ctx, usdInr(85, now)))
+
+	// Attempted at once, then after 1 and 2 more minutes.
+	for _, wait := range []time.Duration{0, time.Minute, 2 * time.Minute} {
+		now = now.Add(wait - time.Second)
+		assert.NoError(t, d.deliverDue(ctx))
+		now = now.Add(time.Second)
+		assert.NoError(t, d.deliverDue(ctx))
+	}
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Then given up on.
+	now = now.Add(maxBackoff)
+	assert.NoError(t, d.deliverDue(ctx))
+	assert.Equal(t, int32(3), calls.Load())
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Contains(t, listed[0].LastError, "after 3 attempts: status 503: down for maintenance")
+}
+
+func TestDispatcher_DeliversConcurrently(t *testing.T) {
+	// Every request waits until both have arrived, so this only finishes when the two
+	// deliveries run at once.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		arrived.Wait()
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, 5)
+	ctx := context.Background()
+	for _, threshold := range []float64{86, 87} {
+		_, err := store.Create(ctx, NewWebhook{Owner: "partner-a", URL: server.URL, Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionBelow, Threshold: threshold})
+		assert.NoError(t, err)
+	}
+	d := NewDispatcher(store, nil, Options{MaxAttempts: 1, RetryBackoff: time.Minute, Timeout: 5 * time.Second, Workers: 2, AllowPrivateTargets: true}, discardLogger)
+	now := time.Unix(1744624800, 0)
+	d.now = func() time.Time { return now }
+	assert.NoError(t, d.evaluate(ctx, usdInr(85, now)))
+
+	start := time.Now()
+	assert.NoError(t, d.deliverDue(ctx))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	for _, webhook := range listed {
+		assert.Empty(t, webhook.LastError)
+	}
+}
+
+// ownerKeys maps key IDs to keys; other IDs are not found.
+type ownerKeys map[string]apikey.Key
+
+func (k ownerKeys) Get(ctx context.Context, id string) (apikey.Key, error) {
+	key, ok := k[id]
+	if !ok {
+		return apikey.Key{}, apikey.ErrNotFound
+	}
+	return key, nil
+}
+
+func TestDispatcher_SkipsInactiveOwners(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	now := time.Unix(1744624800, 0)
+	keys := ownerKeys{"partner-a": {ID: "partner-a"}}
+	store := newTestStore(t, 5)
+	ctx := context.Background()
+	for _, owner := range []string{"partner-a", "jwt:dashboard"} {
+		_, err := store.Create(ctx, NewWebhook{Owner: owner, URL: server.URL, Secret: "0123456789abcdef", Base: "USD", Target: "INR", Condition: ConditionBelow, Threshold: 86})
+		assert.NoError(t, err)
+	}
+	d := NewDispatcher(store, keys, Options{MaxAttempts: 1, RetryBackoff: time.Minute, Timeout: time.Second, AllowPrivateTargets: true}, discardLogger)
+	d.now = func() time.Time { return now }
+
+	// Revoked after its webhook fired: the pending delivery is dropped.
+	assert.NoError(t, d.evaluate(ctx, usdInr(85, now)))
+	revokedAt := now
+	keys["partner-a"] = apikey.Key{ID: "partner-a", RevokedAt: &revokedAt}
+	assert.NoError(t, d.deliverDue(ctx))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// And its webhook no longer fires; the JWT caller's, which isn't a key, still does.
+	assert.NoError(t, d.evaluate(ctx, usdInr(87, now)))
+	assert.NoError(t, d.evaluate(ctx, usdInr(85.5, now)))
+	assert.NoError(t, d.deliverDue(ctx))
+	assert.Equal(t, int32(2), calls.Load())
+	listed, err := store.List(ctx, "partner-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 85.0, listed[0].LastRate)
+}
+
+func TestDispatcher_RefusesPrivateTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivered to a loopback address")
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(newTestStore(t, 5), nil, Options{MaxAttempts: 1, RetryBackoff: time.Minute, Timeout: time.Second}, discardLogger)
+	err := d.post(context.Background(), record{Webhook: Webhook{URL: server.URL}}, Event{})
+	assert.ErrorContains(t, err, "is not a public address")
+}
+
+func TestRefusePrivate(t *testing.T) {
+	for _, host := range []string{
+		"10.1.2.3", "100.64.0.1", "100.127.255.254", "127.0.0.1", "169.254.169.254", "172.16.0.1",
+		"192.0.0.8", "192.168.1.1", "198.18.0.1", "198.19.255.255", "203.0.113.7", "0.0.0.0",
+		"255.255.255.255", "224.0.0.1", "::1", "::", "::ffff:10.0.0.1", "64:ff9b::a00:1",
+		"2002:a00:1::1", "fd00::1", "fe80::1%eth0", "ff02::1",
+	} {
+		assert.Error(t, refusePrivate("tcp", net.JoinHostPort(host, "443"), nil), host)
+	}
+	for _, host := range []string{"8.8.8.8", "100.128.0.1", "198.20.0.1", "2606:4700:4700::1111", "::ffff:1.1.1.1"} {
+		assert.NoError(t, refusePrivate("tcp", net.JoinHostPort(host, "443"), nil), host)
+	}
+}
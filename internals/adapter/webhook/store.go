@@ -0,0 +1,293 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Conditions a webhook can fire on.
+const (
+	ConditionAbove  = "above"  // the rate rose above Threshold
+	ConditionBelow  = "below"  // the rate fell below Threshold
+	ConditionChange = "change" // the rate moved by Threshold percent since the webhook last fired
+)
+
+// minSecretLength keeps secrets long enough that signatures can't be brute-forced.
+const minSecretLength = 16
+
+var (
+	ErrNotFound = errors.New("webhook not found")
+	ErrTooMany  = errors.New("too many webhooks")
+)
+
+// Webhook is a URL told about a pair's rate whenever its condition fires. The secret it
+// signs deliveries with is never returned.
+type Webhook struct {
+	ID        string          `json:"id"`
+	Owner     string          `json:"-"` // ID of the API key that registered it
+	URL       string          `json:"url"`
+	Base      domain.Currency `json:"base"`
+	Target    domain.Currency `json:"target"`
+	Condition string          `json:"condition"`
+	Threshold float64         `json:"threshold"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// LastRate is the rate the condition was last checked against.
+	LastRate    float64    `json:"lastRate,omitempty"`
+	LastFiredAt *time.Time `json:"lastFiredAt,omitempty"`
+	// LastError is why the last delivery was given up on, cleared by the next one to succeed.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// NewWebhook holds what a client chooses when registering a webhook.
+type NewWebhook struct {
+	Owner     string
+	URL       string
+	Secret    string
+	Base      domain.Currency
+	Target    domain.Currency
+	Condition string
+	Threshold float64
+}
+
+// Validate checks the URL, secret, condition and threshold. The pair is the caller's to check.
+func (n NewWebhook) Validate() error {
+	u, err := url.Parse(n.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if len(n.Secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+	switch n.Condition {
+	case ConditionAbove, ConditionBelow:
+		if !(n.Threshold > 0) || math.IsInf(n.Threshold, 0) {
+			return errors.New("threshold must be a rate greater than 0")
+		}
+	case ConditionChange:
+		if !(n.Threshold > 0) || math.IsInf(n.Threshold, 0) {
+			return errors.New("threshold must be a percentage greater than 0")
+		}
+	default:
+		return fmt.Errorf("unknown condition %q, expected above, below or change", n.Condition)
+	}
+	return nil
+}
+
+// record is what is stored per webhook: the Webhook, its secret and what the change condition
+// is measured from.
+type record struct {
+	Webhook
+	Owner    string  `json:"owner"`
+	Secret   string  `json:"secret"`
+	Baseline float64 `json:"baseline,omitempty"`
+}
+
+// check records rate as the latest one and reports whether it fires the webhook's condition:
+// above and below fire when the rate crosses the threshold, or on the first check when it is
+// already past it, but not again for as long as it stays there.
+func (r *record) check(rate float64) bool {
+	last := r.LastRate
+	r.LastRate = rate
+	switch r.Condition {
+	case ConditionAbove:
+		return rate > r.Threshold && !(last > r.Threshold)
+	case ConditionBelow:
+		return rate < r.Threshold && (last == 0 || last >= r.Threshold)
+	case ConditionChange:
+		if r.Baseline == 0 {
+			r.Baseline = rate
+			return false
+		}
+		if math.Abs(rate-r.Baseline)/r.Baseline*100 < r.Threshold {
+			return false
+		}
+		r.Baseline = rate
+		return true
+	}
+	return false
+}
+
+// RedisStore keeps webhooks in Redis, shared by every replica.
+type RedisStore struct {
+	client   *redis.Client
+	maxOwned int
+	now      func() time.Time
+}
+
+// NewRedisStore lets every API key register up to maxOwned webhooks.
+func NewRedisStore(client *redis.Client, maxOwned int) *RedisStore {
+	return &RedisStore{client: client, maxOwned: maxOwned, now: time.Now}
+}
+
+func webhookKey(id string) string {
+	return "webhook:" + id
+}
+
+func ownerKey(owner string) string {
+	return "webhooks_owner:" + owner
+}
+
+func baseKey(base domain.Currency) string {
+	return "webhooks_base:" + string(base)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook id: %w", err)
+	}
+	return "wh-" + hex.EncodeToString(b), nil
+}
+
+// createScript stores a webhook unless its owner already has ARGV[3] of them, checking and
+// adding in one step so concurrent creates can't overshoot the limit. It returns 0 when the
+// owner is at the limit.
+const createScript = `
+if redis.call("SCARD", KEYS[2]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2])
+redis.call("SADD", KEYS[2], ARGV[1])
+redis.call("SADD", KEYS[3], ARGV[1])
+return 1
+`
+
+// Create registers a webhook, unless its owner already has maxOwned of them.
+func (s *RedisStore) Create(ctx context.Context, newWebhook NewWebhook) (Webhook, error) {
+	if err := newWebhook.Validate(); err != nil {
+		return Webhook{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	rec := record{
+		Webhook: Webhook{
+			ID:        id,
+			URL:       newWebhook.URL,
+			Base:      newWebhook.Base,
+			Target:    newWebhook.Target,
+			Condition: newWebhook.Condition,
+			Threshold: newWebhook.Threshold,
+			CreatedAt: s.now().UTC(),
+		},
+		Owner:  newWebhook.Owner,
+		Secret: newWebhook.Secret,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return Webhook{}, err
+	}
+	keys := []string{webhookKey(id), ownerKey(rec.Owner), baseKey(rec.Base)}
+	created, err := s.client.Eval(ctx, createScript, keys, id, data, s.maxOwned).Int()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to store webhook: %w", err)
+	}
+	if created == 0 {
+		return Webhook{}, ErrTooMany
+	}
+	return rec.public(), nil
+}
+
+// List returns owner's webhooks, oldest first.
+func (s *RedisStore) List(ctx context.Context, owner string) ([]Webhook, error) {
+	records, err := s.loadAll(ctx, ownerKey(owner))
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]Webhook, 0, len(records))
+	for _, rec := range records {
+		webhooks = append(webhooks, rec.public())
+	}
+	return webhooks, nil
+}
+
+// Delete removes owner's webhook id. Other owners' webhooks are reported as not found.
+func (s *RedisStore) Delete(ctx context.Context, owner, id string) error {
+	rec, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rec.Owner != owner {
+		return ErrNotFound
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, webhookKey(id))
+		pipe.SRem(ctx, ownerKey(owner), id)
+		pipe.SRem(ctx, baseKey(rec.Base), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// public returns the record's Webhook with its owner set.
+func (r record) public() Webhook {
+	w := r.Webhook
+	w.Owner = r.Owner
+	return w
+}
+
+func (s *RedisStore) load(ctx context.Context, id string) (record, error) {
+	data, err := s.client.Get(ctx, webhookKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return record{}, ErrNotFound
+	}
+	if err != nil {
+		return record{}, fmt.Errorf("failed to read webhook: %w", err)
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return record{}, fmt.Errorf("invalid webhook %s: %w", id, err)
+	}
+	return rec, nil
+}
+
+// loadAll loads the webhooks whose IDs are in the set at key, oldest first, skipping any
+// deleted meanwhile.
+func (s *RedisStore) loadAll(ctx context.Context, key string) ([]record, error) {
+	ids, err := s.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	records := make([]record, 0, len(ids))
+	for _, id := range ids {
+		rec, err := s.load(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	return records, nil
+}
+
+// save writes back the state of rec, unless it was deleted meanwhile.
+func (s *RedisStore) save(ctx context.Context, rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := s.client.SetXX(ctx, webhookKey(rec.ID), data, redis.KeepTTL).Err(); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+
+	"currency-exchange/internals/adapter/cache/schedular"
+	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/logging"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProviderStatusReporter exposes the rolling call statistics of the rate providers.
+type ProviderStatusReporter interface {
+	Statuses() []exchangerateapi.ProviderStatus
+}
+
+// SchedulerStatusReporter exposes what the background refresh loops have been doing.
+type SchedulerStatusReporter interface {
+	Statuses() []schedular.LoopStatus
+}
+
+// SchedulerControl pauses and resumes the background refreshes of every replica.
+type SchedulerControl interface {
+	Pause(ctx context.Context, reason string) error
+	Resume(ctx context.Context) error
+	State(ctx context.Context) (schedular.PauseState, error)
+}
+
+// AdminHandler serves operational endpoints under /admin.
+type AdminHandler struct {
+	providers ProviderStatusReporter
+	scheduler SchedulerStatusReporter
+	control   SchedulerControl
+	logger    *slog.Logger
+}
+
+func NewAdminHandler(providers ProviderStatusReporter, scheduler SchedulerStatusReporter, control SchedulerControl, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{providers: providers, scheduler: scheduler, control: control, logger: logger}
+}
+
+func (h *AdminHandler) GetProviders(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"providers": h.providers.Statuses()})
+}
+
+func (h *AdminHandler) GetScheduler(c *fiber.Ctx) error {
+	body := fiber.Map{"loops": h.scheduler.Statuses()}
+	if state, err := h.control.State(c.UserContext()); err == nil {
+		body["pause"] = state
+	} else {
+		logging.WithRequest(c.UserContext(), h.logger).Warn("Could not read scheduler pause state", "error", err)
+	}
+	return c.JSON(body)
+}
+
+type pauseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PauseScheduler stops background refreshes on all replicas until ResumeScheduler is called.
+// The body is optional; {"reason": "..."} is recorded alongside the pause.
+func (h *AdminHandler) PauseScheduler(c *fiber.Ctx) error {
+	var req pauseRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"reason\": \"...\"}")
+		}
+	}
+
+	setAuditParam(c, "reason", req.Reason)
+	if err := h.control.Pause(c.UserContext(), req.Reason); err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Background refreshes paused via admin API", "reason", req.Reason)
+	return h.pauseState(c)
+}
+
+func (h *AdminHandler) ResumeScheduler(c *fiber.Ctx) error {
+	if err := h.control.Resume(c.UserContext()); err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Background refreshes resumed via admin API")
+	return h.pauseState(c)
+}
+
+func (h *AdminHandler) pauseState(c *fiber.Ctx) error {
+	state, err := h.control.State(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/cache/schedular"
+	"currency-exchange/internals/adapter/exchangerateapi"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProviderStatusReporter struct {
+	statuses []exchangerateapi.ProviderStatus
+}
+
+func (m *mockProviderStatusReporter) Statuses() []exchangerateapi.ProviderStatus {
+	return m.statuses
+}
+
+type mockSchedulerStatusReporter struct {
+	statuses []schedular.LoopStatus
+}
+
+func (m *mockSchedulerStatusReporter) Statuses() []schedular.LoopStatus {
+	return m.statuses
+}
+
+type mockSchedulerControl struct {
+	state    schedular.PauseState
+	pauseErr error
+	stateErr error
+}
+
+func (m *mockSchedulerControl) Pause(ctx context.Context, reason string) error {
+	if m.pauseErr != nil {
+		return m.pauseErr
+	}
+	since := time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)
+	m.state = schedular.PauseState{Paused: true, Reason: reason, Since: &since}
+	return nil
+}
+
+func (m *mockSchedulerControl) Resume(ctx context.Context) error {
+	m.state = schedular.PauseState{}
+	return nil
+}
+
+func (m *mockSchedulerControl) State(ctx context.Context) (schedular.PauseState, error) {
+	return m.state, m.stateErr
+}
+
+func TestGetProviders(t *testing.T) {
+	reporter := &mockProviderStatusReporter{statuses: []exchangerateapi.ProviderStatus{
+		{Name: "frankfurter", Calls: 10, ErrorRate: 0.1, AvgLatencyMs: 120, LastError: "timeout"},
+	}}
+	app := fiber.New()
+	app.Get("/admin/providers", NewAdminHandler(reporter, &mockSchedulerStatusReporter{}, &mockSchedulerControl{}, discardLogger).GetProviders)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/providers", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Providers []exchangerateapi.ProviderStatus `json:"providers"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, reporter.statuses, body.Providers)
+}
+
+func TestGetScheduler(t *testing.T) {
+	lastRun := time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC)
+	reporter := &mockSchedulerStatusReporter{statuses: []schedular.LoopStatus{{
+		Name:           "Background refresh",
+		Interval:       "1h0m0s",
+		LastRunAt:      &lastRun,
+		LastDurationMs: 1500,
+		Bases:          map[string]schedular.BaseStatus{"USD": {Successes: 3, Failures: 1, LastError: "timeout"}},
+	}}}
+	app := fiber.New()
+	app.Get("/admin/scheduler", NewAdminHandler(&mockProviderStatusReporter{}, reporter, &mockSchedulerControl{}, discardLogger).GetScheduler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/scheduler", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Loops []schedular.LoopStatus `json:"loops"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, reporter.statuses, body.Loops)
+}
+
+func TestGetScheduler_IncludesPauseState(t *testing.T) {
+	control := &mockSchedulerControl{}
+	assert.NoError(t, control.Pause(context.Background(), "maintenance"))
+	app := fiber.New()
+	app.Get("/admin/scheduler", NewAdminHandler(&mockProviderStatusReporter{}, &mockSchedulerStatusReporter{}, control, discardLogger).GetScheduler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/scheduler", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Pause schedular.PauseState `json:"pause"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.True(t, body.Pause.Paused)
+	assert.Equal(t, "maintenance", body.Pause.Reason)
+}
+
+func TestPauseAndResumeScheduler(t *testing.T) {
+	control := &mockSchedulerControl{}
+	handler := NewAdminHandler(&mockProviderStatusReporter{}, &mockSchedulerStatusReporter{}, control, discardLogger)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/admin/scheduler/pause", handler.PauseScheduler)
+	app.Post("/admin/scheduler/resume", handler.ResumeScheduler)
+
+	req := httptest.NewRequest("POST", "/admin/scheduler/pause", strings.NewReader(`{"reason":"provider outage"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var state schedular.PauseState
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.True(t, state.Paused)
+	assert.Equal(t, "provider outage", state.Reason)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/admin/scheduler/resume", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.False(t, state.Paused)
+}
+
+func TestPauseScheduler_WithoutBody(t *testing.T) {
+	control := &mockSchedulerControl{}
+	app := fiber.New()
+	app.Post("/admin/scheduler/pause", NewAdminHandler(&mockProviderStatusReporter{}, &mockSchedulerStatusReporter{}, control, discardLogger).PauseScheduler)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/admin/scheduler/pause", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, control.state.Paused)
+}
+
+func TestPauseScheduler_Errors(t *testing.T) {
+	control := &mockSchedulerControl{pauseErr: errors.New("redis down")}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/admin/scheduler/pause", NewAdminHandler(&mockProviderStatusReporter{}, &mockSchedulerStatusReporter{}, control, discardLogger).PauseScheduler)
+
+	req := httptest.NewRequest("POST", "/admin/scheduler/pause", strings.NewReader(`not json`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/admin/scheduler/pause", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/logging"
+	"errors"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// maxRotationGrace bounds how long a rotated secret keeps working.
+const maxRotationGrace = 7 * 24 * time.Hour
+
+// Codes of the 403s for requests outside a key's restrictions, so clients can tell them from
+// a missing scope.
+const (
+	EndpointNotAllowedCode = "Endpoint Not Allowed"
+	PairNotAllowedCode     = "Pair Not Allowed"
+)
+
+// APIKeyStore manages the API keys clients authenticate with.
+type APIKeyStore interface {
+	Create(ctx context.Context, newKey apikey.NewKey) (apikey.Key, apikey.Secrets, error)
+	List(ctx context.Context) ([]apikey.Key, error)
+	Rotate(ctx context.Context, id string, grace time.Duration) (apikey.Key, apikey.Secrets, error)
+	Revoke(ctx context.Context, id string) (apikey.Key, error)
+	Authenticate(ctx context.Context, secret string) (apikey.Key, error)
+}
+
+// APIKeyHandler authenticates requests carrying an API key and serves key management under
+// /admin/keys, so every client gets its own key instead of sharing ADMIN_API_KEY.
+type APIKeyHandler struct {
+	store  APIKeyStore
+	logger *slog.Logger
+}
+
+func NewAPIKeyHandler(store APIKeyStore, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{store: store, logger: logger}
+}
+
+// Authenticate resolves the X-API-Key header, when present, to its key. Requests with an
+// unknown, revoked or expired key are rejected; requests without one are passed on, and the
+// routes decide whether they need a key.
+func (h *APIKeyHandler) Authenticate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret := c.Get(APIKeyHeader)
+		if secret == "" {
+			return c.Next()
+		}
+		key, err := h.store.Authenticate(c.UserContext(), secret)
+		if errors.Is(err, apikey.ErrInvalid) {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid API key")
+		}
+		if err != nil {
+			return err
+		}
+		setAuthenticated(c, Caller{ID: key.ID, Scopes: key.Grants(), Restrictions: key.Restrictions})
+		return c.Next()
+	}
+}
+
+// EnforceRestrictions rejects rate requests to an endpoint or currency pair the caller's key
+// is restricted from. Requests without a well formed pair are left for the handler to reject.
+func EnforceRestrictions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller, ok := authenticatedCaller(c)
+		if !ok {
+			return c.Next()
+		}
+		base, target, _ := strings.Cut(requestPair(c), "/")
+		if err := checkRestrictions(caller.Restrictions, path.Base(c.Path()), base, target); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// checkRestrictions rejects a request to endpoint for rates from base to target. An empty
+// base or target isn't checked.
+func checkRestrictions(r apikey.Restrictions, endpoint, base, target string) error {
+	if !r.AllowsEndpoint(endpoint) {
+		return &CodedError{
+			Status:  fiber.StatusForbidden,
+			Code:    EndpointNotAllowedCode,
+			Message: "this API key may not use /" + endpoint + ", only " + strings.Join(r.Endpoints, ", "),
+		}
+	}
+	if base != "" && target != "" && !r.AllowsPair(base, target) {
+		return &CodedError{
+			Status:  fiber.StatusForbidden,
+			Code:    PairNotAllowedCode,
+			Message: "this API key may not query " + base + "/" + target + ", only " + strings.Join(r.Pairs, ", "),
+		}
+	}
+	return nil
+}
+
+// RequireScope rejects requests whose API key or JWT lacks scope. Requests without either
+// are only rejected when required is set, so keys can be rolled out before they are enforced.
+func RequireScope(scope string, required bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller, ok := authenticatedCaller(c)
+		if !ok {
+			if required {
+				return fiber.NewError(fiber.StatusUnauthorized, "API key required")
+			}
+			return c.Next()
+		}
+		if !caller.HasScope(scope) {
+			return fiber.NewError(fiber.StatusForbidden, "credentials lack the "+scope+" scope")
+		}
+		return c.Next()
+	}
+}
+
+type createKeyRequest struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Scopes    []string   `json:"scopes"`
+	Endpoints []string   `json:"endpoints"`
+	Pairs     []string   `json:"pairs"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// keyWithSecret is returned when a key is created or rotated, the only time its secret is
+// ever shown.
+type keyWithSecret struct {
+	apikey.Key
+	Secret        string `json:"secret"`
+	SigningSecret string `json:"signingSecret,omitempty"`
+}
+
+func newKeyWithSecret(key apikey.Key, secrets apikey.Secrets) keyWithSecret {
+	return keyWithSecret{Key: key, Secret: secrets.Secret, SigningSecret: secrets.SigningSecret}
+}
+
+// Create issues a new key. The body is {"name": "...", "role": "reader"}, where "role" can be
+// replaced or extended by "scopes", with an optional "id" (used to configure per-key rate
+// limits), RFC 3339 "expiresAt", and "endpoints" and "pairs" the key is restricted to.
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	var req createKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"name\": \"...\", \"role\": \"reader\"}")
+	}
+	newKey := apikey.NewKey{ID: req.ID, Name: req.Name, Role: req.Role, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	newKey.Endpoints = req.Endpoints
+	for _, pair := range req.Pairs {
+		newKey.Pairs = append(newKey.Pairs, strings.ToUpper(pair))
+	}
+	if err := newKey.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return fiber.NewError(fiber.StatusBadRequest, "expiresAt must be in the future")
+	}
+
+	setAuditParam(c, "name", req.Name)
+	setAuditParam(c, "role", req.Role)
+	setAuditParam(c, "scopes", strings.Join(req.Scopes, ","))
+	setAuditParam(c, "endpoints", strings.Join(newKey.Endpoints, ","))
+	setAuditParam(c, "pairs", strings.Join(newKey.Pairs, ","))
+	key, secrets, err := h.store.Create(c.UserContext(), newKey)
+	if err != nil {
+		return keyStoreError(err)
+	}
+	setAuditParam(c, "id", key.ID)
+	logging.WithRequest(c.UserContext(), h.logger).Info("API key created via admin API", "key_id", key.ID, "role", key.Role, "scopes", key.Scopes)
+	return c.Status(fiber.StatusCreated).JSON(newKeyWithSecret(key, secrets))
+}
+
+// List serves every key without its secret, revoked and expired ones included.
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	keys, err := h.store.List(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"keys": keys})
+}
+
+type rotateKeyRequest struct {
+	GracePeriod string `json:"gracePeriod"`
+}
+
+// Rotate issues a new secret for a key. The optional body {"gracePeriod": "24h"} keeps the
+// old secret working for that long, at most 7 days; by default it stops working at once.
+func (h *APIKeyHandler) Rotate(c *fiber.Ctx) error {
+	var req rotateKeyRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"gracePeriod\": \"24h\"}")
+		}
+	}
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		d, err := time.ParseDuration(req.GracePeriod)
+		if err != nil || d < 0 || d > maxRotationGrace {
+			return fiber.NewError(fiber.StatusBadRequest, "gracePeriod must be a duration of at most 168h, e.g. 24h")
+		}
+		grace = d
+	}
+
+	setAuditParam(c, "gracePeriod", grace.String())
+	key, secrets, err := h.store.Rotate(c.UserContext(), c.Params("id"), grace)
+	if err != nil {
+		return keyStoreError(err)
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("API key rotated via admin API", "key_id", key.ID, "grace_period", grace)
+	return c.JSON(newKeyWithSecret(key, secrets))
+}
+
+// Revoke disables a key for good.
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	key, err := h.store.Revoke(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return keyStoreError(err)
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("API key revoked via admin API", "key_id", key.ID)
+	return c.JSON(key)
+}
+
+func keyStoreError(err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "API key not found")
+	case errors.Is(err, apikey.ErrExists):
+		return fiber.NewError(fiber.StatusConflict, "an API key with this id already exists")
+	case errors.Is(err, apikey.ErrRevoked):
+		return fiber.NewError(fiber.StatusConflict, "API key is revoked")
+	}
+	return err
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAPIKeyStore keeps keys in memory, keyed by their secret.
+type mockAPIKeyStore struct {
+	keys  map[string]apikey.Key
+	grace time.Duration
+}
+
+func newMockAPIKeyStore() *mockAPIKeyStore {
+	return &mockAPIKeyStore{keys: make(map[string]apikey.Key)}
+}
+
+func (m *mockAPIKeyStore) add(secret string, key apikey.Key) {
+	m.keys[secret] = key
+}
+
+func (m *mockAPIKeyStore) Create(ctx context.Context, newKey apikey.NewKey) (apikey.Key, apikey.Secrets, error) {
+	for _, key := range m.keys {
+		if key.ID == newKey.ID {
+			return apikey.Key{}, apikey.Secrets{}, apikey.ErrExists
+		}
+	}
+	key := apikey.Key{ID: newKey.ID, Name: newKey.Name, Role: newKey.Role, Scopes: newKey.Scopes, Prefix: "cx_new", ExpiresAt: newKey.ExpiresAt, Restrictions: newKey.Restrictions}
+	secret := "cx_new-" + newKey.ID
+	m.keys[secret] = key
+	return key, apikey.Secrets{Secret: secret, SigningSecret: "cxs_new-" + newKey.ID}, nil
+}
+
+func (m *mockAPIKeyStore) List(ctx context.Context) ([]apikey.Key, error) {
+	keys := make([]apikey.Key, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyStore) Rotate(ctx context.Context, id string, grace time.Duration) (apikey.Key, apikey.Secrets, error) {
+	for secret, key := range m.keys {
+		if key.ID == id {
+			m.grace = grace
+			delete(m.keys, secret)
+			m.keys["cx_rotated-"+id] = key
+			return key, apikey.Secrets{Secret: "cx_rotated-" + id, SigningSecret: "cxs_rotated-" + id}, nil
+		}
+	}
+	return apikey.Key{}, apikey.Secrets{}, apikey.ErrNotFound
+}
+
+func (m *mockAPIKeyStore) Revoke(ctx context.Context, id string) (apikey.Key, error) {
+	for secret, key := range m.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.RevokedAt = &now
+			m.keys[secret] = key
+			return key, nil
+		}
+	}
+	return apikey.Key{}, apikey.ErrNotFound
+}
+
+func (m *mockAPIKeyStore) Authenticate(ctx context.Context, secret string) (apikey.Key, error) {
+	key, ok := m.keys[secret]
+	if !ok || !key.Active(time.Now()) {
+		return apikey.Key{}, apikey.ErrInvalid
+	}
+	return key, nil
+}
+
+func setupAPIKeyTestApp(store *mockAPIKeyStore, required bool) *fiber.App {
+	h := NewAPIKeyHandler(store, discardLogger)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(h.Authenticate())
+	app.Get("/v1/latest", RequireScope(apikey.ScopeRatesRead, required), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"caller": callerID(c)})
+	})
+	admin := app.Group("/admin", RequireAdmin("secret"), func(c *fiber.Ctx) error {
+		err := c.Next()
+		c.Set("X-Caller", callerID(c))
+		return err
+	})
+	admin.Get("/keys", h.List)
+	admin.Post("/keys", h.Create)
+	admin.Post("/keys/:id/rotate", h.Rotate)
+	admin.Delete("/keys/:id", h.Revoke)
+	return app
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	store.add("cx_admin", apikey.Key{ID: "ops", Scopes: []string{apikey.ScopeAdmin}})
+	store.add("cx_reader_role", apikey.Key{ID: "partner-b", Role: apikey.RoleReader})
+	store.add("cx_admin_role", apikey.Key{ID: "ops-2", Role: apikey.RoleAdmin})
+
+	tests := []struct {
+		name     string
+		required bool
+		path     string
+		key      string
+		status   int
+	}{
+		{"no key while optional", false, "/v1/latest", "", 200},
+		{"no key while required", true, "/v1/latest", "", 401},
+		{"reader key", true, "/v1/latest", "cx_reader", 200},
+		{"unknown key", false, "/v1/latest", "cx_unknown", 401},
+		{"key without scope", false, "/v1/latest", "cx_admin", 403},
+		{"reader key on admin route", false, "/admin/keys", "cx_reader", 401},
+		{"admin key on admin route", false, "/admin/keys", "cx_admin", 200},
+		{"reader role", true, "/v1/latest", "cx_reader_role", 200},
+		{"reader role on admin route", false, "/admin/keys", "cx_reader_role", 401},
+		{"admin role", true, "/v1/latest", "cx_admin_role", 200},
+		{"admin role on admin route", false, "/admin/keys", "cx_admin_role", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupAPIKeyTestApp(store, tt.required)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAPIKeyAuthentication_SetsCaller(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	store.add("cx_admin", apikey.Key{ID: "ops", Scopes: []string{apikey.ScopeAdmin}})
+	app := setupAPIKeyTestApp(store, false)
+
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(APIKeyHeader, "cx_reader")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var body map[string]string
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "partner-a", body["caller"])
+
+	// Admin callers are named after their key; the shared admin key is just "admin".
+	req = httptest.NewRequest("GET", "/admin/keys", nil)
+	req.Header.Set(APIKeyHeader, "cx_admin")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", resp.Header.Get("X-Caller"))
+
+	req = httptest.NewRequest("GET", "/admin/keys", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", resp.Header.Get("X-Caller"))
+}
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	store := newMockAPIKeyStore()
+	app := setupAPIKeyTestApp(store, false)
+
+	create := func(body string) (int, map[string]any) {
+		req := httptest.NewRequest("POST", "/admin/keys", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(AdminKeyHeader, "secret")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, body := create(`{"id":"partner-a","name":"Partner A","scopes":["rates:read"]}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, "partner-a", body["id"])
+	assert.Equal(t, "cx_new-partner-a", body["secret"])
+	assert.Equal(t, "cxs_new-partner-a", body["signingSecret"])
+
+	status, _ = create(`{"id":"partner-a","name":"Partner A","scopes":["rates:read"]}`)
+	assert.Equal(t, 409, status)
+
+	status, body = create(`{"name":"Partner B","scopes":["rates:write"]}`)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, `unknown scope "rates:write"`, body["error"].(map[string]any)["message"])
+
+	status, body = create(`{"name":"Partner B","role":"owner"}`)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, `unknown role "owner"`, body["error"].(map[string]any)["message"])
+
+	status, body = create(`{"id":"partner-b","name":"Partner B","role":"reader"}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, "reader", body["role"])
+
+	status, body = create(`{"id":"partner-eu","name":"Partner EU","role":"reader","endpoints":["latest"],"pairs":["eur/*"]}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, []any{"EUR/*"}, body["pairs"])
+
+	status, body = create(`{"name":"Partner C","role":"reader","endpoints":["rates"]}`)
+	assert.Equal(t, 400, status)
+	assert.Contains(t, body["error"].(map[string]any)["message"], `unknown endpoint "rates"`)
+
+	status, body = create(`{"name":"Partner B","scopes":["rates:read"],"expiresAt":"2020-01-01T00:00:00Z"}`)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "expiresAt must be in the future", body["error"].(map[string]any)["message"])
+
+	// The new key works straight away.
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(APIKeyHeader, "cx_new-partner-a")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestAPIKeyHandler_RotateAndRevoke(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	app := setupAPIKeyTestApp(store, false)
+
+	send := func(method, target, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(AdminKeyHeader, "secret")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, _ := send("POST", "/admin/keys/partner-a/rotate", `{"gracePeriod":"200h"}`)
+	assert.Equal(t, 400, status)
+
+	status, body := send("POST", "/admin/keys/partner-a/rotate", `{"gracePeriod":"24h"}`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "cx_rotated-partner-a", body["secret"])
+	assert.Equal(t, "cxs_rotated-partner-a", body["signingSecret"])
+	assert.Equal(t, 24*time.Hour, store.grace)
+
+	status, _ = send("POST", "/admin/keys/missing/rotate", "")
+	assert.Equal(t, 404, status)
+
+	status, body = send("DELETE", "/admin/keys/partner-a", "")
+	assert.Equal(t, 200, status)
+	assert.NotNil(t, body["revokedAt"])
+	assert.Nil(t, body["secret"])
+	assert.Nil(t, body["signingSecret"])
+
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(APIKeyHeader, "cx_rotated-partner-a")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestEnforceRestrictions(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_euro", apikey.Key{ID: "partner-eu", Role: apikey.RoleReader, Restrictions: apikey.Restrictions{
+		Endpoints: []string{"latest", "convert"},
+		Pairs:     []string{"EUR/*", "*/EUR"},
+	}})
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Role: apikey.RoleReader})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	rates := app.Group("/v1", EnforceRestrictions())
+	for _, endpoint := range []string{"/latest", "/convert", "/historical"} {
+		rates.Get(endpoint, func(c *fiber.Ctx) error { return c.SendString("ok") })
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		key    string
+		status int
+		code   string
+	}{
+		{"allowed pair", "/v1/latest?base=eur&symbol=USD", "cx_euro", 200, ""},
+		{"allowed as target", "/v1/convert?from=INR&to=EUR&amount=5", "cx_euro", 200, ""},
+		{"pair outside restriction", "/v1/latest?base=USD&symbol=INR", "cx_euro", 403, PairNotAllowedCode},
+		{"convert checks from and to", "/v1/convert?base=EUR&symbol=EUR&from=GBP&to=JPY&amount=1", "cx_euro", 403, PairNotAllowedCode},
+		{"latest checks base and symbol", "/v1/latest?base=GBP&symbol=JPY&from=EUR&to=EUR", "cx_euro", 403, PairNotAllowedCode},
+		{"endpoint outside restriction", "/v1/historical?base=EUR&symbol=USD&startDate=2025-04-01", "cx_euro", 403, EndpointNotAllowedCode},
+		{"malformed pair is left to the handler", "/v1/latest?base=USD&symbol=IN1", "cx_euro", 200, ""},
+		{"unrestricted key", "/v1/historical?base=USD&symbol=INR&startDate=2025-04-01", "cx_reader", 200, ""},
+		{"no key", "/v1/historical?base=USD&symbol=INR&startDate=2025-04-01", "", 200, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			var body ErrorResponse
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			assert.Equal(t, tt.code, body.Error.Code)
+		})
+	}
+}
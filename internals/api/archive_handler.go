@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/adapter/exchangerateapi"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ArchiveHandler serves /admin/import, where operators load historical rates into the rate
+// archive, e.g. to seed dates from before the provider's coverage, /admin/backfill, where
+// they fill it from the provider, /admin/export, where they dump it for analysis or backup,
+// and /admin/restore, where they load it back from its off-site snapshots.
+type ArchiveHandler struct {
+	store     archive.Store // nil when no archive is configured
+	cache     archive.DayCache
+	provider  exchangerateapi.RateAPIClient
+	snapshots *archive.Snapshots // nil when no snapshot bucket is configured
+	logger    *slog.Logger
+}
+
+func NewArchiveHandler(store archive.Store, cache archive.DayCache, provider exchangerateapi.RateAPIClient, snapshots *archive.Snapshots, logger *slog.Logger) *ArchiveHandler {
+	return &ArchiveHandler{store: store, cache: cache, provider: provider, snapshots: snapshots, logger: logger}
+}
+
+// available rejects requests while no archive is configured.
+func (h *ArchiveHandler) available() error {
+	if h.store == nil {
+		return fiber.NewError(fiber.StatusConflict, "no rate archive is configured, set ARCHIVE_BACKEND")
+	}
+	return nil
+}
+
+// Import loads a CSV body of date,base,target,rate rows, with an optional header row, into the
+// archive. Nothing is imported unless every row is valid.
+func (h *ArchiveHandler) Import(c *fiber.Ctx) error {
+	if err := h.available(); err != nil {
+		return err
+	}
+	days, err := archive.ReadCSV(bytes.NewReader(c.Body()))
+	var csvErr *archive.CSVError
+	if errors.As(err, &csvErr) {
+		return fiber.NewError(fiber.StatusBadRequest, csvErr.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "the CSV has no rows, expected date,base,target,rate")
+	}
+
+	rows := 0
+	for _, day := range days {
+		rows += len(day.Rates) - 1 // every day also gets the base's own rate of 1
+	}
+	from, to := days[0].Date.Format("2006-01-02"), days[len(days)-1].Date.Format("2006-01-02")
+	setAuditParam(c, "rows", strconv.Itoa(rows))
+	setAuditParam(c, "from", from)
+	setAuditParam(c, "to", to)
+	if err := archive.Import(c.UserContext(), h.store, h.cache, days); err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Historical rates imported via admin API", "rows", rows, "days", len(days), "from", from, "to", to)
+	return c.JSON(fiber.Map{"rows": rows, "days": len(days), "from": from, "to": to})
+}
+
+// maxBackfillDays caps the span of one backfill request, so each stays a few provider calls.
+const maxBackfillDays = 366
+
+// maxRestoreDays caps the span of one restore request, so a single call can't load years of
+// snapshots into the archive and cache at once.
+const maxRestoreDays = 366
+
+type backfillRequest struct {
+	Base string `json:"base"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Backfill fetches the rates of a base against every supported currency from the provider
+// for the days from `from` to `to` (YYYY-MM-DD, at most a year apart) and saves them to the
+// archive. The body is {"base": "USD", "from": "2015-01-01", "to": "2015-03-31"}. Days the
+// provider has no rates for, such as weekends, are skipped; fetching days again overwrites
+// them, so a failed backfill can simply be repeated.
+func (h *ArchiveHandler) Backfill(c *fiber.Ctx) error {
+	if err := h.available(); err != nil {
+		return err
+	}
+	var req backfillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, `invalid request body, expected {"base": "USD", "from": "YYYY-MM-DD", "to": "YYYY-MM-DD"}`)
+	}
+	base, err := parseCurrency("base", strings.ToUpper(req.Base))
+	if err != nil {
+		return err
+	}
+	if !base.IsSupported() {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("currency %s is not supported", base))
+	}
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid `from` format, expected YYYY-MM-DD")
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid `to` format, expected YYYY-MM-DD")
+	}
+	if from.After(to) {
+		return fiber.NewError(fiber.StatusBadRequest, "`from` must not be after `to`")
+	}
+	if to.After(time.Now().UTC()) {
+		return fiber.NewError(fiber.StatusBadRequest, "`to` must not be in the future")
+	}
+	if span := int(to.Sub(from).Hours()/24) + 1; span > maxBackfillDays {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a backfill spans at most %d days, got %d", maxBackfillDays, span))
+	}
+	setAuditParam(c, "base", string(base))
+	setAuditParam(c, "from", req.From)
+	setAuditParam(c, "to", req.To)
+
+	targets := make([]domain.Currency, 0, len(domain.SupportedCurrencies))
+	for currency := range domain.SupportedCurrencies {
+		if currency != base {
+			targets = append(targets, currency)
+		}
+	}
+	series, err := h.provider.FetchHistoricalTimeSeriesRates(c.UserContext(), from, to, base, targets)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadGateway, "failed to fetch historical rates from the provider: "+err.Error())
+	}
+	days := make([]archive.Day, 0, len(series.Rates))
+	for date, rates := range series.Rates {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("the provider returned rates for an invalid date %q", date))
+		}
+		if day.Before(from) || day.After(to) {
+			continue
+		}
+		dayRates := make(map[domain.Currency]float64, len(rates)+1)
+		dayRates[base] = 1
+		for currency, rate := range rates {
+			dayRates[domain.Currency(currency)] = rate
+		}
+		days = append(days, archive.Day{Date: day, Base: base, Rates: dayRates})
+	}
+	if err := archive.Import(c.UserContext(), h.store, h.cache, days); err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Historical rates backfilled via admin API", "base", base, "from", req.From, "to", req.To, "days", len(days))
+	return c.JSON(fiber.Map{"base": base, "from": req.From, "to": req.To, "days": len(days)})
+}
+
+type restoreRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Restore imports the snapshots of the days from `from` to `to` (YYYY-MM-DD) back into the
+// archive, e.g. after losing the database. The body is {"from": "2025-01-01", "to":
+// "2025-03-31"}. Days without a snapshot are skipped; restored rates replace archived ones.
+func (h *ArchiveHandler) Restore(c *fiber.Ctx) error {
+	if err := h.available(); err != nil {
+		return err
+	}
+	if h.snapshots == nil {
+		return fiber.NewError(fiber.StatusConflict, "no snapshot bucket is configured, set SNAPSHOT_S3_BUCKET")
+	}
+	var req restoreRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, `invalid request body, expected {"from": "YYYY-MM-DD", "to": "YYYY-MM-DD"}`)
+	}
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid `from` format, expected YYYY-MM-DD")
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid `to` format, expected YYYY-MM-DD")
+	}
+	if from.After(to) {
+		return fiber.NewError(fiber.StatusBadRequest, "`from` must not be after `to`")
+	}
+	if span := int(to.Sub(from).Hours()/24) + 1; span > maxRestoreDays {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a restore spans at most %d days, got %d", maxRestoreDays, span))
+	}
+	setAuditParam(c, "from", req.From)
+	setAuditParam(c, "to", req.To)
+
+	logger := logging.WithRequest(c.UserContext(), h.logger)
+	restored, err := h.snapshots.Restore(c.UserContext(), h.store, h.cache, from, to)
+	if err != nil {
+		logger.Error("Restoring rate snapshots failed", "from", req.From, "to", req.To, "days_restored", restored.Days, "error", err)
+		return err
+	}
+	logger.Info("Rate snapshots restored via admin API", "from", req.From, "to", req.To, "days", restored.Days, "rows", restored.Rows)
+	return c.JSON(fiber.Map{"days": restored.Days, "rows": restored.Rows, "from": req.From, "to": req.To})
+}
+
+// exportContentTypes are the content types of the export formats.
+var exportContentTypes = map[string]string{
+	archive.FormatCSV:     "text/csv",
+	archive.FormatParquet: "application/vnd.apache.parquet",
+}
+
+// Export serves the archived rates as a CSV file, the format Import takes, or with
+// ?format=parquet as a Parquet file. `pair` (BASE-TARGET), `from` and `to` (YYYY-MM-DD)
+// optionally narrow it down. The file is streamed as it is read; an export that fails midway
+// is cut off without completing the response, so clients can tell it is incomplete.
+func (h *ArchiveHandler) Export(c *fiber.Ctx) error {
+	if err := h.available(); err != nil {
+		return err
+	}
+	format := c.Query("format", archive.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("`format` must be %s or %s", archive.FormatCSV, archive.FormatParquet))
+	}
+	var filter archive.Filter
+	if value := c.Query("pair"); value != "" {
+		pair, err := parseStreamPair(value)
+		if err != nil {
+			return err
+		}
+		filter.Base, filter.Target = pair.base, pair.target
+	}
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &filter.Start}, {"to", &filter.End}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid `"+param.name+"` format, expected YYYY-MM-DD")
+		}
+		*param.dest = day
+	}
+	if !filter.Start.IsZero() && !filter.End.IsZero() && filter.Start.After(filter.End) {
+		return fiber.NewError(fiber.StatusBadRequest, "`from` must not be after `to`")
+	}
+
+	// The export outlives the handler, which returns once the response starts streaming.
+	ctx := context.WithoutCancel(c.UserContext())
+	logger := logging.WithRequest(ctx, h.logger)
+	body, w := io.Pipe()
+	go func() {
+		started := time.Now()
+		rows, err := h.export(ctx, filter, format, w)
+		if err != nil {
+			logger.Error("Historical rates export failed", "format", format, "rows", rows, "error", err)
+		} else {
+			logger.Info("Historical rates exported via admin API", "format", format, "rows", rows, "duration", time.Since(started).Round(time.Millisecond))
+		}
+		w.CloseWithError(err)
+	}()
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="rates.`+format+`"`)
+	return c.SendStream(body)
+}
+
+// export writes the rows filter matches to w in format, returning how many it wrote.
+func (h *ArchiveHandler) export(ctx context.Context, filter archive.Filter, format string, w io.Writer) (int, error) {
+	writer, err := archive.NewRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	err = h.store.Export(ctx, filter, func(row archive.Row) error {
+		rows++
+		return writer.Write(row)
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
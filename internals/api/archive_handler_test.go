@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/archive"
+	"currency-exchange/internals/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockArchiveStore struct {
+	saved  int
+	rows   []archive.Row
+	filter archive.Filter
+}
+
+func (m *mockArchiveStore) SaveDay(ctx context.Context, day time.Time, base domain.Currency, rates map[domain.Currency]float64) error {
+	m.saved++
+	return nil
+}
+
+func (m *mockArchiveStore) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	return nil, nil
+}
+
+func (m *mockArchiveStore) Export(ctx context.Context, filter archive.Filter, fn func(archive.Row) error) error {
+	m.filter = filter
+	for _, row := range m.rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockArchiveStore) Close() error { return nil }
+
+func TestImport(t *testing.T) {
+	store := &mockArchiveStore{}
+	request := func(h *ArchiveHandler, body string) (int, map[string]any) {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/admin/import", h.Import)
+		req := httptest.NewRequest("POST", "/admin/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, body := request(NewArchiveHandler(store, nil, nil, nil, discardLogger),
+		"date,base,target,rate\n2001-01-02,USD,INR,46.7\n2001-01-02,USD,EUR,1.06\n2001-01-03,USD,INR,46.8\n")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, map[string]any{"rows": 3.0, "days": 2.0, "from": "2001-01-02", "to": "2001-01-03"}, body)
+	assert.Equal(t, 2, store.saved)
+
+	status, _ = request(NewArchiveHandler(store, nil, nil, nil, discardLogger), "2001-01-02,USD,INR,forty\n")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	status, _ = request(NewArchiveHandler(store, nil, nil, nil, discardLogger), "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, 2, store.saved)
+
+	status, _ = request(NewArchiveHandler(nil, nil, nil, nil, discardLogger), "2001-01-02,USD,INR,46.7\n")
+	assert.Equal(t, fiber.StatusConflict, status)
+}
+
+func TestExport(t *testing.T) {
+	jan2 := time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC)
+	store := &mockArchiveStore{rows: []archive.Row{
+		{Date: jan2, Base: "USD", Target: "INR", Rate: 46.7},
+		{Date: jan2.AddDate(0, 0, 1), Base: "USD", Target: "INR", Rate: 46.8},
+	}}
+	request := func(h *ArchiveHandler, query string) (*http.Response, string) {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/admin/export", h.Export)
+		resp, err := app.Test(httptest.NewRequest("GET", "/admin/export"+query, nil))
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := request(NewArchiveHandler(store, nil, nil, nil, discardLogger), "?pair=usd-inr&from=2001-01-01&to=2001-01-31")
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "date,base,target,rate\n2001-01-02,USD,INR,46.7\n2001-01-03,USD,INR,46.8\n", body)
+	assert.Equal(t, archive.Filter{Start: jan2.AddDate(0, 0, -1), End: jan2.AddDate(0, 0, 29), Base: "USD", Target: "INR"}, store.filter)
+
+	resp, body = request(NewArchiveHandler(store, nil, nil, nil, discardLogger), "?format=parquet")
+	assert.Equal(t, "application/vnd.apache.parquet", resp.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(body, "PAR1"))
+	assert.Equal(t, archive.Filter{}, store.filter)
+
+	for _, query := range []string{"?format=xlsx", "?pair=USDINR", "?from=2001/01/01", "?from=2001-02-01&to=2001-01-01"} {
+		resp, _ = request(NewArchiveHandler(store, nil, nil, nil, discardLogger), query)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	resp, _ = request(NewArchiveHandler(nil, nil, nil, nil, discardLogger), "")
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+type mockSeriesProvider struct {
+	series     map[string]map[string]float64
+	err        error
+	start, end time.Time
+}
+
+func (m *mockSeriesProvider) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	return nil, time.Time{}, nil
+}
+
+func (m *mockSeriesProvider) FetchHistoricalTimeSeriesRates(ctx context.Context, start, end time.Time, base domain.Currency, targets []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	m.start, m.end = start, end
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.HistoricalTimeSeriesRatesResponse{Base: string(base), Rates: m.series}, nil
+}
+
+func TestBackfill(t *testing.T) {
+	store := &mockArchiveStore{}
+	provider := &mockSeriesProvider{series: map[string]map[string]float64{
+		"2015-01-02": {"INR": 63.1, "EUR": 0.83},
+		"2015-01-05": {"INR": 63.4, "EUR": 0.84},
+	}}
+	request := func(h *ArchiveHandler, body string) (int, map[string]any) {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/admin/backfill", h.Backfill)
+		req := httptest.NewRequest("POST", "/admin/backfill", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	handler := NewArchiveHandler(store, nil, provider, nil, discardLogger)
+	status, body := request(handler, `{"base":"usd","from":"2015-01-01","to":"2015-01-06"}`)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, map[string]any{"base": "USD", "from": "2015-01-01", "to": "2015-01-06", "days": 2.0}, body)
+	assert.Equal(t, time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), provider.start)
+	assert.Equal(t, time.Date(2015, 1, 6, 0, 0, 0, 0, time.UTC), provider.end)
+	assert.Equal(t, 2, store.saved)
+
+	for _, bad := range []string{
+		`{"base":"XYZ","from":"2015-01-01","to":"2015-01-06"}`,
+		`{"base":"USD","from":"2015/01/01","to":"2015-01-06"}`,
+		`{"base":"USD","from":"2015-01-06","to":"2015-01-01"}`,
+		`{"base":"USD","from":"2015-01-01","to":"2016-12-31"}`,
+		`{"base":"USD","from":"2015-01-01","to":"` + time.Now().AddDate(0, 0, 2).Format("2006-01-02") + `"}`,
+	} {
+		status, _ = request(handler, bad)
+		assert.Equal(t, fiber.StatusBadRequest, status, bad)
+	}
+
+	provider.err = assert.AnError
+	status, _ = request(handler, `{"base":"USD","from":"2015-01-01","to":"2015-01-06"}`)
+	assert.Equal(t, fiber.StatusBadGateway, status)
+	assert.Equal(t, 2, store.saved)
+
+	status, _ = request(NewArchiveHandler(nil, nil, provider, nil, discardLogger), `{"base":"USD","from":"2015-01-01","to":"2015-01-06"}`)
+	assert.Equal(t, fiber.StatusConflict, status)
+}
+
+type memBucket map[string][]byte
+
+func (b memBucket) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	b[key] = data
+	return err
+}
+
+func (b memBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b[key]
+	if !ok {
+		return nil, archive.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestRestore(t *testing.T) {
+	var snapshot bytes.Buffer
+	compressed := gzip.NewWriter(&snapshot)
+	compressed.Write([]byte("date,base,target,rate\n2025-04-14,USD,INR,85.2\n2025-04-14,USD,EUR,0.88\n"))
+	compressed.Close()
+	snapshots := archive.NewSnapshots(memBucket{"rates/2025/04/14.csv.gz": snapshot.Bytes()}, "rates/")
+	store := &mockArchiveStore{}
+	request := func(h *ArchiveHandler, body string) (int, map[string]any) {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/admin/restore", h.Restore)
+		req := httptest.NewRequest("POST", "/admin/restore", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, body := request(NewArchiveHandler(store, nil, nil, snapshots, discardLogger), `{"from":"2025-04-01","to":"2025-04-30"}`)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, map[string]any{"days": 1.0, "rows": 2.0, "from": "2025-04-01", "to": "2025-04-30"}, body)
+	assert.Equal(t, 1, store.saved)
+
+	status, _ = request(NewArchiveHandler(store, nil, nil, snapshots, discardLogger), `{"from":"2025-04-30","to":"2025-04-01"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	// A restore over the day cap is refused before anything is loaded.
+	status, _ = request(NewArchiveHandler(store, nil, nil, snapshots, discardLogger), `{"from":"2020-01-01","to":"2024-12-31"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, 1, store.saved)
+	status, _ = request(NewArchiveHandler(store, nil, nil, nil, discardLogger), `{"from":"2025-04-01","to":"2025-04-30"}`)
+	assert.Equal(t, fiber.StatusConflict, status)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/audit"
+	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/logging"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditParamsLocal holds parameters a handler wants recorded beyond the query string, such
+// as fields of the request body.
+const auditParamsLocal = "auditParams"
+
+// AuditLog stores the trail of admin operations.
+type AuditLog interface {
+	Record(ctx context.Context, entry audit.Entry) error
+	List(ctx context.Context, before string, limit int) ([]audit.Entry, error)
+}
+
+// AuditHandler records admin operations and serves them under /admin/audit.
+type AuditHandler struct {
+	log    AuditLog
+	logger *slog.Logger
+}
+
+func NewAuditHandler(log AuditLog, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{log: log, logger: logger}
+}
+
+// setAuditParam adds a parameter to the audit entry of the current request.
+func setAuditParam(c *fiber.Ctx, name, value string) {
+	params, _ := c.Locals(auditParamsLocal).(map[string]string)
+	if params == nil {
+		params = make(map[string]string)
+		c.Locals(auditParamsLocal, params)
+	}
+	params[name] = value
+}
+
+// Record audits every admin request that changes something, whether or not it succeeded.
+// Reads are not recorded. A failure to write the entry is logged but doesn't fail the
+// request, whose operation has already happened by then.
+func (h *AuditHandler) Record() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+
+		params, _ := c.Locals(auditParamsLocal).(map[string]string)
+		if params == nil {
+			params = make(map[string]string)
+		}
+		for name, value := range c.AllParams() {
+			params[name] = utils.CopyString(value)
+		}
+		c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+			params[string(key)] = string(value)
+		})
+
+		entry := audit.Entry{
+			Time:      time.Now(),
+			Actor:     callerID(c),
+			Action:    c.Method() + " " + c.Route().Path,
+			Params:    params,
+			Status:    status,
+			IP:        clientIP(c),
+			RequestID: helpers.RequestID(c.UserContext()),
+		}
+		if recordErr := h.log.Record(c.UserContext(), entry); recordErr != nil {
+			logging.WithRequest(c.UserContext(), h.logger).Error("Could not record admin operation in the audit log", "action", entry.Action, "error", recordErr)
+		}
+		return err
+	}
+}
+
+// List serves the audit trail, newest first. `limit` caps the number of entries (default
+// 100, at most 1000) and `before` takes the ID of the oldest entry of the previous page.
+func (h *AuditHandler) List(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultAuditLimit)
+	if limit < 1 || limit > maxAuditLimit {
+		return fiber.NewError(fiber.StatusBadRequest, "limit must be between 1 and 1000")
+	}
+
+	entries, err := h.log.List(c.UserContext(), c.Query("before"), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"entries": entries})
+}
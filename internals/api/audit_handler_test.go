@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/audit"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditLog struct {
+	entries   []audit.Entry
+	recordErr error
+	before    string
+	limit     int
+}
+
+func (m *mockAuditLog) Record(ctx context.Context, entry audit.Entry) error {
+	m.entries = append(m.entries, entry)
+	return m.recordErr
+}
+
+func (m *mockAuditLog) List(ctx context.Context, before string, limit int) ([]audit.Entry, error) {
+	m.before, m.limit = before, limit
+	return m.entries, nil
+}
+
+func setupAuditTestApp(log *mockAuditLog, control *mockSchedulerControl) *fiber.App {
+	auditHandler := NewAuditHandler(log, discardLogger)
+	adminHandler := NewAdminHandler(&mockProviderStatusReporter{}, &mockSchedulerStatusReporter{}, control, discardLogger)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	admin := app.Group("/admin", RequireAdmin("secret"), auditHandler.Record())
+	admin.Get("/audit", auditHandler.List)
+	admin.Get("/scheduler", adminHandler.GetScheduler)
+	admin.Post("/scheduler/pause", adminHandler.PauseScheduler)
+	return app
+}
+
+func TestAuditHandler_RecordsChanges(t *testing.T) {
+	log := &mockAuditLog{}
+	app := setupAuditTestApp(log, &mockSchedulerControl{})
+
+	req := httptest.NewRequest("POST", "/admin/scheduler/pause?dryRun=false", strings.NewReader(`{"reason":"provider outage"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	req = httptest.NewRequest("GET", "/admin/scheduler", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	_, err = app.Test(req)
+	assert.NoError(t, err)
+
+	// Requests without the admin key never reach the audit middleware.
+	_, err = app.Test(httptest.NewRequest("POST", "/admin/scheduler/pause", nil))
+	assert.NoError(t, err)
+
+	assert.Len(t, log.entries, 1)
+	entry := log.entries[0]
+	assert.Equal(t, "admin", entry.Actor)
+	assert.Equal(t, "POST /admin/scheduler/pause", entry.Action)
+	assert.Equal(t, map[string]string{"reason": "provider outage", "dryRun": "false"}, entry.Params)
+	assert.Equal(t, 200, entry.Status)
+	assert.False(t, entry.Time.IsZero())
+}
+
+func TestAuditHandler_RecordsFailures(t *testing.T) {
+	log := &mockAuditLog{recordErr: errors.New("redis down")}
+	app := setupAuditTestApp(log, &mockSchedulerControl{pauseErr: errors.New("redis down")})
+
+	req := httptest.NewRequest("POST", "/admin/scheduler/pause", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+
+	assert.Len(t, log.entries, 1)
+	assert.Equal(t, 500, log.entries[0].Status)
+}
+
+func TestAuditHandler_List(t *testing.T) {
+	log := &mockAuditLog{entries: []audit.Entry{{ID: "2-0", Actor: "admin", Action: "POST /admin/scheduler/resume", Status: 200}}}
+	app := setupAuditTestApp(log, &mockSchedulerControl{})
+
+	req := httptest.NewRequest("GET", "/admin/audit?limit=10&before=5-0", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, log.entries, body.Entries)
+	assert.Equal(t, "5-0", log.before)
+	assert.Equal(t, 10, log.limit)
+
+	req = httptest.NewRequest("GET", "/admin/audit?limit=5000", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/logging"
+	"currency-exchange/internals/metrics"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LockedOutCode is the code of the 429 for requests from a source that failed to
+// authenticate too often, so clients can tell it from a rate limit.
+const LockedOutCode = "Locked Out"
+
+// AuthFailureTracker counts failed authentication attempts per source and blocks sources that
+// fail too often.
+type AuthFailureTracker interface {
+	Fail(ctx context.Context, source string) (bool, error)
+	BlockedFor(ctx context.Context, source string) (time.Duration, error)
+}
+
+// AuthLockout logs every request whose credentials are rejected and counts the failure
+// against its IP. IPs that fail too often are turned away with a 429 until their block ends,
+// which slows down brute-forcing keys. Failures are not counted against the key ID a signed
+// request claims, since that is unverified: anyone could lock a key out by naming it.
+// Requests without credentials are left alone. When the tracker can't be reached, requests
+// go through.
+func AuthLockout(tracker AuthFailureTracker, logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !hasCredentials(c) && c.Get(AdminKeyHeader) == "" {
+			return c.Next()
+		}
+		source := "ip:" + clientIP(c)
+		blockedFor, err := tracker.BlockedFor(c.UserContext(), source)
+		if err != nil {
+			logging.WithRequest(c.UserContext(), logger).Warn("Could not check authentication lockout", "source", source, "error", err)
+		}
+		if blockedFor > 0 {
+			metrics.ObserveAuthFailure("blocked")
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(blockedFor.Seconds()))))
+			return &CodedError{
+				Status:  fiber.StatusTooManyRequests,
+				Code:    LockedOutCode,
+				Message: "too many failed authentication attempts, retry later",
+			}
+		}
+
+		// A 401 may come back as an error or, from middleware that renders errors itself such
+		// as debug logging, as a response already written. Both count.
+		err = c.Next()
+		status, reason := c.Response().StatusCode(), "unauthorized"
+		if err != nil {
+			status, reason = errorStatus(err), err.Error()
+		}
+		if status != fiber.StatusUnauthorized {
+			return err
+		}
+		log := logging.WithRequest(c.UserContext(), logger)
+		log.Warn("Authentication failed", "ip", clientIP(c), "method", c.Method(), "path", c.Path(), "key_id", c.Get(SignatureKeyIDHeader), "reason", reason)
+		metrics.ObserveAuthFailure("rejected")
+		blocked, failErr := tracker.Fail(c.UserContext(), source)
+		if failErr != nil {
+			log.Warn("Could not count authentication failure", "source", source, "error", failErr)
+		} else if blocked {
+			log.Warn("Authentication source locked out", "source", source)
+		}
+		return err
+	}
+}
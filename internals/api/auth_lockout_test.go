@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/debuglog"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuthFailureTracker struct {
+	threshold int
+	failures  map[string]int
+	blocked   map[string]bool
+	err       error
+}
+
+func newMockAuthFailureTracker(threshold int) *mockAuthFailureTracker {
+	return &mockAuthFailureTracker{threshold: threshold, failures: map[string]int{}, blocked: map[string]bool{}}
+}
+
+func (m *mockAuthFailureTracker) Fail(ctx context.Context, source string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.failures[source]++
+	if m.failures[source] >= m.threshold {
+		m.blocked[source] = true
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *mockAuthFailureTracker) BlockedFor(ctx context.Context, source string) (time.Duration, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.blocked[source] {
+		return 90 * time.Second, nil
+	}
+	return 0, nil
+}
+
+func setupAuthLockoutTestApp(tracker *mockAuthFailureTracker) *fiber.App {
+	store := newMockAPIKeyStore()
+	store.add("cx_valid", apikey.Key{ID: "partner-a", Role: apikey.RoleReader})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(AuthLockout(tracker, discardLogger))
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Get("/v1/latest", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func lockoutRequest(t *testing.T, app *fiber.App, apiKey string) (int, string, *http.Response) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var body ErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Error.Code, resp
+}
+
+func TestAuthLockout(t *testing.T) {
+	tracker := newMockAuthFailureTracker(3)
+	app := setupAuthLockoutTestApp(tracker)
+
+	for i := 0; i < 3; i++ {
+		status, _, _ := lockoutRequest(t, app, "cx_guess")
+		assert.Equal(t, 401, status)
+	}
+	assert.Equal(t, 3, tracker.failures["ip:0.0.0.0"])
+
+	// Once blocked, even a valid key is turned away until the block ends.
+	status, code, resp := lockoutRequest(t, app, "cx_valid")
+	assert.Equal(t, 429, status)
+	assert.Equal(t, LockedOutCode, code)
+	assert.Equal(t, "90", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	// Requests without credentials aren't checked.
+	status, _, _ = lockoutRequest(t, app, "")
+	assert.Equal(t, 200, status)
+}
+
+func TestAuthLockout_CountsRenderedFailures(t *testing.T) {
+	tracker := newMockAuthFailureTracker(2)
+	debugLog := NewDebugLogHandler(&mockDebugLogSwitch{state: debuglog.State{Enabled: true}}, 1024, discardLogger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(AuthLockout(tracker, discardLogger))
+	app.Use(debugLog.Middleware()) // renders errors itself and returns nil
+	app.Get("/admin/providers", RequireAdmin("secret"), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/admin/providers", nil)
+		req.Header.Set(AdminKeyHeader, "guess")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+	}
+	assert.Equal(t, 2, tracker.failures["ip:0.0.0.0"])
+
+	req := httptest.NewRequest("GET", "/admin/providers", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+}
+
+func TestAuthLockout_CountsPerIPOnly(t *testing.T) {
+	tracker := newMockAuthFailureTracker(3)
+	app := setupAuthLockoutTestApp(tracker)
+
+	status, _, _ := lockoutRequest(t, app, "cx_valid")
+	assert.Equal(t, 200, status)
+	assert.Empty(t, tracker.failures)
+
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(APIKeyHeader, "cx_guess")
+	req.Header.Set(SignatureKeyIDHeader, "partner-b")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+	// The claimed key ID is unverified, so failures naming it must not lock the key out.
+	assert.Equal(t, map[string]int{"ip:0.0.0.0": 1}, tracker.failures)
+}
+
+func TestAuthLockout_FailsOpen(t *testing.T) {
+	tracker := newMockAuthFailureTracker(1)
+	tracker.err = errors.New("redis down")
+	app := setupAuthLockoutTestApp(tracker)
+
+	status, _, _ := lockoutRequest(t, app, "cx_guess")
+	assert.Equal(t, 401, status)
+	status, _, _ = lockoutRequest(t, app, "cx_valid")
+	assert.Equal(t, 200, status)
+}
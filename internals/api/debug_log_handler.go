@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/debuglog"
+	"currency-exchange/internals/logging"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultDebugLogWindow = 15 * time.Minute
+	maxDebugLogWindow     = time.Hour
+
+	// debugStateRefresh is how long a replica relies on the switch state it last read, so
+	// checking it doesn't cost a Redis call on every request.
+	debugStateRefresh = 2 * time.Second
+
+	redacted = "[REDACTED]"
+)
+
+// sensitiveName matches query parameter and JSON field names whose values are never logged.
+var sensitiveName = regexp.MustCompile(`(?i)(key|token|secret|password|signature|app_?id|auth)`)
+
+// DebugLogSwitch turns request/response debug logging on and off for every replica.
+type DebugLogSwitch interface {
+	Enable(ctx context.Context, d time.Duration) error
+	Disable(ctx context.Context) error
+	State(ctx context.Context) (debuglog.State, error)
+}
+
+// DebugLogHandler logs the query parameters and response body of every request while debug
+// logging is switched on under /admin/debug-logging, for short troubleshooting windows.
+// Values that look like credentials are redacted and bodies are cut at maxBodyBytes.
+type DebugLogHandler struct {
+	sw           DebugLogSwitch
+	maxBodyBytes int
+	logger       *slog.Logger
+	now          func() time.Time
+
+	active    atomic.Bool
+	checkedAt atomic.Int64
+}
+
+func NewDebugLogHandler(sw DebugLogSwitch, maxBodyBytes int, logger *slog.Logger) *DebugLogHandler {
+	return &DebugLogHandler{sw: sw, maxBodyBytes: maxBodyBytes, logger: logger, now: time.Now}
+}
+
+// enabled reports the switch state, reading it again once it is older than
+// debugStateRefresh. Only one request rereads it; the others go on with the last state.
+func (h *DebugLogHandler) enabled(ctx context.Context) bool {
+	now := h.now().UnixNano()
+	last := h.checkedAt.Load()
+	if now-last >= int64(debugStateRefresh) && h.checkedAt.CompareAndSwap(last, now) {
+		state, err := h.sw.State(ctx)
+		if err != nil {
+			h.logger.Debug("Could not read debug logging state", "error", err)
+		}
+		h.active.Store(state.Enabled)
+	}
+	return h.active.Load()
+}
+
+// Middleware logs each request while debug logging is on. Like AccessLog, it renders errors
+// through the app's error handler itself, so the logged body is the one the client got.
+func (h *DebugLogHandler) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !h.enabled(c.UserContext()) {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		query := make(map[string]string)
+		c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+			query[string(key)] = redact(string(key), string(value))
+		})
+		logging.WithRequest(c.UserContext(), h.logger).Info("Request debug",
+			"method", c.Method(),
+			"path", c.Path(),
+			"query", query,
+			"status", c.Response().StatusCode(),
+			"response", h.responseBody(bufferedResponseBody(c)),
+		)
+		return nil
+	}
+}
+
+// responseBody returns body for the log, with credentials in JSON bodies redacted, cut at
+// maxBodyBytes, or before it when that would split a UTF-8 character.
+func (h *DebugLogHandler) responseBody(body []byte) string {
+	var value any
+	if json.Unmarshal(body, &value) == nil {
+		if data, err := json.Marshal(redactJSON(value)); err == nil {
+			body = data
+		}
+	}
+	if len(body) <= h.maxBodyBytes {
+		return string(body)
+	}
+	cut := h.maxBodyBytes
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes)", body[:cut], len(body))
+}
+
+func redact(name, value string) string {
+	if sensitiveName.MatchString(name) {
+		return redacted
+	}
+	return value
+}
+
+func redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if sensitiveName.MatchString(name) {
+				v[name] = redacted
+			} else {
+				v[name] = redactJSON(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
+
+type debugLogRequest struct {
+	Duration string `json:"duration"`
+}
+
+// GetState serves whether debug logging is on and until when.
+func (h *DebugLogHandler) GetState(c *fiber.Ctx) error {
+	state, err := h.sw.State(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// Enable turns debug logging on for every replica. The optional body {"duration": "30m"}
+// sets the window, 15 minutes by default and at most an hour.
+func (h *DebugLogHandler) Enable(c *fiber.Ctx) error {
+	var req debugLogRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"duration\": \"15m\"}")
+		}
+	}
+	window := defaultDebugLogWindow
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 || d > maxDebugLogWindow {
+			return fiber.NewError(fiber.StatusBadRequest, "duration must be a positive duration of at most 1h, e.g. 15m")
+		}
+		window = d
+	}
+
+	setAuditParam(c, "duration", window.String())
+	if err := h.sw.Enable(c.UserContext(), window); err != nil {
+		return err
+	}
+	h.checkedAt.Store(0)
+	logging.WithRequest(c.UserContext(), h.logger).Warn("Request debug logging enabled via admin API", "window", window)
+	return h.GetState(c)
+}
+
+func (h *DebugLogHandler) Disable(c *fiber.Ctx) error {
+	if err := h.sw.Disable(c.UserContext()); err != nil {
+		return err
+	}
+	h.checkedAt.Store(0)
+	logging.WithRequest(c.UserContext(), h.logger).Info("Request debug logging disabled via admin API")
+	return h.GetState(c)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/adapter/debuglog"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDebugLogSwitch struct {
+	state  debuglog.State
+	window time.Duration
+	reads  int
+}
+
+func (m *mockDebugLogSwitch) Enable(ctx context.Context, d time.Duration) error {
+	until := time.Now().Add(d)
+	m.state, m.window = debuglog.State{Enabled: true, Until: &until}, d
+	return nil
+}
+
+func (m *mockDebugLogSwitch) Disable(ctx context.Context) error {
+	m.state = debuglog.State{}
+	return nil
+}
+
+func (m *mockDebugLogSwitch) State(ctx context.Context) (debuglog.State, error) {
+	m.reads++
+	return m.state, nil
+}
+
+func setupDebugLogTestApp(sw *mockDebugLogSwitch, maxBodyBytes int, buf *bytes.Buffer) *fiber.App {
+	h := NewDebugLogHandler(sw, maxBodyBytes, slog.New(slog.NewJSONHandler(buf, nil)))
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(h.Middleware())
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"base": "USD", "rates": fiber.Map{"INR": 82.5}, "credentials": []fiber.Map{{"apiKey": "k-123"}}})
+	})
+	app.Get("/v1/missing", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusNotFound, "no such thing")
+	})
+	admin := app.Group("/admin", RequireAdmin("secret"))
+	admin.Get("/debug-logging", h.GetState)
+	admin.Post("/debug-logging", h.Enable)
+	admin.Delete("/debug-logging", h.Disable)
+	return app
+}
+
+func TestDebugLog_OffByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	app := setupDebugLogTestApp(&mockDebugLogSwitch{}, 1024, &buf)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/latest?base=USD", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, buf.String())
+}
+
+func TestDebugLog_LogsRedactedRequestsWhileEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	sw := &mockDebugLogSwitch{}
+	app := setupDebugLogTestApp(sw, 1024, &buf)
+
+	req := httptest.NewRequest("POST", "/admin/debug-logging", strings.NewReader(`{"duration":"30m"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 30*time.Minute, sw.window)
+	buf.Reset()
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/v1/latest?base=USD&api_key=abc", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest("GET", "/v1/missing", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	var latest, missing map[string]any
+	assert.NoError(t, json.Unmarshal(lines[0], &latest))
+	assert.NoError(t, json.Unmarshal(lines[1], &missing))
+	assert.Equal(t, "Request debug", latest["msg"])
+	assert.Equal(t, map[string]any{"base": "USD", "api_key": "[REDACTED]"}, latest["query"])
+	assert.Equal(t, `{"base":"USD","credentials":[{"apiKey":"[REDACTED]"}],"rates":{"INR":82.5}}`, latest["response"])
+	assert.Equal(t, float64(404), missing["status"])
+	assert.Contains(t, missing["response"], "no such thing")
+
+	req = httptest.NewRequest("DELETE", "/admin/debug-logging", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	var state debuglog.State
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.False(t, state.Enabled)
+	buf.Reset()
+
+	_, err = app.Test(httptest.NewRequest("GET", "/v1/latest", nil))
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Request debug")
+}
+
+func TestDebugLog_TruncatesBodiesAndCachesState(t *testing.T) {
+	var buf bytes.Buffer
+	sw := &mockDebugLogSwitch{}
+	_ = sw.Enable(context.Background(), time.Minute)
+	app := setupDebugLogTestApp(sw, 10, &buf)
+
+	for i := 0; i < 3; i++ {
+		_, err := app.Test(httptest.NewRequest("GET", "/v1/latest", nil))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, sw.reads)
+
+	var line map[string]any
+	assert.NoError(t, json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &line))
+	assert.True(t, strings.HasPrefix(line["response"].(string), `{"base":"U... (truncated, `), line["response"])
+}
+
+func TestDebugLog_RejectsLongWindows(t *testing.T) {
+	app := setupDebugLogTestApp(&mockDebugLogSwitch{}, 1024, &bytes.Buffer{})
+
+	for _, body := range []string{`{"duration":"2h"}`, `{"duration":"soon"}`, `not json`} {
+		req := httptest.NewRequest("POST", "/admin/debug-logging", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(AdminKeyHeader, "secret")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode, body)
+	}
+}
+
+func TestDebugLogHandler_TruncatesOnRuneBoundary(t *testing.T) {
+	h := NewDebugLogHandler(&mockDebugLogSwitch{}, 5, discardLogger)
+	// "€" takes bytes 4 to 6, so cutting at 5 would split it.
+	body := h.responseBody([]byte("abcd€fgh"))
+	assert.Equal(t, "abcd... (truncated, 10 bytes)", body)
+	assert.True(t, utf8.ValidString(body))
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/grpcapi/ratespb"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Media types the rate endpoints answer with besides JSON, for internal callers that poll
+// often enough for payload size and parse cost to matter. Errors are always JSON.
+const (
+	MIMEProtobuf = "application/x-protobuf"
+	MIMEMsgpack  = "application/x-msgpack"
+)
+
+// msgpackAliases are the other names MessagePack goes by in Accept headers.
+var msgpackAliases = []string{"application/msgpack", "application/vnd.msgpack"}
+
+// respond writes v as JSON, protobuf or MessagePack, whichever the Accept header prefers.
+// Protobuf bodies are the messages in proto/currencyexchange/v1/responses.proto; MessagePack
+// bodies have the same shape as the JSON ones.
+func respond(c *fiber.Ctx, v any) error {
+	c.Vary(fiber.HeaderAccept)
+	offers := append([]string{fiber.MIMEApplicationJSON, MIMEProtobuf, MIMEMsgpack}, msgpackAliases...)
+	switch accepted := c.Accepts(offers...); accepted {
+	case MIMEProtobuf:
+		message, ok := protoOf(v)
+		if !ok {
+			break
+		}
+		data, err := proto.Marshal(message)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, MIMEProtobuf)
+		return c.Send(data)
+	case MIMEMsgpack, msgpackAliases[0], msgpackAliases[1]:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(msgpackOf(v)); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, accepted)
+		return c.Send(buf.Bytes())
+	}
+	return c.JSON(v)
+}
+
+// protoOf converts a rate endpoint's response to its protobuf message.
+func protoOf(v any) (proto.Message, bool) {
+	switch v := v.(type) {
+	case *domain.LatestRates:
+		rates := make(map[string]float64, len(v.Rates))
+		for currency, rate := range v.Rates {
+			rates[string(currency)] = rate
+		}
+		return &ratespb.LatestRatesResponse{Base: string(v.Base), Rates: rates, Timestamp: v.Timestamp, Stale: v.Stale}, true
+	case *domain.ConversionResult:
+		var onDate string
+		if v.Date != nil {
+			onDate = v.Date.String()
+		}
+		return &ratespb.ConversionResponse{
+			From:            string(v.Amount.Currency),
+			To:              string(v.Converted.Currency),
+			Amount:          v.Amount.Float64(),
+			ConvertedAmount: v.Converted.Float64(),
+			Rate:            v.Rate,
+			OnDate:          onDate,
+			Stale:           v.Stale,
+			Timestamp:       v.Timestamp,
+		}, true
+	case *domain.HistoricalRates:
+		dates := v.Dates()
+		rates := make([]*ratespb.HistoricalRate, len(dates))
+		for i, date := range dates {
+			rates[i] = &ratespb.HistoricalRate{Date: date.String(), Rate: v.Rates[date]}
+		}
+		return &ratespb.HistoricalRatesResponse{Base: string(v.Base), Target: string(v.Target), Amount: v.Amount, Rates: rates}, true
+	}
+	return nil, false
+}
+
+// msgpackOf gives date-keyed maps the string keys they have in JSON, since MessagePack would
+// otherwise encode them as timestamps, and conversions their flat JSON shape, with amounts as
+// floats.
+func msgpackOf(v any) any {
+	switch v := v.(type) {
+	case *domain.ConversionResult:
+		return struct {
+			From            domain.Currency `json:"from"`
+			To              domain.Currency `json:"to"`
+			OriginalAmount  float64         `json:"amount"`
+			ConvertedAmount float64         `json:"convertedAmount"`
+			Rate            float64         `json:"rate"`
+			Date            string          `json:"onDate,omitempty"`
+			Timestamp       int64           `json:"timestamp,omitempty"`
+			Stale           bool            `json:"stale,omitempty"`
+		}{v.Amount.Currency, v.Converted.Currency, v.Amount.Float64(), v.Converted.Float64(), v.Rate, optionalDateString(v.Date), v.Timestamp, v.Stale}
+	case *domain.HistoricalRates:
+		rates := make(map[string]float64, len(v.Rates))
+		for date, rate := range v.Rates {
+			rates[date.String()] = rate
+		}
+		return struct {
+			Base   domain.Currency    `json:"base"`
+			Rates  map[string]float64 `json:"rates"`
+			Amount float64            `json:"amount"`
+			Target domain.Currency    `json:"target"`
+		}{v.Base, rates, v.Amount, v.Target}
+	}
+	return v
+}
+
+// optionalDateString formats date as YYYY-MM-DD, or "" for nil.
+func optionalDateString(date *domain.CustomDate) string {
+	if date == nil {
+		return ""
+	}
+	return date.String()
+}
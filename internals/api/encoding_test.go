@@ -0,0 +1,100 @@
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/grpcapi/ratespb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+func getWithAccept(t *testing.T, mock *MockRateService, url, accept string) (string, []byte) {
+	t.Helper()
+	req := httptest.NewRequest("GET", url, nil)
+	req.Header.Set("Accept", accept)
+	resp, err := setupTestApp(mock).Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "Accept", resp.Header.Get("Vary"))
+	body, _ := io.ReadAll(resp.Body)
+	return resp.Header.Get("Content-Type"), body
+}
+
+func TestRespond_Protobuf(t *testing.T) {
+	onDate := domain.DateOf(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	mock := &MockRateService{
+		LatestRatesResp:  &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}, Timestamp: 1744624800},
+		ConversionResult: &domain.ConversionResult{Amount: domain.NewMoneyFromFloat(10, "USD"), Converted: domain.NewMoneyFromFloat(852, "INR"), Rate: 85.2, Date: &onDate},
+		HistoricalRates: &domain.HistoricalRates{Base: "USD", Target: "INR", Amount: 1, Rates: map[domain.CustomDate]float64{
+			domain.DateOf(time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)): 85.3,
+			onDate: 85.1,
+		}},
+	}
+
+	contentType, body := getWithAccept(t, mock, "/v1/latest?base=USD&symbol=INR", MIMEProtobuf)
+	assert.Equal(t, MIMEProtobuf, contentType)
+	var latest ratespb.LatestRatesResponse
+	assert.NoError(t, proto.Unmarshal(body, &latest))
+	assert.Equal(t, map[string]float64{"INR": 85.2}, latest.GetRates())
+	assert.Equal(t, int64(1744624800), latest.GetTimestamp())
+
+	_, body = getWithAccept(t, mock, "/v1/convert?from=USD&to=INR&amount=10&date=2025-04-01", MIMEProtobuf)
+	var conversion ratespb.ConversionResponse
+	assert.NoError(t, proto.Unmarshal(body, &conversion))
+	assert.Equal(t, 852.0, conversion.GetConvertedAmount())
+	assert.Equal(t, "2025-04-01", conversion.GetOnDate())
+	assert.Zero(t, conversion.GetTimestamp())
+
+	_, body = getWithAccept(t, mock, "/v1/historical?base=USD&symbol=INR&startDate=2025-04-01&endDate=2025-04-02", MIMEProtobuf)
+	var historical ratespb.HistoricalRatesResponse
+	assert.NoError(t, proto.Unmarshal(body, &historical))
+	assert.Len(t, historical.GetRates(), 2)
+	assert.Equal(t, "2025-04-01", historical.GetRates()[0].GetDate())
+	assert.Equal(t, 85.3, historical.GetRates()[1].GetRate())
+}
+
+func TestRespond_Msgpack(t *testing.T) {
+	mock := &MockRateService{
+		LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}, Timestamp: 1744624800},
+		HistoricalRates: &domain.HistoricalRates{Base: "USD", Target: "INR", Amount: 1, Rates: map[domain.CustomDate]float64{
+			domain.DateOf(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)): 85.1,
+		}},
+		ConversionResult: &domain.ConversionResult{Amount: domain.NewMoneyFromFloat(10, "USD"), Converted: domain.NewMoneyFromFloat(852.35, "INR"), Rate: 85.235, Timestamp: 1744624800},
+	}
+
+	contentType, body := getWithAccept(t, mock, "/v1/latest?base=USD&symbol=INR", "application/msgpack")
+	assert.Equal(t, "application/msgpack", contentType)
+	var latest map[string]any
+	assert.NoError(t, msgpack.Unmarshal(body, &latest))
+	assert.Equal(t, "USD", latest["base"])
+	assert.Equal(t, map[string]any{"INR": 85.2}, latest["rates"])
+	assert.NotContains(t, latest, "stale")
+
+	// Dates are keyed as they are in JSON.
+	_, body = getWithAccept(t, mock, "/v1/historical?base=USD&symbol=INR&startDate=2025-04-01", MIMEMsgpack)
+	var historical map[string]any
+	assert.NoError(t, msgpack.Unmarshal(body, &historical))
+	assert.Equal(t, map[string]any{"2025-04-01": 85.1}, historical["rates"])
+
+	// Conversions are as flat as in JSON.
+	_, body = getWithAccept(t, mock, "/v1/convert?from=USD&to=INR&amount=10", MIMEMsgpack)
+	var conversion map[string]any
+	assert.NoError(t, msgpack.Unmarshal(body, &conversion))
+	assert.Equal(t, map[string]any{"from": "USD", "to": "INR", "amount": 10.0, "convertedAmount": 852.35, "rate": 85.235, "timestamp": int64(1744624800)}, conversion)
+}
+
+func TestRespond_DefaultsToJSON(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}}}
+
+	for _, accept := range []string{"", "*/*", "text/html"} {
+		contentType, body := getWithAccept(t, mock, "/v1/latest?base=USD&symbol=INR", accept)
+		assert.Equal(t, "application/json", contentType, accept)
+		assert.JSONEq(t, `{"base":"USD","rates":{"INR":85.2},"timestamp":0}`, string(body))
+	}
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+# Rate lookups are nullable, so one failing lookup doesn't take the others' data with it.
+type Query {
+	# Latest rate from base to symbol, as served by /v1/latest.
+	latest(base: String!, symbol: String!): LatestRates
+	# Converts amount, at the rate of date (YYYY-MM-DD) when given, as served by /v1/convert.
+	convert(from: String!, to: String!, amount: Float!, date: String): Conversion
+	# Daily rates from base to symbol, as served by /v1/historical. Either date may be left out
+	# for a single day.
+	historical(base: String!, symbol: String!, startDate: String, endDate: String): HistoricalRates
+	# The currencies the service handles, as served by /v1/currencies.
+	currencies: [Currency!]!
+}
+
+type LatestRates {
+	base: String!
+	rates: [Rate!]!
+	updatedAt: String!
+	stale: Boolean!
+}
+
+type Rate {
+	currency: String!
+	rate: Float!
+}
+
+type Conversion {
+	from: String!
+	to: String!
+	amount: Float!
+	convertedAmount: Float!
+	rate: Float!
+	date: String
+	# When the rate was published, for conversions at the latest rate.
+	updatedAt: String
+	stale: Boolean!
+}
+
+type HistoricalRates {
+	base: String!
+	target: String!
+	rates: [DatedRate!]!
+}
+
+type DatedRate {
+	date: String!
+	rate: Float!
+}
+
+enum CurrencyKind {
+	FIAT
+	CRYPTO
+	METAL
+}
+
+type Currency {
+	code: String!
+	kind: CurrencyKind!
+	name: String!
+	# ISO 4217 numeric code, e.g. "978" for EUR; null for crypto assets.
+	numericCode: String
+	# Digits after the decimal point amounts are rounded to; -1 for metals.
+	minorUnits: Int!
+	symbol: String
+	# First and last day (YYYY-MM-DD) a recently introduced or a retired currency was in use.
+	validFrom: String
+	validUntil: String
+}
+`
+
+// maxGraphQLLookups bounds how many rate lookups one GraphQL request may make, aliases
+// included, so a single request can't fan out into hundreds of provider calls.
+const maxGraphQLLookups = 20
+
+// maxGraphQLDepth bounds how deeply a query may nest, which the schema never needs beyond 3.
+const maxGraphQLDepth = 5
+
+// GraphQLHandler serves /graphql, letting dashboards fetch latest rates, conversions,
+// historical series and currency metadata in one request, with only the fields they need.
+// Lookups go through the same validation and key restrictions as the REST endpoints, and each
+// is charged to the quota and recorded in usage like a request to its endpoint. Either of
+// quotas and usage may be nil.
+type GraphQLHandler struct {
+	schema *graphql.Schema
+	logger *slog.Logger
+}
+
+func NewGraphQLHandler(handler *Handler, quotas *QuotaHandler, usage *UsageHandler, logger *slog.Logger) *GraphQLHandler {
+	resolver := &graphQLResolver{handler: handler, quotas: quotas, usage: usage}
+	return &GraphQLHandler{
+		schema: graphql.MustParseSchema(graphQLSchema, resolver, graphql.UseFieldResolvers(), graphql.MaxDepth(maxGraphQLDepth)),
+		logger: logger,
+	}
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLCall is what resolvers know about the request they are serving.
+type graphQLCall struct {
+	caller  Caller
+	lookups atomic.Int32
+}
+
+type graphQLCallKey struct{}
+
+// Serve executes a query posted as {"query", "operationName", "variables"}. Like any GraphQL
+// server it answers 200 with an `errors` list when fields fail; each error's `extensions.code`
+// is the code the REST endpoint would have answered with.
+func (h *GraphQLHandler) Serve(c *fiber.Ctx) error {
+	var req graphQLRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "request body must be a JSON object with a `query`")
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "`query` is required")
+	}
+	call := &graphQLCall{}
+	call.caller, _ = authenticatedCaller(c)
+	ctx := context.WithValue(c.UserContext(), graphQLCallKey{}, call)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	for _, e := range resp.Errors {
+		if e.ResolverError != nil {
+			logging.WithRequest(c.UserContext(), h.logger).Warn("Error resolving GraphQL field", "path", e.Path, "error", e.ResolverError)
+		}
+	}
+	return c.JSON(resp)
+}
+
+type graphQLResolver struct {
+	handler *Handler
+	quotas  *QuotaHandler
+	usage   *UsageHandler
+}
+
+// lookup validates a rate lookup against the key's restrictions and the supported currencies,
+// counts it towards maxGraphQLLookups, and charges it to the caller's quota and usage as a
+// request to endpoint.
+func (r *graphQLResolver) lookup(ctx context.Context, endpoint, baseParam, base, targetParam, target string) (domain.Currency, domain.Currency, error) {
+	call, _ := ctx.Value(graphQLCallKey{}).(*graphQLCall)
+	if call == nil {
+		call = &graphQLCall{}
+	}
+	if call.lookups.Add(1) > maxGraphQLLookups {
+		return "", "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a query may look up at most %d rates", maxGraphQLLookups))
+	}
+	baseCurrency, err := parseCurrency(baseParam, strings.ToUpper(base))
+	if err != nil {
+		return "", "", err
+	}
+	targetCurrency, err := parseCurrency(targetParam, strings.ToUpper(target))
+	if err != nil {
+		return "", "", err
+	}
+	if err := checkRestrictions(call.caller.Restrictions, endpoint, string(baseCurrency), string(targetCurrency)); err != nil {
+		return "", "", err
+	}
+	if call.caller.ID != "" {
+		if r.quotas != nil {
+			if _, err := r.quotas.charge(ctx, call.caller); err != nil {
+				return "", "", err
+			}
+		}
+		if r.usage != nil {
+			r.usage.record(ctx, call.caller, endpoint, string(baseCurrency)+"/"+string(targetCurrency))
+		}
+	}
+	if err := r.handler.checkCurrencies(baseCurrency, targetCurrency); err != nil {
+		return "", "", err
+	}
+	return baseCurrency, targetCurrency, nil
+}
+
+type graphQLLatestRates struct {
+	Base      string
+	Rates     []graphQLRate
+	UpdatedAt string
+	Stale     bool
+}
+
+type graphQLRate struct {
+	Currency string
+	Rate     float64
+}
+
+func (r *graphQLResolver) Latest(ctx context.Context, args struct{ Base, Symbol string }) (*graphQLLatestRates, error) {
+	base, target, err := r.lookup(ctx, "latest", "base", args.Base, "symbol", args.Symbol)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	rates, err := r.handler.rateService.GetLatestRates(ctx, base, target)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	result := &graphQLLatestRates{
+		Base:      string(rates.Base),
+		Rates:     make([]graphQLRate, 0, len(rates.Rates)),
+		UpdatedAt: time.Unix(rates.Timestamp, 0).UTC().Format(time.RFC3339),
+		Stale:     rates.Stale,
+	}
+	for currency, rate := range rates.Rates {
+		result.Rates = append(result.Rates, graphQLRate{Currency: string(currency), Rate: rate})
+	}
+	sort.Slice(result.Rates, func(i, j int) bool { return result.Rates[i].Currency < result.Rates[j].Currency })
+	return result, nil
+}
+
+type graphQLConversion struct {
+	From            string
+	To              string
+	Amount          float64
+	ConvertedAmount float64
+	Rate            float64
+	Date            *string
+	UpdatedAt       *string
+	Stale           bool
+}
+
+func (r *graphQLResolver) Convert(ctx context.Context, args struct {
+	From, To string
+	Amount   float64
+	Date     *string
+}) (*graphQLConversion, error) {
+	from, to, err := r.lookup(ctx, "convert", "from", args.From, "to", args.To)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if !(args.Amount > 0) || math.IsInf(args.Amount, 0) {
+		return nil, toGraphQLError(&CodedError{Status: fiber.StatusBadRequest, Code: InvalidAmountCode, Message: "amount must be a non-zero positive number"})
+	}
+	req := domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(args.Amount, from), To: to}
+	if args.Date != nil {
+		date, err := time.Parse("2006-01-02", *args.Date)
+		if err != nil {
+			return nil, toGraphQLError(fiber.NewError(fiber.StatusBadRequest, "invalid `date` format, expected YYYY-MM-DD"))
+		}
+		onDate := domain.CustomDate(date)
+		req.Date = &onDate
+	}
+	result, err := r.handler.rateService.Convert(ctx, req)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	conversion := &graphQLConversion{
+		From:            string(result.Amount.Currency),
+		To:              string(result.Converted.Currency),
+		Amount:          result.Amount.Float64(),
+		ConvertedAmount: result.Converted.Float64(),
+		Rate:            result.Rate,
+		Stale:           result.Stale,
+	}
+	conversion.Date = optionalDate(result.Date)
+	if result.Timestamp != 0 {
+		updatedAt := time.Unix(result.Timestamp, 0).UTC().Format(time.RFC3339)
+		conversion.UpdatedAt = &updatedAt
+	}
+	return conversion, nil
+}
+
+type graphQLHistoricalRates struct {
+	Base   string
+	Target string
+	Rates  []graphQLDatedRate
+}
+
+type graphQLDatedRate struct {
+	Date string
+	Rate float64
+}
+
+func (r *graphQLResolver) Historical(ctx context.Context, args struct {
+	Base, Symbol       string
+	StartDate, EndDate *string
+}) (*graphQLHistoricalRates, error) {
+	base, target, err := r.lookup(ctx, "historical", "base", args.Base, "symbol", args.Symbol)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	var startDate, endDate string
+	if args.StartDate != nil {
+		startDate = *args.StartDate
+	}
+	if args.EndDate != nil {
+		endDate = *args.EndDate
+	}
+	if startDate == "" && endDate == "" {
+		return nil, toGraphQLError(fiber.NewError(fiber.StatusBadRequest, "at least one of `startDate` or `endDate` is required"))
+	}
+	if startDate == "" {
+		startDate = endDate
+	} else if endDate == "" {
+		endDate = startDate
+	}
+	rates, err := r.handler.rateService.GetHistoricalRates(ctx, startDate, endDate, base, target)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	result := &graphQLHistoricalRates{
+		Base:   string(rates.Base),
+		Target: string(rates.Target),
+		Rates:  make([]graphQLDatedRate, 0, len(rates.Rates)),
+	}
+	for _, date := range rates.Dates() {
+		result.Rates = append(result.Rates, graphQLDatedRate{Date: date.String(), Rate: rates.Rates[date]})
+	}
+	return result, nil
+}
+
+type graphQLCurrency struct {
+	Code        string
+	Kind        string
+	Name        string
+	NumericCode *string
+	MinorUnits  int32
+	Symbol      *string
+	ValidFrom   *string
+	ValidUntil  *string
+}
+
+func (r *graphQLResolver) Currencies() []graphQLCurrency {
+	supported := supportedCurrencies()
+	currencies := make([]graphQLCurrency, 0, len(supported))
+	for _, currency := range supported {
+		currencies = append(currencies, graphQLCurrency{
+			Code:        string(currency.Code),
+			Kind:        currency.Kind,
+			Name:        currency.Name,
+			NumericCode: optionalString(currency.Numeric),
+			MinorUnits:  int32(currency.MinorUnits),
+			Symbol:      optionalString(currency.Symbol),
+			ValidFrom:   optionalDate(currency.ValidFrom),
+			ValidUntil:  optionalDate(currency.ValidUntil),
+		})
+	}
+	return currencies
+}
+
+// optionalDate formats a date as YYYY-MM-DD, nil staying null.
+func optionalDate(date *domain.CustomDate) *string {
+	if date == nil {
+		return nil
+	}
+	return optionalString(date.String())
+}
+
+// optionalString maps an empty string to null.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// graphQLError carries the code the REST endpoint would have answered with to the GraphQL
+// error's extensions.
+type graphQLError struct {
+	code    string
+	message string
+	err     error
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+func (e *graphQLError) Unwrap() error {
+	return e.err
+}
+
+func (e *graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+// toGraphQLError renders err as NewErrorHandler would, hiding the message of unexpected errors.
+func toGraphQLError(err error) error {
+	code, message := describeError(err)
+	return &graphQLError{code: code, message: message, err: err}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/usage"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGraphQLTestApp(mock *MockRateService) *fiber.App {
+	store := newMockAPIKeyStore()
+	store.add("cx_eur_only", apikey.Key{ID: "partner-a", Role: apikey.RoleReader, Restrictions: apikey.Restrictions{
+		Endpoints: []string{"latest", "convert"},
+		Pairs:     []string{"EUR/*"},
+	}})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Post("/graphql", NewGraphQLHandler(NewHandler(mock), nil, nil, discardLogger).Serve)
+	return app
+}
+
+type graphQLTestResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func graphQLQuery(t *testing.T, app *fiber.App, apiKey, query string, variables map[string]any) (int, graphQLTestResponse) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var out graphQLTestResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestGraphQL_Queries(t *testing.T) {
+	convertedOn := domain.DateOf(time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC))
+	mock := &MockRateService{
+		LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}, Timestamp: 1744624800},
+		ConversionResult: &domain.ConversionResult{
+			Amount: domain.NewMoneyFromFloat(100, "USD"), Converted: domain.NewMoneyFromFloat(91, "EUR"), Rate: 0.91, Date: &convertedOn,
+		},
+		HistoricalRates: &domain.HistoricalRates{Base: "USD", Target: "INR", Rates: map[domain.CustomDate]float64{
+			domain.DateOf(time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)): 85.5,
+			domain.DateOf(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)): 85.1,
+		}},
+	}
+	app := setupGraphQLTestApp(mock)
+
+	status, resp := graphQLQuery(t, app, "", `query($amount: Float!) {
+		latest(base: "usd", symbol: "INR") { base rates { currency rate } updatedAt }
+		convert(from: "USD", to: "EUR", amount: $amount, date: "2025-04-10") { convertedAmount rate date updatedAt }
+		historical(base: "USD", symbol: "INR", startDate: "2025-04-01", endDate: "2025-04-02") { rates { date rate } }
+		currencies { code kind name numericCode minorUnits symbol }
+	}`, map[string]any{"amount": 100})
+	assert.Equal(t, 200, status)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]any{
+		"base":      "USD",
+		"rates":     []any{map[string]any{"currency": "INR", "rate": 85.2}},
+		"updatedAt": "2025-04-14T10:00:00Z",
+	}, resp.Data["latest"])
+	assert.Equal(t, map[string]any{"convertedAmount": float64(91), "rate": 0.91, "date": "2025-04-10", "updatedAt": nil}, resp.Data["convert"])
+	assert.Equal(t, map[string]any{"rates": []any{
+		map[string]any{"date": "2025-04-01", "rate": 85.1},
+		map[string]any{"date": "2025-04-02", "rate": 85.5},
+	}}, resp.Data["historical"])
+	assert.Contains(t, resp.Data["currencies"], map[string]any{
+		"code": "JPY", "kind": "FIAT", "name": "Yen", "numericCode": "392", "minorUnits": float64(0), "symbol": "¥",
+	})
+}
+
+func TestGraphQL_ConvertAtLatestRate(t *testing.T) {
+	mock := &MockRateService{ConversionResult: &domain.ConversionResult{
+		Amount: domain.NewMoneyFromFloat(100, "USD"), Converted: domain.NewMoneyFromFloat(91, "EUR"), Rate: 0.91, Timestamp: 1744624800,
+	}}
+	app := setupGraphQLTestApp(mock)
+
+	status, resp := graphQLQuery(t, app, "", `{ convert(from: "USD", to: "EUR", amount: 100) { convertedAmount date updatedAt } }`, nil)
+	assert.Equal(t, 200, status)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]any{"convertedAmount": float64(91), "date": nil, "updatedAt": "2025-04-14T10:00:00Z"}, resp.Data["convert"])
+}
+
+func TestGraphQL_Errors(t *testing.T) {
+	mock := &MockRateService{LatestRatesErr: errors.New("redis: connection refused")}
+	app := setupGraphQLTestApp(mock)
+
+	status, resp := graphQLQuery(t, app, "", `query($amount: Float!) {
+		bad: latest(base: "US$", symbol: "INR") { base }
+		down: latest(base: "USD", symbol: "INR") { base }
+		amount: convert(from: "USD", to: "EUR", amount: $amount) { rate }
+	}`, map[string]any{"amount": -1})
+	assert.Equal(t, 200, status)
+	codes := map[string]string{}
+	for _, e := range resp.Errors {
+		codes[e.Path[0].(string)] = e.Extensions["code"].(string)
+		if e.Path[0] == "down" {
+			// Unexpected errors don't leak their details.
+			assert.Equal(t, "Internal Server Error", e.Message)
+		}
+	}
+	assert.Equal(t, map[string]string{"bad": MalformedCurrencyCode, "down": "Internal Server Error", "amount": InvalidAmountCode}, codes)
+
+	status, _ = graphQLQuery(t, app, "", "", nil)
+	assert.Equal(t, 400, status)
+	status, resp = graphQLQuery(t, app, "", `{ latest(base: "USD") { base } }`, nil)
+	assert.Equal(t, 200, status)
+	assert.NotEmpty(t, resp.Errors)
+}
+
+func TestGraphQL_Restrictions(t *testing.T) {
+	mock := &MockRateService{
+		LatestRatesResp:  &domain.LatestRates{Base: "EUR", Rates: map[domain.Currency]float64{"USD": 1.1}},
+		ConversionResult: &domain.ConversionResult{Amount: domain.NewMoneyFromFloat(1, "USD"), Converted: domain.NewMoneyFromFloat(0, "EUR")},
+		HistoricalRates:  &domain.HistoricalRates{Base: "EUR", Target: "USD"},
+	}
+	app := setupGraphQLTestApp(mock)
+
+	_, resp := graphQLQuery(t, app, "cx_eur_only", `{
+		ok: latest(base: "EUR", symbol: "USD") { base }
+		pair: convert(from: "USD", to: "EUR", amount: 1) { rate }
+		endpoint: historical(base: "EUR", symbol: "USD", startDate: "2025-04-01") { base }
+	}`, nil)
+	assert.Equal(t, map[string]any{"base": "EUR"}, resp.Data["ok"])
+	codes := map[string]string{}
+	for _, e := range resp.Errors {
+		codes[e.Path[0].(string)] = e.Extensions["code"].(string)
+	}
+	assert.Equal(t, map[string]string{"pair": PairNotAllowedCode, "endpoint": EndpointNotAllowedCode}, codes)
+}
+
+func TestGraphQL_LookupLimit(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.2}}}
+	app := setupGraphQLTestApp(mock)
+
+	var query strings.Builder
+	query.WriteString("{")
+	for i := 0; i < maxGraphQLLookups+1; i++ {
+		query.WriteString(` r` + string(rune('a'+i)) + `: latest(base: "USD", symbol: "INR") { base }`)
+	}
+	query.WriteString("}")
+	_, resp := graphQLQuery(t, app, "", query.String(), nil)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "at most 20 rates")
+}
+
+// lockedQuotaCounter and lockedUsageRecorder guard the mocks, since GraphQL resolves the
+// lookups of a query concurrently.
+type lockedQuotaCounter struct {
+	mu sync.Mutex
+	*mockQuotaCounter
+}
+
+func (m *lockedQuotaCounter) Increment(ctx context.Context, keyID string, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mockQuotaCounter.Increment(ctx, keyID, now)
+}
+
+func (m *lockedQuotaCounter) Decrement(ctx context.Context, keyID string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mockQuotaCounter.Decrement(ctx, keyID, now)
+}
+
+type lockedUsageRecorder struct {
+	mu sync.Mutex
+	*mockUsageRecorder
+}
+
+func (m *lockedUsageRecorder) Record(ctx context.Context, hit usage.Hit, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mockUsageRecorder.Record(ctx, hit, now)
+}
+
+func TestGraphQL_ChargesEachLookup(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "EUR", Rates: map[domain.Currency]float64{"USD": 1.1}}}
+	store := newMockAPIKeyStore()
+	store.add("cx_a", apikey.Key{ID: "partner-a", Role: apikey.RoleReader})
+	counter := &lockedQuotaCounter{mockQuotaCounter: &mockQuotaCounter{counts: map[string]int64{}}}
+	recorder := &lockedUsageRecorder{mockUsageRecorder: &mockUsageRecorder{}}
+	quotas := NewQuotaHandler(counter, config.RateLimitConfig{Enabled: true, PerKey: config.Limit{MonthlyQuota: 2}}, discardLogger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Post("/graphql", NewGraphQLHandler(NewHandler(mock), quotas, NewUsageHandler(recorder, 31, discardLogger), discardLogger).Serve)
+
+	status, resp := graphQLQuery(t, app, "cx_a", `{
+		a: latest(base: "EUR", symbol: "USD") { base }
+		b: latest(base: "EUR", symbol: "USD") { base }
+		c: latest(base: "EUR", symbol: "USD") { base }
+		currencies { code }
+	}`, nil)
+	assert.Equal(t, 200, status)
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, QuotaExceededCode, resp.Errors[0].Extensions["code"])
+	}
+	assert.NotNil(t, resp.Data["currencies"])
+	assert.Equal(t, int64(2), counter.counts["partner-a"])
+	assert.Len(t, recorder.hits, 2)
+	for _, hit := range recorder.hits {
+		assert.Equal(t, "partner-a", hit.KeyID)
+		assert.Equal(t, "EUR/USD", hit.Pair)
+		assert.Equal(t, "latest", hit.Endpoint)
+	}
+}
@@ -2,13 +2,14 @@ package api
 
 import (
 	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
 	"currency-exchange/internals/service"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -28,27 +29,96 @@ type ErrorResponse struct {
 	} `json:"error"`
 }
 
-func ErrorHandler(c *fiber.Ctx, err error) error {
-	log.Printf("Error handling request: %v", err)
+// CodedError is like a *fiber.Error with its own code in the ErrorResponse, for clients that need
+// to tell apart errors sharing a status, such as the two kinds of 429.
+type CodedError struct {
+	Status  int
+	Code    string
+	Message string
+}
 
-	code := fiber.StatusInternalServerError
-	message := "Internal Server Error"
+func (e *CodedError) Error() string {
+	return e.Message
+}
 
+// asCodedError finds the CodedError err is rendered with, which domain errors clients need to
+// tell apart are turned into.
+func asCodedError(err error) (*CodedError, bool) {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded, true
+	}
+	var notInUse *domain.CurrencyNotInUseError
+	if errors.As(err, &notInUse) {
+		code := CurrencyNotIntroducedCode
+		if notInUse.Retired {
+			code = CurrencyRetiredCode
+		}
+		return &CodedError{Status: fiber.StatusBadRequest, Code: code, Message: notInUse.Error()}, true
+	}
+	return nil, false
+}
+
+// errorStatus returns the status err is rendered with by the error handler.
+func errorStatus(err error) int {
+	if coded, ok := asCodedError(err); ok {
+		return coded.Status
+	}
 	var e *fiber.Error
 	if errors.As(err, &e) {
-		code = e.Code
-		message = e.Message
+		return e.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// describeError returns the code and message NewErrorHandler would answer err with, for
+// protocols that report errors in their own envelope. Unexpected errors are described as an
+// Internal Server Error without their details.
+func describeError(err error) (string, string) {
+	code, message := http.StatusText(fiber.StatusInternalServerError), "Internal Server Error"
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		code, message = http.StatusText(e.Code), e.Message
+	}
+	if coded, ok := asCodedError(err); ok {
+		code, message = coded.Code, coded.Message
+	}
+	return code, message
+}
+
+// NewErrorHandler renders errors as an ErrorResponse and logs them with the request ID.
+func NewErrorHandler(logger *slog.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		logging.WithRequest(c.UserContext(), logger).Error("Error handling request", "method", c.Method(), "path", c.Path(), "error", err)
+
+		code := fiber.StatusInternalServerError
+		message := "Internal Server Error"
+
+		var e *fiber.Error
+		if errors.As(err, &e) {
+			code = e.Code
+			message = e.Message
+		}
+		errorCode := http.StatusText(code)
+		if coded, ok := asCodedError(err); ok {
+			code, errorCode, message = coded.Status, coded.Code, coded.Message
+		}
+
+		return c.Status(code).JSON(ErrorResponse{
+			Error: struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}{
+				Code:    errorCode,
+				Message: message,
+			},
+		})
 	}
+}
 
-	return c.Status(code).JSON(ErrorResponse{
-		Error: struct {
-			Code    string `json:"code"`
-			Message string `json:"message"`
-		}{
-			Code:    http.StatusText(code),
-			Message: message,
-		},
-	})
+// ErrorHandler is NewErrorHandler logging through the default logger.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	return NewErrorHandler(slog.Default())(c, err)
 }
 
 func (h *Handler) checkCurrencies(baseCurrency, targetCurrency domain.Currency) error {
@@ -67,8 +137,8 @@ func (h *Handler) checkCurrencies(baseCurrency, targetCurrency domain.Currency)
 }
 
 func (h *Handler) GetLatest(c *fiber.Ctx) error {
-	baseCurrency := domain.Currency(strings.ToUpper(c.Query("base")))
-	if baseCurrency == "" {
+	baseStr := strings.ToUpper(c.Query("base"))
+	if baseStr == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "base query parameter is required")
 	}
 
@@ -77,75 +147,85 @@ func (h *Handler) GetLatest(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "target currency parameter is required")
 	}
 
-	symbols := strings.Split(symbolsStr, ",")
-	if len(symbols) > 1 {
-		return fiber.NewError(fiber.StatusBadRequest, "More than one target currencies provided, specify any one !")
+	baseCurrency, err := parseCurrency("base", baseStr)
+	if err != nil {
+		return err
+	}
+	symbols, err := parseCurrencyList("symbol", symbolsStr, maxSymbols)
+	if err != nil {
+		return err
 	}
 
-	err := h.checkCurrencies(baseCurrency, domain.Currency(symbolsStr))
+	err = h.checkCurrencies(baseCurrency, symbols[0])
 	if err != nil {
 		return err
 	}
 
-	rates, err := h.rateService.GetLatestRates(c.Context(), baseCurrency, domain.Currency(symbolsStr))
+	rates, err := h.rateService.GetLatestRates(c.UserContext(), baseCurrency, symbols[0])
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(rates)
+	return respond(c, rates)
 }
 
 func (h *Handler) Convert(c *fiber.Ctx) error {
-	fromCurrency := domain.Currency(strings.ToUpper(c.Query("from")))
-	toCurrency := domain.Currency(strings.ToUpper(c.Query("to")))
+	fromStr := strings.ToUpper(c.Query("from"))
+	toStr := strings.ToUpper(c.Query("to"))
 	amountStr := c.Query("amount")
 
-	if fromCurrency == "" || toCurrency == "" || amountStr == "" {
+	if fromStr == "" || toStr == "" || amountStr == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "from, to, and amount query parameters are required")
 	}
 
-	err := h.checkCurrencies(fromCurrency, toCurrency)
+	fromCurrency, err := parseCurrency("from", fromStr)
+	if err != nil {
+		return err
+	}
+	toCurrency, err := parseCurrency("to", toStr)
+	if err != nil {
+		return err
+	}
+
+	err = h.checkCurrencies(fromCurrency, toCurrency)
 	if err != nil {
 		return err
 	}
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil || amount <= 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "amount must be a non-zero positive number")
+	amount, err := parseAmount(amountStr)
+	if err != nil {
+		return err
 	}
 
 	dateStr := c.Query("date")
-	var conversionDate *time.Time
+	var conversionDate *domain.CustomDate
 	if dateStr != "" {
-		parsedDate, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
+		var parsedDate domain.CustomDate
+		if err := parsedDate.UnmarshalText([]byte(dateStr)); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid `date` format, expected YYYY-MM-DD")
 		}
 		conversionDate = &parsedDate
-	} else {
-		conversionDate = nil
 	}
 
 	req := domain.ConversionRequest{
-		From:   fromCurrency,
+		Amount: domain.NewMoney(amount, fromCurrency),
 		To:     toCurrency,
-		Amount: amount,
 		Date:   conversionDate,
 	}
 
-	result, err := h.rateService.Convert(c.Context(), req)
+	result, err := h.rateService.Convert(c.UserContext(), req)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(result)
+	return respond(c, result)
 }
 
 func (h *Handler) GetHistorical(c *fiber.Ctx) error {
 	startDate := c.Query("startDate")
 	endDate := c.Query("endDate")
-	baseCurrency := domain.Currency(strings.ToUpper(c.Query("base")))
-	if baseCurrency == "" {
+	baseStr := strings.ToUpper(c.Query("base"))
+	if baseStr == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "`base` query parameter is required")
 	}
 
@@ -154,7 +234,16 @@ func (h *Handler) GetHistorical(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "target currency parameter is required")
 	}
 
-	err := h.checkCurrencies(baseCurrency, domain.Currency(symbolsStr))
+	baseCurrency, err := parseCurrency("base", baseStr)
+	if err != nil {
+		return err
+	}
+	symbols, err := parseCurrencyList("symbol", symbolsStr, maxSymbols)
+	if err != nil {
+		return err
+	}
+
+	err = h.checkCurrencies(baseCurrency, symbols[0])
 	if err != nil {
 		return err
 	}
@@ -169,15 +258,80 @@ func (h *Handler) GetHistorical(c *fiber.Ctx) error {
 		endDate = startDate
 	}
 
-	symbols := strings.Split(symbolsStr, ",")
-	if len(symbols) > 1 {
-		return fiber.NewError(fiber.StatusBadRequest, "More than one target currencies provided, specify any one !")
+	rates, err := h.rateService.GetHistoricalRates(c.UserContext(), startDate, endDate, baseCurrency, symbols[0])
+	if err != nil {
+		return err
+	}
+
+	return respond(c, rates)
+}
+
+// currencyResponse is a supported currency as listed by GET /v1/currencies.
+type currencyResponse struct {
+	domain.CurrencyInfo
+	Kind string `json:"kind"` // FIAT, CRYPTO or METAL
+}
+
+// newCurrencyResponse returns the reference data of code.
+func newCurrencyResponse(code domain.Currency) currencyResponse {
+	kind := "FIAT"
+	switch {
+	case code.IsCrypto():
+		kind = "CRYPTO"
+	case code.IsMetal():
+		kind = "METAL"
+	}
+	return currencyResponse{CurrencyInfo: code.Info(), Kind: kind}
+}
+
+// supportedCurrencies returns the reference data of the supported currencies, ordered by code.
+func supportedCurrencies() []currencyResponse {
+	currencies := make([]currencyResponse, 0, len(domain.SupportedCurrencies))
+	for code := range domain.SupportedCurrencies {
+		currencies = append(currencies, newCurrencyResponse(code))
 	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// GetCurrencies lists the supported currencies with their ISO 4217 metadata.
+func (h *Handler) GetCurrencies(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"currencies": supportedCurrencies()})
+}
 
-	rates, err := h.rateService.GetHistoricalRates(c.Context(), startDate, endDate, baseCurrency, domain.Currency(symbolsStr))
+// countryCurrency is a currency used in a country, as listed by GET /v1/currencies/by-country.
+type countryCurrency struct {
+	currencyResponse
+	Supported bool `json:"supported"`
+}
+
+// countryCurrenciesResponse is the body of GET /v1/currencies/by-country.
+type countryCurrenciesResponse struct {
+	Country    string            `json:"country"`
+	Name       string            `json:"name"`
+	Default    domain.Currency   `json:"default,omitempty"` // the first supported currency, if any
+	Currencies []countryCurrency `json:"currencies"`
+}
+
+// GetCurrenciesByCountry lists the currencies used in the country with the ISO 3166-1 alpha-2
+// code in `code`, so storefronts can pick a default currency from a user's locale.
+func (h *Handler) GetCurrenciesByCountry(c *fiber.Ctx) error {
+	code := strings.ToUpper(c.Query("code"))
+	if code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "`code` query parameter is required")
+	}
+	code, err := parseCountry("code", code)
 	if err != nil {
 		return err
 	}
-
-	return c.JSON(rates)
+	country, ok := domain.LookupCountry(code)
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("no currency is known for country %s", code))
+	}
+	resp := countryCurrenciesResponse{Country: country.Code, Name: country.Name, Currencies: make([]countryCurrency, 0, len(country.Currencies))}
+	resp.Default, _ = country.DefaultCurrency()
+	for _, currency := range country.Currencies {
+		resp.Currencies = append(resp.Currencies, countryCurrency{currencyResponse: newCurrencyResponse(currency), Supported: currency.IsSupported()})
+	}
+	return c.JSON(resp)
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -14,6 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 // --- Mock Service Implementation ---
 
 type MockRateService struct {
@@ -70,6 +74,8 @@ func setupTestApp(mock *MockRateService) *fiber.App {
 	app.Get("/v1/latest", h.GetLatest)
 	app.Get("/v1/convert", h.Convert)
 	app.Get("/v1/historical", h.GetHistorical)
+	app.Get("/v1/currencies", h.GetCurrencies)
+	app.Get("/v1/currencies/by-country", h.GetCurrenciesByCountry)
 	return app
 }
 
@@ -133,16 +139,27 @@ func TestGetLatest_ServiceError(t *testing.T) {
 	assert.Equal(t, 500, resp.StatusCode)
 }
 
+func TestGetLatest_RetiredCurrency(t *testing.T) {
+	retired := &domain.CurrencyNotInUseError{Currency: "HRK", Retired: true, Date: domain.DateOf(time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC))}
+	mock := &MockRateService{LatestRatesErr: retired}
+	app := setupTestApp(mock)
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/latest?base=HRK&symbol=EUR", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	var body ErrorResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, CurrencyRetiredCode, body.Error.Code)
+	assert.Equal(t, retired.Error(), body.Error.Message)
+}
+
 // --- Tests for /v1/convert ---
 
 func TestConvert_Success(t *testing.T) {
 	mock := &MockRateService{
 		ConversionResult: &domain.ConversionResult{
-			From:            "USD",
-			To:              "INR",
-			OriginalAmount:  100,
-			ConvertedAmount: 8250,
-			Rate:            82.5,
+			Amount:    domain.NewMoneyFromFloat(100, "USD"),
+			Converted: domain.NewMoneyFromFloat(8250, "INR"),
+			Rate:      82.5,
 		},
 	}
 	app := setupTestApp(mock)
@@ -152,9 +169,8 @@ func TestConvert_Success(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 	var result domain.ConversionResult
 	json.NewDecoder(resp.Body).Decode(&result)
-	assert.Equal(t, "USD", string(result.From))
-	assert.Equal(t, "INR", string(result.To))
-	assert.Equal(t, 8250.0, result.ConvertedAmount)
+	assert.Equal(t, "100 USD", result.Amount.String())
+	assert.Equal(t, "8250 INR", result.Converted.String())
 }
 
 func TestConvert_MissingParams(t *testing.T) {
@@ -192,12 +208,10 @@ func TestConvert_ServiceError(t *testing.T) {
 func TestConvert_DateParam_Success(t *testing.T) {
 	mock := &MockRateService{
 		ConversionResult: &domain.ConversionResult{
-			From:            "USD",
-			To:              "INR",
-			OriginalAmount:  100,
-			ConvertedAmount: 8000,
-			Rate:            80.0,
-			Date:            ptrTime(time.Now().AddDate(0, 0, -10)),
+			Amount:    domain.NewMoneyFromFloat(100, "USD"),
+			Converted: domain.NewMoneyFromFloat(8000, "INR"),
+			Rate:      80.0,
+			Date:      ptrDate(domain.DateOf(time.Now().AddDate(0, 0, -10))),
 		},
 	}
 	app := setupTestApp(mock)
@@ -208,7 +222,7 @@ func TestConvert_DateParam_Success(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 	var result domain.ConversionResult
 	json.NewDecoder(resp.Body).Decode(&result)
-	assert.Equal(t, 8000.0, result.ConvertedAmount)
+	assert.Equal(t, "8000 INR", result.Converted.String())
 }
 
 func TestConvert_InvalidDate(t *testing.T) {
@@ -226,7 +240,7 @@ func TestGetHistorical_Success(t *testing.T) {
 		HistoricalRates: &domain.HistoricalRates{
 			Base:   "USD",
 			Target: "INR",
-			Rates:  map[time.Time]float64{time.Now().AddDate(0, 0, -1).Truncate(24 * time.Hour): 80.0},
+			Rates:  map[domain.CustomDate]float64{domain.DateOf(time.Now().AddDate(0, 0, -1)): 80.0},
 		},
 	}
 	app := setupTestApp(mock)
@@ -289,4 +303,49 @@ func TestGetHistorical_ServiceError(t *testing.T) {
 	assert.Equal(t, 500, resp.StatusCode)
 }
 
-func ptrTime(t time.Time) *time.Time { return &t }
+// --- Tests for /v1/currencies ---
+
+func TestGetCurrencies(t *testing.T) {
+	app := setupTestApp(&MockRateService{})
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/currencies", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var result struct {
+		Currencies []map[string]any `json:"currencies"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	codes := make([]string, 0, len(result.Currencies))
+	for _, currency := range result.Currencies {
+		codes = append(codes, currency["code"].(string))
+	}
+	assert.Equal(t, []string{"EUR", "GBP", "INR", "JPY", "USD"}, codes)
+	assert.Equal(t, map[string]any{
+		"code": "INR", "numericCode": "356", "name": "Indian Rupee", "minorUnits": float64(2), "symbol": "₹", "kind": "FIAT",
+	}, result.Currencies[2])
+}
+
+func TestGetCurrenciesByCountry(t *testing.T) {
+	app := setupTestApp(&MockRateService{})
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/currencies/by-country?code=bt", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var result map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "BT", result["country"])
+	assert.Equal(t, "Bhutan", result["name"])
+	assert.Equal(t, "INR", result["default"])
+	currencies := result["currencies"].([]any)
+	assert.Len(t, currencies, 2)
+	assert.Equal(t, map[string]any{
+		"code": "BTN", "numericCode": "064", "name": "Ngultrum", "minorUnits": float64(2), "symbol": "Nu.", "kind": "FIAT", "supported": false,
+	}, currencies[0])
+	assert.Equal(t, true, currencies[1].(map[string]any)["supported"])
+
+	for query, status := range map[string]int{"": 400, "?code=IND": 400, "?code=1N": 400, "?code=AQ": 404} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/v1/currencies/by-country"+query, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, query)
+	}
+}
+
+func ptrDate(d domain.CustomDate) *domain.CustomDate { return &d }
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"currency-exchange/internals/adapter/cache"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadinessChecker is implemented by dependencies the service cannot serve traffic without.
+type ReadinessChecker interface {
+	Name() string
+	Ready() error
+}
+
+// CacheStatusReporter exposes the latest probe of the cache.
+type CacheStatusReporter interface {
+	Status() cache.RedisStatus
+}
+
+// HealthSources feed the detailed health report. Any of them may be left nil.
+type HealthSources struct {
+	Cache     CacheStatusReporter
+	Providers ProviderStatusReporter
+	Scheduler SchedulerStatusReporter
+}
+
+type HealthHandler struct {
+	checkers  []ReadinessChecker
+	sources   HealthSources
+	startedAt time.Time
+	draining  atomic.Bool
+	now       func() time.Time
+}
+
+func NewHealthHandler(sources HealthSources, checkers ...ReadinessChecker) *HealthHandler {
+	return &HealthHandler{checkers: checkers, sources: sources, startedAt: time.Now(), now: time.Now}
+}
+
+type dependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "UP"})
+}
+
+// StartDraining makes Readiness report 503 from now on, so load balancers stop sending new
+// requests while the ones already in flight finish.
+func (h *HealthHandler) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Readiness reports 503 when any dependency is down, or the instance is shutting down, so load
+// balancers stop routing to this instance.
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	if h.draining.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "DRAINING"})
+	}
+
+	ready, checks := h.checkDependencies()
+
+	status := "READY"
+	code := fiber.StatusOK
+	if !ready {
+		status = "NOT_READY"
+		code = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// Serving reports whether Readiness would answer 200, for health checks made over other
+// protocols.
+func (h *HealthHandler) Serving() bool {
+	if h.draining.Load() {
+		return false
+	}
+	ready, _ := h.checkDependencies()
+	return ready
+}
+
+func (h *HealthHandler) checkDependencies() (bool, map[string]dependencyStatus) {
+	ready := true
+	checks := make(map[string]dependencyStatus, len(h.checkers))
+	for _, checker := range h.checkers {
+		if err := checker.Ready(); err != nil {
+			ready = false
+			checks[checker.Name()] = dependencyStatus{Status: "DOWN", Error: err.Error()}
+			continue
+		}
+		checks[checker.Name()] = dependencyStatus{Status: "UP"}
+	}
+	return ready, checks
+}
+
+// healthDetails is the report served by /health/details. Its shape is stable so monitoring
+// tools can alert on individual fields.
+type healthDetails struct {
+	// Status is UP, DEGRADED when a refresh loop has missed a whole cycle, DOWN when a
+	// dependency is down or DRAINING during shutdown.
+	Status        string                      `json:"status"`
+	StartedAt     time.Time                   `json:"startedAt"`
+	UptimeSeconds int64                       `json:"uptimeSeconds"`
+	Checks        map[string]dependencyStatus `json:"checks"`
+	Cache         *cacheHealth                `json:"cache,omitempty"`
+	Providers     *providersHealth            `json:"providers,omitempty"`
+	Scheduler     []loopHealth                `json:"scheduler,omitempty"`
+}
+
+// cacheHealth reports the round trip of the most recent cache probe.
+type cacheHealth struct {
+	Up          bool      `json:"up"`
+	LatencyMs   float64   `json:"latencyMs"`
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// providersHealth reports when rates were last fetched successfully, overall and by provider.
+type providersHealth struct {
+	LastSuccess   *time.Time            `json:"lastSuccess"`
+	LastSuccessBy map[string]*time.Time `json:"lastSuccessBy"`
+}
+
+// loopHealth reports how far a refresh loop is behind its schedule. LagSeconds is 0 while
+// the loop is on time.
+type loopHealth struct {
+	Name       string     `json:"name"`
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"`
+	NextRunAt  *time.Time `json:"nextRunAt,omitempty"`
+	LagSeconds float64    `json:"lagSeconds"`
+}
+
+// Details serves the full health report. It answers 503 when Readiness would.
+func (h *HealthHandler) Details(c *fiber.Ctx) error {
+	now := h.now()
+	ready, checks := h.checkDependencies()
+	details := healthDetails{
+		Status:        "UP",
+		StartedAt:     h.startedAt.UTC(),
+		UptimeSeconds: int64(now.Sub(h.startedAt).Seconds()),
+		Checks:        checks,
+	}
+
+	if h.sources.Cache != nil {
+		status := h.sources.Cache.Status()
+		details.Cache = &cacheHealth{Up: status.Up, LatencyMs: status.LatencyMs, LastChecked: status.LastChecked}
+	}
+
+	if h.sources.Providers != nil {
+		providers := &providersHealth{LastSuccessBy: make(map[string]*time.Time)}
+		for _, status := range h.sources.Providers.Statuses() {
+			providers.LastSuccessBy[status.Name] = status.LastSuccess
+			if status.LastSuccess != nil && (providers.LastSuccess == nil || status.LastSuccess.After(*providers.LastSuccess)) {
+				providers.LastSuccess = status.LastSuccess
+			}
+		}
+		details.Providers = providers
+	}
+
+	degraded := false
+	if h.sources.Scheduler != nil {
+		for _, loop := range h.sources.Scheduler.Statuses() {
+			health := loopHealth{Name: loop.Name, LastRunAt: loop.LastRunAt, NextRunAt: loop.NextRunAt}
+			if loop.NextRunAt != nil && now.After(*loop.NextRunAt) {
+				lag := now.Sub(*loop.NextRunAt)
+				health.LagSeconds = lag.Seconds()
+				if interval, err := time.ParseDuration(loop.Interval); err == nil && lag > interval {
+					degraded = true
+				}
+			}
+			details.Scheduler = append(details.Scheduler, health)
+		}
+	}
+
+	code := fiber.StatusOK
+	switch {
+	case h.draining.Load():
+		details.Status = "DRAINING"
+		code = fiber.StatusServiceUnavailable
+	case !ready:
+		details.Status = "DOWN"
+		code = fiber.StatusServiceUnavailable
+	case degraded:
+		details.Status = "DEGRADED"
+	}
+	return c.Status(code).JSON(details)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"currency-exchange/internals/adapter/cache"
+	"currency-exchange/internals/adapter/cache/schedular"
+	"currency-exchange/internals/adapter/exchangerateapi"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReadinessChecker struct {
+	name string
+	err  error
+}
+
+func (m *mockReadinessChecker) Name() string { return m.name }
+func (m *mockReadinessChecker) Ready() error { return m.err }
+
+func setupHealthTestApp(checkers ...ReadinessChecker) *fiber.App {
+	app := fiber.New()
+	h := NewHealthHandler(HealthSources{}, checkers...)
+	app.Get("/health", h.Health)
+	app.Get("/readyz", h.Readiness)
+	return app
+}
+
+func TestHealth_AlwaysUp(t *testing.T) {
+	app := setupHealthTestApp(&mockReadinessChecker{name: "redis", err: errors.New("down")})
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestReadiness_AllUp(t *testing.T) {
+	app := setupHealthTestApp(&mockReadinessChecker{name: "redis"})
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Status string                      `json:"status"`
+		Checks map[string]dependencyStatus `json:"checks"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, "READY", body.Status)
+	assert.Equal(t, "UP", body.Checks["redis"].Status)
+}
+
+func TestReadiness_DependencyDown(t *testing.T) {
+	app := setupHealthTestApp(&mockReadinessChecker{name: "redis", err: errors.New("connection refused")})
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+
+	var body struct {
+		Status string                      `json:"status"`
+		Checks map[string]dependencyStatus `json:"checks"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, "NOT_READY", body.Status)
+	assert.Equal(t, "DOWN", body.Checks["redis"].Status)
+	assert.Equal(t, "connection refused", body.Checks["redis"].Error)
+}
+
+func TestReadiness_Draining(t *testing.T) {
+	app := fiber.New()
+	h := NewHealthHandler(HealthSources{}, &mockReadinessChecker{name: "redis"})
+	app.Get("/readyz", h.Readiness)
+
+	h.StartDraining()
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	var body map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "DRAINING", body["status"])
+}
+
+func TestServing(t *testing.T) {
+	checker := &mockReadinessChecker{name: "redis"}
+	h := NewHealthHandler(HealthSources{}, checker)
+	assert.True(t, h.Serving())
+
+	checker.err = errors.New("connection refused")
+	assert.False(t, h.Serving())
+
+	checker.err = nil
+	h.StartDraining()
+	assert.False(t, h.Serving())
+}
+
+type mockCacheStatusReporter struct {
+	status cache.RedisStatus
+}
+
+func (m *mockCacheStatusReporter) Status() cache.RedisStatus { return m.status }
+
+func TestHealthDetails(t *testing.T) {
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+	ecbSuccess := now.Add(-10 * time.Minute)
+	fixerSuccess := now.Add(-time.Hour)
+	onTime := now.Add(5 * time.Minute)
+	overdue := now.Add(-90 * time.Minute)
+
+	sources := HealthSources{
+		Cache: &mockCacheStatusReporter{status: cache.RedisStatus{Up: true, LatencyMs: 0.8, LastChecked: now}},
+		Providers: &mockProviderStatusReporter{statuses: []exchangerateapi.ProviderStatus{
+			{Name: "ecb", LastSuccess: &ecbSuccess},
+			{Name: "fixer", LastSuccess: &fixerSuccess},
+			{Name: "oxr"},
+		}},
+		Scheduler: &mockSchedulerStatusReporter{statuses: []schedular.LoopStatus{
+			{Name: "Background refresh", Interval: "1h0m0s", NextRunAt: &onTime},
+			{Name: "Historical refresh", Interval: "1h0m0s", NextRunAt: &overdue},
+		}},
+	}
+	h := NewHealthHandler(sources, &mockReadinessChecker{name: "redis"})
+	h.startedAt = now.Add(-2 * time.Hour)
+	h.now = func() time.Time { return now }
+	app := fiber.New()
+	app.Get("/health/details", h.Details)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health/details", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var details healthDetails
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+	assert.Equal(t, "DEGRADED", details.Status)
+	assert.Equal(t, int64(7200), details.UptimeSeconds)
+	assert.Equal(t, "UP", details.Checks["redis"].Status)
+	assert.Equal(t, &cacheHealth{Up: true, LatencyMs: 0.8, LastChecked: now}, details.Cache)
+	assert.Equal(t, ecbSuccess, *details.Providers.LastSuccess)
+	assert.Contains(t, details.Providers.LastSuccessBy, "oxr")
+	assert.Nil(t, details.Providers.LastSuccessBy["oxr"])
+	assert.Equal(t, 0.0, details.Scheduler[0].LagSeconds)
+	assert.Equal(t, 5400.0, details.Scheduler[1].LagSeconds)
+}
+
+func TestHealthDetails_Down(t *testing.T) {
+	h := NewHealthHandler(HealthSources{}, &mockReadinessChecker{name: "redis", err: errors.New("connection refused")})
+	app := fiber.New()
+	app.Get("/health/details", h.Details)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health/details", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+
+	var details map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+	assert.Equal(t, "DOWN", details["status"])
+	assert.NotContains(t, details, "cache")
+}
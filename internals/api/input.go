@@ -0,0 +1,133 @@
+package api
+
+import (
+	"currency-exchange/internals/core/domain"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Codes of the input errors clients may want to tell apart from a generic "Bad Request".
+const (
+	MalformedCurrencyCode = "Malformed Currency"
+	TooManyCurrenciesCode = "Too Many Currencies"
+	InvalidAmountCode     = "Invalid Amount"
+	QueryTooLongCode      = "Query Too Long"
+	// A currency asked for on a day it wasn't in use, e.g. the latest rates of HRK.
+	CurrencyRetiredCode       = "Currency Retired"
+	CurrencyNotIntroducedCode = "Currency Not Introduced"
+)
+
+// maxSymbols bounds how many currencies a `symbol` list may name. Every endpoint takes a
+// single target for now.
+const maxSymbols = 1
+
+// maxCurrencyParamLength is how much of a bad currency parameter is echoed in the error.
+const maxCurrencyParamLength = 16
+
+// MaxQueryLength rejects requests whose query string is longer than max bytes with a 414,
+// before any of it is parsed. A max of 0 disables the check.
+func MaxQueryLength(max int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if max > 0 && len(c.Request().URI().QueryString()) > max {
+			return &CodedError{
+				Status:  fiber.StatusRequestURITooLong,
+				Code:    QueryTooLongCode,
+				Message: fmt.Sprintf("query string must be at most %d bytes", max),
+			}
+		}
+		return c.Next()
+	}
+}
+
+// parseCurrency checks that value, the upper-cased query parameter name, looks like a
+// currency code, so malformed input never reaches the service layer.
+func parseCurrency(name, value string) (domain.Currency, error) {
+	currency := domain.Currency(value)
+	if !currency.WellFormed() {
+		return "", &CodedError{
+			Status:  fiber.StatusBadRequest,
+			Code:    MalformedCurrencyCode,
+			Message: fmt.Sprintf("`%s` must be a currency code of 3 to 5 letters, got %q", name, truncate(value, maxCurrencyParamLength)),
+		}
+	}
+	return currency, nil
+}
+
+// parseCountry checks that value, the upper-cased query parameter name, looks like an
+// ISO 3166-1 alpha-2 country code.
+func parseCountry(name, value string) (string, error) {
+	if len(value) != 2 || value[0] < 'A' || value[0] > 'Z' || value[1] < 'A' || value[1] > 'Z' {
+		return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("`%s` must be an ISO 3166-1 alpha-2 country code such as IN, got %q", name, truncate(value, maxCurrencyParamLength)))
+	}
+	return value, nil
+}
+
+// parseCurrencyList splits the comma separated query parameter name into at most max
+// currency codes.
+func parseCurrencyList(name, value string, max int) ([]domain.Currency, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) > max {
+		return nil, &CodedError{
+			Status:  fiber.StatusBadRequest,
+			Code:    TooManyCurrenciesCode,
+			Message: fmt.Sprintf("`%s` lists %d currencies, the limit is %d", name, len(parts), max),
+		}
+	}
+	currencies := make([]domain.Currency, 0, len(parts))
+	for _, part := range parts {
+		currency, err := parseCurrency(name, part)
+		if err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies, nil
+}
+
+// parseAmount accepts finite positive decimal amounts; strconv alone would also take NaN,
+// infinities and hex floats. The amount is kept as the decimal it was written as.
+func parseAmount(value string) (decimal.Decimal, error) {
+	invalid := &CodedError{
+		Status:  fiber.StatusBadRequest,
+		Code:    InvalidAmountCode,
+		Message: "amount must be a non-zero positive number",
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) || strings.ContainsAny(value, "xXpP") {
+		return decimal.Decimal{}, invalid
+	}
+	exact, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Decimal{}, invalid
+	}
+	return exact, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// requestPair returns the "BASE/TARGET" currency pair of a rates request, or "" when it has
+// no well formed one. It reads the parameters the endpoint's handler reads: from and to for
+// /convert, base and symbol for the others.
+func requestPair(c *fiber.Ctx) string {
+	baseParam, targetParam := "base", "symbol"
+	if path.Base(c.Path()) == "convert" {
+		baseParam, targetParam = "from", "to"
+	}
+	base := domain.Currency(strings.ToUpper(c.Query(baseParam)))
+	target := domain.Currency(strings.ToUpper(c.Query(targetParam)))
+	if !base.WellFormed() || !target.WellFormed() {
+		return ""
+	}
+	return string(base) + "/" + string(target)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func errorCode(t *testing.T, app *fiber.App, target string) (int, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	assert.NoError(t, err)
+	var body ErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Error.Code
+}
+
+func TestInputValidation(t *testing.T) {
+	// The service accepts every currency, so only the handler's own checks can reject them.
+	app := setupTestApp(&MockRateService{})
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+	}{
+		{"malformed base", "/v1/latest?base=US$&symbol=INR", 400, MalformedCurrencyCode},
+		{"digits in symbol", "/v1/latest?base=USD&symbol=IN1", 400, MalformedCurrencyCode},
+		{"overlong code", "/v1/convert?from=USDOLLAR&to=INR&amount=1", 400, MalformedCurrencyCode},
+		{"too many symbols", "/v1/historical?base=USD&symbol=INR,EUR&startDate=2024-05-01", 400, TooManyCurrenciesCode},
+		{"empty list entry", "/v1/latest?base=USD&symbol=INR,", 400, TooManyCurrenciesCode},
+		{"NaN amount", "/v1/convert?from=USD&to=INR&amount=NaN", 400, InvalidAmountCode},
+		{"infinite amount", "/v1/convert?from=USD&to=INR&amount=Inf", 400, InvalidAmountCode},
+		{"hex amount", "/v1/convert?from=USD&to=INR&amount=0x1p4", 400, InvalidAmountCode},
+		{"crypto ticker", "/v1/convert?from=USDT&to=inr&amount=1.5", 200, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorCode(t, app, tt.target)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestMaxQueryLength(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MaxQueryLength(32))
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	status, _ := errorCode(t, app, "/v1/latest?base=USD&symbol=INR")
+	assert.Equal(t, 200, status)
+	status, code := errorCode(t, app, "/v1/latest?base=USD&symbol=INR&pad="+strings.Repeat("x", 32))
+	assert.Equal(t, 414, status)
+	assert.Equal(t, QueryTooLongCode, code)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IPNotAllowedCode is the code of the 403 for requests from an IP outside the allowlist or
+// on the denylist.
+const IPNotAllowedCode = "IP Not Allowed"
+
+const clientIPLocal = "client_ip"
+
+// ClientIP works out the IP of the client behind the request. Requests from a trusted proxy
+// have it read from header, e.g. X-Forwarded-For, walking the list from the right and
+// skipping the trusted proxies on the way, so a client can't pose as another IP by sending
+// the header itself. Other requests use the connection's address.
+func ClientIP(trusted []netip.Prefix, header string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(clientIPLocal, resolveClientIP(c, trusted, header))
+		return c.Next()
+	}
+}
+
+func resolveClientIP(c *fiber.Ctx, trusted []netip.Prefix, header string) netip.Addr {
+	ip, _ := netip.AddrFromSlice(c.Context().RemoteIP())
+	ip = ip.Unmap()
+	if !inRanges(trusted, ip) {
+		return ip
+	}
+	hops := strings.Split(c.Get(header), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A mangled entry ends what can be trusted; the last good hop is the client.
+			break
+		}
+		ip = hop.Unmap()
+		if !inRanges(trusted, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// clientIP returns the IP found by ClientIP, or the connection's address when it didn't run.
+func clientIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(clientIPLocal).(netip.Addr); ok {
+		return ip.String()
+	}
+	return c.IP()
+}
+
+// IPFilter turns away clients outside allow, when it is set, or inside deny, before any
+// credentials are looked at. Deny wins over allow, so a range can be allowed save for a few
+// addresses.
+func IPFilter(allow, deny []netip.Prefix) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ip, err := netip.ParseAddr(clientIP(c))
+		if err != nil || inRanges(deny, ip) || (len(allow) > 0 && !inRanges(allow, ip)) {
+			return &CodedError{
+				Status:  fiber.StatusForbidden,
+				Code:    IPNotAllowedCode,
+				Message: "requests from " + clientIP(c) + " are not allowed",
+			}
+		}
+		return c.Next()
+	}
+}
+
+func inRanges(ranges []netip.Prefix, ip netip.Addr) bool {
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func prefixes(values ...string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, v := range values {
+		out = append(out, netip.MustParsePrefix(v))
+	}
+	return out
+}
+
+func TestClientIP(t *testing.T) {
+	// app.Test connects from 0.0.0.0.
+	tests := []struct {
+		name    string
+		trusted []netip.Prefix
+		header  string
+		want    string
+	}{
+		{"untrusted connection ignores the header", prefixes("10.0.0.0/8"), "203.0.113.7", "0.0.0.0"},
+		{"trusted proxy without header", prefixes("0.0.0.0/32"), "", "0.0.0.0"},
+		{"trusted proxy", prefixes("0.0.0.0/32"), "203.0.113.7", "203.0.113.7"},
+		{"spoofed entries left of the client", prefixes("0.0.0.0/32"), "10.0.0.1, 203.0.113.7", "203.0.113.7"},
+		{"chain of trusted proxies", prefixes("0.0.0.0/32", "10.0.0.0/8"), "203.0.113.7, 10.0.0.2, 10.0.0.1", "203.0.113.7"},
+		{"mangled entry", prefixes("0.0.0.0/32", "10.0.0.0/8"), "203.0.113.7, junk, 10.0.0.1", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(ClientIP(tt.trusted, "X-Forwarded-For"))
+			app.Get("/", func(c *fiber.Ctx) error { return c.SendString(clientIP(c)) })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Forwarded-For", tt.header)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []netip.Prefix
+		deny       []netip.Prefix
+		clientIP   string
+		wantStatus int
+	}{
+		{"allowed", prefixes("203.0.113.0/24"), nil, "203.0.113.7", 200},
+		{"outside the allowlist", prefixes("203.0.113.0/24"), nil, "198.51.100.1", 403},
+		{"denied", nil, prefixes("198.51.100.0/24"), "198.51.100.1", 403},
+		{"not denied", nil, prefixes("198.51.100.0/24"), "203.0.113.7", 200},
+		{"deny wins over allow", prefixes("203.0.113.0/24"), prefixes("203.0.113.7/32"), "203.0.113.7", 403},
+		{"ipv6", prefixes("2001:db8::/32"), nil, "2001:db8::1", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Use(ClientIP(prefixes("0.0.0.0/32"), "X-Forwarded-For"))
+			app.Use(IPFilter(tt.allow, tt.deny))
+			app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("X-Forwarded-For", tt.clientIP)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
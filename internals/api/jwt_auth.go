@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/jwtauth"
+	"currency-exchange/internals/logging"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// jwtCallerPrefix keeps JWT subjects apart from API key IDs, so a token can't take on the
+// rate limits, quota, usage or webhooks of a key whose ID matches its subject.
+const jwtCallerPrefix = "jwt:"
+
+// TokenVerifier checks JWT bearer tokens.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (jwtauth.Identity, error)
+}
+
+// JWTAuth authenticates requests carrying an `Authorization: Bearer` token, as an
+// alternative to API keys. The token's subject, prefixed with "jwt:", becomes the caller
+// ID, its role and scopes what it may access, and its tier picks the RATE_LIMIT_TIERS
+// entry. Requests already authenticated by an API key are left alone.
+func JWTAuth(verifier TokenVerifier, logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := authenticatedCaller(c); ok {
+			return c.Next()
+		}
+		scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return c.Next()
+		}
+
+		identity, err := verifier.Verify(c.UserContext(), strings.TrimSpace(token))
+		if errors.Is(err, jwtauth.ErrInvalid) {
+			logging.WithRequest(c.UserContext(), logger).Debug("Rejected bearer token", "error", err)
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid bearer token")
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "bearer tokens can't be verified right now")
+		}
+		setAuthenticated(c, Caller{ID: jwtCallerPrefix + identity.Subject, Scopes: apikey.Grant(identity.Role, identity.Scopes), Tier: identity.Tier})
+		return c.Next()
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/jwtauth"
+	"currency-exchange/internals/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTokenVerifier accepts the tokens in identities.
+type mockTokenVerifier struct {
+	identities map[string]jwtauth.Identity
+	err        error
+}
+
+func (m *mockTokenVerifier) Verify(ctx context.Context, token string) (jwtauth.Identity, error) {
+	if m.err != nil {
+		return jwtauth.Identity{}, m.err
+	}
+	identity, ok := m.identities[token]
+	if !ok {
+		return jwtauth.Identity{}, fmt.Errorf("%w: bad signature", jwtauth.ErrInvalid)
+	}
+	return identity, nil
+}
+
+func setupJWTTestApp(verifier *mockTokenVerifier, limits config.RateLimitConfig) *fiber.App {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	limiter := NewRateLimiter(limits)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Use(JWTAuth(verifier, discardLogger))
+	app.Use(limiter.PerKey())
+	app.Get("/v1/latest", RequireScope(apikey.ScopeRatesRead, true), func(c *fiber.Ctx) error {
+		caller, _ := authenticatedCaller(c)
+		return c.JSON(fiber.Map{"caller": callerID(c), "tier": caller.Tier})
+	})
+	app.Get("/admin/keys", RequireAdmin(""), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func bearerRequest(t *testing.T, app *fiber.App, path, authorization, apiKey string) (int, map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	if authorization != "" {
+		req.Header.Set(fiber.HeaderAuthorization, authorization)
+	}
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var body map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
+
+func TestJWTAuth(t *testing.T) {
+	verifier := &mockTokenVerifier{identities: map[string]jwtauth.Identity{
+		"reader-token": {Subject: "acme", Tier: "gold", Scopes: []string{apikey.ScopeRatesRead}},
+		"admin-token":  {Subject: "ops-bot", Scopes: []string{apikey.ScopeAdmin}},
+		"admin-role":   {Subject: "ops-lead", Role: apikey.RoleAdmin},
+		"reader-role":  {Subject: "initech", Role: apikey.RoleReader},
+	}}
+	app := setupJWTTestApp(verifier, config.RateLimitConfig{})
+
+	status, body := bearerRequest(t, app, "/v1/latest", "Bearer reader-token", "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, map[string]string{"caller": "jwt:acme", "tier": "gold"}, body)
+
+	status, _ = bearerRequest(t, app, "/v1/latest", "bearer forged-token", "")
+	assert.Equal(t, 401, status)
+
+	status, _ = bearerRequest(t, app, "/v1/latest", "Bearer admin-token", "")
+	assert.Equal(t, 403, status)
+	status, _ = bearerRequest(t, app, "/admin/keys", "Bearer admin-token", "")
+	assert.Equal(t, 200, status)
+	status, _ = bearerRequest(t, app, "/admin/keys", "Bearer reader-token", "")
+	assert.Equal(t, 401, status)
+
+	// Roles grant their scopes: admin reaches both groups, reader only /v1.
+	status, _ = bearerRequest(t, app, "/v1/latest", "Bearer admin-role", "")
+	assert.Equal(t, 200, status)
+	status, _ = bearerRequest(t, app, "/admin/keys", "Bearer admin-role", "")
+	assert.Equal(t, 200, status)
+	status, _ = bearerRequest(t, app, "/v1/latest", "Bearer reader-role", "")
+	assert.Equal(t, 200, status)
+	status, _ = bearerRequest(t, app, "/admin/keys", "Bearer reader-role", "")
+	assert.Equal(t, 401, status)
+
+	// Other schemes are not ours to judge, and an API key wins over a token.
+	status, _ = bearerRequest(t, app, "/v1/latest", "Basic dXNlcjpwYXNz", "")
+	assert.Equal(t, 401, status)
+	status, body = bearerRequest(t, app, "/v1/latest", "Bearer forged-token", "cx_reader")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "partner-a", body["caller"])
+}
+
+func TestJWTAuth_VerifierUnavailable(t *testing.T) {
+	app := setupJWTTestApp(&mockTokenVerifier{err: errors.New("JWKS unreachable")}, config.RateLimitConfig{})
+
+	status, _ := bearerRequest(t, app, "/v1/latest", "Bearer reader-token", "")
+	assert.Equal(t, 503, status)
+}
+
+func TestJWTAuth_TierLimits(t *testing.T) {
+	verifier := &mockTokenVerifier{identities: map[string]jwtauth.Identity{
+		"gold-token":  {Subject: "acme", Tier: "gold", Scopes: []string{apikey.ScopeRatesRead}},
+		"basic-token": {Subject: "initech", Scopes: []string{apikey.ScopeRatesRead}},
+	}}
+	app := setupJWTTestApp(verifier, config.RateLimitConfig{
+		PerKey: config.Limit{RPS: 0.001, Burst: 1},
+		Tiers:  map[string]config.Limit{"gold": {RPS: 0.001, Burst: 3}},
+	})
+
+	var gold, basic []int
+	for i := 0; i < 4; i++ {
+		status, _ := bearerRequest(t, app, "/v1/latest", "Bearer gold-token", "")
+		gold = append(gold, status)
+		status, _ = bearerRequest(t, app, "/v1/latest", "Bearer basic-token", "")
+		basic = append(basic, status)
+	}
+	assert.Equal(t, []int{200, 200, 200, 429}, gold)
+	assert.Equal(t, []int{200, 429, 429, 429}, basic)
+}
+
+func TestJWTAuth_SubjectDoesNotShareKeyLimits(t *testing.T) {
+	verifier := &mockTokenVerifier{identities: map[string]jwtauth.Identity{
+		"lookalike-token": {Subject: "partner-a", Scopes: []string{apikey.ScopeRatesRead}},
+	}}
+	app := setupJWTTestApp(verifier, config.RateLimitConfig{
+		PerKey: config.Limit{RPS: 0.001, Burst: 1},
+		Keys:   map[string]config.Limit{"partner-a": {RPS: 0.001, Burst: 3}},
+	})
+
+	status, body := bearerRequest(t, app, "/v1/latest", "Bearer lookalike-token", "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "jwt:partner-a", body["caller"])
+	status, _ = bearerRequest(t, app, "/v1/latest", "Bearer lookalike-token", "")
+	assert.Equal(t, 429, status)
+
+	// The key itself keeps its own, larger bucket.
+	for i := 0; i < 3; i++ {
+		status, _ = bearerRequest(t, app, "/v1/latest", "", "cx_reader")
+		assert.Equal(t, 200, status)
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/errorreport"
+	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/logging"
+	"currency-exchange/internals/metrics"
+	"currency-exchange/internals/repository"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+)
+
+const AdminKeyHeader = "X-Admin-Key"
+
+// callerLocal holds the ID of the API key that authenticated the request, for the access log.
+const callerLocal = "caller"
+
+// authLocal holds the Caller of a request authenticated with an API key or a JWT.
+const authLocal = "auth"
+
+// Caller is who made a request, as established by its API key or JWT.
+type Caller struct {
+	ID           string
+	Scopes       []string
+	Tier         string // picks the RATE_LIMIT_TIERS entry; empty for the defaults
+	Restrictions apikey.Restrictions
+}
+
+// HasScope reports whether the caller was granted scope.
+func (c Caller) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func setAuthenticated(c *fiber.Ctx, caller Caller) {
+	c.Locals(authLocal, caller)
+	setCaller(c, caller.ID)
+}
+
+func authenticatedCaller(c *fiber.Ctx) (Caller, bool) {
+	caller, ok := c.Locals(authLocal).(Caller)
+	return caller, ok
+}
+
+func setCaller(c *fiber.Ctx, keyID string) {
+	c.Locals(callerLocal, keyID)
+}
+
+func callerID(c *fiber.Ctx) string {
+	id, _ := c.Locals(callerLocal).(string)
+	return id
+}
+
+// bufferedResponseBody returns c's response body, or nothing for a streamed one, e.g. server-sent
+// events: reading it would consume the stream before it reaches the client.
+func bufferedResponseBody(c *fiber.Ctx) []byte {
+	if c.Response().IsBodyStream() {
+		return nil
+	}
+	return c.Response().Body()
+}
+
+// isAdmin accepts an API key or JWT with the admin scope, or the admin key header. An empty
+// configured admin key disables the header.
+func isAdmin(c *fiber.Ctx, adminKey string) bool {
+	if caller, ok := authenticatedCaller(c); ok && caller.HasScope(apikey.ScopeAdmin) {
+		return true
+	}
+	if adminKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.Get(AdminKeyHeader)), []byte(adminKey)) == 1
+}
+
+// setAdminCaller names the caller "admin", unless an API key or JWT already identified it.
+func setAdminCaller(c *fiber.Ctx) {
+	if callerID(c) == "" {
+		setCaller(c, "admin")
+	}
+}
+
+// RequireAdmin rejects requests that carry neither the admin key nor admin credentials.
+func RequireAdmin(adminKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !isAdmin(c, adminKey) {
+			return fiber.NewError(fiber.StatusUnauthorized, "admin credentials required")
+		}
+		setAdminCaller(c)
+		return c.Next()
+	}
+}
+
+// CacheBypass honours `noCache=true` on admin requests, sending them straight to the provider
+// while still refreshing the cache. Intended for debugging suspected cache corruption.
+func CacheBypass(enabled bool, adminKey string, logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Query("noCache") != "true" {
+			return c.Next()
+		}
+		if !enabled {
+			return fiber.NewError(fiber.StatusBadRequest, "cache bypass is disabled on this server")
+		}
+		if !isAdmin(c, adminKey) {
+			return fiber.NewError(fiber.StatusForbidden, "`noCache` is restricted to admin callers")
+		}
+
+		setAdminCaller(c)
+		logging.WithRequest(c.UserContext(), logger).Info("Cache bypass enabled", "path", c.OriginalURL())
+		c.SetUserContext(repository.WithCacheBypass(c.UserContext()))
+		return c.Next()
+	}
+}
+
+const maxRequestIDLength = 128
+
+// validRequestID accepts caller supplied IDs that are short and printable, so they can be
+// echoed back and forwarded to providers without header injection.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestTracing gives every request an X-Request-ID (the caller's, or a fresh one) and a
+// traceparent continuing the caller's trace, and stores both in the user context so provider
+// calls made for the request carry them upstream.
+func RequestTracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(helpers.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(helpers.RequestIDHeader, id)
+
+		ctx := helpers.WithRequestID(c.UserContext(), id)
+		ctx = helpers.WithTraceParent(ctx, helpers.ChildTraceParent(c.Get(helpers.TraceParentHeader)))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// Metrics records the route, status and latency of every request. The route pattern is used
+// rather than the URL so the number of series stays bounded.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+		metrics.ObserveHTTP(c.UserContext(), c.Route().Path, c.Method(), status, time.Since(start))
+		return err
+	}
+}
+
+// AccessLog writes one line per request with its status, response size, latency, caller and
+// cache outcome. Errors are rendered here through the app's error handler, as Fiber's own
+// logger middleware does, so the logged status and size match what the client received.
+//
+// Requests that take at least slowThreshold are logged as "Slow request" at WARN or above,
+// together with the time spent in the cache and in provider calls. A zero slowThreshold
+// turns this off.
+func AccessLog(logger *slog.Logger, slowThreshold time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		c.SetUserContext(repository.WithTiming(repository.WithCacheOutcome(c.UserContext())))
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		duration := time.Since(start)
+		attrs := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"bytes", len(bufferedResponseBody(c)),
+			"duration", duration,
+			"ip", clientIP(c),
+		}
+		if id := callerID(c); id != "" {
+			attrs = append(attrs, "api_key_id", id)
+		}
+		if outcome := repository.CacheOutcome(c.UserContext()); outcome != "" {
+			attrs = append(attrs, "cache", outcome)
+		}
+
+		msg := "Request handled"
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		if slowThreshold > 0 && duration >= slowThreshold {
+			timing := repository.RequestTiming(c.UserContext())
+			msg = "Slow request"
+			level = max(level, slog.LevelWarn)
+			attrs = append(attrs, "threshold", slowThreshold, "cache_time", timing.Cache, "provider_time", timing.Provider)
+		}
+		logging.WithRequest(c.UserContext(), logger).Log(c.UserContext(), level, msg, attrs...)
+		return nil
+	}
+}
+
+// reportTimeout bounds how long forwarding a panic to the error tracker may take.
+const reportTimeout = 10 * time.Second
+
+// Recover turns a panic in a later handler into a plain 500 response instead of a crashed
+// process. The panic is logged with its stack trace and, when reporter is not nil, forwarded
+// to the error tracker in the background.
+func Recover(logger *slog.Logger, reporter errorreport.Reporter) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// Fiber reuses its buffers once the handler returns, so copy what the report needs.
+			report := errorreport.Report{
+				Message:   fmt.Sprint(recovered),
+				Stack:     string(debug.Stack()),
+				RequestID: utils.CopyString(helpers.RequestID(c.UserContext())),
+				Method:    utils.CopyString(c.Method()),
+				Path:      utils.CopyString(c.Path()),
+				Time:      time.Now(),
+			}
+			log := logging.WithRequest(c.UserContext(), logger)
+			log.Error("Recovered from panic", "panic", report.Message, "method", report.Method, "path", report.Path, "stack", report.Stack)
+			if reporter != nil {
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+					defer cancel()
+					if err := reporter.Report(ctx, report); err != nil {
+						log.Warn("Could not forward panic to the error tracker", "error", err)
+					}
+				}()
+			}
+			err = fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+		}()
+		return c.Next()
+	}
+}
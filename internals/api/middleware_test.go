@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/adapter/errorreport"
+	"currency-exchange/internals/helpers"
+	"currency-exchange/internals/metrics"
+	"currency-exchange/internals/repository"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCacheBypassTestApp(enabled bool, adminKey string, bypassed *bool) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(CacheBypass(enabled, adminKey, discardLogger))
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		*bypassed = repository.CacheBypassed(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestCacheBypass_NoParamPassesThrough(t *testing.T) {
+	var bypassed bool
+	app := setupCacheBypassTestApp(false, "", &bypassed)
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/latest", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.False(t, bypassed)
+}
+
+func TestCacheBypass_Disabled(t *testing.T) {
+	var bypassed bool
+	app := setupCacheBypassTestApp(false, "secret", &bypassed)
+	req := httptest.NewRequest("GET", "/v1/latest?noCache=true", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, _ := app.Test(req)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestCacheBypass_NonAdminForbidden(t *testing.T) {
+	var bypassed bool
+	app := setupCacheBypassTestApp(true, "secret", &bypassed)
+	req := httptest.NewRequest("GET", "/v1/latest?noCache=true", nil)
+	req.Header.Set(AdminKeyHeader, "wrong")
+	resp, _ := app.Test(req)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestCacheBypass_EmptyAdminKeyForbidden(t *testing.T) {
+	var bypassed bool
+	app := setupCacheBypassTestApp(true, "", &bypassed)
+	resp, _ := app.Test(httptest.NewRequest("GET", "/v1/latest?noCache=true", nil))
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestCacheBypass_AdminAllowed(t *testing.T) {
+	var bypassed bool
+	app := setupCacheBypassTestApp(true, "secret", &bypassed)
+	req := httptest.NewRequest("GET", "/v1/latest?noCache=true", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, bypassed)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequireAdmin("secret"))
+	app.Get("/admin/providers", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/providers", nil))
+	assert.Equal(t, 401, resp.StatusCode)
+
+	req := httptest.NewRequest("GET", "/admin/providers", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	resp, _ = app.Test(req)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func setupTracingTestApp(requestID, traceParent *string) *fiber.App {
+	app := fiber.New()
+	app.Use(RequestTracing())
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		*requestID = helpers.RequestID(c.UserContext())
+		*traceParent = helpers.TraceParent(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRequestTracing_KeepsCallerRequestID(t *testing.T) {
+	var requestID, traceParent string
+	app := setupTracingTestApp(&requestID, &traceParent)
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(helpers.RequestIDHeader, "abc-123")
+	req.Header.Set(helpers.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-123", resp.Header.Get(helpers.RequestIDHeader))
+	assert.Equal(t, "abc-123", requestID)
+	assert.Contains(t, traceParent, "4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+func TestRequestTracing_GeneratesRequestID(t *testing.T) {
+	var requestID, traceParent string
+	app := setupTracingTestApp(&requestID, &traceParent)
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(helpers.RequestIDHeader, "bad id\twith spaces")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, requestID)
+	assert.NotEqual(t, "bad id\twith spaces", requestID)
+	assert.Equal(t, requestID, resp.Header.Get(helpers.RequestIDHeader))
+	assert.NotEmpty(t, traceParent)
+}
+
+func TestMetrics_RecordsRoutePatternAndErrorStatus(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(Metrics())
+	app.Get("/admin/items/:id", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusNotFound, "no such item")
+	})
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/items/42", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `http_requests_total{endpoint="/admin/items/:id",method="GET",status="404"} 1`)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequestTracing())
+	app.Use(AccessLog(slog.New(slog.NewJSONHandler(&buf, nil)), 0))
+	app.Get("/admin/providers", RequireAdmin("secret"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest("GET", "/admin/providers", nil)
+	req.Header.Set(AdminKeyHeader, "secret")
+	req.Header.Set(helpers.RequestIDHeader, "req-1")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/providers", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var ok, denied map[string]any
+	assert.NoError(t, json.Unmarshal(lines[0], &ok))
+	assert.NoError(t, json.Unmarshal(lines[1], &denied))
+	assert.Equal(t, "INFO", ok["level"])
+	assert.Equal(t, float64(200), ok["status"])
+	assert.Equal(t, float64(2), ok["bytes"])
+	assert.Equal(t, "admin", ok["api_key_id"])
+	assert.Equal(t, "req-1", ok["request_id"])
+	assert.Contains(t, ok, "duration")
+	assert.Equal(t, "WARN", denied["level"])
+	assert.Equal(t, float64(401), denied["status"])
+	assert.NotContains(t, denied, "api_key_id")
+	assert.Greater(t, denied["bytes"], float64(0))
+}
+
+func TestAccessLog_SlowRequest(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(AccessLog(slog.New(slog.NewJSONHandler(&buf, nil)), 10*time.Millisecond))
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.SendString("ok")
+	})
+
+	for _, path := range []string{"/fast", "/slow"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		assert.NoError(t, err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var fast, slow map[string]any
+	assert.NoError(t, json.Unmarshal(lines[0], &fast))
+	assert.NoError(t, json.Unmarshal(lines[1], &slow))
+	assert.Equal(t, "Request handled", fast["msg"])
+	assert.NotContains(t, fast, "provider_time")
+	assert.Equal(t, "Slow request", slow["msg"])
+	assert.Equal(t, "WARN", slow["level"])
+	assert.Equal(t, float64(10*time.Millisecond), slow["threshold"])
+	assert.Equal(t, float64(0), slow["cache_time"])
+	assert.Equal(t, float64(0), slow["provider_time"])
+}
+
+type recordingReporter struct {
+	reports chan errorreport.Report
+}
+
+func (r *recordingReporter) Report(ctx context.Context, report errorreport.Report) error {
+	r.reports <- report
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	reporter := &recordingReporter{reports: make(chan errorreport.Report, 1)}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequestTracing())
+	app.Use(Recover(discardLogger, reporter))
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		var rates map[string]float64
+		rates["USD"] = 1
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	req.Header.Set(helpers.RequestIDHeader, "req-1")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error":{"code":"Internal Server Error","message":"Internal Server Error"}}`, string(body))
+
+	select {
+	case report := <-reporter.reports:
+		assert.Equal(t, "assignment to entry in nil map", report.Message)
+		assert.Equal(t, "req-1", report.RequestID)
+		assert.Equal(t, "/v1/latest", report.Path)
+		assert.Contains(t, report.Stack, "middleware_test.go")
+	case <-time.After(time.Second):
+		t.Fatal("panic was not reported")
+	}
+}
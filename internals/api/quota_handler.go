@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/quota"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/logging"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// QuotaExceededCode tells a client over its monthly quota apart from one that is just going
+// too fast, which gets the plain "Too Many Requests" code and can retry in a second.
+const QuotaExceededCode = "Quota Exceeded"
+
+const (
+	QuotaLimitHeader     = "X-Quota-Limit"
+	QuotaRemainingHeader = "X-Quota-Remaining"
+)
+
+// QuotaCounter counts requests per API key per calendar month.
+type QuotaCounter interface {
+	Increment(ctx context.Context, keyID string, now time.Time) (int64, error)
+	Decrement(ctx context.Context, keyID string, now time.Time) error
+	Usage(ctx context.Context, keyID string, now time.Time) (int64, error)
+}
+
+// QuotaHandler counts the requests of every API key and, when rate limits are enabled,
+// rejects those over the key's monthly quota.
+type QuotaHandler struct {
+	counter QuotaCounter
+	limits  config.RateLimitConfig
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewQuotaHandler(counter QuotaCounter, limits config.RateLimitConfig, logger *slog.Logger) *QuotaHandler {
+	return &QuotaHandler{counter: counter, limits: limits, logger: logger, now: time.Now}
+}
+
+// quotaFor returns the monthly quota of caller, 0 when it has none to enforce.
+func (h *QuotaHandler) quotaFor(caller Caller) int64 {
+	if !h.limits.Enabled {
+		return 0
+	}
+	return h.limits.ForCaller(caller.ID, caller.Tier).MonthlyQuota
+}
+
+// quotaCharge is the outcome of counting a request against its caller's quota.
+type quotaCharge struct {
+	limit     int64 // 0 when the caller has no quota to enforce
+	remaining int64
+	resetsAt  time.Time
+}
+
+// charge counts one request of caller. A request over quota is rejected and not counted, so
+// usage never reads more than the quota. If the count can't be kept, e.g. while Redis is down,
+// requests are let through rather than failing.
+func (h *QuotaHandler) charge(ctx context.Context, caller Caller) (quotaCharge, error) {
+	now := h.now()
+	count, err := h.counter.Increment(ctx, caller.ID, now)
+	if err != nil {
+		logging.WithRequest(ctx, h.logger).Warn("Could not count request against quota", "key_id", caller.ID, "error", err)
+		return quotaCharge{}, nil
+	}
+	limit := h.quotaFor(caller)
+	if limit == 0 {
+		return quotaCharge{}, nil
+	}
+	_, resetsAt := quota.Period(now)
+	if count > limit {
+		if err := h.counter.Decrement(ctx, caller.ID, now); err != nil {
+			logging.WithRequest(ctx, h.logger).Warn("Could not uncount rejected request", "key_id", caller.ID, "error", err)
+		}
+		return quotaCharge{limit: limit, resetsAt: resetsAt}, &CodedError{
+			Status:  fiber.StatusTooManyRequests,
+			Code:    QuotaExceededCode,
+			Message: "monthly quota of " + strconv.FormatInt(limit, 10) + " requests exceeded, it resets on " + resetsAt.Format(time.RFC3339),
+		}
+	}
+	return quotaCharge{limit: limit, remaining: limit - count, resetsAt: resetsAt}, nil
+}
+
+// Enforce counts the request against its API key, rejecting it when it is over quota.
+func (h *QuotaHandler) Enforce() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := authenticatedCaller(c)
+		if !ok {
+			return c.Next()
+		}
+		charged, err := h.charge(c.UserContext(), key)
+		if charged.limit == 0 {
+			return c.Next()
+		}
+		c.Set(QuotaLimitHeader, strconv.FormatInt(charged.limit, 10))
+		c.Set(QuotaRemainingHeader, strconv.FormatInt(charged.remaining, 10))
+		if err != nil {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(charged.resetsAt.Sub(h.now()).Seconds())+1))
+			return err
+		}
+		return c.Next()
+	}
+}
+
+type usageResponse struct {
+	KeyID     string    `json:"keyId"`
+	Month     string    `json:"month"`
+	Used      int64     `json:"used"`
+	Quota     *int64    `json:"quota"`
+	Remaining *int64    `json:"remaining"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// GetUsage serves how many requests the caller's API key made this month and how many it
+// has left. Quota and remaining are null for keys without a quota.
+func (h *QuotaHandler) GetUsage(c *fiber.Ctx) error {
+	key, ok := authenticatedCaller(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "API key required")
+	}
+	now := h.now()
+	used, err := h.counter.Usage(c.UserContext(), key.ID, now)
+	if err != nil {
+		return err
+	}
+
+	month, resetsAt := quota.Period(now)
+	resp := usageResponse{KeyID: key.ID, Month: month, Used: used, ResetsAt: resetsAt}
+	if limit := h.quotaFor(key); limit > 0 {
+		remaining := max(limit-used, 0)
+		resp.Quota, resp.Remaining = &limit, &remaining
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/config"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockQuotaCounter struct {
+	counts map[string]int64
+	err    error
+}
+
+func (m *mockQuotaCounter) Increment(ctx context.Context, keyID string, now time.Time) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.counts[keyID]++
+	return m.counts[keyID], nil
+}
+
+func (m *mockQuotaCounter) Decrement(ctx context.Context, keyID string, now time.Time) error {
+	m.counts[keyID]--
+	return nil
+}
+
+func (m *mockQuotaCounter) Usage(ctx context.Context, keyID string, now time.Time) (int64, error) {
+	return m.counts[keyID], m.err
+}
+
+func setupQuotaTestApp(counter *mockQuotaCounter, limits config.RateLimitConfig) *fiber.App {
+	store := newMockAPIKeyStore()
+	store.add("cx_a", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	store.add("cx_b", apikey.Key{ID: "partner-b", Scopes: []string{apikey.ScopeRatesRead}})
+	h := NewQuotaHandler(counter, limits, discardLogger)
+	h.now = func() time.Time { return time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC) }
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	v1 := app.Group("/v1")
+	v1.Get("/account/usage", h.GetUsage)
+	rates := v1.Group("", h.Enforce())
+	rates.Get("/latest", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func quotaRequest(t *testing.T, app *fiber.App, path, key string) (int, map[string]any, fiber.Map) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	headers := fiber.Map{
+		QuotaLimitHeader:       resp.Header.Get(QuotaLimitHeader),
+		QuotaRemainingHeader:   resp.Header.Get(QuotaRemainingHeader),
+		fiber.HeaderRetryAfter: resp.Header.Get(fiber.HeaderRetryAfter),
+	}
+	return resp.StatusCode, body, headers
+}
+
+func TestQuota_RejectsOverQuota(t *testing.T) {
+	counter := &mockQuotaCounter{counts: map[string]int64{}}
+	app := setupQuotaTestApp(counter, config.RateLimitConfig{
+		Enabled: true,
+		PerKey:  config.Limit{MonthlyQuota: 2},
+		Keys:    map[string]config.Limit{"partner-b": {}},
+	})
+
+	status, _, headers := quotaRequest(t, app, "/v1/latest", "cx_a")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "2", headers[QuotaLimitHeader])
+	assert.Equal(t, "1", headers[QuotaRemainingHeader])
+	status, _, _ = quotaRequest(t, app, "/v1/latest", "cx_a")
+	assert.Equal(t, 200, status)
+
+	status, body, headers := quotaRequest(t, app, "/v1/latest", "cx_a")
+	assert.Equal(t, 429, status)
+	assert.Equal(t, QuotaExceededCode, body["error"].(map[string]any)["code"])
+	assert.Equal(t, "0", headers[QuotaRemainingHeader])
+	// Retry once the month is over: April 14th 10:00 to May 1st is 16 days and 14 hours.
+	assert.Equal(t, "1432801", headers[fiber.HeaderRetryAfter])
+	assert.Equal(t, int64(2), counter.counts["partner-a"])
+
+	// partner-b has no quota and requests without a key aren't counted.
+	for i := 0; i < 3; i++ {
+		status, _, _ = quotaRequest(t, app, "/v1/latest", "cx_b")
+		assert.Equal(t, 200, status)
+		status, _, _ = quotaRequest(t, app, "/v1/latest", "")
+		assert.Equal(t, 200, status)
+	}
+	assert.Equal(t, int64(3), counter.counts["partner-b"])
+	assert.Len(t, counter.counts, 2)
+}
+
+func TestQuota_NotEnforcedWithoutRateLimits(t *testing.T) {
+	counter := &mockQuotaCounter{counts: map[string]int64{}}
+	app := setupQuotaTestApp(counter, config.RateLimitConfig{PerKey: config.Limit{MonthlyQuota: 1}})
+
+	for i := 0; i < 3; i++ {
+		status, _, _ := quotaRequest(t, app, "/v1/latest", "cx_a")
+		assert.Equal(t, 200, status)
+	}
+	assert.Equal(t, int64(3), counter.counts["partner-a"])
+}
+
+func TestQuota_LetsRequestsThroughWhenCounterFails(t *testing.T) {
+	counter := &mockQuotaCounter{counts: map[string]int64{}, err: errors.New("redis down")}
+	app := setupQuotaTestApp(counter, config.RateLimitConfig{Enabled: true, PerKey: config.Limit{MonthlyQuota: 1}})
+
+	status, _, _ := quotaRequest(t, app, "/v1/latest", "cx_a")
+	assert.Equal(t, 200, status)
+}
+
+func TestGetUsage(t *testing.T) {
+	counter := &mockQuotaCounter{counts: map[string]int64{"partner-a": 5}}
+	app := setupQuotaTestApp(counter, config.RateLimitConfig{
+		Enabled: true,
+		PerKey:  config.Limit{MonthlyQuota: 3},
+		Keys:    map[string]config.Limit{"partner-b": {}},
+	})
+
+	// Usage can be checked over quota, and checking it doesn't count.
+	status, body, _ := quotaRequest(t, app, "/v1/account/usage", "cx_a")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, map[string]any{
+		"keyId":     "partner-a",
+		"month":     "2025-04",
+		"used":      float64(5),
+		"quota":     float64(3),
+		"remaining": float64(0),
+		"resetsAt":  "2025-05-01T00:00:00Z",
+	}, body)
+	assert.Equal(t, int64(5), counter.counts["partner-a"])
+
+	_, body, _ = quotaRequest(t, app, "/v1/account/usage", "cx_b")
+	assert.Nil(t, body["quota"])
+	assert.Nil(t, body["remaining"])
+
+	status, _, _ = quotaRequest(t, app, "/v1/account/usage", "")
+	assert.Equal(t, 401, status)
+}
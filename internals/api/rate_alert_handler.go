@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/ratealert"
+	"currency-exchange/internals/logging"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateAlertStore keeps the rate alert rules operators are notified by.
+type RateAlertStore interface {
+	Create(ctx context.Context, newRule ratealert.NewRule) (ratealert.Rule, error)
+	List(ctx context.Context) ([]ratealert.Rule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// RateAlertHandler serves /admin/alerts, where operators add rules that alert them through the
+// notification channel when a pair's rate crosses a threshold or moves too far in a day.
+type RateAlertHandler struct {
+	handler         *Handler
+	store           RateAlertStore
+	defaultCooldown time.Duration
+	logger          *slog.Logger
+}
+
+// NewRateAlertHandler gives rules created without a cooldown defaultCooldown.
+func NewRateAlertHandler(handler *Handler, store RateAlertStore, defaultCooldown time.Duration, logger *slog.Logger) *RateAlertHandler {
+	return &RateAlertHandler{handler: handler, store: store, defaultCooldown: defaultCooldown, logger: logger}
+}
+
+type createRateAlertRequest struct {
+	Name      string  `json:"name"`
+	Pair      string  `json:"pair"`
+	Condition string  `json:"condition"`
+	Threshold float64 `json:"threshold"`
+	Cooldown  string  `json:"cooldown"`
+}
+
+// Create adds an armed rule. The body is {"name": "...", "pair": "USD-INR", "condition":
+// "above", "threshold": 84}, where "condition" is above or below a rate, or daily_change by a
+// percentage, with an optional "cooldown" such as "30m" before a resolved rule is armed again.
+func (h *RateAlertHandler) Create(c *fiber.Ctx) error {
+	var req createRateAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"name\": \"...\", \"pair\": \"USD-INR\", \"condition\": \"above\", \"threshold\": 84}")
+	}
+	pair, err := parseStreamPair(req.Pair)
+	if err != nil {
+		return err
+	}
+	if err := h.handler.checkCurrencies(pair.base, pair.target); err != nil {
+		return err
+	}
+	cooldown := h.defaultCooldown
+	if req.Cooldown != "" {
+		cooldown, err = time.ParseDuration(req.Cooldown)
+		if err != nil || cooldown < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "cooldown must be a duration such as 30m")
+		}
+	}
+	newRule := ratealert.NewRule{
+		Name:      strings.TrimSpace(req.Name),
+		Base:      pair.base,
+		Target:    pair.target,
+		Condition: strings.ToLower(req.Condition),
+		Threshold: req.Threshold,
+		Cooldown:  cooldown,
+	}
+	if err := newRule.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	setAuditParam(c, "name", newRule.Name)
+	setAuditParam(c, "pair", string(pair.base)+"-"+string(pair.target))
+	setAuditParam(c, "condition", newRule.Condition)
+	setAuditParam(c, "threshold", strconv.FormatFloat(newRule.Threshold, 'f', -1, 64))
+	rule, err := h.store.Create(c.UserContext(), newRule)
+	if err != nil {
+		return err
+	}
+	setAuditParam(c, "id", rule.ID)
+	logging.WithRequest(c.UserContext(), h.logger).Info("Rate alert created via admin API", "rule_id", rule.ID, "name", rule.Name, "condition", rule.Condition, "threshold", rule.Threshold)
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// List serves every rule with its state.
+func (h *RateAlertHandler) List(c *fiber.Ctx) error {
+	rules, err := h.store.List(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"alerts": rules})
+}
+
+func (h *RateAlertHandler) Delete(c *fiber.Ctx) error {
+	err := h.store.Delete(c.UserContext(), c.Params("id"))
+	if errors.Is(err, ratealert.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "alert rule not found")
+	}
+	if err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Rate alert deleted via admin API", "rule_id", c.Params("id"))
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/ratealert"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRateAlertStore struct {
+	rules []ratealert.Rule
+}
+
+func (m *mockRateAlertStore) Create(ctx context.Context, newRule ratealert.NewRule) (ratealert.Rule, error) {
+	rule := ratealert.Rule{ID: "ra-1", Name: newRule.Name, Base: newRule.Base, Target: newRule.Target, Condition: newRule.Condition,
+		Threshold: newRule.Threshold, CooldownSeconds: int64(newRule.Cooldown / time.Second), State: ratealert.StateArmed}
+	m.rules = append(m.rules, rule)
+	return rule, nil
+}
+
+func (m *mockRateAlertStore) List(ctx context.Context) ([]ratealert.Rule, error) {
+	return m.rules, nil
+}
+
+func (m *mockRateAlertStore) Delete(ctx context.Context, id string) error {
+	for i, rule := range m.rules {
+		if rule.ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return ratealert.ErrNotFound
+}
+
+func TestRateAlerts_CreateListDelete(t *testing.T) {
+	store := &mockRateAlertStore{}
+	h := NewRateAlertHandler(NewHandler(&MockRateService{}), store, time.Hour, discardLogger)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/admin/alerts", h.List)
+	app.Post("/admin/alerts", h.Create)
+	app.Delete("/admin/alerts/:id", h.Delete)
+	request := func(method, path, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		var decoded map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, body := request("POST", "/admin/alerts", `{"name": "rupee watch", "pair": "usd-inr", "condition": "above", "threshold": 84}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, "armed", body["state"])
+	assert.Equal(t, float64(3600), body["cooldownSeconds"])
+
+	status, _ = request("POST", "/admin/alerts", `{"name": "daily", "pair": "USD-INR", "condition": "daily_change", "threshold": 1, "cooldown": "soon"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	status, body = request("POST", "/admin/alerts", `{"name": "daily", "pair": "USD-INR", "condition": "weekly_change", "threshold": 1}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body["error"].(map[string]any)["message"], "unknown condition")
+
+	status, body = request("GET", "/admin/alerts", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Len(t, body["alerts"], 1)
+
+	status, _ = request("DELETE", "/admin/alerts/ra-1", "")
+	assert.Equal(t, fiber.StatusNoContent, status)
+	status, _ = request("DELETE", "/admin/alerts/ra-1", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
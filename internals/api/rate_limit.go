@@ -0,0 +1,180 @@
+package api
+
+import (
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/metrics"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long a per-IP or per-key bucket is kept after its caller's last
+// request. A caller coming back later starts with a full bucket, as it would anyway.
+const idleLimiterTTL = 10 * time.Minute
+
+// limiterSet holds one token bucket per caller.
+type limiterSet struct {
+	mu        sync.Mutex
+	limiters  map[string]*idleLimiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type idleLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newLimiterSet(now func() time.Time) *limiterSet {
+	return &limiterSet{limiters: make(map[string]*idleLimiter), lastSweep: now(), now: now}
+}
+
+// get returns the bucket of id, creating it with limit on first use. Buckets idle for longer
+// than idleLimiterTTL are dropped now and then, so scanners can't grow the set forever.
+func (s *limiterSet) get(id string, limit config.Limit) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= idleLimiterTTL {
+		for key, entry := range s.limiters {
+			if now.Sub(entry.lastSeen) >= idleLimiterTTL {
+				delete(s.limiters, key)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.limiters[id]
+	if !ok {
+		entry = &idleLimiter{limiter: newLimiter(limit)}
+		s.limiters[id] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter
+}
+
+func newLimiter(limit config.Limit) *rate.Limiter {
+	if limit.RPS <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst)
+}
+
+// RateLimiter enforces the inbound request rate limits of this instance. Global and per-IP
+// limits run before authentication, so anonymous scanning is throttled before it costs a
+// Redis lookup; the per-key limit runs once the API key is known.
+type RateLimiter struct {
+	limits config.RateLimitConfig
+	global *rate.Limiter
+	ips    *limiterSet
+	keys   *limiterSet
+	now    func() time.Time
+}
+
+func NewRateLimiter(limits config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		limits: limits,
+		global: newLimiter(limits.Global),
+		ips:    newLimiterSet(time.Now),
+		keys:   newLimiterSet(time.Now),
+		now:    time.Now,
+	}
+}
+
+// Middleware applies the global limit to every request and the per-IP limit to requests
+// without an API key or bearer token. Requests with one only need their IP to have a token
+// left, and use one up if it turns out to be invalid, so guessing keys is throttled like
+// anonymous traffic while valid keys behind a shared IP are left to the per-key limit.
+func (l *RateLimiter) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !l.global.AllowN(l.now(), 1) {
+			return rateLimited(c, "global", l.limits.Global)
+		}
+
+		if l.limits.PerIP.RPS <= 0 {
+			return c.Next()
+		}
+		ip := l.ips.get(clientIP(c), l.limits.PerIP)
+		if !hasCredentials(c) {
+			if !ip.AllowN(l.now(), 1) {
+				return rateLimited(c, "ip", l.limits.PerIP)
+			}
+			return c.Next()
+		}
+		if ip.TokensAt(l.now()) < 1 {
+			return rateLimited(c, "ip", l.limits.PerIP)
+		}
+
+		err := c.Next()
+		var e *fiber.Error
+		if errors.As(err, &e) && e.Code == fiber.StatusUnauthorized {
+			ip.AllowN(l.now(), 1)
+		}
+		return err
+	}
+}
+
+// PerKey applies the limit of the request's API key or JWT subject: its RATE_LIMIT_KEYS
+// entry, that of its tier, or the default. Requests without credentials pass; Middleware has
+// already limited them by IP.
+func (l *RateLimiter) PerKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller, ok := authenticatedCaller(c)
+		if !ok {
+			return c.Next()
+		}
+		limit := l.limits.ForCaller(caller.ID, caller.Tier)
+		if !l.keys.get(caller.ID, limit).AllowN(l.now(), 1) {
+			return rateLimited(c, "key", limit)
+		}
+		return c.Next()
+	}
+}
+
+// AllowCall applies the limits to a call that doesn't go through these middlewares, such as
+// a gRPC stream, sharing their buckets: the global limit, then the caller's per-key limit,
+// or the per-IP one when callerID is empty.
+func (l *RateLimiter) AllowCall(ip, callerID, tier string) bool {
+	if !l.limits.Enabled {
+		return true
+	}
+	now := l.now()
+	if !l.global.AllowN(now, 1) {
+		metrics.ObserveRateLimited("global")
+		return false
+	}
+	if callerID == "" {
+		if l.limits.PerIP.RPS > 0 && !l.ips.get(ip, l.limits.PerIP).AllowN(now, 1) {
+			metrics.ObserveRateLimited("ip")
+			return false
+		}
+		return true
+	}
+	if !l.keys.get(callerID, l.limits.ForCaller(callerID, tier)).AllowN(now, 1) {
+		metrics.ObserveRateLimited("key")
+		return false
+	}
+	return true
+}
+
+func hasCredentials(c *fiber.Ctx) bool {
+	return c.Get(APIKeyHeader) != "" || c.Get(fiber.HeaderAuthorization) != "" || c.Get(SignatureHeader) != ""
+}
+
+// rateLimited rejects the request with a 429, telling the client to retry once the bucket
+// has refilled one token.
+func rateLimited(c *fiber.Ctx, scope string, limit config.Limit) error {
+	metrics.ObserveRateLimited(scope)
+	retryAfter := 1
+	if limit.RPS > 0 {
+		retryAfter = int(math.Ceil(1 / limit.RPS))
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return fiber.NewError(fiber.StatusTooManyRequests, "rate limit exceeded, retry later")
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/config"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRateLimitTestApp(limits config.RateLimitConfig, store *mockAPIKeyStore) (*fiber.App, *RateLimiter) {
+	limiter := NewRateLimiter(limits)
+	now := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(limiter.Middleware())
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Use(limiter.PerKey())
+	app.Get("/v1/latest", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app, limiter
+}
+
+func statuses(t *testing.T, app *fiber.App, n int, key string) []int {
+	t.Helper()
+	var codes []int
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest("GET", "/v1/latest", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		codes = append(codes, resp.StatusCode)
+	}
+	return codes
+}
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	app, _ := setupRateLimitTestApp(config.RateLimitConfig{PerIP: config.Limit{RPS: 1, Burst: 2}}, newMockAPIKeyStore())
+
+	assert.Equal(t, []int{200, 200, 429}, statuses(t, app, 3, ""))
+
+	req := httptest.NewRequest("GET", "/v1/latest", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+}
+
+func TestRateLimiter_Global(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	app, _ := setupRateLimitTestApp(config.RateLimitConfig{Global: config.Limit{RPS: 0.5, Burst: 2}}, store)
+
+	// The global limit counts keyed and anonymous requests alike.
+	assert.Equal(t, []int{200}, statuses(t, app, 1, ""))
+	assert.Equal(t, []int{200, 429}, statuses(t, app, 2, "cx_reader"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/latest", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resp.Header.Get(fiber.HeaderRetryAfter))
+}
+
+func TestRateLimiter_PerKey(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_a", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	store.add("cx_b", apikey.Key{ID: "partner-b", Scopes: []string{apikey.ScopeRatesRead}})
+	app, _ := setupRateLimitTestApp(config.RateLimitConfig{
+		PerIP:  config.Limit{RPS: 1, Burst: 1},
+		PerKey: config.Limit{RPS: 1, Burst: 2},
+		Keys:   map[string]config.Limit{"partner-b": {RPS: 1, Burst: 3}},
+	}, store)
+
+	// Valid keys share an IP without being held to its limit.
+	assert.Equal(t, []int{200, 200, 429}, statuses(t, app, 3, "cx_a"))
+	assert.Equal(t, []int{200, 200, 200, 429}, statuses(t, app, 4, "cx_b"))
+}
+
+func TestRateLimiter_InvalidKeysUseIPLimit(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_a", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	app, limiter := setupRateLimitTestApp(config.RateLimitConfig{PerIP: config.Limit{RPS: 1, Burst: 2}}, store)
+
+	assert.Equal(t, []int{401, 401, 429}, statuses(t, app, 3, "cx_guess"))
+	// Once the IP is out of tokens, it can't even try a valid key until they refill.
+	assert.Equal(t, []int{429}, statuses(t, app, 1, "cx_a"))
+
+	later := limiter.now().Add(time.Second)
+	limiter.now = func() time.Time { return later }
+	assert.Equal(t, []int{200, 200}, statuses(t, app, 2, "cx_a"))
+}
+
+func TestRateLimiter_AllowCallSharesBuckets(t *testing.T) {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Scopes: []string{apikey.ScopeRatesRead}})
+	app, limiter := setupRateLimitTestApp(config.RateLimitConfig{
+		Enabled: true,
+		PerKey:  config.Limit{RPS: 0.001, Burst: 1},
+		PerIP:   config.Limit{RPS: 0.001, Burst: 1},
+	}, store)
+
+	assert.Equal(t, []int{200}, statuses(t, app, 1, "cx_reader"))
+	assert.False(t, limiter.AllowCall("192.0.2.1", "partner-a", ""))
+	assert.True(t, limiter.AllowCall("192.0.2.1", "", ""))
+	assert.False(t, limiter.AllowCall("192.0.2.1", "", ""))
+
+	limiter.limits.Enabled = false
+	assert.True(t, limiter.AllowCall("192.0.2.1", "partner-a", ""))
+}
+
+func TestLimiterSet_DropsIdleBuckets(t *testing.T) {
+	now := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+	set := newLimiterSet(func() time.Time { return now })
+	first := set.get("10.0.0.1", config.Limit{RPS: 1, Burst: 1})
+	assert.Same(t, first, set.get("10.0.0.1", config.Limit{RPS: 1, Burst: 1}))
+
+	now = now.Add(idleLimiterTTL)
+	set.get("10.0.0.2", config.Limit{RPS: 1, Burst: 1})
+	assert.Len(t, set.limiters, 1)
+	assert.NotSame(t, first, set.get("10.0.0.1", config.Limit{RPS: 1, Burst: 1}))
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/logging"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of a signed request.
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureKeyIDHeader     = "X-Signature-Key-Id"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureNonceHeader     = "X-Signature-Nonce"
+)
+
+var noncePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+// SigningKeyStore looks up the key a signed request claims to come from.
+type SigningKeyStore interface {
+	SigningKey(ctx context.Context, id string) (apikey.Key, []byte, error)
+}
+
+// NonceStore remembers the nonces of signed requests.
+type NonceStore interface {
+	Claim(ctx context.Context, keyID, nonce string, ttl time.Duration) (bool, error)
+}
+
+// SignatureVerifier authenticates requests signed with an API key instead of carrying it, so
+// the secret never travels with the request and a captured request can't be altered or
+// replayed.
+type SignatureVerifier struct {
+	keys    SigningKeyStore
+	nonces  NonceStore
+	maxSkew time.Duration
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewSignatureVerifier(keys SigningKeyStore, nonces NonceStore, maxSkew time.Duration, logger *slog.Logger) *SignatureVerifier {
+	return &SignatureVerifier{keys: keys, nonces: nonces, maxSkew: maxSkew, logger: logger, now: time.Now}
+}
+
+// CanonicalRequest is what a request signature covers: the method, path, query parameters
+// sorted by name, timestamp, nonce and the hex SHA-256 of the body, one per line. It fails
+// when rawQuery can't be parsed, as the parameters it covers would then be ambiguous.
+func CanonicalRequest(method, path, rawQuery, timestamp, nonce string, body []byte) (string, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), path, query.Encode(), timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n"), nil
+}
+
+// Sign returns the hex HMAC-SHA256 of canonical under signingKey.
+func Sign(signingKey []byte, canonical string) string {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware checks requests carrying an X-Signature header. Their timestamp must be within
+// the allowed skew of our clock, their nonce unused, and their signature made with the
+// signing key of the key named in X-Signature-Key-Id. Requests already authenticated, or
+// without a signature, are left alone.
+func (v *SignatureVerifier) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		signature := c.Get(SignatureHeader)
+		if signature == "" {
+			return c.Next()
+		}
+		if _, ok := authenticatedCaller(c); ok {
+			return c.Next()
+		}
+		keyID, timestamp, nonce := c.Get(SignatureKeyIDHeader), c.Get(SignatureTimestampHeader), c.Get(SignatureNonceHeader)
+		if keyID == "" || timestamp == "" || nonce == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "signed requests need the "+SignatureKeyIDHeader+", "+SignatureTimestampHeader+" and "+SignatureNonceHeader+" headers")
+		}
+		if !noncePattern.MatchString(nonce) {
+			return fiber.NewError(fiber.StatusUnauthorized, "nonce must be 16 to 128 letters, digits, dashes or underscores")
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "timestamp must be in Unix seconds")
+		}
+		if skew := v.now().Sub(time.Unix(seconds, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+			return fiber.NewError(fiber.StatusUnauthorized, "timestamp is more than "+v.maxSkew.String()+" away from the server's clock")
+		}
+
+		canonical, err := CanonicalRequest(c.Method(), c.Path(), string(c.Request().URI().QueryString()), timestamp, nonce, c.Body())
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "signed requests need a well-formed query string")
+		}
+
+		key, signingKey, err := v.keys.SigningKey(c.UserContext(), keyID)
+		if errors.Is(err, apikey.ErrInvalid) {
+			return v.reject(c, keyID, "unknown, revoked or expired key")
+		}
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(Sign(signingKey, canonical))) {
+			return v.reject(c, keyID, "signature mismatch")
+		}
+
+		// The nonce is only claimed once the signature checks out, so nobody else can use it up.
+		// It is kept for twice the skew, as long as its timestamp could be accepted.
+		claimed, err := v.nonces.Claim(c.UserContext(), key.ID, nonce, 2*v.maxSkew)
+		if err != nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "signed requests can't be checked for replays right now")
+		}
+		if !claimed {
+			return v.reject(c, keyID, "nonce already used")
+		}
+		setAuthenticated(c, Caller{ID: key.ID, Scopes: key.Grants()})
+		return c.Next()
+	}
+}
+
+func (v *SignatureVerifier) reject(c *fiber.Ctx, keyID, reason string) error {
+	logging.WithRequest(c.UserContext(), v.logger).Debug("Rejected signed request", "key_id", keyID, "reason", reason)
+	return fiber.NewError(fiber.StatusUnauthorized, "invalid request signature")
+}
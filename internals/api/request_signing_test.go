@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSigningKeyStore struct {
+	keys map[string]apikey.Key
+}
+
+func (m *mockSigningKeyStore) SigningKey(ctx context.Context, id string) (apikey.Key, []byte, error) {
+	key, ok := m.keys[id]
+	if !ok {
+		return apikey.Key{}, nil, apikey.ErrInvalid
+	}
+	return key, []byte("signing-key-" + id), nil
+}
+
+type mockNonceStore struct {
+	used map[string]bool
+	err  error
+}
+
+func (m *mockNonceStore) Claim(ctx context.Context, keyID, nonce string, ttl time.Duration) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.used[keyID+":"+nonce] {
+		return false, nil
+	}
+	m.used[keyID+":"+nonce] = true
+	return true, nil
+}
+
+// signedRequest describes a request to sign; signedAs changes who signs it, and the other
+// fields can be tampered with after signing.
+type signedRequest struct {
+	method, path, query, body string
+	keyID, nonce              string
+	timestamp                 time.Time
+	signedAs                  string
+	sentQuery                 string
+}
+
+func (r signedRequest) send(t *testing.T, app *fiber.App) int {
+	t.Helper()
+	ts := strconv.FormatInt(r.timestamp.Unix(), 10)
+	signedAs := r.signedAs
+	if signedAs == "" {
+		signedAs = r.keyID
+	}
+	canonical, err := CanonicalRequest(r.method, r.path, r.query, ts, r.nonce, []byte(r.body))
+	assert.NoError(t, err)
+	signature := Sign([]byte("signing-key-"+signedAs), canonical)
+	sentQuery := r.query
+	if r.sentQuery != "" {
+		sentQuery = r.sentQuery
+	}
+	req := httptest.NewRequest(r.method, r.path+"?"+sentQuery, strings.NewReader(r.body))
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(SignatureKeyIDHeader, r.keyID)
+	req.Header.Set(SignatureTimestampHeader, ts)
+	req.Header.Set(SignatureNonceHeader, r.nonce)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+func setupSigningTestApp(nonces *mockNonceStore, now time.Time) *fiber.App {
+	keys := &mockSigningKeyStore{keys: map[string]apikey.Key{
+		"partner-a": {ID: "partner-a", Role: apikey.RoleReader},
+		"partner-b": {ID: "partner-b", Role: apikey.RoleReader},
+	}}
+	verifier := NewSignatureVerifier(keys, nonces, 5*time.Minute, discardLogger)
+	verifier.now = func() time.Time { return now }
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(verifier.Middleware())
+	app.Get("/v1/convert", RequireScope(apikey.ScopeRatesRead, true), func(c *fiber.Ctx) error {
+		return c.SendString(callerID(c))
+	})
+	app.Post("/admin/keys", RequireAdmin(""), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	now := time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC)
+	app := setupSigningTestApp(&mockNonceStore{used: map[string]bool{}}, now)
+	valid := func(nonce string) signedRequest {
+		return signedRequest{method: "GET", path: "/v1/convert", query: "from=USD&to=EUR&amount=10", keyID: "partner-a", nonce: nonce, timestamp: now}
+	}
+
+	assert.Equal(t, 200, valid("nonce-000000000001").send(t, app))
+	// The same request again is a replay.
+	assert.Equal(t, 401, valid("nonce-000000000001").send(t, app))
+
+	tests := []struct {
+		name   string
+		modify func(*signedRequest)
+		status int
+	}{
+		{"query order does not matter", func(r *signedRequest) { r.sentQuery = "amount=10&to=EUR&from=USD" }, 200},
+		{"clock slightly ahead", func(r *signedRequest) { r.timestamp = now.Add(4 * time.Minute) }, 200},
+		{"tampered query", func(r *signedRequest) { r.sentQuery = "from=USD&to=EUR&amount=1000" }, 401},
+		{"signed by another key", func(r *signedRequest) { r.signedAs = "partner-b" }, 401},
+		{"unknown key", func(r *signedRequest) { r.keyID = "partner-z" }, 401},
+		{"stale timestamp", func(r *signedRequest) { r.timestamp = now.Add(-6 * time.Minute) }, 401},
+		{"short nonce", func(r *signedRequest) { r.nonce = "abc" }, 401},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid("nonce-10000000000" + strconv.Itoa(i))
+			tt.modify(&req)
+			assert.Equal(t, tt.status, req.send(t, app))
+		})
+	}
+
+	// A signed request carries its key's role, so a reader key can't reach admin routes.
+	req := signedRequest{method: "POST", path: "/admin/keys", body: `{"name":"x"}`, keyID: "partner-a", nonce: "nonce-200000000000", timestamp: now}
+	assert.Equal(t, 401, req.send(t, app))
+}
+
+func TestSignatureVerifier_NonceStoreUnavailable(t *testing.T) {
+	now := time.Now()
+	app := setupSigningTestApp(&mockNonceStore{err: errors.New("redis down")}, now)
+
+	req := signedRequest{method: "GET", path: "/v1/convert", query: "from=USD&to=EUR", keyID: "partner-a", nonce: "nonce-000000000001", timestamp: now}
+	assert.Equal(t, 503, req.send(t, app))
+}
+
+func TestSignatureVerifier_MissingHeaders(t *testing.T) {
+	app := setupSigningTestApp(&mockNonceStore{used: map[string]bool{}}, time.Now())
+
+	req := httptest.NewRequest("GET", "/v1/convert", nil)
+	req.Header.Set(SignatureHeader, "deadbeef")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	canonical, err := CanonicalRequest("get", "/v1/convert", "to=EUR&amount=10&from=USD", "1744624800", "nonce-0123456789ab", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"GET",
+		"/v1/convert",
+		"amount=10&from=USD&to=EUR",
+		"1744624800",
+		"nonce-0123456789ab",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, "\n"), canonical)
+}
+
+func TestCanonicalRequest_RejectsMalformedQuery(t *testing.T) {
+	_, err := CanonicalRequest("GET", "/v1/convert", "from=%zz", "1744624800", "nonce-0123456789ab", nil)
+	assert.Error(t, err)
+}
+
+func TestSignatureVerifier_MalformedQuery(t *testing.T) {
+	now := time.Now()
+	nonces := &mockNonceStore{used: map[string]bool{}}
+	app := setupSigningTestApp(nonces, now)
+
+	status := signedRequest{
+		method: "GET", path: "/v1/convert", query: "from=USD", sentQuery: "from=%zz",
+		keyID: "partner-a", nonce: "nonce-0123456789ab", timestamp: now,
+	}.send(t, app)
+
+	assert.Equal(t, 400, status)
+	assert.Empty(t, nonces.used)
+}
@@ -1,24 +1,131 @@
 package api
 
 import (
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/errorreport"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/metrics"
+	"log/slog"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
-func SetupRouter(app *fiber.App, handler *Handler) {
+type RouterConfig struct {
+	AdminAPIKey          string
+	APIKeysRequired      bool
+	RateLimits           config.RateLimitConfig
+	Network              config.NetworkConfig
+	CacheBypassEnabled   bool
+	MaxQueryLength       int
+	MetricsEnabled       bool
+	SlowRequestThreshold time.Duration
+	Logger               *slog.Logger
+	ErrorReporter        errorreport.Reporter
+	TokenVerifier        TokenVerifier
+	Signatures           *SignatureVerifier
+	AuthLockout          AuthFailureTracker
+	// Limiter enforces RateLimits. It is shared with the gRPC listener when both run; one is
+	// made when it is nil.
+	Limiter *RateLimiter
+}
+
+func SetupRouter(app *fiber.App, handler *Handler, healthHandler *HealthHandler, adminHandler *AdminHandler, auditHandler *AuditHandler, debugLogHandler *DebugLogHandler, apiKeyHandler *APIKeyHandler, quotaHandler *QuotaHandler, usageHandler *UsageHandler, graphQLHandler *GraphQLHandler, streamHandler *StreamHandler, webhookHandler *WebhookHandler, rateAlertHandler *RateAlertHandler, archiveHandler *ArchiveHandler, cfg RouterConfig) {
 
 	// Middleware
-	app.Use(logger.New())
+	app.Use(RequestTracing())
+	app.Use(ClientIP(cfg.Network.TrustedProxies, cfg.Network.ClientIPHeader))
+	app.Use(AccessLog(cfg.logger(), cfg.SlowRequestThreshold))
+	if cfg.MetricsEnabled {
+		app.Use(Metrics())
+	}
+	app.Use(Recover(cfg.logger(), cfg.ErrorReporter))
+	if len(cfg.Network.Allow) > 0 || len(cfg.Network.Deny) > 0 {
+		app.Use(IPFilter(cfg.Network.Allow, cfg.Network.Deny))
+	}
+	app.Use(MaxQueryLength(cfg.MaxQueryLength))
+	if cfg.AuthLockout != nil {
+		app.Use(AuthLockout(cfg.AuthLockout, cfg.logger()))
+	}
+	limiter := cfg.Limiter
+	if limiter == nil {
+		limiter = NewRateLimiter(cfg.RateLimits)
+	}
+	if cfg.RateLimits.Enabled {
+		app.Use(limiter.Middleware())
+	}
+	app.Use(apiKeyHandler.Authenticate())
+	if cfg.Signatures != nil {
+		app.Use(cfg.Signatures.Middleware())
+	}
+	if cfg.TokenVerifier != nil {
+		app.Use(JWTAuth(cfg.TokenVerifier, cfg.logger()))
+	}
+	if cfg.RateLimits.Enabled {
+		app.Use(limiter.PerKey())
+	}
+	app.Use(debugLogHandler.Middleware())
 
 	// Routes
-	v1 := app.Group("/v1")
+	v1 := app.Group("/v1", RequireScope(apikey.ScopeRatesRead, cfg.APIKeysRequired))
+	// Registered ahead of the quota middleware below, so listing currencies, checking usage or
+	// managing webhooks doesn't use up quota.
+	v1.Get("/currencies", handler.GetCurrencies)
+	v1.Get("/currencies/by-country", handler.GetCurrenciesByCountry)
+	v1.Get("/account/usage", quotaHandler.GetUsage)
+	v1.Get("/account/usage/detail", usageHandler.GetDetail)
+	v1.Get("/webhooks", webhookHandler.List)
+	v1.Post("/webhooks", webhookHandler.Create)
+	v1.Delete("/webhooks/:id", webhookHandler.Delete)
+	// Streams check key restrictions per subscribed pair. Opening one counts as a request.
+	v1.Get("/stream", quotaHandler.Enforce(), usageHandler.Record(), streamHandler.WebSocket())
+	v1.Get("/stream/sse", quotaHandler.Enforce(), usageHandler.Record(), streamHandler.ServerSentEvents)
+	rates := v1.Group("", EnforceRestrictions(), quotaHandler.Enforce(), usageHandler.Record(), CacheBypass(cfg.CacheBypassEnabled, cfg.AdminAPIKey, cfg.logger()))
+	{
+		rates.Get("/latest", handler.GetLatest)
+		rates.Get("/convert", handler.Convert)
+		rates.Get("/historical", handler.GetHistorical)
+	}
+	// GraphQL checks key restrictions, quota and usage per lookup, as one request may make
+	// several lookups on different endpoints.
+	app.Post("/graphql", RequireScope(apikey.ScopeRatesRead, cfg.APIKeysRequired), graphQLHandler.Serve)
+
+	admin := app.Group("/admin", RequireAdmin(cfg.AdminAPIKey), auditHandler.Record())
 	{
-		v1.Get("/latest", handler.GetLatest)
-		v1.Get("/convert", handler.Convert)
-		v1.Get("/historical", handler.GetHistorical)
+		admin.Get("/audit", auditHandler.List)
+		admin.Get("/debug-logging", debugLogHandler.GetState)
+		admin.Post("/debug-logging", debugLogHandler.Enable)
+		admin.Delete("/debug-logging", debugLogHandler.Disable)
+		admin.Get("/keys", apiKeyHandler.List)
+		admin.Post("/keys", apiKeyHandler.Create)
+		admin.Post("/keys/:id/rotate", apiKeyHandler.Rotate)
+		admin.Delete("/keys/:id", apiKeyHandler.Revoke)
+		admin.Get("/usage", usageHandler.List)
+		admin.Get("/providers", adminHandler.GetProviders)
+		admin.Get("/scheduler", adminHandler.GetScheduler)
+		admin.Post("/scheduler/pause", adminHandler.PauseScheduler)
+		admin.Post("/scheduler/resume", adminHandler.ResumeScheduler)
+		admin.Get("/alerts", rateAlertHandler.List)
+		admin.Post("/alerts", rateAlertHandler.Create)
+		admin.Delete("/alerts/:id", rateAlertHandler.Delete)
+		admin.Post("/import", archiveHandler.Import)
+		admin.Post("/backfill", archiveHandler.Backfill)
+		admin.Get("/export", archiveHandler.Export)
+		admin.Post("/restore", archiveHandler.Restore)
 	}
 
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "UP"})
-	})
+	app.Get("/health", healthHandler.Health)
+	app.Get("/readyz", healthHandler.Readiness)
+	app.Get("/health/details", healthHandler.Details)
+	if cfg.MetricsEnabled {
+		app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+	}
+}
+
+func (cfg RouterConfig) logger() *slog.Logger {
+	if cfg.Logger == nil {
+		return slog.Default()
+	}
+	return cfg.Logger
 }
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/metrics"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// streamWriteTimeout bounds every write to a stream, so a client that stopped reading can't
+// hold its connection open.
+const streamWriteTimeout = 10 * time.Second
+
+// maxStreamMessageSize bounds the messages a client may send; subscribing to every allowed
+// pair fits comfortably.
+const maxStreamMessageSize = 4096
+
+// StreamConfig controls the rate streams.
+type StreamConfig struct {
+	PingInterval time.Duration // keepalive; a client that misses two pings is disconnected
+	MaxPairs     int           // pairs one connection may subscribe to
+}
+
+// StreamHandler pushes latest rates to clients as the background refresh fetches them.
+// Subscriptions go through the same validation and key restrictions as /v1/latest.
+type StreamHandler struct {
+	handler *Handler
+	bus     *updates.Bus
+	cfg     StreamConfig
+	logger  *slog.Logger
+}
+
+func NewStreamHandler(handler *Handler, bus *updates.Bus, cfg StreamConfig, logger *slog.Logger) *StreamHandler {
+	return &StreamHandler{handler: handler, bus: bus, cfg: cfg, logger: logger}
+}
+
+// streamRequest is a message from the client, e.g.
+// {"action": "subscribe", "pairs": ["USD-INR"], "threshold": 0.5}.
+type streamRequest struct {
+	Action string   `json:"action"`
+	Pairs  []string `json:"pairs"`
+	// Threshold is the change, in percent of the last rate pushed, below which refreshes of
+	// the pairs aren't pushed. 0 pushes every refresh.
+	Threshold float64 `json:"threshold"`
+
+	err error // the message couldn't be decoded
+}
+
+type streamRate struct {
+	Type      string          `json:"type"`
+	Pair      string          `json:"pair"`
+	Base      domain.Currency `json:"base"`
+	Target    domain.Currency `json:"target"`
+	Rate      float64         `json:"rate"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+type streamSubscriptions struct {
+	Type  string   `json:"type"`
+	Pairs []string `json:"pairs"`
+}
+
+type streamError struct {
+	Type  string `json:"type"`
+	Pair  string `json:"pair,omitempty"`
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newStreamError(pair string, err error) streamError {
+	e := streamError{Type: "error", Pair: pair}
+	e.Error.Code, e.Error.Message = describeError(err)
+	return e
+}
+
+type streamPair struct {
+	base, target domain.Currency
+}
+
+func (p streamPair) String() string {
+	return string(p.base) + "-" + string(p.target)
+}
+
+// parseStreamPair parses a pair written as BASE-TARGET, e.g. USD-INR.
+func parseStreamPair(value string) (streamPair, error) {
+	base, target, ok := strings.Cut(strings.ToUpper(value), "-")
+	if !ok {
+		return streamPair{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("pairs must be written as BASE-TARGET, e.g. USD-INR, got %q", truncate(value, maxCurrencyParamLength)))
+	}
+	baseCurrency, err := parseCurrency("base", base)
+	if err != nil {
+		return streamPair{}, err
+	}
+	targetCurrency, err := parseCurrency("target", target)
+	if err != nil {
+		return streamPair{}, err
+	}
+	return streamPair{base: baseCurrency, target: targetCurrency}, nil
+}
+
+// pairSubscription is one pair a connection subscribed to.
+type pairSubscription struct {
+	threshold float64
+	last      float64 // last rate pushed, 0 before the first
+}
+
+// due reports whether rate moved far enough from the last one pushed to be pushed.
+func (s *pairSubscription) due(rate float64) bool {
+	if s.threshold <= 0 || s.last == 0 {
+		return true
+	}
+	return math.Abs(rate-s.last)/s.last*100 >= s.threshold
+}
+
+// WebSocket serves GET /v1/stream. Plain HTTP requests are answered 426.
+func (h *StreamHandler) WebSocket() fiber.Handler {
+	upgrade := websocket.New(h.serveWebSocket)
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		return upgrade(c)
+	}
+}
+
+// serveWebSocket runs one connection. All writes happen here; a separate goroutine reads the
+// client's messages and hands them over.
+func (h *StreamHandler) serveWebSocket(conn *websocket.Conn) {
+	metrics.ObserveStreamOpened("websocket")
+	defer metrics.ObserveStreamClosed("websocket")
+	started := time.Now()
+	caller, _ := conn.Locals(authLocal).(Caller)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := h.bus.Subscribe()
+	defer sub.Close()
+
+	readTimeout := 2 * h.cfg.PingInterval
+	conn.SetReadLimit(maxStreamMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	requests := make(chan streamRequest)
+	done := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		defer close(requests)
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req streamRequest
+			if err := json.Unmarshal(message, &req); err != nil {
+				req.err = fiber.NewError(fiber.StatusBadRequest, "messages must be JSON objects with an `action`")
+			}
+			select {
+			case requests <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer func() {
+		// The connection is released once this returns, so the reader must be gone by then.
+		close(done)
+		conn.Close()
+		<-readerDone
+	}()
+
+	subscriptions := make(map[streamPair]*pairSubscription)
+	defer func() {
+		h.logger.Info("Rate stream closed", "caller", caller.ID, "transport", "websocket", "pairs", len(subscriptions), "duration", time.Since(started).Round(time.Second))
+	}()
+	ping := time.NewTicker(h.cfg.PingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			err = h.handleRequest(ctx, conn, caller, subscriptions, req)
+		case update, ok := <-sub.Updates():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(streamWriteTimeout))
+				return
+			}
+			for _, rate := range ratesDue(subscriptions, update) {
+				if err = h.write(conn, rate); err != nil {
+					break
+				}
+			}
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (h *StreamHandler) write(conn *websocket.Conn, message any) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return conn.WriteJSON(message)
+}
+
+// handleRequest applies a subscribe or unsubscribe and answers with the pairs the connection is
+// now subscribed to. A request that can't be applied is answered with an error, and leaves the
+// subscriptions as they were. Only failing writes are returned.
+func (h *StreamHandler) handleRequest(ctx context.Context, conn *websocket.Conn, caller Caller, subscriptions map[streamPair]*pairSubscription, req streamRequest) error {
+	var added []streamPair
+	var err error
+	switch {
+	case req.err != nil:
+		err = req.err
+	case req.Action == "subscribe":
+		added, err = h.subscribe(caller, subscriptions, req)
+	case req.Action == "unsubscribe":
+		err = h.unsubscribe(subscriptions, req)
+	default:
+		err = fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown action %q, expected subscribe or unsubscribe", truncate(req.Action, maxCurrencyParamLength)))
+	}
+	if err != nil {
+		return h.write(conn, newStreamError("", err))
+	}
+
+	if err := h.write(conn, subscribedPairs(subscriptions)); err != nil {
+		return err
+	}
+
+	// New subscribers get the current rate straight away rather than at the next refresh.
+	for _, message := range h.currentRates(ctx, subscriptions, added) {
+		if err := h.write(conn, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribedPairs lists the pairs a stream is subscribed to, sorted.
+func subscribedPairs(subscriptions map[streamPair]*pairSubscription) streamSubscriptions {
+	pairs := make([]string, 0, len(subscriptions))
+	for pair := range subscriptions {
+		pairs = append(pairs, pair.String())
+	}
+	sort.Strings(pairs)
+	return streamSubscriptions{Type: "subscribed", Pairs: pairs}
+}
+
+// currentRates looks up the rates of newly subscribed pairs, as rate messages, or error
+// messages for pairs whose rate couldn't be looked up.
+func (h *StreamHandler) currentRates(ctx context.Context, subscriptions map[streamPair]*pairSubscription, pairs []streamPair) []any {
+	messages := make([]any, 0, len(pairs))
+	for _, pair := range pairs {
+		rates, err := h.handler.rateService.GetLatestRates(ctx, pair.base, pair.target)
+		if err != nil {
+			messages = append(messages, newStreamError(pair.String(), err))
+			continue
+		}
+		rate, ok := rates.Rates[pair.target]
+		if !ok {
+			continue
+		}
+		subscriptions[pair].last = rate
+		messages = append(messages, streamRate{Type: "rate", Pair: pair.String(), Base: pair.base, Target: pair.target, Rate: rate, Timestamp: rates.Timestamp})
+	}
+	return messages
+}
+
+// subscribe adds req's pairs, or updates their threshold when already subscribed, and returns
+// the pairs that are new.
+func (h *StreamHandler) subscribe(caller Caller, subscriptions map[streamPair]*pairSubscription, req streamRequest) ([]streamPair, error) {
+	if len(req.Pairs) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "`pairs` is required")
+	}
+	if !(req.Threshold >= 0) || math.IsInf(req.Threshold, 0) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "`threshold` must be a percentage of 0 or more")
+	}
+	pairs := make([]streamPair, 0, len(req.Pairs))
+	count := len(subscriptions)
+	for _, value := range req.Pairs {
+		pair, err := parseStreamPair(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRestrictions(caller.Restrictions, "latest", string(pair.base), string(pair.target)); err != nil {
+			return nil, err
+		}
+		if err := h.handler.checkCurrencies(pair.base, pair.target); err != nil {
+			return nil, err
+		}
+		// Streams only carry latest rates, which retired currencies no longer have.
+		now := time.Now()
+		for _, currency := range []domain.Currency{pair.base, pair.target} {
+			if err := currency.CheckInUse(now, now); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := subscriptions[pair]; !ok {
+			count++
+		}
+		pairs = append(pairs, pair)
+	}
+	if count > h.cfg.MaxPairs {
+		return nil, &CodedError{
+			Status:  fiber.StatusBadRequest,
+			Code:    TooManyCurrenciesCode,
+			Message: fmt.Sprintf("a stream may subscribe to at most %d pairs", h.cfg.MaxPairs),
+		}
+	}
+
+	var added []streamPair
+	for _, pair := range pairs {
+		if existing, ok := subscriptions[pair]; ok {
+			existing.threshold = req.Threshold
+			continue
+		}
+		subscriptions[pair] = &pairSubscription{threshold: req.Threshold}
+		added = append(added, pair)
+	}
+	return added, nil
+}
+
+// unsubscribe removes req's pairs. Pairs the connection isn't subscribed to are ignored.
+func (h *StreamHandler) unsubscribe(subscriptions map[streamPair]*pairSubscription, req streamRequest) error {
+	pairs := make([]streamPair, 0, len(req.Pairs))
+	for _, value := range req.Pairs {
+		pair, err := parseStreamPair(value)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair)
+	}
+	for _, pair := range pairs {
+		delete(subscriptions, pair)
+	}
+	return nil
+}
+
+// ratesDue returns the rates update carries for subscribed pairs that changed and whose change
+// is due, and records them as pushed.
+func ratesDue(subscriptions map[streamPair]*pairSubscription, update updates.Update) []streamRate {
+	var due []streamRate
+	for pair, sub := range subscriptions {
+		if pair.base != update.Base {
+			continue
+		}
+		rate, ok := update.Rates[pair.target]
+		if !ok || !update.HasChanged(pair.target) || !sub.due(rate) {
+			continue
+		}
+		sub.last = rate
+		due = append(due, streamRate{Type: "rate", Pair: pair.String(), Base: pair.base, Target: pair.target, Rate: rate, Timestamp: update.Timestamp.Unix()})
+	}
+	return due
+}
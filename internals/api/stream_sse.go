@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bufio"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/metrics"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServerSentEvents serves GET /v1/stream/sse?pairs=USD-INR,EUR-USD&threshold=0.5, the same rate
+// stream as the WebSocket for clients that can't open one, e.g. browsers behind proxies that
+// strip upgrades. The pairs are fixed for the life of the stream, so they are checked up front
+// and rejected with a plain HTTP error. Every message is the data of an unnamed event, in the
+// same JSON as the WebSocket's.
+func (h *StreamHandler) ServerSentEvents(c *fiber.Ctx) error {
+	caller, _ := c.Locals(authLocal).(Caller)
+	req := streamRequest{Action: "subscribe"}
+	if pairs := c.Query("pairs"); pairs != "" {
+		req.Pairs = strings.Split(pairs, ",")
+	}
+	if value := c.Query("threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "`threshold` must be a percentage of 0 or more")
+		}
+		req.Threshold = threshold
+	}
+	subscriptions := make(map[streamPair]*pairSubscription)
+	added, err := h.subscribe(caller, subscriptions, req)
+	if err != nil {
+		return err
+	}
+
+	// Subscribed before the current rates are looked up, so no refresh is missed in between.
+	sub := h.bus.Subscribe()
+	initial := append([]any{subscribedPairs(subscriptions)}, h.currentRates(c.UserContext(), subscriptions, added)...)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	// Stops nginx from buffering the stream.
+	c.Set("X-Accel-Buffering", "no")
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.serveEvents(conn, w, caller, sub, subscriptions, initial)
+	})
+	return nil
+}
+
+// serveEvents writes one stream until the client goes away or the bus is closed. It runs on its
+// own goroutine once the handler has returned, so it only gets what it needs from the request.
+func (h *StreamHandler) serveEvents(conn net.Conn, w *bufio.Writer, caller Caller, sub *updates.Subscription, subscriptions map[streamPair]*pairSubscription, initial []any) {
+	metrics.ObserveStreamOpened("sse")
+	defer metrics.ObserveStreamClosed("sse")
+	defer sub.Close()
+	started := time.Now()
+	defer func() {
+		h.logger.Info("Rate stream closed", "caller", caller.ID, "transport", "sse", "pairs", len(subscriptions), "duration", time.Since(started).Round(time.Second))
+	}()
+
+	for _, message := range initial {
+		if err := writeEvent(conn, w, message); err != nil {
+			return
+		}
+	}
+	// A client that went away is only noticed when writing to it, so idle streams are written to
+	// every ping interval regardless.
+	ping := time.NewTicker(h.cfg.PingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case update, ok := <-sub.Updates():
+			if !ok {
+				return
+			}
+			for _, rate := range ratesDue(subscriptions, update) {
+				if err = writeEvent(conn, w, rate); err != nil {
+					break
+				}
+			}
+		case <-ping.C:
+			// A comment line, which EventSource ignores. It also keeps proxies from timing out
+			// the stream.
+			if _, err = w.WriteString(": ping\n\n"); err == nil {
+				err = flushEvents(conn, w)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(conn net.Conn, w *bufio.Writer, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := w.WriteString("data: " + string(data) + "\n\n"); err != nil {
+		return err
+	}
+	return flushEvents(conn, w)
+}
+
+// flushEvents sends what was written so far. The server's write timeout would otherwise cut
+// every stream off once it elapsed, so each flush gets streamWriteTimeout from now instead.
+func flushEvents(conn net.Conn, w *bufio.Writer) error {
+	if conn != nil {
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	}
+	return w.Flush()
+}
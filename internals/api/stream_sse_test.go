@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bufio"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSSETestServer(t *testing.T, mock *MockRateService, cfg StreamConfig) (*updates.Bus, string) {
+	t.Helper()
+	bus := updates.NewBus(8)
+	// A write timeout shorter than the test, which a stream must outlive.
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler, WriteTimeout: 100 * time.Millisecond})
+	app.Use(AccessLog(discardLogger, 0))
+	app.Get("/v1/stream/sse", NewStreamHandler(NewHandler(mock), bus, cfg, discardLogger).ServerSentEvents)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go app.Listener(lis)
+	t.Cleanup(func() {
+		bus.Close()
+		_ = app.Shutdown()
+	})
+	return bus, "http://" + lis.Addr().String() + "/v1/stream/sse"
+}
+
+// readEvent returns the data of the next event, skipping comments.
+func readEvent(t *testing.T, events *bufio.Reader) map[string]any {
+	t.Helper()
+	for {
+		line, err := events.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSuffix(line, "\n"), "data: "); ok {
+			var message map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(data), &message))
+			return message
+		}
+	}
+}
+
+func TestSSE_SubscribeAndPush(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85}, Timestamp: 1744624800}}
+	bus, url := setupSSETestServer(t, mock, StreamConfig{PingInterval: 20 * time.Millisecond, MaxPairs: 5})
+
+	resp, err := http.Get(url + "?pairs=usd-inr&threshold=1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	events := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, map[string]any{"type": "subscribed", "pairs": []any{"USD-INR"}}, readEvent(t, events))
+	assert.Equal(t, map[string]any{
+		"type": "rate", "pair": "USD-INR", "base": "USD", "target": "INR", "rate": float64(85), "timestamp": float64(1744624800),
+	}, readEvent(t, events))
+
+	// Pinged past the server's write timeout.
+	time.Sleep(200 * time.Millisecond)
+	at := time.Unix(1744628400, 0)
+	bus.Publish(updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.5}, Timestamp: at})
+	bus.Publish(updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 86}, Timestamp: at})
+	assert.Equal(t, map[string]any{
+		"type": "rate", "pair": "USD-INR", "base": "USD", "target": "INR", "rate": float64(86), "timestamp": float64(1744628400),
+	}, readEvent(t, events))
+
+	// Shutdown ends the stream.
+	bus.Close()
+	for {
+		if _, err := events.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
+
+func TestSSE_RejectsBadSubscriptions(t *testing.T) {
+	_, url := setupSSETestServer(t, &MockRateService{}, StreamConfig{PingInterval: time.Minute, MaxPairs: 1})
+
+	// Checked before the stream starts, so answered like any other request.
+	for _, query := range []string{
+		"",
+		"?pairs=USDINR",
+		"?pairs=USD-US$",
+		"?pairs=USD-INR&threshold=x",
+		"?pairs=USD-INR&threshold=-1",
+		"?pairs=USD-INR,EUR-USD",
+	} {
+		resp, err := http.Get(url + query)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
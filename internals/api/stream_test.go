@@ -0,0 +1,156 @@
+package api
+
+import (
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/updates"
+	"currency-exchange/internals/core/domain"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStreamTestServer(t *testing.T, mock *MockRateService, cfg StreamConfig) (*updates.Bus, string) {
+	t.Helper()
+	store := newMockAPIKeyStore()
+	store.add("cx_eur_only", apikey.Key{ID: "partner-a", Role: apikey.RoleReader, Restrictions: apikey.Restrictions{Pairs: []string{"EUR/*"}}})
+
+	bus := updates.NewBus(8)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	app.Get("/v1/stream", NewStreamHandler(NewHandler(mock), bus, cfg, discardLogger).WebSocket())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go app.Listener(lis)
+	t.Cleanup(func() {
+		bus.Close()
+		_ = app.Shutdown()
+	})
+	return bus, "ws://" + lis.Addr().String() + "/v1/stream"
+}
+
+func dialStream(t *testing.T, url, apiKey string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{}
+	if apiKey != "" {
+		header.Set(APIKeyHeader, apiKey)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func readStream(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	var message map[string]any
+	assert.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestStream_SubscribeAndPush(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85}, Timestamp: 1744624800}}
+	bus, url := setupStreamTestServer(t, mock, StreamConfig{PingInterval: time.Minute, MaxPairs: 5})
+	conn := dialStream(t, url, "")
+
+	assert.NoError(t, conn.WriteJSON(map[string]any{"action": "subscribe", "pairs": []string{"usd-inr"}, "threshold": 1}))
+	assert.Equal(t, map[string]any{"type": "subscribed", "pairs": []any{"USD-INR"}}, readStream(t, conn))
+	// The current rate comes straight away.
+	assert.Equal(t, map[string]any{
+		"type": "rate", "pair": "USD-INR", "base": "USD", "target": "INR", "rate": float64(85), "timestamp": float64(1744624800),
+	}, readStream(t, conn))
+
+	assert.Eventually(t, func() bool { return bus.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+	at := time.Unix(1744628400, 0)
+	// Below the 1% threshold, and for another base: not pushed.
+	bus.Publish(updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85.5}, Timestamp: at})
+	bus.Publish(updates.Update{Base: "EUR", Rates: map[domain.Currency]float64{"INR": 92}, Timestamp: at})
+	bus.Publish(updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 86}, Timestamp: at})
+	assert.Equal(t, map[string]any{
+		"type": "rate", "pair": "USD-INR", "base": "USD", "target": "INR", "rate": float64(86), "timestamp": float64(1744628400),
+	}, readStream(t, conn))
+
+	assert.NoError(t, conn.WriteJSON(map[string]any{"action": "unsubscribe", "pairs": []string{"USD-INR"}}))
+	assert.Equal(t, map[string]any{"type": "subscribed", "pairs": []any{}}, readStream(t, conn))
+}
+
+func TestStream_Errors(t *testing.T) {
+	mock := &MockRateService{LatestRatesResp: &domain.LatestRates{Base: "EUR", Rates: map[domain.Currency]float64{"USD": 1.1}}}
+	_, url := setupStreamTestServer(t, mock, StreamConfig{PingInterval: time.Minute, MaxPairs: 2})
+	conn := dialStream(t, url, "cx_eur_only")
+
+	errorCode := func(request any) string {
+		t.Helper()
+		assert.NoError(t, conn.WriteJSON(request))
+		message := readStream(t, conn)
+		assert.Equal(t, "error", message["type"])
+		return message["error"].(map[string]any)["code"].(string)
+	}
+	assert.Equal(t, "Bad Request", errorCode("not an object"))
+	assert.Equal(t, "Bad Request", errorCode(map[string]any{"action": "list"}))
+	assert.Equal(t, "Bad Request", errorCode(map[string]any{"action": "subscribe", "pairs": []string{"EURUSD"}}))
+	assert.Equal(t, MalformedCurrencyCode, errorCode(map[string]any{"action": "subscribe", "pairs": []string{"EUR-US$"}}))
+	assert.Equal(t, PairNotAllowedCode, errorCode(map[string]any{"action": "subscribe", "pairs": []string{"EUR-USD", "USD-INR"}}))
+	assert.Equal(t, TooManyCurrenciesCode, errorCode(map[string]any{"action": "subscribe", "pairs": []string{"EUR-USD", "EUR-INR", "EUR-JPY"}}))
+	assert.Equal(t, "Bad Request", errorCode(map[string]any{"action": "subscribe", "pairs": []string{"EUR-USD"}, "threshold": -1}))
+
+	// Failed requests leave the connection open and its subscriptions as they were.
+	assert.NoError(t, conn.WriteJSON(map[string]any{"action": "subscribe", "pairs": []string{"EUR-USD"}}))
+	assert.Equal(t, map[string]any{"type": "subscribed", "pairs": []any{"EUR-USD"}}, readStream(t, conn))
+}
+
+func TestStream_KeepaliveAndShutdown(t *testing.T) {
+	mock := &MockRateService{}
+	bus, url := setupStreamTestServer(t, mock, StreamConfig{PingInterval: 20 * time.Millisecond, MaxPairs: 1})
+	conn := dialStream(t, url, "")
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(string) error {
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				close(pings)
+				return
+			}
+		}
+	}()
+
+	// Answering pings keeps the connection open well past the read timeout.
+	for i := 0; i < 5; i++ {
+		_, ok := <-pings
+		assert.True(t, ok)
+	}
+
+	bus.Close()
+	for range pings {
+	}
+	assert.Eventually(t, func() bool { return bus.Subscribers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStream_RequiresUpgrade(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/v1/stream", NewStreamHandler(NewHandler(&MockRateService{}), updates.NewBus(1), StreamConfig{PingInterval: time.Minute, MaxPairs: 1}, discardLogger).WebSocket())
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/stream", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUpgradeRequired, resp.StatusCode)
+}
+
+func TestRatesDue_SkipsUnchangedTargets(t *testing.T) {
+	usdInr, usdEur := streamPair{base: "USD", target: "INR"}, streamPair{base: "USD", target: "EUR"}
+	subscriptions := map[streamPair]*pairSubscription{usdInr: {last: 85}, usdEur: {last: 0.9}}
+	at := time.Unix(1744628400, 0)
+
+	due := ratesDue(subscriptions, updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.91}, Changed: []domain.Currency{"EUR"}, Timestamp: at})
+	assert.Equal(t, []streamRate{{Type: "rate", Pair: "USD-EUR", Base: "USD", Target: "EUR", Rate: 0.91, Timestamp: at.Unix()}}, due)
+	// Without Changed, every target counts as changed.
+	assert.Len(t, ratesDue(subscriptions, updates.Update{Base: "USD", Rates: map[domain.Currency]float64{"INR": 85, "EUR": 0.91}, Timestamp: at}), 2)
+}
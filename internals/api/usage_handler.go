@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/usage"
+	"currency-exchange/internals/logging"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultUsageDays is how many days, today included, a usage breakdown covers by default.
+const defaultUsageDays = 7
+
+// UsageRecorder aggregates request counts per key, endpoint, currency pair and day.
+type UsageRecorder interface {
+	Record(ctx context.Context, hit usage.Hit, now time.Time) error
+	Breakdown(ctx context.Context, keyID string, from, to time.Time) ([]usage.Entry, error)
+}
+
+// UsageHandler records which endpoints and currency pairs every API key or JWT subject asks
+// for, so operators can see which consumers drive provider traffic.
+type UsageHandler struct {
+	recorder      UsageRecorder
+	retentionDays int
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+func NewUsageHandler(recorder UsageRecorder, retentionDays int, logger *slog.Logger) *UsageHandler {
+	return &UsageHandler{recorder: recorder, retentionDays: retentionDays, logger: logger, now: time.Now}
+}
+
+// Record counts every request of an authenticated caller once it has been served. Requests
+// naming a malformed currency are counted without a pair, so junk input can't grow the
+// breakdown. A failure to record is logged and never fails the request.
+func (h *UsageHandler) Record() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if caller, ok := authenticatedCaller(c); ok {
+			h.record(c.UserContext(), caller, path.Base(c.Route().Path), requestPair(c))
+		}
+		return err
+	}
+}
+
+// record counts one request of caller to endpoint for pair, which may be empty.
+func (h *UsageHandler) record(ctx context.Context, caller Caller, endpoint, pair string) {
+	hit := usage.Hit{KeyID: caller.ID, Endpoint: endpoint, Pair: pair}
+	if err := h.recorder.Record(ctx, hit, h.now()); err != nil {
+		logging.WithRequest(ctx, h.logger).Warn("Could not record usage", "key_id", caller.ID, "error", err)
+	}
+}
+
+// dateRange reads the optional `from` and `to` days (YYYY-MM-DD, UTC) of a breakdown. They
+// default to the last 7 days and may not reach back further than usage is kept.
+func (h *UsageHandler) dateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	now := h.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from, to := today.AddDate(0, 0, 1-defaultUsageDays), today
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid `"+param.name+"` format, expected YYYY-MM-DD")
+		}
+		*param.dest = day
+	}
+	if to.After(today) {
+		to = today
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "`from` must not be after `to`")
+	}
+	if oldest := today.AddDate(0, 0, 1-h.retentionDays); from.Before(oldest) {
+		return time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("usage is only kept for %d days, `from` must be %s or later", h.retentionDays, oldest.Format("2006-01-02")))
+	}
+	return from, to, nil
+}
+
+type usageDetailResponse struct {
+	KeyID   string        `json:"keyId"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Total   int64         `json:"total"`
+	Entries []usage.Entry `json:"entries"`
+}
+
+// GetDetail serves the caller's own requests per endpoint, currency pair and day.
+func (h *UsageHandler) GetDetail(c *fiber.Ctx) error {
+	caller, ok := authenticatedCaller(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "API key required")
+	}
+	from, to, err := h.dateRange(c)
+	if err != nil {
+		return err
+	}
+	entries, err := h.recorder.Breakdown(c.UserContext(), caller.ID, from, to)
+	if err != nil {
+		return err
+	}
+	resp := usageDetailResponse{KeyID: caller.ID, From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), Entries: entries}
+	for _, e := range entries {
+		resp.Total += e.Count
+	}
+	return c.JSON(resp)
+}
+
+type usageReportResponse struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Totals  map[string]int64 `json:"totals"`
+	Entries []usage.Entry    `json:"entries"`
+}
+
+// List serves the requests of every key, or only of `keyId`, per endpoint, currency pair and
+// day, along with each key's total.
+func (h *UsageHandler) List(c *fiber.Ctx) error {
+	from, to, err := h.dateRange(c)
+	if err != nil {
+		return err
+	}
+	entries, err := h.recorder.Breakdown(c.UserContext(), c.Query("keyId"), from, to)
+	if err != nil {
+		return err
+	}
+	resp := usageReportResponse{From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), Totals: map[string]int64{}, Entries: entries}
+	for _, e := range entries {
+		resp.Totals[e.KeyID] += e.Count
+	}
+	return c.JSON(resp)
+}
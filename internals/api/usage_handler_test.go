@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/usage"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockUsageRecorder struct {
+	hits   []usage.Hit
+	err    error
+	from   time.Time
+	to     time.Time
+	keyID  string
+	report []usage.Entry
+}
+
+func (m *mockUsageRecorder) Record(ctx context.Context, hit usage.Hit, now time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.hits = append(m.hits, hit)
+	return nil
+}
+
+func (m *mockUsageRecorder) Breakdown(ctx context.Context, keyID string, from, to time.Time) ([]usage.Entry, error) {
+	m.keyID, m.from, m.to = keyID, from, to
+	var entries []usage.Entry
+	for _, e := range m.report {
+		if keyID == "" || e.KeyID == keyID {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].KeyID < entries[j].KeyID })
+	return entries, nil
+}
+
+func setupUsageTestApp(recorder *mockUsageRecorder) *fiber.App {
+	store := newMockAPIKeyStore()
+	store.add("cx_reader", apikey.Key{ID: "partner-a", Role: apikey.RoleReader})
+	h := NewUsageHandler(recorder, 31, discardLogger)
+	h.now = func() time.Time { return time.Date(2025, 4, 14, 10, 0, 0, 0, time.UTC) }
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(store, discardLogger).Authenticate())
+	v1 := app.Group("/v1")
+	v1.Get("/account/usage/detail", h.GetDetail)
+	rates := v1.Group("", h.Record())
+	rates.Get("/latest", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	rates.Get("/convert", func(c *fiber.Ctx) error { return fiber.NewError(fiber.StatusBadRequest, "bad amount") })
+	app.Get("/admin/usage", RequireAdmin("secret"), h.List)
+	return app
+}
+
+func usageRequest(t *testing.T, app *fiber.App, target, apiKey string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest("GET", target, nil)
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	} else {
+		req.Header.Set(AdminKeyHeader, "secret")
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
+
+func TestUsageHandler_Record(t *testing.T) {
+	recorder := &mockUsageRecorder{}
+	app := setupUsageTestApp(recorder)
+
+	status, _ := usageRequest(t, app, "/v1/latest?base=usd&symbol=INR", "cx_reader")
+	assert.Equal(t, 200, status)
+	status, _ = usageRequest(t, app, "/v1/convert?from=USD&to=<script>&amount=x", "cx_reader")
+	assert.Equal(t, 400, status)
+	// Conversions are counted under the pair they convert, whatever base and symbol say.
+	status, _ = usageRequest(t, app, "/v1/convert?base=EUR&symbol=EUR&from=GBP&to=JPY&amount=x", "cx_reader")
+	assert.Equal(t, 400, status)
+	// Requests without a key aren't attributed to anyone.
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/latest?base=USD&symbol=INR", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	assert.Equal(t, []usage.Hit{
+		{KeyID: "partner-a", Endpoint: "latest", Pair: "USD/INR"},
+		{KeyID: "partner-a", Endpoint: "convert", Pair: ""},
+		{KeyID: "partner-a", Endpoint: "convert", Pair: "GBP/JPY"},
+	}, recorder.hits)
+
+	// A failure to record doesn't fail the request.
+	recorder.err = errors.New("redis down")
+	status, _ = usageRequest(t, app, "/v1/latest?base=USD&symbol=INR", "cx_reader")
+	assert.Equal(t, 200, status)
+}
+
+func TestUsageHandler_GetDetail(t *testing.T) {
+	recorder := &mockUsageRecorder{report: []usage.Entry{
+		{Day: "2025-04-13", KeyID: "partner-a", Endpoint: "convert", Pair: "USD/INR", Count: 3},
+		{Day: "2025-04-14", KeyID: "partner-a", Endpoint: "latest", Pair: "EUR/GBP", Count: 2},
+		{Day: "2025-04-14", KeyID: "partner-b", Endpoint: "latest", Pair: "USD/JPY", Count: 9},
+	}}
+	app := setupUsageTestApp(recorder)
+
+	status, body := usageRequest(t, app, "/v1/account/usage/detail", "cx_reader")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "partner-a", body["keyId"])
+	assert.Equal(t, "2025-04-08", body["from"])
+	assert.Equal(t, "2025-04-14", body["to"])
+	assert.Equal(t, float64(5), body["total"])
+	assert.Len(t, body["entries"], 2)
+	assert.Equal(t, "partner-a", recorder.keyID)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/account/usage/detail", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	for _, target := range []string{
+		"/v1/account/usage/detail?from=14-04-2025",
+		"/v1/account/usage/detail?from=2025-04-14&to=2025-04-10",
+		"/v1/account/usage/detail?from=2025-03-01",
+	} {
+		status, _ = usageRequest(t, app, target, "cx_reader")
+		assert.Equal(t, 400, status, target)
+	}
+}
+
+func TestUsageHandler_List(t *testing.T) {
+	recorder := &mockUsageRecorder{report: []usage.Entry{
+		{Day: "2025-04-14", KeyID: "partner-a", Endpoint: "latest", Pair: "EUR/GBP", Count: 2},
+		{Day: "2025-04-14", KeyID: "partner-b", Endpoint: "latest", Pair: "USD/JPY", Count: 9},
+	}}
+	app := setupUsageTestApp(recorder)
+
+	status, body := usageRequest(t, app, "/admin/usage?from=2025-04-14", "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, map[string]any{"partner-a": float64(2), "partner-b": float64(9)}, body["totals"])
+	assert.Equal(t, time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC), recorder.from)
+
+	status, body = usageRequest(t, app, "/admin/usage?keyId=partner-b", "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, map[string]any{"partner-b": float64(9)}, body["totals"])
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/webhook"
+	"currency-exchange/internals/logging"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookStore keeps the webhooks clients register.
+type WebhookStore interface {
+	Create(ctx context.Context, newWebhook webhook.NewWebhook) (webhook.Webhook, error)
+	List(ctx context.Context, owner string) ([]webhook.Webhook, error)
+	Delete(ctx context.Context, owner, id string) error
+}
+
+// WebhookHandler serves /v1/webhooks, where clients register URLs to be POSTed a pair's rate
+// when it crosses a threshold or moves by a percentage. Webhooks belong to the API key or JWT
+// subject that registered them.
+type WebhookHandler struct {
+	handler *Handler
+	store   WebhookStore
+	logger  *slog.Logger
+}
+
+func NewWebhookHandler(handler *Handler, store WebhookStore, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{handler: handler, store: store, logger: logger}
+}
+
+type createWebhookRequest struct {
+	URL       string  `json:"url"`
+	Secret    string  `json:"secret"`
+	Pair      string  `json:"pair"`
+	Condition string  `json:"condition"`
+	Threshold float64 `json:"threshold"`
+}
+
+// webhookOwner returns who the request's webhooks belong to, rejecting anonymous requests.
+func webhookOwner(c *fiber.Ctx) (Caller, error) {
+	caller, ok := authenticatedCaller(c)
+	if !ok {
+		return Caller{}, fiber.NewError(fiber.StatusUnauthorized, "API key required")
+	}
+	return caller, nil
+}
+
+// Create registers a webhook. The body is {"url": "https://...", "secret": "...",
+// "pair": "USD-INR", "condition": "above", "threshold": 86}, where "condition" is above or
+// below a rate, or change by a percentage since the webhook last fired. Deliveries are signed
+// with the secret, which is never returned.
+func (h *WebhookHandler) Create(c *fiber.Ctx) error {
+	caller, err := webhookOwner(c)
+	if err != nil {
+		return err
+	}
+	var req createWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body, expected {\"url\": \"...\", \"secret\": \"...\", \"pair\": \"USD-INR\", \"condition\": \"above\", \"threshold\": 86}")
+	}
+	pair, err := parseStreamPair(req.Pair)
+	if err != nil {
+		return err
+	}
+	if err := h.handler.checkCurrencies(pair.base, pair.target); err != nil {
+		return err
+	}
+	if err := checkRestrictions(caller.Restrictions, "latest", string(pair.base), string(pair.target)); err != nil {
+		return err
+	}
+	newWebhook := webhook.NewWebhook{
+		Owner:     caller.ID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Base:      pair.base,
+		Target:    pair.target,
+		Condition: strings.ToLower(req.Condition),
+		Threshold: req.Threshold,
+	}
+	if err := newWebhook.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	created, err := h.store.Create(c.UserContext(), newWebhook)
+	if errors.Is(err, webhook.ErrTooMany) {
+		return fiber.NewError(fiber.StatusConflict, "webhook limit reached, delete one before registering another")
+	}
+	if err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Webhook registered", "webhook_id", created.ID, "key_id", caller.ID, "base", created.Base, "target", created.Target, "condition", created.Condition)
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// List serves the caller's webhooks, with when each last fired and why its last delivery
+// failed, if it did.
+func (h *WebhookHandler) List(c *fiber.Ctx) error {
+	caller, err := webhookOwner(c)
+	if err != nil {
+		return err
+	}
+	webhooks, err := h.store.List(c.UserContext(), caller.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"webhooks": webhooks})
+}
+
+// Delete removes one of the caller's webhooks. Deliveries still pending are dropped.
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	caller, err := webhookOwner(c)
+	if err != nil {
+		return err
+	}
+	err = h.store.Delete(c.UserContext(), caller.ID, c.Params("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "webhook not found")
+	}
+	if err != nil {
+		return err
+	}
+	logging.WithRequest(c.UserContext(), h.logger).Info("Webhook deleted", "webhook_id", c.Params("id"), "key_id", caller.ID)
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/webhook"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWebhookStore struct {
+	webhooks map[string]webhook.Webhook
+	max      int
+	created  int
+}
+
+func (m *mockWebhookStore) Create(ctx context.Context, newWebhook webhook.NewWebhook) (webhook.Webhook, error) {
+	owned, _ := m.List(ctx, newWebhook.Owner)
+	if len(owned) >= m.max {
+		return webhook.Webhook{}, webhook.ErrTooMany
+	}
+	m.created++
+	w := webhook.Webhook{ID: fmt.Sprintf("wh-%d", m.created), Owner: newWebhook.Owner, URL: newWebhook.URL,
+		Base: newWebhook.Base, Target: newWebhook.Target, Condition: newWebhook.Condition, Threshold: newWebhook.Threshold}
+	m.webhooks[w.ID] = w
+	return w, nil
+}
+
+func (m *mockWebhookStore) List(ctx context.Context, owner string) ([]webhook.Webhook, error) {
+	webhooks := []webhook.Webhook{}
+	for _, w := range m.webhooks {
+		if w.Owner == owner {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookStore) Delete(ctx context.Context, owner, id string) error {
+	if w, ok := m.webhooks[id]; !ok || w.Owner != owner {
+		return webhook.ErrNotFound
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+func setupWebhookTestApp(store *mockWebhookStore) *fiber.App {
+	keys := newMockAPIKeyStore()
+	keys.add("cx_a", apikey.Key{ID: "partner-a", Role: apikey.RoleReader})
+	keys.add("cx_b", apikey.Key{ID: "partner-b", Role: apikey.RoleReader})
+	keys.add("cx_eur_only", apikey.Key{ID: "partner-eu", Role: apikey.RoleReader, Restrictions: apikey.Restrictions{Pairs: []string{"EUR/*"}}})
+	h := NewWebhookHandler(NewHandler(&MockRateService{}), store, discardLogger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(NewAPIKeyHandler(keys, discardLogger).Authenticate())
+	app.Get("/v1/webhooks", h.List)
+	app.Post("/v1/webhooks", h.Create)
+	app.Delete("/v1/webhooks/:id", h.Delete)
+	return app
+}
+
+func webhookRequest(t *testing.T, app *fiber.App, method, path, key, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	var decoded map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+func TestWebhooks_CreateListDelete(t *testing.T) {
+	store := &mockWebhookStore{webhooks: map[string]webhook.Webhook{}, max: 5}
+	app := setupWebhookTestApp(store)
+
+	status, body := webhookRequest(t, app, "POST", "/v1/webhooks", "cx_a",
+		`{"url": "https://example.com/hook", "secret": "0123456789abcdef", "pair": "usd-inr", "condition": "Above", "threshold": 86}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, "USD", body["base"])
+	assert.Equal(t, "INR", body["target"])
+	assert.Equal(t, "above", body["condition"])
+	assert.NotContains(t, body, "secret")
+	id := body["id"].(string)
+
+	// Only the owner sees and deletes it.
+	status, body = webhookRequest(t, app, "GET", "/v1/webhooks", "cx_b", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Empty(t, body["webhooks"])
+	status, _ = webhookRequest(t, app, "DELETE", "/v1/webhooks/"+id, "cx_b", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+
+	status, body = webhookRequest(t, app, "GET", "/v1/webhooks", "cx_a", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Len(t, body["webhooks"], 1)
+	status, _ = webhookRequest(t, app, "DELETE", "/v1/webhooks/"+id, "cx_a", "")
+	assert.Equal(t, fiber.StatusNoContent, status)
+	assert.Empty(t, store.webhooks)
+}
+
+func TestWebhooks_RejectsInvalidRequests(t *testing.T) {
+	store := &mockWebhookStore{webhooks: map[string]webhook.Webhook{}, max: 1}
+	app := setupWebhookTestApp(store)
+	valid := `{"url": "https://example.com/hook", "secret": "0123456789abcdef", "pair": "EUR-INR", "condition": "change", "threshold": 0.5}`
+
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+		code   string
+	}{
+		{"anonymous", "", valid, fiber.StatusUnauthorized, "Unauthorized"},
+		{"bad pair", "cx_a", strings.Replace(valid, "EUR-INR", "EURINR", 1), fiber.StatusBadRequest, "Bad Request"},
+		{"restricted pair", "cx_eur_only", strings.Replace(valid, "EUR-INR", "USD-INR", 1), fiber.StatusForbidden, PairNotAllowedCode},
+		{"bad url", "cx_a", strings.Replace(valid, "https://example.com/hook", "ftp://example.com", 1), fiber.StatusBadRequest, "Bad Request"},
+		{"short secret", "cx_a", strings.Replace(valid, "0123456789abcdef", "short", 1), fiber.StatusBadRequest, "Bad Request"},
+		{"unknown condition", "cx_a", strings.Replace(valid, "change", "sideways", 1), fiber.StatusBadRequest, "Bad Request"},
+		{"no threshold", "cx_a", strings.Replace(valid, "0.5", "0", 1), fiber.StatusBadRequest, "Bad Request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := webhookRequest(t, app, "POST", "/v1/webhooks", tt.key, tt.body)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, body["error"].(map[string]any)["code"])
+		})
+	}
+
+	status, _ := webhookRequest(t, app, "POST", "/v1/webhooks", "cx_eur_only", valid)
+	assert.Equal(t, fiber.StatusCreated, status)
+	status, _ = webhookRequest(t, app, "POST", "/v1/webhooks", "cx_eur_only", valid)
+	assert.Equal(t, fiber.StatusConflict, status)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_JWT(t *testing.T) {
+	setEnv(t, map[string]string{
+		"JWT_JWKS_URL":     "https://auth.example.com/.well-known/jwks.json",
+		"JWT_ISSUER":       "https://auth.example.com/",
+		"RATE_LIMIT_TIERS": `{"gold": {"rps": 50, "burst": 100}}`,
+	})
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.JWT.Enabled())
+	assert.Equal(t, 10*time.Minute, cfg.JWT.JWKSRefresh)
+	assert.Equal(t, "sub", cfg.JWT.SubjectClaim)
+	assert.Equal(t, "tier", cfg.JWT.TierClaim)
+	assert.Equal(t, "role", cfg.JWT.RoleClaim)
+	assert.Equal(t, "scope", cfg.JWT.ScopeClaim)
+	assert.Equal(t, Limit{RPS: 50, Burst: 100}, cfg.RateLimits.Tiers["gold"])
+}
+
+func TestLoadConfig_JWTDisabledByDefault(t *testing.T) {
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.False(t, cfg.JWT.Enabled())
+}
+
+func TestLoadConfig_JWTProblems(t *testing.T) {
+	setEnv(t, map[string]string{
+		"JWT_HMAC_SECRET":           "too-short",
+		"JWT_JWKS_URL":              "auth.example.com/jwks",
+		"JWT_JWKS_REFRESH_INTERVAL": "0s",
+	})
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`JWT_HMAC_SECRET: cannot be used with JWT_JWKS_URL, choose one way to verify tokens`,
+		`JWT_HMAC_SECRET: must be at least 32 bytes long, got 9`,
+		`JWT_JWKS_REFRESH_INTERVAL: must be greater than 0, got 0s`,
+		`JWT_JWKS_URL: "auth.example.com/jwks" is not a valid http(s) URL`,
+	}, validationErr.Problems)
+}
+
+func TestJWTConfig_StringRedactsSecret(t *testing.T) {
+	jwt := JWTConfig{HMACSecret: "0123456789abcdef0123456789abcdef", SubjectClaim: "sub"}
+	assert.NotContains(t, jwt.String(), jwt.HMACSecret)
+	assert.Contains(t, jwt.String(), "HMACSecret:[REDACTED]")
+}
+
+func TestLoadConfig_RequestSigning(t *testing.T) {
+	pepper := "0123456789abcdef0123456789abcdef"
+	setEnv(t, map[string]string{"REQUEST_SIGNING_ENABLED": "true", "REQUEST_SIGNING_PEPPER": pepper})
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.RequestSigningEnabled)
+	assert.Equal(t, 5*time.Minute, cfg.RequestSigningMaxSkew)
+	assert.Equal(t, pepper, cfg.RequestSigningPepper)
+
+	setEnv(t, map[string]string{"REQUEST_SIGNING_ENABLED": "true", "REQUEST_SIGNING_MAX_SKEW": "0s", "REQUEST_SIGNING_PEPPER": "short"})
+	_, err = LoadConfig()
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`REQUEST_SIGNING_MAX_SKEW: must be greater than 0, got 0s`,
+		`REQUEST_SIGNING_PEPPER: must be at least 32 bytes long when REQUEST_SIGNING_ENABLED is set, got 5`,
+	}, validationErr.Problems)
+}
+
+func TestLoadConfig_AuthLockout(t *testing.T) {
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.AuthLockoutThreshold)
+	assert.Equal(t, 10*time.Minute, cfg.AuthLockoutWindow)
+	assert.Equal(t, 15*time.Minute, cfg.AuthLockoutDuration)
+
+	// A threshold of 0 turns the lockout off, and with it the checks of the other settings.
+	setEnv(t, map[string]string{"AUTH_LOCKOUT_THRESHOLD": "0", "AUTH_LOCKOUT_DURATION": "0s"})
+	_, err = LoadConfig()
+	assert.NoError(t, err)
+
+	setEnv(t, map[string]string{"AUTH_LOCKOUT_THRESHOLD": "5", "AUTH_LOCKOUT_WINDOW": "0s", "AUTH_LOCKOUT_DURATION": "15m"})
+	_, err = LoadConfig()
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{`AUTH_LOCKOUT_WINDOW: must be greater than 0, got 0s`}, validationErr.Problems)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// BrokerConfig publishes every refresh to NATS, MQTT or both, for consumers that would
+// otherwise poll.
+type BrokerConfig struct {
+	NATSURL     string
+	NATSSubject string // prefix of the per-base subjects
+
+	MQTTBrokerURL string
+	MQTTClientID  string // unique per replica; derived from the host name when empty
+	MQTTUsername  string
+	MQTTPassword  string
+	MQTTTopic     string // prefix of the per-base topics
+	MQTTQoS       int
+	MQTTRetain    bool
+}
+
+// String keeps the MQTT password out of the "Config loaded" log line.
+func (b BrokerConfig) String() string {
+	password := ""
+	if b.MQTTPassword != "" {
+		password = "[REDACTED]"
+	}
+	return fmt.Sprintf("{NATSURL:%s NATSSubject:%s MQTTBrokerURL:%s MQTTClientID:%s MQTTUsername:%s MQTTPassword:%s MQTTTopic:%s MQTTQoS:%d MQTTRetain:%t}",
+		b.NATSURL, b.NATSSubject, b.MQTTBrokerURL, b.MQTTClientID, b.MQTTUsername, password, b.MQTTTopic, b.MQTTQoS, b.MQTTRetain)
+}
+
+// validateBroker checks the NATS_* and MQTT_* settings.
+func (c *Config) validateBroker(v *validator) {
+	b := c.Broker
+	if b.NATSURL != "" {
+		if strings.ContainsAny(b.NATSSubject, " \t*>") || b.NATSSubject == "" || strings.HasSuffix(b.NATSSubject, ".") {
+			v.addf("NATS_SUBJECT", "%q is not a subject to publish on", b.NATSSubject)
+		}
+	}
+	if b.MQTTBrokerURL != "" {
+		u, err := url.Parse(b.MQTTBrokerURL)
+		if err != nil || u.Host == "" {
+			v.addf("MQTT_BROKER_URL", "%q is not a broker URL such as tcp://mosquitto:1883", b.MQTTBrokerURL)
+		} else {
+			v.oneOf("MQTT_BROKER_URL", u.Scheme, "tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss")
+		}
+		if strings.ContainsAny(b.MQTTTopic, "+#") || b.MQTTTopic == "" || strings.HasSuffix(b.MQTTTopic, "/") {
+			v.addf("MQTT_TOPIC", "%q is not a topic to publish on", b.MQTTTopic)
+		}
+		if b.MQTTQoS < 0 || b.MQTTQoS > 2 {
+			v.addf("MQTT_QOS", "must be 0, 1 or 2, got %d", b.MQTTQoS)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_Broker(t *testing.T) {
+	setEnv(t, map[string]string{
+		"NATS_URL":        "nats://nats:4222",
+		"MQTT_BROKER_URL": "tcp://mosquitto:1883",
+		"MQTT_PASSWORD":   "hunter2",
+	})
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "rates", cfg.Broker.NATSSubject)
+	assert.Equal(t, "rates", cfg.Broker.MQTTTopic)
+	assert.Equal(t, 0, cfg.Broker.MQTTQoS)
+	assert.True(t, cfg.Broker.MQTTRetain)
+	assert.NotContains(t, cfg.Broker.String(), "hunter2")
+}
+
+func TestLoadConfig_BrokerProblems(t *testing.T) {
+	setEnv(t, map[string]string{
+		"NATS_URL":        "nats://nats:4222",
+		"NATS_SUBJECT":    "rates.>",
+		"MQTT_BROKER_URL": "http://mosquitto:1883",
+		"MQTT_TOPIC":      "rates/#",
+		"MQTT_QOS":        "3",
+	})
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`MQTT_BROKER_URL: "http" is not one of tcp, ssl, tls, mqtt, mqtts, ws, wss`,
+		`MQTT_QOS: must be 0, 1 or 2, got 3`,
+		`MQTT_TOPIC: "rates/#" is not a topic to publish on`,
+		`NATS_SUBJECT: "rates.>" is not a subject to publish on`,
+	}, validationErr.Problems)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"text/template"
+)
+
+// EmailConfig emails alerts over SMTP, alongside ALERT_WEBHOOK_URL.
+type EmailConfig struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string
+	Recipients []EmailRecipient
+	// SubjectTemplate and BodyTemplate replace the default text/template sources.
+	SubjectTemplate string
+	BodyTemplate    string
+}
+
+// EmailRecipient is one ALERT_EMAIL_RECIPIENTS entry.
+type EmailRecipient struct {
+	Address  string
+	Name     string
+	Kinds    []string // alert kinds wanted, refresh or rate; empty means all
+	Resolved bool     // also send resolved alerts
+}
+
+// Enabled reports whether alerts are emailed.
+func (e EmailConfig) Enabled() bool {
+	return e.Host != ""
+}
+
+// String keeps the SMTP password out of the "Config loaded" log line.
+func (e EmailConfig) String() string {
+	password := ""
+	if e.Password != "" {
+		password = "[REDACTED]"
+	}
+	return fmt.Sprintf("{Host:%s Port:%d Username:%s Password:%s From:%s Recipients:%v}", e.Host, e.Port, e.Username, password, e.From, e.Recipients)
+}
+
+type emailRecipientJSON struct {
+	Address  string   `json:"address"`
+	Name     string   `json:"name"`
+	Kinds    []string `json:"kinds"`
+	Resolved *bool    `json:"resolved"`
+}
+
+// parseEmailRecipients reads ALERT_EMAIL_RECIPIENTS, a JSON array. Recipients get resolved
+// alerts unless they opt out:
+//
+//	[{"address": "ops@example.com"}, {"address": "fx@example.com", "name": "FX desk", "kinds": ["rate"], "resolved": false}]
+func parseEmailRecipients(value string) ([]EmailRecipient, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var raw []emailRecipientJSON
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, err
+	}
+	recipients := make([]EmailRecipient, 0, len(raw))
+	for _, entry := range raw {
+		recipient := EmailRecipient{Address: entry.Address, Name: entry.Name, Kinds: entry.Kinds, Resolved: true}
+		if entry.Resolved != nil {
+			recipient.Resolved = *entry.Resolved
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// validateEmail checks the SMTP_* and ALERT_EMAIL_* settings.
+func (c *Config) validateEmail(v *validator) {
+	email := c.Email
+	if !email.Enabled() {
+		if len(email.Recipients) > 0 {
+			v.addf("ALERT_EMAIL_RECIPIENTS", "requires SMTP_HOST")
+		}
+		return
+	}
+	if email.Port < 1 || email.Port > 65535 {
+		v.addf("SMTP_PORT", "must be a port between 1 and 65535, got %d", email.Port)
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		v.addf("ALERT_EMAIL_FROM", "%q is not an email address", email.From)
+	}
+	if len(email.Recipients) == 0 {
+		v.addf("ALERT_EMAIL_RECIPIENTS", "must list at least one recipient when SMTP_HOST is set")
+	}
+	for _, recipient := range email.Recipients {
+		if _, err := mail.ParseAddress(recipient.Address); err != nil {
+			v.addf("ALERT_EMAIL_RECIPIENTS", "%q is not an email address", recipient.Address)
+		}
+		for _, kind := range recipient.Kinds {
+			v.oneOf("ALERT_EMAIL_RECIPIENTS", kind, "refresh", "rate")
+		}
+	}
+	if _, err := template.New("subject").Parse(email.SubjectTemplate); err != nil {
+		v.addf("ALERT_EMAIL_SUBJECT_TEMPLATE", "%v", err)
+	}
+	if _, err := template.New("body").Parse(email.BodyTemplate); err != nil {
+		v.addf("ALERT_EMAIL_BODY_TEMPLATE", "%v", err)
+	}
+}
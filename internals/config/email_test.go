@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_Email(t *testing.T) {
+	setEnv(t, map[string]string{
+		"SMTP_HOST":              "smtp.example.com",
+		"SMTP_PASSWORD":          "hunter2",
+		"ALERT_EMAIL_FROM":       "Exchange Rates <alerts@example.com>",
+		"ALERT_EMAIL_RECIPIENTS": `[{"address": "ops@example.com"}, {"address": "fx@example.com", "name": "FX desk", "kinds": ["rate"], "resolved": false}]`,
+	})
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.Email.Enabled())
+	assert.Equal(t, 587, cfg.Email.Port)
+	assert.Equal(t, []EmailRecipient{
+		{Address: "ops@example.com", Resolved: true},
+		{Address: "fx@example.com", Name: "FX desk", Kinds: []string{"rate"}},
+	}, cfg.Email.Recipients)
+	assert.NotContains(t, cfg.Email.String(), "hunter2")
+}
+
+func TestLoadConfig_EmailProblems(t *testing.T) {
+	setEnv(t, map[string]string{
+		"SMTP_HOST":                    "smtp.example.com",
+		"SMTP_PORT":                    "0",
+		"ALERT_EMAIL_RECIPIENTS":       `[{"address": "ops"}, {"address": "fx@example.com", "kinds": ["weather"]}]`,
+		"ALERT_EMAIL_SUBJECT_TEMPLATE": "{{.Alert.Title",
+	})
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`ALERT_EMAIL_FROM: "" is not an email address`,
+		`ALERT_EMAIL_RECIPIENTS: "ops" is not an email address`,
+		`ALERT_EMAIL_RECIPIENTS: "weather" is not one of refresh, rate`,
+		`ALERT_EMAIL_SUBJECT_TEMPLATE: template: subject:1: unclosed action`,
+		`SMTP_PORT: must be a port between 1 and 65535, got 0`,
+	}, validationErr.Problems)
+
+	setEnv(t, map[string]string{"ALERT_EMAIL_RECIPIENTS": `{"ops@example.com": {}}`})
+	_, err = LoadConfig()
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Problems, `ALERT_EMAIL_RECIPIENTS: "{\"ops@example.com\": {}}" is not a valid JSON list of recipients`)
+	assert.NotContains(t, validationErr.Problems, `ALERT_EMAIL_RECIPIENTS: must list at least one recipient when SMTP_HOST is set`)
+}
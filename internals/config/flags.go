@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// flagKeys maps each command-line flag onto the setting it overrides.
+var flagKeys = []struct {
+	flag, key, usage string
+}{
+	{"port", "SERVER_PORT", "port to listen on"},
+	{"redis-addr", "REDIS_ADDR", "Redis host:port"},
+	{"provider", "RATE_PROVIDER", "exchange rate provider"},
+	{"log-level", "LOG_LEVEL", "log level: debug, info, warn or error"},
+}
+
+// BindFlags registers the command-line flags on fs. Once fs is parsed, flags that were passed
+// win over env vars and profile defaults in LoadConfig; flags that weren't change nothing.
+func BindFlags(fs *pflag.FlagSet) error {
+	for _, f := range flagKeys {
+		fs.String(f.flag, "", f.usage+" (overrides "+f.key+")")
+		if err := viper.BindPFlag(f.key, fs.Lookup(f.flag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFlags(t *testing.T, args ...string) {
+	t.Cleanup(viper.Reset)
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	assert.NoError(t, BindFlags(fs))
+	assert.NoError(t, fs.Parse(args))
+}
+
+func TestBindFlags_OverrideEnv(t *testing.T) {
+	setEnv(t, map[string]string{"SERVER_PORT": "8080", "RATE_PROVIDER": "ecb"})
+	parseFlags(t, "--port", "9090", "--redis-addr=redis.internal:6380", "--log-level", "DEBUG")
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "9090", cfg.ServerPort)
+	assert.Equal(t, "redis.internal:6380", cfg.RedisAddr)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "ecb", cfg.RateProvider)
+}
+
+func TestBindFlags_OverrideProfileDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "dev")
+	parseFlags(t, "--provider", "frankfurter")
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "frankfurter", cfg.RateProvider)
+}
+
+func TestBindFlags_UnsetFlagsKeepDefaults(t *testing.T) {
+	parseFlags(t)
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.ServerPort)
+	assert.Equal(t, "info", cfg.LogLevel)
+}
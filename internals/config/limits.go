@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Limit caps how fast and how much one caller may use the API.
+type Limit struct {
+	RPS          float64 // sustained requests per second; 0 means unlimited
+	Burst        int     // requests allowed above RPS in a short spike
+	MonthlyQuota int64   // requests per calendar month; 0 means unlimited
+}
+
+// RateLimitConfig holds the inbound rate limits and quotas. Global is shared by every caller,
+// PerKey applies to each API key and PerIP to callers that don't send one.
+type RateLimitConfig struct {
+	Enabled bool
+	Global  Limit
+	PerKey  Limit
+	PerIP   Limit
+	Keys    map[string]Limit // per-key overrides, keyed by API key ID
+	Tiers   map[string]Limit // per-tier overrides, for callers whose JWT names a tier
+}
+
+// ForKey returns the limit for the API key with the given ID.
+func (r RateLimitConfig) ForKey(id string) Limit {
+	if limit, ok := r.Keys[id]; ok {
+		return limit
+	}
+	return r.PerKey
+}
+
+// ForCaller returns the limit for the caller with the given ID and tier: its own override if
+// it has one, then its tier's, then the default.
+func (r RateLimitConfig) ForCaller(id, tier string) Limit {
+	if limit, ok := r.Keys[id]; ok {
+		return limit
+	}
+	if limit, ok := r.Tiers[tier]; ok && tier != "" {
+		return limit
+	}
+	return r.PerKey
+}
+
+// limitJSON uses pointers so an override can set a field to 0 (unlimited) explicitly.
+type limitJSON struct {
+	RPS          *float64 `json:"rps"`
+	Burst        *int     `json:"burst"`
+	MonthlyQuota *int64   `json:"monthlyQuota"`
+}
+
+// parseKeyLimits reads the RATE_LIMIT_KEYS or RATE_LIMIT_TIERS section named key, a JSON
+// object keyed by API key ID or tier. Fields left out fall back to base:
+//
+//	{"partner-a": {"rps": 50, "burst": 100, "monthlyQuota": 1000000}}
+func parseKeyLimits(key, value string, base Limit) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	raw := make(map[string]limitJSON)
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	for id, entry := range raw {
+		limit := base
+		if entry.RPS != nil {
+			limit.RPS = *entry.RPS
+		}
+		if entry.Burst != nil {
+			limit.Burst = *entry.Burst
+		}
+		if entry.MonthlyQuota != nil {
+			limit.MonthlyQuota = *entry.MonthlyQuota
+		}
+		limits[id] = limit
+	}
+	return limits, nil
+}
+
+// limit checks one Limit; the keys name its fields in problem messages.
+func (v *validator) limit(rpsKey, burstKey, quotaKey string, limit Limit) {
+	if limit.RPS < 0 {
+		v.addf(rpsKey, "must not be negative, got %g", limit.RPS)
+	}
+	if limit.RPS > 0 && limit.Burst < 1 {
+		v.addf(burstKey, "must be at least 1 when %s is set, got %d", rpsKey, limit.Burst)
+	}
+	if limit.MonthlyQuota < 0 {
+		v.addf(quotaKey, "must not be negative, got %d", limit.MonthlyQuota)
+	}
+}
+
+// validateRateLimits checks the RATE_LIMIT_* settings.
+func (c *Config) validateRateLimits(v *validator) {
+	limits := c.RateLimits
+	v.limit("RATE_LIMIT_GLOBAL_RPS", "RATE_LIMIT_GLOBAL_BURST", "", limits.Global)
+	v.limit("RATE_LIMIT_KEY_RPS", "RATE_LIMIT_KEY_BURST", "RATE_LIMIT_KEY_MONTHLY_QUOTA", limits.PerKey)
+	v.limit("RATE_LIMIT_IP_RPS", "RATE_LIMIT_IP_BURST", "", limits.PerIP)
+	for id, limit := range limits.Keys {
+		prefix := "RATE_LIMIT_KEYS." + id + "."
+		v.limit(prefix+"rps", prefix+"burst", prefix+"monthlyQuota", limit)
+	}
+	for tier, limit := range limits.Tiers {
+		prefix := "RATE_LIMIT_TIERS." + tier + "."
+		v.limit(prefix+"rps", prefix+"burst", prefix+"monthlyQuota", limit)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyLimits(t *testing.T) {
+	base := Limit{RPS: 10, Burst: 20, MonthlyQuota: 1000}
+
+	limits, err := parseKeyLimits("RATE_LIMIT_KEYS", `{"partner-a": {"rps": 50, "burst": 100}, "internal": {"monthlyQuota": 0}}`, base)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{RPS: 50, Burst: 100, MonthlyQuota: 1000}, limits["partner-a"])
+	assert.Equal(t, Limit{RPS: 10, Burst: 20}, limits["internal"])
+}
+
+func TestParseKeyLimits_Invalid(t *testing.T) {
+	_, err := parseKeyLimits("RATE_LIMIT_KEYS", `{"partner-a": {"rps": "fast"}}`, Limit{})
+	assert.ErrorContains(t, err, "invalid RATE_LIMIT_KEYS")
+}
+
+func TestRateLimitConfig_ForKey(t *testing.T) {
+	limits := RateLimitConfig{
+		PerKey: Limit{RPS: 10, Burst: 20},
+		Keys:   map[string]Limit{"partner-a": {RPS: 50, Burst: 100}},
+	}
+	assert.Equal(t, Limit{RPS: 50, Burst: 100}, limits.ForKey("partner-a"))
+	assert.Equal(t, Limit{RPS: 10, Burst: 20}, limits.ForKey("someone-else"))
+}
+
+func TestLoadConfig_RateLimits(t *testing.T) {
+	setEnv(t, map[string]string{
+		"RATE_LIMIT_ENABLED":           "true",
+		"RATE_LIMIT_KEY_MONTHLY_QUOTA": "50000",
+		"RATE_LIMIT_KEYS":              `{"partner-a": {"rps": 50, "burst": 100}}`,
+	})
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.RateLimits.Enabled)
+	assert.Equal(t, Limit{RPS: 10, Burst: 20, MonthlyQuota: 50000}, cfg.RateLimits.PerKey)
+	assert.Equal(t, Limit{RPS: 5, Burst: 10}, cfg.RateLimits.PerIP)
+	assert.Equal(t, Limit{RPS: 50, Burst: 100, MonthlyQuota: 50000}, cfg.RateLimits.ForKey("partner-a"))
+}
+
+func TestLoadConfig_RateLimitProblems(t *testing.T) {
+	setEnv(t, map[string]string{
+		"RATE_LIMIT_GLOBAL_RPS":        "100",
+		"RATE_LIMIT_KEY_MONTHLY_QUOTA": "-1",
+		"RATE_LIMIT_KEYS":              `{"partner-a": {"rps": -5}}`,
+	})
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`RATE_LIMIT_GLOBAL_BURST: must be at least 1 when RATE_LIMIT_GLOBAL_RPS is set, got 0`,
+		`RATE_LIMIT_KEYS.partner-a.monthlyQuota: must not be negative, got -1`,
+		`RATE_LIMIT_KEYS.partner-a.rps: must not be negative, got -5`,
+		`RATE_LIMIT_KEY_MONTHLY_QUOTA: must not be negative, got -1`,
+	}, validationErr.Problems)
+}
+
+func TestRateLimitConfig_ForCaller(t *testing.T) {
+	limits := RateLimitConfig{
+		PerKey: Limit{RPS: 10, Burst: 20},
+		Keys:   map[string]Limit{"partner-a": {RPS: 50, Burst: 100}},
+		Tiers:  map[string]Limit{"gold": {RPS: 30, Burst: 60}},
+	}
+	assert.Equal(t, Limit{RPS: 50, Burst: 100}, limits.ForCaller("partner-a", "gold"))
+	assert.Equal(t, Limit{RPS: 30, Burst: 60}, limits.ForCaller("acme", "gold"))
+	assert.Equal(t, Limit{RPS: 10, Burst: 20}, limits.ForCaller("acme", "silver"))
+	assert.Equal(t, Limit{RPS: 10, Burst: 20}, limits.ForCaller("acme", ""))
+}
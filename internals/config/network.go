@@ -0,0 +1,52 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// NetworkConfig restricts which client IPs may call the service, for deployments limited to
+// internal networks or specific partner ranges. Ranges are CIDRs; a bare IP is a range of one.
+type NetworkConfig struct {
+	Allow []netip.Prefix // when set, only these ranges are served
+	Deny  []netip.Prefix // never served, even when allowed
+	// TrustedProxies are the ranges of the proxies in front of the service. Only requests
+	// arriving from them have their client IP read from ClientIPHeader.
+	TrustedProxies []netip.Prefix
+	ClientIPHeader string
+}
+
+// prefixes parses the comma separated CIDR ranges or IPs of key.
+func (v *validator) prefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range splitList(viper.GetString(key)) {
+		prefix, err := parsePrefix(item)
+		if err != nil {
+			v.parseFailed(key, item, "CIDR range or IP address")
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// validateNetwork checks the IP_* and proxy settings.
+func (c *Config) validateNetwork(v *validator) {
+	if len(c.Network.TrustedProxies) > 0 && strings.TrimSpace(c.Network.ClientIPHeader) == "" {
+		v.addf("CLIENT_IP_HEADER", "must not be empty when TRUSTED_PROXIES is set")
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_Network(t *testing.T) {
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Network.Allow)
+	assert.Equal(t, "X-Forwarded-For", cfg.Network.ClientIPHeader)
+
+	setEnv(t, map[string]string{
+		"IP_ALLOWLIST":    "10.0.0.0/8, 203.0.113.7",
+		"IP_DENYLIST":     "10.1.2.3/16",
+		"TRUSTED_PROXIES": "2001:db8::/32",
+	})
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("203.0.113.7/32")}, cfg.Network.Allow)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}, cfg.Network.Deny)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}, cfg.Network.TrustedProxies)
+}
+
+func TestLoadConfig_NetworkInvalid(t *testing.T) {
+	setEnv(t, map[string]string{
+		"IP_ALLOWLIST":     "10.0.0.0/33,partner",
+		"TRUSTED_PROXIES":  "10.0.0.1",
+		"CLIENT_IP_HEADER": " ",
+	})
+	_, err := LoadConfig()
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		`CLIENT_IP_HEADER: must not be empty when TRUSTED_PROXIES is set`,
+		`IP_ALLOWLIST: "10.0.0.0/33" is not a valid CIDR range or IP address`,
+	}, validationErr.Problems)
+}
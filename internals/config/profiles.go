@@ -0,0 +1,68 @@
+package config
+
+import "strings"
+
+// profile bundles the defaults and extra checks of one APP_ENV. Profile defaults replace the
+// built-in defaults but, like them, are overridden by anything set explicitly.
+type profile struct {
+	defaults map[string]any
+	// requireAdminKey refuses to start without ADMIN_API_KEY, so admin endpoints are never
+	// left open.
+	requireAdminKey bool
+	// production refuses settings only meant for local work: fake or recorded provider data
+	// and the in-process cache.
+	production bool
+}
+
+var profiles = map[string]profile{
+	// dev runs without network access or a Redis server.
+	"dev": {
+		defaults: map[string]any{
+			"RATE_PROVIDER":           "sandbox",
+			"CACHE_BACKEND":           "memory",
+			"REFRESH_START_JITTER":    "0s",
+			"LEADER_ELECTION_ENABLED": false,
+			"CACHE_BYPASS_ENABLED":    true,
+		},
+	},
+	"staging": {
+		requireAdminKey: true,
+	},
+	// prod samples repetitive debug lines so LOG_LEVEL=debug during an incident or backfill
+	// can't fill the disk.
+	"prod": {
+		defaults: map[string]any{
+			"LOG_SAMPLE_INITIAL": 100,
+		},
+		requireAdminKey: true,
+		production:      true,
+	},
+}
+
+// lookupProfile returns the profile for appEnv. An empty APP_ENV keeps the built-in defaults.
+func lookupProfile(appEnv string) (profile, bool) {
+	if appEnv == "" {
+		return profile{}, true
+	}
+	p, ok := profiles[strings.ToLower(appEnv)]
+	return p, ok
+}
+
+// validate applies the profile's extra checks.
+func (p profile) validate(c *Config, v *validator) {
+	if p.requireAdminKey && c.AdminAPIKey == "" {
+		v.addf("ADMIN_API_KEY", "is required when APP_ENV is %s", c.AppEnv)
+	}
+	if !p.production {
+		return
+	}
+	if c.RateProvider == "sandbox" {
+		v.addf("RATE_PROVIDER", "the sandbox provider serves fake rates and can't be used when APP_ENV is %s", c.AppEnv)
+	}
+	if c.ProviderRecordMode == "replay" {
+		v.addf("PROVIDER_RECORD_MODE", "replaying recorded responses can't be used when APP_ENV is %s", c.AppEnv)
+	}
+	if c.CacheBackend == "memory" {
+		v.addf("CACHE_BACKEND", "the in-process cache is not shared between replicas and can't be used when APP_ENV is %s", c.AppEnv)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_NoProfile(t *testing.T) {
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "frankfurter", cfg.RateProvider)
+	assert.Equal(t, "redis", cfg.CacheBackend)
+}
+
+func TestLoadConfig_DevProfile(t *testing.T) {
+	t.Setenv("APP_ENV", "dev")
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sandbox", cfg.RateProvider)
+	assert.Equal(t, "memory", cfg.CacheBackend)
+	assert.Equal(t, time.Duration(0), cfg.RefreshStartJitter)
+	assert.False(t, cfg.LeaderElectionEnabled)
+}
+
+func TestLoadConfig_ProfileDefaultsYieldToEnv(t *testing.T) {
+	setEnv(t, map[string]string{"APP_ENV": "DEV", "RATE_PROVIDER": "ecb"})
+
+	cfg, err := LoadConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", cfg.AppEnv)
+	assert.Equal(t, "ecb", cfg.RateProvider)
+	assert.Equal(t, "memory", cfg.CacheBackend)
+}
+
+func TestLoadConfig_ProdProfile(t *testing.T) {
+	setEnv(t, map[string]string{"APP_ENV": "prod", "RATE_PROVIDER": "sandbox", "CACHE_BACKEND": "memory"})
+
+	_, err := LoadConfig()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 3)
+	assert.Contains(t, err.Error(), "ADMIN_API_KEY: is required when APP_ENV is prod")
+	assert.Contains(t, err.Error(), "RATE_PROVIDER: the sandbox provider")
+	assert.Contains(t, err.Error(), "CACHE_BACKEND: the in-process cache")
+
+	setEnv(t, map[string]string{"RATE_PROVIDER": "frankfurter", "CACHE_BACKEND": "redis", "ADMIN_API_KEY": "secret"})
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 100, cfg.LogSampleInitial)
+}
+
+func TestLoadConfig_StagingRequiresAdminKey(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+	_, err := LoadConfig()
+	assert.ErrorContains(t, err, "ADMIN_API_KEY: is required when APP_ENV is staging")
+}
+
+func TestLoadConfig_UnknownProfile(t *testing.T) {
+	t.Setenv("APP_ENV", "qa")
+	_, err := LoadConfig()
+	assert.ErrorContains(t, err, `APP_ENV: "qa" is not one of dev, staging, prod`)
+}
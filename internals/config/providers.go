@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProviderConfig holds the connection settings of one rate provider.
+type ProviderConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration // overall deadline per call, retries included; 0 means none
+}
+
+// String keeps the API key out of the "Config loaded" log line.
+func (p ProviderConfig) String() string {
+	return fmt.Sprintf("{BaseURL:%s APIKey:%s Timeout:%s}", p.BaseURL, redacted(p.APIKey), p.Timeout)
+}
+
+type providerConfigJSON struct {
+	BaseURL string `json:"baseURL"`
+	APIKey  string `json:"apiKey"`
+	Timeout string `json:"timeout"`
+}
+
+// parseProviders reads the PROVIDERS section, a JSON object keyed by provider name:
+//
+//	{"fixer": {"baseURL": "https://data.fixer.io/api/", "apiKey": "...", "timeout": "10s"}}
+func parseProviders(value string) (map[string]ProviderConfig, error) {
+	providers := make(map[string]ProviderConfig)
+	if strings.TrimSpace(value) == "" {
+		return providers, nil
+	}
+
+	raw := make(map[string]providerConfigJSON)
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("invalid PROVIDERS: %w", err)
+	}
+	for name, entry := range raw {
+		provider := ProviderConfig{BaseURL: entry.BaseURL, APIKey: entry.APIKey}
+		if entry.Timeout != "" {
+			timeout, err := time.ParseDuration(entry.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PROVIDERS timeout for %s: %w", name, err)
+			}
+			provider.Timeout = timeout
+		}
+		providers[strings.ToLower(name)] = provider
+	}
+	return providers, nil
+}
+
+// legacyProvider maps the flat per-provider env vars onto a ProviderConfig.
+func (c *Config) legacyProvider(name string) ProviderConfig {
+	switch name {
+	case "frankfurter":
+		return ProviderConfig{BaseURL: c.ExternalAPIURL}
+	case "ecb":
+		return ProviderConfig{BaseURL: c.ECBFeedURL}
+	case "openexchangerates":
+		return ProviderConfig{BaseURL: c.OXRAPIURL, APIKey: c.OXRAppID}
+	case "fixer":
+		return ProviderConfig{BaseURL: c.FixerAPIURL, APIKey: c.FixerAPIKey}
+	case "currencylayer":
+		return ProviderConfig{BaseURL: c.CurrencyLayerAPIURL, APIKey: c.CurrencyLayerAPIKey}
+	case "exchangeratehost":
+		return ProviderConfig{BaseURL: c.ExchangeRateHostAPIURL, APIKey: c.ExchangeRateHostAPIKey}
+	case "coingecko":
+		return ProviderConfig{BaseURL: c.CoinGeckoAPIURL, APIKey: c.CoinGeckoAPIKey}
+	case "metalpriceapi":
+		return ProviderConfig{BaseURL: c.MetalPriceAPIURL, APIKey: c.MetalPriceAPIKey}
+	}
+	return ProviderConfig{}
+}
+
+// Provider returns the settings for the named provider. Fields set in the PROVIDERS section
+// win over the older flat env vars such as EXTERNAL_API_URL or FIXER_API_KEY.
+func (c *Config) Provider(name string) ProviderConfig {
+	name = strings.ToLower(name)
+	provider := c.legacyProvider(name)
+
+	override, ok := c.Providers[name]
+	if !ok {
+		return provider
+	}
+	if override.BaseURL != "" {
+		provider.BaseURL = override.BaseURL
+	}
+	if override.APIKey != "" {
+		provider.APIKey = override.APIKey
+	}
+	if override.Timeout > 0 {
+		provider.Timeout = override.Timeout
+	}
+	return provider
+}
+
+// RateLimit is an outbound token bucket: RPS tokens are added per second up to Burst.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// ParseRateLimits parses per-provider overrides written as "name=rps:burst,name=rps:burst".
+func ParseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		rpsValue, burstValue, hasBurst := strings.Cut(spec, ":")
+		if !ok || !hasBurst {
+			return nil, fmt.Errorf("invalid rate limit %q, expected name=rps:burst", entry)
+		}
+		rps, err := strconv.ParseFloat(rpsValue, 64)
+		if err != nil || rps <= 0 {
+			return nil, fmt.Errorf("invalid rps in rate limit %q", entry)
+		}
+		burst, err := strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid burst in rate limit %q", entry)
+		}
+		limits[strings.ToLower(strings.TrimSpace(name))] = RateLimit{RPS: rps, Burst: burst}
+	}
+	return limits, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProviders(t *testing.T) {
+	providers, err := parseProviders(`{"Fixer": {"baseURL": "https://fixer.test/", "apiKey": "secret", "timeout": "10s"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderConfig{BaseURL: "https://fixer.test/", APIKey: "secret", Timeout: 10 * time.Second}, providers["fixer"])
+}
+
+func TestProviderConfig_StringRedactsAPIKey(t *testing.T) {
+	cfg := Config{Providers: map[string]ProviderConfig{"fixer": {BaseURL: "https://fixer.test/", APIKey: "provider-key-value"}}}
+	logged := fmt.Sprintf("%+v", cfg)
+	assert.NotContains(t, logged, "provider-key-value")
+	assert.Contains(t, logged, "fixer:{BaseURL:https://fixer.test/ APIKey:[REDACTED] Timeout:0s}")
+}
+
+func TestParseProviders_Empty(t *testing.T) {
+	providers, err := parseProviders("  ")
+	assert.NoError(t, err)
+	assert.Empty(t, providers)
+}
+
+func TestParseProviders_Invalid(t *testing.T) {
+	_, err := parseProviders(`{"fixer": `)
+	assert.ErrorContains(t, err, "invalid PROVIDERS")
+
+	_, err = parseProviders(`{"fixer": {"timeout": "soon"}}`)
+	assert.ErrorContains(t, err, "invalid PROVIDERS timeout for fixer")
+}
+
+func TestProvider_OverridesLegacyEnv(t *testing.T) {
+	cfg := &Config{
+		FixerAPIURL: "https://data.fixer.io/api/",
+		FixerAPIKey: "legacy",
+		Providers: map[string]ProviderConfig{
+			"fixer": {APIKey: "structured", Timeout: 5 * time.Second},
+		},
+	}
+
+	provider := cfg.Provider("Fixer")
+	assert.Equal(t, "https://data.fixer.io/api/", provider.BaseURL)
+	assert.Equal(t, "structured", provider.APIKey)
+	assert.Equal(t, 5*time.Second, provider.Timeout)
+}
+
+func TestProvider_FallsBackToLegacyEnv(t *testing.T) {
+	cfg := &Config{ExternalAPIURL: "https://api.frankfurter.app/"}
+
+	assert.Equal(t, ProviderConfig{BaseURL: "https://api.frankfurter.app/"}, cfg.Provider("frankfurter"))
+	assert.Equal(t, ProviderConfig{}, cfg.Provider("unknown"))
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits(" Fixer=0.5:1, coingecko=2:5 ,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]RateLimit{
+		"fixer":     {RPS: 0.5, Burst: 1},
+		"coingecko": {RPS: 2, Burst: 5},
+	}, limits)
+
+	for _, bad := range []string{"fixer", "fixer=1", "fixer=x:1", "fixer=1:0", "fixer=-1:2"} {
+		_, err := ParseRateLimits(bad)
+		assert.Error(t, err, bad)
+	}
+}
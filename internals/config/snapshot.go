@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SnapshotConfig copies the archived rates to S3-compatible object storage as compressed
+// daily snapshots, as an off-site backup of the archive database.
+type SnapshotConfig struct {
+	Bucket    string // empty to not take snapshots
+	Prefix    string // prepended to the object keys
+	Endpoint  string // host[:port] of the S3 API
+	Region    string
+	AccessKey string
+	SecretKey string
+	Insecure  bool // plain HTTP, e.g. for a local MinIO
+	Interval  time.Duration
+	Days      int // how many past days each run writes again
+}
+
+// Enabled reports whether snapshots are taken.
+func (s SnapshotConfig) Enabled() bool {
+	return s.Bucket != ""
+}
+
+// String keeps the secret key out of the "Config loaded" log line.
+func (s SnapshotConfig) String() string {
+	secretKey := ""
+	if s.SecretKey != "" {
+		secretKey = "[REDACTED]"
+	}
+	return fmt.Sprintf("{Bucket:%s Prefix:%s Endpoint:%s Region:%s AccessKey:%s SecretKey:%s Insecure:%t Interval:%s Days:%d}",
+		s.Bucket, s.Prefix, s.Endpoint, s.Region, s.AccessKey, secretKey, s.Insecure, s.Interval, s.Days)
+}
+
+// validateSnapshot checks the SNAPSHOT_* settings.
+func (c *Config) validateSnapshot(v *validator) {
+	s := c.Snapshot
+	if !s.Enabled() {
+		return
+	}
+	if !c.Archive.Enabled() {
+		v.addf("SNAPSHOT_S3_BUCKET", "snapshots are taken of the rate archive, set ARCHIVE_BACKEND too")
+	}
+	if s.Endpoint == "" || strings.Contains(s.Endpoint, "/") {
+		v.addf("SNAPSHOT_S3_ENDPOINT", "%q is not a host[:port] such as s3.amazonaws.com", s.Endpoint)
+	}
+	if (s.AccessKey == "") != (s.SecretKey == "") {
+		v.addf("SNAPSHOT_S3_ACCESS_KEY", "SNAPSHOT_S3_ACCESS_KEY and SNAPSHOT_S3_SECRET_KEY are set together")
+	}
+	v.positive("SNAPSHOT_INTERVAL", s.Interval)
+	v.atLeast("SNAPSHOT_DAYS", s.Days, 1)
+}
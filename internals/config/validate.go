@@ -0,0 +1,386 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"currency-exchange/internals/core/domain"
+
+	"github.com/spf13/viper"
+)
+
+// ValidationError lists every problem found in the configuration, so all of them can be
+// fixed in one go instead of discovering them one restart at a time.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// currencyCode matches ISO 4217 codes as well as the slightly longer tickers used for crypto assets.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3,5}$`)
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// validator collects configuration problems. Keys that failed to parse are remembered so the
+// range checks that follow don't report them a second time.
+type validator struct {
+	problems []string
+	invalid  map[string]bool
+}
+
+func newValidator() *validator {
+	return &validator{invalid: make(map[string]bool)}
+}
+
+func (v *validator) addf(key, format string, args ...any) {
+	if v.invalid[key] {
+		return
+	}
+	v.problems = append(v.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) parseFailed(key, value, kind string) {
+	v.addf(key, "%q is not a valid %s", value, kind)
+	v.invalid[key] = true
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	sort.Strings(v.problems)
+	return &ValidationError{Problems: v.problems}
+}
+
+// duration reads key as a Go duration such as "30s" or "1h".
+func (v *validator) duration(key string) time.Duration {
+	value := viper.GetString(key)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		v.parseFailed(key, value, "duration")
+	}
+	return d
+}
+
+func (v *validator) integer(key string) int {
+	value := strings.TrimSpace(viper.GetString(key))
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		v.parseFailed(key, value, "integer")
+	}
+	return n
+}
+
+func (v *validator) integer64(key string) int64 {
+	value := strings.TrimSpace(viper.GetString(key))
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		v.parseFailed(key, value, "integer")
+	}
+	return n
+}
+
+func (v *validator) boolean(key string) bool {
+	value := strings.TrimSpace(viper.GetString(key))
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		v.parseFailed(key, value, "boolean")
+	}
+	return b
+}
+
+func (v *validator) float(key string) float64 {
+	value := strings.TrimSpace(viper.GetString(key))
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		v.parseFailed(key, value, "number")
+	}
+	return f
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	if d <= 0 {
+		v.addf(key, "must be greater than 0, got %s", d)
+	}
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	if d < 0 {
+		v.addf(key, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) atLeast(key string, n, min int) {
+	if n < min {
+		v.addf(key, "must be at least %d, got %d", min, n)
+	}
+}
+
+func (v *validator) httpURL(key, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(key, "%q is not a valid http(s) URL", value)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+// file checks that path names a readable file.
+func (v *validator) file(key, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		v.addf(key, "cannot read %q: %v", path, errors.Unwrap(err))
+		return
+	}
+	f.Close()
+}
+
+// validateTLS checks that the certificate and key come as a pair and that every configured
+// file can be read, so a typo fails at startup rather than on the first handshake.
+func (c *Config) validateTLS(v *validator) {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		v.addf("TLS_CERT_FILE", "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		v.addf("TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	for key, path := range map[string]string{
+		"TLS_CERT_FILE":      c.TLSCertFile,
+		"TLS_KEY_FILE":       c.TLSKeyFile,
+		"TLS_CLIENT_CA_FILE": c.TLSClientCAFile,
+	} {
+		if path != "" {
+			v.file(key, path)
+		}
+	}
+}
+
+// validate checks the ranges and formats LoadConfig can't enforce while parsing.
+func (c *Config) validate(v *validator) {
+	if port, err := strconv.Atoi(c.ServerPort); err != nil || port < 1 || port > 65535 {
+		v.addf("SERVER_PORT", "%q is not a valid port", c.ServerPort)
+	}
+	v.nonNegative("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	v.nonNegative("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	v.nonNegative("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	v.atLeast("SERVER_BODY_LIMIT", c.ServerBodyLimit, 1)
+	v.atLeast("SERVER_MAX_QUERY_LENGTH", c.ServerMaxQueryLength, 1)
+	v.atLeast("SERVER_CONCURRENCY", c.ServerConcurrency, 1)
+	if c.ServerSocket != "" && c.ServerPrefork {
+		v.addf("SERVER_SOCKET", "cannot be used with SERVER_PREFORK, which needs a TCP port")
+	}
+	if c.ServerSocket != "" && c.TLSCertFile != "" {
+		v.addf("SERVER_SOCKET", "cannot be used with TLS_CERT_FILE; terminate TLS in the proxy in front of the socket")
+	}
+	if c.GRPCPort != "" {
+		if port, err := strconv.Atoi(c.GRPCPort); err != nil || port < 1 || port > 65535 {
+			v.addf("GRPC_PORT", "%q is not a valid port", c.GRPCPort)
+		} else if c.GRPCPort == c.ServerPort && c.ServerSocket == "" {
+			v.addf("GRPC_PORT", "must differ from SERVER_PORT")
+		}
+		if c.ServerPrefork {
+			v.addf("GRPC_PORT", "cannot be used with SERVER_PREFORK, whose child processes would all bind it")
+		}
+		v.positive("GRPC_HEALTH_CHECK_INTERVAL", c.GRPCHealthCheckInterval)
+	}
+	v.positive("STREAM_PING_INTERVAL", c.StreamPingInterval)
+	v.atLeast("STREAM_MAX_PAIRS", c.StreamMaxPairs, 1)
+	v.atLeast("STREAM_BUFFER", c.StreamBuffer, 1)
+	v.atLeast("WEBHOOK_MAX_PER_KEY", c.WebhookMaxPerKey, 1)
+	v.atLeast("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts, 1)
+	v.positive("WEBHOOK_RETRY_BACKOFF", c.WebhookRetryBackoff)
+	v.positive("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	v.atLeast("WEBHOOK_WORKERS", c.WebhookWorkers, 1)
+	if strings.Contains(c.ServerHost, ":") && net.ParseIP(c.ServerHost) == nil {
+		v.addf("SERVER_HOST", "%q is not a host name or IP address", c.ServerHost)
+	}
+	if c.ServerPrefork && c.CacheBackend == "memory" {
+		v.addf("SERVER_PREFORK", "cannot be used with CACHE_BACKEND=memory, each process would get its own cache")
+	}
+	if c.ServerPrefork && c.RateLimits.Enabled {
+		v.addf("SERVER_PREFORK", "cannot be used with RATE_LIMIT_ENABLED, each process would enforce its own rate limits")
+	}
+	c.validateTLS(v)
+	v.nonNegative("SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay)
+	v.positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	v.positive("SHUTDOWN_WORKER_TIMEOUT", c.ShutdownWorkerTimeout)
+	if _, _, err := net.SplitHostPort(c.RedisAddr); err != nil {
+		v.addf("REDIS_ADDR", "%q is not a host:port address", c.RedisAddr)
+	}
+	v.atLeast("REDIS_DB", c.RedisDB, 0)
+	v.oneOf("CACHE_BACKEND", c.CacheBackend, "redis", "memory")
+
+	v.positive("LATEST_RATE_CACHE_TTL", c.LatestRateCacheTTL)
+	v.positive("HISTORICAL_CACHE_TTL", c.HistoricalCacheTTL)
+	v.positive("REFRESH_INTERVAL", c.RefreshInterval)
+	v.nonNegative("REFRESH_START_JITTER", c.RefreshStartJitter)
+	v.nonNegative("REFRESH_JITTER", c.RefreshJitter)
+	v.nonNegative("REFRESH_MIN_AGE", c.RefreshMinAge)
+	v.nonNegative("REFRESH_RETRY_BACKOFF", c.RefreshRetryDelay)
+	v.atLeast("REFRESH_WORKERS", c.RefreshWorkers, 1)
+	v.atLeast("REFRESH_RETRIES", c.RefreshRetries, 0)
+	v.atLeast("REFRESH_FAILURE_THRESHOLD", c.RefreshFailureThreshold, 0)
+	v.nonNegative("RATE_ALERT_COOLDOWN", c.RateAlertCooldown)
+	v.atLeast("HISTORY_DAYS_LIMIT", c.HistoryDaysLimit, 1)
+	for _, code := range c.SupportedCurrencies {
+		if !currencyCode.MatchString(code) {
+			v.addf("SUPPORTED_CURRENCIES", "%q is not a currency code", code)
+		} else if _, ok := domain.LookupCurrency(domain.Currency(code)); !ok {
+			v.addf("SUPPORTED_CURRENCIES", "%q is not an ISO 4217 currency code", code)
+		}
+	}
+	if c.HistoricalRefreshEnabled {
+		v.positive("HISTORICAL_REFRESH_INTERVAL", c.HistoricalRefreshInterval)
+	}
+	if c.LeaderElectionEnabled {
+		v.positive("LEADER_LEASE", c.LeaderLease)
+	}
+	if c.MetalsEnabled {
+		v.positive("METALS_REFRESH_INTERVAL", c.MetalsRefreshInterval)
+	}
+	if c.CacheWarmUpEnabled {
+		v.positive("CACHE_WARMUP_TIMEOUT", c.CacheWarmUpTimeout)
+	}
+	if c.CacheHygieneEnabled {
+		v.positive("CACHE_HYGIENE_INTERVAL", c.CacheHygieneInterval)
+	}
+	if c.RetentionEnabled {
+		v.positive("RETENTION_INTERVAL", c.RetentionInterval)
+	}
+
+	v.positive("REDIS_HEALTH_CHECK_INTERVAL", c.RedisHealthCheckInterval)
+	v.positive("REDIS_BACKOFF_BASE", c.RedisBackoffBase)
+	if c.RedisBackoffMax < c.RedisBackoffBase {
+		v.addf("REDIS_BACKOFF_MAX", "must not be shorter than REDIS_BACKOFF_BASE (%s), got %s", c.RedisBackoffBase, c.RedisBackoffMax)
+	}
+
+	v.nonNegative("EXTERNAL_API_TIMEOUT", c.ExternalAPITimeout)
+	v.nonNegative("EXTERNAL_API_IDLE_CONN_TIMEOUT", c.ExternalAPIIdleConnTimeout)
+	v.atLeast("EXTERNAL_API_MAX_IDLE_CONNS", c.ExternalAPIMaxIdleConns, 0)
+	v.atLeast("EXTERNAL_API_MAX_IDLE_CONNS_PER_HOST", c.ExternalAPIMaxIdleConnsPerHost, 0)
+	if c.ExternalAPIProxyURL != "" {
+		v.httpURL("EXTERNAL_API_PROXY_URL", c.ExternalAPIProxyURL)
+	}
+	if c.AlertWebhookURL != "" {
+		v.httpURL("ALERT_WEBHOOK_URL", c.AlertWebhookURL)
+	}
+	if c.ErrorReportingDSN != "" {
+		v.httpURL("ERROR_REPORTING_DSN", c.ErrorReportingDSN)
+	}
+
+	for key, value := range map[string]string{
+		"EXTERNAL_API_URL":          c.ExternalAPIURL,
+		"ECB_FEED_URL":              c.ECBFeedURL,
+		"OXR_API_URL":               c.OXRAPIURL,
+		"FIXER_API_URL":             c.FixerAPIURL,
+		"CURRENCYLAYER_API_URL":     c.CurrencyLayerAPIURL,
+		"EXCHANGERATE_HOST_API_URL": c.ExchangeRateHostAPIURL,
+		"COINGECKO_API_URL":         c.CoinGeckoAPIURL,
+		"METALPRICE_API_URL":        c.MetalPriceAPIURL,
+	} {
+		v.httpURL(key, value)
+	}
+	for name, provider := range c.Providers {
+		if provider.BaseURL != "" {
+			v.httpURL("PROVIDERS."+name+".baseURL", provider.BaseURL)
+		}
+		if provider.Timeout < 0 {
+			v.addf("PROVIDERS."+name+".timeout", "must not be negative, got %s", provider.Timeout)
+		}
+	}
+
+	v.oneOf("AGGREGATION_METHOD", c.AggregationMethod, "median", "mean", "trimmed-mean")
+	if c.AggregationTrim < 0 || c.AggregationTrim >= 0.5 {
+		v.addf("AGGREGATION_TRIM", "must be at least 0 and below 0.5, got %g", c.AggregationTrim)
+	}
+	v.atLeast("AGGREGATION_MIN_PROVIDERS", c.AggregationMinProviders, 1)
+	if c.CircuitBreakerEnabled {
+		v.positive("CIRCUIT_BREAKER_OPEN_TIMEOUT", c.CircuitBreakerOpenTimeout)
+	}
+	if c.CircuitBreakerEnabled && c.CircuitBreakerFailureThreshold < 1 {
+		v.addf("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "must be at least 1, got %d", c.CircuitBreakerFailureThreshold)
+	}
+	if c.ProviderRateLimitRPS < 0 {
+		v.addf("PROVIDER_RATE_LIMIT_RPS", "must be at least 0, got %g", c.ProviderRateLimitRPS)
+	}
+	if c.ProviderRateLimitRPS > 0 {
+		v.atLeast("PROVIDER_RATE_LIMIT_BURST", c.ProviderRateLimitBurst, 1)
+	}
+	if _, err := ParseRateLimits(c.ProviderRateLimits); err != nil {
+		v.addf("PROVIDER_RATE_LIMITS", "%v", err)
+	}
+	v.nonNegative("RESPONSE_MAX_AGE", c.ResponseMaxAge)
+	v.atLeast("HISTORICAL_CHUNK_CONCURRENCY", c.HistoricalChunkConcurrency, 0)
+	v.oneOf("PROVIDER_RECORD_MODE", c.ProviderRecordMode, "", "record", "replay")
+	if c.ProviderSLOMinSuccessRate < 0 || c.ProviderSLOMinSuccessRate > 1 {
+		v.addf("PROVIDER_SLO_MIN_SUCCESS_RATE", "must be between 0 and 1, got %g", c.ProviderSLOMinSuccessRate)
+	}
+	v.nonNegative("PROVIDER_SLO_MAX_P95_LATENCY", c.ProviderSLOMaxP95Latency)
+	v.atLeast("PROVIDER_SLO_MIN_CALLS", c.ProviderSLOMinCalls, 0)
+	v.nonNegative("PROVIDER_PROBATION", c.ProviderProbation)
+	c.validateRateLimits(v)
+	c.validateJWT(v)
+	c.validateEmail(v)
+	c.validateBroker(v)
+	c.validateArchive(v)
+	c.validateSnapshot(v)
+	c.validateNetwork(v)
+	if c.RequestSigningEnabled {
+		v.positive("REQUEST_SIGNING_MAX_SKEW", c.RequestSigningMaxSkew)
+		if len(c.RequestSigningPepper) < minSigningPepperLength {
+			v.addf("REQUEST_SIGNING_PEPPER", "must be at least %d bytes long when REQUEST_SIGNING_ENABLED is set, got %d", minSigningPepperLength, len(c.RequestSigningPepper))
+		}
+	}
+	v.atLeast("AUTH_LOCKOUT_THRESHOLD", c.AuthLockoutThreshold, 0)
+	if c.AuthLockoutThreshold > 0 {
+		v.positive("AUTH_LOCKOUT_WINDOW", c.AuthLockoutWindow)
+		v.positive("AUTH_LOCKOUT_DURATION", c.AuthLockoutDuration)
+	}
+	v.atLeast("AUDIT_LOG_MAX_ENTRIES", c.AuditLogMaxEntries, 1)
+	v.atLeast("AUDIT_LOG_RETENTION_DAYS", c.AuditLogRetentionDays, 0)
+	v.atLeast("USAGE_RETENTION_DAYS", c.UsageRetentionDays, 1)
+	v.atLeast("DEBUG_LOG_MAX_BODY_BYTES", c.DebugLogMaxBodyBytes, 1)
+	if c.OTLPMetricsEndpoint != "" {
+		v.httpURL("OTLP_METRICS_ENDPOINT", c.OTLPMetricsEndpoint)
+		v.positive("OTLP_METRICS_INTERVAL", c.OTLPMetricsInterval)
+		if !c.MetricsEnabled {
+			v.addf("OTLP_METRICS_ENDPOINT", "requires METRICS_ENABLED=true, nothing is recorded otherwise")
+		}
+	}
+	v.positive("RUNTIME_CHECK_INTERVAL", c.RuntimeCheckInterval)
+	v.atLeast("CACHE_WRITES_WARN_THRESHOLD", c.CacheWritesWarnThreshold, 1)
+	if c.RedisPoolWarnUsage <= 0 || c.RedisPoolWarnUsage > 1 {
+		v.addf("REDIS_POOL_WARN_USAGE", "must be above 0 and at most 1, got %g", c.RedisPoolWarnUsage)
+	}
+	v.oneOf("LOG_LEVEL", c.LogLevel, logLevels...)
+	v.oneOf("LOG_FORMAT", c.LogFormat, "console", "json")
+	for component, level := range c.LogOverrides {
+		v.oneOf("LOG_LEVELS."+component, level, logLevels...)
+	}
+	if c.LogFile != "" {
+		v.atLeast("LOG_FILE_MAX_SIZE_MB", c.LogFileMaxSizeMB, 1)
+		v.atLeast("LOG_FILE_MAX_BACKUPS", c.LogFileMaxBackups, 0)
+		v.atLeast("LOG_FILE_MAX_AGE_DAYS", c.LogFileMaxAgeDays, 0)
+	}
+	v.atLeast("LOG_SAMPLE_INITIAL", c.LogSampleInitial, 0)
+	v.atLeast("LOG_SAMPLE_THEREAFTER", c.LogSampleThereafter, 0)
+	v.nonNegative("SLOW_REQUEST_THRESHOLD", c.SlowRequestThreshold)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// iso3166CSV maps the ISO 3166-1 alpha-2 code of each country and territory to its name and
+// the currencies that are legal tender there, the one most used first. Antarctica, which has
+// none, is left out.
+//
+//go:embed iso3166.csv
+var iso3166CSV string
+
+// Country is a country or territory and the currencies used there.
+type Country struct {
+	Code       string     `json:"code"` // ISO 3166-1 alpha-2, e.g. "IN"
+	Name       string     `json:"name"`
+	Currencies []Currency `json:"currencies"` // the one most used first
+}
+
+var countries = parseISO3166(iso3166CSV)
+
+// parseISO3166 indexes the embedded list by country code. Like parseISO4217 it panics on a
+// malformed row, including one naming a currency the ISO 4217 list doesn't have.
+func parseISO3166(data string) map[string]Country {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iso3166.csv: %v", err))
+	}
+	byCode := make(map[string]Country, len(records))
+	for _, record := range records[1:] {
+		country := Country{Code: record[0], Name: record[1]}
+		for _, code := range strings.Fields(record[2]) {
+			if !Currency(code).IsISO() {
+				panic(fmt.Sprintf("iso3166.csv: %s uses unknown currency %s", record[0], code))
+			}
+			country.Currencies = append(country.Currencies, Currency(code))
+		}
+		if len(country.Currencies) == 0 {
+			panic(fmt.Sprintf("iso3166.csv: %s has no currency", record[0]))
+		}
+		byCode[country.Code] = country
+	}
+	return byCode
+}
+
+// LookupCountry returns the country with the ISO 3166-1 alpha-2 code, in any case.
+func LookupCountry(code string) (Country, bool) {
+	country, ok := countries[strings.ToUpper(code)]
+	return country, ok
+}
+
+// DefaultCurrency returns the first of the country's currencies that is supported, for
+// picking the currency to show a user from their locale.
+func (c Country) DefaultCurrency() (Currency, bool) {
+	for _, currency := range c.Currencies {
+		if currency.IsSupported() {
+			return currency, true
+		}
+	}
+	return "", false
+}
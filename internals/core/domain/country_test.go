@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCountry(t *testing.T) {
+	country, ok := LookupCountry("in")
+	assert.True(t, ok)
+	assert.Equal(t, Country{Code: "IN", Name: "India", Currencies: []Currency{"INR"}}, country)
+
+	country, ok = LookupCountry("BT")
+	assert.True(t, ok)
+	assert.Equal(t, []Currency{"BTN", "INR"}, country.Currencies)
+
+	_, ok = LookupCountry("AQ")
+	assert.False(t, ok)
+	_, ok = LookupCountry("IND")
+	assert.False(t, ok)
+	assert.Greater(t, len(countries), 240)
+}
+
+func TestCountry_DefaultCurrency(t *testing.T) {
+	// BTN isn't supported by default, so Bhutan falls back to INR.
+	country, _ := LookupCountry("BT")
+	currency, ok := country.DefaultCurrency()
+	assert.True(t, ok)
+	assert.Equal(t, Currency("INR"), currency)
+
+	country, _ = LookupCountry("BR")
+	_, ok = country.DefaultCurrency()
+	assert.False(t, ok)
+}
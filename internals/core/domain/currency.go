@@ -0,0 +1,165 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iso4217CSV is the ISO 4217 list of currencies and funds: code, numeric code, name, minor
+// units (empty where ISO says N.A.), a commonly used symbol, if there is one, and for recently
+// introduced or retired currencies the first or last day they were in use.
+//
+//go:embed iso4217.csv
+var iso4217CSV string
+
+// NoMinorUnits is the MinorUnits of currencies that aren't divided, such as precious metals
+// and units of account.
+const NoMinorUnits = -1
+
+// CurrencyInfo is the reference data of a currency.
+type CurrencyInfo struct {
+	Code       Currency `json:"code"`
+	Numeric    string   `json:"numericCode,omitempty"` // ISO 4217 numeric code, e.g. "008"; empty for crypto assets
+	Name       string   `json:"name"`
+	MinorUnits int      `json:"minorUnits"` // digits after the decimal point, or NoMinorUnits
+	Symbol     string   `json:"symbol,omitempty"`
+	// ValidFrom and ValidUntil are the first and last day the currency was in use, when it was
+	// introduced recently enough for rates to predate it, or has been retired.
+	ValidFrom  *CustomDate `json:"validFrom,omitempty"`
+	ValidUntil *CustomDate `json:"validUntil,omitempty"`
+}
+
+// CurrencyNotInUseError is the error of a lookup of a currency on a day it wasn't in use,
+// either before it was introduced or after it was retired.
+type CurrencyNotInUseError struct {
+	Currency Currency
+	Retired  bool       // false when it wasn't introduced yet
+	Date     CustomDate // the day it was introduced, or the last day it was in use
+}
+
+func (e *CurrencyNotInUseError) Error() string {
+	if e.Retired {
+		return fmt.Sprintf("%s was retired after %s; its rates can only be looked up until then", e.Currency, e.Date)
+	}
+	return fmt.Sprintf("%s was introduced on %s; its rates can only be looked up from then on", e.Currency, e.Date)
+}
+
+// cryptoInfo describes the crypto assets, which ISO 4217 doesn't cover. Their minor units are
+// the smallest amount each one tracks.
+var cryptoInfo = map[Currency]CurrencyInfo{
+	"BTC":  {Code: "BTC", Name: "Bitcoin", MinorUnits: 8, Symbol: "₿"},
+	"ETH":  {Code: "ETH", Name: "Ether", MinorUnits: 18, Symbol: "Ξ"},
+	"USDT": {Code: "USDT", Name: "Tether", MinorUnits: 6, Symbol: "₮"},
+}
+
+var iso4217, iso4217ByNumeric = parseISO4217(iso4217CSV)
+
+// parseISO4217 indexes the embedded list by code and numeric code, numeric codes that ISO
+// reassigned going to the current currency. It panics on a malformed row, as the list is
+// part of the binary.
+func parseISO4217(data string) (map[Currency]CurrencyInfo, map[string]CurrencyInfo) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iso4217.csv: %v", err))
+	}
+	byCode := make(map[Currency]CurrencyInfo, len(records))
+	byNumeric := make(map[string]CurrencyInfo, len(records))
+	for _, record := range records[1:] {
+		info := CurrencyInfo{Code: Currency(record[0]), Numeric: record[1], Name: record[2], MinorUnits: NoMinorUnits, Symbol: record[4]}
+		if record[3] != "" {
+			if info.MinorUnits, err = strconv.Atoi(record[3]); err != nil {
+				panic(fmt.Sprintf("iso4217.csv: %s has minor units %q", record[0], record[3]))
+			}
+		}
+		if info.ValidFrom, err = parseValidity(record[5]); err != nil {
+			panic(fmt.Sprintf("iso4217.csv: %s has valid_from %q", record[0], record[5]))
+		}
+		if info.ValidUntil, err = parseValidity(record[6]); err != nil {
+			panic(fmt.Sprintf("iso4217.csv: %s has valid_until %q", record[0], record[6]))
+		}
+		byCode[info.Code] = info
+		if _, taken := byNumeric[info.Numeric]; !taken || info.ValidUntil == nil {
+			byNumeric[info.Numeric] = info
+		}
+	}
+	return byCode, byNumeric
+}
+
+// parseValidity parses an optional YYYY-MM-DD validity date.
+func parseValidity(value string) (*CustomDate, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	validity := CustomDate(date)
+	return &validity, nil
+}
+
+// LookupCurrency returns the ISO 4217 entry of code, or the entry of a crypto asset.
+func LookupCurrency(code Currency) (CurrencyInfo, bool) {
+	if info, ok := iso4217[code]; ok {
+		return info, true
+	}
+	info, ok := cryptoInfo[code]
+	return info, ok
+}
+
+// LookupNumericCurrency returns the ISO 4217 entry with the numeric code, e.g. "978" for EUR.
+// Of a retired currency and the one its code was reassigned to, the latter is returned.
+func LookupNumericCurrency(numeric string) (CurrencyInfo, bool) {
+	info, ok := iso4217ByNumeric[numeric]
+	return info, ok
+}
+
+// ISOCurrencies returns every ISO 4217 entry, current or retired, ordered by code.
+func ISOCurrencies() []CurrencyInfo {
+	infos := make([]CurrencyInfo, 0, len(iso4217))
+	for _, info := range iso4217 {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// IsISO checks if a currency code is in ISO 4217.
+func (c Currency) IsISO() bool {
+	_, ok := iso4217[c]
+	return ok
+}
+
+// Info returns the reference data of c. Codes neither ISO 4217 nor a known crypto asset only
+// get their code, with two minor units.
+func (c Currency) Info() CurrencyInfo {
+	if info, ok := LookupCurrency(c); ok {
+		return info
+	}
+	return CurrencyInfo{Code: c, Name: string(c), MinorUnits: 2}
+}
+
+// CheckInUse returns a *CurrencyNotInUseError if c wasn't in use on some day from start to
+// end. Only the UTC day of start and end counts, so latest rates are checked with the time
+// they are asked for.
+func (c Currency) CheckInUse(start, end time.Time) error {
+	info := c.Info()
+	start, end = start.UTC().Truncate(24*time.Hour), end.UTC().Truncate(24*time.Hour)
+	if info.ValidFrom != nil && start.Before(info.ValidFrom.ToTime()) {
+		return &CurrencyNotInUseError{Currency: c, Date: *info.ValidFrom}
+	}
+	if info.ValidUntil != nil && end.After(info.ValidUntil.ToTime()) {
+		return &CurrencyNotInUseError{Currency: c, Retired: true, Date: *info.ValidUntil}
+	}
+	return nil
+}
+
+// InUse reports whether c was in use on date.
+func (c Currency) InUse(date time.Time) bool {
+	return c.CheckInUse(date, date) == nil
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	info, ok := LookupCurrency("EUR")
+	assert.True(t, ok)
+	assert.Equal(t, CurrencyInfo{Code: "EUR", Numeric: "978", Name: "Euro", MinorUnits: 2, Symbol: "€"}, info)
+
+	info, ok = LookupCurrency("BTC")
+	assert.True(t, ok)
+	assert.Equal(t, 8, info.MinorUnits)
+	assert.False(t, Currency("BTC").IsISO())
+
+	_, ok = LookupCurrency("ABC")
+	assert.False(t, ok)
+	assert.Equal(t, CurrencyInfo{Code: "ABC", Name: "ABC", MinorUnits: 2}, Currency("ABC").Info())
+
+	info, ok = LookupNumericCurrency("392")
+	assert.True(t, ok)
+	assert.Equal(t, Currency("JPY"), info.Code)
+
+	info, ok = LookupNumericCurrency("532")
+	assert.True(t, ok)
+	assert.Equal(t, Currency("XCG"), info.Code)
+}
+
+func TestCheckInUse(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	assert.NoError(t, Currency("USD").CheckInUse(date("1990-01-01"), time.Now()))
+	assert.NoError(t, Currency("HRK").CheckInUse(date("2020-01-01"), date("2022-12-31").Add(23*time.Hour)))
+	assert.Equal(t, &CurrencyNotInUseError{Currency: "HRK", Retired: true, Date: CustomDate(date("2022-12-31"))},
+		Currency("HRK").CheckInUse(date("2022-12-31"), date("2023-01-01")))
+	assert.Equal(t, &CurrencyNotInUseError{Currency: "XCG", Date: CustomDate(date("2025-03-31"))},
+		Currency("XCG").CheckInUse(date("2025-03-30"), date("2025-04-30")))
+	assert.True(t, Currency("XCG").InUse(time.Now()))
+	assert.False(t, Currency("ANG").InUse(time.Now()))
+	assert.EqualError(t, Currency("HRK").CheckInUse(time.Now(), time.Now()),
+		"HRK was retired after 2022-12-31; its rates can only be looked up until then")
+}
+
+func TestISOCurrencies(t *testing.T) {
+	infos := ISOCurrencies()
+	assert.Greater(t, len(infos), 150)
+	numerics := map[string]Currency{}
+	for i, info := range infos {
+		if i > 0 {
+			assert.Less(t, infos[i-1].Code, info.Code)
+		}
+		assert.Regexp(t, `^[A-Z]{3}$`, info.Code)
+		assert.Regexp(t, `^[0-9]{3}$`, info.Numeric)
+		assert.NotEmpty(t, info.Name)
+		if info.ValidUntil != nil {
+			continue // ISO reassigns the numeric codes of retired currencies, as ANG's to XCG
+		}
+		assert.NotContains(t, numerics, info.Numeric, "%s shares its numeric code with %s", info.Code, numerics[info.Numeric])
+		numerics[info.Numeric] = info.Code
+	}
+	for code := range SupportedCurrencies {
+		assert.True(t, code.IsISO(), code)
+	}
+	for code := range MetalCurrencies {
+		assert.Equal(t, NoMinorUnits, code.Info().MinorUnits, code)
+	}
+}
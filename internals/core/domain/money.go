@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrCurrencyMismatch is returned when amounts of different currencies are added, subtracted
+// or compared.
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// allocationPlaces is how many decimals amounts without minor units, such as metals, are
+// allocated to.
+const allocationPlaces = 8
+
+// Money is an amount of a currency. The amount is a decimal, so sums and roundings come out
+// as they would on paper rather than off by a binary fraction.
+type Money struct {
+	Amount   decimal.Decimal
+	Currency Currency
+}
+
+// NewMoney returns amount of currency.
+func NewMoney(amount decimal.Decimal, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// NewMoneyFromFloat returns amount of currency, amount being read as the shortest decimal
+// that parses back to it, e.g. 0.1 rather than 0.1000000000000000055511151231257827.
+func NewMoneyFromFloat(amount float64, currency Currency) Money {
+	return Money{Amount: decimal.NewFromFloat(amount), Currency: currency}
+}
+
+// String formats m as e.g. "12.35 USD".
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
+
+// Float64 returns the amount as the nearest float64, for wire formats that only have floats.
+func (m Money) Float64() float64 {
+	return m.Amount.InexactFloat64()
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount.IsPositive()
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount.IsNegative()
+}
+
+// Add returns m + other, which must be of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+// Sub returns m - other, which must be of the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
+}
+
+// Mul returns m times factor, unrounded.
+func (m Money) Mul(factor decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}
+}
+
+// Cmp compares m to other, which must be of the same currency: -1 if m is less, 0 if they
+// are equal and +1 if m is more.
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(other.Amount), nil
+}
+
+// Equal reports whether m and other are the same amount of the same currency, however many
+// trailing zeros either is written with.
+func (m Money) Equal(other Money) bool {
+	return m.Currency == other.Currency && m.Amount.Equal(other.Amount)
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// Round rounds m to the minor units of its currency, halves away from zero, e.g. to cents
+// for USD. Amounts of currencies without minor units, such as metals, are left as they are.
+func (m Money) Round() Money {
+	units := m.Currency.Info().MinorUnits
+	if units == NoMinorUnits {
+		return m
+	}
+	return Money{Amount: m.Amount.Round(int32(units)), Currency: m.Currency}
+}
+
+// Convert returns m in currency to at rate, rounded to the minor units of to.
+func (m Money) Convert(to Currency, rate decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Mul(rate), Currency: to}.Round()
+}
+
+// Allocate splits m, rounded first, into parts in proportion to ratios, each rounded to the
+// minor units of the currency. The minor units left over by rounding go one each to the
+// first parts, so the parts always add up to m: 100 USD split 1:1:1 is 33.34, 33.33 and 33.33.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("no ratios to allocate by")
+	}
+	total := 0
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("ratio %d is negative", ratio)
+		}
+		total += ratio
+	}
+	if total == 0 {
+		return nil, errors.New("ratios add up to zero")
+	}
+
+	places := int32(m.Currency.Info().MinorUnits)
+	if places == NoMinorUnits {
+		places = allocationPlaces
+	}
+	// Shares are worked out in whole minor units, so that none is rounded up past its due.
+	units := m.Amount.Round(places).Shift(places).BigInt()
+	totalRatio := big.NewInt(int64(total))
+	remainder := new(big.Int).Set(units)
+	shares := make([]*big.Int, len(ratios))
+	for i, ratio := range ratios {
+		shares[i] = new(big.Int).Quo(new(big.Int).Mul(units, big.NewInt(int64(ratio))), totalRatio)
+		remainder.Sub(remainder, shares[i])
+	}
+	step := big.NewInt(int64(remainder.Sign()))
+	for i := 0; remainder.Sign() != 0; i++ {
+		shares[i].Add(shares[i], step)
+		remainder.Sub(remainder, step)
+	}
+	parts := make([]Money, len(shares))
+	for i, share := range shares {
+		parts[i] = Money{Amount: decimal.NewFromBigInt(share, -places), Currency: m.Currency}
+	}
+	return parts, nil
+}
+
+// Split splits m into n parts as equal as its minor units allow, as Allocate does.
+func (m Money) Split(n int) ([]Money, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("can't split into %d parts", n)
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// moneyJSON is the JSON form of Money. The amount is written as a number with exactly the
+// digits of the decimal; strings are read too, for clients that keep amounts as strings to
+// spare them a trip through float64.
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency Currency    `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: json.Number(m.Amount.String()), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v struct {
+		Amount   any      `json:"amount"`
+		Currency Currency `json:"currency"`
+	}
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+	amount, err := parseDecimal(v.Amount)
+	if err != nil {
+		return err
+	}
+	*m = Money{Amount: amount, Currency: v.Currency}
+	return nil
+}
+
+// parseDecimal reads a JSON number or numeric string, as decoded with UseNumber.
+func parseDecimal(v any) (decimal.Decimal, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case string:
+		return decimal.NewFromString(v)
+	}
+	return decimal.Decimal{}, fmt.Errorf("amount must be a number, got %v", v)
+}
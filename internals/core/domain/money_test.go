@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func usd(amount string) Money {
+	return NewMoney(decimal.RequireFromString(amount), "USD")
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := usd("0.1").Add(usd("0.2"))
+	assert.NoError(t, err)
+	assert.True(t, sum.Equal(usd("0.3")))
+
+	difference, err := usd("10").Sub(usd("12.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-2.5 USD", difference.String())
+	assert.True(t, difference.IsNegative())
+
+	_, err = usd("1").Add(NewMoneyFromFloat(1, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = usd("1").Cmp(NewMoneyFromFloat(1, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	cmp, err := usd("1.50").Cmp(usd("1.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cmp)
+	assert.False(t, usd("1").Equal(NewMoneyFromFloat(1, "EUR")))
+
+	assert.Equal(t, "3.75 USD", usd("1.25").Mul(decimal.NewFromInt(3)).String())
+}
+
+func TestMoney_Round(t *testing.T) {
+	assert.Equal(t, "12.35 USD", usd("12.345").Round().String())
+	assert.Equal(t, "-12.35 USD", usd("-12.345").Round().String())
+	assert.Equal(t, "1235 JPY", NewMoneyFromFloat(1234.5, "JPY").Round().String())
+	assert.Equal(t, "1.235 KWD", NewMoneyFromFloat(1.2346, "KWD").Round().String())
+	assert.Equal(t, "0.123456789 XAU", NewMoneyFromFloat(0.123456789, "XAU").Round().String())
+
+	assert.Equal(t, "852.35 INR", usd("10").Convert("INR", decimal.NewFromFloat(85.23456)).String())
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	amounts := func(parts []Money) []string {
+		strings := make([]string, len(parts))
+		for i, part := range parts {
+			strings[i] = part.Amount.String()
+		}
+		return strings
+	}
+
+	parts, err := usd("100").Split(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"33.34", "33.33", "33.33"}, amounts(parts))
+
+	parts, err = usd("-0.05").Allocate(70, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-0.04", "-0.01"}, amounts(parts))
+
+	parts, err = NewMoneyFromFloat(1000, "JPY").Allocate(1, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"334", "0", "666"}, amounts(parts))
+
+	parts, err = NewMoneyFromFloat(1, "XAU").Split(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0.33333334", "0.33333333", "0.33333333"}, amounts(parts))
+
+	_, err = usd("1").Allocate()
+	assert.Error(t, err)
+	_, err = usd("1").Allocate(0, 0)
+	assert.Error(t, err)
+	_, err = usd("1").Allocate(1, -1)
+	assert.Error(t, err)
+	_, err = usd("1").Split(0)
+	assert.Error(t, err)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(usd("0.10"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":0.1,"currency":"USD"}`, string(data))
+	assert.Equal(t, `{"amount":0.1,"currency":"USD"}`, string(data))
+
+	var m Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"12345678901234567890.01","currency":"USD"}`), &m))
+	assert.Equal(t, "12345678901234567890.01 USD", m.String())
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":1e2,"currency":"EUR"}`), &m))
+	assert.True(t, m.Equal(NewMoneyFromFloat(100, "EUR")))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":true,"currency":"EUR"}`), &m))
+}
+
+func TestConversionResult_JSON(t *testing.T) {
+	onDate := DateOf(time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC))
+	result := ConversionResult{Amount: usd("100"), Converted: NewMoneyFromFloat(8599.5, "INR"), Rate: 85.995, Date: &onDate}
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"from":"USD","to":"INR","amount":100,"convertedAmount":8599.5,"rate":85.995,"onDate":"2025-04-14"}`, string(data))
+
+	var decoded ConversionResult
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Amount.Equal(result.Amount))
+	assert.True(t, decoded.Converted.Equal(result.Converted))
+	assert.Equal(t, onDate, *decoded.Date)
+
+	latest := ConversionResult{Amount: usd("100"), Converted: NewMoneyFromFloat(8599.5, "INR"), Rate: 85.995, Timestamp: 1744624800}
+	data, err = json.Marshal(latest)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"from":"USD","to":"INR","amount":100,"convertedAmount":8599.5,"rate":85.995,"timestamp":1744624800}`, string(data))
+	decoded = ConversionResult{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, int64(1744624800), decoded.Timestamp)
+	assert.Nil(t, decoded.Date)
+}
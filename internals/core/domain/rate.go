@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"encoding/json"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,17 +20,78 @@ var SupportedCurrencies = map[Currency]bool{
 	"GBP": true,
 }
 
+// CryptoCurrencies lists the crypto assets that can be enabled alongside fiat.
+var CryptoCurrencies = map[Currency]bool{
+	"BTC":  true,
+	"ETH":  true,
+	"USDT": true,
+}
+
+// MetalCurrencies lists the precious metals that can be enabled, each denominated in troy ounces.
+var MetalCurrencies = map[Currency]bool{
+	"XAU": true, // gold
+	"XAG": true, // silver
+	"XPT": true, // platinum
+}
+
+// codePattern matches ISO 4217 codes as well as the slightly longer tickers used for crypto assets.
+var codePattern = regexp.MustCompile(`^[A-Z]{3,5}$`)
+
+// WellFormed checks if a currency code has the shape of one, supported or not.
+func (c Currency) WellFormed() bool {
+	return codePattern.MatchString(string(c))
+}
+
 // IsSupported checks if a currency code is supported.
 func (c Currency) IsSupported() bool {
 	_, ok := SupportedCurrencies[c]
 	return ok
 }
 
+// IsCrypto checks if a currency code is a crypto asset rather than fiat.
+func (c Currency) IsCrypto() bool {
+	return CryptoCurrencies[c]
+}
+
+// IsMetal checks if a currency code is a precious metal quoted per troy ounce.
+func (c Currency) IsMetal() bool {
+	return MetalCurrencies[c]
+}
+
+// EnableCurrencies adds codes to SupportedCurrencies. It is only safe to call during startup,
+// before requests are served.
+func EnableCurrencies(codes ...Currency) {
+	for _, code := range codes {
+		SupportedCurrencies[code] = true
+	}
+}
+
+// SetSupportedCurrencies replaces SupportedCurrencies with codes. Like EnableCurrencies it is
+// only safe to call during startup.
+func SetSupportedCurrencies(codes ...Currency) {
+	SupportedCurrencies = make(map[Currency]bool, len(codes))
+	EnableCurrencies(codes...)
+}
+
+// CustomDate is a calendar day, written as YYYY-MM-DD in JSON values and map keys alike.
 type CustomDate time.Time
 
+// DateOf returns the UTC day t falls on.
+func DateOf(t time.Time) CustomDate {
+	return CustomDate(t.UTC().Truncate(24 * time.Hour))
+}
+
 func (cd *CustomDate) UnmarshalJSON(b []byte) error {
-	s := strings.Trim(string(b), `"`)
-	t, err := time.Parse("2006-01-02", s)
+	return cd.UnmarshalText([]byte(strings.Trim(string(b), `"`)))
+}
+
+func (cd CustomDate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + cd.String() + `"`), nil
+}
+
+// UnmarshalText and MarshalText let CustomDate key JSON objects, such as historical rates.
+func (cd *CustomDate) UnmarshalText(b []byte) error {
+	t, err := time.Parse("2006-01-02", string(b))
 	if err != nil {
 		return err
 	}
@@ -35,8 +99,12 @@ func (cd *CustomDate) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func (cd CustomDate) MarshalJSON() ([]byte, error) {
-	return []byte(`"` + time.Time(cd).Format("2006-01-02") + `"`), nil
+func (cd CustomDate) MarshalText() ([]byte, error) {
+	return []byte(cd.String()), nil
+}
+
+func (cd CustomDate) String() string {
+	return time.Time(cd).Format("2006-01-02")
 }
 
 func (cd CustomDate) ToTime() time.Time {
@@ -54,13 +122,26 @@ type LatestRates struct {
 	Base      Currency             `json:"base"`
 	Rates     map[Currency]float64 `json:"rates"`
 	Timestamp int64                `json:"timestamp"` // Unix timestamp
+	Stale     bool                 `json:"stale,omitempty"`
 }
 
+// HistoricalRates are the daily rates from Base to Target. Rates are keyed by YYYY-MM-DD in
+// JSON, oldest first.
 type HistoricalRates struct {
-	Base   Currency              `json:"base"`
-	Rates  map[time.Time]float64 `json:"rates"`
-	Amount float64               `json:"amount"`
-	Target Currency              `json:"target"`
+	Base   Currency               `json:"base"`
+	Rates  map[CustomDate]float64 `json:"rates"`
+	Amount float64                `json:"amount"`
+	Target Currency               `json:"target"`
+}
+
+// Dates returns the days of r.Rates, oldest first.
+func (r *HistoricalRates) Dates() []CustomDate {
+	dates := make([]CustomDate, 0, len(r.Rates))
+	for date := range r.Rates {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].ToTime().Before(dates[j].ToTime()) })
+	return dates
 }
 
 type HistoricalTimeSeriesRatesResponse struct {
@@ -71,18 +152,68 @@ type HistoricalTimeSeriesRatesResponse struct {
 	Rates     map[string]map[string]float64 `json:"rates"`
 }
 
+// ConversionRequest asks for Amount in To, at the rate of Date, or the latest rate without one.
 type ConversionRequest struct {
-	From   Currency   `json:"from"`
-	To     Currency   `json:"to"`
-	Amount float64    `json:"amount"`
-	Date   *time.Time `json:"date,omitempty"`
+	Amount Money
+	To     Currency
+	Date   *CustomDate
 }
 
+// ConversionResult is a converted amount. Its JSON keeps the flat shape the API had before
+// amounts were Money: "from", "to", "amount" and "convertedAmount".
 type ConversionResult struct {
-	From            Currency   `json:"from"`
-	To              Currency   `json:"to"`
-	OriginalAmount  float64    `json:"amount"`
-	ConvertedAmount float64    `json:"convertedAmount"`
-	Rate            float64    `json:"rate"`
-	Date            *time.Time `json:"onDate,omitempty"`
+	Amount    Money
+	Converted Money // rounded to the minor units of its currency
+	Rate      float64
+	Date      *CustomDate // set for conversions at a past date's rate
+	Timestamp int64       // Unix time the rate was published at, set for conversions at the latest rate
+	Stale     bool
+}
+
+type conversionResultJSON struct {
+	From            Currency    `json:"from"`
+	To              Currency    `json:"to"`
+	OriginalAmount  json.Number `json:"amount"`
+	ConvertedAmount json.Number `json:"convertedAmount"`
+	Rate            float64     `json:"rate"`
+	Date            *CustomDate `json:"onDate,omitempty"`
+	Timestamp       int64       `json:"timestamp,omitempty"`
+	Stale           bool        `json:"stale,omitempty"`
+}
+
+func (r ConversionResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conversionResultJSON{
+		From:            r.Amount.Currency,
+		To:              r.Converted.Currency,
+		OriginalAmount:  json.Number(r.Amount.Amount.String()),
+		ConvertedAmount: json.Number(r.Converted.Amount.String()),
+		Rate:            r.Rate,
+		Date:            r.Date,
+		Timestamp:       r.Timestamp,
+		Stale:           r.Stale,
+	})
+}
+
+func (r *ConversionResult) UnmarshalJSON(b []byte) error {
+	var v conversionResultJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	amount, err := parseDecimal(v.OriginalAmount)
+	if err != nil {
+		return err
+	}
+	converted, err := parseDecimal(v.ConvertedAmount)
+	if err != nil {
+		return err
+	}
+	*r = ConversionResult{
+		Amount:    NewMoney(amount, v.From),
+		Converted: NewMoney(converted, v.To),
+		Rate:      v.Rate,
+		Date:      v.Date,
+		Timestamp: v.Timestamp,
+		Stale:     v.Stale,
+	}
+	return nil
 }
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoricalRates_JSON(t *testing.T) {
+	first := DateOf(time.Date(2025, 4, 9, 18, 30, 0, 0, time.FixedZone("IST", 19800)))
+	second := DateOf(time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC))
+	rates := HistoricalRates{Base: "USD", Target: "INR", Amount: 1, Rates: map[CustomDate]float64{second: 86.16, first: 86.67}}
+
+	data, err := json.Marshal(rates)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"base":"USD","rates":{"2025-04-09":86.67,"2025-04-10":86.16},"amount":1,"target":"INR"}`, string(data))
+	assert.Equal(t, []CustomDate{first, second}, rates.Dates())
+
+	var decoded HistoricalRates
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, rates, decoded)
+	assert.Error(t, json.Unmarshal([]byte(`{"rates":{"2025-04-10T00:00:00Z":86.16}}`), &decoded))
+}
@@ -0,0 +1,221 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/quota"
+	"currency-exchange/internals/adapter/usage"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/metrics"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// CallLimiter applies the inbound rate limits, sharing its buckets with the HTTP listener.
+type CallLimiter interface {
+	AllowCall(ip, callerID, tier string) bool
+}
+
+// QuotaCounter counts calls per API key per calendar month, together with HTTP requests.
+type QuotaCounter interface {
+	Increment(ctx context.Context, keyID string, now time.Time) (int64, error)
+	Decrement(ctx context.Context, keyID string, now time.Time) error
+}
+
+// UsageRecorder aggregates calls per key, endpoint, currency pair and day.
+type UsageRecorder interface {
+	Record(ctx context.Context, hit usage.Hit, now time.Time) error
+}
+
+// AuthFailureTracker counts failed authentication attempts per source and blocks sources that
+// fail too often.
+type AuthFailureTracker interface {
+	Fail(ctx context.Context, source string) (bool, error)
+	BlockedFor(ctx context.Context, source string) (time.Duration, error)
+}
+
+// admit applies the rules the /v1/historical route applies to HTTP requests, in the same
+// order: the IP allow and deny lists, the authentication lockout, the API key with its scope
+// and restrictions, the rate limits and the monthly quota. It returns the ID of the caller's
+// key, empty for anonymous calls.
+func (r *ratesServer) admit(ctx context.Context, base, target domain.Currency) (string, error) {
+	ip := r.clientIP(ctx)
+	network := r.opts.Network
+	if len(network.Allow) > 0 || len(network.Deny) > 0 {
+		if !ip.IsValid() || inRanges(network.Deny, ip) || (len(network.Allow) > 0 && !inRanges(network.Allow, ip)) {
+			return "", status.Error(codes.PermissionDenied, "calls from "+ipString(ip)+" are not allowed")
+		}
+	}
+
+	var secret string
+	if values := metadata.ValueFromIncomingContext(ctx, APIKeyMetadata); len(values) > 0 {
+		secret = values[0]
+	}
+	source := "ip:" + ipString(ip)
+	if secret != "" && r.opts.AuthLockout != nil {
+		blockedFor, err := r.opts.AuthLockout.BlockedFor(ctx, source)
+		if err != nil {
+			r.logger.Warn("Could not check authentication lockout", "source", source, "error", err)
+		}
+		if blockedFor > 0 {
+			metrics.ObserveAuthFailure("blocked")
+			retryAfter := strconv.Itoa(int(math.Ceil(blockedFor.Seconds())))
+			return "", status.Error(codes.ResourceExhausted, "too many failed authentication attempts, retry in "+retryAfter+"s")
+		}
+	}
+
+	key, err := r.authorize(ctx, secret, base, target)
+	if status.Code(err) == codes.Unauthenticated && secret != "" && r.opts.AuthLockout != nil {
+		r.logger.Warn("Authentication failed", "ip", ipString(ip), "method", "StreamHistoricalRates", "reason", status.Convert(err).Message())
+		metrics.ObserveAuthFailure("rejected")
+		if blocked, failErr := r.opts.AuthLockout.Fail(ctx, source); failErr != nil {
+			r.logger.Warn("Could not count authentication failure", "source", source, "error", failErr)
+		} else if blocked {
+			r.logger.Warn("Authentication source locked out", "source", source)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if r.opts.Limiter != nil && !r.opts.Limiter.AllowCall(ipString(ip), key.ID, "") {
+		return "", status.Error(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	}
+	if key.ID != "" {
+		if err := r.chargeQuota(ctx, key.ID); err != nil {
+			return "", err
+		}
+	}
+	return key.ID, nil
+}
+
+// authorize applies the API key rules of the /v1/historical endpoint. Anonymous callers get a
+// zero key when keys aren't required.
+func (r *ratesServer) authorize(ctx context.Context, secret string, base, target domain.Currency) (apikey.Key, error) {
+	if secret == "" || r.opts.Keys == nil {
+		if r.opts.KeysRequired {
+			return apikey.Key{}, status.Error(codes.Unauthenticated, "API key required")
+		}
+		return apikey.Key{}, nil
+	}
+	key, err := r.opts.Keys.Authenticate(ctx, secret)
+	if errors.Is(err, apikey.ErrInvalid) {
+		return apikey.Key{}, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if err != nil {
+		r.logger.Error("Failed to authenticate API key", "error", err)
+		return apikey.Key{}, status.Error(codes.Internal, "failed to authenticate API key")
+	}
+	if !key.HasScope(apikey.ScopeRatesRead) {
+		return apikey.Key{}, status.Error(codes.PermissionDenied, "credentials lack the "+apikey.ScopeRatesRead+" scope")
+	}
+	if !key.Restrictions.AllowsEndpoint("historical") {
+		return apikey.Key{}, status.Error(codes.PermissionDenied, "this API key may not use historical rates")
+	}
+	if !key.Restrictions.AllowsPair(string(base), string(target)) {
+		return apikey.Key{}, status.Error(codes.PermissionDenied, "this API key may not query "+string(base)+"/"+string(target))
+	}
+	return key, nil
+}
+
+// chargeQuota counts the call against the key's monthly quota like an HTTP request. A call
+// over quota is refused and not counted; when the count can't be kept, calls are let through.
+func (r *ratesServer) chargeQuota(ctx context.Context, keyID string) error {
+	if r.opts.Quota == nil {
+		return nil
+	}
+	now := r.now()
+	count, err := r.opts.Quota.Increment(ctx, keyID, now)
+	if err != nil {
+		r.logger.Warn("Could not count call against quota", "key_id", keyID, "error", err)
+		return nil
+	}
+	var limit int64
+	if r.opts.RateLimits.Enabled {
+		limit = r.opts.RateLimits.ForCaller(keyID, "").MonthlyQuota
+	}
+	if limit == 0 || count <= limit {
+		return nil
+	}
+	if err := r.opts.Quota.Decrement(ctx, keyID, now); err != nil {
+		r.logger.Warn("Could not uncount rejected call", "key_id", keyID, "error", err)
+	}
+	_, resetsAt := quota.Period(now)
+	return status.Error(codes.ResourceExhausted, "monthly quota of "+strconv.FormatInt(limit, 10)+" requests exceeded, it resets on "+resetsAt.Format(time.RFC3339))
+}
+
+// recordUsage counts a served call in the usage breakdown. Failures are only logged.
+func (r *ratesServer) recordUsage(ctx context.Context, keyID string, base, target domain.Currency) {
+	if r.opts.Usage == nil || keyID == "" {
+		return
+	}
+	hit := usage.Hit{KeyID: keyID, Endpoint: "historical"}
+	if base.WellFormed() && target.WellFormed() {
+		hit.Pair = string(base) + "/" + string(target)
+	}
+	if err := r.opts.Usage.Record(context.WithoutCancel(ctx), hit, r.now()); err != nil {
+		r.logger.Warn("Could not record usage", "key_id", keyID, "error", err)
+	}
+}
+
+// clientIP returns the address of the peer, or for peers in Network.TrustedProxies the client
+// they name in the Network.ClientIPHeader metadata, read from the right as for HTTP. It is
+// invalid when the peer isn't on an IP network.
+func (r *ratesServer) clientIP(ctx context.Context) netip.Addr {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	ip = ip.Unmap()
+	trusted := r.opts.Network.TrustedProxies
+	if !inRanges(trusted, ip) || r.opts.Network.ClientIPHeader == "" {
+		return ip
+	}
+	hops := strings.Split(strings.Join(metadata.ValueFromIncomingContext(ctx, strings.ToLower(r.opts.Network.ClientIPHeader)), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !inRanges(trusted, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func ipString(ip netip.Addr) string {
+	if !ip.IsValid() {
+		return "unknown"
+	}
+	return ip.String()
+}
+
+func inRanges(ranges []netip.Prefix, ip netip.Addr) bool {
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/adapter/usage"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/grpcapi/ratespb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type fakeQuota struct{ counts map[string]int64 }
+
+func (f *fakeQuota) Increment(_ context.Context, keyID string, _ time.Time) (int64, error) {
+	f.counts[keyID]++
+	return f.counts[keyID], nil
+}
+
+func (f *fakeQuota) Decrement(_ context.Context, keyID string, _ time.Time) error {
+	f.counts[keyID]--
+	return nil
+}
+
+type fakeUsage struct{ hits []usage.Hit }
+
+func (f *fakeUsage) Record(_ context.Context, hit usage.Hit, _ time.Time) error {
+	f.hits = append(f.hits, hit)
+	return nil
+}
+
+type fakeLimiter struct{ allow bool }
+
+func (f fakeLimiter) AllowCall(ip, callerID, tier string) bool { return f.allow }
+
+type fakeLockout struct {
+	failed  []string
+	blocked time.Duration
+}
+
+func (f *fakeLockout) Fail(_ context.Context, source string) (bool, error) {
+	f.failed = append(f.failed, source)
+	return false, nil
+}
+
+func (f *fakeLockout) BlockedFor(context.Context, string) (time.Duration, error) {
+	return f.blocked, nil
+}
+
+func TestStreamHistoricalRates_QuotaAndUsage(t *testing.T) {
+	quotas, recorder := &fakeQuota{counts: map[string]int64{}}, &fakeUsage{}
+	_, conn := setupServer(t, &mockReadiness{}, func(s *Server) {
+		s.RegisterRates(&fakeRates{}, RatesOptions{
+			Keys:       fakeKeys{"reader": {ID: "k1", Role: apikey.RoleReader}},
+			RateLimits: config.RateLimitConfig{Enabled: true, PerKey: config.Limit{MonthlyQuota: 1}},
+			Quota:      quotas,
+			Usage:      recorder,
+		})
+	})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, "reader")
+	client := ratespb.NewRatesClient(conn)
+	call := func() error {
+		stream, err := client.StreamHistoricalRates(ctx, &ratespb.StreamHistoricalRatesRequest{Base: "USD", Target: "INR", StartDate: "2025-01-01"})
+		assert.NoError(t, err)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	assert.NoError(t, call())
+	err := call()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "monthly quota of 1 requests exceeded")
+	// The refused call is neither counted nor recorded.
+	assert.Equal(t, int64(1), quotas.counts["k1"])
+	assert.Equal(t, []usage.Hit{{KeyID: "k1", Endpoint: "historical", Pair: "USD/INR"}}, recorder.hits)
+}
+
+func TestAdmit(t *testing.T) {
+	from := func(ip, secret string, md ...string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+		if secret != "" {
+			md = append(md, APIKeyMetadata, secret)
+		}
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(md...))
+	}
+	newServer := func(opts RatesOptions) *ratesServer {
+		opts.Keys = fakeKeys{"reader": {ID: "k1", Role: apikey.RoleReader}}
+		return &ratesServer{opts: opts, logger: slog.New(slog.NewTextHandler(io.Discard, nil)), now: time.Now}
+	}
+
+	network := config.NetworkConfig{
+		Deny:           []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		ClientIPHeader: "X-Forwarded-For",
+	}
+	r := newServer(RatesOptions{Network: network})
+	_, err := r.admit(from("203.0.113.9", "reader"), "USD", "INR")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	// Behind a trusted proxy the forwarded client is the one checked.
+	_, err = r.admit(from("10.0.0.2", "reader", "x-forwarded-for", "203.0.113.9"), "USD", "INR")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	keyID, err := r.admit(from("10.0.0.2", "reader", "x-forwarded-for", "198.51.100.1"), "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+
+	r = newServer(RatesOptions{Limiter: fakeLimiter{allow: false}})
+	_, err = r.admit(from("198.51.100.1", "reader"), "USD", "INR")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	lockout := &fakeLockout{}
+	r = newServer(RatesOptions{AuthLockout: lockout})
+	_, err = r.admit(from("198.51.100.1", "guess"), "USD", "INR")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"ip:198.51.100.1"}, lockout.failed)
+	lockout.blocked = time.Minute
+	_, err = r.admit(from("198.51.100.1", "reader"), "USD", "INR")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
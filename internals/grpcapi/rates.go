@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/config"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/grpcapi/ratespb"
+	"currency-exchange/internals/service"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadata is the metadata key callers send their API key in, the gRPC counterpart of
+// the X-API-Key header.
+const APIKeyMetadata = "x-api-key"
+
+const dateLayout = "2006-01-02"
+
+// KeyAuthenticator resolves an API key secret to its key.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, secret string) (apikey.Key, error)
+}
+
+// RatesOptions configures the rates service. Calls are held to the same network, lockout,
+// rate limit, quota and usage rules as /v1/historical; those left nil are not applied.
+type RatesOptions struct {
+	Keys         KeyAuthenticator
+	KeysRequired bool // reject calls without an API key, as API_KEYS_REQUIRED does for /v1
+	// Prefetch is how many months of a range are fetched ahead of the one being sent.
+	Prefetch    int
+	Network     config.NetworkConfig
+	RateLimits  config.RateLimitConfig // picks each key's monthly quota
+	Limiter     CallLimiter
+	Quota       QuotaCounter
+	Usage       UsageRecorder
+	AuthLockout AuthFailureTracker
+}
+
+type ratesServer struct {
+	ratespb.UnimplementedRatesServer
+	rates  service.RateService
+	opts   RatesOptions
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// RegisterRates serves the currencyexchange.v1.Rates service from rates. Call it before Serve.
+func (s *Server) RegisterRates(rates service.RateService, opts RatesOptions) {
+	if opts.Prefetch < 1 {
+		opts.Prefetch = 1
+	}
+	ratespb.RegisterRatesServer(s.grpc, &ratesServer{rates: rates, opts: opts, logger: s.logger, now: time.Now})
+}
+
+type monthRates struct {
+	rates *domain.HistoricalRates
+	err   error
+}
+
+// StreamHistoricalRates fetches the range a calendar month at a time, up to Prefetch months
+// ahead, and sends each month's rates as soon as it and the months before it are in.
+func (r *ratesServer) StreamHistoricalRates(req *ratespb.StreamHistoricalRatesRequest, stream ratespb.Rates_StreamHistoricalRatesServer) error {
+	base := domain.Currency(strings.ToUpper(req.GetBase()))
+	target := domain.Currency(strings.ToUpper(req.GetTarget()))
+	keyID, err := r.admit(stream.Context(), base, target)
+	if err != nil {
+		return err
+	}
+	defer r.recordUsage(stream.Context(), keyID, base, target)
+	for _, currency := range []domain.Currency{base, target} {
+		if err := r.rates.ValidateCurrencies(currency); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	start, err := time.Parse(dateLayout, req.GetStartDate())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "start_date must be formatted as yyyy-mm-dd")
+	}
+	end := start
+	if req.GetEndDate() != "" {
+		if end, err = time.Parse(dateLayout, req.GetEndDate()); err != nil {
+			return status.Error(codes.InvalidArgument, "end_date must be formatted as yyyy-mm-dd")
+		}
+	}
+	if end.Before(start) {
+		return status.Error(codes.InvalidArgument, "end_date is before start_date")
+	}
+	for _, currency := range []domain.Currency{base, target} {
+		if err := currency.CheckInUse(start, end); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	months := monthsOf(start, end)
+	results := make([]chan monthRates, len(months))
+	for i := range results {
+		results[i] = make(chan monthRates, 1)
+	}
+	// Months are started in order and each holds a slot until it has been sent, so the next
+	// month to send is never stuck behind later ones.
+	slots := make(chan struct{}, r.opts.Prefetch)
+	go func() {
+		for i, month := range months {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				rates, err := r.rates.GetHistoricalRates(ctx, month[0].Format(dateLayout), month[1].Format(dateLayout), base, target)
+				results[i] <- monthRates{rates: rates, err: err}
+			}()
+		}
+	}()
+
+	for i := range months {
+		var result monthRates
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if result.err != nil {
+			return r.statusOf(result.err, base, target)
+		}
+		for _, date := range result.rates.Dates() {
+			if err := stream.Send(&ratespb.HistoricalRate{Date: date.String(), Rate: result.rates.Rates[date]}); err != nil {
+				return err
+			}
+		}
+		<-slots
+	}
+	return nil
+}
+
+// statusOf maps an error of the rate service to a gRPC status.
+func (r *ratesServer) statusOf(err error, base, target domain.Currency) error {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr) && fiberErr.Code == http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, fiberErr.Message)
+	case errors.Is(err, service.ErrCurrencyNotSupported), errors.As(err, new(*domain.CurrencyNotInUseError)):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	r.logger.Error("Failed to fetch historical rates", "base", base, "target", target, "error", err)
+	return status.Error(codes.Unavailable, "failed to fetch historical rates")
+}
+
+// monthsOf splits [start, end] at calendar month boundaries.
+func monthsOf(start, end time.Time) [][2]time.Time {
+	var months [][2]time.Time
+	for monthStart := start; !monthStart.After(end); {
+		nextMonth := time.Date(monthStart.Year(), monthStart.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		monthEnd := nextMonth.AddDate(0, 0, -1)
+		if monthEnd.After(end) {
+			monthEnd = end
+		}
+		months = append(months, [2]time.Time{monthStart, monthEnd})
+		monthStart = nextMonth
+	}
+	return months
+}
@@ -0,0 +1,127 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"currency-exchange/internals/adapter/apikey"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/grpcapi/ratespb"
+	"currency-exchange/internals/service"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRates serves a rate of day-of-month for every day, and holds back the months in
+// blocked until they are released.
+type fakeRates struct {
+	service.RateService
+	blocked map[string]chan struct{}
+}
+
+func (f *fakeRates) ValidateCurrencies(currency domain.Currency) error {
+	if !currency.IsSupported() {
+		return service.ErrCurrencyNotSupported
+	}
+	return nil
+}
+
+func (f *fakeRates) GetHistoricalRates(ctx context.Context, startDate, endDate string, base, target domain.Currency) (*domain.HistoricalRates, error) {
+	if release, ok := f.blocked[startDate]; ok {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	start, _ := time.Parse(dateLayout, startDate)
+	end, _ := time.Parse(dateLayout, endDate)
+	rates := map[domain.CustomDate]float64{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		rates[domain.DateOf(day)] = float64(day.Day())
+	}
+	return &domain.HistoricalRates{Base: base, Target: target, Amount: 1, Rates: rates}, nil
+}
+
+type fakeKeys map[string]apikey.Key
+
+func (f fakeKeys) Authenticate(_ context.Context, secret string) (apikey.Key, error) {
+	key, ok := f[secret]
+	if !ok {
+		return apikey.Key{}, apikey.ErrInvalid
+	}
+	return key, nil
+}
+
+func TestStreamHistoricalRates_SendsMonthsAsTheyArrive(t *testing.T) {
+	release := make(chan struct{})
+	rates := &fakeRates{blocked: map[string]chan struct{}{"2025-03-01": release}}
+	_, conn := setupServer(t, &mockReadiness{}, func(s *Server) { s.RegisterRates(rates, RatesOptions{Prefetch: 2}) })
+
+	stream, err := ratespb.NewRatesClient(conn).StreamHistoricalRates(context.Background(), &ratespb.StreamHistoricalRatesRequest{
+		Base: "usd", Target: "INR", StartDate: "2025-01-30", EndDate: "2025-03-02",
+	})
+	assert.NoError(t, err)
+
+	// January and February arrive while March is still being fetched.
+	var dates []string
+	for range 2 + 28 {
+		point, err := stream.Recv()
+		assert.NoError(t, err)
+		dates = append(dates, point.GetDate())
+	}
+	assert.Equal(t, "2025-01-30", dates[0])
+	assert.Equal(t, "2025-02-01", dates[2])
+	assert.Equal(t, "2025-02-28", dates[29])
+
+	close(release)
+	for _, want := range []string{"2025-03-01", "2025-03-02"} {
+		point, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, want, point.GetDate())
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamHistoricalRates_RejectsBadRequests(t *testing.T) {
+	keys := fakeKeys{
+		"eur-only": {ID: "k1", Role: apikey.RoleReader, Restrictions: apikey.Restrictions{Pairs: []string{"EUR/*"}}},
+	}
+	_, conn := setupServer(t, &mockReadiness{}, func(s *Server) {
+		s.RegisterRates(&fakeRates{}, RatesOptions{Keys: keys, KeysRequired: true})
+	})
+	client := ratespb.NewRatesClient(conn)
+	withKey := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, secret)
+	}
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		req  *ratespb.StreamHistoricalRatesRequest
+		code codes.Code
+	}{
+		{context.Background(), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "INR", StartDate: "2025-01-01"}, codes.Unauthenticated},
+		{withKey("unknown"), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "INR", StartDate: "2025-01-01"}, codes.Unauthenticated},
+		{withKey("eur-only"), &ratespb.StreamHistoricalRatesRequest{Base: "USD", Target: "INR", StartDate: "2025-01-01"}, codes.PermissionDenied},
+		{withKey("eur-only"), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "XXX", StartDate: "2025-01-01"}, codes.InvalidArgument},
+		{withKey("eur-only"), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "INR", StartDate: "01/01/2025"}, codes.InvalidArgument},
+		{withKey("eur-only"), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "INR", StartDate: "2025-02-01", EndDate: "2025-01-01"}, codes.InvalidArgument},
+	} {
+		stream, err := client.StreamHistoricalRates(tc.ctx, tc.req)
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, tc.code, status.Code(err), "%v", tc.req)
+	}
+
+	stream, err := client.StreamHistoricalRates(withKey("eur-only"), &ratespb.StreamHistoricalRatesRequest{Base: "EUR", Target: "INR", StartDate: "2025-01-01"})
+	assert.NoError(t, err)
+	point, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, &ratespb.HistoricalRate{Date: "2025-01-01", Rate: 1}, &ratespb.HistoricalRate{Date: point.GetDate(), Rate: point.GetRate()})
+}
@@ -0,0 +1,132 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: currencyexchange/v1/rates.proto
+
+package ratespb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Rates_StreamHistoricalRates_FullMethodName = "/currencyexchange.v1.Rates/StreamHistoricalRates"
+)
+
+// RatesClient is the client API for Rates service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Rates serves exchange rates over gRPC.
+type RatesClient interface {
+	// StreamHistoricalRates sends the daily rates of a range in date order, a month at a time as
+	// each month is fetched, so consumers of long ranges can start before the whole range is in.
+	StreamHistoricalRates(ctx context.Context, in *StreamHistoricalRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoricalRate], error)
+}
+
+type ratesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRatesClient(cc grpc.ClientConnInterface) RatesClient {
+	return &ratesClient{cc}
+}
+
+func (c *ratesClient) StreamHistoricalRates(ctx context.Context, in *StreamHistoricalRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoricalRate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Rates_ServiceDesc.Streams[0], Rates_StreamHistoricalRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamHistoricalRatesRequest, HistoricalRate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Rates_StreamHistoricalRatesClient = grpc.ServerStreamingClient[HistoricalRate]
+
+// RatesServer is the server API for Rates service.
+// All implementations must embed UnimplementedRatesServer
+// for forward compatibility.
+//
+// Rates serves exchange rates over gRPC.
+type RatesServer interface {
+	// StreamHistoricalRates sends the daily rates of a range in date order, a month at a time as
+	// each month is fetched, so consumers of long ranges can start before the whole range is in.
+	StreamHistoricalRates(*StreamHistoricalRatesRequest, grpc.ServerStreamingServer[HistoricalRate]) error
+	mustEmbedUnimplementedRatesServer()
+}
+
+// UnimplementedRatesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRatesServer struct{}
+
+func (UnimplementedRatesServer) StreamHistoricalRates(*StreamHistoricalRatesRequest, grpc.ServerStreamingServer[HistoricalRate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamHistoricalRates not implemented")
+}
+func (UnimplementedRatesServer) mustEmbedUnimplementedRatesServer() {}
+func (UnimplementedRatesServer) testEmbeddedByValue()               {}
+
+// UnsafeRatesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RatesServer will
+// result in compilation errors.
+type UnsafeRatesServer interface {
+	mustEmbedUnimplementedRatesServer()
+}
+
+func RegisterRatesServer(s grpc.ServiceRegistrar, srv RatesServer) {
+	// If the following call pancis, it indicates UnimplementedRatesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Rates_ServiceDesc, srv)
+}
+
+func _Rates_StreamHistoricalRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamHistoricalRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RatesServer).StreamHistoricalRates(m, &grpc.GenericServerStream[StreamHistoricalRatesRequest, HistoricalRate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Rates_StreamHistoricalRatesServer = grpc.ServerStreamingServer[HistoricalRate]
+
+// Rates_ServiceDesc is the grpc.ServiceDesc for Rates service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Rates_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "currencyexchange.v1.Rates",
+	HandlerType: (*RatesServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamHistoricalRates",
+			Handler:       _Rates_StreamHistoricalRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "currencyexchange/v1/rates.proto",
+}
@@ -0,0 +1,100 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// ServiceName is the service load balancers can ask the health of, besides "" for the server
+// as a whole. Both report the same status.
+const ServiceName = "currency-exchange"
+
+// Readiness tells whether the service can take traffic, as the HTTP readiness probe does.
+type Readiness interface {
+	Serving() bool
+}
+
+// Server is the gRPC listener. It serves the standard grpc.health.v1 service, kept in step
+// with the HTTP readiness probe, and server reflection, so load balancers and grpcurl work
+// without the service's protos.
+type Server struct {
+	grpc      *grpc.Server
+	health    *health.Server
+	readiness Readiness
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewServer checks readiness every interval to update the health status.
+func NewServer(readiness Readiness, interval time.Duration, logger *slog.Logger) *Server {
+	s := &Server{
+		grpc:      grpc.NewServer(),
+		health:    health.NewServer(),
+		readiness: readiness,
+		interval:  interval,
+		logger:    logger,
+	}
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	s.update()
+	return s
+}
+
+// Serve accepts connections on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// WatchHealth keeps the health status in step with readiness until ctx is done.
+func (s *Server) WatchHealth(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.update()
+		}
+	}
+}
+
+func (s *Server) update() {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if s.readiness.Serving() {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	if current, err := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ServiceName}); err == nil && current.Status == status {
+		return
+	}
+	s.logger.Info("gRPC health status changed", "status", status.String())
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(ServiceName, status)
+}
+
+// Drain reports NOT_SERVING from now on, so load balancers stop routing here during shutdown.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Stop waits for in-flight calls to finish until ctx is done, then closes the remaining
+// connections.
+func (s *Server) Stop(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
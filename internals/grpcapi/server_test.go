@@ -0,0 +1,95 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockReadiness struct {
+	serving atomic.Bool
+}
+
+func (m *mockReadiness) Serving() bool { return m.serving.Load() }
+
+// setupServer serves over an in-memory listener, after running register on the server.
+func setupServer(t *testing.T, readiness Readiness, register ...func(*Server)) (*Server, *grpc.ClientConn) {
+	server := NewServer(readiness, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, r := range register {
+		r(server)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return server, conn
+}
+
+func checkHealth(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	assert.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestServer_Health(t *testing.T) {
+	readiness := &mockReadiness{}
+	readiness.serving.Store(true)
+	server, conn := setupServer(t, readiness)
+	client := healthpb.NewHealthClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.WatchHealth(ctx)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkHealth(t, client, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkHealth(t, client, ServiceName))
+
+	readiness.serving.Store(false)
+	assert.Eventually(t, func() bool {
+		return checkHealth(t, client, ServiceName) == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	readiness.serving.Store(true)
+	assert.Eventually(t, func() bool {
+		return checkHealth(t, client, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	// Once draining, the status stays NOT_SERVING whatever readiness says.
+	server.Drain()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkHealth(t, client, ""))
+}
+
+func TestServer_Reflection(t *testing.T) {
+	_, conn := setupServer(t, &mockReadiness{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	assert.Contains(t, services, "grpc.health.v1.Health")
+	assert.Contains(t, services, "grpc.reflection.v1.ServerReflection")
+}
@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+)
+
+// validatorCacheBytes bounds the payloads kept for answering 304s. The scheduler only ever
+// asks for a handful of bases, so this is generous; historical ranges can be large, though,
+// hence a bound in bytes rather than entries.
+const validatorCacheBytes = 8 << 20
+
+// credentialParam matches the query parameters providers take credentials in, such as
+// access_key, api_key or app_id. They are left out of cache keys.
+var credentialParam = regexp.MustCompile(`(?i)(key|token|secret|password|app_?id)`)
+
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// ValidatorCache remembers the ETag / Last-Modified and body of the last 200 response per
+// request, so repeat requests can be made conditional and a 304 answered from memory. Entries
+// are keyed by URL without credentials, and evicted once their bodies exceed maxBytes.
+type ValidatorCache struct {
+	mu       sync.Mutex
+	entries  map[string]validatedResponse
+	size     int
+	maxBytes int
+}
+
+func NewValidatorCache() *ValidatorCache {
+	return &ValidatorCache{entries: make(map[string]validatedResponse), maxBytes: validatorCacheBytes}
+}
+
+// DefaultValidatorCache is used by doRequest. Set it to nil to turn conditional requests off.
+var DefaultValidatorCache = NewValidatorCache()
+
+// validatorKey identifies a request to rawURL with params, leaving out credentials so the cache
+// never holds them.
+func validatorKey(rawURL string, params url.Values) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+	kept := make(url.Values, len(params))
+	for name, values := range params {
+		if !credentialParam.MatchString(name) {
+			kept[name] = values
+		}
+	}
+	if len(kept) == 0 {
+		return rawURL
+	}
+	return rawURL + "?" + kept.Encode()
+}
+
+// prepare adds If-None-Match / If-Modified-Since to req when we hold a payload for key.
+func (c *ValidatorCache) prepare(key string, req *http.Request) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	if entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+}
+
+// store keeps body for key when the response carries a validator we can send back later and
+// the body fits in the cache.
+func (c *ValidatorCache) store(key string, header http.Header, body []byte) {
+	if c == nil {
+		return
+	}
+	entry := validatedResponse{
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+		body:         body,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, ok := c.entries[key]; ok {
+		c.size -= len(previous.body)
+		delete(c.entries, key)
+	}
+	if (entry.etag == "" && entry.lastModified == "") || len(body) > c.maxBytes {
+		return
+	}
+	for other, evicted := range c.entries {
+		if c.size+len(body) <= c.maxBytes {
+			break
+		}
+		// Drop arbitrary entries; the worst case is one unconditional request each.
+		c.size -= len(evicted.body)
+		delete(c.entries, other)
+	}
+	c.entries[key] = entry
+	c.size += len(body)
+}
+
+// cached returns the payload stored for key, for answering a 304.
+func (c *ValidatorCache) cached(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry.body, ok
+}
@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoRequest_ReusesPayloadOnNotModified(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"rate":82.5}`))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		var body struct{ Rate float64 }
+		err := GetJSON(context.Background(), server.URL, nil, &body)
+		assert.NoError(t, err)
+		assert.Equal(t, 82.5, body.Rate)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestDoRequest_SendsIfModifiedSince(t *testing.T) {
+	const lastModified = "Fri, 03 May 2024 16:00:00 GMT"
+	var conditional string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Get("If-Modified-Since")
+		if conditional == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(`{"rate":1.1}`))
+	}))
+	defer server.Close()
+
+	var first, second struct{ Rate float64 }
+	assert.NoError(t, GetJSON(context.Background(), server.URL, nil, &first))
+	assert.Empty(t, conditional)
+	assert.NoError(t, GetJSON(context.Background(), server.URL, nil, &second))
+	assert.Equal(t, lastModified, conditional)
+	assert.Equal(t, 1.1, second.Rate)
+}
+
+func TestValidatorCache_SkipsResponsesWithoutValidators(t *testing.T) {
+	cache := NewValidatorCache()
+	cache.store("http://example.test/latest", http.Header{}, []byte(`{}`))
+
+	_, ok := cache.cached("http://example.test/latest")
+	assert.False(t, ok)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test/latest", nil)
+	cache.prepare("http://example.test/latest", req)
+	assert.Empty(t, req.Header.Get("If-None-Match"))
+}
+
+func TestDoRequest_NotModifiedWithoutPayloadFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	var body struct{}
+	err := GetJSON(context.Background(), server.URL, nil, &body)
+	assert.ErrorContains(t, err, "http status 304")
+}
+
+func TestValidatorKey_LeavesOutCredentials(t *testing.T) {
+	params := url.Values{"access_key": {"secret-1"}, "app_id": {"secret-2"}, "base": {"USD"}, "symbols": {"INR"}}
+	assert.Equal(t, "https://api.example.test/latest?base=USD&symbols=INR", validatorKey("https://api.example.test/latest", params))
+	assert.Equal(t, "https://api.example.test/latest", validatorKey("https://api.example.test/latest", url.Values{"api_key": {"secret"}}))
+}
+
+func TestValidatorCache_BoundedByBytes(t *testing.T) {
+	cache := NewValidatorCache()
+	cache.maxBytes = 10
+	header := http.Header{"Etag": {`"v1"`}}
+
+	cache.store("a", header, []byte("123456"))
+	cache.store("b", header, []byte("1234"))
+	assert.Equal(t, 10, cache.size)
+	// Storing c evicts enough to make room.
+	cache.store("c", header, []byte("12345"))
+	assert.LessOrEqual(t, cache.size, 10)
+	_, ok := cache.cached("c")
+	assert.True(t, ok)
+	// Replacing an entry frees its old body first.
+	cache.store("c", header, []byte("1"))
+	_, ok = cache.cached("c")
+	assert.True(t, ok)
+	assert.LessOrEqual(t, cache.size, 10)
+	// Bodies larger than the whole cache aren't kept.
+	cache.store("d", header, []byte("12345678901"))
+	_, ok = cache.cached("d")
+	assert.False(t, ok)
+}
@@ -1,12 +1,19 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
 	"currency-exchange/internals/core/domain"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,26 +24,41 @@ import (
 // )
 
 type FrankFurterAPI interface {
-	GetLatest(fromCurrency string, toCurrencies []string) (*domain.ExchangeResponse, error)
-	GetHistoricalTimeSeries(fromCurrency string, toCurrency []string, startDate time.Time, endDate time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error)
+	GetLatest(ctx context.Context, fromCurrency string, toCurrencies []string) (*domain.ExchangeResponse, error)
+	GetHistorical(ctx context.Context, fromCurrency string, toCurrencies []string, date time.Time) (*domain.ExchangeResponse, error)
+	GetHistoricalTimeSeries(ctx context.Context, fromCurrency string, toCurrency []string, startDate time.Time, endDate time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error)
+	GetCurrencies(ctx context.Context) (map[string]string, error)
 }
 
 type FrankFurterAPIClient struct {
-	baseURL string
-	dateFmt string
+	baseURL    string
+	dateFmt    string
+	httpClient *http.Client
 }
 
-func NewFrankFurterAPI(baseURL, dateFmt string) FrankFurterAPI {
+// NewFrankFurterAPI builds the Frankfurter client. httpClient may be nil, in which case
+// DefaultHTTPClient is used.
+func NewFrankFurterAPI(baseURL, dateFmt string, httpClient *http.Client) FrankFurterAPI {
+	if httpClient == nil {
+		httpClient = DefaultHTTPClient
+	}
 	return &FrankFurterAPIClient{
-		baseURL: baseURL,
-		dateFmt: dateFmt,
+		baseURL:    baseURL,
+		dateFmt:    dateFmt,
+		httpClient: httpClient,
 	}
 }
 
-func (f *FrankFurterAPIClient) GetLatest(fromCurrency string, toCurrencies []string) (*domain.ExchangeResponse, error) {
-	log.Printf("Fetching latest currecy exchange rates using %v API, for base %v urrency to target currecies %v", f.baseURL, fromCurrency, toCurrencies)
+// frankfurterLog returns the default logger, tagged like the providers' logger. internals/logging
+// depends on this package, so the per-component level of "providers" doesn't apply here.
+func frankfurterLog() *slog.Logger {
+	return slog.Default().With("component", "providers", "provider", "frankfurter")
+}
+
+func (f *FrankFurterAPIClient) GetLatest(ctx context.Context, fromCurrency string, toCurrencies []string) (*domain.ExchangeResponse, error) {
+	frankfurterLog().Debug("Fetching latest rates", "url", f.baseURL, "base", fromCurrency, "targets", toCurrencies)
 	response := &domain.ExchangeResponse{}
-	err := doRequest(f.baseURL+"latest", makeParams(fromCurrency, toCurrencies), response)
+	err := getJSON(ctx, f.httpClient, f.baseURL+"latest", makeParams(fromCurrency, toCurrencies), response)
 	if err != nil {
 		return nil, err
 	}
@@ -45,10 +67,23 @@ func (f *FrankFurterAPIClient) GetLatest(fromCurrency string, toCurrencies []str
 
 }
 
-func (f *FrankFurterAPIClient) GetHistoricalTimeSeries(fromCurrency string, toCurrency []string, startDate time.Time, endDate time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error) {
-	log.Printf("Fetching historical currecy exchange rates using %v API, for base %v urrency to target currecies %v from day %v to day %v", f.baseURL, fromCurrency, toCurrency, startDate, endDate)
+// GetHistorical returns the rates published for a single day. On weekends and holidays
+// Frankfurter answers with the closest earlier working day, so check the returned date.
+func (f *FrankFurterAPIClient) GetHistorical(ctx context.Context, fromCurrency string, toCurrencies []string, date time.Time) (*domain.ExchangeResponse, error) {
+	frankfurterLog().Debug("Fetching historical rates", "url", f.baseURL, "base", fromCurrency, "targets", toCurrencies, "date", date.Format(f.dateFmt))
+	response := &domain.ExchangeResponse{}
+	err := getJSON(ctx, f.httpClient, f.baseURL+date.Format(f.dateFmt), makeParams(fromCurrency, toCurrencies), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (f *FrankFurterAPIClient) GetHistoricalTimeSeries(ctx context.Context, fromCurrency string, toCurrency []string, startDate time.Time, endDate time.Time) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	frankfurterLog().Debug("Fetching historical time series", "url", f.baseURL, "base", fromCurrency, "targets", toCurrency, "start", startDate.Format(f.dateFmt), "end", endDate.Format(f.dateFmt))
 	response := &domain.HistoricalTimeSeriesRatesResponse{}
-	err := doRequest(f.baseURL+startDate.Format(f.dateFmt)+".."+endDate.Format(f.dateFmt), makeParams(fromCurrency, toCurrency), response)
+	err := getJSON(ctx, f.httpClient, f.baseURL+startDate.Format(f.dateFmt)+".."+endDate.Format(f.dateFmt), makeParams(fromCurrency, toCurrency), response)
 
 	if err != nil {
 		return nil, err
@@ -58,6 +93,18 @@ func (f *FrankFurterAPIClient) GetHistoricalTimeSeries(fromCurrency string, toCu
 
 }
 
+// GetCurrencies returns every currency Frankfurter publishes, as code -> name.
+func (f *FrankFurterAPIClient) GetCurrencies(ctx context.Context) (map[string]string, error) {
+	frankfurterLog().Debug("Fetching supported currencies", "url", f.baseURL)
+	currencies := make(map[string]string)
+	err := getJSON(ctx, f.httpClient, f.baseURL+"currencies", nil, &currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	return currencies, nil
+}
+
 // func doRequest(url string, params url.Values, w interface{}) error {
 // 	if len(params) > 0 {
 // 		url = fmt.Sprintf("%s?%s", url, params.Encode())
@@ -76,35 +123,207 @@ func (f *FrankFurterAPIClient) GetHistoricalTimeSeries(fromCurrency string, toCu
 // 	return json.NewDecoder(resp.Body).Decode(w)
 // }
 
-func doRequest(url string, params url.Values, w interface{}) error {
+// RetryPolicy controls how doRequest retries failed calls.
+type RetryPolicy struct {
+	MaxRetries    int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration // longest Retry-After we are willing to wait out
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:    5,
+	BaseDelay:     time.Second,
+	MaxDelay:      30 * time.Second,
+	MaxRetryAfter: time.Minute,
+}
+
+// DefaultHTTPClient is shared by callers that do not bring their own client, so connections
+// are pooled across requests instead of a new client being built for every call.
+var DefaultHTTPClient = &http.Client{
+	Timeout: time.Second * 30,
+}
+
+// HTTPClientOptions tunes the outbound HTTP client. Zero values keep Go's defaults.
+type HTTPClientOptions struct {
+	Timeout             time.Duration
+	ProxyURL            string
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+}
+
+// NewHTTPClient builds an *http.Client from opts. Without ProxyURL the standard
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables still apply.
+func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %q: %w", opts.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// GetJSON issues a GET request with retries and decodes the JSON body into w.
+func GetJSON(ctx context.Context, url string, params url.Values, w interface{}) error {
+	return getJSON(ctx, DefaultHTTPClient, url, params, w)
+}
+
+// GetXML issues a GET request with retries and decodes the XML body into w.
+func GetXML(ctx context.Context, url string, params url.Values, w interface{}) error {
+	return doRequest(ctx, DefaultHTTPClient, url, params, func(body io.Reader) error {
+		return xml.NewDecoder(body).Decode(w)
+	})
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, params url.Values, w interface{}) error {
+	return doRequest(ctx, client, url, params, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(w)
+	})
+}
+
+// doRequest retries network errors, 429 and 5xx responses with jittered exponential backoff,
+// honouring Retry-After when the server sends one. It gives up as soon as ctx is done or
+// when the next wait would run past the ctx deadline. Requests for URLs we already hold a
+// validated payload for are made conditional, and a 304 reuses that payload.
+func doRequest(ctx context.Context, client *http.Client, url string, params url.Values, decode func(body io.Reader) error) error {
+	cacheKey := validatorKey(url, params)
 	if len(params) > 0 {
 		url = fmt.Sprintf("%s?%s", url, params.Encode())
 	}
 
-	client := &http.Client{
-		Timeout: time.Second * 30,
-	}
+	policy := DefaultRetryPolicy
+	validators := DefaultValidatorCache
 
 	var lastErr error
-	baseDelay := time.Second
-	maxRetries := 5
+	for attempt := 0; attempt < policy.MaxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return withoutQuery(err)
+		}
+		setOutboundHeaders(ctx, req)
+		validators.prepare(cacheKey, req)
 
-	for i := 0; i < maxRetries; i++ {
-		resp, err := client.Get(url)
-		if err == nil {
-			defer resp.Body.Close()
+		var retryAfter time.Duration
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Network error, retry
+			lastErr = withoutQuery(err)
+		} else {
 			if resp.StatusCode == http.StatusOK {
-				return json.NewDecoder(resp.Body).Decode(w)
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					return err
+				}
+				validators.store(cacheKey, resp.Header, body)
+				return decode(bytes.NewReader(body))
 			}
-			// Treat non-200 as error
+			if resp.StatusCode == http.StatusNotModified {
+				resp.Body.Close()
+				if body, ok := validators.cached(cacheKey); ok {
+					return decode(bytes.NewReader(body))
+				}
+				return fmt.Errorf("http status %d without a cached payload", resp.StatusCode)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
 			lastErr = fmt.Errorf("http status %d", resp.StatusCode)
-			return lastErr
+			if !retryableStatus(resp.StatusCode) {
+				return lastErr
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if retryAfter > policy.MaxRetryAfter {
+				return fmt.Errorf("%w: server asked to retry after %s", lastErr, retryAfter)
+			}
+		}
+
+		if attempt == policy.MaxRetries-1 {
+			break
+		}
+		wait := retryAfter
+		if wait == 0 {
+			wait = backoff(policy, attempt)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("external API error, not retrying past context deadline: %w", lastErr)
 		}
-		// Network error, retry
-		lastErr = err
-		time.Sleep(baseDelay * (1 << i))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("external API error after %d retries: %w", policy.MaxRetries, lastErr)
+}
+
+// withoutQuery drops the query string from the URL that *url.Error messages quote, since
+// providers take their API keys as query parameters and these errors end up in logs and in
+// /admin/providers.
+func withoutQuery(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	if i := strings.IndexByte(redacted.URL, '?'); i >= 0 {
+		redacted.URL = redacted.URL[:i]
+	}
+	return &redacted
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// backoff returns the exponential delay for attempt with "equal jitter": half fixed, half random,
+// so instances retrying the same outage spread out without ever retrying immediately.
+func backoff(policy RetryPolicy, attempt int) time.Duration {
+	delay := policy.BaseDelay << attempt
+	if delay > policy.MaxDelay || delay <= 0 {
+		delay = policy.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter understands both forms of Retry-After: delay in seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
 	}
-	return fmt.Errorf("external API error after %d retries: %w", maxRetries, lastErr)
+	return 0
 }
 
 func makeParams(base string, currencies []string) url.Values {
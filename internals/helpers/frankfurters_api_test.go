@@ -1,9 +1,11 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -23,8 +25,8 @@ func TestGetLatest_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02")
-	resp, err := api.GetLatest("USD", []string{"INR", "EUR"})
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
+	resp, err := api.GetLatest(context.Background(), "USD", []string{"INR", "EUR"})
 	assert.NoError(t, err)
 	assert.Equal(t, "USD", resp.Base)
 	assert.Equal(t, 82.5, resp.Rates["INR"])
@@ -38,8 +40,8 @@ func TestGetLatest_Error(t *testing.T) {
 	}))
 	defer server.Close()
 
-	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02")
-	resp, err := api.GetLatest("USD", []string{"INR"})
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
+	resp, err := api.GetLatest(context.Background(), "USD", []string{"INR"})
 	assert.Error(t, err)
 	assert.Nil(t, resp)
 }
@@ -59,10 +61,10 @@ func TestGetHistoricalTimeSeries_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02")
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
 	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
-	resp, err := api.GetHistoricalTimeSeries("USD", []string{"INR"}, start, end)
+	resp, err := api.GetHistoricalTimeSeries(context.Background(), "USD", []string{"INR"}, start, end)
 	assert.NoError(t, err)
 	assert.Equal(t, "USD", resp.Base)
 	assert.Equal(t, 80.0, resp.Rates["2024-05-01"]["INR"])
@@ -75,10 +77,217 @@ func TestGetHistoricalTimeSeries_Error(t *testing.T) {
 	}))
 	defer server.Close()
 
-	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02")
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
 	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
-	resp, err := api.GetHistoricalTimeSeries("USD", []string{"INR"}, start, end)
+	resp, err := api.GetHistoricalTimeSeries(context.Background(), "USD", []string{"INR"}, start, end)
 	assert.Error(t, err)
 	assert.Nil(t, resp)
 }
+
+func TestGetHistorical_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2024-05-07", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("from"))
+		w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2024-05-07","rates":{"INR":82.5}}`))
+	}))
+	defer server.Close()
+
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
+	resp, err := api.GetHistorical(context.Background(), "USD", []string{"INR"}, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 82.5, resp.Rates["INR"])
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC), resp.Date.ToTime())
+}
+
+func TestGetCurrencies_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/currencies", r.URL.Path)
+		w.Write([]byte(`{"EUR":"Euro","INR":"Indian Rupee","USD":"United States Dollar"}`))
+	}))
+	defer server.Close()
+
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
+	currencies, err := api.GetCurrencies(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"EUR": "Euro", "INR": "Indian Rupee", "USD": "United States Dollar"}, currencies)
+}
+
+func TestGetCurrencies_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", nil)
+	currencies, err := api.GetCurrencies(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, currencies)
+}
+
+func TestDoRequest_RetriesServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	var body struct{ OK bool }
+	err := GetJSON(context.Background(), server.URL, nil, &body)
+	assert.NoError(t, err)
+	assert.True(t, body.OK)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoRequest_HonoursRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	var body struct{ OK bool }
+	err := GetJSON(context.Background(), server.URL, nil, &body)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, 2, calls)
+}
+
+func TestDoRequest_ClientErrorNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "bad", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var body struct{}
+	err := GetJSON(context.Background(), server.URL, nil, &body)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoRequest_StopsAtContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var body struct{}
+	err := GetJSON(ctx, server.URL, nil, &body)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoRequest_CancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var body struct{}
+	err := GetJSON(ctx, server.URL, nil, &body)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDoRequest_NetworkErrorHidesQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close() // nothing listens any more, so every attempt fails to connect
+
+	var body struct{}
+	err := GetJSON(context.Background(), server.URL+"/latest", url.Values{"access_key": {"provider-key-value"}}, &body)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "provider-key-value")
+	assert.Contains(t, err.Error(), server.URL+"/latest")
+	var urlErr *url.Error
+	assert.ErrorAs(t, err, &urlErr)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, 10*time.Second, parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestBackoff_JitterWithinBounds(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 6; attempt++ {
+		delay := policy.BaseDelay << attempt
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		wait := backoff(policy, attempt)
+		assert.GreaterOrEqual(t, wait, delay/2)
+		assert.LessOrEqual(t, wait, delay)
+	}
+}
+
+func TestNewFrankFurterAPI_UsesInjectedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "injected", r.Header.Get("X-Test-Client"))
+		json.NewEncoder(w).Encode(domain.ExchangeResponse{Base: "USD", Rates: map[string]float64{"INR": 82.5}})
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: headerTransport{"X-Test-Client", "injected"}}
+	api := NewFrankFurterAPI(server.URL+"/", "2006-01-02", httpClient)
+	resp, err := api.GetLatest(context.Background(), "USD", []string{"INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 82.5, resp.Rates["INR"])
+}
+
+type headerTransport struct{ key, value string }
+
+func (h headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(h.key, h.value)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	client, err := NewHTTPClient(HTTPClientOptions{
+		Timeout:             5 * time.Second,
+		ProxyURL:            "http://proxy.internal:3128",
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     time.Minute,
+		DisableKeepAlives:   true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 50, transport.MaxIdleConns)
+	assert.Equal(t, 20, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+
+	proxy, err := transport.Proxy(httptest.NewRequest("GET", "https://api.frankfurter.app/latest", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+
+	_, err = NewHTTPClient(HTTPClientOptions{ProxyURL: "://bad"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,14 @@
+package helpers
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Keep retrying tests fast.
+	DefaultRetryPolicy.BaseDelay = time.Millisecond
+	DefaultRetryPolicy.MaxDelay = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
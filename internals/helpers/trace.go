@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceParentHeader = "traceparent"
+)
+
+// UserAgent is sent on every provider call so upstreams can tell our traffic apart.
+var UserAgent = "currency-exchange/1.0"
+
+type requestIDKey struct{}
+type traceParentKey struct{}
+type runIDKey struct{}
+
+// WithRequestID attaches the ID of the inbound request to ctx so provider calls made on
+// its behalf carry the same X-Request-ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRunID attaches the ID of a background refresh run to ctx, so the provider calls made
+// by the run can be traced back to it.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// WithTraceParent attaches a W3C traceparent to ctx for propagation to providers.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+func TraceParent(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentKey{}).(string)
+	return traceParent
+}
+
+// TraceID returns the trace ID of the traceparent in ctx, or "" when there is none.
+func TraceID(ctx context.Context) string {
+	parts := strings.Split(TraceParent(ctx), "-")
+	if len(parts) != 4 {
+		return ""
+	}
+	return parts[1]
+}
+
+// ChildTraceParent continues the trace in parent with a new span ID, or starts a new trace
+// when parent is missing or malformed.
+func ChildTraceParent(parent string) string {
+	traceID, flags := randomHex(16), "00"
+	parts := strings.Split(parent, "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && len(parts[3]) == 2 && isHex(parts[1]) && isHex(parts[3]) && strings.Trim(parts[1], "0") != "" {
+		traceID, flags = strings.ToLower(parts[1]), strings.ToLower(parts[3])
+	}
+	return "00-" + traceID + "-" + randomHex(8) + "-" + flags
+}
+
+// setOutboundHeaders stamps the User-Agent and any trace headers found in ctx on req.
+func setOutboundHeaders(ctx context.Context, req *http.Request) {
+	if UserAgent != "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	if id := RequestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	if traceParent := TraceParent(ctx); traceParent != "" {
+		req.Header.Set(TraceParentHeader, traceParent)
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
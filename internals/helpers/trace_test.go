@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoRequest_SendsUserAgentAndTraceHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := WithTraceParent(WithRequestID(context.Background(), "req-123"), traceParent)
+	var body struct{}
+	assert.NoError(t, GetJSON(ctx, server.URL, nil, &body))
+
+	assert.Equal(t, UserAgent, got.Get("User-Agent"))
+	assert.Equal(t, "req-123", got.Get(RequestIDHeader))
+	assert.Equal(t, traceParent, got.Get(TraceParentHeader))
+}
+
+func TestDoRequest_OmitsTraceHeadersWithoutContext(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var body struct{}
+	assert.NoError(t, GetJSON(context.Background(), server.URL, nil, &body))
+	assert.Empty(t, got.Get(RequestIDHeader))
+	assert.Empty(t, got.Get(TraceParentHeader))
+}
+
+func TestChildTraceParent_ContinuesTrace(t *testing.T) {
+	child := ChildTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	parts := strings.Split(child, "-")
+	assert.Len(t, parts, 4)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parts[1])
+	assert.NotEqual(t, "00f067aa0ba902b7", parts[2])
+	assert.Equal(t, "01", parts[3])
+}
+
+func TestChildTraceParent_StartsNewTrace(t *testing.T) {
+	for _, parent := range []string{"", "garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		parts := strings.Split(ChildTraceParent(parent), "-")
+		assert.Len(t, parts, 4)
+		assert.Len(t, parts[1], 32)
+		assert.NotEqual(t, strings.Repeat("0", 32), parts[1])
+		assert.Len(t, parts[2], 16)
+		assert.Equal(t, "00", parts[3])
+	}
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"io"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileOptions configures a log file that is rotated once it reaches MaxSizeMB. Rotated files
+// beyond MaxBackups or older than MaxAgeDays are deleted; 0 keeps them regardless.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool // gzip rotated files
+}
+
+// NewFile returns a writer appending to opts.Path and rotating it as configured. The file and
+// its directory are created on the first write.
+func NewFile(opts FileOptions) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   opts.Path,
+		MaxSize:    opts.MaxSizeMB,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAgeDays,
+		Compress:   opts.Compress,
+	}
+}
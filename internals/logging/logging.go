@@ -0,0 +1,134 @@
+// Package logging configures slog for the service and hands out per-component loggers.
+//
+// Log lines use the same attribute names everywhere so they can be searched across
+// components: request_id, base, target, date, provider, duration and error.
+package logging
+
+import (
+	"context"
+	"currency-exchange/internals/helpers"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Options configures the process-wide logger.
+type Options struct {
+	Level     string            // debug, info, warn or error
+	Format    string            // console or json
+	Overrides map[string]string // component name to level, e.g. "cache": "warn"
+	Sampling  Sampling
+}
+
+var (
+	mu         sync.RWMutex
+	base       slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	level      slog.Level   = slog.LevelInfo
+	overrides               = map[string]slog.Level{}
+	components              = map[string]*slog.Logger{}
+)
+
+// ParseLevel reads one of debug, info, warn or error, in any case.
+func ParseLevel(value string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("%q is not a log level", value)
+	}
+	return l, nil
+}
+
+// Setup replaces the default logger with one writing to w in the given format. Messages from
+// the standard log package are routed through it at info level, so LOG_LEVEL=warn also hides
+// their output. It is only safe to call during startup.
+func Setup(w io.Writer, opts Options) error {
+	l, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	levels := make(map[string]slog.Level, len(opts.Overrides))
+	for component, value := range opts.Overrides {
+		componentLevel, err := ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("level for %s: %w", component, err)
+		}
+		levels[strings.ToLower(component)] = componentLevel
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	switch opts.Format {
+	case "", "console":
+		h = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		h = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("%q is not a log format", opts.Format)
+	}
+	if opts.Sampling.enabled() {
+		h = newSamplingHandler(h, opts.Sampling)
+	}
+
+	mu.Lock()
+	base, level, overrides = h, l, levels
+	components = map[string]*slog.Logger{}
+	mu.Unlock()
+
+	slog.SetDefault(slog.New(&levelHandler{Handler: h, level: l}))
+	return nil
+}
+
+// For returns the logger of a component such as "cache" or "schedular". Its records carry a
+// component attribute and are filtered by the component's level override, if it has one.
+func For(component string) *slog.Logger {
+	component = strings.ToLower(component)
+	mu.RLock()
+	logger, ok := components[component]
+	mu.RUnlock()
+	if ok {
+		return logger
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if logger, ok := components[component]; ok {
+		return logger
+	}
+	l, ok := overrides[component]
+	if !ok {
+		l = level
+	}
+	logger = slog.New(&levelHandler{Handler: base, level: l}).With("component", component)
+	components[component] = logger
+	return logger
+}
+
+// WithRequest adds the request_id carried by ctx to logger, so every line logged while serving
+// one request can be found together.
+func WithRequest(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if id := helpers.RequestID(ctx); id != "" {
+		return logger.With("request_id", id)
+	}
+	return logger
+}
+
+// levelHandler filters records below level before they reach the wrapped handler, which is
+// built to accept everything so each component can have its own threshold.
+type levelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h *levelHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
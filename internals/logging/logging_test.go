@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"currency-exchange/internals/helpers"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setup(t *testing.T, opts Options) *bytes.Buffer {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		_ = Setup(&bytes.Buffer{}, Options{Level: "info"})
+		slog.SetDefault(previous)
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, Setup(&buf, opts))
+	return &buf
+}
+
+func TestSetup_JSONWithComponentOverride(t *testing.T) {
+	buf := setup(t, Options{Level: "warn", Format: "json", Overrides: map[string]string{"Cache": "debug"}})
+
+	slog.Info("hidden")
+	For("cache").Debug("cache hit", "base", "USD")
+	For("schedular").Info("hidden too")
+	For("schedular").Error("refresh failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "cache hit", record["msg"])
+	assert.Equal(t, "cache", record["component"])
+	assert.Equal(t, "USD", record["base"])
+	assert.Contains(t, lines[1], `"component":"schedular"`)
+}
+
+func TestSetup_RoutesStandardLog(t *testing.T) {
+	buf := setup(t, Options{Level: "info", Format: "console"})
+	log.Printf("Server starting on port %s", "8080")
+	assert.Contains(t, buf.String(), `level=INFO msg="Server starting on port 8080"`)
+
+	buf = setup(t, Options{Level: "error"})
+	log.Printf("Server starting on port %s", "8080")
+	assert.Empty(t, buf.String())
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	assert.ErrorContains(t, Setup(&bytes.Buffer{}, Options{Level: "loud"}), `"loud" is not a log level`)
+	assert.ErrorContains(t, Setup(&bytes.Buffer{}, Options{Level: "info", Format: "xml"}), `"xml" is not a log format`)
+	assert.ErrorContains(t, Setup(&bytes.Buffer{}, Options{Level: "info", Overrides: map[string]string{"cache": "quiet"}}), "level for cache")
+}
+
+func TestWithRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	WithRequest(helpers.WithRequestID(context.Background(), "req-1"), logger).Info("with id")
+	WithRequest(context.Background(), logger).Info("without id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"request_id":"req-1"`)
+	assert.NotContains(t, lines[1], "request_id")
+}
+
+func TestSetup_SamplesRepeatedDebugLines(t *testing.T) {
+	buf := setup(t, Options{Level: "debug", Sampling: Sampling{Initial: 2, Thereafter: 3}})
+
+	for range 8 {
+		slog.Debug("Cache hit")
+	}
+	slog.Debug("Cached latest rates")
+	for range 3 {
+		slog.Info("Refresh finished")
+	}
+
+	out := buf.String()
+	// The first 2 hits, then every 3rd of the remaining 6.
+	assert.Equal(t, 4, strings.Count(out, "Cache hit"))
+	assert.Equal(t, 1, strings.Count(out, "Cached latest rates"))
+	assert.Equal(t, 3, strings.Count(out, "Refresh finished"))
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "service.log")
+	file := NewFile(FileOptions{Path: path, MaxSizeMB: 1})
+	_, err := file.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	written, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(written))
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampling thins out debug lines that repeat within a second, such as the per-date cache hits
+// of a historical backfill. Info and above are never sampled.
+type Sampling struct {
+	Initial    int // lines with the same message kept each second; 0 turns sampling off
+	Thereafter int // after that, every Thereafter-th line is kept; 0 drops the rest
+}
+
+func (s Sampling) enabled() bool {
+	return s.Initial > 0
+}
+
+// samplingHandler applies Sampling before records reach the wrapped handler. Copies made by
+// WithAttrs and WithGroup share the counters, so a component logger with extra attributes
+// still counts towards the same message.
+type samplingHandler struct {
+	slog.Handler
+	sampling Sampling
+	counts   *sampleCounts
+}
+
+type sampleCounts struct {
+	mu     sync.Mutex
+	second int64
+	seen   map[string]int
+}
+
+func newSamplingHandler(h slog.Handler, sampling Sampling) *samplingHandler {
+	return &samplingHandler{Handler: h, sampling: sampling, counts: &sampleCounts{seen: map[string]int{}}}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelInfo || h.counts.keep(r.Message, r.Time, h.sampling) {
+		return h.Handler.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampling: h.sampling, counts: h.counts}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampling: h.sampling, counts: h.counts}
+}
+
+func (c *sampleCounts) keep(message string, at time.Time, sampling Sampling) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if second := at.Unix(); second != c.second {
+		c.second = second
+		clear(c.seen)
+	}
+	c.seen[message]++
+	n := c.seen[message] - sampling.Initial
+	if n <= 0 {
+		return true
+	}
+	return sampling.Thereafter > 0 && n%sampling.Thereafter == 0
+}
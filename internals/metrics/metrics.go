@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric the service exports on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route, method and status code.",
+	}, []string{"endpoint", "method", "status"})
+
+	httpDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint", "method"})
+
+	cacheLookups = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Cache reads by tier, kind of rates, base and outcome (hit, miss, error or unavailable).",
+	}, []string{"tier", "kind", "base", "outcome"})
+
+	providerRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_requests_total",
+		Help: "Calls to rate providers by provider, operation and outcome (success or error).",
+	}, []string{"provider", "operation", "outcome"})
+
+	rateLimited = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Requests rejected by the inbound rate limits, by the limit that was hit (global, ip or key).",
+	}, []string{"limit"})
+
+	authFailures = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failures_total",
+		Help: "Requests with credentials that failed to authenticate (rejected), or were turned away while their source was locked out (blocked).",
+	}, []string{"outcome"})
+
+	streamConnections = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_connections",
+		Help: "Open rate streams, by transport.",
+	}, []string{"transport"})
+
+	providerDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "provider_request_duration_seconds",
+		Help:    "Time taken by rate provider calls, retries included.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider", "operation"})
+)
+
+// Handler serves the registry in the Prometheus text format, or OpenMetrics when the scraper
+// asks for it, which is needed for exemplars to show up.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// ObserveHTTP records one served request.
+func ObserveHTTP(ctx context.Context, endpoint, method string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(endpoint, method, strconv.Itoa(status)).Inc()
+	observe(ctx, httpDuration.WithLabelValues(endpoint, method), duration.Seconds())
+}
+
+// ObserveCacheLookup records one cache read. tier names the store that answered, e.g. "redis".
+func ObserveCacheLookup(tier, kind string, base domain.Currency, outcome string) {
+	cacheLookups.WithLabelValues(tier, kind, string(base), outcome).Inc()
+}
+
+// ObserveRateLimited records one request rejected by the limit named scope.
+func ObserveRateLimited(scope string) {
+	rateLimited.WithLabelValues(scope).Inc()
+}
+
+// ObserveAuthFailure records one request rejected for its credentials, with outcome "rejected"
+// or "blocked".
+func ObserveAuthFailure(outcome string) {
+	authFailures.WithLabelValues(outcome).Inc()
+}
+
+// ObserveStreamOpened records a rate stream connecting over transport, e.g. "websocket".
+func ObserveStreamOpened(transport string) {
+	streamConnections.WithLabelValues(transport).Inc()
+}
+
+// ObserveStreamClosed records a rate stream over transport ending.
+func ObserveStreamClosed(transport string) {
+	streamConnections.WithLabelValues(transport).Dec()
+}
+
+// ObserveProviderCall records one call to a rate provider.
+func ObserveProviderCall(ctx context.Context, provider, operation string, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	providerRequests.WithLabelValues(provider, operation, outcome).Inc()
+	observe(ctx, providerDuration.WithLabelValues(provider, operation), duration.Seconds())
+}
+
+// observe attaches the trace ID of ctx, and the refresh run ID when a background refresh made
+// the call, as an exemplar, so a slow bucket on a dashboard links to a request or run that
+// landed in it.
+func observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	labels := prometheus.Labels{}
+	if traceID := helpers.TraceID(ctx); traceID != "" {
+		labels["trace_id"] = traceID
+	}
+	if runID := helpers.RunID(ctx); runID != "" {
+		labels["run_id"] = runID
+	}
+	if exemplars, ok := observer.(prometheus.ExemplarObserver); ok && len(labels) > 0 {
+		exemplars.ObserveWithExemplar(value, labels)
+		return
+	}
+	observer.Observe(value)
+}
+
+var rateAgeDesc = prometheus.NewDesc("rate_age_seconds", "Seconds since the cached latest rates of a base were refreshed.", []string{"base"}, nil)
+
+// rateAgeCollector reads the age of the cached rates at scrape time, so every replica reports
+// the same freshness whichever of them did the refresh.
+type rateAgeCollector struct {
+	ages func() map[domain.Currency]time.Duration
+}
+
+func (c rateAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rateAgeDesc
+}
+
+func (c rateAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	for base, age := range c.ages() {
+		ch <- prometheus.MustNewConstMetric(rateAgeDesc, prometheus.GaugeValue, age.Seconds(), string(base))
+	}
+}
+
+// RegisterRateAge exports rate_age_seconds per base from ages, which is called on every scrape.
+func RegisterRateAge(ages func() map[domain.Currency]time.Duration) error {
+	return Registry.Register(rateAgeCollector{ages: ages})
+}
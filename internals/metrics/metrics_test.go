@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/helpers"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveCacheLookup(t *testing.T) {
+	before := testutil.ToFloat64(cacheLookups.WithLabelValues("redis", "latest", "USD", "hit"))
+	ObserveCacheLookup("redis", "latest", "USD", "hit")
+	assert.Equal(t, before+1, testutil.ToFloat64(cacheLookups.WithLabelValues("redis", "latest", "USD", "hit")))
+}
+
+func TestObserveProviderCall(t *testing.T) {
+	ObserveProviderCall(context.Background(), "test-provider", "latest", time.Second, nil)
+	ObserveProviderCall(context.Background(), "test-provider", "latest", time.Second, errors.New("boom"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(providerRequests.WithLabelValues("test-provider", "latest", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(providerRequests.WithLabelValues("test-provider", "latest", "error")))
+}
+
+func TestHandler_ExemplarsAndRateAge(t *testing.T) {
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := helpers.WithTraceParent(context.Background(), "00-"+traceID+"-00f067aa0ba902b7-01")
+	ObserveHTTP(ctx, "/v1/latest", "GET", 200, 20*time.Millisecond)
+	ObserveProviderCall(helpers.WithRunID(context.Background(), "run-1"), "exemplar-provider", "latest", 1500*time.Millisecond, nil)
+	assert.NoError(t, RegisterRateAge(func() map[domain.Currency]time.Duration {
+		return map[domain.Currency]time.Duration{"EUR": 90 * time.Second}
+	}))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	text := string(body)
+	assert.Contains(t, text, `http_requests_total{endpoint="/v1/latest",method="GET",status="200"} 1`)
+	assert.Contains(t, text, `rate_age_seconds{base="EUR"} 90`)
+	assert.True(t, strings.Contains(text, `# {trace_id="`+traceID+`"} 0.02`), text)
+	assert.True(t, strings.Contains(text, `# {run_id="run-1"} 1.5`), text)
+}
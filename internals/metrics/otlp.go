@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// otlpShutdownTimeout bounds the final push made when the pusher stops.
+const otlpShutdownTimeout = 10 * time.Second
+
+// OTLPOptions configures pushing metrics to an OpenTelemetry collector.
+type OTLPOptions struct {
+	Endpoint    string // collector URL; without a path, metrics go to /v1/metrics
+	Interval    time.Duration
+	ServiceName string
+	Environment string
+	Logger      *slog.Logger
+}
+
+// OTLPPusher pushes everything in Registry to an OpenTelemetry collector over OTLP/HTTP, for
+// clusters where Prometheus can't scrape /metrics. It works alongside scraping, not instead
+// of it. Headers, e.g. for authentication, are taken from the standard
+// OTEL_EXPORTER_OTLP_HEADERS variable.
+type OTLPPusher struct {
+	provider *sdkmetric.MeterProvider
+	logger   *slog.Logger
+}
+
+// NewOTLPPusher starts pushing every opts.Interval.
+func NewOTLPPusher(ctx context.Context, opts OTLPOptions) (*OTLPPusher, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("%q is not a valid OTLP endpoint", opts.Endpoint)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/metrics"
+	}
+
+	exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up OTLP exporter: %w", err)
+	}
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(opts.Interval),
+		sdkmetric.WithProducer(promBridge.NewMetricProducer(promBridge.WithGatherer(Registry))),
+	)
+	attrs := []attribute.KeyValue{attribute.String("service.name", opts.ServiceName)}
+	if opts.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment", opts.Environment))
+	}
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewSchemaless(attrs...)),
+	)
+
+	// Pushes happen in the background, so this is the only place a failing collector shows up.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		opts.Logger.Warn("Failed to push metrics to the OTLP collector", "error", err)
+	}))
+	return &OTLPPusher{provider: provider, logger: opts.Logger}, nil
+}
+
+// Start waits until ctx is cancelled, then pushes once more so the last interval isn't lost,
+// and stops.
+func (p *OTLPPusher) Start(ctx context.Context) {
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+	defer cancel()
+	if err := p.provider.Shutdown(shutdownCtx); err != nil {
+		p.logger.Warn("Failed to push the final metrics to the OTLP collector", "error", err)
+	}
+}
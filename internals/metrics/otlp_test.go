@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func TestOTLPPusher_PushesRegistryOnStop(t *testing.T) {
+	requests := make(chan *collectormetrics.ExportMetricsServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		var req collectormetrics.ExportMetricsServiceRequest
+		assert.NoError(t, proto.Unmarshal(body, &req))
+		requests <- &req
+	}))
+	defer collector.Close()
+
+	ObserveHTTP(context.Background(), "/v1/otlp", "GET", 200, 10*time.Millisecond)
+	pusher, err := NewOTLPPusher(context.Background(), OTLPOptions{
+		Endpoint:    collector.URL,
+		Interval:    time.Hour,
+		ServiceName: "currency-exchange",
+		Environment: "test",
+		Logger:      discardLogger,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pusher.Start(ctx)
+
+	req := <-requests
+	resource := req.ResourceMetrics[0].Resource
+	assert.Contains(t, resource.String(), "currency-exchange")
+	names := make(map[string]bool)
+	for _, scope := range req.ResourceMetrics[0].ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			names[metric.Name] = true
+		}
+	}
+	assert.True(t, names["http_requests_total"], names)
+	assert.True(t, names["http_request_duration_seconds"], names)
+}
+
+func TestNewOTLPPusher_InvalidEndpoint(t *testing.T) {
+	_, err := NewOTLPPusher(context.Background(), OTLPOptions{Endpoint: "collector:4318", Interval: time.Minute, Logger: discardLogger})
+	assert.Error(t, err)
+}
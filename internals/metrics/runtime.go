@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	redisPoolConnsDesc    = prometheus.NewDesc("redis_pool_connections", "Connections in the Redis client pool, by state (idle or in_use).", []string{"state"}, nil)
+	redisPoolSizeDesc     = prometheus.NewDesc("redis_pool_size", "Maximum number of connections in the Redis client pool.", nil, nil)
+	redisPoolTimeoutsDesc = prometheus.NewDesc("redis_pool_timeouts_total", "Times a caller gave up waiting for a free Redis connection.", nil, nil)
+)
+
+// redisPoolCollector reads the pool statistics of the Redis client at scrape time.
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+func (c redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisPoolConnsDesc
+	ch <- redisPoolSizeDesc
+	ch <- redisPoolTimeoutsDesc
+}
+
+func (c redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisPoolConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns), "idle")
+	ch <- prometheus.MustNewConstMetric(redisPoolConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns-stats.IdleConns), "in_use")
+	ch <- prometheus.MustNewConstMetric(redisPoolSizeDesc, prometheus.GaugeValue, float64(c.client.Options().PoolSize))
+	ch <- prometheus.MustNewConstMetric(redisPoolTimeoutsDesc, prometheus.CounterValue, float64(stats.Timeouts))
+}
+
+// RegisterRuntime exports the Go runtime metrics (goroutines, heap, GC pauses), the process
+// metrics, the Redis connection pool of client and the number of cache writes still running
+// in the background, as reported by pendingWrites.
+func RegisterRuntime(client *redis.Client, pendingWrites func() int) error {
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		redisPoolCollector{client: client},
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_pending_writes",
+			Help: "Cache writes started by requests that have not finished yet.",
+		}, func() float64 { return float64(pendingWrites()) }),
+	} {
+		if err := Registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuntimeWatchdog warns when background cache writes pile up or the Redis pool runs out of
+// connections, which usually means Redis has become slow. Each condition is logged once when
+// it starts and once when it clears, rather than on every check.
+type RuntimeWatchdog struct {
+	client           *redis.Client
+	pendingWrites    func() int
+	maxPendingWrites int
+	maxPoolUsage     float64
+	interval         time.Duration
+	logger           *slog.Logger
+	writesBacklogged bool
+	poolSaturated    bool
+	poolTimeouts     uint32
+}
+
+// NewRuntimeWatchdog builds a watchdog that warns once more than maxPendingWrites cache writes
+// are running, or once at least maxPoolUsage (0 to 1) of the Redis pool is in use or callers
+// start timing out waiting for a connection.
+func NewRuntimeWatchdog(client *redis.Client, pendingWrites func() int, maxPendingWrites int, maxPoolUsage float64, interval time.Duration, logger *slog.Logger) *RuntimeWatchdog {
+	return &RuntimeWatchdog{
+		client:           client,
+		pendingWrites:    pendingWrites,
+		maxPendingWrites: maxPendingWrites,
+		maxPoolUsage:     maxPoolUsage,
+		interval:         interval,
+		logger:           logger,
+		poolTimeouts:     client.PoolStats().Timeouts,
+	}
+}
+
+// Start runs Check every interval until ctx is cancelled.
+func (w *RuntimeWatchdog) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check compares the current values with the thresholds and logs any change of state.
+func (w *RuntimeWatchdog) Check() {
+	pending := w.pendingWrites()
+	backlogged := pending > w.maxPendingWrites
+	switch {
+	case backlogged && !w.writesBacklogged:
+		w.logger.Warn("Background cache writes are piling up", "pending", pending, "threshold", w.maxPendingWrites)
+	case !backlogged && w.writesBacklogged:
+		w.logger.Info("Background cache writes caught up", "pending", pending)
+	}
+	w.writesBacklogged = backlogged
+
+	stats := w.client.PoolStats()
+	size := w.client.Options().PoolSize
+	inUse := int(stats.TotalConns - stats.IdleConns)
+	newTimeouts := stats.Timeouts > w.poolTimeouts
+	w.poolTimeouts = stats.Timeouts
+
+	saturated := newTimeouts || (size > 0 && float64(inUse) >= w.maxPoolUsage*float64(size))
+	switch {
+	case saturated && !w.poolSaturated:
+		w.logger.Warn("Redis connection pool is saturated", "in_use", inUse, "pool_size", size, "timeouts", stats.Timeouts)
+	case !saturated && w.poolSaturated:
+		w.logger.Info("Redis connection pool has recovered", "in_use", inUse, "pool_size", size)
+	}
+	w.poolSaturated = saturated
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRuntime(t *testing.T) {
+	mini := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr(), PoolSize: 4})
+	assert.NoError(t, client.Ping(t.Context()).Err())
+
+	assert.NoError(t, RegisterRuntime(client, func() int { return 3 }))
+
+	resp := httptest.NewRecorder()
+	Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "go_goroutines ")
+	assert.Contains(t, string(body), "go_gc_duration_seconds")
+	assert.Contains(t, string(body), "go_memstats_heap_inuse_bytes ")
+	assert.Contains(t, string(body), `redis_pool_connections{state="idle"} 1`)
+	assert.Contains(t, string(body), "redis_pool_size 4")
+	assert.Contains(t, string(body), "cache_pending_writes 3")
+}
+
+func TestRuntimeWatchdog(t *testing.T) {
+	mini := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mini.Addr(), PoolSize: 2})
+	var buf bytes.Buffer
+	pending := 0
+	watchdog := NewRuntimeWatchdog(client, func() int { return pending }, 10, 0.9, time.Minute, slog.New(slog.NewTextHandler(&buf, nil)))
+
+	watchdog.Check()
+	assert.Empty(t, buf.String())
+
+	pending = 11
+	watchdog.Check()
+	watchdog.Check()
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("Background cache writes are piling up")))
+
+	pending = 2
+	watchdog.Check()
+	assert.Contains(t, buf.String(), "Background cache writes caught up")
+
+	// Hold both pool connections.
+	conns := []*redis.Conn{client.Conn(), client.Conn()}
+	for _, conn := range conns {
+		assert.NoError(t, conn.Ping(t.Context()).Err())
+	}
+	watchdog.Check()
+	assert.Contains(t, buf.String(), "Redis connection pool is saturated")
+
+	for _, conn := range conns {
+		assert.NoError(t, conn.Close())
+	}
+	watchdog.Check()
+	assert.Contains(t, buf.String(), "Redis connection pool has recovered")
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "context"
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass marks ctx so the repository skips cache and archive reads and goes straight
+// to the provider. Fresh results are still written back to both.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// CacheBypassed reports whether ctx was marked with WithCacheBypass.
+func CacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// Cache outcomes recorded for a request.
+const (
+	CacheHit    = "hit"
+	CacheMiss   = "miss"
+	CacheBypass = "bypass"
+)
+
+type cacheOutcomeKey struct{}
+
+type cacheOutcome struct {
+	mu    sync.Mutex
+	value string
+}
+
+// WithCacheOutcome makes ctx collect whether the repository answered from the cache, so the
+// access log can report it once the request is done.
+func WithCacheOutcome(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheOutcomeKey{}, &cacheOutcome{})
+}
+
+// CacheOutcome returns hit, miss or bypass for the lookups made with ctx, or "" when none were
+// made. A request that needed the provider for any lookup counts as a miss.
+func CacheOutcome(ctx context.Context) string {
+	outcome, ok := ctx.Value(cacheOutcomeKey{}).(*cacheOutcome)
+	if !ok {
+		return ""
+	}
+	outcome.mu.Lock()
+	defer outcome.mu.Unlock()
+	return outcome.value
+}
+
+func recordCacheOutcome(ctx context.Context, value string) {
+	outcome, ok := ctx.Value(cacheOutcomeKey{}).(*cacheOutcome)
+	if !ok {
+		return
+	}
+	outcome.mu.Lock()
+	defer outcome.mu.Unlock()
+	if outcome.value == "" || outcome.value == CacheHit {
+		outcome.value = value
+	}
+}
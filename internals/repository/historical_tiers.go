@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
+	"fmt"
+	"time"
+)
+
+// historicalTier is one link of the historical read path: the cache, then the archive, then
+// the provider. Each tier answers the days it has and leaves the rest to the next one.
+type historicalTier interface {
+	name() string
+	// read returns the rates from base to target on those of days the tier has, along with
+	// every rate of base on each day it had to fetch, for the tiers before it to keep.
+	read(ctx context.Context, days []time.Time, base, target domain.Currency) (rates map[time.Time]float64, fetched map[time.Time]map[domain.Currency]float64, err error)
+	// keep stores days a later tier fetched, in the background.
+	keep(base domain.Currency, fetched map[time.Time]map[domain.Currency]float64)
+}
+
+// historicalTiers returns the tiers historical reads go through. With a cache bypass, the
+// cache and archive answer nothing, so the rates come from the provider, and both still keep
+// what it fetched.
+func (r *cachedRateRepository) historicalTiers() []historicalTier {
+	tiers := []historicalTier{cacheTier{r}}
+	if r.archive != nil {
+		tiers = append(tiers, archiveTier{r})
+	}
+	return append(tiers, providerTier{r})
+}
+
+// cacheTier reads days from the cache.
+type cacheTier struct{ r *cachedRateRepository }
+
+func (cacheTier) name() string { return "cache" }
+
+func (t cacheTier) read(ctx context.Context, days []time.Time, base, target domain.Currency) (map[time.Time]float64, map[time.Time]map[domain.Currency]float64, error) {
+	if CacheBypassed(ctx) {
+		return nil, nil, nil
+	}
+	rates := make(map[time.Time]float64)
+	for _, date := range days {
+		cacheStart := time.Now()
+		cachedRates, found := t.r.cache.GetHistoricalRates(date, base)
+		recordCacheTime(ctx, cacheStart)
+		if !found {
+			continue
+		}
+		rate, ok := cachedRates[target]
+		if !ok {
+			logging.WithRequest(ctx, t.r.logger).Warn("Cached historical rates are missing the target", "base", base, "target", target, "date", date.Format("2006-01-02"))
+		}
+		rates[date] = rate
+	}
+	return rates, nil, nil
+}
+
+func (t cacheTier) keep(base domain.Currency, fetched map[time.Time]map[domain.Currency]float64) {
+	for date, rates := range fetched {
+		t.r.writeAsync(func() { t.r.cache.SetHistoricalRates(date, base, rates) })
+	}
+}
+
+// archiveTier reads days from the archive. They aren't copied into the cache: an imported day
+// may only have some targets, and a cached day is taken to have them all.
+type archiveTier struct{ r *cachedRateRepository }
+
+func (archiveTier) name() string { return "archive" }
+
+func (t archiveTier) read(ctx context.Context, days []time.Time, base, target domain.Currency) (map[time.Time]float64, map[time.Time]map[domain.Currency]float64, error) {
+	if CacheBypassed(ctx) {
+		return nil, nil, nil
+	}
+	archived, err := t.r.archive.Range(ctx, days[0], days[len(days)-1], base, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	rates := make(map[time.Time]float64, len(days))
+	for _, date := range days {
+		if rate, ok := archived[date]; ok {
+			rates[date] = rate
+		}
+	}
+	return rates, nil, nil
+}
+
+func (t archiveTier) keep(base domain.Currency, fetched map[time.Time]map[domain.Currency]float64) {
+	for date, rates := range fetched {
+		t.r.writeAsync(func() { t.r.archiveDay(date, base, rates) })
+	}
+}
+
+// providerTier asks the provider for the span from the first to the last missing day.
+type providerTier struct{ r *cachedRateRepository }
+
+func (providerTier) name() string { return "provider" }
+
+func (t providerTier) read(ctx context.Context, days []time.Time, base, target domain.Currency) (map[time.Time]float64, map[time.Time]map[domain.Currency]float64, error) {
+	allSupportedTargets := make([]domain.Currency, 0, len(domain.SupportedCurrencies))
+	for curr := range domain.SupportedCurrencies {
+		if curr != base {
+			allSupportedTargets = append(allSupportedTargets, curr)
+		}
+	}
+
+	providerStart := time.Now()
+	apiRates, err := t.r.apiClient.FetchHistoricalTimeSeriesRates(ctx, days[0], days[len(days)-1], base, allSupportedTargets)
+	recordProviderTime(ctx, providerStart)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch historical rates from API: %w", err)
+	}
+	rates := make(map[time.Time]float64)
+	fetched := make(map[time.Time]map[domain.Currency]float64, len(apiRates.Rates))
+	for date, currencyRateMap := range apiRates.Rates {
+		parsedDate, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			logging.WithRequest(ctx, t.r.logger).Warn("Skipping historical rates with an unparseable date", "base", base, "date", date, "error", err)
+			continue
+		}
+		dayRates := make(map[domain.Currency]float64, len(currencyRateMap))
+		for currency, rate := range currencyRateMap {
+			if currency == string(target) {
+				rates[parsedDate] = rate
+			}
+			dayRates[domain.Currency(currency)] = rate
+		}
+		fetched[parsedDate] = dayRates
+	}
+	return rates, fetched, nil
+}
+
+func (providerTier) keep(domain.Currency, map[time.Time]map[domain.Currency]float64) {}
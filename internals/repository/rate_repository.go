@@ -2,34 +2,80 @@ package repository
 
 import (
 	"context"
+	"currency-exchange/internals/adapter/archive"
 	"currency-exchange/internals/adapter/cache"
 	"currency-exchange/internals/adapter/exchangerateapi"
 	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrNoArchive is returned for archived rates when no archive is configured.
+var ErrNoArchive = errors.New("no rate archive configured")
+
 type RateRepository interface {
 	GetLatestRates(ctx context.Context, base domain.Currency, targets domain.Currency) (rates map[domain.Currency]float64, timestamp time.Time, err error)
 	GetHistoricalRates(ctx context.Context, startDate time.Time, endDate time.Time, base domain.Currency, targets domain.Currency) (map[time.Time]float64, error)
 }
 
+// StalenessReporter is implemented by repositories that know when the latest rates of a base
+// are stale because background refreshes for it keep failing.
+type StalenessReporter interface {
+	LatestRatesStale(base domain.Currency) bool
+}
+
+// ArchiveReader is implemented by repositories that can read rates from the permanent
+// archive, for dates older than the cache and provider cover.
+type ArchiveReader interface {
+	GetArchivedRates(ctx context.Context, startDate time.Time, endDate time.Time, base domain.Currency, target domain.Currency) (map[time.Time]float64, error)
+}
+
+// Flusher is implemented by repositories that write to the cache in the background. Flush
+// waits for those writes so they aren't lost on shutdown, and PendingWrites reports how many
+// are still running.
+type Flusher interface {
+	Flush(ctx context.Context) error
+	PendingWrites() int
+}
+
+// archiveWriteTimeout bounds each background write to the archive.
+const archiveWriteTimeout = 10 * time.Second
+
 type cachedRateRepository struct {
 	apiClient exchangerateapi.RateAPIClient
 	cache     cache.Cache
+	archive   archive.Store
+	logger    *slog.Logger
+	writes    sync.WaitGroup
+	pending   atomic.Int64
 }
 
-func NewCachedRateRepository(apiClient exchangerateapi.RateAPIClient, cache cache.Cache) RateRepository {
+func NewCachedRateRepository(apiClient exchangerateapi.RateAPIClient, cache cache.Cache, logger *slog.Logger) RateRepository {
+	return NewArchivedRateRepository(apiClient, cache, nil, logger)
+}
+
+// NewArchivedRateRepository is NewCachedRateRepository also writing every day of historical
+// rates fetched from the provider to store, which may be nil.
+func NewArchivedRateRepository(apiClient exchangerateapi.RateAPIClient, cache cache.Cache, store archive.Store, logger *slog.Logger) RateRepository {
 	return &cachedRateRepository{
 		apiClient: apiClient,
 		cache:     cache,
+		archive:   store,
+		logger:    logger,
 	}
 }
 
 func (r *cachedRateRepository) GetLatestRates(ctx context.Context, base domain.Currency, target domain.Currency) (map[domain.Currency]float64, time.Time, error) {
-	cachedRates, timestamp, found := r.cache.GetLatestRates(base)
-	if found {
+	if CacheBypassed(ctx) {
+		recordCacheOutcome(ctx, CacheBypass)
+		logging.WithRequest(ctx, r.logger).Info("Cache bypass requested, fetching latest rates from API", "base", base)
+	} else if cachedRates, timestamp, found := r.cachedLatestRates(ctx, base); found {
+		recordCacheOutcome(ctx, CacheHit)
 		result := make(map[domain.Currency]float64)
 		if rate, ok := cachedRates[target]; ok {
 			result[target] = rate
@@ -37,16 +83,22 @@ func (r *cachedRateRepository) GetLatestRates(ctx context.Context, base domain.C
 
 		result[base] = 1.0
 		return result, timestamp, nil
+	} else {
+		recordCacheOutcome(ctx, CacheMiss)
 	}
 
+	// Retired currencies have no latest rates to ask for.
+	now := time.Now()
 	allSupportedTargets := make([]domain.Currency, 0, len(domain.SupportedCurrencies))
 	for curr := range domain.SupportedCurrencies {
-		if curr != base { // API doesn't return base=base
+		if curr != base && curr.InUse(now) { // API doesn't return base=base
 			allSupportedTargets = append(allSupportedTargets, curr)
 		}
 	}
 
+	providerStart := time.Now()
 	apiRates, apiTimestamp, err := r.apiClient.FetchLatestRates(ctx, base, allSupportedTargets)
+	recordProviderTime(ctx, providerStart)
 	if err != nil {
 		return nil, time.Time{}, fmt.Errorf("failed to fetch latest rates from API: %w", err)
 	}
@@ -57,70 +109,122 @@ func (r *cachedRateRepository) GetLatestRates(ctx context.Context, base domain.C
 	}
 	fullRates[base] = 1.0 // Rate of base to itself is always 1
 
-	go r.cache.SetLatestRates(base, fullRates, apiTimestamp)
+	r.writeAsync(func() { r.cache.SetLatestRates(base, fullRates, apiTimestamp) })
 
 	result := make(map[domain.Currency]float64)
 	if rate, ok := fullRates[target]; ok {
 		result[target] = rate
 	} else {
-		log.Printf("Warning: API did not return expected rate for target %s (base %s)", target, base)
+		logging.WithRequest(ctx, r.logger).Warn("API did not return the expected rate", "base", base, "target", target)
 	}
 	result[base] = 1.0
 
 	return result, apiTimestamp, nil
 }
 
-// GetHistoricalRates retrieves historical rates
-func (r *cachedRateRepository) GetHistoricalRates(ctx context.Context, startDate time.Time, endDate time.Time, base domain.Currency, target domain.Currency) (map[time.Time]float64, error) {
-	resultantDateToRateMap := make(map[time.Time]float64)
-	allFound := true
-	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
-		cachedRates, found := r.cache.GetHistoricalRates(date, base)
-		if found {
-			rate, ok := cachedRates[target]
-			if !ok {
-				log.Printf("Did not recieive anything in cache map for target currency : %v", target)
-			}
-			resultantDateToRateMap[date] = rate
-		} else {
-			allFound = false
-			break
-		}
+func (r *cachedRateRepository) cachedLatestRates(ctx context.Context, base domain.Currency) (map[domain.Currency]float64, time.Time, bool) {
+	defer recordCacheTime(ctx, time.Now())
+	return r.cache.GetLatestRates(base)
+}
 
-	}
-	if allFound {
-		return resultantDateToRateMap, nil
-	}
+// writeAsync runs a cache write without holding up the response.
+func (r *cachedRateRepository) writeAsync(write func()) {
+	r.writes.Add(1)
+	r.pending.Add(1)
+	go func() {
+		defer r.writes.Done()
+		defer r.pending.Add(-1)
+		write()
+	}()
+}
 
-	allSupportedTargets := make([]domain.Currency, 0, len(domain.SupportedCurrencies))
-	for curr := range domain.SupportedCurrencies {
-		if curr != base {
-			allSupportedTargets = append(allSupportedTargets, curr)
-		}
+func (r *cachedRateRepository) PendingWrites() int {
+	return int(r.pending.Load())
+}
+
+// Flush waits until every background cache write has finished or ctx is done.
+func (r *cachedRateRepository) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cache writes still pending: %w", ctx.Err())
 	}
+}
 
-	apiRates, err := r.apiClient.FetchHistoricalTimeSeriesRates(ctx, startDate, endDate, base, allSupportedTargets)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch historical rates from API: %w", err)
+func (r *cachedRateRepository) LatestRatesStale(base domain.Currency) bool {
+	tracker, ok := r.cache.(cache.StalenessTracker)
+	return ok && tracker.LatestRatesStale(base)
+}
+
+// GetHistoricalRates retrieves historical rates, going through the cache, the archive and the
+// provider in turn for the days each tier before didn't have. Days fetched from the provider
+// are written back to the cache and the archive.
+func (r *cachedRateRepository) GetHistoricalRates(ctx context.Context, startDate time.Time, endDate time.Time, base domain.Currency, target domain.Currency) (map[time.Time]float64, error) {
+	var missing []time.Time
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		missing = append(missing, date)
 	}
-	cacheCurrencyMap := make(map[domain.Currency]float64)
-	rates := apiRates.Rates
-	for date, currencyRateMap := range rates {
-		parsedDate, err := time.Parse("2006-01-02", date)
+	resultantDateToRateMap := make(map[time.Time]float64, len(missing))
+	tiers := r.historicalTiers()
+	cacheMissed := false
+	for i, tier := range tiers {
+		if len(missing) == 0 {
+			break
+		}
+		cacheMissed = cacheMissed || i > 0
+		rates, fetched, err := tier.read(ctx, missing, base, target)
 		if err != nil {
-			log.Printf("An Error occurred while parsing the string date so not adding it to resultant map\n")
+			if i == len(tiers)-1 {
+				return nil, err
+			}
+			logging.WithRequest(ctx, r.logger).Warn("Historical rates read failed, trying the next tier", "tier", tier.name(), "base", base, "error", err)
 			continue
 		}
-		for currency, rate := range currencyRateMap {
-			if currency == string(target) {
-				resultantDateToRateMap[parsedDate] = rate
+		for _, earlier := range tiers[:i] {
+			earlier.keep(base, fetched)
+		}
+		remaining := missing[:0]
+		for _, date := range missing {
+			if rate, ok := rates[date]; ok {
+				resultantDateToRateMap[date] = rate
+			} else {
+				remaining = append(remaining, date)
 			}
-			cacheCurrencyMap[domain.Currency(currency)] = rate
 		}
+		missing = remaining
+	}
 
-		go r.cache.SetHistoricalRates(parsedDate, base, cacheCurrencyMap)
+	switch {
+	case CacheBypassed(ctx):
+		recordCacheOutcome(ctx, CacheBypass)
+	case !cacheMissed:
+		recordCacheOutcome(ctx, CacheHit)
+	default:
+		recordCacheOutcome(ctx, CacheMiss)
+	}
+	return resultantDateToRateMap, nil
+}
 
+// archiveDay writes a day fetched for a request to the archive. The request has been
+// answered by then, so the write gets its own deadline rather than the request's.
+func (r *cachedRateRepository) archiveDay(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), archiveWriteTimeout)
+	defer cancel()
+	if err := r.archive.SaveDay(ctx, date, base, rates); err != nil {
+		r.logger.Warn("Failed to archive historical rates", "base", base, "date", date.Format("2006-01-02"), "error", err)
 	}
+}
 
-	return resultantDateToRateMap, nil
+// GetArchivedRates reads rates from the archive only, returning ErrNoArchive without one.
+func (r *cachedRateRepository) GetArchivedRates(ctx context.Context, startDate time.Time, endDate time.Time, base domain.Currency, target domain.Currency) (map[time.Time]float64, error) {
+	if r.archive == nil {
+		return nil, ErrNoArchive
+	}
+	return r.archive.Range(ctx, startDate, endDate, base, target)
 }
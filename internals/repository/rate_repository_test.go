@@ -3,14 +3,20 @@ package repository
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
+	"currency-exchange/internals/adapter/archive"
 	"currency-exchange/internals/core/domain"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 // --- Mock Cache ---
 type mockCache struct {
 	latestRates     map[domain.Currency]float64
@@ -50,15 +56,19 @@ type mockAPIClient struct {
 	latestRatesResp    map[domain.Currency]float64
 	latestRatesTime    time.Time
 	latestRatesErr     error
+	latestDelay        time.Duration
 	histTimeSeriesResp *domain.HistoricalTimeSeriesRatesResponse
 	histTimeSeriesErr  error
+	histFetched        [][2]time.Time // start and end of each historical fetch
 }
 
 func (m *mockAPIClient) FetchLatestRates(ctx context.Context, base domain.Currency, targets []domain.Currency) (map[domain.Currency]float64, time.Time, error) {
+	time.Sleep(m.latestDelay)
 	return m.latestRatesResp, m.latestRatesTime, m.latestRatesErr
 }
 
 func (m *mockAPIClient) FetchHistoricalTimeSeriesRates(ctx context.Context, startDate, endDate time.Time, baseCurrency domain.Currency, targetCurrencies []domain.Currency) (*domain.HistoricalTimeSeriesRatesResponse, error) {
+	m.histFetched = append(m.histFetched, [2]time.Time{startDate, endDate})
 	return m.histTimeSeriesResp, m.histTimeSeriesErr
 }
 
@@ -68,7 +78,7 @@ func TestGetLatestRates_CacheHit(t *testing.T) {
 		latestTimestamp: time.Now(),
 		latestFound:     true,
 	}
-	repo := NewCachedRateRepository(nil, cache)
+	repo := NewCachedRateRepository(nil, cache, discardLogger)
 	rates, ts, err := repo.GetLatestRates(context.Background(), "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 82.5, rates["INR"])
@@ -83,7 +93,7 @@ func TestGetLatestRates_CacheMiss_APISuccess(t *testing.T) {
 		latestRatesResp: map[domain.Currency]float64{"INR": 82.5, "EUR": 0.9},
 		latestRatesTime: time.Now(),
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, ts, err := repo.GetLatestRates(context.Background(), "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 82.5, rates["INR"])
@@ -102,7 +112,7 @@ func TestGetLatestRates_CacheMiss_APINoTarget(t *testing.T) {
 		latestRatesResp: map[domain.Currency]float64{"EUR": 0.9},
 		latestRatesTime: time.Now(),
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, ts, err := repo.GetLatestRates(context.Background(), "USD", "INR")
 	assert.NoError(t, err)
 	assert.NotContains(t, rates, "INR")
@@ -115,7 +125,7 @@ func TestGetLatestRates_APIFails(t *testing.T) {
 	api := &mockAPIClient{
 		latestRatesErr: errors.New("api error"),
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, ts, err := repo.GetLatestRates(context.Background(), "USD", "INR")
 	assert.Error(t, err)
 	assert.Nil(t, rates)
@@ -128,7 +138,7 @@ func TestGetHistoricalRates_AllCacheHit(t *testing.T) {
 		histRates: map[domain.Currency]float64{"INR": 80.0},
 		histFound: true,
 	}
-	repo := NewCachedRateRepository(nil, cache)
+	repo := NewCachedRateRepository(nil, cache, discardLogger)
 	rates, err := repo.GetHistoricalRates(context.Background(), date, date, "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 80.0, rates[date])
@@ -149,7 +159,7 @@ func TestGetHistoricalRates_CacheMiss_APISuccess(t *testing.T) {
 			},
 		},
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, err := repo.GetHistoricalRates(context.Background(), date, date, "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 81.0, rates[date])
@@ -169,7 +179,7 @@ func TestGetHistoricalRates_CacheMiss_APIFails(t *testing.T) {
 	api := &mockAPIClient{
 		histTimeSeriesErr: errors.New("api error"),
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, err := repo.GetHistoricalRates(context.Background(), date, date, "USD", "INR")
 	assert.Error(t, err)
 	assert.Nil(t, rates)
@@ -188,8 +198,252 @@ func TestGetHistoricalRates_APIReturnsBadDate(t *testing.T) {
 			},
 		},
 	}
-	repo := NewCachedRateRepository(api, cache)
+	repo := NewCachedRateRepository(api, cache, discardLogger)
 	rates, err := repo.GetHistoricalRates(context.Background(), date, date, "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(rates))
 }
+
+func TestGetLatestRates_CacheBypass_SkipsCacheButWritesBack(t *testing.T) {
+	ch := make(chan struct{}, 1)
+	cache := &mockCache{
+		latestRates:     map[domain.Currency]float64{"INR": 1.0},
+		latestTimestamp: time.Now(),
+		latestFound:     true,
+		setLatestCalled: ch,
+	}
+	api := &mockAPIClient{
+		latestRatesResp: map[domain.Currency]float64{"INR": 82.5},
+		latestRatesTime: time.Now(),
+	}
+	repo := NewCachedRateRepository(api, cache, discardLogger)
+	rates, _, err := repo.GetLatestRates(WithCacheBypass(context.Background()), "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, 82.5, rates["INR"])
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Error("SetLatestRates was not called in time")
+	}
+}
+
+func TestGetHistoricalRates_CacheBypass_SkipsCache(t *testing.T) {
+	date := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	cache := &mockCache{
+		histRates: map[domain.Currency]float64{"INR": 1.0},
+		histFound: true,
+	}
+	api := &mockAPIClient{
+		histTimeSeriesResp: &domain.HistoricalTimeSeriesRatesResponse{
+			Rates: map[string]map[string]float64{
+				"2024-05-07": {"INR": 81.0},
+			},
+		},
+	}
+	repo := NewCachedRateRepository(api, cache, discardLogger)
+	rates, err := repo.GetHistoricalRates(WithCacheBypass(context.Background()), date, date, "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, 81.0, rates[date])
+}
+
+type staleMockCache struct {
+	mockCache
+	stale map[domain.Currency]bool
+}
+
+func (m *staleMockCache) MarkLatestRatesStale(base domain.Currency, stale bool) {
+	m.stale[base] = stale
+}
+
+func (m *staleMockCache) LatestRatesStale(base domain.Currency) bool {
+	return m.stale[base]
+}
+
+func TestLatestRatesStale(t *testing.T) {
+	staleCache := &staleMockCache{stale: map[domain.Currency]bool{"USD": true}}
+	repo := NewCachedRateRepository(&mockAPIClient{}, staleCache, discardLogger).(StalenessReporter)
+	assert.True(t, repo.LatestRatesStale("USD"))
+	assert.False(t, repo.LatestRatesStale("EUR"))
+
+	// Caches that don't track staleness never report it.
+	repo = NewCachedRateRepository(&mockAPIClient{}, &mockCache{}, discardLogger).(StalenessReporter)
+	assert.False(t, repo.LatestRatesStale("USD"))
+}
+
+func TestFlush_WaitsForCacheWrites(t *testing.T) {
+	blocked := make(chan struct{})
+	cache := &mockCache{setLatestCalled: blocked}
+	api := &mockAPIClient{latestRatesResp: map[domain.Currency]float64{"INR": 82.5}, latestRatesTime: time.Now()}
+	repo := NewCachedRateRepository(api, cache, discardLogger)
+
+	_, _, err := repo.GetLatestRates(context.Background(), "USD", "INR")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, repo.(Flusher).Flush(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 1, repo.(Flusher).PendingWrites())
+
+	<-blocked
+	assert.NoError(t, repo.(Flusher).Flush(context.Background()))
+	assert.Equal(t, 0, repo.(Flusher).PendingWrites())
+	assert.Equal(t, 82.5, cache.latestRates["INR"])
+}
+
+func TestCacheOutcome(t *testing.T) {
+	hit := &mockCache{latestRates: map[domain.Currency]float64{"INR": 82.5}, latestFound: true}
+	ctx := WithCacheOutcome(context.Background())
+	_, _, _ = NewCachedRateRepository(nil, hit, discardLogger).GetLatestRates(ctx, "USD", "INR")
+	assert.Equal(t, CacheHit, CacheOutcome(ctx))
+
+	// One lookup needing the provider makes the whole request a miss.
+	miss := NewCachedRateRepository(&mockAPIClient{latestRatesResp: map[domain.Currency]float64{"INR": 82.5}}, &mockCache{}, discardLogger)
+	_, _, _ = miss.GetLatestRates(ctx, "USD", "INR")
+	_, _, _ = NewCachedRateRepository(nil, hit, discardLogger).GetLatestRates(ctx, "USD", "INR")
+	assert.Equal(t, CacheMiss, CacheOutcome(ctx))
+
+	assert.Empty(t, CacheOutcome(context.Background()))
+}
+
+func TestRequestTiming(t *testing.T) {
+	api := &mockAPIClient{latestRatesResp: map[domain.Currency]float64{"INR": 82.5}, latestDelay: 20 * time.Millisecond}
+	repo := NewCachedRateRepository(api, &mockCache{}, discardLogger)
+	ctx := WithTiming(context.Background())
+	_, _, err := repo.GetLatestRates(ctx, "USD", "INR")
+	assert.NoError(t, err)
+
+	timing := RequestTiming(ctx)
+	assert.GreaterOrEqual(t, timing.Provider, 20*time.Millisecond)
+	assert.Less(t, timing.Cache, 20*time.Millisecond)
+	assert.Zero(t, RequestTiming(context.Background()))
+}
+
+// mockArchive records the days saved to it.
+type mockArchive struct {
+	mu    sync.Mutex
+	saved map[time.Time]map[domain.Currency]float64
+}
+
+func (m *mockArchive) SaveDay(ctx context.Context, day time.Time, base domain.Currency, rates map[domain.Currency]float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved[day] = rates
+	return nil
+}
+
+func (m *mockArchive) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	return map[time.Time]float64{start: 70.0}, nil
+}
+
+func (m *mockArchive) Export(ctx context.Context, filter archive.Filter, fn func(archive.Row) error) error {
+	return nil
+}
+
+func (m *mockArchive) Close() error { return nil }
+
+func TestGetHistoricalRates_ArchivesEveryDay(t *testing.T) {
+	first := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	api := &mockAPIClient{
+		histTimeSeriesResp: &domain.HistoricalTimeSeriesRatesResponse{
+			Rates: map[string]map[string]float64{
+				"2024-05-06": {"INR": 80.0, "EUR": 0.91},
+				"2024-05-07": {"INR": 81.0, "EUR": 0.9},
+			},
+		},
+	}
+	store := &mockArchive{saved: map[time.Time]map[domain.Currency]float64{}}
+	repo := NewArchivedRateRepository(api, &dayCache{days: map[time.Time]map[domain.Currency]float64{}}, store, discardLogger)
+
+	_, err := repo.GetHistoricalRates(context.Background(), first, second, "USD", "INR")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.(Flusher).Flush(context.Background()))
+
+	assert.Equal(t, map[time.Time]map[domain.Currency]float64{
+		first:  {"INR": 80.0, "EUR": 0.91},
+		second: {"INR": 81.0, "EUR": 0.9},
+	}, store.saved)
+
+	archived, err := repo.(ArchiveReader).GetArchivedRates(context.Background(), first, first, "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{first: 70.0}, archived)
+
+	_, err = NewCachedRateRepository(api, &mockCache{}, discardLogger).(ArchiveReader).GetArchivedRates(context.Background(), first, first, "USD", "INR")
+	assert.ErrorIs(t, err, ErrNoArchive)
+}
+
+// dayCache holds historical rates per day.
+type dayCache struct {
+	mockCache
+	mu   sync.Mutex
+	days map[time.Time]map[domain.Currency]float64
+}
+
+func (m *dayCache) SetHistoricalRates(date time.Time, base domain.Currency, rates map[domain.Currency]float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.days[date] = rates
+}
+
+func (m *dayCache) GetHistoricalRates(date time.Time, base domain.Currency) (map[domain.Currency]float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rates, ok := m.days[date]
+	return rates, ok
+}
+
+// rangeArchive serves Range from its rates, or fails with err.
+type rangeArchive struct {
+	mockArchive
+	rates map[time.Time]float64
+	err   error
+}
+
+func (m *rangeArchive) Range(ctx context.Context, start, end time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	return m.rates, m.err
+}
+
+func TestGetHistoricalRates_ReadsThroughTiers(t *testing.T) {
+	first := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	second, third := first.AddDate(0, 0, 1), first.AddDate(0, 0, 2)
+	cache := &dayCache{days: map[time.Time]map[domain.Currency]float64{first: {"INR": 80.0}}}
+	store := &rangeArchive{
+		mockArchive: mockArchive{saved: map[time.Time]map[domain.Currency]float64{}},
+		rates:       map[time.Time]float64{second: 80.5},
+	}
+	api := &mockAPIClient{
+		histTimeSeriesResp: &domain.HistoricalTimeSeriesRatesResponse{
+			Rates: map[string]map[string]float64{"2024-05-08": {"INR": 81.0, "EUR": 0.9}},
+		},
+	}
+	repo := NewArchivedRateRepository(api, cache, store, discardLogger)
+	ctx := WithCacheOutcome(context.Background())
+
+	rates, err := repo.GetHistoricalRates(ctx, first, third, "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{first: 80.0, second: 80.5, third: 81.0}, rates)
+	assert.Equal(t, CacheMiss, CacheOutcome(ctx))
+	// Only the day neither the cache nor the archive had is fetched.
+	assert.Equal(t, [][2]time.Time{{third, third}}, api.histFetched)
+
+	// It is written back to both; the archived day isn't copied into the cache.
+	assert.NoError(t, repo.(Flusher).Flush(context.Background()))
+	assert.Equal(t, map[time.Time]map[domain.Currency]float64{third: {"INR": 81.0, "EUR": 0.9}}, store.saved)
+	assert.Equal(t, map[time.Time]map[domain.Currency]float64{first: {"INR": 80.0}, third: {"INR": 81.0, "EUR": 0.9}}, cache.days)
+}
+
+func TestGetHistoricalRates_ArchiveFails(t *testing.T) {
+	date := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	store := &rangeArchive{mockArchive: mockArchive{saved: map[time.Time]map[domain.Currency]float64{}}, err: errors.New("connection refused")}
+	api := &mockAPIClient{
+		histTimeSeriesResp: &domain.HistoricalTimeSeriesRatesResponse{
+			Rates: map[string]map[string]float64{"2024-05-07": {"INR": 81.0}},
+		},
+	}
+	repo := NewArchivedRateRepository(api, &mockCache{}, store, discardLogger)
+
+	rates, err := repo.GetHistoricalRates(context.Background(), date, date, "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Time]float64{date: 81.0}, rates)
+	assert.NoError(t, repo.(Flusher).Flush(context.Background()))
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Timing is how long a request spent waiting on the cache and on rate providers.
+type Timing struct {
+	Cache    time.Duration
+	Provider time.Duration
+}
+
+type timingKey struct{}
+
+type requestTiming struct {
+	mu     sync.Mutex
+	timing Timing
+}
+
+// WithTiming makes ctx collect the time the repository spends in the cache and in provider
+// calls, so slow requests can be broken down once they are done.
+func WithTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, &requestTiming{})
+}
+
+// RequestTiming returns the time spent so far by lookups made with ctx. Both durations are 0
+// when ctx doesn't collect timings.
+func RequestTiming(ctx context.Context) Timing {
+	timing, ok := ctx.Value(timingKey{}).(*requestTiming)
+	if !ok {
+		return Timing{}
+	}
+	timing.mu.Lock()
+	defer timing.mu.Unlock()
+	return timing.timing
+}
+
+func recordCacheTime(ctx context.Context, start time.Time) {
+	recordTiming(ctx, func(t *Timing) { t.Cache += time.Since(start) })
+}
+
+func recordProviderTime(ctx context.Context, start time.Time) {
+	recordTiming(ctx, func(t *Timing) { t.Provider += time.Since(start) })
+}
+
+func recordTiming(ctx context.Context, add func(*Timing)) {
+	timing, ok := ctx.Value(timingKey{}).(*requestTiming)
+	if !ok {
+		return
+	}
+	timing.mu.Lock()
+	defer timing.mu.Unlock()
+	add(&timing.timing)
+}
@@ -3,13 +3,15 @@ package service
 import (
 	"context"
 	"currency-exchange/internals/core/domain"
+	"currency-exchange/internals/logging"
 	"currency-exchange/internals/repository"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -17,6 +19,8 @@ var (
 	ErrRateNotFound         = errors.New("exchange rate not found")
 )
 
+var errOlderThanLimit = fiber.NewError(fiber.StatusBadRequest, "requested date is older than 90 days")
+
 // RateService defines the business logic for exchange rates.
 type RateService interface {
 	GetLatestRate(ctx context.Context, base, target domain.Currency) (float64, time.Time, error)
@@ -31,18 +35,26 @@ type RateService interface {
 type rateServiceImpl struct {
 	repo             repository.RateRepository
 	historyDaysLimit int
+	logger           *slog.Logger
 }
 
-func NewRateService(repo repository.RateRepository, historyDaysLimit int) RateService {
+func NewRateService(repo repository.RateRepository, historyDaysLimit int, logger *slog.Logger) RateService {
 	return &rateServiceImpl{
 		repo:             repo,
 		historyDaysLimit: historyDaysLimit,
+		logger:           logger,
 	}
 }
 
+// GetSupportedCurrencies lists the supported currencies still in use, the ones the
+// schedulers keep rates of. Retired ones are only supported for their history.
 func (s *rateServiceImpl) GetSupportedCurrencies() []string {
+	now := time.Now()
 	keys := make([]string, 0, len(domain.SupportedCurrencies))
 	for k := range domain.SupportedCurrencies {
+		if !k.InUse(now) {
+			continue
+		}
 		keys = append(keys, string(k))
 	}
 	return keys
@@ -56,15 +68,19 @@ func (s *rateServiceImpl) ValidateCurrencies(currency domain.Currency) error {
 	return nil
 }
 
+// checkInUse checks that base and target were both in use on every day from start to end,
+// returning a *domain.CurrencyNotInUseError otherwise.
+func checkInUse(start, end time.Time, base, target domain.Currency) error {
+	if err := base.CheckInUse(start, end); err != nil {
+		return err
+	}
+	return target.CheckInUse(start, end)
+}
+
 func (s *rateServiceImpl) validateDate(dateStr string) (time.Time, error) {
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid date format please format the date in YYYY-MM-DD")
-	}
-
-	oldestAllowedDate := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -s.historyDaysLimit)
-	if date.Before(oldestAllowedDate) {
-		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "requested date is older than 90 days")
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid date format please format the date in yyyy-mm-dd")
 	}
 
 	if date.After(time.Now().UTC().Truncate(24 * time.Hour)) {
@@ -74,7 +90,17 @@ func (s *rateServiceImpl) validateDate(dateStr string) (time.Time, error) {
 	return date, nil
 }
 
+// oldestLiveDate is the oldest date the cache and provider are asked for; older ones can only
+// be served from the archive.
+func (s *rateServiceImpl) oldestLiveDate() time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -s.historyDaysLimit)
+}
+
 func (s *rateServiceImpl) GetLatestRate(ctx context.Context, base, target domain.Currency) (float64, time.Time, error) {
+	now := time.Now()
+	if err := checkInUse(now, now, base, target); err != nil {
+		return 0, time.Time{}, err
+	}
 
 	if base == target {
 		return 1.0, time.Now().UTC(), nil // Rate to self is always 1
@@ -87,7 +113,7 @@ func (s *rateServiceImpl) GetLatestRate(ctx context.Context, base, target domain
 
 	rate, ok := rates[target]
 	if !ok {
-		log.Printf("Rate not found in repository result for %s -> %s", base, target)
+		logging.WithRequest(ctx, s.logger).Warn("Rate not found in repository result", "base", base, "target", target)
 		return 0, time.Time{}, ErrRateNotFound
 	}
 
@@ -96,32 +122,37 @@ func (s *rateServiceImpl) GetLatestRate(ctx context.Context, base, target domain
 
 func (s *rateServiceImpl) Convert(ctx context.Context, req domain.ConversionRequest) (*domain.ConversionResult, error) {
 	var err error
-	if req.From == req.To {
+	from := req.Amount.Currency
+	if from == req.To {
 		return nil, fiber.NewError(fiber.StatusBadRequest, "from and to currencies cannot be the same for conversion")
 	}
 	var rate float64
+	var timestamp int64
 	if req.Date == nil {
-		rate, _, err = s.GetLatestRate(ctx, req.From, req.To)
+		var published time.Time
+		rate, published, err = s.GetLatestRate(ctx, from, req.To)
+		timestamp = published.Unix()
 	} else {
-		rate, err = s.GetHistoricalRate(ctx, *req.Date, req.From, req.To)
+		rate, err = s.GetHistoricalRate(ctx, req.Date.ToTime(), from, req.To)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("could not get rate for conversion: %w", err)
 	}
 
-	convertedAmount := req.Amount * rate
-
 	return &domain.ConversionResult{
-		From:            req.From,
-		To:              req.To,
-		OriginalAmount:  req.Amount,
-		ConvertedAmount: convertedAmount,
-		Rate:            rate,
-		Date:            req.Date,
+		Amount:    req.Amount,
+		Converted: req.Amount.Convert(req.To, decimal.NewFromFloat(rate)),
+		Rate:      rate,
+		Date:      req.Date,
+		Timestamp: timestamp,
+		Stale:     req.Date == nil && s.latestRatesStale(from),
 	}, nil
 }
 
 func (s *rateServiceImpl) GetHistoricalRate(ctx context.Context, onDate time.Time, base, target domain.Currency) (float64, error) {
+	if err := checkInUse(onDate, onDate, base, target); err != nil {
+		return 0, err
+	}
 
 	if base == target {
 		return 1.0, nil // Rate to self is always 1
@@ -134,7 +165,7 @@ func (s *rateServiceImpl) GetHistoricalRate(ctx context.Context, onDate time.Tim
 
 	rate, ok := currencyRates[onDate]
 	if !ok {
-		log.Printf("Historical rate not found in repository result for %s -> %s on %s", base, target, onDate)
+		logging.WithRequest(ctx, s.logger).Warn("Historical rate not found in repository result", "base", base, "target", target, "date", onDate.Format("2006-01-02"))
 		return 0, ErrRateNotFound
 	}
 
@@ -142,6 +173,10 @@ func (s *rateServiceImpl) GetHistoricalRate(ctx context.Context, onDate time.Tim
 }
 
 func (s *rateServiceImpl) GetLatestRates(ctx context.Context, base domain.Currency, target domain.Currency) (*domain.LatestRates, error) {
+	now := time.Now()
+	if err := checkInUse(now, now, base, target); err != nil {
+		return nil, err
+	}
 
 	rates, timestamp, err := s.repo.GetLatestRates(ctx, base, target)
 	if err != nil {
@@ -154,9 +189,16 @@ func (s *rateServiceImpl) GetLatestRates(ctx context.Context, base domain.Curren
 		Base:      base,
 		Rates:     rates,
 		Timestamp: timestamp.Unix(),
+		Stale:     s.latestRatesStale(base),
 	}, nil
 }
 
+// latestRatesStale reports whether the repository has flagged the latest rates of base as stale.
+func (s *rateServiceImpl) latestRatesStale(base domain.Currency) bool {
+	reporter, ok := s.repo.(repository.StalenessReporter)
+	return ok && reporter.LatestRatesStale(base)
+}
+
 func (s *rateServiceImpl) GetHistoricalRates(ctx context.Context, startDate string, endDate string, base domain.Currency, target domain.Currency) (*domain.HistoricalRates, error) {
 	convStartDate, err := s.validateDate(startDate)
 	if err != nil {
@@ -168,15 +210,59 @@ func (s *rateServiceImpl) GetHistoricalRates(ctx context.Context, startDate stri
 		return nil, err
 	}
 
-	rates, err := s.repo.GetHistoricalRates(ctx, convStartDate, convEndDate, base, target)
+	if err := checkInUse(convStartDate, convEndDate, base, target); err != nil {
+		return nil, err
+	}
+
+	rates, err := s.historicalRates(ctx, convStartDate, convEndDate, base, target)
 	if err != nil {
 		return nil, err
 	}
 
+	dated := make(map[domain.CustomDate]float64, len(rates))
+	for date, rate := range rates {
+		dated[domain.DateOf(date)] = rate
+	}
+
 	return &domain.HistoricalRates{
 		Base:   base,
-		Rates:  rates,
+		Rates:  dated,
 		Amount: 1.0,
 		Target: target,
 	}, nil
 }
+
+// historicalRates reads the part of the range older than the history limit from the
+// archive, and the rest through the repository.
+func (s *rateServiceImpl) historicalRates(ctx context.Context, startDate, endDate time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	oldestLive := s.oldestLiveDate()
+	if !startDate.Before(oldestLive) {
+		return s.repo.GetHistoricalRates(ctx, startDate, endDate, base, target)
+	}
+	reader, ok := s.repo.(repository.ArchiveReader)
+	if !ok {
+		return nil, errOlderThanLimit
+	}
+	archivedEnd := endDate
+	if !archivedEnd.Before(oldestLive) {
+		archivedEnd = oldestLive.AddDate(0, 0, -1)
+	}
+	rates, err := reader.GetArchivedRates(ctx, startDate, archivedEnd, base, target)
+	if errors.Is(err, repository.ErrNoArchive) {
+		return nil, errOlderThanLimit
+	}
+	if err != nil {
+		return nil, err
+	}
+	if endDate.Before(oldestLive) {
+		return rates, nil
+	}
+	live, err := s.repo.GetHistoricalRates(ctx, oldestLive, endDate, base, target)
+	if err != nil {
+		return nil, err
+	}
+	for date, rate := range live {
+		rates[date] = rate
+	}
+	return rates, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"currency-exchange/internals/core/domain"
 	"errors"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -11,6 +12,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// discardLogger keeps test output free of the log lines the code under test writes.
+var discardLogger = slog.New(slog.DiscardHandler)
+
 // --- Mock Repository ---
 
 type MockRateRepository struct {
@@ -28,42 +32,84 @@ func (m *MockRateRepository) GetHistoricalRates(ctx context.Context, startDate,
 	return m.HistoricalRatesResp, m.HistoricalRatesErr
 }
 
-func ptrTime(t time.Time) *time.Time { return &t }
+// staleRateRepository flags every base's latest rates as stale.
+type staleRateRepository struct {
+	MockRateRepository
+}
+
+func (m *staleRateRepository) LatestRatesStale(base domain.Currency) bool {
+	return true
+}
+
+// archivedRateRepository serves dates before the limit from an archive, and records the
+// ranges asked of the live path.
+type archivedRateRepository struct {
+	MockRateRepository
+	archived   map[time.Time]float64
+	liveRanges [][2]time.Time
+}
+
+func (m *archivedRateRepository) GetHistoricalRates(ctx context.Context, startDate, endDate time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	m.liveRanges = append(m.liveRanges, [2]time.Time{startDate, endDate})
+	return m.MockRateRepository.GetHistoricalRates(ctx, startDate, endDate, base, target)
+}
+
+func (m *archivedRateRepository) GetArchivedRates(ctx context.Context, startDate, endDate time.Time, base, target domain.Currency) (map[time.Time]float64, error) {
+	rates := make(map[time.Time]float64)
+	for date, rate := range m.archived {
+		if !date.Before(startDate) && !date.After(endDate) {
+			rates[date] = rate
+		}
+	}
+	return rates, nil
+}
+
+func ptrDate(t time.Time) *domain.CustomDate {
+	d := domain.DateOf(t)
+	return &d
+}
 
 // --- Tests ---
 
 func TestGetSupportedCurrencies(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	currencies := svc.GetSupportedCurrencies()
 	assert.Contains(t, currencies, "USD")
 	assert.Contains(t, currencies, "INR")
 	assert.Len(t, currencies, 5)
 }
 
+func TestGetSupportedCurrencies_LeavesOutRetired(t *testing.T) {
+	domain.EnableCurrencies("HRK")
+	t.Cleanup(func() { delete(domain.SupportedCurrencies, "HRK") })
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
+	assert.NotContains(t, svc.GetSupportedCurrencies(), "HRK")
+}
+
 func TestValidateCurrencies_Supported(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	err := svc.ValidateCurrencies("USD")
 	assert.NoError(t, err)
 }
 
 func TestValidateCurrencies_Unsupported(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	err := svc.ValidateCurrencies("FOO")
 	assert.ErrorIs(t, err, ErrCurrencyNotSupported)
 }
 
 func TestValidateDate_Valid(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	dateStr := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
 	date, err := svc.(*rateServiceImpl).validateDate(dateStr)
 	assert.NoError(t, err)
 	assert.Equal(t, dateStr, date.Format("2006-01-02"))
 }
 
-func TestValidateDate_TooOld(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+func TestGetHistoricalRates_TooOld(t *testing.T) {
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	dateStr := time.Now().AddDate(0, 0, -100).Format("2006-01-02")
-	_, err := svc.(*rateServiceImpl).validateDate(dateStr)
+	_, err := svc.GetHistoricalRates(context.Background(), dateStr, dateStr, "USD", "INR")
 
 	var fiberErr *fiber.Error
 	if assert.Error(t, err) && assert.ErrorAs(t, err, &fiberErr) {
@@ -73,7 +119,7 @@ func TestValidateDate_TooOld(t *testing.T) {
 }
 
 func TestValidateDate_Future(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	dateStr := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
 	_, err := svc.(*rateServiceImpl).validateDate(dateStr)
 	assert.Error(t, err)
@@ -81,7 +127,7 @@ func TestValidateDate_Future(t *testing.T) {
 }
 
 func TestValidateDate_InvalidFormat(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	_, err := svc.(*rateServiceImpl).validateDate("2024-13-40")
 
 	var fiberErr *fiber.Error
@@ -92,7 +138,7 @@ func TestValidateDate_InvalidFormat(t *testing.T) {
 }
 
 func TestGetLatestRate_SameCurrency(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	rate, ts, err := svc.GetLatestRate(context.Background(), "USD", "USD")
 	assert.NoError(t, err)
 	assert.Equal(t, 1.0, rate)
@@ -101,7 +147,7 @@ func TestGetLatestRate_SameCurrency(t *testing.T) {
 
 func TestGetLatestRate_RepoError(t *testing.T) {
 	mockRepo := &MockRateRepository{LatestRatesErr: errors.New("repo error")}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, _, err := svc.GetLatestRate(context.Background(), "USD", "INR")
 	assert.Error(t, err)
 }
@@ -111,7 +157,7 @@ func TestGetLatestRate_RateNotFound(t *testing.T) {
 		LatestRatesResp: map[domain.Currency]float64{"EUR": 0.9},
 		LatestRatesTime: time.Now(),
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, _, err := svc.GetLatestRate(context.Background(), "USD", "INR")
 	assert.ErrorIs(t, err, ErrRateNotFound)
 }
@@ -121,7 +167,7 @@ func TestGetLatestRate_Success(t *testing.T) {
 		LatestRatesResp: map[domain.Currency]float64{"INR": 82.5},
 		LatestRatesTime: time.Now(),
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	rate, ts, err := svc.GetLatestRate(context.Background(), "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 82.5, rate)
@@ -129,8 +175,8 @@ func TestGetLatestRate_Success(t *testing.T) {
 }
 
 func TestConvert_SameCurrency(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
-	req := domain.ConversionRequest{From: "USD", To: "USD", Amount: 10}
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
+	req := domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "USD"}
 	_, err := svc.Convert(context.Background(), req)
 
 	var fiberErr *fiber.Error
@@ -143,40 +189,60 @@ func TestConvert_SameCurrency(t *testing.T) {
 func TestConvert_LatestRate_Success(t *testing.T) {
 	mockRepo := &MockRateRepository{
 		LatestRatesResp: map[domain.Currency]float64{"INR": 80.0},
-		LatestRatesTime: time.Now(),
+		LatestRatesTime: time.Unix(1744624800, 0),
 	}
-	svc := NewRateService(mockRepo, 90)
-	req := domain.ConversionRequest{From: "USD", To: "INR", Amount: 10}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+	req := domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR"}
 	res, err := svc.Convert(context.Background(), req)
 	assert.NoError(t, err)
-	assert.Equal(t, 800.0, res.ConvertedAmount)
+	assert.Equal(t, "800 INR", res.Converted.String())
 	assert.Equal(t, 80.0, res.Rate)
+	assert.Equal(t, int64(1744624800), res.Timestamp)
+	assert.Nil(t, res.Date)
+}
+
+func TestConvert_RoundsToMinorUnits(t *testing.T) {
+	mockRepo := &MockRateRepository{
+		LatestRatesResp: map[domain.Currency]float64{"INR": 85.23456, "JPY": 143.678},
+		LatestRatesTime: time.Now(),
+	}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+
+	res, err := svc.Convert(context.Background(), domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR"})
+	assert.NoError(t, err)
+	assert.Equal(t, "852.35 INR", res.Converted.String())
+	assert.Equal(t, 85.23456, res.Rate)
+
+	res, err = svc.Convert(context.Background(), domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "JPY"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1437 JPY", res.Converted.String())
 }
 
 func TestConvert_HistoricalRate_Success(t *testing.T) {
-	date := time.Now().AddDate(0, 0, -5).Truncate(24 * time.Hour)
+	date := time.Now().UTC().AddDate(0, 0, -5).Truncate(24 * time.Hour)
 	mockRepo := &MockRateRepository{
 		HistoricalRatesResp: map[time.Time]float64{date: 75.0},
 	}
-	svc := NewRateService(mockRepo, 90)
-	req := domain.ConversionRequest{From: "USD", To: "INR", Amount: 10, Date: &date}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+	req := domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR", Date: ptrDate(date)}
 	res, err := svc.Convert(context.Background(), req)
 	assert.NoError(t, err)
-	assert.Equal(t, 750.0, res.ConvertedAmount)
+	assert.Equal(t, "750 INR", res.Converted.String())
 	assert.Equal(t, 75.0, res.Rate)
+	assert.Zero(t, res.Timestamp)
 }
 
 func TestConvert_RepoError(t *testing.T) {
 	mockRepo := &MockRateRepository{LatestRatesErr: errors.New("repo error")}
-	svc := NewRateService(mockRepo, 90)
-	req := domain.ConversionRequest{From: "USD", To: "INR", Amount: 10}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+	req := domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR"}
 	_, err := svc.Convert(context.Background(), req)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "could not get rate for conversion")
 }
 
 func TestGetHistoricalRate_SameCurrency(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	rate, err := svc.GetHistoricalRate(context.Background(), time.Now(), "USD", "USD")
 	assert.NoError(t, err)
 	assert.Equal(t, 1.0, rate)
@@ -184,7 +250,7 @@ func TestGetHistoricalRate_SameCurrency(t *testing.T) {
 
 func TestGetHistoricalRate_RepoError(t *testing.T) {
 	mockRepo := &MockRateRepository{HistoricalRatesErr: errors.New("repo error")}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, err := svc.GetHistoricalRate(context.Background(), time.Now(), "USD", "INR")
 	assert.Error(t, err)
 }
@@ -194,7 +260,7 @@ func TestGetHistoricalRate_RateNotFound(t *testing.T) {
 	mockRepo := &MockRateRepository{
 		HistoricalRatesResp: map[time.Time]float64{},
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, err := svc.GetHistoricalRate(context.Background(), date, "USD", "INR")
 	assert.ErrorIs(t, err, ErrRateNotFound)
 }
@@ -204,7 +270,7 @@ func TestGetHistoricalRate_Success(t *testing.T) {
 	mockRepo := &MockRateRepository{
 		HistoricalRatesResp: map[time.Time]float64{date: 81.0},
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	rate, err := svc.GetHistoricalRate(context.Background(), date, "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, 81.0, rate)
@@ -212,7 +278,7 @@ func TestGetHistoricalRate_Success(t *testing.T) {
 
 func TestGetLatestRates_RepoError(t *testing.T) {
 	mockRepo := &MockRateRepository{LatestRatesErr: errors.New("repo error")}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, err := svc.GetLatestRates(context.Background(), "USD", "INR")
 	assert.Error(t, err)
 }
@@ -222,7 +288,7 @@ func TestGetLatestRates_Success(t *testing.T) {
 		LatestRatesResp: map[domain.Currency]float64{"INR": 79.0},
 		LatestRatesTime: time.Now(),
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	res, err := svc.GetLatestRates(context.Background(), "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, "USD", string(res.Base))
@@ -235,16 +301,16 @@ func TestGetHistoricalRates_Valid(t *testing.T) {
 	mockRepo := &MockRateRepository{
 		HistoricalRatesResp: map[time.Time]float64{date: 77.0},
 	}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	res, err := svc.GetHistoricalRates(context.Background(), date.Format("2006-01-02"), date.Format("2006-01-02"), "USD", "INR")
 	assert.NoError(t, err)
 	assert.Equal(t, "USD", string(res.Base))
-	assert.Equal(t, 77.0, res.Rates[date])
+	assert.Equal(t, 77.0, res.Rates[domain.DateOf(date)])
 	assert.Equal(t, "INR", string(res.Target))
 }
 
 func TestGetHistoricalRates_InvalidStartDate(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	_, err := svc.GetHistoricalRates(context.Background(), "invalid", "2024-05-01", "USD", "INR")
 
 	var fiberErr *fiber.Error
@@ -256,7 +322,7 @@ func TestGetHistoricalRates_InvalidStartDate(t *testing.T) {
 }
 
 func TestGetHistoricalRates_InvalidEndDate(t *testing.T) {
-	svc := NewRateService(&MockRateRepository{}, 90)
+	svc := NewRateService(&MockRateRepository{}, 90, discardLogger)
 	start := time.Now().Format("2006-01-02")
 	_, err := svc.GetHistoricalRates(context.Background(), start, "invalid", "USD", "INR")
 
@@ -271,7 +337,87 @@ func TestGetHistoricalRates_InvalidEndDate(t *testing.T) {
 func TestGetHistoricalRates_RepoError(t *testing.T) {
 	date := time.Now().Truncate(24 * time.Hour)
 	mockRepo := &MockRateRepository{HistoricalRatesErr: errors.New("repo error")}
-	svc := NewRateService(mockRepo, 90)
+	svc := NewRateService(mockRepo, 90, discardLogger)
 	_, err := svc.GetHistoricalRates(context.Background(), date.Format("2006-01-02"), date.Format("2006-01-02"), "USD", "INR")
 	assert.Error(t, err)
 }
+
+func TestGetLatestRates_Stale(t *testing.T) {
+	mockRepo := &staleRateRepository{MockRateRepository{
+		LatestRatesResp: map[domain.Currency]float64{"INR": 79.0},
+		LatestRatesTime: time.Now(),
+	}}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+	res, err := svc.GetLatestRates(context.Background(), "USD", "INR")
+	assert.NoError(t, err)
+	assert.True(t, res.Stale)
+
+	res, err = NewRateService(&mockRepo.MockRateRepository, 90, discardLogger).GetLatestRates(context.Background(), "USD", "INR")
+	assert.NoError(t, err)
+	assert.False(t, res.Stale)
+}
+
+func TestConvert_Stale(t *testing.T) {
+	date := time.Now().UTC().AddDate(0, 0, -5).Truncate(24 * time.Hour)
+	mockRepo := &staleRateRepository{MockRateRepository{
+		LatestRatesResp:     map[domain.Currency]float64{"INR": 80.0},
+		HistoricalRatesResp: map[time.Time]float64{date: 75.0},
+	}}
+	svc := NewRateService(mockRepo, 90, discardLogger)
+
+	res, err := svc.Convert(context.Background(), domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR"})
+	assert.NoError(t, err)
+	assert.True(t, res.Stale)
+
+	// Historical conversions never depend on the latest rates refresh.
+	res, err = svc.Convert(context.Background(), domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "USD"), To: "INR", Date: ptrDate(date)})
+	assert.NoError(t, err)
+	assert.False(t, res.Stale)
+}
+
+func TestGetHistoricalRates_OlderThanLimitFromArchive(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	oldestLive := today.AddDate(0, 0, -90)
+	repo := &archivedRateRepository{
+		MockRateRepository: MockRateRepository{HistoricalRatesResp: map[time.Time]float64{oldestLive: 86.0}},
+		archived: map[time.Time]float64{
+			today.AddDate(-2, 0, 0):      75.0,
+			oldestLive.AddDate(0, 0, -1): 85.0,
+		},
+	}
+	svc := NewRateService(repo, 90, discardLogger)
+
+	rates, err := svc.GetHistoricalRates(context.Background(), today.AddDate(-2, 0, 0).Format("2006-01-02"), oldestLive.Format("2006-01-02"), "USD", "INR")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.CustomDate]float64{
+		domain.DateOf(today.AddDate(-2, 0, 0)):      75.0,
+		domain.DateOf(oldestLive.AddDate(0, 0, -1)): 85.0,
+		domain.DateOf(oldestLive):                   86.0,
+	}, rates.Rates)
+	// Only the part within the limit goes to the cache and provider.
+	assert.Equal(t, [][2]time.Time{{oldestLive, oldestLive}}, repo.liveRanges)
+}
+
+func TestRetiredCurrency(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	lastKuna := time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC)
+	repo := &archivedRateRepository{archived: map[time.Time]float64{lastKuna: 0.1327}}
+	svc := NewRateService(repo, 90, discardLogger)
+	var notInUse *domain.CurrencyNotInUseError
+
+	_, err := svc.GetLatestRates(context.Background(), "HRK", "EUR")
+	assert.ErrorAs(t, err, &notInUse)
+	assert.Equal(t, &domain.CurrencyNotInUseError{Currency: "HRK", Retired: true, Date: domain.DateOf(lastKuna)}, notInUse)
+	_, err = svc.Convert(context.Background(), domain.ConversionRequest{Amount: domain.NewMoneyFromFloat(10, "EUR"), To: "HRK"})
+	assert.ErrorAs(t, err, &notInUse)
+
+	rates, err := svc.GetHistoricalRates(context.Background(), "2022-12-31", "2022-12-31", "HRK", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.CustomDate]float64{domain.DateOf(lastKuna): 0.1327}, rates.Rates)
+
+	_, err = svc.GetHistoricalRates(context.Background(), "2022-12-31", today.Format("2006-01-02"), "HRK", "EUR")
+	assert.ErrorAs(t, err, &notInUse)
+	_, err = svc.GetHistoricalRate(context.Background(), time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), "USD", "SLE")
+	assert.EqualError(t, err, "SLE was introduced on 2022-07-01; its rates can only be looked up from then on")
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// APIKey is an API key as the admin API describes it.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	Prefix    string     `json:"prefix"` // first characters of the secret, to tell keys apart
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	Endpoints []string   `json:"endpoints,omitempty"`
+	Pairs     []string   `json:"pairs,omitempty"`
+	// Secret and SigningSecret are only set on keys just created or rotated; the service can't
+	// give them out again. SigningSecret signs requests, when the service accepts signed ones.
+	Secret        string `json:"secret,omitempty"`
+	SigningSecret string `json:"signingSecret,omitempty"`
+}
+
+// NewKey describes an API key to create. Only Name is required.
+type NewKey struct {
+	ID        string     `json:"id,omitempty"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role,omitempty"` // reader or admin
+	Scopes    []string   `json:"scopes,omitempty"`
+	Endpoints []string   `json:"endpoints,omitempty"` // e.g. latest, convert
+	Pairs     []string   `json:"pairs,omitempty"`     // e.g. USD/INR, EUR/*
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Keys lists every API key, revoked and expired ones included.
+func (c *Client) Keys(ctx context.Context) ([]APIKey, error) {
+	var resp struct {
+		Keys []APIKey `json:"keys"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/keys", admin: true, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// CreateKey issues an API key. The returned key carries its secret.
+func (c *Client) CreateKey(ctx context.Context, key NewKey) (*APIKey, error) {
+	var created APIKey
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/keys", body: key, admin: true}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// RotateKey issues a new secret for the key with id. The old secret keeps working for grace, at
+// most 168h; with 0 it stops working at once. The returned key carries the new secret.
+func (c *Client) RotateKey(ctx context.Context, id string, grace time.Duration) (*APIKey, error) {
+	var body any
+	if grace > 0 {
+		body = map[string]string{"gracePeriod": grace.String()}
+	}
+	var rotated APIKey
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/keys/" + id + "/rotate", body: body, admin: true}, &rotated); err != nil {
+		return nil, err
+	}
+	return &rotated, nil
+}
+
+// RevokeKey disables the key with id for good.
+func (c *Client) RevokeKey(ctx context.Context, id string) (*APIKey, error) {
+	var revoked APIKey
+	if err := c.do(ctx, request{method: http.MethodDelete, path: "/admin/keys/" + id, admin: true}, &revoked); err != nil {
+		return nil, err
+	}
+	return &revoked, nil
+}
+
+// PauseState says whether background refreshes are paused on every replica.
+type PauseState struct {
+	Paused bool       `json:"paused"`
+	Reason string     `json:"reason,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+// BaseStatus counts the refresh outcomes of one base since the replica started.
+type BaseStatus struct {
+	Successes   int        `json:"successes"`
+	Failures    int        `json:"failures"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// RefreshLoop describes one background refresh loop of a replica.
+type RefreshLoop struct {
+	Name           string                `json:"name"`
+	Interval       string                `json:"interval"`
+	LastRunAt      *time.Time            `json:"lastRunAt,omitempty"`
+	LastRunID      string                `json:"lastRunId,omitempty"`
+	LastDurationMs int64                 `json:"lastDurationMs"`
+	NextRunAt      *time.Time            `json:"nextRunAt,omitempty"`
+	HoldsLock      bool                  `json:"holdsLock"`
+	Bases          map[string]BaseStatus `json:"bases"`
+}
+
+// SchedulerStatus is what the background refreshes of the replica that answered are doing.
+type SchedulerStatus struct {
+	Loops []RefreshLoop `json:"loops"`
+	Pause *PauseState   `json:"pause,omitempty"` // nil when the replica couldn't read it
+}
+
+func (c *Client) SchedulerStatus(ctx context.Context) (*SchedulerStatus, error) {
+	var status SchedulerStatus
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/scheduler", admin: true, idempotent: true}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// PauseScheduler pauses background refreshes on every replica, recording reason, if any.
+func (c *Client) PauseScheduler(ctx context.Context, reason string) (*PauseState, error) {
+	var state PauseState
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/scheduler/pause", body: map[string]string{"reason": reason}, admin: true}, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (c *Client) ResumeScheduler(ctx context.Context) (*PauseState, error) {
+	var state PauseState
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/scheduler/resume", admin: true}, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ProviderStatus is the rolling call statistics of one rate provider.
+type ProviderStatus struct {
+	Name               string     `json:"name"`
+	LastSuccess        *time.Time `json:"lastSuccess,omitempty"`
+	LastError          string     `json:"lastError,omitempty"`
+	LastErrorAt        *time.Time `json:"lastErrorAt,omitempty"`
+	Calls              int        `json:"calls"`
+	ErrorRate          float64    `json:"errorRate"`
+	SuccessRate        float64    `json:"successRate"`
+	AvgLatencyMs       float64    `json:"avgLatencyMs"`
+	P95LatencyMs       float64    `json:"p95LatencyMs"`
+	Deprioritized      bool       `json:"deprioritized"`
+	DeprioritizedUntil *time.Time `json:"deprioritizedUntil,omitempty"`
+}
+
+func (c *Client) Providers(ctx context.Context) ([]ProviderStatus, error) {
+	var resp struct {
+		Providers []ProviderStatus `json:"providers"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/providers", admin: true, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Providers, nil
+}
+
+// ImportResult summarizes an import of historical rates.
+type ImportResult struct {
+	Rows int    `json:"rows"`
+	Days int    `json:"days"` // distinct days and bases
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`
+}
+
+// ImportCSV loads rows of date,base,target,rate, with an optional header row, into the
+// service's rate archive. Nothing is imported unless every row is valid. Importing the same
+// rows again is harmless, so failed imports are retried.
+func (c *Client) ImportCSV(ctx context.Context, csv []byte) (*ImportResult, error) {
+	var result ImportResult
+	req := request{method: http.MethodPost, path: "/admin/import", rawBody: csv, contentType: "text/csv", admin: true, idempotent: true}
+	if err := c.do(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BackfillResult summarizes a backfill of historical rates from the provider.
+type BackfillResult struct {
+	Base string `json:"base"`
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`
+	Days int    `json:"days"` // days the provider had rates for
+}
+
+// Backfill has the service fetch the rates of base against every supported currency from its
+// provider for the days from from to to, at most a year apart, and save them to its rate
+// archive. Backfilling the same days again is harmless, so failed backfills are retried.
+func (c *Client) Backfill(ctx context.Context, base string, from, to time.Time) (*BackfillResult, error) {
+	var result BackfillResult
+	body := map[string]string{"base": base, "from": from.Format("2006-01-02"), "to": to.Format("2006-01-02")}
+	req := request{method: http.MethodPost, path: "/admin/backfill", body: body, admin: true, idempotent: true}
+	if err := c.do(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RestoreResult summarizes a restore of archived rates from their snapshots.
+type RestoreResult struct {
+	Days int    `json:"days"` // days a snapshot was found for
+	Rows int    `json:"rows"`
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`
+}
+
+// Restore has the service load the snapshots of the days from from to to, at most 366 of
+// them, from its snapshot bucket back into its rate archive. Restoring the same days again
+// is harmless, so failed restores are retried.
+func (c *Client) Restore(ctx context.Context, from, to time.Time) (*RestoreResult, error) {
+	var result RestoreResult
+	body := map[string]string{"from": from.Format("2006-01-02"), "to": to.Format("2006-01-02")}
+	req := request{method: http.MethodPost, path: "/admin/restore", body: body, admin: true, idempotent: true}
+	if err := c.do(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExportRequest selects the archived rates to export. Zero fields select everything.
+type ExportRequest struct {
+	Format   string // "csv", the default, or "parquet"
+	Base     string
+	Target   string // both or neither of Base and Target
+	From, To *time.Time
+}
+
+// Export writes the service's archived rates to w as a CSV file, which ImportCSV takes, or a
+// Parquet file. As part of the file may already be written when it fails, it is not retried.
+func (c *Client) Export(ctx context.Context, req ExportRequest, w io.Writer) error {
+	query := url.Values{}
+	if req.Format != "" {
+		query.Set("format", req.Format)
+	}
+	if req.Base != "" || req.Target != "" {
+		query.Set("pair", req.Base+"-"+req.Target)
+	}
+	if req.From != nil {
+		query.Set("from", req.From.Format("2006-01-02"))
+	}
+	if req.To != nil {
+		query.Set("to", req.To.Format("2006-01-02"))
+	}
+	return c.do(ctx, request{method: http.MethodGet, path: "/admin/export", query: query, download: w, accept: "*/*", admin: true}, nil)
+}
@@ -0,0 +1,240 @@
+// Package client is a Go client for the exchange rate service's REST API.
+//
+//	c, err := client.New("https://rates.internal", client.WithAPIKey(os.Getenv("RATES_API_KEY")))
+//	if err != nil {
+//		return err
+//	}
+//	latest, err := c.Latest(ctx, "USD", "INR")
+//
+// Requests that failed on the network or with 429, 502, 503 or 504 are retried with exponential
+// backoff, as long as they are safe to repeat. Error responses come back as *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyHeader   = "X-API-Key"
+	adminKeyHeader = "X-Admin-Key"
+)
+
+// Client calls one service. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	adminKey   string
+	httpClient *http.Client
+	userAgent  string
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with an API key issued under /admin/keys. Admin methods
+// need one with the admin role, unless WithAdminKey is used.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithAdminKey sends the service's ADMIN_API_KEY with admin methods.
+func WithAdminKey(key string) Option {
+	return func(c *Client) { c.adminKey = key }
+}
+
+// WithHTTPClient replaces the default http.Client, which times requests out after 10 seconds.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets how often a failed request is retried, 2 by default, and the delay before the
+// first retry, 200ms by default, which doubles with every further one. 0 retries disables them.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// WithUserAgent replaces the User-Agent requests are sent with.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client of the service at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q, expected e.g. http://localhost:8080", baseURL)
+	}
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		userAgent:  "currency-exchange-go-client",
+		retries:    2,
+		backoff:    200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Error is an error response of the service.
+type Error struct {
+	StatusCode int
+	Code       string // e.g. UNSUPPORTED_CURRENCY, or the status text when the service gave none
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+// retryable reports whether a request answered with status may succeed when sent again.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// request is one call to the service.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	// rawBody is sent as is, with contentType, instead of body as JSON.
+	rawBody     []byte
+	contentType string
+	// download receives the body of a successful response, as it arrives, instead of it being
+	// decoded as JSON; accept is then the Accept header.
+	download io.Writer
+	accept   string
+	admin    bool // authenticate with the admin key, when there is one
+	// idempotent requests are retried. Admin operations that change state are not, as a lost
+	// response doesn't mean they didn't happen.
+	idempotent bool
+}
+
+// do sends req, retrying it when that is safe, and decodes the response into out, unless nil.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	body := req.rawBody
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return err
+		}
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		data, retryAfter, err := c.send(ctx, req, body)
+		if err == nil {
+			if out == nil {
+				return nil
+			}
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("invalid response from %s: %w", req.path, err)
+			}
+			return nil
+		}
+		if attempt >= c.retries || !req.idempotent || ctx.Err() != nil {
+			return err
+		}
+		var apiErr *Error
+		if errors.As(err, &apiErr) && !retryable(apiErr.StatusCode) {
+			return err
+		}
+
+		delay := max(backoff, retryAfter)
+		if c.maxBackoff > 0 {
+			delay = min(delay, c.maxBackoff)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// send makes one attempt at req. It also returns how long a 429 or 503 asked to wait.
+func (c *Client) send(ctx context.Context, req request, body []byte) ([]byte, time.Duration, error) {
+	target := c.baseURL.JoinPath(req.path)
+	target.RawQuery = req.query.Encode()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target.String(), reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	} else if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.accept != "" {
+		httpReq.Header.Set("Accept", req.accept)
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		httpReq.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	if req.admin && c.adminKey != "" {
+		httpReq.Header.Set(adminKeyHeader, c.adminKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if req.download != nil && resp.StatusCode < 300 {
+		_, err := io.Copy(req.download, resp.Body)
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode < 300 {
+		return data, 0, nil
+	}
+
+	apiErr := &Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode), Message: strings.TrimSpace(string(data))}
+	var decoded struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &decoded) == nil && decoded.Error.Message != "" {
+		apiErr.Code, apiErr.Message = decoded.Error.Code, decoded.Error.Message
+	}
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return nil, retryAfter, apiErr
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, append([]Option{WithRetries(2, time.Millisecond)}, opts...)...)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestLatest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/latest", r.URL.Path)
+		assert.Equal(t, "base=USD&symbol=INR", r.URL.RawQuery)
+		assert.Equal(t, "cx_test", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"base":"USD","rates":{"INR":85.2},"timestamp":1744624800}`))
+	}, WithAPIKey("cx_test"))
+
+	latest, err := c.Latest(context.Background(), "USD", "INR")
+	assert.NoError(t, err)
+	assert.Equal(t, &LatestRates{Base: "USD", Rates: map[string]float64{"INR": 85.2}, Timestamp: 1744624800}, latest)
+	assert.Equal(t, time.Unix(1744624800, 0), latest.Time())
+}
+
+func TestHistorical(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2025-01-01", r.URL.Query().Get("startDate"))
+		assert.Equal(t, "2025-01-02", r.URL.Query().Get("endDate"))
+		w.Write([]byte(`{"base":"USD","target":"INR","amount":1,"rates":{"2025-01-01":85.6,"2025-01-02":85.7}}`))
+	})
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := c.Historical(context.Background(), "USD", "INR", start, start.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []DatedRate{{Date: start, Rate: 85.6}, {Date: start.AddDate(0, 0, 1), Rate: 85.7}}, rates.Rates)
+}
+
+func TestConvert(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2025-04-14", r.URL.Query().Get("date"))
+		w.Write([]byte(`{"from":"USD","to":"INR","amount":100,"convertedAmount":8599.5,"rate":85.995,"onDate":"2025-04-14"}`))
+	})
+
+	onDate := time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)
+	conversion, err := c.Convert(context.Background(), ConvertRequest{From: "USD", To: "INR", Amount: 100, Date: &onDate})
+	assert.NoError(t, err)
+	assert.Equal(t, &Conversion{From: "USD", To: "INR", Amount: 100, ConvertedAmount: 8599.5, Rate: 85.995, Date: &onDate}, conversion)
+}
+
+func TestCurrenciesByCountry(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/currencies/by-country", r.URL.Path)
+		assert.Equal(t, "code=IN", r.URL.RawQuery)
+		w.Write([]byte(`{"country":"IN","name":"India","default":"INR","currencies":[{"code":"INR","numericCode":"356","name":"Indian Rupee","minorUnits":2,"symbol":"₹","kind":"FIAT","supported":true}]}`))
+	})
+
+	currencies, err := c.CurrenciesByCountry(context.Background(), "IN")
+	assert.NoError(t, err)
+	assert.Equal(t, &CountryCurrencies{Country: "IN", Name: "India", Default: "INR", Currencies: []CountryCurrency{{
+		Currency:  Currency{Code: "INR", Kind: "FIAT", NumericCode: "356", Name: "Indian Rupee", MinorUnits: 2, Symbol: "₹"},
+		Supported: true,
+	}}}, currencies)
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"from":"USD","to":"INR","amount":100,"convertedAmount":8520,"rate":85.2}`))
+	})
+
+	conversion, err := c.Convert(context.Background(), ConvertRequest{From: "USD", To: "INR", Amount: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 8520.0, conversion.ConvertedAmount)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestErrors(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/v1/convert":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"UNSUPPORTED_CURRENCY","message":"currency XYZ is not supported"}}`))
+		default:
+			assert.Equal(t, "secret", r.Header.Get("X-Admin-Key"))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}, WithAdminKey("secret"))
+
+	// Client errors aren't retried.
+	_, err := c.Convert(context.Background(), ConvertRequest{From: "USD", To: "XYZ", Amount: 1})
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, &Error{StatusCode: http.StatusBadRequest, Code: "UNSUPPORTED_CURRENCY", Message: "currency XYZ is not supported"}, apiErr)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Nor are admin operations that change state.
+	_, err = c.PauseScheduler(context.Background(), "maintenance")
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestImportCSV(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/import", r.URL.Path)
+		assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+		assert.Equal(t, "admin", r.Header.Get("X-Admin-Key"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "2001-01-02,USD,INR,46.7\n", string(body))
+		w.Write([]byte(`{"rows":1,"days":1,"from":"2001-01-02","to":"2001-01-02"}`))
+	}, WithAdminKey("admin"))
+
+	result, err := c.ImportCSV(context.Background(), []byte("2001-01-02,USD,INR,46.7\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &ImportResult{Rows: 1, Days: 1, From: "2001-01-02", To: "2001-01-02"}, result)
+}
+
+func TestBackfill(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /admin/backfill", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "admin", r.Header.Get("X-Admin-Key"))
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"base": "USD", "from": "2015-01-01", "to": "2015-03-31"}, body)
+		w.Write([]byte(`{"base":"USD","from":"2015-01-01","to":"2015-03-31","days":63}`))
+	}, WithAdminKey("admin"))
+
+	from, to := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, 3, 31, 0, 0, 0, 0, time.UTC)
+	result, err := c.Backfill(context.Background(), "USD", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, &BackfillResult{Base: "USD", From: "2015-01-01", To: "2015-03-31", Days: 63}, result)
+}
+
+func TestRestore(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /admin/restore", r.Method+" "+r.URL.Path)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"from": "2025-01-01", "to": "2025-03-31"}, body)
+		w.Write([]byte(`{"days":63,"rows":1260,"from":"2025-01-01","to":"2025-03-31"}`))
+	}, WithAdminKey("admin"))
+
+	from, to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	result, err := c.Restore(context.Background(), from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, &RestoreResult{Days: 63, Rows: 1260, From: "2025-01-01", To: "2025-03-31"}, result)
+}
+
+func TestExport(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/export", r.URL.Path)
+		assert.Equal(t, "format=parquet&from=2001-01-01&pair=USD-INR", r.URL.RawQuery)
+		w.Write([]byte("PAR1..."))
+	}, WithAdminKey("admin"))
+
+	from := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+	err := c.Export(context.Background(), ExportRequest{Format: "parquet", Base: "USD", Target: "INR", From: &from}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "PAR1...", out.String())
+}
+
+func TestNew_RejectsRelativeURLs(t *testing.T) {
+	_, err := New("localhost:8080")
+	assert.Error(t, err)
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// dateLayout is how the service writes and reads dates.
+const dateLayout = "2006-01-02"
+
+// LatestRates are a base's latest rates, as served by GET /v1/latest.
+type LatestRates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	Timestamp int64              `json:"timestamp"` // Unix time the rates were published at
+	Stale     bool               `json:"stale,omitempty"`
+}
+
+// Time returns Timestamp as a time.Time.
+func (r LatestRates) Time() time.Time {
+	return time.Unix(r.Timestamp, 0)
+}
+
+// Latest returns the latest rate of base against target.
+func (c *Client) Latest(ctx context.Context, base, target string) (*LatestRates, error) {
+	var rates LatestRates
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/v1/latest",
+		query:      url.Values{"base": {base}, "symbol": {target}},
+		idempotent: true,
+	}, &rates)
+	if err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
+
+// ConvertRequest is an amount to convert. Without a Date it is converted at the latest rate.
+type ConvertRequest struct {
+	From   string
+	To     string
+	Amount float64
+	Date   *time.Time
+}
+
+// Conversion is the result of GET /v1/convert.
+type Conversion struct {
+	From            string     `json:"from"`
+	To              string     `json:"to"`
+	Amount          float64    `json:"amount"`
+	ConvertedAmount float64    `json:"convertedAmount"`
+	Rate            float64    `json:"rate"`
+	Date            *time.Time `json:"onDate,omitempty"`    // set for conversions at a past date's rate
+	Timestamp       int64      `json:"timestamp,omitempty"` // Unix time the rate was published at, set for conversions at the latest rate
+	Stale           bool       `json:"stale,omitempty"`
+}
+
+func (c *Conversion) UnmarshalJSON(data []byte) error {
+	type conversion Conversion
+	var wire struct {
+		*conversion
+		Date string `json:"onDate"`
+	}
+	wire.conversion = (*conversion)(c)
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	c.Date = nil
+	if wire.Date != "" {
+		date, err := parseDate(wire.Date)
+		if err != nil {
+			return err
+		}
+		c.Date = &date
+	}
+	return nil
+}
+
+// Convert converts req.Amount from req.From to req.To.
+func (c *Client) Convert(ctx context.Context, req ConvertRequest) (*Conversion, error) {
+	query := url.Values{
+		"from":   {req.From},
+		"to":     {req.To},
+		"amount": {strconv.FormatFloat(req.Amount, 'f', -1, 64)},
+	}
+	if req.Date != nil {
+		query.Set("date", req.Date.Format(dateLayout))
+	}
+	var conversion Conversion
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/convert", query: query, idempotent: true}, &conversion); err != nil {
+		return nil, err
+	}
+	return &conversion, nil
+}
+
+// DatedRate is a pair's rate on one day.
+type DatedRate struct {
+	Date time.Time `json:"date"`
+	Rate float64   `json:"rate"`
+}
+
+// HistoricalRates are a pair's daily rates over a date range, as served by GET /v1/historical.
+type HistoricalRates struct {
+	Base   string
+	Target string
+	Rates  []DatedRate // oldest first
+}
+
+func (r *HistoricalRates) UnmarshalJSON(data []byte) error {
+	var wire struct {
+		Base   string             `json:"base"`
+		Target string             `json:"target"`
+		Rates  map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	r.Base, r.Target, r.Rates = wire.Base, wire.Target, make([]DatedRate, 0, len(wire.Rates))
+	for key, rate := range wire.Rates {
+		date, err := parseDate(key)
+		if err != nil {
+			return err
+		}
+		r.Rates = append(r.Rates, DatedRate{Date: date, Rate: rate})
+	}
+	sort.Slice(r.Rates, func(i, j int) bool { return r.Rates[i].Date.Before(r.Rates[j].Date) })
+	return nil
+}
+
+// parseDate reads a date the service wrote, as either a date or, from older versions, a full
+// timestamp.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+func (r HistoricalRates) MarshalJSON() ([]byte, error) {
+	rates := make(map[string]float64, len(r.Rates))
+	for _, rate := range r.Rates {
+		rates[rate.Date.Format(dateLayout)] = rate.Rate
+	}
+	return json.Marshal(struct {
+		Base   string             `json:"base"`
+		Target string             `json:"target"`
+		Rates  map[string]float64 `json:"rates"`
+	}{r.Base, r.Target, rates})
+}
+
+// Historical returns the daily rates of base against target from start to end, both included.
+func (c *Client) Historical(ctx context.Context, base, target string, start, end time.Time) (*HistoricalRates, error) {
+	query := url.Values{
+		"base":      {base},
+		"symbol":    {target},
+		"startDate": {start.Format(dateLayout)},
+		"endDate":   {end.Format(dateLayout)},
+	}
+	var rates HistoricalRates
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/historical", query: query, idempotent: true}, &rates); err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
+
+// Currency is a currency the service handles, with its ISO 4217 metadata.
+type Currency struct {
+	Code        string `json:"code"`
+	Kind        string `json:"kind"`                  // FIAT, CRYPTO or METAL
+	NumericCode string `json:"numericCode,omitempty"` // ISO 4217 numeric code; empty for crypto assets
+	Name        string `json:"name"`
+	MinorUnits  int    `json:"minorUnits"` // digits after the decimal point, -1 for metals
+	Symbol      string `json:"symbol,omitempty"`
+	ValidFrom   string `json:"validFrom,omitempty"`  // YYYY-MM-DD the currency was introduced, if recently
+	ValidUntil  string `json:"validUntil,omitempty"` // YYYY-MM-DD of the last day a retired currency was in use
+}
+
+// Currencies lists the supported currencies, ordered by code.
+func (c *Client) Currencies(ctx context.Context) ([]Currency, error) {
+	var resp struct {
+		Currencies []Currency `json:"currencies"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/currencies", idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Currencies, nil
+}
+
+// CountryCurrency is a currency used in a country.
+type CountryCurrency struct {
+	Currency
+	Supported bool `json:"supported"`
+}
+
+// CountryCurrencies are the currencies used in a country, as served by
+// GET /v1/currencies/by-country.
+type CountryCurrencies struct {
+	Country    string            `json:"country"` // ISO 3166-1 alpha-2
+	Name       string            `json:"name"`
+	Default    string            `json:"default"` // the first supported currency; empty if none is
+	Currencies []CountryCurrency `json:"currencies"`
+}
+
+// CurrenciesByCountry lists the currencies used in the country with the ISO 3166-1 alpha-2
+// code, the one most used first.
+func (c *Client) CurrenciesByCountry(ctx context.Context, country string) (*CountryCurrencies, error) {
+	var currencies CountryCurrencies
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/currencies/by-country", query: url.Values{"code": {country}}, idempotent: true}, &currencies)
+	if err != nil {
+		return nil, err
+	}
+	return &currencies, nil
+}
+
+// Usage is how much of its monthly quota an API key used, as served by GET /v1/account/usage.
+type Usage struct {
+	KeyID     string    `json:"keyId"`
+	Month     string    `json:"month"`
+	Used      int64     `json:"used"`
+	Quota     *int64    `json:"quota"`     // nil for keys without a quota
+	Remaining *int64    `json:"remaining"` // nil for keys without a quota
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// Usage returns the quota usage of the client's API key.
+func (c *Client) Usage(ctx context.Context) (*Usage, error) {
+	var usage Usage
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/account/usage", idempotent: true}, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}